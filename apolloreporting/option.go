@@ -0,0 +1,83 @@
+package apolloreporting
+
+import (
+	"net/http"
+	"time"
+)
+
+const defaultEndpoint = "https://usage-reporting.api.apollographql.com/api/ingress/traces"
+
+type config struct {
+	endpoint       string
+	client         *http.Client
+	flushInterval  time.Duration
+	maxBatch       int
+	serviceVersion string
+	errorHandler   func(err error)
+}
+
+// Option is anything that can configure Reporter.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		endpoint:      defaultEndpoint,
+		client:        http.DefaultClient,
+		flushInterval: 20 * time.Second,
+		maxBatch:      1000,
+		errorHandler:  func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithEndpoint sends reports to url instead of Apollo's ingress.
+func WithEndpoint(url string) Option {
+	return func(cfg *config) {
+		cfg.endpoint = url
+	}
+}
+
+// WithHTTPClient sends reports with client instead of http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(cfg *config) {
+		cfg.client = client
+	}
+}
+
+// WithFlushInterval sets how often buffered traces are sent. Defaults to 20
+// seconds.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(cfg *config) {
+		cfg.flushInterval = interval
+	}
+}
+
+// WithMaxBatch sends the buffered traces as soon as there are n of them,
+// without waiting for the flush interval, 1000 by default. A value of 0 or
+// less only sends them at the flush interval.
+func WithMaxBatch(n int) Option {
+	return func(cfg *config) {
+		cfg.maxBatch = n
+	}
+}
+
+// WithServiceVersion reports the version of the server, shown by Apollo
+// Studio next to each trace.
+func WithServiceVersion(version string) Option {
+	return func(cfg *config) {
+		cfg.serviceVersion = version
+	}
+}
+
+// WithErrorHandler is called when a background flush fails. The traces of a
+// failed flush are dropped.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
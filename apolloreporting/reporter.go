@@ -0,0 +1,257 @@
+// Package apolloreporting ships traces of gqlgen operations to Apollo
+// Studio / GraphOS usage reporting.
+package apolloreporting
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/apollotrace"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Reporter is a gqlgen handler extension recording a trace with field
+// timings, errors and client headers for every operation, and sending them
+// to Apollo in batches.
+type Reporter struct {
+	apiKey string
+	cfg    config
+	header apollotrace.ReportHeader
+
+	mu     sync.Mutex
+	traces map[string][][]byte
+	count  int
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = (*Reporter)(nil)
+
+type recorderKey struct{}
+
+// New returns a Reporter sending traces for graphRef (for example
+// "my-graph@production") authenticated with apiKey. Call Close on shutdown
+// to send the remaining traces.
+func New(apiKey, graphRef string, opts ...Option) *Reporter {
+	cfg := newConfig(opts...)
+	hostname, _ := os.Hostname()
+
+	r := &Reporter{
+		apiKey: apiKey,
+		cfg:    *cfg,
+		header: apollotrace.ReportHeader{
+			GraphRef:       graphRef,
+			Hostname:       hostname,
+			AgentVersion:   "gqlgen-contrib apolloreporting",
+			ServiceVersion: cfg.serviceVersion,
+			RuntimeVersion: runtime.Version(),
+			Uname:          runtime.GOOS + ", " + runtime.GOARCH,
+		},
+		traces: map[string][][]byte{},
+		flush:  make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go r.loop()
+
+	return r
+}
+
+func (r *Reporter) ExtensionName() string {
+	return "ApolloUsageReporting"
+}
+
+func (r *Reporter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (r *Reporter) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	return next(context.WithValue(ctx, recorderKey{}, apollotrace.NewRecorder(oc.Stats.OperationStart)))
+}
+
+func (r *Reporter) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	rec, ok := ctx.Value(recorderKey{}).(*apollotrace.Recorder)
+	if !ok {
+		return next(ctx)
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	rec.StartField(fc)
+	res, err := next(ctx)
+	rec.EndField(fc)
+
+	return res, err
+}
+
+func (r *Reporter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	rec, ok := ctx.Value(recorderKey{}).(*apollotrace.Recorder)
+	if !ok {
+		// The operation failed before execution.
+		rec = apollotrace.NewRecorder(oc.Stats.OperationStart)
+	}
+	rec.AddErrors(res.Errors)
+
+	client := clientinfo.ForContext(ctx)
+	trace := apollotrace.Trace{
+		StartTime:     oc.Stats.OperationStart,
+		EndTime:       time.Now(),
+		Root:          rec.Root(),
+		OperationName: oc.OperationName,
+		ClientName:    client.Name,
+		ClientVersion: client.Version,
+	}
+	r.add(statsReportKey(oc, res.Errors), trace.Marshal())
+
+	return res
+}
+
+func (r *Reporter) add(key string, trace []byte) {
+	r.mu.Lock()
+	r.traces[key] = append(r.traces[key], trace)
+	r.count++
+	full := r.cfg.maxBatch > 0 && r.count >= r.cfg.maxBatch
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (r *Reporter) loop() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.cfg.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		case <-r.flush:
+		}
+		if err := r.Flush(context.Background()); err != nil {
+			r.cfg.errorHandler(err)
+		}
+	}
+}
+
+// Flush sends the buffered traces now.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	traces, count := r.traces, r.count
+	r.traces, r.count = map[string][][]byte{}, 0
+	r.mu.Unlock()
+
+	if count == 0 {
+		return nil
+	}
+
+	report := apollotrace.Report{
+		Header:         r.header,
+		EndTime:        time.Now(),
+		TracesPerKey:   traces,
+		OperationCount: count,
+	}
+
+	return r.send(ctx, report.Marshal())
+}
+
+// Close stops the periodic flush and sends the remaining traces.
+func (r *Reporter) Close(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+
+	return r.Flush(ctx)
+}
+
+func (r *Reporter) send(ctx context.Context, report []byte) error {
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if _, err := gz.Write(report); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Api-Key", r.apiKey)
+	req.Header.Set("Content-Type", "application/protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", r.header.AgentVersion)
+
+	resp, err := r.cfg.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("apolloreporting: could not send report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("apolloreporting: report rejected with status %s: %s", resp.Status, msg)
+	}
+
+	return nil
+}
+
+// statsReportKey returns the key Apollo groups traces by: the operation name
+// followed by its usage reporting signature. Like Apollo's, the signature
+// hides literals, which may hold personal data, drops aliases and unused
+// fragments and sorts selections and arguments, see normalize.
+func statsReportKey(oc *graphql.OperationContext, errs gqlerror.List) string {
+	if oc.Doc == nil || oc.Operation == nil {
+		for _, err := range errs {
+			if err.Extensions["code"] == errcode.ParseFailed {
+				return "## GraphQLParseFailure\n"
+			}
+		}
+		return "## GraphQLValidationFailure\n"
+	}
+
+	name := oc.Operation.Name
+	if name == "" {
+		name = "-"
+	}
+
+	signature, err := normalize.Document(oc.Doc, oc.OperationName, normalize.DropAliases())
+	if err != nil {
+		return "## GraphQLUnknownOperationName\n"
+	}
+
+	return "# " + name + "\n" + signature
+}
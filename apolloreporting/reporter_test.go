@@ -0,0 +1,193 @@
+package apolloreporting_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/apolloreporting"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestReporter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports [][]byte
+	)
+	ingress := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "service:key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "application/protobuf", r.Header.Get("Content-Type"))
+
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+
+		mu.Lock()
+		reports = append(reports, body)
+		mu.Unlock()
+	}))
+	defer ingress.Close()
+
+	reporter := apolloreporting.New("service:key", "todo@current",
+		apolloreporting.WithEndpoint(ingress.URL),
+		apolloreporting.WithFlushInterval(time.Hour),
+		apolloreporting.WithServiceVersion("1.2.3"),
+	)
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(reporter)
+
+	doRequest(srv, `{"query":"query Todos { todos { id } }"}`)
+	doRequest(srv, `{"query":"query Todos { todos { id } }"}`)
+	doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+	doRequest(srv, `{"operationName":"Item","query":"query Item { item: todo(id: \"alice@example.com\") { ...T } } query Other { todos { ...U } } fragment T on Todo { id } fragment U on Todo { text }"}`)
+	doRequest(srv, `{"query":"{ todos {"}`)
+
+	require.NoError(t, reporter.Close(context.Background()))
+	require.Len(t, reports, 1)
+
+	report := parse(t, reports[0])
+	header := parse(t, report.bytes(1))
+	assert.Equal(t, "todo@current", string(header.bytes(12)))
+	assert.Equal(t, "1.2.3", string(header.bytes(7)))
+	assert.Equal(t, uint64(5), report.varint(6))
+
+	tracesPerKey := map[string][][]byte{}
+	for _, entry := range report[5] {
+		e := parse(t, entry.b)
+		for _, trace := range parse(t, e.bytes(2))[1] {
+			tracesPerKey[string(e.bytes(1))] = append(tracesPerKey[string(e.bytes(1))], trace.b)
+		}
+	}
+	require.Len(t, tracesPerKey, 4)
+	require.Len(t, tracesPerKey["# Todos\nquery Todos { todos { id } }"], 2)
+	require.Len(t, tracesPerKey["## GraphQLParseFailure\n"], 1)
+
+	trace := parse(t, tracesPerKey["# Todos\nquery Todos { todos { id } }"][0])
+	assert.Equal(t, "web", string(trace.bytes(7)))
+	assert.Equal(t, "1.0", string(trace.bytes(8)))
+	assert.NotZero(t, trace.varint(11))
+
+	root := parse(t, trace.bytes(14))
+	todos := parse(t, root.bytes(12))
+	assert.Equal(t, "todos", string(todos.bytes(1)))
+	assert.Equal(t, "[Todo!]!", string(todos.bytes(3)))
+	assert.Equal(t, "Query", string(todos.bytes(13)))
+	require.Len(t, todos[12], 3)
+	var indexes []uint64
+	for _, child := range todos[12] {
+		item := parse(t, child.b)
+		indexes = append(indexes, item.varint(2))
+		assert.Equal(t, "id", string(parse(t, item.bytes(12)).bytes(1)))
+	}
+	assert.ElementsMatch(t, []uint64{0, 1, 2}, indexes)
+
+	require.Len(t, tracesPerKey[`# Item`+"\n"+`query Item { todo(id: "") { ...T } } fragment T on Todo { id }`], 1, "literals, aliases and unused fragments are not part of the key")
+	failed := parse(t, tracesPerKey[`# -`+"\n"+`query { todo(id: "") { id } }`][0])
+	todo := parse(t, parse(t, failed.bytes(14)).bytes(12))
+	assert.Equal(t, "todo not found", string(parse(t, todo.bytes(11)).bytes(1)))
+}
+
+func TestReporter_MaxBatch(t *testing.T) {
+	var reports atomic.Int64
+	ingress := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reports.Add(1)
+	}))
+	defer ingress.Close()
+
+	reporter := apolloreporting.New("service:key", "todo@current",
+		apolloreporting.WithEndpoint(ingress.URL),
+		apolloreporting.WithFlushInterval(time.Hour),
+		apolloreporting.WithMaxBatch(2),
+	)
+	defer reporter.Close(context.Background())
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(reporter)
+
+	doRequest(srv, `{"query":"query Todos { todos { id } }"}`)
+	doRequest(srv, `{"query":"query Todos { todos { id } }"}`)
+	assert.Eventually(t, func() bool {
+		return reports.Load() == 1
+	}, time.Second, 5*time.Millisecond, "full batches are sent before the flush interval")
+}
+
+type field struct {
+	v uint64
+	b []byte
+}
+
+// message is a decoded protobuf message, good enough to assert on reports
+// without the generated Apollo types.
+type message map[protowire.Number][]field
+
+func (m message) bytes(num protowire.Number) []byte {
+	if len(m[num]) == 0 {
+		return nil
+	}
+	return m[num][0].b
+}
+
+func (m message) varint(num protowire.Number) uint64 {
+	if len(m[num]) == 0 {
+		return 0
+	}
+	return m[num][0].v
+}
+
+func parse(t *testing.T, b []byte) message {
+	t.Helper()
+
+	m := message{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			require.GreaterOrEqual(t, n, 0)
+			m[num] = append(m[num], field{v: v})
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			require.GreaterOrEqual(t, n, 0)
+			m[num] = append(m[num], field{b: v})
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+	}
+
+	return m
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("apollographql-client-name", "web")
+	r.Header.Set("apollographql-client-version", "1.0")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
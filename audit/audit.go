@@ -0,0 +1,102 @@
+// Package audit writes one record per mutation to an append-only sink, as
+// required by compliance regimes tracking who changed what.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Status values of Record.
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Record describes one mutation.
+type Record struct {
+	Time          time.Time      `json:"time"`
+	Actor         string         `json:"actor,omitempty"`
+	OperationName string         `json:"operation_name,omitempty"`
+	Fields        []string       `json:"fields"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Status        string         `json:"status"`
+	Errors        []string       `json:"errors,omitempty"`
+	DurationMs    float64        `json:"duration_ms"`
+}
+
+// Auditor is a gqlgen handler extension handing a Record to its Sink once
+// every mutation completed, including those failing on their variables.
+// Queries, subscriptions and documents that fail to parse or validate are
+// not audited.
+type Auditor struct {
+	sink Sink
+	cfg  *config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = Auditor{}
+
+// New returns an Auditor writing to sink.
+func New(sink Sink, opts ...Option) Auditor {
+	return Auditor{
+		sink: sink,
+		cfg:  newConfig(opts...),
+	}
+}
+
+func (a Auditor) ExtensionName() string {
+	return "Audit"
+}
+
+func (a Auditor) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (a Auditor) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
+		return res
+	}
+
+	record := &Record{
+		Time:          oc.Stats.OperationStart,
+		Actor:         a.cfg.actor(ctx),
+		OperationName: oc.Operation.Name,
+		Fields:        []string{},
+		Status:        StatusSuccess,
+		DurationMs:    float64(time.Since(oc.Stats.OperationStart)) / float64(time.Millisecond),
+	}
+	for _, field := range graphql.CollectFields(oc, oc.Operation.SelectionSet, nil) {
+		record.Fields = append(record.Fields, field.Name)
+	}
+	if len(oc.Variables) > 0 {
+		record.Variables = a.cfg.redactor.Variables(oc)
+	}
+	if len(res.Errors) > 0 {
+		record.Status = StatusFailure
+		for _, err := range res.Errors {
+			record.Errors = append(record.Errors, err.Message)
+		}
+	}
+
+	// The mutation is committed, so its record is written even if the client
+	// disconnected in the meantime.
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.cfg.writeTimeout)
+	defer cancel()
+	if err := a.sink.Write(writeCtx, record); err != nil {
+		a.cfg.errorHandler(ctx, err)
+	}
+
+	return res
+}
@@ -0,0 +1,214 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/audit"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type actorKey struct{}
+
+func newServer(sink audit.Sink, opts ...audit.Option) http.Handler {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(audit.New(sink, append(opts, audit.WithActor(func(ctx context.Context) string {
+		actor, _ := ctx.Value(actorKey{}).(string)
+		return actor
+	}))...))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), actorKey{}, r.Header.Get("X-User"))
+		srv.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func TestAuditor(t *testing.T) {
+	var buf bytes.Buffer
+	srv := newServer(audit.WriterSink(&buf), audit.WithRedactedFields("input.text"))
+
+	query := `{
+		"query": "mutation Create($input: NewTodo!) { createTodo(input: $input) { id } }",
+		"variables": {"input": {"text": "sensitive", "userId": "1"}}
+	}`
+	resp := doRequest(srv, query, "alice")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = doRequest(srv, `{"query":"{ todos { id } }"}`, "alice")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = doRequest(srv, `{"query":"mutation { createTodo(input: {text: \"a\", userId: \"1\"}) { id } a: todo(id: \"x\") { id } }"}`, "bob")
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1, "queries and invalid operations are not audited")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.NotEmpty(t, record["time"])
+	assert.Contains(t, record, "duration_ms")
+	delete(record, "time")
+	delete(record, "duration_ms")
+
+	assert.Equal(t, map[string]any{
+		"actor":          "alice",
+		"operation_name": "Create",
+		"fields":         []any{"createTodo"},
+		"variables": map[string]any{
+			"input": map[string]any{"text": "[REDACTED]", "userId": "1"},
+		},
+		"status": "success",
+	}, record)
+}
+
+func TestAuditor_Redactor(t *testing.T) {
+	var records []*audit.Record
+	srv := newServer(audit.SinkFunc(func(ctx context.Context, record *audit.Record) error {
+		records = append(records, record)
+		return nil
+	}), audit.WithRedactedFields("input.text"), audit.WithRedactor(redact.New(nil, redact.Path("input.userId"))))
+
+	query := `{
+		"query": "mutation Create($input: NewTodo!) { createTodo(input: $input) { id } }",
+		"variables": {"input": {"text": "sensitive", "userId": "1"}}
+	}`
+	resp := doRequest(srv, query, "alice")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]any{
+		"input": map[string]any{"text": "[REDACTED]", "userId": "[REDACTED]"},
+	}, records[0].Variables)
+}
+
+func TestAuditor_HTTPSink(t *testing.T) {
+	records := make(chan audit.Record, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record audit.Record
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+		records <- record
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer collector.Close()
+
+	var sinkErr error
+	srv := newServer(audit.HTTPSink(collector.Client(), collector.URL),
+		audit.WithErrorHandler(func(ctx context.Context, err error) { sinkErr = err }),
+	)
+
+	resp := doRequest(srv, `{"query":"mutation { createTodo(input: {text: \"a\", userId: \"1\"}) { id } }"}`, "bob")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NoError(t, sinkErr)
+
+	record := <-records
+	assert.Equal(t, "bob", record.Actor)
+	assert.Equal(t, audit.StatusSuccess, record.Status)
+	assert.Equal(t, []string{"createTodo"}, record.Fields)
+	assert.Empty(t, record.Variables)
+
+	collector.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	resp = doRequest(srv, `{"query":"mutation { createTodo(input: {text: \"a\", userId: \"1\"}) { id } }"}`, "bob")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.ErrorContains(t, sinkErr, "responded 503 Service Unavailable")
+}
+
+func TestAuditor_Failure(t *testing.T) {
+	var records []*audit.Record
+	srv := newServer(audit.SinkFunc(func(ctx context.Context, record *audit.Record) error {
+		records = append(records, record)
+		return nil
+	}), audit.WithRedactedFields("*"))
+
+	query := `{
+		"query": "mutation Create($input: NewTodo!) { createTodo(input: $input) { id } }",
+		"variables": {"input": {"text": "a"}}
+	}`
+	resp := doRequest(srv, query, "")
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, audit.StatusFailure, records[0].Status)
+	assert.Equal(t, []string{"must be defined"}, records[0].Errors)
+	assert.Empty(t, records[0].Variables, "variables failing validation are not coerced")
+
+	query = `{
+		"query": "mutation Create($input: NewTodo!) { createTodo(input: $input) { id } }",
+		"variables": {"input": {"text": "a", "userId": "1"}}
+	}`
+	resp = doRequest(srv, query, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, records, 2)
+	assert.Equal(t, audit.StatusSuccess, records[1].Status)
+	assert.Equal(t, map[string]any{"input": "[REDACTED]"}, records[1].Variables)
+}
+
+func TestAuditor_Disconnected(t *testing.T) {
+	var records []*audit.Record
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(audit.New(audit.SinkFunc(func(ctx context.Context, record *audit.Record) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("sink context has no deadline")
+		}
+		records = append(records, record)
+		return nil
+	}), audit.WithWriteTimeout(time.Minute)))
+
+	// The client goes away once the mutation is committed.
+	ctx, cancel := context.WithCancel(context.Background())
+	srv.AroundRootFields(func(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+		defer cancel()
+		return next(ctx)
+	})
+
+	r := httptest.NewRequestWithContext(ctx, http.MethodPost, "/query", strings.NewReader(`{"query":"mutation { createTodo(input: {text: \"a\", userId: \"1\"}) { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), r)
+
+	require.Len(t, records, 1, "records of disconnected clients are written")
+	assert.Equal(t, audit.StatusSuccess, records[0].Status)
+}
+
+func TestAuditor_MalformedBody(t *testing.T) {
+	var records []*audit.Record
+	srv := newServer(audit.SinkFunc(func(ctx context.Context, record *audit.Record) error {
+		records = append(records, record)
+		return nil
+	}))
+
+	resp := doRequest(srv, `{"query":`, "alice")
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "json request body could not be decoded")
+	assert.Empty(t, records)
+}
+
+func doRequest(handler http.Handler, body string, user string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-User", user)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/redact"
+)
+
+type config struct {
+	actor        func(ctx context.Context) string
+	redactor     *redact.Redactor
+	redacted     []string
+	writeTimeout time.Duration
+	errorHandler func(ctx context.Context, err error)
+}
+
+// Option is anything that can configure Auditor.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		actor:        func(ctx context.Context) string { return "" },
+		writeTimeout: 10 * time.Second,
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if len(cfg.redacted) != 0 {
+		cfg.redactor = cfg.redactor.With(redact.Path(cfg.redacted...))
+	}
+
+	return cfg
+}
+
+// WithActor sets how the actor of a mutation, typically the authenticated
+// user, is read from the request context.
+func WithActor(fn func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.actor = fn
+	}
+}
+
+// WithRedactedFields replaces the variable values at the given dotted paths
+// with "[REDACTED]", for example "input.password", see redact.Path.
+func WithRedactedFields(paths ...string) Option {
+	return func(cfg *config) {
+		cfg.redacted = append(cfg.redacted, paths...)
+	}
+}
+
+// WithRedactor redacts the recorded variables with r, on top of the paths
+// given to WithRedactedFields.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(cfg *config) {
+		cfg.redactor = r
+	}
+}
+
+// WithWriteTimeout bounds how long the sink may take to write a record,
+// 10s by default. Records are written even if the client went away.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.writeTimeout = d
+	}
+}
+
+// WithErrorHandler is called when the sink fails to write a record, which
+// is otherwise lost.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/99designs/gqlgen-contrib/internal/jsonsink"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Sink stores audit records. Write is called synchronously before the
+// response is sent and must be safe for concurrent use. Its context is not
+// canceled with the request but expires after the write timeout, see
+// WithWriteTimeout.
+type Sink interface {
+	Write(ctx context.Context, record *Record) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, record *Record) error
+
+func (f SinkFunc) Write(ctx context.Context, record *Record) error {
+	return f(ctx, record)
+}
+
+// WriterSink writes records to w as JSON lines.
+func WriterSink(w io.Writer) Sink {
+	return writerSink{jsonsink.NewWriter(w)}
+}
+
+type writerSink struct {
+	w *jsonsink.Writer
+}
+
+func (s writerSink) Write(ctx context.Context, record *Record) error {
+	return s.w.Encode(record)
+}
+
+// HTTPSink POSTs every record as JSON to url. Responses other than 2xx are
+// errors.
+func HTTPSink(client *http.Client, url string) Sink {
+	return SinkFunc(func(ctx context.Context, record *Record) error {
+		if err := jsonsink.Post(ctx, client, url, nil, record); err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+		return nil
+	})
+}
+
+// KafkaSink produces every record as JSON to topic, keyed by actor so the
+// records of an actor stay ordered.
+func KafkaSink(client *kgo.Client, topic string) Sink {
+	return SinkFunc(func(ctx context.Context, record *Record) error {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		return client.ProduceSync(ctx, &kgo.Record{
+			Topic: topic,
+			Key:   []byte(record.Actor),
+			Value: value,
+		}).FirstErr()
+	})
+}
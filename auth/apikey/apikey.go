@@ -0,0 +1,143 @@
+// Package apikey authenticates requests by API key and exposes the quotas of
+// each key to the ratelimit and complexity limit extensions.
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/99designs/gqlgen-contrib/auth"
+	"github.com/99designs/gqlgen-contrib/ratelimit"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeUnauthenticated is the extensions.code of the error returned for
+// missing or unknown API keys.
+const ErrCodeUnauthenticated = "UNAUTHENTICATED"
+
+// ErrNotFound is returned by stores for unknown API keys.
+var ErrNotFound = errors.New("apikey: not found")
+
+// Key describes an API key. It never holds the secret itself.
+type Key struct {
+	// ID identifies the key in logs and rate limits.
+	ID string `json:"id"`
+	// Owner is the user the key acts as, reported by auth.UserID.
+	Owner    string            `json:"owner,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// RateLimit overrides the default limit of the ratelimit extension.
+	RateLimit *ratelimit.Limit `json:"rate_limit,omitempty"`
+	// MaxComplexity overrides the default complexity limit, unless 0.
+	MaxComplexity int `json:"max_complexity,omitempty"`
+}
+
+// Store looks up API keys by secret, returning ErrNotFound for unknown ones.
+type Store interface {
+	Lookup(ctx context.Context, secret string) (*Key, error)
+}
+
+// Hash returns the hex encoded SHA-256 of secret, under which SQLStore and
+// RedisStore expect keys to be stored.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator checks the API key of requests against a Store.
+type Authenticator struct {
+	store Store
+	cfg   *config
+}
+
+// New returns an Authenticator looking keys up in store.
+func New(store Store, opts ...Option) *Authenticator {
+	return &Authenticator{
+		store: store,
+		cfg:   newConfig(opts...),
+	}
+}
+
+type keyKey struct{}
+
+// ForContext returns the API key of the request, nil if there is none.
+func ForContext(ctx context.Context) *Key {
+	key, _ := ctx.Value(keyKey{}).(*Key)
+	return key
+}
+
+// Middleware authenticates requests bearing an API key, answering 401 to
+// those whose key is unknown.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(a.cfg.header)
+		if secret == "" {
+			if a.cfg.required {
+				respond(w, http.StatusUnauthorized, "missing API key", ErrCodeUnauthenticated)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := a.store.Lookup(r.Context(), secret)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			respond(w, http.StatusUnauthorized, "invalid API key", ErrCodeUnauthenticated)
+			return
+		case err != nil:
+			a.cfg.errorHandler(r.Context(), err)
+			respond(w, http.StatusServiceUnavailable, "authentication unavailable", "SERVICE_UNAVAILABLE")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), keyKey{}, key)
+		ctx = auth.WithUser(ctx, key.Owner, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RateLimitKey is a ratelimit.KeyFunc limiting by API key ID.
+func RateLimitKey(ctx context.Context) string {
+	if key := ForContext(ctx); key != nil {
+		return key.ID
+	}
+	return ""
+}
+
+var _ ratelimit.KeyFunc = RateLimitKey
+
+// RateLimit returns the limit of the API key of ctx, for
+// ratelimit.WithLimitFunc.
+func RateLimit(ctx context.Context, _ string) (ratelimit.Limit, bool) {
+	if key := ForContext(ctx); key != nil && key.RateLimit != nil {
+		return *key.RateLimit, true
+	}
+	return ratelimit.Limit{}, false
+}
+
+// ComplexityLimit returns a func for extension.ComplexityLimit applying the
+// MaxComplexity of the API key of ctx, or fallback.
+func ComplexityLimit(fallback int) func(ctx context.Context, oc *graphql.OperationContext) int {
+	return func(ctx context.Context, oc *graphql.OperationContext) int {
+		if key := ForContext(ctx); key != nil && key.MaxComplexity > 0 {
+			return key.MaxComplexity
+		}
+		return fallback
+	}
+}
+
+func respond(w http.ResponseWriter, status int, message, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": gqlerror.List{{
+			Message:    message,
+			Extensions: map[string]any{"code": code},
+		}},
+	})
+}
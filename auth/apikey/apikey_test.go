@@ -0,0 +1,195 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/auth"
+	"github.com/99designs/gqlgen-contrib/auth/apikey"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/ratelimit"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keys = apikey.StaticStore{
+	"free-secret": {ID: "free", Owner: "alice"},
+	"pro-secret": {
+		ID:            "pro",
+		Owner:         "bob",
+		RateLimit:     &ratelimit.Limit{Rate: 1, Burst: 3},
+		MaxComplexity: 10,
+	},
+}
+
+func newServer(authenticator *apikey.Authenticator) http.Handler {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(&extension.ComplexityLimit{Func: apikey.ComplexityLimit(3)})
+	srv.Use(ratelimit.New(ratelimit.NewMemoryStore(), apikey.RateLimitKey, ratelimit.Limit{Rate: 1, Burst: 1},
+		ratelimit.WithLimitFunc(apikey.RateLimit),
+	))
+
+	return authenticator.Middleware(srv)
+}
+
+func doRequest(handler http.Handler, secret string, query string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(query))
+	r.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		r.Header.Set("X-API-Key", secret)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestAuthenticator_Quotas(t *testing.T) {
+	srv := newServer(apikey.New(keys))
+	large := `{"query":"{ todos { id text done } }"}`
+	small := `{"query":"{ todos { id } }"}`
+
+	// The free key gets the default complexity limit and burst.
+	resp := doRequest(srv, "free-secret", large)
+	assert.Contains(t, resp.Body.String(), "COMPLEXITY_LIMIT_EXCEEDED")
+	resp = doRequest(srv, "free-secret", small)
+	assert.NotContains(t, resp.Body.String(), "errors")
+	resp = doRequest(srv, "free-secret", small)
+	assert.Contains(t, resp.Body.String(), "RATE_LIMITED")
+
+	// The pro key has its own.
+	for i := 0; i < 3; i++ {
+		resp = doRequest(srv, "pro-secret", large)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "errors")
+	}
+	resp = doRequest(srv, "pro-secret", large)
+	assert.Contains(t, resp.Body.String(), "RATE_LIMITED")
+
+	// Requests without a key are not limited.
+	resp = doRequest(srv, "", small)
+	assert.NotContains(t, resp.Body.String(), "errors")
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	var key *apikey.Key
+	var user string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = apikey.ForContext(r.Context())
+		user = auth.UserID(r.Context())
+	})
+
+	var storeErr error
+	failing := storeFunc(func(ctx context.Context, secret string) (*apikey.Key, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	resp := doRequest(apikey.New(keys).Middleware(next), "free-secret", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "free", key.ID)
+	assert.Equal(t, "alice", user)
+
+	resp = doRequest(apikey.New(keys).Middleware(next), "unknown", "")
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"invalid API key","extensions":{"code":"UNAUTHENTICATED"}}]}`, resp.Body.String())
+
+	resp = doRequest(apikey.New(keys, apikey.WithRequired()).Middleware(next), "", "")
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Contains(t, resp.Body.String(), "missing API key")
+
+	resp = doRequest(apikey.New(failing, apikey.WithErrorHandler(func(ctx context.Context, err error) {
+		storeErr = err
+	})).Middleware(next), "free-secret", "")
+	require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.EqualError(t, storeErr, "connection refused")
+}
+
+type storeFunc func(ctx context.Context, secret string) (*apikey.Key, error)
+
+func (f storeFunc) Lookup(ctx context.Context, secret string) (*apikey.Key, error) {
+	return f(ctx, secret)
+}
+
+func TestSQLStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	query := "SELECT id, owner, metadata, rate, burst, max_complexity FROM api_keys WHERE hash = ?"
+	columns := []string{"id", "owner", "metadata", "rate", "burst", "max_complexity"}
+	mock.ExpectQuery(query).WithArgs(apikey.Hash("pro-secret")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("pro", "bob", `{"plan":"pro"}`, 2.5, 10, 100))
+	mock.ExpectQuery(query).WithArgs(apikey.Hash("free-secret")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("free", nil, nil, nil, nil, nil))
+	mock.ExpectQuery(query).WithArgs(apikey.Hash("burstless-secret")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("burstless", nil, nil, 2.5, nil, nil))
+	mock.ExpectQuery(query).WithArgs(apikey.Hash("invalid-secret")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("invalid", nil, nil, 0, 10, nil))
+	mock.ExpectQuery(query).WithArgs(apikey.Hash("unknown")).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	store := apikey.SQLStore{DB: db, Query: query}
+
+	key, err := store.Lookup(context.Background(), "pro-secret")
+	require.NoError(t, err)
+	assert.Equal(t, &apikey.Key{
+		ID:            "pro",
+		Owner:         "bob",
+		Metadata:      map[string]string{"plan": "pro"},
+		RateLimit:     &ratelimit.Limit{Rate: 2.5, Burst: 10},
+		MaxComplexity: 100,
+	}, key)
+
+	key, err = store.Lookup(context.Background(), "free-secret")
+	require.NoError(t, err)
+	assert.Equal(t, &apikey.Key{ID: "free"}, key)
+
+	key, err = store.Lookup(context.Background(), "burstless-secret")
+	require.NoError(t, err)
+	assert.Equal(t, &ratelimit.Limit{Rate: 2.5, Burst: 3}, key.RateLimit)
+
+	_, err = store.Lookup(context.Background(), "invalid-secret")
+	assert.EqualError(t, err, "apikey: rate limit of invalid: ratelimit: rate must be positive, got 0")
+
+	_, err = store.Lookup(context.Background(), "unknown")
+	assert.ErrorIs(t, err, apikey.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("apikey:"+apikey.Hash("pro-secret"), `{"id":"pro","owner":"bob","rate_limit":{"rate":1,"burst":3},"max_complexity":10}`)
+	mr.Set("apikey:"+apikey.Hash("invalid-secret"), `{"id":"invalid","rate_limit":{"rate":1}}`)
+
+	store := apikey.RedisStore{
+		Client: redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+		Prefix: "apikey:",
+	}
+
+	key, err := store.Lookup(context.Background(), "pro-secret")
+	require.NoError(t, err)
+	assert.Equal(t, &apikey.Key{
+		ID:            "pro",
+		Owner:         "bob",
+		RateLimit:     &ratelimit.Limit{Rate: 1, Burst: 3},
+		MaxComplexity: 10,
+	}, key)
+
+	_, err = store.Lookup(context.Background(), "invalid-secret")
+	assert.EqualError(t, err, "apikey: rate limit of invalid: ratelimit: burst must be at least 1, got 0")
+
+	_, err = store.Lookup(context.Background(), "unknown")
+	assert.ErrorIs(t, err, apikey.ErrNotFound)
+}
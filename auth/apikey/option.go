@@ -0,0 +1,49 @@
+package apikey
+
+import "context"
+
+type config struct {
+	header       string
+	required     bool
+	errorHandler func(ctx context.Context, err error)
+}
+
+// Option is anything that can configure Authenticator.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		header:       "X-API-Key",
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithHeader reads the API key from the given request header instead of
+// X-API-Key.
+func WithHeader(name string) Option {
+	return func(cfg *config) {
+		cfg.header = name
+	}
+}
+
+// WithRequired rejects requests without an API key. By default they reach
+// the server unauthenticated.
+func WithRequired() Option {
+	return func(cfg *config) {
+		cfg.required = true
+	}
+}
+
+// WithErrorHandler is called when the Store fails, in which case requests
+// are answered with 503 Service Unavailable.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
@@ -0,0 +1,104 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/99designs/gqlgen-contrib/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// StaticStore looks keys up in a map from secret to Key, for keys known at
+// startup.
+type StaticStore map[string]*Key
+
+func (s StaticStore) Lookup(ctx context.Context, secret string) (*Key, error) {
+	if key, ok := s[secret]; ok {
+		return key, nil
+	}
+	return nil, ErrNotFound
+}
+
+// SQLStore looks keys up with query, which is passed the Hash of the secret
+// and must select the id, owner, metadata, rate, burst and max_complexity
+// columns, in that order. metadata is a JSON object, all but id may be
+// NULL. A NULL burst with a rate defaults to the rate rounded up, allowing
+// one second worth of operations at once. For example:
+//
+//	SELECT id, owner, metadata, rate, burst, max_complexity FROM api_keys
+//	WHERE hash = $1 AND revoked_at IS NULL
+type SQLStore struct {
+	DB    *sql.DB
+	Query string
+}
+
+func (s SQLStore) Lookup(ctx context.Context, secret string) (*Key, error) {
+	var (
+		key           Key
+		owner         sql.NullString
+		metadata      sql.NullString
+		rate          sql.NullFloat64
+		burst         sql.NullInt64
+		maxComplexity sql.NullInt64
+	)
+	err := s.DB.QueryRowContext(ctx, s.Query, Hash(secret)).
+		Scan(&key.ID, &owner, &metadata, &rate, &burst, &maxComplexity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("apikey: lookup: %w", err)
+	}
+
+	key.Owner = owner.String
+	if metadata.Valid && metadata.String != "" {
+		if err := json.Unmarshal([]byte(metadata.String), &key.Metadata); err != nil {
+			return nil, fmt.Errorf("apikey: metadata of %s: %w", key.ID, err)
+		}
+	}
+	if rate.Valid {
+		key.RateLimit = &ratelimit.Limit{Rate: rate.Float64, Burst: int(burst.Int64)}
+		if !burst.Valid {
+			key.RateLimit.Burst = max(1, int(math.Ceil(rate.Float64)))
+		}
+		if err := key.RateLimit.Validate(); err != nil {
+			return nil, fmt.Errorf("apikey: rate limit of %s: %w", key.ID, err)
+		}
+	}
+	key.MaxComplexity = int(maxComplexity.Int64)
+
+	return &key, nil
+}
+
+// RedisStore looks keys up as JSON encoded Key values stored under prefix
+// followed by the Hash of the secret.
+type RedisStore struct {
+	Client redis.UniversalClient
+	Prefix string
+}
+
+func (s RedisStore) Lookup(ctx context.Context, secret string) (*Key, error) {
+	data, err := s.Client.Get(ctx, s.Prefix+Hash(secret)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("apikey: lookup: %w", err)
+	}
+
+	var key Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("apikey: decode key: %w", err)
+	}
+	if key.RateLimit != nil {
+		if err := key.RateLimit.Validate(); err != nil {
+			return nil, fmt.Errorf("apikey: rate limit of %s: %w", key.ID, err)
+		}
+	}
+
+	return &key, nil
+}
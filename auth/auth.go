@@ -0,0 +1,33 @@
+// Package auth carries the authenticated user of a request in its context,
+// whichever mechanism authenticated it.
+package auth
+
+import "context"
+
+type principalKey struct{}
+
+type principal struct {
+	userID string
+	claims any
+}
+
+// WithUser returns a copy of ctx authenticated as userID. claims holds
+// whatever the authentication mechanism knows about the user, retrieved
+// with Claims.
+func WithUser(ctx context.Context, userID string, claims any) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal{userID: userID, claims: claims})
+}
+
+// UserID returns the authenticated user of ctx, "" if there is none.
+func UserID(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey{}).(principal)
+	return p.userID
+}
+
+// Claims returns the claims of the authenticated user of ctx, if there is
+// one and its claims are a T.
+func Claims[T any](ctx context.Context) (T, bool) {
+	p, _ := ctx.Value(principalKey{}).(principal)
+	claims, ok := p.claims.(T)
+	return claims, ok
+}
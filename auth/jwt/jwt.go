@@ -0,0 +1,209 @@
+// Package jwt authenticates requests bearing a JSON Web Token, over HTTP and
+// websockets, storing the user in their context for the accessors of the
+// auth package.
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/auth"
+	"github.com/99designs/gqlgen-contrib/wsauth"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeUnauthenticated is the extensions.code of the error returned for
+// missing or invalid tokens.
+const ErrCodeUnauthenticated = "UNAUTHENTICATED"
+
+// Authenticator verifies tokens against the configured keys.
+type Authenticator struct {
+	cfg    *config
+	jwks   *jwks
+	parser *jwtlib.Parser
+}
+
+// New returns an Authenticator. At least one of WithHMACSecret,
+// WithPublicKey and WithJWKS is required.
+func New(opts ...Option) (*Authenticator, error) {
+	cfg := newConfig(opts...)
+	if cfg.hmacSecret == nil && len(cfg.publicKeys) == 0 && cfg.jwksURL == "" {
+		return nil, errors.New("jwt: no key configured")
+	}
+
+	algorithms := cfg.algorithms
+	if algorithms == nil {
+		if cfg.hmacSecret != nil {
+			algorithms = append(algorithms, "HS256", "HS384", "HS512")
+		}
+		if len(cfg.publicKeys) > 0 || cfg.jwksURL != "" {
+			algorithms = append(algorithms, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+		}
+	}
+
+	a := &Authenticator{
+		cfg:    cfg,
+		parser: jwtlib.NewParser(append([]jwtlib.ParserOption{jwtlib.WithValidMethods(algorithms)}, cfg.parserOpts...)...),
+	}
+	if cfg.jwksURL != "" {
+		a.jwks = &jwks{
+			url:        cfg.jwksURL,
+			client:     cfg.httpClient,
+			refresh:    cfg.jwksRefresh,
+			minRefresh: cfg.jwksMinRefresh,
+		}
+	}
+
+	return a, nil
+}
+
+// Authenticate verifies token and returns ctx authenticated as its subject.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (context.Context, error) {
+	id, err := a.verify(ctx, token)
+	if err != nil {
+		return ctx, err
+	}
+
+	return auth.WithUser(ctx, id.Subject, id.Value), nil
+}
+
+// Middleware authenticates requests bearing an Authorization: Bearer
+// header, answering 401 to those whose token is invalid.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			if a.cfg.required {
+				unauthorized(w, "missing token")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := a.Authenticate(r.Context(), token)
+		if err != nil {
+			unauthorized(w, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// InitFunc returns a transport.WebsocketInitFunc authenticating connections
+// from the token of their connection_init payload, see wsauth.New for the
+// options. Connections are closed once their token expires.
+func (a *Authenticator) InitFunc(opts ...wsauth.Option) transport.WebsocketInitFunc {
+	init := wsauth.New(a.verify, opts...).InitFunc
+
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		ctx, ack, err := init(ctx, payload)
+		if err != nil {
+			return ctx, ack, err
+		}
+
+		id := wsauth.ForContext(ctx)
+		return auth.WithUser(ctx, id.Subject, id.Value), ack, nil
+	}
+}
+
+func (a *Authenticator) verify(ctx context.Context, token string) (*wsauth.Identity, error) {
+	claims := a.cfg.claims()
+	if _, err := a.parser.ParseWithClaims(token, claims, a.keyFunc(ctx)); err != nil {
+		return nil, err
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return nil, err
+	}
+	if subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	id := &wsauth.Identity{
+		Subject: subject,
+		Value:   claims,
+	}
+	if exp, err := claims.GetExpirationTime(); err != nil {
+		return nil, err
+	} else if exp != nil {
+		id.ExpiresAt = exp.Time
+	}
+	if m, ok := claims.(jwtlib.MapClaims); ok {
+		id.Claims = m
+	}
+
+	return id, nil
+}
+
+func (a *Authenticator) keyFunc(ctx context.Context) jwtlib.Keyfunc {
+	return func(token *jwtlib.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		switch token.Method.(type) {
+		case *jwtlib.SigningMethodHMAC:
+			if a.cfg.hmacSecret == nil {
+				return nil, errors.New("no HMAC secret configured")
+			}
+			return a.cfg.hmacSecret, nil
+		case *jwtlib.SigningMethodRSA, *jwtlib.SigningMethodRSAPSS, *jwtlib.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unsupported signing method %s", token.Method.Alg())
+		}
+
+		if key, ok := a.cfg.publicKeys[kid]; ok {
+			return key, nil
+		}
+		if key, ok := a.cfg.publicKeys[""]; ok {
+			return key, nil
+		}
+		if a.jwks != nil {
+			key, err := a.jwks.key(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+			if !matches(token.Method, key) {
+				return nil, fmt.Errorf("key %q does not match signing method %s", kid, token.Method.Alg())
+			}
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+}
+
+// matches reports whether key can verify method, JWKS mixing key types.
+func matches(method jwtlib.SigningMethod, key any) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwtlib.SigningMethodRSA, *jwtlib.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwtlib.SigningMethodECDSA)
+		return ok
+	}
+	return false
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": gqlerror.List{{
+			Message:    message,
+			Extensions: map[string]any{"code": ErrCodeUnauthenticated},
+		}},
+	})
+}
@@ -0,0 +1,236 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/auth"
+	"github.com/99designs/gqlgen-contrib/auth/jwt"
+	"github.com/99designs/gqlgen-contrib/wsauth"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("secret")
+
+func sign(t *testing.T, method jwtlib.SigningMethod, kid string, key any, claims jwtlib.Claims) string {
+	token := jwtlib.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// whoami answers with the authenticated user of the request.
+var whoami = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(auth.UserID(r.Context())))
+})
+
+func doRequest(handler http.Handler, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	authenticator, err := jwt.New(jwt.WithHMACSecret(secret), jwt.WithIssuer("https://issuer.example"))
+	require.NoError(t, err)
+	srv := authenticator.Middleware(whoami)
+
+	valid := sign(t, jwtlib.SigningMethodHS256, "", secret, jwtlib.MapClaims{"sub": "alice", "iss": "https://issuer.example"})
+	resp := doRequest(srv, valid)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "alice", resp.Body.String())
+
+	resp = doRequest(srv, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Body.String())
+
+	wrongIssuer := sign(t, jwtlib.SigningMethodHS256, "", secret, jwtlib.MapClaims{"sub": "alice", "iss": "https://other.example"})
+	resp = doRequest(srv, wrongIssuer)
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, resp.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"errors":[{"message":"token has invalid claims: token has invalid issuer","extensions":{"code":"UNAUTHENTICATED"}}]}`, resp.Body.String())
+
+	// The none algorithm and algorithms of other key types are refused.
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	resp = doRequest(srv, sign(t, jwtlib.SigningMethodRS256, "", key, jwtlib.MapClaims{"sub": "alice"}))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	resp = doRequest(srv, sign(t, jwtlib.SigningMethodNone, "", jwtlib.UnsafeAllowNoneSignatureType, jwtlib.MapClaims{"sub": "alice"}))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestMiddleware_Required(t *testing.T) {
+	authenticator, err := jwt.New(jwt.WithHMACSecret(secret), jwt.WithRequired())
+	require.NoError(t, err)
+
+	resp := doRequest(authenticator.Middleware(whoami), "")
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Contains(t, resp.Body.String(), "missing token")
+}
+
+type customClaims struct {
+	Roles []string `json:"roles"`
+	jwtlib.RegisteredClaims
+}
+
+func TestMiddleware_Claims(t *testing.T) {
+	authenticator, err := jwt.New(
+		jwt.WithHMACSecret(secret),
+		jwt.WithClaims(func() jwtlib.Claims { return &customClaims{} }),
+	)
+	require.NoError(t, err)
+
+	var roles []string
+	srv := authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.Claims[*customClaims](r.Context())
+		require.True(t, ok)
+		roles = claims.Roles
+	}))
+
+	token := sign(t, jwtlib.SigningMethodHS256, "", secret, &customClaims{
+		Roles:            []string{"admin"},
+		RegisteredClaims: jwtlib.RegisteredClaims{Subject: "alice"},
+	})
+	resp := doRequest(srv, token)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, []string{"admin"}, roles)
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys := []map[string]string{rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey)}
+	var fetches atomic.Int32
+	var served atomic.Value
+	served.Store(keys)
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": served.Load()})
+	}))
+	defer jwksServer.Close()
+
+	authenticator, err := jwt.New(
+		jwt.WithJWKS(jwksServer.URL),
+		jwt.WithHTTPClient(jwksServer.Client()),
+		jwt.WithJWKSRefresh(time.Hour, 0),
+	)
+	require.NoError(t, err)
+	srv := authenticator.Middleware(whoami)
+
+	resp := doRequest(srv, sign(t, jwtlib.SigningMethodRS256, "rsa", rsaKey, jwtlib.MapClaims{"sub": "alice"}))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, "alice", resp.Body.String())
+
+	resp = doRequest(srv, sign(t, jwtlib.SigningMethodES256, "ec", ecKey, jwtlib.MapClaims{"sub": "bob"}))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, "bob", resp.Body.String())
+	assert.Equal(t, int32(1), fetches.Load(), "keys are cached")
+
+	// A key of the wrong type for the algorithm is refused.
+	resp = doRequest(srv, sign(t, jwtlib.SigningMethodES256, "rsa", ecKey, jwtlib.MapClaims{"sub": "bob"}))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	// An unknown key triggers a refetch, picking up rotated keys.
+	served.Store(append(keys, rsaJWK("rotated", &rotated.PublicKey)))
+	resp = doRequest(srv, sign(t, jwtlib.SigningMethodRS256, "rotated", rotated, jwtlib.MapClaims{"sub": "carol"}))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, "carol", resp.Body.String())
+}
+
+func TestJWKS_Outage(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer jwksServer.Close()
+
+	authenticator, err := jwt.New(
+		jwt.WithJWKS(jwksServer.URL),
+		jwt.WithHTTPClient(jwksServer.Client()),
+		jwt.WithJWKSRefresh(time.Hour, time.Hour),
+	)
+	require.NoError(t, err)
+	srv := authenticator.Middleware(whoami)
+
+	token := sign(t, jwtlib.SigningMethodRS256, "rsa", key, jwtlib.MapClaims{"sub": "alice"})
+	for range 3 {
+		assert.Equal(t, http.StatusUnauthorized, doRequest(srv, token).Code)
+	}
+	assert.Equal(t, int32(1), fetches.Load(), "failed fetches are retried after minRefresh")
+}
+
+func TestInitFunc(t *testing.T) {
+	authenticator, err := jwt.New(jwt.WithHMACSecret(secret))
+	require.NoError(t, err)
+	init := authenticator.InitFunc(wsauth.WithMaxLifetime(50 * time.Millisecond))
+
+	token := sign(t, jwtlib.SigningMethodHS256, "", secret, jwtlib.MapClaims{
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	ctx, _, err := init(context.Background(), transport.InitPayload{"Authorization": "Bearer " + token})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", auth.UserID(ctx))
+	claims, ok := auth.Claims[jwtlib.MapClaims](ctx)
+	require.True(t, ok)
+	assert.Equal(t, "alice", claims["sub"])
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("connection not closed after its lifetime")
+	}
+
+	_, _, err = init(context.Background(), transport.InitPayload{"Authorization": "Bearer invalid"})
+	assert.Error(t, err)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
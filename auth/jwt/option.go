@@ -0,0 +1,132 @@
+package jwt
+
+import (
+	"net/http"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+type config struct {
+	hmacSecret []byte
+	publicKeys map[string]any
+	jwksURL    string
+
+	httpClient     *http.Client
+	jwksRefresh    time.Duration
+	jwksMinRefresh time.Duration
+
+	algorithms []string
+	parserOpts []jwtlib.ParserOption
+	claims     func() jwtlib.Claims
+	required   bool
+}
+
+// Option is anything that can configure Authenticator.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		publicKeys:     map[string]any{},
+		httpClient:     &http.Client{Timeout: 10 * time.Second},
+		jwksRefresh:    time.Hour,
+		jwksMinRefresh: time.Minute,
+		claims:         func() jwtlib.Claims { return jwtlib.MapClaims{} },
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithHMACSecret accepts tokens signed with HS256, HS384 or HS512 and
+// secret.
+func WithHMACSecret(secret []byte) Option {
+	return func(cfg *config) {
+		cfg.hmacSecret = secret
+	}
+}
+
+// WithPublicKey accepts tokens whose kid header is kid, or any kid when kid
+// is "", signed with the private counterpart of key, an *rsa.PublicKey or
+// *ecdsa.PublicKey.
+func WithPublicKey(kid string, key any) Option {
+	return func(cfg *config) {
+		cfg.publicKeys[kid] = key
+	}
+}
+
+// WithJWKS accepts tokens signed with the RSA and EC keys of the JSON Web Key
+// Set served at url, as published by most identity providers.
+func WithJWKS(url string) Option {
+	return func(cfg *config) {
+		cfg.jwksURL = url
+	}
+}
+
+// WithHTTPClient sets the client fetching the JWKS. Defaults to a client
+// timing out after 10 seconds.
+func WithHTTPClient(client *http.Client) Option {
+	return func(cfg *config) {
+		cfg.httpClient = client
+	}
+}
+
+// WithJWKSRefresh sets how long the JWKS is cached, defaulting to one hour,
+// and how often at most it is fetched again for tokens signed with an
+// unknown key after a rotation, or after failing to fetch it, defaulting to
+// one minute.
+func WithJWKSRefresh(refresh, minRefresh time.Duration) Option {
+	return func(cfg *config) {
+		cfg.jwksRefresh = refresh
+		cfg.jwksMinRefresh = minRefresh
+	}
+}
+
+// WithAlgorithms restricts the accepted signing algorithms. By default every
+// algorithm matching the configured keys is accepted.
+func WithAlgorithms(algorithms ...string) Option {
+	return func(cfg *config) {
+		cfg.algorithms = algorithms
+	}
+}
+
+// WithIssuer only accepts tokens issued by issuer.
+func WithIssuer(issuer string) Option {
+	return func(cfg *config) {
+		cfg.parserOpts = append(cfg.parserOpts, jwtlib.WithIssuer(issuer))
+	}
+}
+
+// WithAudience only accepts tokens intended for audience.
+func WithAudience(audience string) Option {
+	return func(cfg *config) {
+		cfg.parserOpts = append(cfg.parserOpts, jwtlib.WithAudience(audience))
+	}
+}
+
+// WithLeeway tolerates clock skew when validating the time based claims.
+func WithLeeway(leeway time.Duration) Option {
+	return func(cfg *config) {
+		cfg.parserOpts = append(cfg.parserOpts, jwtlib.WithLeeway(leeway))
+	}
+}
+
+// WithClaims parses tokens into the claims returned by fn, typically a
+// pointer to a struct embedding jwt.RegisteredClaims, instead of
+// jwt.MapClaims. Retrieve them with auth.Claims.
+func WithClaims(fn func() jwtlib.Claims) Option {
+	return func(cfg *config) {
+		cfg.claims = fn
+	}
+}
+
+// WithRequired rejects requests without a token. By default they reach the
+// server unauthenticated, leaving it to resolvers to check auth.UserID.
+func WithRequired() Option {
+	return func(cfg *config) {
+		cfg.required = true
+	}
+}
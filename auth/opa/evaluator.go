@@ -0,0 +1,111 @@
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+type regoEvaluator struct {
+	query rego.PreparedEvalQuery
+}
+
+// Rego returns an Evaluator evaluating query, such as
+// "data.graphql.authz.allow", against the given Rego modules in process.
+// modules maps file names, used in error messages, to their source.
+func Rego(ctx context.Context, query string, modules map[string]string) (Evaluator, error) {
+	opts := []func(*rego.Rego){rego.Query(query)}
+	for name, src := range modules {
+		opts = append(opts, rego.Module(name, src))
+	}
+
+	prepared, err := rego.New(opts...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("opa: prepare %s: %w", query, err)
+	}
+	return &regoEvaluator{query: prepared}, nil
+}
+
+func (e *regoEvaluator) Evaluate(ctx context.Context, input *Input) (Decision, error) {
+	results, err := e.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return Decision{}, fmt.Errorf("opa: eval: %w", err)
+	}
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return Decision{}, nil
+	}
+	return decide(results[0].Expressions[0].Value)
+}
+
+type remoteEvaluator struct {
+	client *http.Client
+	url    string
+}
+
+// Remote returns an Evaluator querying the data API of an OPA server, url
+// being the document to evaluate such as
+// "http://localhost:8181/v1/data/graphql/authz". A nil client means one
+// timing out after 5s, so that a stuck server does not hang every request.
+func Remote(client *http.Client, url string) Evaluator {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &remoteEvaluator{client: client, url: url}
+}
+
+func (e *remoteEvaluator) Evaluate(ctx context.Context, input *Input) (Decision, error) {
+	body, err := json.Marshal(map[string]any{"input": input})
+	if err != nil {
+		return Decision{}, fmt.Errorf("opa: encode input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, fmt.Errorf("opa: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("opa: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("opa: %s answered %s", e.url, resp.Status)
+	}
+
+	var result struct {
+		Result any `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Decision{}, fmt.Errorf("opa: decode result: %w", err)
+	}
+	// An undefined document has no result, and denies access.
+	if result.Result == nil {
+		return Decision{}, nil
+	}
+	return decide(result.Result)
+}
+
+// decide interprets the value a policy produced.
+func decide(value any) (Decision, error) {
+	switch v := value.(type) {
+	case bool:
+		return Decision{Allow: v}, nil
+	case map[string]any:
+		allow, ok := v["allow"].(bool)
+		if !ok {
+			return Decision{}, fmt.Errorf("opa: result has no boolean allow key")
+		}
+		reason, _ := v["reason"].(string)
+		return Decision{Allow: allow, Reason: reason}, nil
+	default:
+		return Decision{}, fmt.Errorf("opa: unexpected result of type %T", value)
+	}
+}
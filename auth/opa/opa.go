@@ -0,0 +1,177 @@
+// Package opa authorizes GraphQL operations and fields against an Open
+// Policy Agent policy, evaluated in process or by a remote OPA server.
+package opa
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen-contrib/auth"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeForbidden is the extensions.code of the error returned for denied
+// operations and fields.
+const ErrCodeForbidden = "FORBIDDEN"
+
+// Input is the input document the policy is evaluated with.
+type Input struct {
+	Operation Operation `json:"operation"`
+	// Field is the field being resolved, nil when the policy is evaluated
+	// per operation.
+	Field *Field `json:"field,omitempty"`
+	User  User   `json:"user"`
+}
+
+// Operation describes the operation being executed.
+type Operation struct {
+	Name string `json:"name,omitempty"`
+	// Type is one of "query", "mutation" or "subscription".
+	Type      string         `json:"type"`
+	Variables map[string]any `json:"variables,omitempty"`
+	// Fields are the names of the root fields the operation selects,
+	// fragments included, when the policy is evaluated per operation.
+	// Policies should match them rather than Name, which clients choose.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Field describes the field being resolved.
+type Field struct {
+	Object    string         `json:"object"`
+	Name      string         `json:"name"`
+	Path      string         `json:"path"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// User is the caller, as reported by auth.UserID and auth.Claims.
+type User struct {
+	ID     string `json:"id,omitempty"`
+	Claims any    `json:"claims,omitempty"`
+}
+
+// Decision is the outcome of a policy evaluation. Policies may produce
+// either a boolean or an object with allow and reason keys.
+type Decision struct {
+	Allow bool `json:"allow"`
+	// Reason is reported to the client when access is denied.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Evaluator evaluates a policy, see Rego and Remote.
+type Evaluator interface {
+	Evaluate(ctx context.Context, input *Input) (Decision, error)
+}
+
+// Authorizer denies operations or fields the policy of its Evaluator does
+// not allow.
+type Authorizer struct {
+	eval Evaluator
+	cfg  *config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = &Authorizer{}
+
+// New returns an Authorizer evaluating policies with eval.
+func New(eval Evaluator, opts ...Option) *Authorizer {
+	return &Authorizer{
+		eval: eval,
+		cfg:  newConfig(opts...),
+	}
+}
+
+func (a *Authorizer) ExtensionName() string {
+	return "OPA"
+}
+
+func (a *Authorizer) Validate(schema graphql.ExecutableSchema) error {
+	if a.eval == nil {
+		return fmt.Errorf("opa: no evaluator")
+	}
+	return nil
+}
+
+func (a *Authorizer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if !a.cfg.perOperation {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	input := &Input{
+		Operation: operation(oc),
+		User:      user(ctx),
+	}
+	input.Operation.Variables = oc.Variables
+	if oc.Operation != nil {
+		for _, field := range graphql.CollectFields(oc, oc.Operation.SelectionSet, nil) {
+			input.Operation.Fields = append(input.Operation.Fields, field.Name)
+		}
+	}
+
+	if err := a.authorize(ctx, input); err != nil {
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{err}})
+	}
+	return next(ctx)
+}
+
+func (a *Authorizer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if a.cfg.perOperation || fc == nil || !a.cfg.fieldFilter(fc) {
+		return next(ctx)
+	}
+
+	input := &Input{
+		Operation: operation(graphql.GetOperationContext(ctx)),
+		Field: &Field{
+			Object:    fc.Object,
+			Name:      fc.Field.Name,
+			Path:      fc.Path().String(),
+			Arguments: fc.Args,
+		},
+		User: user(ctx),
+	}
+
+	if err := a.authorize(ctx, input); err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
+
+// authorize returns the error to report if input is denied, nil otherwise.
+func (a *Authorizer) authorize(ctx context.Context, input *Input) *gqlerror.Error {
+	decision, err := a.eval.Evaluate(ctx, input)
+	if err != nil {
+		a.cfg.errorHandler(ctx, err)
+		decision = Decision{}
+	}
+	if decision.Allow {
+		return nil
+	}
+
+	message := decision.Reason
+	if message == "" {
+		message = "forbidden"
+	}
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]any{"code": ErrCodeForbidden},
+	}
+}
+
+func operation(oc *graphql.OperationContext) Operation {
+	op := Operation{Name: gqlctx.OperationName(oc)}
+	if oc.Operation != nil {
+		op.Type = string(oc.Operation.Operation)
+	}
+	return op
+}
+
+func user(ctx context.Context) User {
+	claims, _ := auth.Claims[any](ctx)
+	return User{ID: auth.UserID(ctx), Claims: claims}
+}
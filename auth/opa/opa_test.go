@@ -0,0 +1,182 @@
+package opa_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/auth"
+	"github.com/99designs/gqlgen-contrib/auth/opa"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policy = `package graphql.authz
+
+default decision := {"allow": false, "reason": "not allowed"}
+
+decision := {"allow": true} if {
+	input.field.object == "Query"
+	input.field.name == "todos"
+}
+
+decision := {"allow": true} if {
+	input.field.name == "todo"
+	input.user.id == "admin"
+}
+
+decision := {"allow": true} if {
+	input.operation.type == "mutation"
+	input.user.claims.role == "editor"
+}
+
+decision := {"allow": true} if {
+	input.operation.type == "query"
+	not input.field
+}
+`
+
+func TestAuthorizer_Rego(t *testing.T) {
+	eval, err := opa.Rego(context.Background(), "data.graphql.authz.decision", map[string]string{
+		"authz.rego": policy,
+	})
+	require.NoError(t, err)
+
+	srv := newServer(opa.New(eval))
+
+	resp := doRequest(srv, "", nil, `{"query":"{ todos { id user { name } } }"}`)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(srv, "someone", nil, `{"query":"{ todo(id: \"`+graph.TodoA.ID+`\") { id } }"}`)
+	assert.Contains(t, resp.Body.String(), `"message":"not allowed","path":["todo"],"locations":[{"line":1,"column":3}],"extensions":{"code":"FORBIDDEN"}`)
+
+	resp = doRequest(srv, "admin", nil, `{"query":"{ todo(id: \"`+graph.TodoA.ID+`\") { id } }"}`)
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.Contains(t, resp.Body.String(), graph.TodoA.ID)
+
+	mutation := `{"query":"mutation { createTodo(input: {text: \"x\", userId: \"1\"}) { id } }"}`
+	resp = doRequest(srv, "someone", map[string]any{"role": "viewer"}, mutation)
+	assert.Contains(t, resp.Body.String(), "FORBIDDEN")
+	resp = doRequest(srv, "someone", map[string]any{"role": "editor"}, mutation)
+	assert.NotContains(t, resp.Body.String(), "errors")
+}
+
+func TestAuthorizer_PerOperation(t *testing.T) {
+	eval, err := opa.Rego(context.Background(), "data.graphql.authz.decision", map[string]string{
+		"authz.rego": policy,
+	})
+	require.NoError(t, err)
+
+	srv := newServer(opa.New(eval, opa.WithPerOperation()))
+
+	resp := doRequest(srv, "", nil, `{"query":"{ todo(id: \"`+graph.TodoA.ID+`\") { id } }"}`)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(srv, "", nil, `{"query":"mutation { createTodo(input: {text: \"x\", userId: \"1\"}) { id } }"}`)
+	assert.Equal(t, `{"errors":[{"message":"not allowed","extensions":{"code":"FORBIDDEN"}}],"data":null}`, resp.Body.String())
+}
+
+func TestAuthorizer_PerOperationFields(t *testing.T) {
+	eval, err := opa.Rego(context.Background(), "data.graphql.operations.allow", map[string]string{
+		"operations.rego": `package graphql.operations
+
+default allow := false
+
+allow if {
+	input.operation.type == "query"
+	count([f | some f in input.operation.fields; f == "todo"]) == 0
+}
+`,
+	})
+	require.NoError(t, err)
+
+	srv := newServer(opa.New(eval, opa.WithPerOperation()))
+
+	resp := doRequest(srv, "", nil, `{"query":"query Todos { todos { id } }"}`)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(srv, "", nil, `{"query":"query Todos { ...F } fragment F on Query { todo(id: \"`+graph.TodoA.ID+`\") { id } }"}`)
+	assert.Contains(t, resp.Body.String(), "FORBIDDEN", "renamed operations are matched by their fields")
+}
+
+func TestAuthorizer_Remote(t *testing.T) {
+	var inputs []opa.Input
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/data/graphql/authz/allow", r.URL.Path)
+
+		var body struct {
+			Input opa.Input `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		inputs = append(inputs, body.Input)
+
+		switch body.Input.Field.Name {
+		case "todos":
+			_, _ = w.Write([]byte(`{"result":true}`))
+		case "todo":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	var errs []error
+	srv := newServer(opa.New(opa.Remote(server.Client(), server.URL+"/v1/data/graphql/authz/allow"),
+		opa.WithFieldFilter(func(fc *graphql.FieldContext) bool {
+			return fc.Object == "Query" || fc.Object == "Mutation"
+		}),
+		opa.WithErrorHandler(func(ctx context.Context, err error) {
+			errs = append(errs, err)
+		}),
+	))
+
+	resp := doRequest(srv, "bob", nil, `{"query":"query List { todos(status: OPEN) { id } }"}`)
+	assert.NotContains(t, resp.Body.String(), "errors")
+	require.Len(t, inputs, 1)
+	assert.Equal(t, opa.Operation{Name: "List", Type: "query"}, inputs[0].Operation)
+	assert.Equal(t, &opa.Field{Object: "Query", Name: "todos", Path: "todos", Arguments: map[string]any{"status": "OPEN"}}, inputs[0].Field)
+	assert.Equal(t, "bob", inputs[0].User.ID)
+
+	resp = doRequest(srv, "bob", nil, `{"query":"{ todo(id: \"`+graph.TodoA.ID+`\") { id } }"}`)
+	assert.Contains(t, resp.Body.String(), `"message":"forbidden"`)
+	assert.Empty(t, errs)
+
+	resp = doRequest(srv, "bob", nil, `{"query":"mutation { createTodo(input: {text: \"x\", userId: \"1\"}) { id } }"}`)
+	assert.Contains(t, resp.Body.String(), "FORBIDDEN")
+	assert.Len(t, errs, 1)
+}
+
+func TestRego_InvalidPolicy(t *testing.T) {
+	_, err := opa.Rego(context.Background(), "data.graphql.authz.allow", map[string]string{
+		"authz.rego": "package graphql.authz\n\nallow if {",
+	})
+	assert.Error(t, err)
+}
+
+func newServer(authorizer *opa.Authorizer) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(authorizer)
+	return srv
+}
+
+func doRequest(handler http.Handler, userID string, claims any, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		r = r.WithContext(auth.WithUser(r.Context(), userID, claims))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,56 @@
+package opa
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type config struct {
+	perOperation bool
+	fieldFilter  func(fc *graphql.FieldContext) bool
+	errorHandler func(ctx context.Context, err error)
+}
+
+// Option is anything that can configure Authorizer.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		fieldFilter: func(fc *graphql.FieldContext) bool {
+			return fc.IsResolver
+		},
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithPerOperation evaluates the policy once per operation, before it is
+// executed, instead of once per field.
+func WithPerOperation() Option {
+	return func(cfg *config) {
+		cfg.perOperation = true
+	}
+}
+
+// WithFieldFilter selects the fields the policy is evaluated for. By default
+// only fields backed by a resolver are, trivial fields inheriting the
+// decision taken for their parent.
+func WithFieldFilter(fn func(fc *graphql.FieldContext) bool) Option {
+	return func(cfg *config) {
+		cfg.fieldFilter = fn
+	}
+}
+
+// WithErrorHandler is called when the policy cannot be evaluated, in which
+// case access is denied.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
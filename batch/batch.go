@@ -0,0 +1,183 @@
+// Package batch executes requests whose JSON body is an array of
+// operations, as sent by Apollo's BatchHttpLink.
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/99designs/gqlgen-contrib/limits"
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Entry locates an operation within its batch.
+type Entry struct {
+	Index int
+	Size  int
+}
+
+type entryKey struct{}
+
+// ForContext returns the Entry of the operation in ctx, false if it was not
+// batched.
+func ForContext(ctx context.Context) (Entry, bool) {
+	entry, ok := ctx.Value(entryKey{}).(Entry)
+	return entry, ok
+}
+
+// Transport is a gqlgen transport for batched POST requests. It must be
+// added before transport.POST, which keeps handling single operations.
+//
+// Each operation of a batch is executed on its own, in order, with its own
+// operation context: extensions see as many operations as the batch holds,
+// so one failing operation only fails its own entry, in metrics too. The
+// response is the array of their responses, with status 200.
+//
+// Batch sizes are observed in the graphql_batch_size histogram, and batches
+// over the maximum size answered 413 with the BATCH_TOO_LARGE code of the
+// limits package. Subscriptions cannot be batched.
+type Transport struct {
+	maxBatchSize int
+	histogram    prometheusclient.Histogram
+	registerer   prometheusclient.Registerer
+}
+
+var _ graphql.Transport = Transport{}
+
+// New returns a Transport whose histogram is registered on the configured
+// registerer.
+func New(opts ...Option) Transport {
+	cfg := newConfig(opts...)
+
+	histogram := prometheusclient.NewHistogram(prometheusclient.HistogramOpts{
+		Namespace:   cfg.namespace,
+		Subsystem:   cfg.subsystem,
+		Name:        "graphql_batch_size",
+		Help:        "Number of operations in batched requests.",
+		ConstLabels: cfg.constLabels,
+		Buckets:     prometheusclient.ExponentialBuckets(1, 2, 7),
+	})
+	cfg.registerer.MustRegister(histogram)
+
+	return Transport{
+		maxBatchSize: cfg.maxBatchSize,
+		histogram:    histogram,
+		registerer:   cfg.registerer,
+	}
+}
+
+// UnRegister removes the histogram from the registerer it was registered on.
+func (t Transport) UnRegister() {
+	t.registerer.Unregister(t.histogram)
+}
+
+// Supports reports whether r is a JSON POST request whose body starts with
+// an array, peeking at the body without consuming it.
+func (t Transport) Supports(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" || r.Method != http.MethodPost || r.Body == nil {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return false
+	}
+
+	br := bufio.NewReader(r.Body)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{br, r.Body}
+
+	for i := 1; ; i++ {
+		buf, err := br.Peek(i)
+		if err != nil {
+			return false
+		}
+		switch buf[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+func (t Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	start := graphql.Now()
+
+	var batch []*graphql.RawParams
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&batch); err != nil {
+		writeError(w, http.StatusBadRequest, &gqlerror.Error{Message: "json request body could not be decoded: " + err.Error()})
+		return
+	}
+	if len(batch) == 0 {
+		writeError(w, http.StatusBadRequest, &gqlerror.Error{Message: "batch is empty"})
+		return
+	}
+
+	t.histogram.Observe(float64(len(batch)))
+	if t.maxBatchSize > 0 && len(batch) > t.maxBatchSize {
+		writeError(w, http.StatusRequestEntityTooLarge, &gqlerror.Error{
+			Message:    "batch has more than " + strconv.Itoa(t.maxBatchSize) + " operations",
+			Extensions: map[string]any{"code": limits.ErrCodeBatchTooLarge},
+		})
+		return
+	}
+
+	readTime := graphql.TraceTiming{Start: start, End: graphql.Now()}
+	responses := make([]*graphql.Response, len(batch))
+	for i, params := range batch {
+		if params == nil {
+			params = &graphql.RawParams{}
+		}
+		params.Headers = r.Header
+		params.ReadTime = readTime
+		entryCtx := context.WithValue(ctx, entryKey{}, Entry{Index: i, Size: len(batch)})
+		responses[i] = execute(entryCtx, exec, params)
+	}
+
+	writeJSON(w, responses)
+}
+
+func execute(ctx context.Context, exec graphql.GraphExecutor, params *graphql.RawParams) *graphql.Response {
+	rc, errs := exec.CreateOperationContext(ctx, params)
+	if errs != nil {
+		return exec.DispatchError(graphql.WithOperationContext(ctx, rc), errs)
+	}
+	if rc.Operation != nil && rc.Operation.Operation == ast.Subscription {
+		return exec.DispatchError(graphql.WithOperationContext(ctx, rc), gqlerror.List{gqlerror.Errorf("subscriptions cannot be batched")})
+	}
+
+	responses, ctx := exec.DispatchOperation(ctx, rc)
+	return responses(ctx)
+}
+
+// writeError answers errors concerning the batch as a whole, which are not
+// dispatched to extensions since they belong to no operation.
+func writeError(w http.ResponseWriter, status int, err *gqlerror.Error) {
+	w.WriteHeader(status)
+	writeJSON(w, &graphql.Response{Errors: gqlerror.List{err}})
+}
+
+func writeJSON(w io.Writer, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,88 @@
+package batch_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/batch"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/prometheus"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tr := batch.New(batch.WithRegisterer(registry), batch.WithMaxBatchSize(3))
+	defer tr.UnRegister()
+	tracer := prometheus.New(prometheus.WithRegisterer(registry))
+	defer tracer.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(tr)
+	srv.AddTransport(transport.POST{})
+	srv.Use(tracer)
+
+	var entries []batch.Entry
+	srv.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		if entry, ok := batch.ForContext(ctx); ok {
+			entries = append(entries, entry)
+		}
+		return next(ctx)
+	})
+
+	resp := doRequest(srv, ` [{"query":"query Todos { todos { id } }"}, {"query":"query Missing { todo(id: \"unknown\") { id } }"}, {"query":"{ unknown }"}]`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var responses []struct {
+		Data   json.RawMessage
+		Errors []struct{ Message string }
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &responses))
+	require.Len(t, responses, 3)
+	assert.Empty(t, responses[0].Errors)
+	assert.NotEqual(t, "null", string(responses[0].Data))
+	assert.Len(t, responses[1].Errors, 1)
+	assert.Len(t, responses[2].Errors, 1)
+	assert.Equal(t, []batch.Entry{{Index: 0, Size: 3}, {Index: 1, Size: 3}}, entries)
+
+	resp = doRequest(srv, `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.True(t, strings.HasPrefix(resp.Body.String(), "{"))
+
+	resp = doRequest(srv, `[{"query":"{ todos { id } }"}, {"query":"{ todos { id } }"}, {"query":"{ todos { id } }"}, {"query":"{ todos { id } }"}]`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"BATCH_TOO_LARGE"`)
+
+	resp = doRequest(srv, `[]`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	metrics := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := metrics.Body.String()
+	assert.Contains(t, body, "graphql_batch_size_count 2")
+	assert.Contains(t, body, "graphql_batch_size_sum 7")
+	assert.Contains(t, body, `graphql_request_completed_total{operation_name="Todos",operation_type="query"} 1`)
+	assert.Contains(t, body, `graphql_request_duration_ms_count{err_code="",exitStatus="success",operation_name="Todos",operation_type="query"} 1`)
+	assert.Contains(t, body, `graphql_request_errors_total{error_code="",operation_name="Missing"} 1`)
+	assert.NotContains(t, body, `exitStatus="failure",operation_name="Todos"`)
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,67 @@
+package batch
+
+import (
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	maxBatchSize int
+	namespace    string
+	subsystem    string
+	constLabels  prometheusclient.Labels
+	registerer   prometheusclient.Registerer
+}
+
+// Option is anything that can configure Transport.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		maxBatchSize: 10,
+		registerer:   prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithMaxBatchSize rejects batches of more than n operations, 10 by default.
+// 0 lifts the limit.
+func WithMaxBatchSize(n int) Option {
+	return func(cfg *config) {
+		cfg.maxBatchSize = n
+	}
+}
+
+// WithNamespace prefixes the metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metric on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,259 @@
+// Package cachecontrol computes Apollo compatible cache policies from
+// @cacheControl(maxAge: Int, scope: CacheControlScope) hints in the schema.
+package cachecontrol
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Hint is the cache hint of a single resolved field.
+type Hint struct {
+	Path   ast.Path `json:"path"`
+	MaxAge int      `json:"maxAge"`
+	Scope  string   `json:"scope,omitempty"`
+}
+
+// Extension is the value of extensions.cacheControl.
+type Extension struct {
+	Version int    `json:"version"`
+	Hints   []Hint `json:"hints"`
+}
+
+// CacheControl is a gqlgen handler extension computing the cache policy of
+// each query from the hints of the fields it resolves, following Apollo
+// Server: a field hint wins over a hint on the type it returns, root fields
+// and fields returning composite types default to WithDefaultMaxAge, the
+// response maxAge is the smallest of them and any PRIVATE hint makes the
+// whole response private.
+//
+// The policy is sent as a Cache-Control header when the handler is wrapped
+// in Middleware. Mutations, subscriptions and responses with errors get
+// "no-store".
+type CacheControl struct {
+	cfg    config
+	schema *ast.Schema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = (*CacheControl)(nil)
+
+const scopePrivate = "PRIVATE"
+
+type policyKey struct{}
+
+// policy collects the hints of one operation.
+type policy struct {
+	mu      sync.Mutex
+	maxAge  int
+	private bool
+	hints   []Hint
+}
+
+// New returns a CacheControl.
+func New(opts ...Option) *CacheControl {
+	c := &CacheControl{
+		cfg: config{hints: true},
+	}
+
+	for _, opt := range opts {
+		opt(&c.cfg)
+	}
+
+	return c
+}
+
+func (c *CacheControl) ExtensionName() string {
+	return "CacheControl"
+}
+
+func (c *CacheControl) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema.Schema()
+	return nil
+}
+
+func (c *CacheControl) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	return next(context.WithValue(ctx, policyKey{}, &policy{maxAge: -1}))
+}
+
+func (c *CacheControl) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	p, ok := ctx.Value(policyKey{}).(*policy)
+	fc := graphql.GetFieldContext(ctx)
+	if !ok || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+
+	if hint, ok := c.hint(fc); ok {
+		p.mu.Lock()
+		p.private = p.private || hint.Scope == scopePrivate
+		if hint.MaxAge >= 0 {
+			if p.maxAge < 0 || hint.MaxAge < p.maxAge {
+				p.maxAge = hint.MaxAge
+			}
+			if c.cfg.hints {
+				p.hints = append(p.hints, hint)
+			}
+		}
+		p.mu.Unlock()
+	}
+
+	return next(ctx)
+}
+
+func (c *CacheControl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil {
+		return res
+	}
+
+	h, hasHeader := ctx.Value(headerKey{}).(*header)
+	p, ok := ctx.Value(policyKey{}).(*policy)
+	if !ok || !graphql.HasOperationContext(ctx) {
+		if hasHeader {
+			h.set("no-store")
+		}
+		return res
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if c.cfg.hints && len(p.hints) != 0 {
+		sort.Slice(p.hints, func(i, j int) bool {
+			return p.hints[i].Path.String() < p.hints[j].Path.String()
+		})
+		if res.Extensions == nil {
+			res.Extensions = map[string]interface{}{}
+		}
+		res.Extensions["cacheControl"] = Extension{Version: 1, Hints: p.hints}
+	}
+
+	if hasHeader {
+		oc := graphql.GetOperationContext(ctx)
+		query := oc.Operation != nil && oc.Operation.Operation == ast.Query
+		if !query || len(res.Errors) != 0 || p.maxAge <= 0 {
+			h.set("no-store")
+		} else {
+			scope := "public"
+			if p.private {
+				scope = "private"
+			}
+			h.set(fmt.Sprintf("max-age=%d, %s", p.maxAge, scope))
+		}
+	}
+
+	return res
+}
+
+// Policy is the cache policy collected from the hints of the fields an
+// operation resolved. MaxAge is -1 when no field had a maxAge hint.
+type Policy struct {
+	MaxAge  int    `json:"maxAge"`
+	Private bool   `json:"private,omitempty"`
+	Hints   []Hint `json:"hints,omitempty"`
+}
+
+// PolicyFor returns the policy collected so far for the operation of ctx,
+// false if CacheControl is not in use.
+func PolicyFor(ctx context.Context) (Policy, bool) {
+	p, ok := ctx.Value(policyKey{}).(*policy)
+	if !ok {
+		return Policy{}, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Policy{MaxAge: p.maxAge, Private: p.private, Hints: append([]Hint(nil), p.hints...)}, true
+}
+
+// SetPolicy replaces the policy collected for the operation of ctx with
+// collected, for responses served without resolving their fields, such as
+// cached ones. It reports false if CacheControl is not in use.
+func SetPolicy(ctx context.Context, collected Policy) bool {
+	p, ok := ctx.Value(policyKey{}).(*policy)
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxAge, p.private, p.hints = collected.MaxAge, collected.Private, append([]Hint(nil), collected.Hints...)
+	return true
+}
+
+// Detach returns a copy of ctx collecting a policy of its own, for
+// executions outside the operation of ctx whose hints must not count
+// towards its policy.
+func Detach(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(policyKey{}).(*policy); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, policyKey{}, &policy{maxAge: -1})
+}
+
+// hint returns the hint applying to the field in fc, if any. MaxAge is -1
+// for scalar fields that only set a scope, since they keep the maxAge of
+// their parent.
+func (c *CacheControl) hint(fc *graphql.FieldContext) (Hint, bool) {
+	maxAge, scope, ok := directiveHint(fc.Field.Definition.Directives)
+	if !ok {
+		if def := c.schema.Types[fc.Field.Definition.Type.Name()]; def != nil {
+			maxAge, scope, ok = directiveHint(def.Directives)
+		}
+	}
+
+	if maxAge < 0 && c.inheritsDefault(fc) {
+		maxAge = int(c.cfg.defaultMaxAge / time.Second)
+		ok = true
+	}
+	if !ok {
+		return Hint{}, false
+	}
+
+	return Hint{Path: fc.Path(), MaxAge: maxAge, Scope: scope}, true
+}
+
+// inheritsDefault reports whether the field is a root field or returns a
+// composite type, which are the fields WithDefaultMaxAge applies to.
+func (c *CacheControl) inheritsDefault(fc *graphql.FieldContext) bool {
+	if len(fc.Path()) == 1 {
+		return true
+	}
+
+	def := c.schema.Types[fc.Field.Definition.Type.Name()]
+	return def != nil && (def.Kind == ast.Object || def.Kind == ast.Interface || def.Kind == ast.Union)
+}
+
+// directiveHint reads the @cacheControl directive in directives. A missing
+// maxAge is returned as -1.
+func directiveHint(directives ast.DirectiveList) (int, string, bool) {
+	d := directives.ForName("cacheControl")
+	if d == nil {
+		return -1, "", false
+	}
+
+	maxAge := -1
+	if arg := d.Arguments.ForName("maxAge"); arg != nil && arg.Value != nil {
+		if v, err := strconv.Atoi(arg.Value.Raw); err == nil {
+			maxAge = v
+		}
+	}
+
+	var scope string
+	if arg := d.Arguments.ForName("scope"); arg != nil && arg.Value != nil {
+		scope = arg.Value.Raw
+	}
+
+	return maxAge, scope, true
+}
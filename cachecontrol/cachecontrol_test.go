@@ -0,0 +1,81 @@
+package cachecontrol_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/cachecontrol"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheControl(t *testing.T) {
+	specs := []struct {
+		SpecName  string
+		Query     string
+		Options   []cachecontrol.Option
+		Header    string
+		Extension string
+	}{
+		{
+			SpecName:  "hinted root field",
+			Query:     `{ todos { id } }`,
+			Header:    "max-age=60, public",
+			Extension: `"cacheControl":{"version":1,"hints":[{"path":["todos"],"maxAge":60}]}`,
+		},
+		{
+			SpecName:  "type hint",
+			Query:     `{ todos { user { name } } }`,
+			Header:    "max-age=30, private",
+			Extension: `{"path":["todos",0,"user"],"maxAge":30,"scope":"PRIVATE"}`,
+		},
+		{
+			SpecName:  "unhinted root field",
+			Query:     `{ todo(id: "0be25fcf-20e6-4a6d-b0f9-7804224ef20e") { id } }`,
+			Header:    "no-store",
+			Extension: `"hints":[{"path":["todo"],"maxAge":0}]`,
+		},
+		{
+			SpecName:  "default max age",
+			Query:     `{ todo(id: "0be25fcf-20e6-4a6d-b0f9-7804224ef20e") { id } }`,
+			Options:   []cachecontrol.Option{cachecontrol.WithDefaultMaxAge(10 * time.Second), cachecontrol.WithHintsExtension(false)},
+			Header:    "max-age=10, public",
+			Extension: "",
+		},
+		{
+			SpecName:  "errors",
+			Query:     `{ todo(id: "unknown") { id } }`,
+			Options:   []cachecontrol.Option{cachecontrol.WithDefaultMaxAge(10 * time.Second)},
+			Header:    "no-store",
+			Extension: `"hints":[{"path":["todo"],"maxAge":10}]`,
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			srv := handler.New(graph.NewExecutableSchema(graph.Config{
+				Resolvers: &graph.Resolver{},
+			}))
+			srv.AddTransport(transport.GET{})
+			srv.Use(cachecontrol.New(spec.Options...))
+
+			r := httptest.NewRequest(http.MethodGet, "/query?query="+url.QueryEscape(spec.Query), nil)
+			w := httptest.NewRecorder()
+			cachecontrol.Middleware(srv).ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, spec.Header, w.Header().Get("Cache-Control"))
+			if spec.Extension != "" {
+				assert.Contains(t, w.Body.String(), spec.Extension)
+			} else {
+				assert.NotContains(t, w.Body.String(), "cacheControl")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cachecontrol
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type headerKey struct{}
+
+// header carries the computed Cache-Control value from the extension to the
+// response writer of Middleware.
+type header struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (h *header) set(value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.value = value
+}
+
+func (h *header) get() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.value
+}
+
+// Capture returns a copy of ctx in which CacheControl records the
+// Cache-Control value of the response, and a func returning it once the
+// response is computed, "" before. It lets transports writing their own
+// headers use the policy without Middleware.
+func Capture(ctx context.Context) (context.Context, func() string) {
+	h := &header{}
+	return context.WithValue(ctx, headerKey{}, h), h.get
+}
+
+// Middleware sets the Cache-Control response header computed by CacheControl.
+// It must wrap the GraphQL handler.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, value := Capture(r.Context())
+		next.ServeHTTP(&responseWriter{ResponseWriter: w, value: value}, r.WithContext(ctx))
+	})
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	value       func() string
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if value := w.value(); value != "" {
+			w.Header().Set("Cache-Control", value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
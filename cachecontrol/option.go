@@ -0,0 +1,28 @@
+package cachecontrol
+
+import "time"
+
+type config struct {
+	defaultMaxAge time.Duration
+	hints         bool
+}
+
+// Option is anything that can configure CacheControl.
+type Option func(cfg *config)
+
+// WithDefaultMaxAge sets the maxAge of root fields and fields returning
+// objects, interfaces or unions that have no hint. Defaults to 0, which
+// makes responses uncacheable unless every such field is hinted.
+func WithDefaultMaxAge(maxAge time.Duration) Option {
+	return func(cfg *config) {
+		cfg.defaultMaxAge = maxAge
+	}
+}
+
+// WithHintsExtension controls whether the per-field hints are returned as
+// extensions.cacheControl. Enabled by default.
+func WithHintsExtension(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.hints = enabled
+	}
+}
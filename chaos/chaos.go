@@ -0,0 +1,249 @@
+// Package chaos injects latency and errors into resolvers, for testing how
+// clients and servers behave when dependencies are slow or failing. Faults
+// are configured at runtime with Injector.Set or over HTTP with
+// Injector.Handler, and are only injected while the Injector is enabled.
+//
+// It is meant for staging environments: never enable it in production.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeFault is the default code of the injected errors.
+const ErrCodeFault = "CHAOS_FAULT"
+
+// Fault is a fault injected into the resolvers of the fields it matches.
+type Fault struct {
+	// Object and Field are path.Match patterns the type and name of fields
+	// must match, such as Query and todo*. Empty patterns match any name.
+	Object string
+	Field  string
+	// Probability is the chance, between 0 and 1, of the fault being
+	// injected into each resolution of a matching field.
+	Probability float64
+	// Latency delays the resolver.
+	Latency time.Duration
+	// Error, if not empty, is the message of the error returned instead of
+	// running the resolver, after the latency.
+	Error string
+	// Code is the extensions code of the error, ErrCodeFault if empty.
+	Code string
+}
+
+type faultJSON struct {
+	Object      string  `json:"object,omitempty"`
+	Field       string  `json:"field,omitempty"`
+	Probability float64 `json:"probability"`
+	Latency     string  `json:"latency,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	Code        string  `json:"code,omitempty"`
+}
+
+// MarshalJSON encodes the latency as a time.Duration string such as
+// "250ms".
+func (f Fault) MarshalJSON() ([]byte, error) {
+	j := faultJSON{Object: f.Object, Field: f.Field, Probability: f.Probability, Error: f.Error, Code: f.Code}
+	if f.Latency != 0 {
+		j.Latency = f.Latency.String()
+	}
+	return json.Marshal(j)
+}
+
+func (f *Fault) UnmarshalJSON(data []byte) error {
+	var j faultJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*f = Fault{Object: j.Object, Field: j.Field, Probability: j.Probability, Error: j.Error, Code: j.Code}
+	if j.Latency != "" {
+		var err error
+		if f.Latency, err = time.ParseDuration(j.Latency); err != nil {
+			return fmt.Errorf("latency: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f Fault) validate() error {
+	for _, pattern := range []string{f.Object, f.Field} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("chaos: pattern %q: %w", pattern, err)
+		}
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		return fmt.Errorf("chaos: probability %v is not between 0 and 1", f.Probability)
+	}
+	if f.Latency < 0 {
+		return fmt.Errorf("chaos: negative latency %s", f.Latency)
+	}
+	return nil
+}
+
+func (f Fault) matches(fc *graphql.FieldContext) bool {
+	return match(f.Object, fc.Object) && match(f.Field, fc.Field.Name)
+}
+
+func match(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// State is the configuration of an Injector.
+type State struct {
+	Enabled bool    `json:"enabled"`
+	Faults  []Fault `json:"faults"`
+}
+
+// Injector is a gqlgen handler extension injecting faults into the
+// resolvers of the fields they match while it is enabled. Every matching
+// fault is rolled for: their latencies add up, and the first error is
+// returned.
+//
+// Injected faults are counted in graphql_chaos_faults_injected_total{object,
+// field,fault}, fault being latency or error.
+type Injector struct {
+	cfg     *config
+	counter *prometheusclient.CounterVec
+
+	mu    sync.RWMutex
+	state State
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Injector{}
+
+// New returns an Injector whose counter is registered on the configured
+// registerer. It panics if a fault given to WithFault is invalid.
+func New(opts ...Option) *Injector {
+	cfg := newConfig(opts...)
+
+	i := &Injector{
+		cfg: cfg,
+		counter: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_chaos_faults_injected_total",
+			Help:        "Total number of faults injected into resolvers, by fault.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"object", "field", "fault"}),
+	}
+	if err := i.Set(State{Enabled: cfg.enabled, Faults: cfg.faults}); err != nil {
+		panic(err)
+	}
+	cfg.registerer.MustRegister(i.counter)
+
+	return i
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (i *Injector) UnRegister() {
+	i.cfg.registerer.Unregister(i.counter)
+}
+
+func (i *Injector) ExtensionName() string {
+	return "Chaos"
+}
+
+func (i *Injector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// State returns the current configuration.
+func (i *Injector) State() State {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return State{Enabled: i.state.Enabled, Faults: append([]Fault{}, i.state.Faults...)}
+}
+
+// Set replaces the configuration, leaving it unchanged if a fault is
+// invalid.
+func (i *Injector) Set(state State) error {
+	for _, f := range state.Faults {
+		if err := f.validate(); err != nil {
+			return err
+		}
+	}
+	state.Faults = append([]Fault{}, state.Faults...)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.state = state
+	return nil
+}
+
+// Enable starts injecting the configured faults.
+func (i *Injector) Enable() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.state.Enabled = true
+}
+
+// Disable stops injecting faults, keeping them configured.
+func (i *Injector) Disable() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.state.Enabled = false
+}
+
+func (i *Injector) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	var latency time.Duration
+	var fault *Fault
+	i.mu.RLock()
+	if i.state.Enabled {
+		for k, f := range i.state.Faults {
+			if !f.matches(fc) || i.cfg.random() >= f.Probability {
+				continue
+			}
+			latency += f.Latency
+			if fault == nil && f.Error != "" {
+				fault = &i.state.Faults[k]
+			}
+		}
+	}
+	i.mu.RUnlock()
+
+	if latency > 0 {
+		i.counter.WithLabelValues(fc.Object, fc.Field.Name, "latency").Inc()
+		timer := time.NewTimer(latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if fault != nil {
+		i.counter.WithLabelValues(fc.Object, fc.Field.Name, "error").Inc()
+		code := fault.Code
+		if code == "" {
+			code = ErrCodeFault
+		}
+		return nil, &gqlerror.Error{
+			Message:    fault.Error,
+			Extensions: map[string]any{"code": code},
+		}
+	}
+
+	return next(ctx)
+}
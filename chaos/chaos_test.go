@@ -0,0 +1,129 @@
+package chaos_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/chaos"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjector(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	injector := chaos.New(
+		chaos.WithRegisterer(registry),
+		chaos.WithRandom(func() float64 { return 0.5 }),
+		chaos.WithFault(chaos.Fault{Object: "Query", Field: "todo", Probability: 1, Latency: 20 * time.Millisecond, Error: "todo is down"}),
+		chaos.WithFault(chaos.Fault{Field: "todos", Probability: 0.4, Error: "never injected"}),
+	)
+	defer injector.UnRegister()
+	srv := newServer(injector)
+
+	query := `{"query":"{ todos { id } todo(id: \"` + graph.TodoA.ID + `\") { id } }"}`
+	assert.NotContains(t, doRequest(srv, query).Body.String(), "errors", "disabled by default")
+
+	injector.Enable()
+	start := time.Now()
+	body := doRequest(srv, query).Body.String()
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Contains(t, body, `"message":"todo is down"`)
+	assert.Contains(t, body, `"code":"CHAOS_FAULT"`)
+	assert.NotContains(t, body, "never injected")
+	assert.Contains(t, body, `"todos":[`)
+
+	injector.Disable()
+	assert.NotContains(t, doRequest(srv, query).Body.String(), "errors")
+
+	metrics := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, series := range []string{
+		`graphql_chaos_faults_injected_total{fault="latency",field="todo",object="Query"} 1`,
+		`graphql_chaos_faults_injected_total{fault="error",field="todo",object="Query"} 1`,
+	} {
+		assert.Contains(t, metrics.Body.String(), series)
+	}
+}
+
+func TestInjector_Handler(t *testing.T) {
+	injector := chaos.New(chaos.WithRegisterer(prometheusclient.NewRegistry()))
+	defer injector.UnRegister()
+	admin := injector.Handler()
+	srv := newServer(injector)
+
+	w := adminRequest(admin, http.MethodPut, `{"enabled":true,"faults":[{"object":"Todo","field":"comp*","probability":1,"error":"flaky","code":"UNAVAILABLE"}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var state chaos.State
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+	assert.Equal(t, chaos.State{Enabled: true, Faults: []chaos.Fault{
+		{Object: "Todo", Field: "comp*", Probability: 1, Error: "flaky", Code: "UNAVAILABLE"},
+	}}, state)
+	assert.Equal(t, state, injector.State())
+
+	body := doRequest(srv, `{"query":"{ todos { id completed } }"}`).Body.String()
+	assert.Contains(t, body, `"code":"UNAVAILABLE"`)
+
+	assert.Equal(t, http.StatusBadRequest, adminRequest(admin, http.MethodPut, `{"faults":[{"probability":2}]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, adminRequest(admin, http.MethodPut, `{"faults":[{"latency":"soon"}]}`).Code)
+	assert.Equal(t, state, injector.State(), "invalid states are rejected")
+
+	w = adminRequest(admin, http.MethodGet, "")
+	assert.JSONEq(t, `{"enabled":true,"faults":[{"object":"Todo","field":"comp*","probability":1,"error":"flaky","code":"UNAVAILABLE"}]}`, w.Body.String())
+
+	require.Equal(t, http.StatusOK, adminRequest(admin, http.MethodDelete, "").Code)
+	assert.Equal(t, chaos.State{Faults: []chaos.Fault{}}, injector.State())
+	assert.NotContains(t, doRequest(srv, `{"query":"{ todos { id completed } }"}`).Body.String(), "errors")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, adminRequest(admin, http.MethodPost, "").Code)
+}
+
+func TestInjector_Latency(t *testing.T) {
+	injector := chaos.New(
+		chaos.WithRegisterer(prometheusclient.NewRegistry()),
+		chaos.WithEnabled(true),
+		chaos.WithFault(chaos.Fault{Object: "Query", Probability: 1, Latency: time.Hour}),
+	)
+	defer injector.UnRegister()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id } }"}`)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	newServer(injector).ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), context.DeadlineExceeded.Error(), "latency ends with the request")
+}
+
+func newServer(injector *chaos.Injector) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(injector)
+	return srv
+}
+
+func adminRequest(handler http.Handler, method, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, chaos.AdminPath, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
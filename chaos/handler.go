@@ -0,0 +1,40 @@
+package chaos
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminPath is the conventional path to serve Handler on.
+const AdminPath = "/debug/graphql/chaos"
+
+// Handler serves the State as JSON to GET requests, replaces it with the
+// State in the body of PUT requests, and disables the Injector and removes
+// its faults on DELETE requests. It is meant for an internal port only, as
+// anyone reaching it can fail every request.
+func (i *Injector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var state State
+			if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := i.Set(state); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			_ = i.Set(State{})
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(i.State())
+	})
+}
@@ -0,0 +1,87 @@
+package chaos
+
+import (
+	"math/rand/v2"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	enabled bool
+	faults  []Fault
+	random  func() float64
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Injector.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		random:     rand.Float64,
+		registerer: prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithFault configures f initially. It can be given several times.
+func WithFault(f Fault) Option {
+	return func(cfg *config) {
+		cfg.faults = append(cfg.faults, f)
+	}
+}
+
+// WithEnabled sets whether faults are injected initially. Injectors start
+// disabled by default.
+func WithEnabled(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.enabled = enabled
+	}
+}
+
+// WithRandom sets the source of the numbers in [0, 1) compared with the
+// probability of faults, math/rand/v2 by default.
+func WithRandom(fn func() float64) Option {
+	return func(cfg *config) {
+		cfg.random = fn
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
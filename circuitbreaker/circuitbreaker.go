@@ -0,0 +1,266 @@
+// Package circuitbreaker stops calling resolvers whose downstream
+// dependency keeps failing, failing them fast until it recovers.
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeServiceUnavailable is the extensions.code of the error returned by
+// resolvers whose breaker is open.
+const ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+
+// State is the state of a breaker.
+type State int
+
+const (
+	// Closed breakers let resolvers run, counting their failures.
+	Closed State = iota
+	// HalfOpen breakers let a few probes run, closing on their success.
+	HalfOpen
+	// Open breakers fail resolvers without running them.
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half_open"
+	case Open:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Hook is called when the breaker named key goes from one state to another.
+type Hook func(key string, from, to State)
+
+// breaker is the state of the resolvers sharing a key.
+type breaker struct {
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+}
+
+type transition struct {
+	key      string
+	from, to State
+}
+
+// Breaker is a gqlgen handler extension guarding resolvers with circuit
+// breakers, one per Object.field by default. A breaker opens once the
+// failure ratio of its resolvers crosses the threshold within a window;
+// resolvers then fail with a SERVICE_UNAVAILABLE error without running.
+// After the open timeout, the breaker lets probes through, closing once one
+// succeeds and opening again otherwise.
+//
+// State changes are exported as the graphql_circuit_breaker_state{resource}
+// gauge, 0 closed, 1 half-open and 2 open, and counted in
+// graphql_circuit_breaker_transitions_total{resource,from,to}. Only
+// resolvers are guarded, not fields read from their parent object.
+type Breaker struct {
+	cfg         *config
+	gauge       *prometheusclient.GaugeVec
+	transitions *prometheusclient.CounterVec
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Breaker{}
+
+// New returns a Breaker whose metrics are registered on the configured
+// registerer.
+func New(opts ...Option) *Breaker {
+	cfg := newConfig(opts...)
+
+	gauge := prometheusclient.NewGaugeVec(
+		prometheusclient.GaugeOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_circuit_breaker_state",
+			Help:        "State of circuit breakers: 0 closed, 1 half-open, 2 open.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"resource"},
+	)
+	transitions := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_circuit_breaker_transitions_total",
+			Help:        "Total number of circuit breaker state transitions.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"resource", "from", "to"},
+	)
+	cfg.registerer.MustRegister(gauge, transitions)
+
+	return &Breaker{
+		cfg:         cfg,
+		gauge:       gauge,
+		transitions: transitions,
+		breakers:    map[string]*breaker{},
+	}
+}
+
+// UnRegister removes the metrics from the registerer they were registered
+// on.
+func (b *Breaker) UnRegister() {
+	b.cfg.registerer.Unregister(b.gauge)
+	b.cfg.registerer.Unregister(b.transitions)
+}
+
+// State returns the state of the breaker named key.
+func (b *Breaker) State(key string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if br, ok := b.breakers[key]; ok {
+		return br.state
+	}
+	return Closed
+}
+
+func (b *Breaker) ExtensionName() string {
+	return "CircuitBreaker"
+}
+
+func (b *Breaker) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (b *Breaker) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	key := b.cfg.key(ctx)
+	if key == "" {
+		return next(ctx)
+	}
+
+	allowed, probe := b.allow(key)
+	if !allowed {
+		return nil, &gqlerror.Error{
+			Message:    "service unavailable",
+			Extensions: map[string]any{"code": ErrCodeServiceUnavailable},
+		}
+	}
+
+	// A panic is recovered outside the interceptors: without recording it, a
+	// panicking probe would keep its slot forever.
+	completed := false
+	defer func() {
+		if !completed {
+			b.record(key, probe, true)
+		}
+	}()
+	res, err := next(ctx)
+	completed = true
+	b.record(key, probe, b.cfg.isFailure(err))
+	return res, err
+}
+
+// allow reports whether a resolver of the breaker named key may run, and
+// whether it runs as a probe.
+func (b *Breaker) allow(key string) (allowed, probe bool) {
+	var changed []transition
+	defer func() { b.notify(changed) }()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	br, ok := b.breakers[key]
+	if !ok {
+		br = &breaker{windowStart: now}
+		b.breakers[key] = br
+	}
+
+	switch br.state {
+	case Open:
+		if now.Sub(br.openedAt) < b.cfg.openTimeout {
+			return false, false
+		}
+		changed = append(changed, b.set(key, br, HalfOpen))
+		br.probes = 0
+		fallthrough
+	case HalfOpen:
+		if br.probes >= b.cfg.halfOpenProbes {
+			return false, false
+		}
+		br.probes++
+		return true, true
+	default:
+		if now.Sub(br.windowStart) >= b.cfg.window {
+			br.windowStart, br.requests, br.failures = now, 0, 0
+		}
+		return true, false
+	}
+}
+
+// record counts the outcome of a resolver of the breaker named key.
+func (b *Breaker) record(key string, probe, failed bool) {
+	var changed []transition
+	defer func() { b.notify(changed) }()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	br := b.breakers[key]
+
+	switch {
+	case br.state == HalfOpen && probe:
+		br.probes--
+		if failed {
+			br.openedAt = now
+			changed = append(changed, b.set(key, br, Open))
+		} else {
+			br.windowStart, br.requests, br.failures = now, 0, 0
+			changed = append(changed, b.set(key, br, Closed))
+		}
+	case br.state == Closed && !probe:
+		br.requests++
+		if failed {
+			br.failures++
+		}
+		if br.requests >= b.cfg.minRequests && float64(br.failures)/float64(br.requests) >= b.cfg.threshold {
+			br.openedAt = now
+			changed = append(changed, b.set(key, br, Open))
+		}
+	}
+}
+
+// set moves br to state, updating the metrics. b.mu must be held.
+func (b *Breaker) set(key string, br *breaker, state State) transition {
+	t := transition{key: key, from: br.state, to: state}
+	br.state = state
+	b.gauge.WithLabelValues(key).Set(float64(state))
+	b.transitions.WithLabelValues(key, t.from.String(), t.to.String()).Inc()
+	return t
+}
+
+// notify calls the hooks, without b.mu held so they may call State.
+func (b *Breaker) notify(changed []transition) {
+	for _, t := range changed {
+		for _, hook := range b.cfg.hooks {
+			hook(t.key, t.from, t.to)
+		}
+	}
+}
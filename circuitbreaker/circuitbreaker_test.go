@@ -0,0 +1,130 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/circuitbreaker"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	var transitions []string
+	breaker := circuitbreaker.New(
+		circuitbreaker.WithRegisterer(registry),
+		circuitbreaker.WithThreshold(0.5, 2),
+		circuitbreaker.WithOpenTimeout(50*time.Millisecond),
+		circuitbreaker.WithHook(func(key string, from, to circuitbreaker.State) {
+			transitions = append(transitions, key+" "+from.String()+" -> "+to.String())
+		}),
+	)
+	defer breaker.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(breaker)
+
+	missing := `{"query":"{ todo(id: \"unknown\") { id } }"}`
+	found := `{"query":"{ todo(id: \"` + graph.TodoA.ID + `\") { id } }"}`
+
+	for range 2 {
+		resp := doRequest(srv, missing)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), graph.ErrTodoNotFound.Error())
+	}
+	assert.Equal(t, circuitbreaker.Open, breaker.State("Query.todo"))
+
+	resp := doRequest(srv, found)
+	assert.Contains(t, resp.Body.String(), `"code":"SERVICE_UNAVAILABLE"`)
+	resp = doRequest(srv, `{"query":"{ todos { id } }"}`)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	// The probe fails, opening the breaker again.
+	time.Sleep(60 * time.Millisecond)
+	resp = doRequest(srv, missing)
+	assert.Contains(t, resp.Body.String(), graph.ErrTodoNotFound.Error())
+	assert.Equal(t, circuitbreaker.Open, breaker.State("Query.todo"))
+
+	// The probe succeeds, closing the breaker.
+	time.Sleep(60 * time.Millisecond)
+	resp = doRequest(srv, found)
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.Equal(t, circuitbreaker.Closed, breaker.State("Query.todo"))
+
+	assert.Equal(t, []string{
+		"Query.todo closed -> open",
+		"Query.todo open -> half_open",
+		"Query.todo half_open -> open",
+		"Query.todo open -> half_open",
+		"Query.todo half_open -> closed",
+	}, transitions)
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `graphql_circuit_breaker_state{resource="Query.todo"} 0`)
+	assert.Contains(t, resp.Body.String(), `graphql_circuit_breaker_transitions_total{from="open",resource="Query.todo",to="half_open"} 2`)
+}
+
+func TestBreaker_Panic(t *testing.T) {
+	breaker := circuitbreaker.New(
+		circuitbreaker.WithRegisterer(prometheusclient.NewRegistry()),
+		circuitbreaker.WithThreshold(0.5, 1),
+		circuitbreaker.WithOpenTimeout(20*time.Millisecond),
+	)
+	defer breaker.UnRegister()
+
+	var panicking atomic.Bool
+	panicking.Store(true)
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(breaker)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if panicking.Load() && graphql.GetFieldContext(ctx).Field.Name == "todos" {
+			panic("boom")
+		}
+		return next(ctx)
+	})
+
+	query := `{"query":"{ todos { id } }"}`
+	doRequest(srv, query)
+	assert.Equal(t, circuitbreaker.Open, breaker.State("Query.todos"), "panics are failures")
+
+	time.Sleep(30 * time.Millisecond)
+	doRequest(srv, query)
+	assert.Equal(t, circuitbreaker.Open, breaker.State("Query.todos"), "a panicking probe releases its slot")
+
+	panicking.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	resp := doRequest(srv, query)
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.Equal(t, circuitbreaker.Closed, breaker.State("Query.todos"))
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	method := http.MethodPost
+	if body == "" {
+		method = http.MethodGet
+	}
+	r := httptest.NewRequest(method, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
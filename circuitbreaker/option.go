@@ -0,0 +1,147 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	key            func(ctx context.Context) string
+	isFailure      func(err error) bool
+	threshold      float64
+	minRequests    int
+	window         time.Duration
+	openTimeout    time.Duration
+	halfOpenProbes int
+	hooks          []Hook
+	namespace      string
+	subsystem      string
+	constLabels    prometheusclient.Labels
+	registerer     prometheusclient.Registerer
+}
+
+// Option is anything that can configure Breaker.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		key:            fieldKey,
+		isFailure:      isFailure,
+		threshold:      0.5,
+		minRequests:    20,
+		window:         10 * time.Second,
+		openTimeout:    30 * time.Second,
+		halfOpenProbes: 1,
+		registerer:     prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// fieldKey keys breakers by Object.field.
+func fieldKey(ctx context.Context) string {
+	fc := graphql.GetFieldContext(ctx)
+	return fc.Object + "." + fc.Field.Name
+}
+
+// isFailure counts every error but cancellations, which the client caused.
+func isFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// WithKeyFunc groups resolvers under the breaker named by fn, for instance
+// the downstream service they call, instead of one breaker per Object.field.
+// Resolvers for which fn returns "" are not guarded.
+func WithKeyFunc(fn func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.key = fn
+	}
+}
+
+// WithFailureFunc counts the resolver errors for which fn returns true as
+// failures, instead of all errors but context.Canceled.
+func WithFailureFunc(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.isFailure = fn
+	}
+}
+
+// WithThreshold opens a breaker once the ratio of failed resolvers within a
+// window reaches ratio, 0.5 by default, provided at least minRequests, 20 by
+// default, ran.
+func WithThreshold(ratio float64, minRequests int) Option {
+	return func(cfg *config) {
+		cfg.threshold = ratio
+		cfg.minRequests = minRequests
+	}
+}
+
+// WithWindow sets the period over which failures are counted, 10s by
+// default.
+func WithWindow(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.window = d
+	}
+}
+
+// WithOpenTimeout sets how long a breaker stays open before letting probes
+// through, 30s by default.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.openTimeout = d
+	}
+}
+
+// WithHalfOpenProbes sets how many resolvers a half-open breaker lets
+// through at once, 1 by default.
+func WithHalfOpenProbes(n int) Option {
+	return func(cfg *config) {
+		cfg.halfOpenProbes = n
+	}
+}
+
+// WithHook calls hook on every state transition. Calling it again adds
+// more hooks.
+func WithHook(hook Hook) Option {
+	return func(cfg *config) {
+		cfg.hooks = append(cfg.hooks, hook)
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,89 @@
+// Package clientinfo identifies the client application sending each
+// operation, from the apollographql-client-name and
+// apollographql-client-version headers by default. The observability
+// extensions of this module read it with ForContext.
+package clientinfo
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Default header names, as sent by Apollo clients.
+const (
+	NameHeader    = "apollographql-client-name"
+	VersionHeader = "apollographql-client-version"
+)
+
+// Info identifies a client application. Both fields are empty for clients
+// that do not identify themselves.
+type Info struct {
+	Name    string
+	Version string
+}
+
+type infoKey struct{}
+
+// WithInfo returns a copy of ctx carrying info.
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// ForContext returns the client of the operation in ctx. Without the
+// Extension, it is read from the default headers.
+func ForContext(ctx context.Context) Info {
+	if info, ok := ctx.Value(infoKey{}).(Info); ok {
+		return info
+	}
+	if !graphql.HasOperationContext(ctx) {
+		return Info{}
+	}
+	return FromHeaders(graphql.GetOperationContext(ctx).Headers, NameHeader, VersionHeader)
+}
+
+// FromHeaders reads an Info from the named headers.
+func FromHeaders(headers http.Header, nameHeader, versionHeader string) Info {
+	return Info{
+		Name:    headers.Get(nameHeader),
+		Version: headers.Get(versionHeader),
+	}
+}
+
+// Extension stores the client of every operation in its context, for
+// ForContext.
+type Extension struct {
+	cfg *config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = Extension{}
+
+// New returns an Extension configured by opts.
+func New(opts ...Option) Extension {
+	return Extension{cfg: newConfig(opts...)}
+}
+
+func (e Extension) ExtensionName() string {
+	return "ClientInfo"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	cfg := e.cfg
+	if cfg == nil {
+		cfg = newConfig()
+	}
+
+	info := FromHeaders(graphql.GetOperationContext(ctx).Headers, cfg.nameHeader, cfg.versionHeader)
+	if info.Name == "" {
+		info.Name = cfg.unknownName
+	}
+	return next(WithInfo(ctx, info))
+}
@@ -0,0 +1,82 @@
+package clientinfo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtension(t *testing.T) {
+	specs := []struct {
+		SpecName  string
+		Extension graphql.HandlerExtension
+		Headers   map[string]string
+		Expected  clientinfo.Info
+	}{
+		{
+			SpecName: "default headers without extension",
+			Headers: map[string]string{
+				"apollographql-client-name":    "web",
+				"apollographql-client-version": "1.2.3",
+			},
+			Expected: clientinfo.Info{Name: "web", Version: "1.2.3"},
+		},
+		{
+			SpecName:  "custom headers",
+			Extension: clientinfo.New(clientinfo.WithHeaders("X-Client", "X-Client-Version")),
+			Headers: map[string]string{
+				"apollographql-client-name": "ignored",
+				"X-Client":                  "ios",
+				"X-Client-Version":          "2.0",
+			},
+			Expected: clientinfo.Info{Name: "ios", Version: "2.0"},
+		},
+		{
+			SpecName:  "unknown client",
+			Extension: clientinfo.New(clientinfo.WithUnknownName("unknown")),
+			Expected:  clientinfo.Info{Name: "unknown"},
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			var got clientinfo.Info
+			srv := handler.New(graph.NewExecutableSchema(graph.Config{
+				Resolvers: &graph.Resolver{},
+			}))
+			srv.AddTransport(transport.POST{})
+			if spec.Extension != nil {
+				srv.Use(spec.Extension)
+			}
+			srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+				got = clientinfo.ForContext(ctx)
+				return next(ctx)
+			})
+
+			r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id } }"}`))
+			r.Header.Set("Content-Type", "application/json")
+			for name, value := range spec.Headers {
+				r.Header.Set(name, value)
+			}
+			srv.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, spec.Expected, got)
+		})
+	}
+}
+
+func TestForContext(t *testing.T) {
+	assert.Equal(t, clientinfo.Info{}, clientinfo.ForContext(context.Background()))
+
+	ctx := clientinfo.WithInfo(context.Background(), clientinfo.Info{Name: "cli"})
+	assert.Equal(t, clientinfo.Info{Name: "cli"}, clientinfo.ForContext(ctx))
+}
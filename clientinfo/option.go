@@ -0,0 +1,40 @@
+package clientinfo
+
+type config struct {
+	nameHeader    string
+	versionHeader string
+	unknownName   string
+}
+
+// Option is anything that can configure Extension.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		nameHeader:    NameHeader,
+		versionHeader: VersionHeader,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithHeaders reads the client name and version from the given headers
+// instead of the Apollo ones.
+func WithHeaders(nameHeader, versionHeader string) Option {
+	return func(cfg *config) {
+		cfg.nameHeader = nameHeader
+		cfg.versionHeader = versionHeader
+	}
+}
+
+// WithUnknownName reports clients that do not identify themselves under
+// name instead of "".
+func WithUnknownName(name string) Option {
+	return func(cfg *config) {
+		cfg.unknownName = name
+	}
+}
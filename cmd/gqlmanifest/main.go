@@ -0,0 +1,91 @@
+// Command gqlmanifest writes the Apollo persisted query manifest of the
+// operations found in .graphql files and gql tagged templates, to be served
+// with the allowlist package.
+//
+// Usage:
+//
+//	gqlmanifest [-o manifest.json] [-normalized] [path ...]
+//
+// Paths are files or directories, walked recursively, and default to the
+// current directory.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/99designs/gqlgen-contrib/persistedquery/manifest"
+)
+
+func main() {
+	output := flag.String("o", "", "write the manifest to `file` instead of stdout")
+	normalized := flag.Bool("normalized", false, "hash normalized operations, for allowlist.WithNormalizedHashes")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: gqlmanifest [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*output, *normalized, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "gqlmanifest: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(output string, normalized bool, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var sources []manifest.Source
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			for _, doc := range manifest.Extract(path, src) {
+				sources = append(sources, manifest.Source{Name: path, Input: doc})
+			}
+			continue
+		}
+
+		found, err := manifest.Walk(os.DirFS(path), ".")
+		if err != nil {
+			return err
+		}
+		for _, src := range found {
+			src.Name = filepath.Join(path, src.Name)
+			sources = append(sources, src)
+		}
+	}
+
+	var opts []manifest.Option
+	if normalized {
+		opts = append(opts, manifest.WithNormalizedHashes())
+	}
+	m, err := manifest.Build(sources, opts...)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(output, data, 0o644)
+}
@@ -0,0 +1,192 @@
+// Package codec lets clients ask for GraphQL responses in a binary encoding
+// such as MessagePack, more compact than JSON and faster to decode, with
+// the Accept header.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes responses in the media type it returns. Marshal is given
+// the values encoding/json decodes responses into, except that numbers are
+// int64 when integral and float64 otherwise, so that any MessagePack or CBOR
+// library can be plugged in.
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+}
+
+// MsgPack encodes responses as application/msgpack.
+var MsgPack Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string           { return "application/msgpack" }
+func (msgpackCodec) Marshal(v any) ([]byte, error) { return msgpack.Marshal(v) }
+
+// Transport wraps a transport answering with single JSON responses, such as
+// transport.POST or transport.GET, so that requests accepting the media type
+// of a codec are answered in it. Requests preferring JSON, or accepting
+// streams as those of the SSE and multipart transports, are passed to the
+// wrapped transport unchanged.
+//
+// gqlgen encodes results in JSON while executing them, so responses are
+// transcoded: the server pays for it, clients get smaller payloads that are
+// quicker to decode.
+type Transport struct {
+	next graphql.Transport
+	cfg  *config
+}
+
+var _ graphql.Transport = &Transport{}
+
+// New returns a Transport wrapping next.
+func New(next graphql.Transport, opts ...Option) *Transport {
+	return &Transport{next: next, cfg: newConfig(opts...)}
+}
+
+func (t *Transport) Supports(r *http.Request) bool {
+	return t.next.Supports(r)
+}
+
+func (t *Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	w.Header().Add("Vary", "Accept")
+	c := t.negotiate(r.Header.Get("Accept"))
+	if c == nil {
+		t.next.Do(w, r, exec)
+		return
+	}
+
+	rec := &recorder{header: http.Header{}, status: http.StatusOK}
+	t.next.Do(rec, r, exec)
+
+	body := rec.body.Bytes()
+	if encoded, err := transcode(c, body); err == nil {
+		rec.header.Set("Content-Type", c.ContentType())
+		body = encoded
+	}
+	rec.header.Del("Content-Length")
+	for key, values := range rec.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(rec.status)
+	_, _ = w.Write(body)
+}
+
+// negotiate returns the codec to encode the response with, nil for JSON.
+// Codecs must be accepted explicitly, wildcards standing for JSON.
+func (t *Transport) negotiate(accept string) Codec {
+	if accept == "" {
+		return nil
+	}
+
+	var ranges []mediaRange
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	jsonQ := max(quality(ranges, "application/json"), quality(ranges, "application/graphql-response+json"))
+	var best Codec
+	bestQ := 0.0
+	for _, c := range t.cfg.codecs {
+		for _, mr := range ranges {
+			if mr.mediaType == c.ContentType() && mr.q > bestQ {
+				best, bestQ = c, mr.q
+			}
+		}
+	}
+	if best == nil || bestQ < jsonQ {
+		return nil
+	}
+	return best
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// quality returns the q-value given to mediaType by the most specific range
+// matching it, 0 if none does.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, 0
+	for _, mr := range ranges {
+		var s int
+		switch mr.mediaType {
+		case mediaType:
+			s = 3
+		case typ + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+	return q
+}
+
+// transcode encodes the JSON response body with c.
+func transcode(c Codec, body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return c.Marshal(numbers(v))
+}
+
+// numbers replaces the json.Number of v by int64 or float64.
+func numbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, value := range v {
+			v[key] = numbers(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = numbers(value)
+		}
+	}
+	return v
+}
+
+// recorder buffers the response of the wrapped transport.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header         { return r.header }
+func (r *recorder) WriteHeader(status int)      { r.status = status }
+func (r *recorder) Write(b []byte) (int, error) { return r.body.Write(b) }
@@ -0,0 +1,102 @@
+package codec_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/codec"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestTransport(t *testing.T) {
+	srv := newServer()
+
+	resp := doRequest(srv, "application/msgpack", `{"query":"{ todos { id done } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/msgpack", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", resp.Header().Get("Vary"))
+
+	var body struct {
+		Data struct {
+			Todos []struct {
+				ID   string `msgpack:"id"`
+				Done bool   `msgpack:"done"`
+			} `msgpack:"todos"`
+		} `msgpack:"data"`
+	}
+	require.NoError(t, msgpack.Unmarshal(resp.Body.Bytes(), &body))
+	require.Len(t, body.Data.Todos, 3)
+	assert.Equal(t, graph.TodoA.ID, body.Data.Todos[0].ID)
+
+	resp = doRequest(srv, "application/json, application/msgpack;q=0.5", `{"query":"{ todos { id } }"}`)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	assert.True(t, json.Valid(resp.Body.Bytes()))
+
+	resp = doRequest(srv, "*/*", `{"query":"{ todos { id } }"}`)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+}
+
+func TestTransport_Errors(t *testing.T) {
+	resp := doRequest(newServer(), "application/msgpack", `{"query":"{ unknown }"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, "application/msgpack", resp.Header().Get("Content-Type"))
+
+	var body map[string]any
+	require.NoError(t, msgpack.Unmarshal(resp.Body.Bytes(), &body))
+	errs := body["errors"].([]any)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "GRAPHQL_VALIDATION_FAILED", errs[0].(map[string]any)["extensions"].(map[string]any)["code"])
+}
+
+func TestTransport_WithCodec(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	var got any
+	srv.AddTransport(codec.New(transport.POST{}, codec.WithCodec(testCodec{v: &got})))
+
+	resp := doRequest(srv, "application/x-test", `{"query":"{ unknown }"}`)
+	assert.Equal(t, "application/x-test", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "test", resp.Body.String())
+	errs := got.(map[string]any)["errors"].([]any)
+	assert.Equal(t, []any{map[string]any{"line": int64(1), "column": int64(3)}}, errs[0].(map[string]any)["locations"])
+
+	resp = doRequest(srv, "application/msgpack", `{"query":"{ todos { id } }"}`)
+	assert.NotEqual(t, "application/msgpack", resp.Header().Get("Content-Type"))
+}
+
+type testCodec struct {
+	v *any
+}
+
+func (testCodec) ContentType() string { return "application/x-test" }
+
+func (c testCodec) Marshal(v any) ([]byte, error) {
+	*c.v = v
+	return []byte("test"), nil
+}
+
+func newServer() *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(codec.New(transport.POST{}))
+	return srv
+}
+
+func doRequest(handler http.Handler, accept, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
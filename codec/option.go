@@ -0,0 +1,31 @@
+package codec
+
+type config struct {
+	codecs []Codec
+}
+
+// Option is anything that can configure Transport.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if len(cfg.codecs) == 0 {
+		cfg.codecs = []Codec{MsgPack}
+	}
+
+	return cfg
+}
+
+// WithCodec adds c to the codecs clients can ask for, instead of MsgPack
+// alone. Codecs listed first are preferred when clients accept several with
+// the same quality.
+func WithCodec(c Codec) Option {
+	return func(cfg *config) {
+		cfg.codecs = append(cfg.codecs, c)
+	}
+}
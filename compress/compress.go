@@ -0,0 +1,290 @@
+// Package compress compresses GraphQL responses without breaking the
+// streamed ones: incremental delivery and SSE responses are flushed through
+// the compressor event by event instead of being buffered, and small
+// responses are left uncompressed.
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// Encodings supported by Compressor.
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+// Compressor is an HTTP middleware compressing responses with the encoding
+// the client prefers among the configured ones. It exports
+// graphql_compression_ratio, the ratio of the size of responses to their
+// compressed size, and graphql_compression_input_bytes_total and
+// graphql_compression_output_bytes_total, all by encoding.
+type Compressor struct {
+	cfg *config
+
+	ratio  *prometheusclient.HistogramVec
+	input  *prometheusclient.CounterVec
+	output *prometheusclient.CounterVec
+	pools  map[string]*sync.Pool
+}
+
+// New returns a Compressor whose metrics are registered on the configured
+// registerer.
+func New(opts ...Option) Compressor {
+	cfg := newConfig(opts...)
+
+	c := Compressor{
+		cfg: cfg,
+		ratio: prometheusclient.NewHistogramVec(prometheusclient.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_compression_ratio",
+			Help:        "Ratio of the uncompressed size of responses to their compressed size.",
+			Buckets:     []float64{1, 1.5, 2, 3, 5, 10, 20},
+			ConstLabels: cfg.constLabels,
+		}, []string{"encoding"}),
+		input: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_compression_input_bytes_total",
+			Help:        "Total number of bytes of responses before compression.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"encoding"}),
+		output: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_compression_output_bytes_total",
+			Help:        "Total number of bytes of responses after compression.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"encoding"}),
+		pools: map[string]*sync.Pool{
+			Gzip: {New: func() any {
+				w, err := gzip.NewWriterLevel(nil, cfg.gzipLevel)
+				if err != nil {
+					w = gzip.NewWriter(nil)
+				}
+				return w
+			}},
+			Brotli: {New: func() any {
+				return brotli.NewWriterLevel(nil, cfg.brotliLevel)
+			}},
+		},
+	}
+	cfg.registerer.MustRegister(c.ratio, c.input, c.output)
+
+	return c
+}
+
+// UnRegister removes the metrics from the registerer they were registered
+// on.
+func (c Compressor) UnRegister() {
+	c.cfg.registerer.Unregister(c.ratio)
+	c.cfg.registerer.Unregister(c.input)
+	c.cfg.registerer.Unregister(c.output)
+}
+
+// Middleware compresses the responses of next. WebSocket upgrades and HEAD
+// requests are passed through, as are responses already encoded or whose
+// media type is not one of the configured content types.
+func (c Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := c.negotiate(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &responseWriter{ResponseWriter: w, c: c, encoding: encoding, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiate returns the configured encoding the client prefers, "" if it
+// accepts none.
+func (c Compressor) negotiate(acceptEncoding string) string {
+	q := map[string]float64{}
+	for part := range strings.SplitSeq(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		value := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if value, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		q[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range c.cfg.encodings {
+		value, ok := q[encoding]
+		if !ok {
+			value = q["*"]
+		}
+		if value > bestQ {
+			best, bestQ = encoding, value
+		}
+	}
+	return best
+}
+
+// compressible reports whether responses of contentType are compressed, and
+// whether they are streamed.
+func (c Compressor) compressible(contentType string) (ok, streamed bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false, false
+	}
+	streamed = mediaType == "multipart/mixed" || mediaType == "text/event-stream"
+	for _, t := range c.cfg.contentTypes {
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*")) {
+			return true, streamed
+		}
+	}
+	return false, streamed
+}
+
+// encoder is the interface shared by gzip and Brotli writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// responseWriter buffers the start of responses until it knows whether to
+// compress them.
+type responseWriter struct {
+	http.ResponseWriter
+	c        Compressor
+	encoding string
+
+	status  int
+	decided bool
+	buf     []byte
+	enc     encoder
+	in, out int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		h := w.Header()
+		if h.Get("Content-Type") == "" {
+			h.Set("Content-Type", http.DetectContentType(b))
+		}
+		ok, streamed := w.c.compressible(h.Get("Content-Type"))
+		switch {
+		case !ok || h.Get("Content-Encoding") != "":
+			w.decide(false)
+		case streamed:
+			w.decide(true)
+		default:
+			w.buf = append(w.buf, b...)
+			if len(w.buf) >= w.c.cfg.minSize {
+				w.decide(true)
+			}
+			return len(b), nil
+		}
+	}
+
+	if w.enc == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	w.in += len(b)
+	return w.enc.Write(b)
+}
+
+// Flush sends what was written so far, compressing streamed responses and
+// leaving the others uncompressed when under the minimum size.
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		_, streamed := w.c.compressible(w.Header().Get("Content-Type"))
+		w.decide(streamed && w.Header().Get("Content-Encoding") == "")
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header and the buffered start of the response,
+// compressed or not.
+func (w *responseWriter) decide(compress bool) {
+	w.decided = true
+	buf := w.buf
+	w.buf = nil
+
+	if compress {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.encoding)
+		w.enc = w.c.pools[w.encoding].Get().(encoder)
+		w.enc.Reset(countingWriter{w})
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(buf) > 0 {
+		_, _ = w.Write(buf)
+	}
+}
+
+// close ends the response once the handler returned.
+func (w *responseWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	w.enc.Reset(nil)
+	w.c.pools[w.encoding].Put(w.enc)
+
+	w.c.input.WithLabelValues(w.encoding).Add(float64(w.in))
+	w.c.output.WithLabelValues(w.encoding).Add(float64(w.out))
+	if w.out > 0 {
+		w.c.ratio.WithLabelValues(w.encoding).Observe(float64(w.in) / float64(w.out))
+	}
+}
+
+// countingWriter counts the compressed bytes of the response.
+type countingWriter struct {
+	w *responseWriter
+}
+
+func (cw countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.ResponseWriter.Write(b)
+	cw.w.out += n
+	return n, err
+}
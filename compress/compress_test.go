@@ -0,0 +1,127 @@
+package compress_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/compress"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/andybalholm/brotli"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const introspection = `{"query":"{ __schema { types { name fields { name } } } }"}`
+
+func TestCompressor(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	c := compress.New(compress.WithRegisterer(registry))
+	srv := c.Middleware(newServer())
+
+	plain := doRequest(srv, "", introspection)
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", plain.Header().Get("Vary"))
+
+	resp := doRequest(srv, "gzip, deflate, br", introspection)
+	assert.Equal(t, compress.Brotli, resp.Header().Get("Content-Encoding"))
+	body, err := io.ReadAll(brotli.NewReader(resp.Body))
+	require.NoError(t, err)
+	assert.Equal(t, plain.Body.String(), string(body))
+	assert.Less(t, resp.Body.Len(), plain.Body.Len())
+
+	resp = doRequest(srv, "br;q=0.5, gzip", introspection)
+	assert.Equal(t, compress.Gzip, resp.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err = io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, plain.Body.String(), string(body))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "graphql_compression_ratio" {
+			require.Len(t, family.GetMetric(), 2)
+			assert.Greater(t, family.GetMetric()[0].GetHistogram().GetSampleSum(), 2.0)
+		}
+	}
+}
+
+func TestCompressor_MinSize(t *testing.T) {
+	c := compress.New(compress.WithRegisterer(prometheusclient.NewRegistry()))
+	srv := c.Middleware(newServer())
+
+	resp := doRequest(srv, "gzip", `{"query":"{ todos { id } }"}`)
+	assert.Empty(t, resp.Header().Get("Content-Encoding"))
+	assert.Contains(t, resp.Body.String(), graph.TodoA.ID)
+
+	resp = doRequest(c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(make([]byte, 4096))
+	})), "gzip", "")
+	assert.Empty(t, resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, 4096, resp.Body.Len())
+}
+
+func TestCompressor_Stream(t *testing.T) {
+	c := compress.New(compress.WithRegisterer(prometheusclient.NewRegistry()), compress.WithEncodings(compress.Gzip))
+	next := make(chan struct{})
+	ts := httptest.NewServer(c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: next\ndata: {}\n\n")
+		w.(http.Flusher).Flush()
+		<-next
+		_, _ = io.WriteString(w, "event: complete\n\n")
+	})))
+	defer ts.Close()
+
+	r, err := http.NewRequest(http.MethodPost, ts.URL, nil)
+	require.NoError(t, err)
+	r.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(r)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, compress.Gzip, resp.Header.Get("Content-Encoding"))
+
+	// The first event is readable before the handler returns.
+	zr, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	lines := bufio.NewReader(zr)
+	line, err := lines.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: next\n", line)
+
+	close(next)
+	rest, err := io.ReadAll(lines)
+	require.NoError(t, err)
+	assert.Equal(t, "data: {}\n\nevent: complete\n\n", string(rest))
+}
+
+func newServer() *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	return srv
+}
+
+func doRequest(handler http.Handler, acceptEncoding, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,120 @@
+package compress
+
+import (
+	"compress/gzip"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	minSize      int
+	encodings    []string
+	gzipLevel    int
+	brotliLevel  int
+	contentTypes []string
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Compressor.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		minSize:   1024,
+		encodings: []string{Brotli, Gzip},
+		gzipLevel: gzip.DefaultCompression,
+		// Higher levels are too slow for responses compressed on the fly.
+		brotliLevel: 4,
+		contentTypes: []string{
+			"application/json",
+			"application/graphql-response+json",
+			"multipart/mixed",
+			"text/event-stream",
+			"text/*",
+		},
+		registerer: prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithMinSize leaves responses under n bytes uncompressed, 1KiB by default,
+// as compressing them costs more than it saves. Streamed responses are
+// compressed from their first event, as their size is not known in advance.
+func WithMinSize(n int) Option {
+	return func(cfg *config) {
+		cfg.minSize = n
+	}
+}
+
+// WithEncodings sets the encodings responses may be compressed with, in
+// order of preference when clients accept several equally, Brotli then Gzip
+// by default.
+func WithEncodings(encodings ...string) Option {
+	return func(cfg *config) {
+		cfg.encodings = encodings
+	}
+}
+
+// WithGzipLevel sets the gzip compression level, gzip.DefaultCompression by
+// default.
+func WithGzipLevel(level int) Option {
+	return func(cfg *config) {
+		cfg.gzipLevel = level
+	}
+}
+
+// WithBrotliLevel sets the Brotli compression level, from 0 to 11, 4 by
+// default.
+func WithBrotliLevel(level int) Option {
+	return func(cfg *config) {
+		cfg.brotliLevel = level
+	}
+}
+
+// WithContentTypes sets the media types of the responses to compress, such
+// as application/json or text/*, instead of those of GraphQL responses,
+// incremental delivery and SSE streams, and text.
+func WithContentTypes(contentTypes ...string) Option {
+	return func(cfg *config) {
+		cfg.contentTypes = contentTypes
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
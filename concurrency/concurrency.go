@@ -0,0 +1,118 @@
+// Package concurrency bounds how many resolvers of a field run at once, so
+// a query fanning out over a list does not overwhelm the service behind it.
+package concurrency
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrCodeConcurrencyLimited is the extensions.code of the error returned by
+// resolvers that timed out waiting for their turn.
+const ErrCodeConcurrencyLimited = "CONCURRENCY_LIMITED"
+
+// Limiter is a gqlgen handler extension running resolvers under a weighted
+// semaphore per key given WithLimit. Resolvers over the limit wait for
+// their turn, in order, up to the queue timeout; the time waited is observed
+// in the graphql_resolver_queue_wait_ms{resource} histogram.
+type Limiter struct {
+	cfg        *config
+	semaphores map[string]*limit
+	histogram  *prometheusclient.HistogramVec
+}
+
+type limit struct {
+	sem *semaphore.Weighted
+	n   int64
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Limiter{}
+
+// New returns a Limiter whose histogram is registered on the configured
+// registerer.
+func New(opts ...Option) *Limiter {
+	cfg := newConfig(opts...)
+
+	histogram := prometheusclient.NewHistogramVec(
+		prometheusclient.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_resolver_queue_wait_ms",
+			Help:        "Time resolvers waited for their concurrency limit.",
+			ConstLabels: cfg.constLabels,
+			Buckets:     prometheusclient.ExponentialBuckets(1, 2, 11),
+		},
+		[]string{"resource"},
+	)
+	cfg.registerer.MustRegister(histogram)
+
+	semaphores := make(map[string]*limit, len(cfg.limits))
+	for key, n := range cfg.limits {
+		semaphores[key] = &limit{sem: semaphore.NewWeighted(n), n: n}
+	}
+
+	return &Limiter{
+		cfg:        cfg,
+		semaphores: semaphores,
+		histogram:  histogram,
+	}
+}
+
+// UnRegister removes the histogram from the registerer it was registered
+// on.
+func (l *Limiter) UnRegister() {
+	l.cfg.registerer.Unregister(l.histogram)
+}
+
+func (l *Limiter) ExtensionName() string {
+	return "ConcurrencyLimit"
+}
+
+func (l *Limiter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l *Limiter) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	key := l.cfg.key(ctx)
+	lim, ok := l.semaphores[key]
+	if !ok {
+		return next(ctx)
+	}
+	weight := min(l.cfg.weight(ctx), lim.n)
+
+	waitCtx := ctx
+	if l.cfg.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, l.cfg.queueTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := lim.sem.Acquire(waitCtx, weight)
+	l.histogram.WithLabelValues(key).Observe(float64(time.Since(start)) / float64(time.Millisecond))
+	if err != nil {
+		// Only the queue timeout is our error, the operation may be over.
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &gqlerror.Error{
+			Message:    "too many concurrent requests to " + key,
+			Extensions: map[string]any{"code": ErrCodeConcurrencyLimited},
+		}
+	}
+	defer lim.sem.Release(weight)
+
+	return next(ctx)
+}
@@ -0,0 +1,94 @@
+package concurrency_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/concurrency"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	limiter := concurrency.New(
+		concurrency.WithRegisterer(registry),
+		concurrency.WithLimit("Query.todos", 1),
+		concurrency.WithQueueTimeout(150*time.Millisecond),
+	)
+	defer limiter.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(limiter)
+
+	var running, maxRunning atomic.Int32
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if fc := graphql.GetFieldContext(ctx); fc.Field.Name != "todos" {
+			return next(ctx)
+		}
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		return next(ctx)
+	})
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 3)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bodies[i] = doRequest(srv, `{"query":"{ todos { id } }"}`).Body.String()
+		}()
+	}
+	wg.Wait()
+
+	var limited int
+	for _, body := range bodies {
+		if strings.Contains(body, `"code":"CONCURRENCY_LIMITED"`) {
+			limited++
+		} else {
+			assert.NotContains(t, body, "errors")
+		}
+	}
+	assert.Equal(t, 1, limited)
+	assert.EqualValues(t, 1, maxRunning.Load())
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `graphql_resolver_queue_wait_ms_count{resource="Query.todos"} 3`)
+	assert.NotContains(t, resp.Body.String(), `resource="Query.todo"}`)
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	method := http.MethodPost
+	if body == "" {
+		method = http.MethodGet
+	}
+	r := httptest.NewRequest(method, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
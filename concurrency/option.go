@@ -0,0 +1,109 @@
+package concurrency
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	limits       map[string]int64
+	key          func(ctx context.Context) string
+	weight       func(ctx context.Context) int64
+	queueTimeout time.Duration
+	namespace    string
+	subsystem    string
+	constLabels  prometheusclient.Labels
+	registerer   prometheusclient.Registerer
+}
+
+// Option is anything that can configure Limiter.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		limits:       map[string]int64{},
+		key:          fieldKey,
+		weight:       func(ctx context.Context) int64 { return 1 },
+		queueTimeout: time.Second,
+		registerer:   prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// fieldKey limits resolvers by Object.field.
+func fieldKey(ctx context.Context) string {
+	fc := graphql.GetFieldContext(ctx)
+	return fc.Object + "." + fc.Field.Name
+}
+
+// WithLimit bounds the total weight of the resolvers running concurrently
+// under key, an Object.field unless WithKeyFunc is used, to n. Resolvers
+// without a limit are not bounded.
+func WithLimit(key string, n int64) Option {
+	return func(cfg *config) {
+		cfg.limits[key] = n
+	}
+}
+
+// WithKeyFunc limits resolvers under the key returned by fn, for instance
+// the downstream service they call, instead of their Object.field.
+func WithKeyFunc(fn func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.key = fn
+	}
+}
+
+// WithWeightFunc weighs resolvers with fn instead of counting 1 for each,
+// for instance by the number of items they fetch. Weights above the limit
+// are lowered to the limit.
+func WithWeightFunc(fn func(ctx context.Context) int64) Option {
+	return func(cfg *config) {
+		cfg.weight = fn
+	}
+}
+
+// WithQueueTimeout fails resolvers that waited d for their turn, 1s by
+// default. 0 lets them wait as long as their operation runs.
+func WithQueueTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.queueTimeout = d
+	}
+}
+
+// WithNamespace prefixes the metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metric on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
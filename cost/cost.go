@@ -0,0 +1,111 @@
+// Package cost reports the cost of each operation in the response
+// extensions, so client developers can see how expensive their queries are.
+package cost
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+)
+
+// Cost is the value of extensions.cost.
+type Cost struct {
+	// Requested is the complexity of the operation, computed before
+	// execution.
+	Requested int `json:"requested"`
+	// Actual is the number of fields that were resolved, which accounts for
+	// the real length of lists.
+	Actual int `json:"actual"`
+	// Limit and Remaining are only set when the complexity limit extension
+	// is in use.
+	Limit     *int `json:"limit,omitempty"`
+	Remaining *int `json:"remaining,omitempty"`
+}
+
+// Extension is a gqlgen handler extension adding Cost to every response as
+// extensions.cost.
+type Extension struct {
+	schema graphql.ExecutableSchema
+	opts   []complexity.Option
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = (*Extension)(nil)
+
+type counterKey struct{}
+
+// New returns an Extension. opts are used to compute the requested cost when
+// the complexity limit extension is not in use, otherwise its value is
+// reported.
+func New(opts ...complexity.Option) *Extension {
+	return &Extension{opts: opts}
+}
+
+func (e *Extension) ExtensionName() string {
+	return "Cost"
+}
+
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	e.schema = schema
+	return nil
+}
+
+func (e *Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	return next(context.WithValue(ctx, counterKey{}, new(atomic.Int64)))
+}
+
+func (e *Extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if counter, ok := ctx.Value(counterKey{}).(*atomic.Int64); ok {
+		counter.Add(1)
+	}
+
+	return next(ctx)
+}
+
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	counter, ok := ctx.Value(counterKey{}).(*atomic.Int64)
+	if !ok {
+		return res
+	}
+
+	cost := e.cost(ctx)
+	cost.Actual = int(counter.Load())
+
+	if res.Extensions == nil {
+		res.Extensions = map[string]interface{}{}
+	}
+	res.Extensions["cost"] = cost
+
+	return res
+}
+
+func (e *Extension) cost(ctx context.Context) Cost {
+	if stats := extension.GetComplexityStats(ctx); stats != nil {
+		remaining := max(0, stats.ComplexityLimit-stats.Complexity)
+		return Cost{
+			Requested: stats.Complexity,
+			Limit:     &stats.ComplexityLimit,
+			Remaining: &remaining,
+		}
+	}
+
+	var cost Cost
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil {
+		cost.Requested = complexity.Calculate(ctx, e.schema, oc.Operation, oc.Variables, e.opts...)
+	}
+
+	return cost
+}
@@ -0,0 +1,56 @@
+package cost_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/cost"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtension(t *testing.T) {
+	newServer := func(extensions ...graphql.HandlerExtension) *handler.Server {
+		srv := handler.New(graph.NewExecutableSchema(graph.Config{
+			Resolvers: &graph.Resolver{},
+		}))
+		srv.AddTransport(transport.POST{})
+		for _, ext := range extensions {
+			srv.Use(ext)
+		}
+		return srv
+	}
+
+	t.Run("without complexity limit", func(t *testing.T) {
+		resp := doRequest(newServer(cost.New()), `{"query":"{ todos { id text } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"extensions":{"cost":{"requested":3,"actual":7}}`)
+	})
+
+	t.Run("with complexity limit", func(t *testing.T) {
+		resp := doRequest(newServer(extension.FixedComplexityLimit(10), cost.New()), `{"query":"{ todos { id text } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"extensions":{"cost":{"requested":3,"actual":7,"limit":10,"remaining":7}}`)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		resp := doRequest(newServer(extension.FixedComplexityLimit(2), cost.New()), `{"query":"{ todos { id text } }"}`)
+		assert.Contains(t, resp.Body.String(), "COMPLEXITY_LIMIT_EXCEEDED")
+		assert.NotContains(t, resp.Body.String(), `"cost"`)
+	})
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,100 @@
+// Package csrf blocks cross-site request forgery the way Apollo Server
+// does: requests a browser may send without a CORS preflight must carry a
+// header that would have triggered one.
+package csrf
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Default headers, as sent by Apollo clients.
+const (
+	OperationNameHeader    = "x-apollo-operation-name"
+	RequirePreflightHeader = "apollo-require-preflight"
+)
+
+// ErrCodeBlocked is the extensions.code of the error answered to blocked
+// requests.
+const ErrCodeBlocked = "CSRF_BLOCKED"
+
+// simpleContentTypes are the media types a browser sends without a
+// preflight.
+var simpleContentTypes = []string{
+	"application/x-www-form-urlencoded",
+	"multipart/form-data",
+	"text/plain",
+}
+
+// Middleware answers 400 to simple requests, which a browser sends cross
+// origin without a preflight, unless they carry a non-empty value for one of
+// the configured headers. These are GET requests, and form or multipart
+// uploads, which need no header to be executed by gqlgen otherwise.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := newConfig(opts...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.allowed(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"errors": gqlerror.List{{
+				Message: "this request has been blocked as a potential cross-site request forgery, " +
+					"send a Content-Type other than " + strings.Join(simpleContentTypes, ", ") +
+					" or one of the headers " + strings.Join(cfg.headers, ", "),
+				Extensions: map[string]any{
+					"code":    ErrCodeBlocked,
+					"headers": cfg.headers,
+				},
+			}},
+		})
+	})
+}
+
+func (cfg *config) allowed(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+	default:
+		return true
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		// Browsers preflight media types they cannot parse too.
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !slices.Contains(simpleContentTypes, mediaType) {
+			return true
+		}
+	}
+
+	for _, name := range cfg.headers {
+		if r.Header.Get(name) != "" {
+			return true
+		}
+	}
+
+	for _, pattern := range cfg.exemptPaths {
+		if ok, _ := path.Match(pattern, r.URL.Path); ok {
+			return true
+		}
+	}
+
+	if len(cfg.exemptClients) != 0 {
+		info := clientinfo.FromHeaders(r.Header, clientinfo.NameHeader, clientinfo.VersionHeader)
+		if cfg.exemptClients[info.Name] {
+			return true
+		}
+	}
+
+	return false
+}
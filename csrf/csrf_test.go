@@ -0,0 +1,83 @@
+package csrf_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/csrf"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	h := csrf.Middleware(srv,
+		csrf.WithExemptPaths("/public/*"),
+		csrf.WithExemptClients("backend"),
+	)
+
+	tests := []struct {
+		name    string
+		path    string
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{name: "get", path: "/query", method: http.MethodGet, status: http.StatusBadRequest},
+		{name: "get with operation name", path: "/query", method: http.MethodGet, headers: map[string]string{csrf.OperationNameHeader: "Todos"}, status: http.StatusOK},
+		{name: "get requiring preflight", path: "/query", method: http.MethodGet, headers: map[string]string{csrf.RequirePreflightHeader: "true"}, status: http.StatusOK},
+		{name: "json post", path: "/query", method: http.MethodPost, headers: map[string]string{"Content-Type": "application/json"}, status: http.StatusOK},
+		{name: "text post", path: "/query", method: http.MethodPost, headers: map[string]string{"Content-Type": "text/plain; charset=utf-8"}, status: http.StatusBadRequest},
+		{name: "multipart post", path: "/query", method: http.MethodPost, headers: map[string]string{"Content-Type": "multipart/form-data; boundary=x"}, status: http.StatusBadRequest},
+		{name: "exempt path", path: "/public/query", method: http.MethodGet, status: http.StatusOK},
+		{name: "exempt client", path: "/query", method: http.MethodGet, headers: map[string]string{"apollographql-client-name": "backend"}, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(h, tt.method, tt.path, tt.headers)
+			assert.Equal(t, tt.status, resp.Code, resp.Body.String())
+		})
+	}
+
+	resp := doRequest(h, http.MethodGet, "/query", nil)
+	var body struct {
+		Errors []struct {
+			Message    string
+			Extensions map[string]any
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	require.Len(t, body.Errors, 1)
+	assert.Equal(t, csrf.ErrCodeBlocked, body.Errors[0].Extensions["code"])
+	assert.Equal(t, []any{csrf.OperationNameHeader, csrf.RequirePreflightHeader}, body.Errors[0].Extensions["headers"])
+}
+
+func doRequest(handler http.Handler, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	query := `{ todos { id } }`
+	var r *http.Request
+	if method == http.MethodGet {
+		r = httptest.NewRequest(method, path+"?query="+url.QueryEscape(query), nil)
+	} else {
+		r = httptest.NewRequest(method, path, strings.NewReader(`{"query":"`+query+`"}`))
+	}
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,54 @@
+package csrf
+
+type config struct {
+	headers       []string
+	exemptPaths   []string
+	exemptClients map[string]bool
+}
+
+// Option is anything that can configure Middleware.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		headers: []string{OperationNameHeader, RequirePreflightHeader},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithHeaders accepts simple requests bearing any of the given headers
+// instead of the Apollo ones.
+func WithHeaders(names ...string) Option {
+	return func(cfg *config) {
+		cfg.headers = names
+	}
+}
+
+// WithExemptPaths lets simple requests to URL paths matching any of the
+// path.Match patterns through, for endpoints that must accept plain HTML
+// forms or links.
+func WithExemptPaths(patterns ...string) Option {
+	return func(cfg *config) {
+		cfg.exemptPaths = append(cfg.exemptPaths, patterns...)
+	}
+}
+
+// WithExemptClients lets simple requests from the named clients, as
+// identified by the clientinfo headers, through. Client names are not
+// authenticated, so only exempt clients the browser cannot impersonate,
+// such as those sending the headers from a server.
+func WithExemptClients(names ...string) Option {
+	return func(cfg *config) {
+		if cfg.exemptClients == nil {
+			cfg.exemptClients = map[string]bool{}
+		}
+		for _, name := range names {
+			cfg.exemptClients[name] = true
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package datadog
+
+import contrib "github.com/99designs/gqlgen-contrib"
+
+type config struct {
+	serviceName string
+	enricher    contrib.Enricher
+}
+
+// Option is anything that can configure Tracer.
+type Option func(cfg *config)
+
+// WithServiceName sets the service name of the produced spans. By default
+// the service name configured on the global tracer is used.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithEnricher sets the labels returned by fn as tags of the operation
+// spans. Calling it again adds more labels.
+func WithEnricher(fn contrib.Enricher) Option {
+	return func(cfg *config) {
+		if cfg.enricher == nil {
+			cfg.enricher = fn
+		} else {
+			cfg.enricher = contrib.Enrichers(cfg.enricher, fn)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package datadog
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	operationSpanName = "graphql.request"
+	fieldSpanName     = "graphql.field"
+)
+
+// Tracer is a gqlgen handler extension creating Datadog APM spans for
+// operations and field resolvers.
+// see https://pkg.go.dev/gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer
+type Tracer struct {
+	cfg config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+// New returns a Tracer configured by opts.
+func New(opts ...Option) Tracer {
+	var t Tracer
+
+	for _, opt := range opts {
+		opt(&t.cfg)
+	}
+
+	return t
+}
+
+func (t Tracer) ExtensionName() string {
+	return "Datadog"
+}
+
+func (t Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := gqlctx.OperationNameOrNameless(oc)
+
+	span, ctx := tracer.StartSpanFromContext(ctx, operationSpanName, t.spanOptions(
+		tracer.ResourceName(name),
+		tracer.StartTime(oc.Stats.OperationStart),
+		tracer.Tag("graphql.operation", name),
+		tracer.Tag("graphql.operation.type", operationType(oc)),
+		tracer.Tag("graphql.source", oc.RawQuery),
+	)...)
+
+	if t.cfg.enricher != nil {
+		for key, value := range t.cfg.enricher(ctx) {
+			span.SetTag(key, value)
+		}
+	}
+
+	res := next(ctx)
+
+	var finishOpts []ddtrace.FinishOption
+	if res != nil && len(res.Errors) != 0 {
+		if codes := extcode.Codes(res.Errors); len(codes) != 0 {
+			span.SetTag("graphql.errors.codes", strings.Join(codes, ","))
+		}
+		finishOpts = append(finishOpts, tracer.WithError(res.Errors))
+	}
+	span.Finish(finishOpts...)
+
+	return res
+}
+
+func (t Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+
+	span, ctx := tracer.StartSpanFromContext(ctx, fieldSpanName, t.spanOptions(
+		tracer.ResourceName(fc.Object+"."+fc.Field.Name),
+		tracer.Tag("graphql.type", fc.Object),
+		tracer.Tag("graphql.field", fc.Field.Name),
+		tracer.Tag("graphql.path", fc.Path().String()),
+	)...)
+
+	res, err := next(ctx)
+
+	var finishOpts []ddtrace.FinishOption
+	if err != nil {
+		if code := extcode.Code(err); code != "" {
+			span.SetTag("graphql.error.code", code)
+		}
+		finishOpts = append(finishOpts, tracer.WithError(err))
+	}
+	span.Finish(finishOpts...)
+
+	return res, err
+}
+
+func (t Tracer) spanOptions(opts ...ddtrace.StartSpanOption) []ddtrace.StartSpanOption {
+	opts = append(opts,
+		tracer.SpanType(ext.SpanTypeGraphQL),
+		tracer.Tag(ext.Component, "gqlgen"),
+	)
+	if t.cfg.serviceName != "" {
+		opts = append(opts, tracer.ServiceName(t.cfg.serviceName))
+	}
+
+	return opts
+}
+
+func operationType(oc *graphql.OperationContext) string {
+	if oc.Operation == nil {
+		return ""
+	}
+	return string(oc.Operation.Operation)
+}
@@ -0,0 +1,80 @@
+package datadog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/datadog"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestTracer(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(datadog.New(datadog.WithServiceName("todo-api")))
+
+	t.Run("operation", func(t *testing.T) {
+		mt.Reset()
+
+		resp := doRequest(srv, `{"query":"query Lookup { todos { id } todo(id: \"unknown\") { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		spans := map[string]mocktracer.Span{}
+		for _, span := range mt.FinishedSpans() {
+			spans[span.Tag(ext.ResourceName).(string)] = span
+		}
+
+		operation := spans["Lookup"]
+		require.NotNil(t, operation)
+		assert.Equal(t, "graphql.request", operation.OperationName())
+		assert.Equal(t, "todo-api", operation.Tag(ext.ServiceName))
+		assert.Equal(t, "Lookup", operation.Tag("graphql.operation"))
+		assert.Equal(t, "query", operation.Tag("graphql.operation.type"))
+		assert.Equal(t, `query Lookup { todos { id } todo(id: "unknown") { id } }`, operation.Tag("graphql.source"))
+		assert.NotNil(t, operation.Tag(ext.ErrorMsg))
+
+		todos := spans["Query.todos"]
+		require.NotNil(t, todos)
+		assert.Equal(t, "graphql.field", todos.OperationName())
+		assert.Equal(t, operation.SpanID(), todos.ParentID())
+		assert.Equal(t, "todos", todos.Tag("graphql.field"))
+		assert.Equal(t, "Query", todos.Tag("graphql.type"))
+		assert.Nil(t, todos.Tag(ext.ErrorMsg))
+
+		todo := spans["Query.todo"]
+		require.NotNil(t, todo)
+		assert.Equal(t, graph.ErrTodoNotFound.Error(), todo.Tag(ext.ErrorMsg))
+	})
+
+	t.Run("error codes", func(t *testing.T) {
+		mt.Reset()
+
+		resp := doRequest(srv, `{"query":"{ unknown }"}`)
+		require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "GRAPHQL_VALIDATION_FAILED", spans[0].Tag("graphql.errors.codes"))
+	})
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,111 @@
+package dataloader
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Factory creates the Loaders of one kind, see Register.
+type Factory[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+	opts  []Option
+}
+
+// Register returns a Factory for Loaders fetching through fetch. It is
+// usually assigned to a package level variable and retrieved with For in
+// every resolver needing it:
+//
+//	var userLoader = dataloader.Register(fetchUsers)
+//
+//	func (r *todoResolver) User(ctx context.Context, obj *Todo) (*User, error) {
+//		return userLoader.For(ctx).Load(ctx, obj.UserID)
+//	}
+func Register[K comparable, V any](fetch BatchFunc[K, V], opts ...Option) *Factory[K, V] {
+	return &Factory[K, V]{
+		fetch: fetch,
+		opts:  opts,
+	}
+}
+
+// New returns a fresh Loader, independent of any request.
+func (f *Factory[K, V]) New() *Loader[K, V] {
+	return NewLoader(f.fetch, f.opts...)
+}
+
+// For returns the Loader of the current request, created on first use.
+// Without Middleware or Extension installed, every call returns a new Loader,
+// so loads are neither batched nor cached.
+func (f *Factory[K, V]) For(ctx context.Context) *Loader[K, V] {
+	s, ok := ctx.Value(loadersKey{}).(*loaders)
+	if !ok {
+		return f.New()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.byFactory[f]; ok {
+		return l.(*Loader[K, V])
+	}
+	l := f.New()
+	s.byFactory[f] = l
+
+	return l
+}
+
+type loadersKey struct{}
+
+// loaders holds the Loaders of one request, keyed by their *Factory.
+type loaders struct {
+	mu        sync.Mutex
+	byFactory map[any]any
+}
+
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{byFactory: map[any]any{}})
+}
+
+// Middleware gives every HTTP request its own Loaders, shared by all
+// operations of a batched request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(withLoaders(r.Context())))
+	})
+}
+
+// Extension is a gqlgen handler extension giving every operation its own
+// Loaders. Unlike Middleware it also gives each event of a subscription its
+// own Loaders, so that values cached for one event are not served stale to
+// the next.
+type Extension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = Extension{}
+
+func (Extension) ExtensionName() string {
+	return "Dataloader"
+}
+
+func (Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Subscription {
+		return next(withLoaders(ctx))
+	}
+
+	// The fields of each event are resolved with the context the response
+	// handler is called with.
+	responses := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		return responses(withLoaders(ctx))
+	}
+}
@@ -0,0 +1,282 @@
+package dataloader_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/dataloader"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a BatchFunc doubling its keys and remembering every batch.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) ([]int, []error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, append([]int(nil), keys...))
+	r.mu.Unlock()
+
+	values := make([]int, len(keys))
+	for i, key := range keys {
+		values[i] = key * 2
+	}
+	return values, nil
+}
+
+func TestLoader_Batch(t *testing.T) {
+	rec := &recorder{}
+	loader := dataloader.NewLoader(rec.fetch, dataloader.WithWait(10*time.Millisecond))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := loader.Load(context.Background(), i)
+			assert.NoError(t, err)
+			assert.Equal(t, i*2, value)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, rec.batches, 1)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, rec.batches[0])
+
+	values, err := loader.LoadAll(context.Background(), []int{3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, []int{6, 8}, values)
+	assert.Len(t, rec.batches, 1, "cached keys are not fetched again")
+}
+
+func TestLoader_MaxBatch(t *testing.T) {
+	rec := &recorder{}
+	loader := dataloader.NewLoader(rec.fetch, dataloader.WithMaxBatch(2))
+
+	values, err := loader.LoadAll(context.Background(), []int{1, 2, 3, 4, 5})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 6, 8, 10}, values)
+
+	sort.Slice(rec.batches, func(i, j int) bool { return rec.batches[i][0] < rec.batches[j][0] })
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, rec.batches)
+}
+
+func TestLoader_Errors(t *testing.T) {
+	errOdd := errors.New("odd")
+	errDown := errors.New("down")
+
+	specs := []struct {
+		SpecName string
+		Fetch    dataloader.BatchFunc[int, int]
+		Expected []error
+	}{
+		{
+			SpecName: "per key",
+			Fetch: func(ctx context.Context, keys []int) ([]int, []error) {
+				errs := make([]error, len(keys))
+				for i, key := range keys {
+					if key%2 == 1 {
+						errs[i] = errOdd
+					}
+				}
+				return make([]int, len(keys)), errs
+			},
+			Expected: []error{errOdd, nil},
+		},
+		{
+			SpecName: "whole batch",
+			Fetch: func(ctx context.Context, keys []int) ([]int, []error) {
+				return nil, []error{errDown}
+			},
+			Expected: []error{errDown, errDown},
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			loader := dataloader.NewLoader(spec.Fetch)
+
+			_, err := loader.LoadAll(context.Background(), []int{1, 2})
+			for _, expected := range spec.Expected {
+				if expected != nil {
+					assert.ErrorIs(t, err, expected)
+				}
+			}
+
+			_, err = loader.Load(context.Background(), 2)
+			if spec.Expected[1] == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, spec.Expected[1])
+			}
+		})
+	}
+
+	t.Run("wrong length", func(t *testing.T) {
+		loader := dataloader.NewLoader(func(ctx context.Context, keys []int) ([]int, []error) {
+			return []int{1}, nil
+		})
+		_, err := loader.LoadAll(context.Background(), []int{1, 2})
+		assert.EqualError(t, err, "dataloader: fetch returned 1 values for 2 keys\ndataloader: fetch returned 1 values for 2 keys")
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		loader := dataloader.NewLoader(func(ctx context.Context, keys []int) ([]int, []error) {
+			panic("boom")
+		})
+		_, err := loader.Load(context.Background(), 1)
+		assert.EqualError(t, err, "dataloader: panic in fetch: boom")
+	})
+}
+
+func TestLoader_PrimeClear(t *testing.T) {
+	rec := &recorder{}
+	loader := dataloader.NewLoader(rec.fetch)
+
+	loader.Prime(1, 100)
+	value, err := loader.Load(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 100, value)
+	assert.Empty(t, rec.batches)
+
+	loader.Clear(1)
+	value, err = loader.Load(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.Len(t, rec.batches, 1)
+}
+
+func TestLoader_Canceled(t *testing.T) {
+	loader := dataloader.NewLoader(func(ctx context.Context, keys []int) ([]int, []error) {
+		<-ctx.Done()
+		return nil, []error{ctx.Err()}
+	}, dataloader.WithWait(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := loader.Load(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMiddleware(t *testing.T) {
+	factory := dataloader.Register((&recorder{}).fetch)
+
+	var same bool
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		same = factory.For(r.Context()) == factory.For(r.Context())
+	})
+
+	doRequest(dataloader.Middleware(h), http.MethodGet, "/", "")
+	assert.True(t, same)
+
+	doRequest(h, http.MethodGet, "/", "")
+	assert.False(t, same, "without middleware every call gets a new loader")
+}
+
+func TestExtension(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	factory := dataloader.Register(func(ctx context.Context, keys []string) ([]string, []error) {
+		mu.Lock()
+		fetched = append(fetched, keys...)
+		mu.Unlock()
+		return keys, nil
+	})
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(dataloader.Extension{})
+	srv.Use(fieldHook(func(ctx context.Context) {
+		fc := graphql.GetFieldContext(ctx)
+		if fc.Object != "Todo" {
+			return
+		}
+		key := fc.Parent.Path().String()
+		value, err := factory.For(ctx).Load(ctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, key, value)
+	}))
+
+	for i := 0; i < 2; i++ {
+		resp := doRequest(srv, http.MethodPost, "/query", `{"query":"{ todos { id text } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	// Each todo is loaded once per operation, however many fields it has.
+	sort.Strings(fetched)
+	expected := []string{}
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("todos[%d]", i)
+		expected = append(expected, key, key)
+	}
+	assert.Equal(t, expected, fetched)
+}
+
+func TestExtension_Subscription(t *testing.T) {
+	var mu sync.Mutex
+	var fetches int
+	factory := dataloader.Register(func(ctx context.Context, keys []string) ([]string, []error) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+		return keys, nil
+	})
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.SSE{})
+	srv.Use(dataloader.Extension{})
+	srv.Use(fieldHook(func(ctx context.Context) {
+		if fc := graphql.GetFieldContext(ctx); fc.Object == "Todo" {
+			_, err := factory.For(ctx).Load(ctx, "todo")
+			assert.NoError(t, err)
+		}
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"subscription { todoAdded { id text } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, 3, fetches, "each event gets its own loaders")
+}
+
+// fieldHook calls fn from inside every resolver.
+type fieldHook func(ctx context.Context)
+
+func (fieldHook) ExtensionName() string                          { return "FieldHook" }
+func (fieldHook) Validate(schema graphql.ExecutableSchema) error { return nil }
+
+func (f fieldHook) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	f(ctx)
+	return next(ctx)
+}
+
+func doRequest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,210 @@
+// Package dataloader batches and caches the loads resolvers make while
+// resolving a single request, avoiding N+1 queries.
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches the values of keys. It returns either one value per key,
+// in the same order, or an error. errs may be nil, hold a single error for
+// the whole batch, or one error per key.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (values []V, errs []error)
+
+// Observer is notified of the activity of Loaders, typically to record
+// metrics. It must be safe for concurrent use.
+type Observer interface {
+	// ObserveLoad is called for every key loaded, hit telling whether it was
+	// already cached or being fetched.
+	ObserveLoad(name string, hit bool)
+	// ObserveBatch is called after every call of a BatchFunc, err being set
+	// when the whole batch failed.
+	ObserveBatch(name string, size int, duration time.Duration, err error)
+}
+
+// Loader batches calls to Load made within a short window into a single call
+// of its BatchFunc, and caches every result for its lifetime, which is
+// typically one request.
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+	cfg   config
+
+	mu    sync.Mutex
+	cache map[K]*result[V]
+	batch *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	ctx     context.Context
+	keys    []K
+	results []*result[V]
+}
+
+// NewLoader returns an empty Loader fetching through fetch.
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch: fetch,
+		cfg:   *newConfig(opts...),
+		cache: map[K]*result[V]{},
+	}
+}
+
+// Load returns the value of key, waiting for the batch it is part of.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	r := l.enqueue(ctx, key)
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadAll returns the values of keys, in order. The returned error joins the
+// errors of every key that failed.
+func (l *Loader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, error) {
+	results := make([]*result[V], len(keys))
+	for i, key := range keys {
+		results[i] = l.enqueue(ctx, key)
+	}
+
+	values := make([]V, len(keys))
+	var errs []error
+	for i, r := range results {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		values[i] = r.value
+		if r.err != nil {
+			errs = append(errs, r.err)
+		}
+	}
+
+	return values, errors.Join(errs...)
+}
+
+// Prime stores value for key unless key is already cached.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	r := &result[V]{done: make(chan struct{}), value: value}
+	close(r.done)
+	l.cache[key] = r
+}
+
+// Clear removes key from the cache, so the next Load fetches it again.
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.cache, key)
+}
+
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) *result[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.cache[key]; ok {
+		l.observeLoad(true)
+		return r
+	}
+	l.observeLoad(false)
+
+	r := &result[V]{done: make(chan struct{})}
+	l.cache[key] = r
+
+	if l.batch == nil {
+		// The batch outlives the Load that started it, so it must not be
+		// canceled along with it.
+		b := &batch[K, V]{ctx: context.WithoutCancel(ctx)}
+		l.batch = b
+		time.AfterFunc(l.cfg.wait, func() { l.dispatch(b) })
+	}
+	l.batch.keys = append(l.batch.keys, key)
+	l.batch.results = append(l.batch.results, r)
+
+	if l.cfg.maxBatch > 0 && len(l.batch.keys) >= l.cfg.maxBatch {
+		b := l.batch
+		l.batch = nil
+		go l.run(b)
+	}
+
+	return r
+}
+
+// dispatch runs b unless it was already run for being full.
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.run(b)
+}
+
+func (l *Loader[K, V]) run(b *batch[K, V]) {
+	start := time.Now()
+	values, errs := l.call(b)
+
+	var batchErr error
+	switch {
+	case len(errs) == 1:
+		batchErr = errs[0]
+	case len(errs) != len(b.keys) && len(values) != len(b.keys):
+		batchErr = fmt.Errorf("dataloader: fetch returned %d values for %d keys", len(values), len(b.keys))
+	}
+	if l.cfg.observer != nil {
+		l.cfg.observer.ObserveBatch(l.cfg.name, len(b.keys), time.Since(start), batchErr)
+	}
+
+	for i, r := range b.results {
+		switch {
+		case batchErr != nil:
+			r.err = batchErr
+		case len(errs) == len(b.keys) && errs[i] != nil:
+			r.err = errs[i]
+		case len(values) != len(b.keys):
+			r.err = fmt.Errorf("dataloader: fetch returned %d values for %d keys", len(values), len(b.keys))
+		default:
+			r.value = values[i]
+		}
+		close(r.done)
+	}
+}
+
+func (l *Loader[K, V]) observeLoad(hit bool) {
+	if l.cfg.observer != nil {
+		l.cfg.observer.ObserveLoad(l.cfg.name, hit)
+	}
+}
+
+func (l *Loader[K, V]) call(b *batch[K, V]) (values []V, errs []error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			values, errs = nil, []error{fmt.Errorf("dataloader: panic in fetch: %v", rec)}
+		}
+	}()
+
+	return l.fetch(b.ctx, b.keys)
+}
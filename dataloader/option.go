@@ -0,0 +1,56 @@
+package dataloader
+
+import "time"
+
+type config struct {
+	wait     time.Duration
+	maxBatch int
+	name     string
+	observer Observer
+}
+
+// Option is anything that can configure a Loader.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		wait:     time.Millisecond,
+		maxBatch: 100,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithWait sets how long a Loader collects keys before fetching them.
+// Defaults to 1ms.
+func WithWait(wait time.Duration) Option {
+	return func(cfg *config) {
+		cfg.wait = wait
+	}
+}
+
+// WithMaxBatch fetches a batch as soon as it holds max keys. Defaults to
+// 100, 0 or less removes the limit.
+func WithMaxBatch(max int) Option {
+	return func(cfg *config) {
+		cfg.maxBatch = max
+	}
+}
+
+// WithName names the Loader, as reported to its Observer.
+func WithName(name string) Option {
+	return func(cfg *config) {
+		cfg.name = name
+	}
+}
+
+// WithObserver reports every load and batch of the Loader to observer.
+func WithObserver(observer Observer) Option {
+	return func(cfg *config) {
+		cfg.observer = observer
+	}
+}
@@ -0,0 +1,231 @@
+// Package debugstats serves the live state of a GraphQL server for
+// debugging: the operations in flight, the errors of the last minutes by
+// code and the hit ratio of the contrib caches.
+package debugstats
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// DebugPath is the conventional path to serve Handler on.
+const DebugPath = "/debug/graphql/stats"
+
+// uncoded is the code errors without extensions.code are counted under.
+const uncoded = "UNCODED"
+
+// errorBuckets is the number of slices the error window is split into.
+const errorBuckets = 10
+
+// Snapshot is the state of a Stats at a point in time.
+type Snapshot struct {
+	InFlight []Operation `json:"in_flight"`
+	// Errors counts the errors returned by operations during the error
+	// window, by extensions.code. Requests failing validation are not
+	// operations and are not counted.
+	Errors map[string]int64 `json:"errors"`
+	// Caches are keyed by the name given to CacheHooks.
+	Caches map[string]Cache `json:"caches"`
+}
+
+// Operation is an operation being executed.
+type Operation struct {
+	Name      string          `json:"name"`
+	Type      string          `json:"type"`
+	Client    clientinfo.Info `json:"client"`
+	Start     time.Time       `json:"start"`
+	ElapsedMs float64         `json:"elapsed_ms"`
+}
+
+// Cache counts the lookups of a cache.
+type Cache struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+type errorBucket struct {
+	start  time.Time
+	counts map[string]int64
+}
+
+// Stats is a gqlgen handler extension tracking the operations in flight and
+// the recent errors. Caches report to it through CacheHooks.
+type Stats struct {
+	cfg *config
+
+	mu       sync.Mutex
+	inFlight map[*Operation]struct{}
+	errors   []errorBucket
+	caches   map[string]*Cache
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Stats{}
+
+// New returns an empty Stats.
+func New(opts ...Option) *Stats {
+	return &Stats{
+		cfg:      newConfig(opts...),
+		inFlight: map[*Operation]struct{}{},
+		caches:   map[string]*Cache{},
+	}
+}
+
+// CacheHooks returns hooks counting the hits and misses of the cache name,
+// to pass to the WithHitMissHooks option of responsecache, memcacheapq or
+// redisapq:
+//
+//	memcacheapq.New(client, memcacheapq.WithHitMissHooks(stats.CacheHooks("apq")))
+func (s *Stats) CacheHooks(name string) (onHit, onMiss func(ctx context.Context)) {
+	s.mu.Lock()
+	if _, ok := s.caches[name]; !ok {
+		s.caches[name] = &Cache{}
+	}
+	s.mu.Unlock()
+
+	count := func(hit bool) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if hit {
+				s.caches[name].Hits++
+			} else {
+				s.caches[name].Misses++
+			}
+		}
+	}
+
+	return count(true), count(false)
+}
+
+// Snapshot returns the current state. Operations in flight are listed
+// oldest first.
+func (s *Stats) Snapshot() Snapshot {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := Snapshot{
+		InFlight: make([]Operation, 0, len(s.inFlight)),
+		Errors:   map[string]int64{},
+		Caches:   make(map[string]Cache, len(s.caches)),
+	}
+	for op := range s.inFlight {
+		o := *op
+		o.ElapsedMs = float64(now.Sub(o.Start)) / float64(time.Millisecond)
+		snapshot.InFlight = append(snapshot.InFlight, o)
+	}
+	sort.Slice(snapshot.InFlight, func(i, j int) bool {
+		return snapshot.InFlight[i].Start.Before(snapshot.InFlight[j].Start)
+	})
+
+	s.expireErrors(now)
+	for _, b := range s.errors {
+		for code, n := range b.counts {
+			snapshot.Errors[code] += n
+		}
+	}
+
+	for name, c := range s.caches {
+		cache := *c
+		if total := cache.Hits + cache.Misses; total != 0 {
+			cache.HitRatio = float64(cache.Hits) / float64(total)
+		}
+		snapshot.Caches[name] = cache
+	}
+
+	return snapshot
+}
+
+func (s *Stats) ExtensionName() string {
+	return "DebugStats"
+}
+
+func (s *Stats) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (s *Stats) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	op := &Operation{
+		Name:   gqlctx.OperationName(oc),
+		Client: clientinfo.ForContext(ctx),
+		Start:  oc.Stats.OperationStart,
+	}
+	if oc.Operation != nil {
+		op.Type = string(oc.Operation.Operation)
+	}
+	subscription := oc.Operation != nil && oc.Operation.Operation == ast.Subscription
+
+	s.mu.Lock()
+	s.inFlight[op] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.inFlight, op)
+			s.mu.Unlock()
+		})
+	}
+
+	responses := next(ctx)
+
+	// Subscriptions stay in flight until the stream ends with a nil response.
+	return func(ctx context.Context) *graphql.Response {
+		res := responses(ctx)
+		if res == nil || !subscription {
+			done()
+		}
+		if res != nil && len(res.Errors) != 0 {
+			s.countErrors(res)
+		}
+		return res
+	}
+}
+
+func (s *Stats) countErrors(res *graphql.Response) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireErrors(now)
+	start := now.Truncate(s.cfg.errorWindow / errorBuckets)
+	if len(s.errors) == 0 || s.errors[len(s.errors)-1].start.Before(start) {
+		s.errors = append(s.errors, errorBucket{start: start, counts: map[string]int64{}})
+	}
+	counts := s.errors[len(s.errors)-1].counts
+
+	for _, err := range res.Errors {
+		code := extcode.Code(err)
+		if code == "" {
+			code = uncoded
+		}
+		counts[code]++
+	}
+}
+
+// expireErrors drops the error buckets that ended before the error window.
+func (s *Stats) expireErrors(now time.Time) {
+	cutoff := now.Add(-s.cfg.errorWindow)
+	i := 0
+	for i < len(s.errors) && !s.errors[i].start.Add(s.cfg.errorWindow/errorBuckets).After(cutoff) {
+		i++
+	}
+	s.errors = slices.Delete(s.errors, 0, i)
+}
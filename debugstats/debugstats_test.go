@@ -0,0 +1,124 @@
+package debugstats_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/debugstats"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/responsecache"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	stats := debugstats.New()
+	release := make(chan struct{})
+	srv := newServer(stats, release)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		doRequest(srv, `{"query":"query Blocked { todos { id } }"}`)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(stats.Snapshot().InFlight) == 1
+	}, time.Second, time.Millisecond)
+	op := stats.Snapshot().InFlight[0]
+	assert.Equal(t, "Blocked", op.Name)
+	assert.Equal(t, "query", op.Type)
+	assert.Equal(t, clientinfo.Info{Name: "web", Version: "2.0"}, op.Client)
+	assert.Positive(t, op.ElapsedMs)
+
+	close(release)
+	<-done
+	assert.Empty(t, stats.Snapshot().InFlight)
+
+	doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+	doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+	assert.Equal(t, map[string]int64{"UNCODED": 2}, stats.Snapshot().Errors)
+
+	// Responses with errors are not cached, so only the second todos query
+	// hits.
+	doRequest(srv, `{"query":"query Blocked { todos { id } }"}`)
+	assert.Equal(t, map[string]debugstats.Cache{
+		"response": {Hits: 1, Misses: 3, HitRatio: 0.25},
+	}, stats.Snapshot().Caches)
+}
+
+func TestStats_ErrorWindow(t *testing.T) {
+	stats := debugstats.New(debugstats.WithErrorWindow(100 * time.Millisecond))
+	release := make(chan struct{})
+	close(release)
+	srv := newServer(stats, release)
+
+	doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+	assert.Len(t, stats.Snapshot().Errors, 1)
+
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, stats.Snapshot().Errors)
+}
+
+func TestHandler(t *testing.T) {
+	stats := debugstats.New()
+	release := make(chan struct{})
+	close(release)
+	srv := newServer(stats, release)
+	doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+
+	w := httptest.NewRecorder()
+	stats.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, debugstats.DebugPath, nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var snapshot debugstats.Snapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	assert.Equal(t, map[string]int64{"UNCODED": 1}, snapshot.Errors)
+	assert.Equal(t, debugstats.Cache{Misses: 1}, snapshot.Caches["response"])
+
+	r := httptest.NewRequest(http.MethodGet, debugstats.DebugPath, nil)
+	r.Header.Set("Accept", "text/html,application/xhtml+xml")
+	w = httptest.NewRecorder()
+	stats.Handler().ServeHTTP(w, r)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<td>UNCODED</td><td>1</td>")
+	assert.Contains(t, w.Body.String(), "<td>response</td><td>0</td><td>1</td><td>0.00</td>")
+}
+
+// newServer blocks the todos resolver until release is closed.
+func newServer(stats *debugstats.Stats, release chan struct{}) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(stats)
+	srv.Use(responsecache.New(responsecache.NewMemoryStore(100),
+		responsecache.WithHitMissHooks(stats.CacheHooks("response")),
+	))
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		if graphql.GetFieldContext(ctx).Field.Name == "todos" {
+			<-release
+		}
+		return next(ctx)
+	})
+	return srv
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("apollographql-client-name", "web")
+	r.Header.Set("apollographql-client-version", "2.0")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
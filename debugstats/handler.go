@@ -0,0 +1,61 @@
+package debugstats
+
+import (
+	"encoding/json"
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var page = template.Must(template.New("debugstats").Funcs(template.FuncMap{
+	"sorted": func(m any) []string {
+		switch m := m.(type) {
+		case map[string]int64:
+			return slices.Sorted(maps.Keys(m))
+		case map[string]Cache:
+			return slices.Sorted(maps.Keys(m))
+		}
+		return nil
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>GraphQL debug stats</title></head>
+<body>
+<h1>In flight</h1>
+<table>
+<tr><th>Operation</th><th>Type</th><th>Client</th><th>Elapsed (ms)</th></tr>
+{{range .InFlight}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{.Client.Name}} {{.Client.Version}}</td><td>{{printf "%.1f" .ElapsedMs}}</td></tr>
+{{end}}</table>
+<h1>Errors</h1>
+<table>
+<tr><th>Code</th><th>Count</th></tr>
+{{$errors := .Errors}}{{range sorted .Errors}}<tr><td>{{.}}</td><td>{{index $errors .}}</td></tr>
+{{end}}</table>
+<h1>Caches</h1>
+<table>
+<tr><th>Cache</th><th>Hits</th><th>Misses</th><th>Hit ratio</th></tr>
+{{$caches := .Caches}}{{range sorted .Caches}}{{$c := index $caches .}}<tr><td>{{.}}</td><td>{{$c.Hits}}</td><td>{{$c.Misses}}</td><td>{{printf "%.2f" $c.HitRatio}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Handler serves the current Snapshot as JSON, or as an HTML page to
+// requests accepting text/html such as those of a browser. It is meant for
+// an internal port only.
+func (s *Stats) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshot := s.Snapshot()
+
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = page.Execute(w, snapshot)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(snapshot)
+	})
+}
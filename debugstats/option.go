@@ -0,0 +1,30 @@
+package debugstats
+
+import "time"
+
+type config struct {
+	errorWindow time.Duration
+}
+
+// Option is anything that can configure Stats.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		errorWindow: 5 * time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithErrorWindow sets over how long errors are counted. It defaults to 5
+// minutes.
+func WithErrorWindow(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.errorWindow = d
+	}
+}
@@ -0,0 +1,150 @@
+// Package deprecation counts how often clients select fields and enum values
+// marked @deprecated, so they can be removed once nobody uses them anymore.
+package deprecation
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/internal/labelguard"
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Tracker is a gqlgen handler extension incrementing
+// graphql_deprecated_field_used_total{object,field,client_name} once per
+// operation for every deprecated field or literal enum value it selects.
+// For enum values, object is the enum type and field the value name.
+type Tracker struct {
+	counter          *prometheusclient.CounterVec
+	registerer       prometheusclient.Registerer
+	clientNameHeader string
+	clientNames      *labelguard.Guard
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = Tracker{}
+
+// New returns a Tracker whose counter is registered on the configured
+// registerer.
+func New(opts ...Option) Tracker {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_deprecated_field_used_total",
+			Help:        "Total number of operations selecting a deprecated field or enum value.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"object", "field", "client_name"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return Tracker{
+		counter:          counter,
+		registerer:       cfg.registerer,
+		clientNameHeader: cfg.clientNameHeader,
+		clientNames:      labelguard.New(nil, cfg.maxClients),
+	}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (t Tracker) UnRegister() {
+	t.registerer.Unregister(t.counter)
+}
+
+func (t Tracker) ExtensionName() string {
+	return "Deprecation"
+}
+
+func (t Tracker) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t Tracker) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil {
+		return next(ctx)
+	}
+
+	clientName := t.clientNames.Value(oc.Headers.Get(t.clientNameHeader))
+	for _, u := range deprecatedUsages(oc.Operation.SelectionSet) {
+		t.counter.WithLabelValues(u.object, u.field, clientName).Inc()
+	}
+
+	return next(ctx)
+}
+
+type usage struct {
+	object string
+	field  string
+}
+
+// deprecatedUsages returns the distinct deprecated fields and enum values
+// selected by set, following fragment spreads.
+func deprecatedUsages(set ast.SelectionSet) []usage {
+	w := walker{
+		seen:      map[usage]bool{},
+		fragments: map[string]bool{},
+	}
+	w.selectionSet(set)
+
+	return w.usages
+}
+
+type walker struct {
+	usages    []usage
+	seen      map[usage]bool
+	fragments map[string]bool
+}
+
+func (w *walker) add(object, field string) {
+	u := usage{object: object, field: field}
+	if w.seen[u] {
+		return
+	}
+	w.seen[u] = true
+	w.usages = append(w.usages, u)
+}
+
+func (w *walker) selectionSet(set ast.SelectionSet) {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if def := sel.Definition; def != nil && def.Directives.ForName("deprecated") != nil {
+				w.add(sel.ObjectDefinition.Name, sel.Name)
+			}
+			for _, arg := range sel.Arguments {
+				w.value(arg.Value)
+			}
+			w.selectionSet(sel.SelectionSet)
+		case *ast.InlineFragment:
+			w.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition == nil || w.fragments[sel.Name] {
+				continue
+			}
+			w.fragments[sel.Name] = true
+			w.selectionSet(sel.Definition.SelectionSet)
+		}
+	}
+}
+
+func (w *walker) value(v *ast.Value) {
+	if v == nil {
+		return
+	}
+
+	if v.Kind == ast.EnumValue && v.Definition != nil {
+		if ev := v.Definition.EnumValues.ForName(v.Raw); ev != nil && ev.Directives.ForName("deprecated") != nil {
+			w.add(v.Definition.Name, v.Raw)
+		}
+	}
+	for _, child := range v.Children {
+		w.value(child.Value)
+	}
+}
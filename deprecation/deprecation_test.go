@@ -0,0 +1,81 @@
+package deprecation_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/deprecation"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracker := deprecation.New(deprecation.WithRegisterer(registry))
+	defer tracker.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tracker)
+
+	queries := []struct {
+		client string
+		body   string
+	}{
+		{"web", `{"query":"{ todos { id completed } }"}`},
+		{"web", `{"query":"{ todos(status: ANY) { ...Fields } } fragment Fields on Todo { completed again: completed }"}`},
+		{"ios", `{"query":"{ todos(status: OPEN) { id done } }"}`},
+	}
+	for _, q := range queries {
+		resp := doRequest(srv, q.client, q.body)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+
+	assert.Contains(t, body, `graphql_deprecated_field_used_total{client_name="web",field="completed",object="Todo"} 2`)
+	assert.Contains(t, body, `graphql_deprecated_field_used_total{client_name="web",field="ANY",object="TodoStatus"} 1`)
+	assert.NotContains(t, body, `client_name="ios"`)
+}
+
+func TestTracker_MaxClients(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracker := deprecation.New(deprecation.WithRegisterer(registry), deprecation.WithMaxClients(1))
+	defer tracker.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tracker)
+	for _, client := range []string{"web", "ios", "android"} {
+		doRequest(srv, client, `{"query":"{ todos { completed } }"}`)
+	}
+
+	body := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "", "").Body.String()
+	assert.Contains(t, body, `graphql_deprecated_field_used_total{client_name="web",field="completed",object="Todo"} 1`)
+	assert.Contains(t, body, `graphql_deprecated_field_used_total{client_name="__overflow__",field="completed",object="Todo"} 2`)
+}
+
+func doRequest(handler http.Handler, client string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if client != "" {
+		r.Header.Set("apollographql-client-name", client)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
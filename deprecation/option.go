@@ -0,0 +1,79 @@
+package deprecation
+
+import (
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	namespace        string
+	subsystem        string
+	constLabels      prometheusclient.Labels
+	registerer       prometheusclient.Registerer
+	clientNameHeader string
+	maxClients       int
+}
+
+// Option is anything that can configure Tracker.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		registerer:       prometheusclient.DefaultRegisterer,
+		clientNameHeader: "apollographql-client-name",
+		maxClients:       50,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithNamespace prefixes the metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metric on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
+
+// WithClientNameHeader reads the client_name label from the given request
+// header instead of apollographql-client-name.
+func WithClientNameHeader(header string) Option {
+	return func(cfg *config) {
+		cfg.clientNameHeader = header
+	}
+}
+
+// WithMaxClients caps the number of distinct client_name label values,
+// which clients choose freely, 50 by default. Values first seen after the
+// cap is reached are reported as "__overflow__". A value of 0 or less
+// disables the cap.
+func WithMaxClients(max int) Option {
+	return func(cfg *config) {
+		cfg.maxClients = max
+	}
+}
@@ -0,0 +1,24 @@
+// Package contrib holds what the extensions of this module share.
+package contrib
+
+import "context"
+
+// Enricher returns extra labels describing the operation in ctx, such as
+// its tenant or region. The observability extensions of this module accept
+// one through their WithEnricher option and attach its labels to the
+// metrics, spans or log records of every operation.
+type Enricher func(ctx context.Context) map[string]string
+
+// Enrichers combines enrichers into one, later ones winning on duplicate
+// keys.
+func Enrichers(enrichers ...Enricher) Enricher {
+	return func(ctx context.Context) map[string]string {
+		labels := map[string]string{}
+		for _, enrich := range enrichers {
+			for key, value := range enrich(ctx) {
+				labels[key] = value
+			}
+		}
+		return labels
+	}
+}
@@ -0,0 +1,22 @@
+package contrib_test
+
+import (
+	"context"
+	"testing"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrichers(t *testing.T) {
+	enrich := contrib.Enrichers(
+		func(ctx context.Context) map[string]string {
+			return map[string]string{"tenant": "acme", "region": "eu"}
+		},
+		func(ctx context.Context) map[string]string {
+			return map[string]string{"region": "us"}
+		},
+	)
+
+	assert.Equal(t, map[string]string{"tenant": "acme", "region": "us"}, enrich(context.Background()))
+}
@@ -0,0 +1,97 @@
+// Package errcode gives every error sent to clients a stable
+// extensions.code, so clients and dashboards can tell error classes apart
+// without parsing messages.
+package errcode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// CodeInternal is the default code of unclassified errors.
+const CodeInternal = "INTERNAL_SERVER_ERROR"
+
+// Presenter classifies errors by code. An error keeps the code already set
+// in its *gqlerror.Error extensions, else takes the code of the first
+// matching WithCode mapping, else the default code. Install it with:
+//
+//	srv.SetErrorPresenter(errcode.New(opts...).Present)
+type Presenter struct {
+	cfg *config
+}
+
+var _ graphql.ErrorPresenterFunc = (*Presenter)(nil).Present
+
+// New returns a Presenter configured by opts.
+func New(opts ...Option) *Presenter {
+	return &Presenter{cfg: newConfig(opts...)}
+}
+
+// Code returns the code err is presented with. Pass it to
+// prometheus.WithErrorCode for resolver metrics to agree with responses.
+func (p *Presenter) Code(err error) string {
+	code, _ := p.classify(err)
+	return code
+}
+
+// Present is a graphql.ErrorPresenterFunc setting extensions.code.
+func (p *Presenter) Present(ctx context.Context, err error) *gqlerror.Error {
+	code, target := p.classify(err)
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	if _, ok := gqlErr.Extensions["code"]; !ok {
+		// Copy, gqlErr may be shared with the resolver that returned it.
+		presented := *gqlErr
+		presented.Extensions = map[string]any{"code": code}
+		for k, v := range gqlErr.Extensions {
+			presented.Extensions[k] = v
+		}
+		gqlErr = &presented
+	}
+
+	if p.cfg.production && !clientFacing(err) {
+		presented := *gqlErr
+		presented.Message = p.cfg.message
+		if target != nil {
+			presented.Message = target.Error()
+		}
+		gqlErr = &presented
+	}
+
+	return gqlErr
+}
+
+// classify returns the code of err and the WithCode target it matched.
+func (p *Presenter) classify(err error) (string, error) {
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		if code, ok := gqlErr.Extensions["code"].(string); ok {
+			return code, nil
+		}
+	}
+
+	for _, m := range p.cfg.codes {
+		if errors.Is(err, m.target) {
+			return m.code, m.target
+		}
+	}
+
+	return p.cfg.defaultCode, nil
+}
+
+// clientFacing reports whether err is a *gqlerror.Error built for clients,
+// in which case its message is never hidden. gqlgen wraps plain resolver
+// errors in a *gqlerror.Error copying their message, those do not count.
+func clientFacing(err error) bool {
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) {
+		return false
+	}
+	if gqlErr.Err != nil && gqlErr.Message == gqlErr.Err.Error() {
+		return clientFacing(gqlErr.Err)
+	}
+	return true
+}
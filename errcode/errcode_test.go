@@ -0,0 +1,147 @@
+package errcode_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/errcode"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+var errForbidden = errors.New("forbidden")
+
+func TestPresenter(t *testing.T) {
+	specs := []struct {
+		SpecName   string
+		Production bool
+		Query      string
+		Expected   string
+	}{
+		{
+			SpecName: "sentinel",
+			Query:    `{"query":"{ todo(id: \"unknown\") { id } }"}`,
+			Expected: `{"errors":[{"message":"todo not found","path":["todo"],"extensions":{"code":"NOT_FOUND"}}],"data":{"todo":null}}`,
+		},
+		{
+			SpecName: "wrapped sentinel",
+			Query:    `{"query":"{ todos { user { id } } }"}`,
+			Expected: `{"errors":[{"message":"user 123: forbidden","path":["todos",0,"user"],"extensions":{"code":"FORBIDDEN"}}],"data":null}`,
+		},
+		{
+			SpecName: "gqlerror code",
+			Query:    `{"query":"{ todos { id text } }"}`,
+			Expected: `{"errors":[{"message":"text is too long","path":["todos",0,"text"],"extensions":{"code":"TOO_LONG","max":10}}],"data":null}`,
+		},
+		{
+			SpecName: "unclassified",
+			Query:    `{"query":"{ todos { done } }"}`,
+			Expected: `{"errors":[{"message":"dial tcp 10.0.0.1:5432: connection refused","path":["todos",0,"done"],"extensions":{"code":"INTERNAL_SERVER_ERROR"}}],"data":null}`,
+		},
+		{
+			SpecName:   "production sentinel",
+			Production: true,
+			Query:      `{"query":"{ todos { user { id } } }"}`,
+			Expected:   `{"errors":[{"message":"forbidden","path":["todos",0,"user"],"extensions":{"code":"FORBIDDEN"}}],"data":null}`,
+		},
+		{
+			SpecName:   "production gqlerror",
+			Production: true,
+			Query:      `{"query":"{ todos { id text } }"}`,
+			Expected:   `{"errors":[{"message":"text is too long","path":["todos",0,"text"],"extensions":{"code":"TOO_LONG","max":10}}],"data":null}`,
+		},
+		{
+			SpecName:   "production unclassified",
+			Production: true,
+			Query:      `{"query":"{ todos { done } }"}`,
+			Expected:   `{"errors":[{"message":"internal system error","path":["todos",0,"done"],"extensions":{"code":"INTERNAL_SERVER_ERROR"}}],"data":null}`,
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			presenter := errcode.New(
+				errcode.WithCode(graph.ErrTodoNotFound, "NOT_FOUND"),
+				errcode.WithCode(errForbidden, "FORBIDDEN"),
+				errcode.WithProductionMode(spec.Production),
+			)
+
+			srv := handler.New(graph.NewExecutableSchema(graph.Config{
+				Resolvers: &graph.Resolver{},
+			}))
+			srv.AddTransport(transport.POST{})
+			srv.SetErrorPresenter(presenter.Present)
+			srv.Use(failingFields{})
+
+			resp := doRequest(srv, http.MethodPost, "/query", spec.Query)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.JSONEq(t, spec.Expected, stripLocations(t, resp.Body.String()))
+		})
+	}
+}
+
+func TestPresenter_Code(t *testing.T) {
+	presenter := errcode.New(
+		errcode.WithCode(errForbidden, "FORBIDDEN"),
+		errcode.WithDefaultCode("UNKNOWN"),
+	)
+
+	assert.Equal(t, "FORBIDDEN", presenter.Code(fmt.Errorf("wrapped: %w", errForbidden)))
+	assert.Equal(t, "UNKNOWN", presenter.Code(errors.New("boom")))
+	assert.Equal(t, "TOO_LONG", presenter.Code(&gqlerror.Error{Extensions: map[string]any{"code": "TOO_LONG"}}))
+}
+
+// failingFields makes the first todo fail to resolve some of its fields.
+type failingFields struct{}
+
+func (failingFields) ExtensionName() string                          { return "FailingFields" }
+func (failingFields) Validate(schema graphql.ExecutableSchema) error { return nil }
+
+func (failingFields) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc.Object != "Todo" || fc.Parent.Index == nil || *fc.Parent.Index != 0 {
+		return next(ctx)
+	}
+
+	switch fc.Field.Name {
+	case "user":
+		return nil, fmt.Errorf("user 123: %w", errForbidden)
+	case "text":
+		return nil, &gqlerror.Error{Message: "text is too long", Extensions: map[string]any{"code": "TOO_LONG", "max": 10}}
+	case "done":
+		return nil, errors.New("dial tcp 10.0.0.1:5432: connection refused")
+	}
+	return next(ctx)
+}
+
+// stripLocations drops the locations of errors, which are irrelevant here.
+func stripLocations(t *testing.T, body string) string {
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	errs, _ := resp["errors"].([]any)
+	for _, e := range errs {
+		delete(e.(map[string]any), "locations")
+	}
+	out, err := json.Marshal(resp)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func doRequest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,62 @@
+package errcode
+
+type config struct {
+	codes       []mapping
+	defaultCode string
+	production  bool
+	message     string
+}
+
+type mapping struct {
+	target error
+	code   string
+}
+
+// Option is anything that can configure Presenter.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		defaultCode: CodeInternal,
+		message:     "internal system error",
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithCode reports errors matching target, as per errors.Is, with code.
+// Mappings are tried in the order they were added.
+func WithCode(target error, code string) Option {
+	return func(cfg *config) {
+		cfg.codes = append(cfg.codes, mapping{target: target, code: code})
+	}
+}
+
+// WithDefaultCode sets the code of errors nothing else classifies. Defaults
+// to INTERNAL_SERVER_ERROR.
+func WithDefaultCode(code string) Option {
+	return func(cfg *config) {
+		cfg.defaultCode = code
+	}
+}
+
+// WithProductionMode hides the message of every unclassified error, as it
+// may leak implementation details. Errors matched by WithCode show the
+// message of their target instead of their own.
+func WithProductionMode(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.production = enabled
+	}
+}
+
+// WithMessage sets the message shown in place of hidden ones. Defaults to
+// "internal system error".
+func WithMessage(message string) Option {
+	return func(cfg *config) {
+		cfg.message = message
+	}
+}
@@ -0,0 +1,206 @@
+// Package expvarstats publishes request and resolver statistics through the
+// standard expvar package, served at /debug/vars by expvar.Handler.
+package expvarstats
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/labelguard"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Operations first seen once maxOperations names are tracked are gathered
+// under "__overflow__", so that clients sending arbitrary names cannot grow
+// the statistics unbounded.
+const maxOperations = 100
+
+// Snapshot is a point in time copy of the statistics of a Stats.
+type Snapshot struct {
+	Started   int64 `json:"started"`
+	Completed int64 `json:"completed"`
+	InFlight  int64 `json:"in_flight"`
+	Errors    int64 `json:"errors"`
+	// Operations are keyed by operation name.
+	Operations map[string]Summary `json:"operations"`
+	// Resolvers are keyed by Object.field.
+	Resolvers map[string]Summary `json:"resolvers"`
+}
+
+// Summary aggregates the calls of an operation or resolver.
+type Summary struct {
+	Count  int64   `json:"count"`
+	Errors int64   `json:"errors"`
+	MinMs  float64 `json:"min_ms"`
+	MaxMs  float64 `json:"max_ms"`
+	MeanMs float64 `json:"mean_ms"`
+	// TotalMs is the sum of the latencies, allowing rates to be derived from
+	// two snapshots.
+	TotalMs float64 `json:"total_ms"`
+}
+
+func (s *Summary) observe(d time.Duration, failed bool) {
+	ms := float64(d) / float64(time.Millisecond)
+	if s.Count == 0 || ms < s.MinMs {
+		s.MinMs = ms
+	}
+	if ms > s.MaxMs {
+		s.MaxMs = ms
+	}
+	s.Count++
+	s.TotalMs += ms
+	s.MeanMs = s.TotalMs / float64(s.Count)
+	if failed {
+		s.Errors++
+	}
+}
+
+// Stats is a gqlgen handler extension gathering request and resolver
+// statistics.
+type Stats struct {
+	mu         sync.Mutex
+	started    int64
+	completed  int64
+	inFlight   int64
+	errors     int64
+	operations map[string]*Summary
+	resolvers  map[string]*Summary
+
+	operationNames *labelguard.Guard
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Stats{}
+
+// New returns a Stats published as the expvar variable name, unless name is
+// empty. Like expvar.Publish, it panics if name is already in use.
+func New(name string) *Stats {
+	s := &Stats{
+		operations: map[string]*Summary{},
+		resolvers:  map[string]*Summary{},
+
+		operationNames: labelguard.New(nil, maxOperations),
+	}
+	if name != "" {
+		expvar.Publish(name, expvar.Func(func() any {
+			return s.Snapshot()
+		}))
+	}
+	return s
+}
+
+// Snapshot returns a copy of the current statistics.
+func (s *Stats) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := Snapshot{
+		Started:    s.started,
+		Completed:  s.completed,
+		InFlight:   s.inFlight,
+		Errors:     s.errors,
+		Operations: make(map[string]Summary, len(s.operations)),
+		Resolvers:  make(map[string]Summary, len(s.resolvers)),
+	}
+	for name, summary := range s.operations {
+		snapshot.Operations[name] = *summary
+	}
+	for name, summary := range s.resolvers {
+		snapshot.Resolvers[name] = *summary
+	}
+
+	return snapshot
+}
+
+func (s *Stats) ExtensionName() string {
+	return "ExpvarStats"
+}
+
+func (s *Stats) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (s *Stats) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	s.mu.Lock()
+	s.started++
+	s.inFlight++
+	s.mu.Unlock()
+
+	oc := graphql.GetOperationContext(ctx)
+	subscription := oc.Operation != nil && oc.Operation.Operation == ast.Subscription
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		})
+	}
+
+	responses := next(ctx)
+
+	// Subscriptions stay in flight until the stream ends with a nil response.
+	return func(ctx context.Context) *graphql.Response {
+		res := responses(ctx)
+		if res == nil || !subscription {
+			done()
+		}
+		return res
+	}
+}
+
+func (s *Stats) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := s.operationNames.Value(gqlctx.OperationName(oc))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completed++
+	s.errors += int64(len(res.Errors))
+
+	summary, ok := s.operations[name]
+	if !ok {
+		summary = &Summary{}
+		s.operations[name] = summary
+	}
+	summary.observe(time.Since(oc.Stats.OperationStart), len(res.Errors) != 0)
+
+	return res
+}
+
+func (s *Stats) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	start := time.Now()
+
+	res, err := next(ctx)
+
+	duration := time.Since(start)
+	key := fc.Object + "." + fc.Field.Name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary, ok := s.resolvers[key]
+	if !ok {
+		summary = &Summary{}
+		s.resolvers[key] = summary
+	}
+	summary.observe(duration, err != nil)
+
+	return res, err
+}
@@ -0,0 +1,84 @@
+package expvarstats_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/expvarstats"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	stats := expvarstats.New("graphql_test")
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(stats)
+
+	for _, query := range []string{
+		`{"query":"query List { todos { id } }"}`,
+		`{"query":"query List { todos { id } }"}`,
+		`{"query":"query Missing { todo(id: \"unknown\") { id } }"}`,
+	} {
+		resp := doRequest(srv, query)
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	snapshot := stats.Snapshot()
+	assert.Equal(t, int64(3), snapshot.Started)
+	assert.Equal(t, int64(3), snapshot.Completed)
+	assert.Equal(t, int64(0), snapshot.InFlight)
+	assert.Equal(t, int64(1), snapshot.Errors)
+
+	list := snapshot.Operations["List"]
+	assert.Equal(t, int64(2), list.Count)
+	assert.Equal(t, int64(0), list.Errors)
+	assert.LessOrEqual(t, list.MinMs, list.MeanMs)
+	assert.LessOrEqual(t, list.MeanMs, list.MaxMs)
+	assert.InDelta(t, list.TotalMs/2, list.MeanMs, 1e-9)
+	assert.Equal(t, int64(1), snapshot.Operations["Missing"].Errors)
+
+	assert.Equal(t, int64(2), snapshot.Resolvers["Query.todos"].Count)
+	assert.Equal(t, int64(6), snapshot.Resolvers["Todo.id"].Count)
+	assert.Equal(t, int64(1), snapshot.Resolvers["Query.todo"].Errors)
+
+	var published expvarstats.Snapshot
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("graphql_test").String()), &published))
+	assert.Equal(t, snapshot.Operations["List"], published.Operations["List"])
+}
+
+func TestStats_OperationCap(t *testing.T) {
+	stats := expvarstats.New("")
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(stats)
+
+	for i := 0; i < 105; i++ {
+		doRequest(srv, `{"query":"query Op`+strings.Repeat("x", i)+` { todos { id } }"}`)
+	}
+
+	snapshot := stats.Snapshot()
+	assert.Len(t, snapshot.Operations, 101)
+	assert.Equal(t, int64(5), snapshot.Operations["__overflow__"].Count)
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,314 @@
+// Package fieldcache caches the results of the resolvers of fields
+// annotated with the @cached directive.
+package fieldcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/entities"
+	"github.com/99designs/gqlgen-contrib/responsecache"
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/sync/singleflight"
+)
+
+// Directive is the name of the directive caching the results of fields,
+// declared as
+//
+//	directive @cached(ttl: String, key: String) on FIELD_DEFINITION
+//
+// ttl being a time.ParseDuration duration such as "30s". key lists, comma
+// separated, the arguments the results depend on, and as parent.name the
+// fields of the parent object, such as "id, parent.owner". By default the
+// key is made of every argument, and of the id of the parent object for
+// fields of other types than Query, whose results are not cached if it has
+// none.
+//
+// Either set skip_runtime in gqlgen.yml and use Cache as an extension, or
+// bind Cache.Directive in the DirectiveRoot.
+const Directive = "cached"
+
+// Cache is a gqlgen handler extension caching the results of the resolvers
+// of fields annotated with @cached, or registered with WithField, in a
+// Store. Concurrent misses of a key run the resolver once, sharing its
+// result. Errors are never cached.
+//
+// Results are stored as JSON, and decoded into the type the resolver
+// returned the first time it ran in the process: they must survive a round
+// trip through encoding/json, which rules out interfaces such as unions.
+//
+// Lookups are counted in graphql_field_cache_requests_total{object,field,
+// result}, result being hit, or miss once per run of the resolver. The
+// lookups served by a run of the resolver along with others are also
+// counted as shared.
+//
+// With WithInvalidator, Invalidate purges the results containing a given
+// object, or resolved for a field of it.
+type Cache struct {
+	store   responsecache.Store
+	cfg     *config
+	counter *prometheusclient.CounterVec
+	group   singleflight.Group
+	types   sync.Map // coordinate -> reflect.Type
+	schema  *ast.Schema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Cache{}
+
+// New returns a Cache keeping results in store, such as a
+// responsecache.MemoryStore or RedisStore, whose counter is registered on
+// the configured registerer.
+func New(store responsecache.Store, opts ...Option) *Cache {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_field_cache_requests_total",
+			Help:        "Total number of lookups of cached fields, by result.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"object", "field", "result"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return &Cache{store: store, cfg: cfg, counter: counter}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (c *Cache) UnRegister() {
+	c.cfg.registerer.Unregister(c.counter)
+}
+
+func (c *Cache) ExtensionName() string {
+	return "FieldCache"
+}
+
+// Validate checks that the fields given to WithField exist, and that the
+// ttl of @cached directives parse.
+func (c *Cache) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema.Schema()
+	for coordinate := range c.cfg.fields {
+		object, name, _ := strings.Cut(coordinate, ".")
+		def := c.schema.Types[object]
+		if def == nil || def.Fields.ForName(name) == nil {
+			return fmt.Errorf("fieldcache: unknown field %s", coordinate)
+		}
+	}
+	for _, def := range c.schema.Types {
+		for _, f := range def.Fields {
+			if _, _, err := directive(f); err != nil {
+				return fmt.Errorf("fieldcache: %s.%s: %w", def.Name, f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Cache) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver || c.root(fc.Object) {
+		return next(ctx)
+	}
+	f, ok := c.cfg.fields[fc.Object+"."+fc.Field.Name]
+	if !ok {
+		// Directives failing to parse were reported by Validate.
+		f, ok, _ = directive(fc.Field.Definition)
+	}
+	if !ok {
+		return next(ctx)
+	}
+	return c.resolve(ctx, fc, f, next)
+}
+
+// Invalidate purges the results containing the object of type typename
+// identified by id, or resolved for a field of it, see
+// responsecache.Invalidator.
+func (c *Cache) Invalidate(ctx context.Context, typename, id string) error {
+	if c.cfg.invalidator == nil {
+		return errors.New("fieldcache: Invalidate requires WithInvalidator")
+	}
+	return c.cfg.invalidator.Invalidate(ctx, typename, id)
+}
+
+// Directive implements @cached, for the DirectiveRoot of servers not
+// using Cache as an extension.
+func (c *Cache) Directive(ctx context.Context, obj any, next graphql.Resolver, ttl *string, key *string) (any, error) {
+	var f field
+	if ttl != nil {
+		var err error
+		if f.ttl, err = time.ParseDuration(*ttl); err != nil {
+			return nil, fmt.Errorf("fieldcache: ttl: %w", err)
+		}
+	}
+	if key != nil {
+		f.key = *key
+	}
+	return c.resolve(ctx, graphql.GetFieldContext(ctx), f, next)
+}
+
+// root reports whether object is the mutation or subscription type, whose
+// fields are never cached.
+func (c *Cache) root(object string) bool {
+	return c.schema != nil && (c.schema.Mutation != nil && c.schema.Mutation.Name == object ||
+		c.schema.Subscription != nil && c.schema.Subscription.Name == object)
+}
+
+// query reports whether object is the query type.
+func (c *Cache) query(object string) bool {
+	return c.schema != nil && c.schema.Query != nil && c.schema.Query.Name == object
+}
+
+// parent returns the JSON object of the parent of fc, nil if it has none.
+func parent(fc *graphql.FieldContext) (map[string]any, error) {
+	if fc.Parent == nil || fc.Parent.Result == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(fc.Parent.Result)
+	if err != nil {
+		return nil, fmt.Errorf("fieldcache: parent of %s.%s: %w", fc.Object, fc.Field.Name, err)
+	}
+	var obj map[string]any
+	_ = json.Unmarshal(data, &obj)
+	return obj, nil
+}
+
+// directive returns the arguments of the @cached directive of def, false if
+// it has none.
+func directive(def *ast.FieldDefinition) (field, bool, error) {
+	if def == nil {
+		return field{}, false, nil
+	}
+	d := def.Directives.ForName(Directive)
+	if d == nil {
+		return field{}, false, nil
+	}
+	var f field
+	if arg := d.Arguments.ForName("ttl"); arg != nil && arg.Value != nil {
+		var err error
+		if f.ttl, err = time.ParseDuration(arg.Value.Raw); err != nil {
+			return field{}, false, fmt.Errorf("ttl: %w", err)
+		}
+	}
+	if arg := d.Arguments.ForName("key"); arg != nil && arg.Value != nil {
+		f.key = arg.Value.Raw
+	}
+	return f, true, nil
+}
+
+func (c *Cache) resolve(ctx context.Context, fc *graphql.FieldContext, f field, next graphql.Resolver) (any, error) {
+	coordinate := fc.Object + "." + fc.Field.Name
+	parent, err := parent(fc)
+	if err != nil {
+		c.cfg.errorHandler(ctx, err)
+		return next(ctx)
+	}
+	if strings.TrimSpace(f.key) == "" && fc.Parent != nil && !c.query(fc.Object) && parent["id"] == nil {
+		// The results of every parent would share a key.
+		c.cfg.errorHandler(ctx, fmt.Errorf("fieldcache: %s: parent has no id, set the key of @cached", coordinate))
+		return next(ctx)
+	}
+	key, err := c.key(ctx, fc, parent, f.key)
+	if err != nil {
+		c.cfg.errorHandler(ctx, err)
+		return next(ctx)
+	}
+
+	if typ, ok := c.types.Load(coordinate); ok {
+		if data, ok, err := c.store.Get(ctx, key); err != nil {
+			c.cfg.errorHandler(ctx, err)
+		} else if ok {
+			v := reflect.New(typ.(reflect.Type))
+			if err := json.Unmarshal(data, v.Interface()); err != nil {
+				c.cfg.errorHandler(ctx, fmt.Errorf("fieldcache: %s: %w", coordinate, err))
+			} else {
+				c.counter.WithLabelValues(fc.Object, fc.Field.Name, "hit").Inc()
+				return v.Elem().Interface(), nil
+			}
+		}
+	}
+
+	res, err, shared := c.group.Do(key, func() (any, error) {
+		c.counter.WithLabelValues(fc.Object, fc.Field.Name, "miss").Inc()
+		res, err := next(ctx)
+		if err != nil {
+			return res, err
+		}
+		if res != nil {
+			c.types.LoadOrStore(coordinate, reflect.TypeOf(res))
+		}
+		data, err := json.Marshal(res)
+		if err != nil {
+			c.cfg.errorHandler(ctx, fmt.Errorf("fieldcache: %s: %w", coordinate, err))
+			return res, nil
+		}
+		ttl := f.ttl
+		if ttl <= 0 {
+			ttl = c.cfg.ttl
+		}
+		if err := c.store.Set(ctx, key, data, ttl); err != nil {
+			c.cfg.errorHandler(ctx, err)
+		} else if c.cfg.invalidator != nil {
+			c.cfg.invalidator.Track(c.store, key, ttl, c.entities(fc, parent, data)...)
+		}
+		return res, nil
+	})
+	if shared {
+		c.counter.WithLabelValues(fc.Object, fc.Field.Name, "shared").Inc()
+	}
+	return res, err
+}
+
+// key returns the store key of the result of fc.
+func (c *Cache) key(ctx context.Context, fc *graphql.FieldContext, parent map[string]any, names string) (string, error) {
+	parts := map[string]any{"vary": c.cfg.vary(ctx)}
+	if strings.TrimSpace(names) == "" {
+		parts["args"] = fc.Args
+		if parent != nil && !c.query(fc.Object) {
+			parts["parent.id"] = parent["id"]
+		}
+	} else {
+		for name := range strings.SplitSeq(names, ",") {
+			name = strings.TrimSpace(name)
+			if field, ok := strings.CutPrefix(name, "parent."); ok {
+				parts[name] = parent[field]
+			} else {
+				parts[name] = fc.Args[name]
+			}
+		}
+	}
+
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", fmt.Errorf("fieldcache: key of %s.%s: %w", fc.Object, fc.Field.Name, err)
+	}
+	sum := sha256.Sum256(data)
+	return "fieldcache:" + fc.Object + "." + fc.Field.Name + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// entities returns the entities the result data of fc depends on: those it
+// contains, and its parent object.
+func (c *Cache) entities(fc *graphql.FieldContext, parent map[string]any, data []byte) []responsecache.Entity {
+	if c.schema == nil {
+		return nil
+	}
+	found := entities.FromType(c.schema, fc.Field.Definition.Type.Name(), data)
+	if id, ok := parent["id"]; ok && id != nil && !c.query(fc.Object) {
+		found = append(found, entities.Entity{Typename: fc.Object, ID: fmt.Sprint(id)})
+	}
+	return found
+}
@@ -0,0 +1,173 @@
+package fieldcache_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/fieldcache"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/responsecache"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestCache(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	cache := fieldcache.New(responsecache.NewMemoryStore(100),
+		fieldcache.WithRegisterer(registry),
+		fieldcache.WithField("Query.todos", 0, ""),
+		fieldcache.WithField("Query.todo", time.Minute, "id"),
+		fieldcache.WithField("Todo.completed", time.Minute, ""),
+	)
+	defer cache.UnRegister()
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	calls := map[string]int{}
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(cache)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		fc := graphql.GetFieldContext(ctx)
+		if !fc.IsResolver {
+			return next(ctx)
+		}
+		mu.Lock()
+		calls[fc.Object+"."+fc.Field.Name]++
+		mu.Unlock()
+		if fc.Field.Name == "todo" {
+			<-release
+		}
+		return next(ctx)
+	})
+
+	query := `{"query":"{ todos { id text completed } }"}`
+	first := doRequest(srv, query)
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.NotContains(t, first.Body.String(), "errors")
+	second := doRequest(srv, query)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, 1, calls["Query.todos"])
+	assert.Equal(t, 3, calls["Todo.completed"])
+
+	// Concurrent misses run the resolver once.
+	var wg sync.WaitGroup
+	responses := make([]string, 2)
+	for i := range responses {
+		wg.Go(func() {
+			responses[i] = doRequest(srv, `{"query":"{ todo(id: \"`+graph.TodoA.ID+`\") { text } }"}`).Body.String()
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, 1, calls["Query.todo"])
+	for _, resp := range responses {
+		assert.JSONEq(t, `{"data":{"todo":{"text":"Todo A"}}}`, resp)
+	}
+
+	metrics := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, series := range []string{
+		`graphql_field_cache_requests_total{field="todos",object="Query",result="hit"} 1`,
+		`graphql_field_cache_requests_total{field="todos",object="Query",result="miss"} 1`,
+		`graphql_field_cache_requests_total{field="completed",object="Todo",result="hit"} 3`,
+		`graphql_field_cache_requests_total{field="todo",object="Query",result="shared"} 2`,
+	} {
+		assert.Contains(t, metrics.Body.String(), series)
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	cache := fieldcache.New(responsecache.NewMemoryStore(100),
+		fieldcache.WithRegisterer(prometheusclient.NewRegistry()),
+		fieldcache.WithInvalidator(responsecache.NewInvalidator()),
+		fieldcache.WithField("Query.todos", 0, ""),
+		fieldcache.WithField("Query.todo", 0, "id"),
+		fieldcache.WithField("Todo.completed", 0, ""),
+	)
+	defer cache.UnRegister()
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(cache)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if fc := graphql.GetFieldContext(ctx); fc.IsResolver {
+			mu.Lock()
+			calls[fc.Object+"."+fc.Field.Name]++
+			mu.Unlock()
+		}
+		return next(ctx)
+	})
+
+	todos := `{"query":"{ todos { id completed } }"}`
+	todoB := `{"query":"{ todo(id: \"` + graph.TodoB.ID + `\") { text } }"}`
+	doRequest(srv, todos)
+	doRequest(srv, todoB)
+	clear(calls)
+
+	require.NoError(t, cache.Invalidate(context.Background(), "Todo", graph.TodoA.ID))
+	doRequest(srv, todos)
+	doRequest(srv, todoB)
+	assert.Equal(t, map[string]int{"Query.todos": 1, "Todo.completed": 1}, calls,
+		"only results containing or resolved for the entity are purged")
+}
+
+func TestCache_ParentWithoutID(t *testing.T) {
+	var errs []error
+	cache := fieldcache.New(responsecache.NewMemoryStore(100),
+		fieldcache.WithRegisterer(prometheusclient.NewRegistry()),
+		fieldcache.WithErrorHandler(func(ctx context.Context, err error) { errs = append(errs, err) }),
+	)
+	defer cache.UnRegister()
+
+	resolve := func(name string) any {
+		ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{Result: &struct{ Name string }{name}})
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object:     "Widget",
+			Field:      graphql.CollectedField{Field: &ast.Field{Name: "label"}},
+			IsResolver: true,
+		})
+		res, err := cache.Directive(ctx, nil, func(ctx context.Context) (any, error) { return name, nil }, nil, nil)
+		require.NoError(t, err)
+		return res
+	}
+
+	assert.Equal(t, "a", resolve("a"))
+	assert.Equal(t, "b", resolve("b"), "parents without id do not share results")
+	assert.Len(t, errs, 2)
+}
+
+func TestCache_Validate(t *testing.T) {
+	cache := fieldcache.New(responsecache.NewMemoryStore(1),
+		fieldcache.WithRegisterer(prometheusclient.NewRegistry()),
+		fieldcache.WithField("Query.unknown", 0, ""),
+	)
+	err := cache.Validate(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	assert.EqualError(t, err, "fieldcache: unknown field Query.unknown")
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
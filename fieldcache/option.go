@@ -0,0 +1,120 @@
+package fieldcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/responsecache"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	ttl          time.Duration
+	fields       map[string]field
+	vary         func(ctx context.Context) string
+	errorHandler func(ctx context.Context, err error)
+	invalidator  *responsecache.Invalidator
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// field is how the results of a field are cached.
+type field struct {
+	ttl time.Duration
+	key string
+}
+
+// Option is anything that can configure Cache.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		ttl:          time.Minute,
+		fields:       map[string]field{},
+		vary:         func(ctx context.Context) string { return "" },
+		errorHandler: func(ctx context.Context, err error) {},
+		registerer:   prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithTTL sets how long results are cached when @cached has no ttl
+// argument. Defaults to one minute.
+func WithTTL(ttl time.Duration) Option {
+	return func(cfg *config) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithField caches the results of the field at coordinate, such as
+// Query.todos, as if it was annotated with @cached(ttl: ttl, key: key). A
+// ttl of 0 is the WithTTL one, an empty key the default key.
+func WithField(coordinate string, ttl time.Duration, key string) Option {
+	return func(cfg *config) {
+		cfg.fields[coordinate] = field{ttl: ttl, key: key}
+	}
+}
+
+// WithVary adds the value returned by fn to the cache keys, so results are
+// only shared between requests for which it is equal, such as those of the
+// same user.
+func WithVary(fn func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.vary = fn
+	}
+}
+
+// WithErrorHandler calls fn with the errors of the store and of the
+// encoding of results, which make lookups misses.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithInvalidator tracks in inv the entities of the cached results, the
+// objects with an id they contain and the parent object of the field, for
+// Invalidate to purge them.
+func WithInvalidator(inv *responsecache.Invalidator) Option {
+	return func(cfg *config) {
+		cfg.invalidator = inv
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,94 @@
+package fieldusage
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// CoveragePath is the conventional path to serve CoverageHandler on.
+const CoveragePath = "/debug/graphql/coverage"
+
+// Coverage tells which fields of a schema operations selected since the
+// Collector was created.
+type Coverage struct {
+	Since  time.Time `json:"since"`
+	Fields int       `json:"fields"`
+	Used   int       `json:"used"`
+	Ratio  float64   `json:"ratio"`
+	// Unused lists the never selected fields as sorted Type.field
+	// coordinates.
+	Unused []string `json:"unused"`
+}
+
+// CoverageReport returns the Coverage of the fields of the object and
+// interface types of es, introspection ones aside. A field selected through
+// an interface only covers the interface field.
+func (c *Collector) CoverageReport(es graphql.ExecutableSchema) Coverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	coverage := Coverage{Since: c.since, Unused: []string{}}
+	for name, def := range es.Schema().Types {
+		if def.Kind != ast.Object && def.Kind != ast.Interface || strings.HasPrefix(name, "__") {
+			continue
+		}
+		for _, field := range def.Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			coverage.Fields++
+			if _, ok := c.used[usageKey{object: name, field: field.Name}]; ok {
+				coverage.Used++
+			} else {
+				coverage.Unused = append(coverage.Unused, name+"."+field.Name)
+			}
+		}
+	}
+	sort.Strings(coverage.Unused)
+	if coverage.Fields != 0 {
+		coverage.Ratio = float64(coverage.Used) / float64(coverage.Fields)
+	}
+
+	return coverage
+}
+
+var coveragePage = template.Must(template.New("coverage").Funcs(template.FuncMap{
+	"percent": func(ratio float64) float64 { return ratio * 100 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>GraphQL schema coverage</title></head>
+<body>
+<h1>Schema coverage</h1>
+<p>{{.Used}} of {{.Fields}} fields selected since {{.Since.Format "2006-01-02 15:04:05 MST"}} ({{printf "%.1f" (percent .Ratio)}}%).</p>
+<h2>Unused fields</h2>
+<ul>
+{{range .Unused}}<li>{{.}}</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// CoverageHandler serves the CoverageReport of es as JSON, or as an HTML
+// page to requests accepting text/html such as those of a browser. It is
+// meant for an internal port only.
+func (c *Collector) CoverageHandler(es graphql.ExecutableSchema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coverage := c.CoverageReport(es)
+
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = coveragePage.Execute(w, coverage)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(coverage)
+	})
+}
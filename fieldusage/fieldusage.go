@@ -0,0 +1,210 @@
+// Package fieldusage counts which fields of the schema each client selects,
+// flushing the counts periodically to a Sink, so unused fields can be
+// deprecated and removed based on data.
+package fieldusage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// OtherClient is the client name of the clients beyond WithMaxClients.
+const OtherClient = "__other__"
+
+// Usage is how many operations of a client selected a field.
+type Usage struct {
+	Object string `json:"object"`
+	Field  string `json:"field"`
+	// Client is the clientinfo name of the client, empty if unknown.
+	Client string `json:"client"`
+	Count  int64  `json:"count"`
+}
+
+// Report holds the usages counted between Start and End, sorted by object,
+// field and client.
+type Report struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Usages []Usage   `json:"usages"`
+}
+
+type usageKey struct {
+	object, field, client string
+}
+
+// Collector is a gqlgen handler extension counting the fields selected by
+// every operation, once per operation whatever the number of times they are
+// selected or resolved. Register it after clientinfo for counts to be per
+// client. Close it to flush the last counts.
+type Collector struct {
+	cfg  *config
+	sink Sink
+
+	mu      sync.Mutex
+	start   time.Time
+	counts  map[usageKey]int64
+	clients map[string]struct{}
+	since   time.Time
+	used    map[usageKey]struct{}
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Collector{}
+
+// New returns a Collector flushing to sink in the background.
+func New(sink Sink, opts ...Option) *Collector {
+	c := &Collector{
+		cfg:     newConfig(opts...),
+		sink:    sink,
+		start:   time.Now(),
+		counts:  map[usageKey]int64{},
+		clients: map[string]struct{}{},
+		used:    map[usageKey]struct{}{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	c.since = c.start
+	go c.run()
+
+	return c
+}
+
+func (c *Collector) ExtensionName() string {
+	return "FieldUsage"
+}
+
+func (c *Collector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (c *Collector) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil {
+		return next(ctx)
+	}
+
+	w := walker{seen: map[usageKey]bool{}, fragments: map[string]bool{}}
+	w.selectionSet(oc.Operation.SelectionSet)
+
+	c.mu.Lock()
+	client := c.client(clientinfo.ForContext(ctx).Name)
+	for key := range w.seen {
+		c.used[key] = struct{}{}
+		key.client = client
+		c.counts[key]++
+	}
+	c.mu.Unlock()
+
+	return next(ctx)
+}
+
+// client returns the name name is counted under. c.mu must be held.
+func (c *Collector) client(name string) string {
+	if name == "" || c.cfg.maxClients <= 0 {
+		return name
+	}
+	if _, ok := c.clients[name]; ok {
+		return name
+	}
+	if len(c.clients) >= c.cfg.maxClients {
+		return OtherClient
+	}
+	c.clients[name] = struct{}{}
+	return name
+}
+
+// Flush sends the usages counted since the last flush to the sink, even if
+// there are none. They are dropped if the sink fails.
+func (c *Collector) Flush(ctx context.Context) error {
+	now := time.Now()
+
+	c.mu.Lock()
+	report := Report{Start: c.start, End: now, Usages: make([]Usage, 0, len(c.counts))}
+	for key, count := range c.counts {
+		report.Usages = append(report.Usages, Usage{Object: key.object, Field: key.field, Client: key.client, Count: count})
+	}
+	c.start = now
+	c.counts = map[usageKey]int64{}
+	c.mu.Unlock()
+
+	sort.Slice(report.Usages, func(i, j int) bool {
+		a, b := report.Usages[i], report.Usages[j]
+		if a.Object != b.Object {
+			return a.Object < b.Object
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.Client < b.Client
+	})
+
+	return c.sink.Flush(ctx, report)
+}
+
+// Close stops the periodic flushes and flushes the last counts.
+func (c *Collector) Close(ctx context.Context) error {
+	c.once.Do(func() { close(c.stop) })
+	<-c.done
+	return c.Flush(ctx)
+}
+
+func (c *Collector) run() {
+	defer close(c.done)
+	if c.cfg.flushInterval <= 0 {
+		<-c.stop
+		return
+	}
+
+	ticker := time.NewTicker(c.cfg.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(context.Background()); err != nil {
+				c.cfg.errorHandler(err)
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+type walker struct {
+	seen      map[usageKey]bool
+	fragments map[string]bool
+}
+
+// selectionSet records the fields selected by set, following fragment
+// spreads. Introspection fields are ignored.
+func (w *walker) selectionSet(set ast.SelectionSet) {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.ObjectDefinition != nil && !strings.HasPrefix(sel.Name, "__") {
+				w.seen[usageKey{object: sel.ObjectDefinition.Name, field: sel.Name}] = true
+				w.selectionSet(sel.SelectionSet)
+			}
+		case *ast.InlineFragment:
+			w.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition == nil || w.fragments[sel.Name] {
+				continue
+			}
+			w.fragments[sel.Name] = true
+			w.selectionSet(sel.Definition.SelectionSet)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package fieldusage_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/fieldusage"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/DATA-DOG/go-sqlmock"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	var mu sync.Mutex
+	var reports []fieldusage.Report
+	collector := fieldusage.New(fieldusage.SinkFunc(func(ctx context.Context, report fieldusage.Report) error {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, report)
+		return nil
+	}), fieldusage.WithFlushInterval(0), fieldusage.WithMaxClients(1))
+
+	srv := newServer(collector)
+	for _, q := range []struct {
+		client string
+		body   string
+	}{
+		{"web", `{"query":"{ todos { id ...Fields } } fragment Fields on Todo { id user { name } }"}`},
+		{"web", `{"query":"{ a: todos { id } b: todos { id } __typename }"}`},
+		{"ios", `{"query":"{ todo(id: \"1\") { id } }"}`},
+		{"", `{"query":"{ __schema { queryType { name } } }"}`},
+	} {
+		resp := doRequest(srv, q.client, q.body)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	require.NoError(t, collector.Close(context.Background()))
+
+	require.Len(t, reports, 1)
+	assert.False(t, reports[0].End.Before(reports[0].Start))
+	assert.Equal(t, []fieldusage.Usage{
+		{Object: "Query", Field: "todo", Client: fieldusage.OtherClient, Count: 1},
+		{Object: "Query", Field: "todos", Client: "web", Count: 2},
+		{Object: "Todo", Field: "id", Client: fieldusage.OtherClient, Count: 1},
+		{Object: "Todo", Field: "id", Client: "web", Count: 2},
+		{Object: "Todo", Field: "user", Client: "web", Count: 1},
+		{Object: "User", Field: "name", Client: "web", Count: 1},
+	}, reports[0].Usages)
+}
+
+func TestCollector_CoverageReport(t *testing.T) {
+	collector := fieldusage.New(fieldusage.SinkFunc(func(ctx context.Context, report fieldusage.Report) error {
+		return nil
+	}), fieldusage.WithFlushInterval(0))
+	defer collector.Close(context.Background())
+
+	es := graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}})
+	srv := newServer(collector)
+	doRequest(srv, "web", `{"query":"{ todos { id text done user { id name } } }"}`)
+	require.NoError(t, collector.Flush(context.Background()))
+	doRequest(srv, "web", `{"query":"mutation { createTodo(input: {text: \"a\", userId: \"1\"}) { id } }"}`)
+
+	coverage := collector.CoverageReport(es)
+	assert.Equal(t, 11, coverage.Fields)
+	assert.Equal(t, 8, coverage.Used)
+	assert.Equal(t, []string{"Query.todo", "Subscription.todoAdded", "Todo.completed"}, coverage.Unused)
+	assert.InDelta(t, 8.0/11, coverage.Ratio, 0.001)
+
+	resp := httptest.NewRecorder()
+	collector.CoverageHandler(es).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, fieldusage.CoveragePath, nil))
+	var served fieldusage.Coverage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &served))
+	assert.Equal(t, coverage.Unused, served.Unused)
+
+	r := httptest.NewRequest(http.MethodGet, fieldusage.CoveragePath, nil)
+	r.Header.Set("Accept", "text/html")
+	resp = httptest.NewRecorder()
+	collector.CoverageHandler(es).ServeHTTP(resp, r)
+	assert.Contains(t, resp.Body.String(), "8 of 11 fields")
+	assert.Contains(t, resp.Body.String(), "<li>Todo.completed</li>")
+}
+
+func TestCollector_FlushInterval(t *testing.T) {
+	flushed := make(chan fieldusage.Report, 10)
+	collector := fieldusage.New(fieldusage.SinkFunc(func(ctx context.Context, report fieldusage.Report) error {
+		flushed <- report
+		return nil
+	}), fieldusage.WithFlushInterval(10*time.Millisecond))
+	defer collector.Close(context.Background())
+
+	doRequest(newServer(collector), "web", `{"query":"{ todos { id } }"}`)
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case report := <-flushed:
+			if len(report.Usages) == 2 {
+				return
+			}
+		case <-timeout:
+			t.Fatal("usages not flushed")
+		}
+	}
+}
+
+func TestPrometheusSink(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	sink := fieldusage.NewPrometheusSink(registry)
+	defer sink.UnRegister()
+
+	report := fieldusage.Report{Usages: []fieldusage.Usage{{Object: "Query", Field: "todos", Client: "web", Count: 3}}}
+	require.NoError(t, sink.Flush(context.Background(), report))
+	require.NoError(t, sink.Flush(context.Background(), report))
+
+	resp := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, resp.Body.String(), `graphql_field_usage_total{client_name="web",field="todos",object="Query"} 6`)
+}
+
+func TestSQLSink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	statement := "INSERT INTO field_usage (period_start, period_end, object, field, client, count) VALUES (?, ?, ?, ?, ?, ?)"
+	start, end := time.Unix(0, 0), time.Unix(60, 0)
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(regexp.QuoteMeta(statement))
+	prepared.ExpectExec().WithArgs(start, end, "Query", "todos", "web", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	prepared.ExpectExec().WithArgs(start, end, "Todo", "id", "", int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	sink := fieldusage.SQLSink{DB: db, Statement: statement}
+	require.NoError(t, sink.Flush(context.Background(), fieldusage.Report{Start: start, End: end, Usages: []fieldusage.Usage{
+		{Object: "Query", Field: "todos", Client: "web", Count: 3},
+		{Object: "Todo", Field: "id", Count: 1},
+	}}))
+	require.NoError(t, sink.Flush(context.Background(), fieldusage.Report{Start: start, End: end}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHTTPSink(t *testing.T) {
+	var received fieldusage.Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if len(received.Usages) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sink := fieldusage.HTTPSink(nil, server.URL)
+	report := fieldusage.Report{Usages: []fieldusage.Usage{{Object: "Query", Field: "todos", Client: "web", Count: 3}}}
+	require.NoError(t, sink.Flush(context.Background(), report))
+	assert.Equal(t, report.Usages, received.Usages)
+
+	assert.Error(t, sink.Flush(context.Background(), fieldusage.Report{}))
+}
+
+func newServer(collector *fieldusage.Collector) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(clientinfo.New())
+	srv.Use(collector)
+	return srv
+}
+
+func doRequest(handler http.Handler, client string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if client != "" {
+		r.Header.Set("apollographql-client-name", client)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,52 @@
+package fieldusage
+
+import "time"
+
+type config struct {
+	flushInterval time.Duration
+	maxClients    int
+	errorHandler  func(err error)
+}
+
+// Option is anything that can configure Collector.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		flushInterval: time.Minute,
+		maxClients:    100,
+		errorHandler:  func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithFlushInterval sets how often the counts are flushed to the sink, every
+// minute by default. A value of 0 or less only flushes on Flush and Close.
+func WithFlushInterval(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.flushInterval = d
+	}
+}
+
+// WithMaxClients caps the number of distinct client names counts are kept
+// for, 100 by default, as clients choose them freely. Clients first seen
+// after the cap is reached are counted as OtherClient. A value of 0 or less
+// disables the cap.
+func WithMaxClients(n int) Option {
+	return func(cfg *config) {
+		cfg.maxClients = n
+	}
+}
+
+// WithErrorHandler is called with the errors of the periodic flushes, which
+// are otherwise dropped along with the counts they failed to send.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
@@ -0,0 +1,132 @@
+package fieldusage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// Sink receives the reports of a Collector. Flush is called from the
+// background goroutine of the Collector, or from Collector.Flush.
+type Sink interface {
+	Flush(ctx context.Context, report Report) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, report Report) error
+
+func (f SinkFunc) Flush(ctx context.Context, report Report) error {
+	return f(ctx, report)
+}
+
+// PrometheusSink adds the reported usages to
+// graphql_field_usage_total{object,field,client_name}.
+type PrometheusSink struct {
+	counter    *prometheusclient.CounterVec
+	registerer prometheusclient.Registerer
+}
+
+// NewPrometheusSink returns a PrometheusSink whose counter is registered on
+// registerer.
+func NewPrometheusSink(registerer prometheusclient.Registerer) *PrometheusSink {
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Name: "graphql_field_usage_total",
+			Help: "Total number of operations selecting a field, by client.",
+		},
+		[]string{"object", "field", "client_name"},
+	)
+	registerer.MustRegister(counter)
+
+	return &PrometheusSink{counter: counter, registerer: registerer}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (s *PrometheusSink) UnRegister() {
+	s.registerer.Unregister(s.counter)
+}
+
+func (s *PrometheusSink) Flush(ctx context.Context, report Report) error {
+	for _, u := range report.Usages {
+		s.counter.WithLabelValues(u.Object, u.Field, u.Client).Add(float64(u.Count))
+	}
+	return nil
+}
+
+// SQLSink executes Statement once per reported usage, in a transaction. It
+// is passed the start and end of the report, the object, field, client and
+// count of the usage, in that order. For example:
+//
+//	INSERT INTO field_usage (period_start, period_end, object, field, client, count)
+//	VALUES ($1, $2, $3, $4, $5, $6)
+type SQLSink struct {
+	DB        *sql.DB
+	Statement string
+}
+
+func (s SQLSink) Flush(ctx context.Context, report Report) error {
+	if len(report.Usages) == 0 {
+		return nil
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("fieldusage: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, s.Statement)
+	if err != nil {
+		return fmt.Errorf("fieldusage: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, u := range report.Usages {
+		if _, err := stmt.ExecContext(ctx, report.Start, report.End, u.Object, u.Field, u.Client, u.Count); err != nil {
+			return fmt.Errorf("fieldusage: insert %s.%s: %w", u.Object, u.Field, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("fieldusage: commit: %w", err)
+	}
+	return nil
+}
+
+// HTTPSink POSTs every report as JSON to url, with client, or
+// http.DefaultClient if nil. Responses other than 2xx are errors.
+func HTTPSink(client *http.Client, url string) Sink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return SinkFunc(func(ctx context.Context, report Report) error {
+		body, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("fieldusage: %s responded %s", url, resp.Status)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+// Package ftv1 adds Apollo federated traces to subgraph responses, so the
+// gateway can show resolver timings of every subgraph in a single trace.
+package ftv1
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/apollotrace"
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const (
+	// headerName is sent by the gateway when it wants a trace.
+	headerName  = "apollo-federation-include-trace"
+	headerValue = "ftv1"
+)
+
+// Tracer is a gqlgen handler extension returning an Apollo trace, base64
+// encoded, as extensions.ftv1 of responses to requests with an
+// apollo-federation-include-trace: ftv1 header. Other requests are not
+// traced.
+type Tracer struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+type recorderKey struct{}
+
+// New returns a Tracer.
+func New() Tracer {
+	return Tracer{}
+}
+
+func (Tracer) ExtensionName() string {
+	return "ApolloFederatedTracingV1"
+}
+
+func (Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (Tracer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Headers.Get(headerName) != headerValue {
+		return next(ctx)
+	}
+
+	return next(context.WithValue(ctx, recorderKey{}, apollotrace.NewRecorder(oc.Stats.OperationStart)))
+}
+
+func (Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	rec, ok := ctx.Value(recorderKey{}).(*apollotrace.Recorder)
+	if !ok {
+		return next(ctx)
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	rec.StartField(fc)
+	res, err := next(ctx)
+	rec.EndField(fc)
+
+	return res, err
+}
+
+func (Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	rec, ok := ctx.Value(recorderKey{}).(*apollotrace.Recorder)
+	if res == nil || !ok {
+		return res
+	}
+
+	rec.AddErrors(res.Errors)
+	trace := apollotrace.Trace{
+		StartTime: graphql.GetOperationContext(ctx).Stats.OperationStart,
+		EndTime:   time.Now(),
+		Root:      rec.Root(),
+	}
+
+	if res.Extensions == nil {
+		res.Extensions = map[string]interface{}{}
+	}
+	res.Extensions["ftv1"] = base64.StdEncoding.EncodeToString(trace.Marshal())
+
+	return res
+}
@@ -0,0 +1,89 @@
+package ftv1_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/ftv1"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestTracer(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(ftv1.New())
+
+	t.Run("without header", func(t *testing.T) {
+		resp := doRequest(srv, false)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "ftv1")
+	})
+
+	t.Run("with header", func(t *testing.T) {
+		resp := doRequest(srv, true)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var body struct {
+			Extensions struct {
+				FTV1 string `json:"ftv1"`
+			} `json:"extensions"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		trace, err := base64.StdEncoding.DecodeString(body.Extensions.FTV1)
+		require.NoError(t, err)
+
+		// Walk Trace.root (14) down to its first child (12) and read its
+		// response_name (1).
+		root := field(t, trace, 14)
+		todo := field(t, root, 12)
+		assert.Equal(t, "todo", string(field(t, todo, 1)))
+		assert.Equal(t, "Query", string(field(t, todo, 13)))
+		assert.NotEmpty(t, field(t, trace, 3), "end_time")
+		assert.NotEmpty(t, field(t, trace, 4), "start_time")
+	})
+}
+
+// field returns the first length delimited field num of the protobuf message
+// b.
+func field(t *testing.T, b []byte, num protowire.Number) []byte {
+	t.Helper()
+
+	for len(b) > 0 {
+		n, typ, l := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, l, 0)
+		b = b[l:]
+
+		l = protowire.ConsumeFieldValue(n, typ, b)
+		require.GreaterOrEqual(t, l, 0)
+		if n == num && typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(b)
+			return v
+		}
+		b = b[l:]
+	}
+
+	t.Fatalf("field %d not found", num)
+	return nil
+}
+
+func doRequest(handler http.Handler, includeTrace bool) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todo(id: \"0be25fcf-20e6-4a6d-b0f9-7804224ef20e\") { id text } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if includeTrace {
+		r.Header.Set("apollo-federation-include-trace", "ftv1")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
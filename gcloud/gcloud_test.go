@@ -0,0 +1,138 @@
+package gcloud_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/gcloud"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/otel"
+	"github.com/99designs/gqlgen-contrib/sloglog"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceID = "105445aa7843bc8bf206b12000100000"
+
+func TestMiddleware(t *testing.T) {
+	specs := []struct {
+		SpecName string
+		Headers  map[string]string
+		TraceID  string
+		SpanID   string
+		Sampled  bool
+	}{
+		{
+			SpecName: "cloud trace context",
+			Headers:  map[string]string{"X-Cloud-Trace-Context": traceID + "/1;o=1"},
+			TraceID:  traceID,
+			SpanID:   "0000000000000001",
+			Sampled:  true,
+		},
+		{
+			SpecName: "unsampled cloud trace context",
+			Headers:  map[string]string{"X-Cloud-Trace-Context": traceID + "/255"},
+			TraceID:  traceID,
+			SpanID:   "00000000000000ff",
+		},
+		{
+			SpecName: "traceparent wins",
+			Headers: map[string]string{
+				"X-Cloud-Trace-Context": traceID + "/1;o=1",
+				"traceparent":           "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			},
+			TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanID:  "00f067aa0ba902b7",
+			Sampled: true,
+		},
+		{
+			SpecName: "invalid",
+			Headers:  map[string]string{"X-Cloud-Trace-Context": "nope/1;o=1"},
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			var sc trace.SpanContext
+			h := gcloud.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sc = trace.SpanContextFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range spec.Headers {
+				r.Header.Set(name, value)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if spec.TraceID == "" {
+				assert.False(t, sc.IsValid())
+				return
+			}
+			assert.Equal(t, spec.TraceID, sc.TraceID().String())
+			assert.Equal(t, spec.SpanID, sc.SpanID().String())
+			assert.Equal(t, spec.Sampled, sc.IsSampled())
+		})
+	}
+}
+
+func TestCloudTraceContext_Inject(t *testing.T) {
+	carrier := propagation.MapCarrier{}
+	ctx := gcloud.CloudTraceContext{}.Extract(context.Background(), propagation.MapCarrier{
+		"X-Cloud-Trace-Context": traceID + "/12345;o=1",
+	})
+	gcloud.CloudTraceContext{}.Inject(ctx, carrier)
+	assert.Equal(t, traceID+"/12345;o=1", carrier.Get("X-Cloud-Trace-Context"))
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(gcloud.NewLogHandler(slog.NewJSONHandler(&buf, nil), "my-project"))
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(otel.New(otel.WithTracerProvider(provider)))
+	srv.Use(sloglog.New(logger))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query List { todos { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Cloud-Trace-Context", traceID+"/1;o=1")
+	w := httptest.NewRecorder()
+	gcloud.Middleware(srv).ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "projects/my-project/traces/"+traceID, record["logging.googleapis.com/trace"])
+	assert.Equal(t, true, record["logging.googleapis.com/trace_sampled"])
+
+	var operation sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "List" {
+			operation = span
+		}
+	}
+	require.NotNil(t, operation)
+	assert.Equal(t, traceID, operation.SpanContext().TraceID().String())
+	assert.Equal(t, operation.SpanContext().SpanID().String(), record["logging.googleapis.com/spanId"])
+
+	buf.Reset()
+	logger.Info("no span")
+	assert.NotContains(t, buf.String(), "logging.googleapis.com")
+}
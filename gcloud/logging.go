@@ -0,0 +1,60 @@
+// Package gcloud correlates GraphQL operations with Google Cloud Trace and
+// Cloud Logging. Spans are created by the otel extension, through a
+// TracerProvider exporting to Cloud Trace; Middleware makes them continue the
+// trace of each request, and NewLogHandler stamps that trace on every log
+// entry so Cloud Console shows logs and traces together.
+package gcloud
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys Cloud Logging correlates structured log entries with traces by.
+const (
+	TraceKey        = "logging.googleapis.com/trace"
+	SpanIDKey       = "logging.googleapis.com/spanId"
+	TraceSampledKey = "logging.googleapis.com/trace_sampled"
+)
+
+// LogAttrs returns the attributes correlating a log entry with the span of
+// ctx, none if ctx has no span.
+func LogAttrs(ctx context.Context, projectID string) []slog.Attr {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []slog.Attr{
+		slog.String(TraceKey, "projects/"+projectID+"/traces/"+sc.TraceID().String()),
+		slog.String(SpanIDKey, sc.SpanID().String()),
+		slog.Bool(TraceSampledKey, sc.IsSampled()),
+	}
+}
+
+type logHandler struct {
+	slog.Handler
+	projectID string
+}
+
+// NewLogHandler wraps h, typically a slog.JSONHandler writing to stdout, to
+// add LogAttrs to every record. Records logged in a group get them in that
+// group, which Cloud Logging does not read.
+func NewLogHandler(h slog.Handler, projectID string) slog.Handler {
+	return &logHandler{Handler: h, projectID: projectID}
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(LogAttrs(ctx, h.projectID)...)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithAttrs(attrs), projectID: h.projectID}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithGroup(name), projectID: h.projectID}
+}
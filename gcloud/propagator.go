@@ -0,0 +1,89 @@
+package gcloud
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceContextHeader is the header Google Cloud load balancers and services
+// propagate traces with, as TRACE_ID/SPAN_ID;o=OPTIONS.
+const TraceContextHeader = "X-Cloud-Trace-Context"
+
+// CloudTraceContext is a propagator for the X-Cloud-Trace-Context header.
+type CloudTraceContext struct{}
+
+var _ propagation.TextMapPropagator = CloudTraceContext{}
+
+func (CloudTraceContext) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	spanID := sc.SpanID()
+	sampled := 0
+	if sc.IsSampled() {
+		sampled = 1
+	}
+	carrier.Set(TraceContextHeader, fmt.Sprintf("%s/%d;o=%d", sc.TraceID(), binary.BigEndian.Uint64(spanID[:]), sampled))
+}
+
+func (CloudTraceContext) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	sc, ok := parseTraceContext(carrier.Get(TraceContextHeader))
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+func (CloudTraceContext) Fields() []string {
+	return []string{TraceContextHeader}
+}
+
+func parseTraceContext(value string) (trace.SpanContext, bool) {
+	value, options, _ := strings.Cut(value, ";")
+	traceIDHex, spanIDDec, _ := strings.Cut(value, "/")
+
+	traceID, err := trace.TraceIDFromHex(traceIDHex)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	var cfg trace.SpanContextConfig
+	cfg.TraceID = traceID
+	cfg.Remote = true
+	if spanIDDec != "" {
+		id, err := strconv.ParseUint(spanIDDec, 10, 64)
+		if err != nil {
+			return trace.SpanContext{}, false
+		}
+		binary.BigEndian.PutUint64(cfg.SpanID[:], id)
+	}
+	if options == "o=1" {
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+
+	sc := trace.NewSpanContext(cfg)
+	return sc, sc.IsValid()
+}
+
+// propagator prefers traceparent over X-Cloud-Trace-Context when a request
+// carries both, as the former is what Google Cloud services now emit.
+var propagator = propagation.NewCompositeTextMapPropagator(CloudTraceContext{}, propagation.TraceContext{})
+
+// Middleware continues the trace of incoming requests, given by their
+// traceparent or X-Cloud-Trace-Context header, so the spans of the otel
+// extension and the entries of Cloud Logging join it.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,179 @@
+// Package getqueries is a GET transport for queries a CDN may cache: only
+// persisted or allowlisted queries are accepted, so that the cache cannot be
+// filled with arbitrary documents, and responses carry the Cache-Control
+// header computed by the cachecontrol extension and an ETag.
+package getqueries
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/cachecontrol"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes set as extensions.code of the errors answered to rejected
+// requests.
+const (
+	ErrCodePersistedQueryRequired = "PERSISTED_QUERY_REQUIRED"
+	ErrCodeQueryStringTooLong     = "QUERY_STRING_TOO_LONG"
+)
+
+// Transport answers GET requests. Register it in place of transport.GET,
+// along with cachecontrol.New and extension.AutomaticPersistedQuery or a
+// persisted query store.
+//
+// Requests must either send the hash of a persisted query in
+// extensions.persistedQuery.sha256Hash, without query, or an allowlisted
+// query; others are answered 400. Automatic persisted queries must hence be
+// registered over POST, as Apollo Client does with useGETForHashedQueries.
+// Mutations are answered 405.
+//
+// Responses without a cache policy, such as those with errors, get
+// "no-store". Requests whose If-None-Match matches the ETag of the response
+// are answered 304.
+type Transport struct {
+	cfg *config
+}
+
+var _ graphql.Transport = &Transport{}
+
+// New returns a Transport.
+func New(opts ...Option) *Transport {
+	return &Transport{cfg: newConfig(opts...)}
+}
+
+func (t *Transport) Supports(r *http.Request) bool {
+	return r.Header.Get("Upgrade") == "" && r.Method == http.MethodGet
+}
+
+func (t *Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	contentType := "application/json"
+	if strings.Contains(r.Header.Get("Accept"), "application/graphql-response+json") {
+		contentType = "application/graphql-response+json"
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	if t.cfg.maxQueryLength > 0 && len(r.URL.RawQuery) > t.cfg.maxQueryLength {
+		reject(w, http.StatusRequestURITooLong, ErrCodeQueryStringTooLong, "query string exceeds "+strconv.Itoa(t.cfg.maxQueryLength)+" bytes")
+		return
+	}
+
+	start := graphql.Now()
+	query, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		reject(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+	params := &graphql.RawParams{
+		Query:         query.Get("query"),
+		OperationName: query.Get("operationName"),
+		Headers:       r.Header,
+	}
+	for key, out := range map[string]*map[string]any{"variables": &params.Variables, "extensions": &params.Extensions} {
+		if value := query.Get(key); value != "" {
+			dec := json.NewDecoder(strings.NewReader(value))
+			dec.UseNumber()
+			if err := dec.Decode(out); err != nil {
+				reject(w, http.StatusBadRequest, "", key+" could not be decoded")
+				return
+			}
+		}
+	}
+	params.ReadTime = graphql.TraceTiming{Start: start, End: graphql.Now()}
+
+	if !t.persisted(params) {
+		reject(w, http.StatusBadRequest, ErrCodePersistedQueryRequired, "GET requests must use persisted queries")
+		return
+	}
+
+	ctx, cacheControl := cachecontrol.Capture(r.Context())
+	rc, errs := exec.CreateOperationContext(ctx, params)
+	if errs != nil {
+		w.Header().Set("Cache-Control", "no-store")
+		status := http.StatusOK
+		if errcode.GetErrorKind(errs) == errcode.KindProtocol {
+			status = http.StatusUnprocessableEntity
+			if contentType == "application/graphql-response+json" {
+				status = http.StatusBadRequest
+			}
+		}
+		w.WriteHeader(status)
+		writeJSON(w, exec.DispatchError(graphql.WithOperationContext(ctx, rc), errs))
+		return
+	}
+	if rc.Operation.Operation != ast.Query {
+		w.Header().Set("Allow", http.MethodPost)
+		reject(w, http.StatusMethodNotAllowed, "", "GET requests only allow query operations")
+		return
+	}
+
+	responses, ctx := exec.DispatchOperation(ctx, rc)
+	body, err := json.Marshal(responses(ctx))
+	if err != nil {
+		reject(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	policy := cacheControl()
+	if policy == "" {
+		policy = "no-store"
+	}
+	w.Header().Set("Cache-Control", policy)
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if matches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+// persisted reports whether params send the hash of a persisted query
+// alone, or an allowlisted query.
+func (t *Transport) persisted(params *graphql.RawParams) bool {
+	if params.Query == "" {
+		pq, _ := params.Extensions["persistedQuery"].(map[string]any)
+		hash, _ := pq["sha256Hash"].(string)
+		return hash != ""
+	}
+	if t.cfg.allowed == nil {
+		return false
+	}
+	sum := sha256.Sum256([]byte(params.Query))
+	return t.cfg.allowed(hex.EncodeToString(sum[:]))
+}
+
+// matches reports whether the If-None-Match header lists etag.
+func matches(ifNoneMatch, etag string) bool {
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func reject(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	err := &gqlerror.Error{Message: message}
+	if code != "" {
+		err.Extensions = map[string]any{"code": code}
+	}
+	writeJSON(w, &graphql.Response{Errors: gqlerror.List{err}})
+}
+
+func writeJSON(w http.ResponseWriter, resp *graphql.Response) {
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,111 @@
+package getqueries_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/cachecontrol"
+	"github.com/99designs/gqlgen-contrib/getqueries"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	todosQuery  = `query Todos { todos { id } }`
+	createQuery = `mutation { createTodo(input: {text: "x", userId: "1"}) { id } }`
+)
+
+func TestTransport(t *testing.T) {
+	srv := newServer(getqueries.WithAllowlist(func(hash string) bool {
+		return hash == sum(todosQuery) || hash == sum(createQuery)
+	}))
+
+	resp := doRequest(srv, url.Values{"query": {`{ todos { text } }`}}, "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), getqueries.ErrCodePersistedQueryRequired)
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+
+	resp = doRequest(srv, url.Values{"query": {todosQuery}}, "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), graph.TodoA.ID)
+	assert.Equal(t, "max-age=60, public", resp.Header().Get("Cache-Control"))
+	etag := resp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	resp = doRequest(srv, url.Values{"query": {todosQuery}}, etag)
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Empty(t, resp.Body.String())
+	assert.Equal(t, "max-age=60, public", resp.Header().Get("Cache-Control"))
+
+	resp = doRequest(srv, url.Values{"query": {createQuery}}, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	assert.Equal(t, http.MethodPost, resp.Header().Get("Allow"))
+}
+
+func TestTransport_PersistedQuery(t *testing.T) {
+	srv := newServer()
+	extensions := `{"persistedQuery":{"version":1,"sha256Hash":"` + sum(todosQuery) + `"}}`
+
+	resp := doRequest(srv, url.Values{"extensions": {extensions}}, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "PERSISTED_QUERY_NOT_FOUND")
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+
+	resp = doRequest(srv, url.Values{"query": {todosQuery}, "extensions": {extensions}}, "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"`+todosQuery+`","extensions":`+extensions+`}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp = doRequest(srv, url.Values{"extensions": {extensions}}, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), graph.TodoA.ID)
+	assert.Equal(t, "max-age=60, public", resp.Header().Get("Cache-Control"))
+}
+
+func TestTransport_MaxQueryLength(t *testing.T) {
+	srv := newServer(getqueries.WithMaxQueryLength(32))
+
+	resp := doRequest(srv, url.Values{"query": {todosQuery}}, "")
+	assert.Equal(t, http.StatusRequestURITooLong, resp.Code)
+	assert.Contains(t, resp.Body.String(), getqueries.ErrCodeQueryStringTooLong)
+}
+
+func newServer(opts ...getqueries.Option) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(getqueries.New(opts...))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](100)})
+	srv.Use(cachecontrol.New())
+	return srv
+}
+
+func sum(query string) string {
+	s := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(s[:])
+}
+
+func doRequest(handler http.Handler, query url.Values, ifNoneMatch string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/query?"+query.Encode(), nil)
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
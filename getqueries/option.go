@@ -0,0 +1,39 @@
+package getqueries
+
+type config struct {
+	allowed        func(hash string) bool
+	maxQueryLength int
+}
+
+// Option is anything that can configure Transport.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		maxQueryLength: 2048,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithAllowlist accepts the requests sending a full query whose hex encoded
+// SHA-256 hash is allowed, such as allowlist.Allowlist.Allowed. Without it,
+// only requests sending the hash of a persisted query are accepted.
+func WithAllowlist(allowed func(hash string) bool) Option {
+	return func(cfg *config) {
+		cfg.allowed = allowed
+	}
+}
+
+// WithMaxQueryLength answers 414 to requests whose query string is longer
+// than n bytes, 2048 by default, under the URL limits of most CDNs. 0 lifts
+// the limit.
+func WithMaxQueryLength(n int) Option {
+	return func(cfg *config) {
+		cfg.maxQueryLength = n
+	}
+}
@@ -6,18 +6,17 @@ import (
 
 	"github.com/99designs/gqlgen-contrib/gqlapollotracing"
 	"github.com/99designs/gqlgen/graphql"
-	"github.com/99designs/gqlgen/handler"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 )
 
 var es graphql.ExecutableSchema
 
 func Example() {
-	handler := handler.GraphQL(
-		es,
-		handler.RequestMiddleware(gqlapollotracing.RequestMiddleware()),
-		handler.Tracer(gqlapollotracing.NewTracer()),
-	)
-	http.Handle("/query", handler)
+	srv := handler.New(es)
+	srv.AddTransport(transport.POST{})
+	srv.Use(gqlapollotracing.NewTracer())
+	http.Handle("/query", srv)
 
 	if err := http.ListenAndServe(":8080", nil); err != nil {
 		log.Fatal(err)
@@ -3,6 +3,8 @@ package gqlapollotracing
 import (
 	"sync"
 	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 type tracingData struct {
@@ -55,8 +57,8 @@ func (e *execution) prepare(td *tracingData) {
 type executionSpan struct {
 	startOffset
 
-	Path       []interface{} `json:"path"`
-	ParentType string        `json:"parentType"`
-	FieldName  string        `json:"fieldName"`
-	ReturnType string        `json:"returnType"`
+	Path       ast.Path `json:"path"`
+	ParentType string   `json:"parentType"`
+	FieldName  string   `json:"fieldName"`
+	ReturnType string   `json:"returnType"`
 }
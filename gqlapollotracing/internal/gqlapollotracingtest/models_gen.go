@@ -2,11 +2,17 @@
 
 package gqlapollotracingtest
 
+type Mutation struct {
+}
+
 type NewTodo struct {
 	Text   string `json:"text"`
 	UserID string `json:"userId"`
 }
 
+type Query struct {
+}
+
 type Todo struct {
 	ID   string `json:"id"`
 	Text string `json:"text"`
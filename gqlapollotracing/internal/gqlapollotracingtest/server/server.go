@@ -6,7 +6,9 @@ import (
 	os "os"
 
 	gqlapollotracingtest "github.com/99designs/gqlgen-contrib/gqlapollotracing/internal/gqlapollotracingtest"
-	handler "github.com/99designs/gqlgen/handler"
+	handler "github.com/99designs/gqlgen/graphql/handler"
+	transport "github.com/99designs/gqlgen/graphql/handler/transport"
+	playground "github.com/99designs/gqlgen/graphql/playground"
 )
 
 const defaultPort = "8080"
@@ -17,8 +19,12 @@ func main() {
 		port = defaultPort
 	}
 
-	http.Handle("/", handler.Playground("GraphQL playground", "/query"))
-	http.Handle("/query", handler.GraphQL(gqlapollotracingtest.NewExecutableSchema(gqlapollotracingtest.Config{Resolvers: &gqlapollotracingtest.Resolver{}})))
+	srv := handler.New(gqlapollotracingtest.NewExecutableSchema(gqlapollotracingtest.Config{Resolvers: gqlapollotracingtest.NewResolver()}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+
+	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
+	http.Handle("/query", srv)
 
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
@@ -9,20 +9,21 @@ import (
 	"time"
 
 	"github.com/99designs/gqlgen-contrib/gqlapollotracing"
-	"github.com/99designs/gqlgen/handler"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewTracer(t *testing.T) {
-	h := handler.GraphQL(
+	h := handler.New(
 		NewExecutableSchema(
 			Config{
 				Resolvers: NewResolver(),
 			},
 		),
-		handler.RequestMiddleware(gqlapollotracing.RequestMiddleware()),
-		handler.Tracer(gqlapollotracing.NewTracer()),
 	)
+	h.AddTransport(transport.POST{})
+	h.Use(gqlapollotracing.NewTracer())
 
 	t.Run("success", func(t *testing.T) {
 		var mu sync.Mutex
@@ -37,7 +38,45 @@ func TestNewTracer(t *testing.T) {
 
 		resp := doRequest(h, "POST", "/query", `{"query":"{ todos { id text } }"}`)
 		assert.Equal(t, http.StatusOK, resp.Code)
-		assert.Equal(t, `{"data":{"todos":[{"id":"Todo:1","text":"Play with cat"}]},"extensions":{"tracing":{"startTime":"2018-10-30T09:00:00.1Z","endTime":"2018-10-30T09:00:01.1Z","duration":1000000000,"parsing":{"startOffset":0,"duration":100000000},"validation":{"startOffset":200000000,"duration":100000000},"execution":{"resolvers":[{"startOffset":400000000,"duration":500000000,"path":["todos"],"parentType":"Query","fieldName":"todos","returnType":"[Todo!]!"},{"startOffset":500000000,"duration":100000000,"path":["todos",0,"id"],"parentType":"Todo","fieldName":"id","returnType":"ID!"},{"startOffset":700000000,"duration":100000000,"path":["todos",0,"text"],"parentType":"Todo","fieldName":"text","returnType":"String!"}]}}}}`, resp.Body.String())
+		assert.Equal(t, `{"data":{"todos":[{"id":"Todo:1","text":"Play with cat"}]},"extensions":{"tracing":{"startTime":"2018-10-30T09:00:00.1Z","endTime":"2018-10-30T09:00:01.4Z","duration":1300000000,"parsing":{"startOffset":300000000,"duration":100000000},"validation":{"startOffset":500000000,"duration":100000000},"execution":{"resolvers":[{"startOffset":700000000,"duration":100000000,"path":["todos"],"parentType":"Query","fieldName":"todos","returnType":"[Todo!]!"},{"startOffset":900000000,"duration":100000000,"path":["todos",0,"id"],"parentType":"Todo","fieldName":"id","returnType":"ID!"},{"startOffset":1100000000,"duration":100000000,"path":["todos",0,"text"],"parentType":"Todo","fieldName":"text","returnType":"String!"}]}}}}`, resp.Body.String())
+	})
+}
+
+func TestNewTracer_OnDemand(t *testing.T) {
+	h := handler.New(
+		NewExecutableSchema(
+			Config{
+				Resolvers: NewResolver(),
+			},
+		),
+	)
+	h.AddTransport(transport.POST{})
+	h.Use(gqlapollotracing.NewTracer(gqlapollotracing.WithOnDemand("X-Apollo-Tracing")))
+
+	t.Run("disabled", func(t *testing.T) {
+		resp := doRequest(h, "POST", "/query", `{"query":"{ todos { id } }"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, `{"data":{"todos":[{"id":"Todo:1"}]}}`, resp.Body.String())
+	})
+
+	t.Run("header", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/query", strings.NewReader(`{"query":"{ todos { id } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Apollo-Tracing", "1")
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, r)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"tracing":{"startTime"`)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/query", strings.NewReader(`{"query":"{ todos { id } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r = r.WithContext(gqlapollotracing.Enable(r.Context()))
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, r)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"tracing":{"startTime"`)
 	})
 }
 
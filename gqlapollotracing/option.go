@@ -0,0 +1,31 @@
+package gqlapollotracing
+
+import "context"
+
+type config struct {
+	onDemand bool
+	header   string
+}
+
+// Option is anything that can configure the tracer returned by NewTracer.
+type Option func(cfg *config)
+
+// WithOnDemand only reports tracing data for requests whose header is set
+// to a true value, as parsed by strconv.ParseBool, or whose context went
+// through Enable, instead of for every request. header may be empty to only
+// rely on Enable.
+func WithOnDemand(header string) Option {
+	return func(cfg *config) {
+		cfg.onDemand = true
+		cfg.header = header
+	}
+}
+
+type enableKey struct{}
+
+// Enable marks the requests run with the returned context as traced, for
+// tracers configured WithOnDemand. Call it from an HTTP middleware, for
+// instance once the caller is known to be a developer.
+func Enable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, enableKey{}, true)
+}
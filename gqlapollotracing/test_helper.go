@@ -1,7 +1,13 @@
 package gqlapollotracing
 
-import "time"
+import (
+	"time"
 
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// SetTimeNowFunc replaces the clock used for tracing timestamps. It shares
+// graphql.Now with gqlgen so operation stats and resolver spans stay aligned.
 func SetTimeNowFunc(f func() time.Time) {
-	timeNowFunc = f
+	graphql.Now = f
 }
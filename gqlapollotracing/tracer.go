@@ -2,106 +2,123 @@ package gqlapollotracing
 
 import (
 	"context"
-	"time"
+	"strconv"
 
 	"github.com/99designs/gqlgen/graphql"
 )
 
-var _ graphql.Tracer = (*tracerImpl)(nil)
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = (*tracerImpl)(nil)
+
+// NewTracer returns an extension that reports Apollo Tracing data in the
+// "tracing" response extension.
+func NewTracer(opts ...Option) graphql.HandlerExtension {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 
-func NewTracer() graphql.Tracer {
-	return &tracerImpl{}
+	return &tracerImpl{
+		onDemand: cfg.onDemand,
+		header:   cfg.header,
+	}
 }
 
-var timeNowFunc = time.Now
-
 var ctxTracingKey = &struct{ tmp string }{}
-var ctxExecSpanKey = &struct{ tmp string }{}
 
 type tracerImpl struct {
+	onDemand bool
+	header   string
 }
 
 func getTracingData(ctx context.Context) *tracingData {
-	return ctx.Value(ctxTracingKey).(*tracingData)
+	td, _ := ctx.Value(ctxTracingKey).(*tracingData)
+	return td
+}
+
+func (t *tracerImpl) ExtensionName() string {
+	return "ApolloTracing"
 }
 
-func getExecutionSpan(ctx context.Context) *executionSpan {
-	return ctx.Value(ctxExecSpanKey).(*executionSpan)
+func (t *tracerImpl) Validate(schema graphql.ExecutableSchema) error {
+	return nil
 }
 
-func (t *tracerImpl) StartOperationParsing(ctx context.Context) context.Context {
-	now := timeNowFunc()
+func (t *tracerImpl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if t.onDemand && !t.enabled(ctx, oc) {
+		return next(ctx)
+	}
+
 	td := &tracingData{
-		StartTime: now,
+		StartTime: oc.Stats.OperationStart,
 		Parsing: &startOffset{
-			StartTime: now,
+			StartTime: oc.Stats.Parsing.Start,
+			EndTime:   oc.Stats.Parsing.End,
+		},
+		Validation: &startOffset{
+			StartTime: oc.Stats.Validation.Start,
+			EndTime:   oc.Stats.Validation.End,
 		},
 	}
 	ctx = context.WithValue(ctx, ctxTracingKey, td)
-	return ctx
-}
+	graphql.RegisterExtension(ctx, "tracing", td)
 
-func (t *tracerImpl) EndOperationParsing(ctx context.Context) {
-	td := getTracingData(ctx)
-	td.Parsing.EndTime = timeNowFunc()
-}
+	res := next(ctx)
 
-func (t *tracerImpl) StartOperationValidation(ctx context.Context) context.Context {
-	td := getTracingData(ctx)
-	td.Validation = &startOffset{}
-	td.Validation.StartTime = timeNowFunc()
-	return ctx
-}
+	td.EndTime = graphql.Now()
+	td.prepare()
 
-func (t *tracerImpl) EndOperationValidation(ctx context.Context) {
-	td := getTracingData(ctx)
-	td.Validation.EndTime = timeNowFunc()
+	return res
 }
 
-func (t *tracerImpl) StartOperationExecution(ctx context.Context) context.Context {
-	return ctx
+// enabled reports whether an on demand tracer reports tracing data for the
+// operation in ctx.
+func (t *tracerImpl) enabled(ctx context.Context, oc *graphql.OperationContext) bool {
+	if enabled, _ := ctx.Value(enableKey{}).(bool); enabled {
+		return true
+	}
+	if t.header == "" {
+		return false
+	}
+	enabled, _ := strconv.ParseBool(oc.Headers.Get(t.header))
+	return enabled
 }
 
-func (t *tracerImpl) StartFieldExecution(ctx context.Context, field graphql.CollectedField) context.Context {
-
+func (t *tracerImpl) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
 	td := getTracingData(ctx)
+	if td == nil {
+		return next(ctx)
+	}
+
+	fc := graphql.GetFieldContext(ctx)
 	es := &executionSpan{
 		startOffset: startOffset{
-			StartTime: timeNowFunc(),
+			StartTime: graphql.Now(),
 		},
-		ParentType: field.ObjectDefinition.Name,
-		FieldName:  field.Name,
-		ReturnType: field.Definition.Type.String(),
+		Path:       fc.Path(),
+		ParentType: fc.Object,
+		FieldName:  fc.Field.Name,
+		ReturnType: fc.Field.Definition.Type.String(),
 	}
-	ctx = context.WithValue(ctx, ctxExecSpanKey, es)
+
 	td.mu.Lock()
-	defer td.mu.Unlock()
 	if td.Execution == nil {
 		td.Execution = &execution{}
 	}
 	td.Execution.Resolvers = append(td.Execution.Resolvers, es)
+	td.mu.Unlock()
 
-	return ctx
-}
-
-func (t *tracerImpl) StartFieldResolverExecution(ctx context.Context, rc *graphql.ResolverContext) context.Context {
-	es := getExecutionSpan(ctx)
-	es.Path = rc.Path()
+	defer func() {
+		es.EndTime = graphql.Now()
+	}()
 
-	return ctx
-}
-
-func (t *tracerImpl) StartFieldChildExecution(ctx context.Context) context.Context {
-	return ctx
-}
-
-func (t *tracerImpl) EndFieldExecution(ctx context.Context) {
-	es := getExecutionSpan(ctx)
-	es.EndTime = timeNowFunc()
-}
-
-func (t *tracerImpl) EndOperationExecution(ctx context.Context) {
-	td := getTracingData(ctx)
-	td.EndTime = timeNowFunc()
-	td.prepare()
+	return next(ctx)
 }
@@ -3,6 +3,7 @@ package gqlopencensus
 import (
 	"context"
 
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
 	"github.com/99designs/gqlgen/graphql"
 	"go.opencensus.io/trace"
 )
@@ -16,19 +17,19 @@ func WithDataDog() Option {
 }
 
 type datadogTracerImpl struct {
-	graphql.Tracer
+	tracer
 }
 
-func (dt *datadogTracerImpl) StartFieldResolverExecution(ctx context.Context, rc *graphql.ResolverContext) context.Context {
-	ctx = dt.Tracer.StartFieldResolverExecution(ctx, rc)
-	span := trace.FromContext(ctx)
-	if !span.IsRecordingEvents() {
-		return ctx
-	}
-	span.AddAttributes(
-		// key from gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext#ResourceName
-		trace.StringAttribute("resource.name", operationName(ctx)),
-	)
+func (dt *datadogTracerImpl) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	return dt.tracer.InterceptField(ctx, func(ctx context.Context) (interface{}, error) {
+		span := trace.FromContext(ctx)
+		if span.IsRecordingEvents() {
+			span.AddAttributes(
+				// key from gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext#ResourceName
+				trace.StringAttribute("resource.name", gqlctx.OperationNameOrNameless(graphql.GetOperationContext(ctx))),
+			)
+		}
 
-	return ctx
+		return next(ctx)
+	})
 }
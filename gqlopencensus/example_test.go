@@ -6,7 +6,9 @@ import (
 
 	"github.com/99designs/gqlgen-contrib/gqlopencensus"
 	"github.com/99designs/gqlgen/graphql"
-	"github.com/99designs/gqlgen/handler"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"go.opencensus.io/stats/view"
 )
 
 var es graphql.ExecutableSchema
@@ -15,11 +17,15 @@ func Example() {
 	// NOTE: requires setting of Exporter
 	//   trace.RegisterExporter(exporter)
 
-	handler := handler.GraphQL(
-		es,
-		handler.Tracer(gqlopencensus.New()),
-	)
-	http.Handle("/query", handler)
+	srv := handler.New(es)
+	srv.AddTransport(transport.POST{})
+	srv.Use(gqlopencensus.New())
+
+	if err := view.Register(gqlopencensus.DefaultViews...); err != nil {
+		log.Fatal(err)
+	}
+	srv.Use(gqlopencensus.Stats{})
+	http.Handle("/query", srv)
 
 	if err := http.ListenAndServe(":8080", nil); err != nil {
 		log.Fatal(err)
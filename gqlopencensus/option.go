@@ -1,9 +1,7 @@
 package gqlopencensus
 
-import "github.com/99designs/gqlgen/graphql"
-
 type config struct {
-	tracer graphql.Tracer
+	tracer tracer
 }
 
 // Option is anything that can configure Tracer.
@@ -0,0 +1,177 @@
+package gqlopencensus
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Measures recorded by Stats, mirroring the metrics of the prometheus
+// package.
+var (
+	RequestCount        = stats.Int64("graphql/requests", "Number of completed requests", stats.UnitDimensionless)
+	RequestLatency      = stats.Float64("graphql/request_latency", "Time taken to handle a request", stats.UnitMilliseconds)
+	RequestErrors       = stats.Int64("graphql/request_errors", "Number of errors returned in responses", stats.UnitDimensionless)
+	ResolverCount       = stats.Int64("graphql/resolvers", "Number of completed resolvers", stats.UnitDimensionless)
+	ResolverLatency     = stats.Float64("graphql/resolver_latency", "Time taken to resolve a field", stats.UnitMilliseconds)
+	OperationComplexity = stats.Int64("graphql/operation_complexity", "Complexity of executed operations", stats.UnitDimensionless)
+)
+
+// Tags set on the measures recorded by Stats.
+var (
+	KeyOperationName = tag.MustNewKey("graphql.operation_name")
+	KeyOperationType = tag.MustNewKey("graphql.operation_type")
+	KeyExitStatus    = tag.MustNewKey("graphql.exit_status")
+	KeyErrorCode     = tag.MustNewKey("graphql.error_code")
+	KeyObject        = tag.MustNewKey("graphql.object")
+	KeyField         = tag.MustNewKey("graphql.field")
+)
+
+var (
+	latencyDistribution    = view.Distribution(1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024)
+	complexityDistribution = view.Distribution(1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048)
+)
+
+// Views aggregating the measures recorded by Stats.
+var (
+	RequestCountView = &view.View{
+		Measure:     RequestCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyOperationName, KeyOperationType, KeyExitStatus},
+	}
+	RequestLatencyView = &view.View{
+		Measure:     RequestLatency,
+		Aggregation: latencyDistribution,
+		TagKeys:     []tag.Key{KeyOperationName, KeyOperationType, KeyExitStatus, KeyErrorCode},
+	}
+	RequestErrorsView = &view.View{
+		Measure:     RequestErrors,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{KeyOperationName, KeyErrorCode},
+	}
+	ResolverCountView = &view.View{
+		Measure:     ResolverCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyObject, KeyField, KeyExitStatus},
+	}
+	ResolverLatencyView = &view.View{
+		Measure:     ResolverLatency,
+		Aggregation: latencyDistribution,
+		TagKeys:     []tag.Key{KeyObject, KeyField, KeyExitStatus, KeyErrorCode},
+	}
+	OperationComplexityView = &view.View{
+		Measure:     OperationComplexity,
+		Aggregation: complexityDistribution,
+		TagKeys:     []tag.Key{KeyOperationName, KeyOperationType},
+	}
+)
+
+// DefaultViews are all the views above, to register with view.Register.
+var DefaultViews = []*view.View{
+	RequestCountView,
+	RequestLatencyView,
+	RequestErrorsView,
+	ResolverCountView,
+	ResolverLatencyView,
+	OperationComplexityView,
+}
+
+const (
+	exitStatusSuccess = "success"
+	exitStatusFailure = "failure"
+)
+
+// Stats is a gqlgen handler extension recording OpenCensus measures for
+// operations and field resolvers. Register DefaultViews, or views of your
+// own, to aggregate them.
+type Stats struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Stats{}
+
+func (Stats) ExtensionName() string {
+	return "OpenCensusStats"
+}
+
+func (Stats) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (Stats) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	var operationType string
+	if oc.Operation != nil {
+		operationType = string(oc.Operation.Operation)
+	}
+	operation := []tag.Mutator{
+		tag.Upsert(KeyOperationName, gqlctx.OperationNameOrNameless(oc)),
+		tag.Upsert(KeyOperationType, operationType),
+	}
+
+	exitStatus, errCode := exitStatusSuccess, ""
+	if len(res.Errors) != 0 {
+		exitStatus, errCode = exitStatusFailure, extcode.Code(res.Errors[0])
+	}
+
+	_ = stats.RecordWithTags(ctx, append(operation,
+		tag.Upsert(KeyExitStatus, exitStatus),
+		tag.Upsert(KeyErrorCode, errCode),
+	),
+		RequestCount.M(1),
+		RequestLatency.M(milliseconds(time.Since(oc.Stats.OperationStart))),
+	)
+
+	for _, err := range res.Errors {
+		_ = stats.RecordWithTags(ctx, append(operation, tag.Upsert(KeyErrorCode, extcode.Code(err))), RequestErrors.M(1))
+	}
+
+	// Stats are only present when the ComplexityLimit extension is in use.
+	if complexity := extension.GetComplexityStats(ctx); complexity != nil {
+		_ = stats.RecordWithTags(ctx, operation, OperationComplexity.M(int64(complexity.Complexity)))
+	}
+
+	return res
+}
+
+func (Stats) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	start := time.Now()
+
+	res, err := next(ctx)
+
+	exitStatus, errCode := exitStatusSuccess, ""
+	if errList := gqlctx.FieldErrors(ctx, fc, err); len(errList) != 0 {
+		exitStatus, errCode = exitStatusFailure, extcode.Code(errList[0])
+	}
+
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(KeyObject, fc.Object),
+		tag.Upsert(KeyField, fc.Field.Name),
+		tag.Upsert(KeyExitStatus, exitStatus),
+		tag.Upsert(KeyErrorCode, errCode),
+	},
+		ResolverCount.M(1),
+		ResolverLatency.M(milliseconds(time.Since(start))),
+	)
+
+	return res, err
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -4,15 +4,23 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"go.opencensus.io/trace"
 )
 
-var _ graphql.Tracer = (tracerImpl)(0)
+type tracer interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+}
+
+var _ tracer = (tracerImpl)(0)
 
 // New returns Tracer for OpenCensus.
 // see https://go.opencensus.io/trace
-func New(opts ...Option) graphql.Tracer {
+func New(opts ...Option) graphql.HandlerExtension {
 	var tracer tracerImpl
 	cfg := &config{tracer}
 
@@ -25,56 +33,61 @@ func New(opts ...Option) graphql.Tracer {
 
 type tracerImpl int
 
-func (tracerImpl) StartOperationParsing(ctx context.Context) context.Context {
-	return ctx
-}
-
-func (tracerImpl) EndOperationParsing(ctx context.Context) {
+func (tracerImpl) ExtensionName() string {
+	return "OpenCensus"
 }
 
-func (tracerImpl) StartOperationValidation(ctx context.Context) context.Context {
-	return ctx
+func (tracerImpl) Validate(schema graphql.ExecutableSchema) error {
+	return nil
 }
 
-func (tracerImpl) EndOperationValidation(ctx context.Context) {
-}
+func (tracerImpl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
 
-func (tracerImpl) StartOperationExecution(ctx context.Context) context.Context {
-	ctx, span := trace.StartSpan(ctx, operationName(ctx))
+	ctx, span := trace.StartSpan(ctx, gqlctx.OperationNameOrNameless(graphql.GetOperationContext(ctx)))
+	defer span.End()
 	if !span.IsRecordingEvents() {
-		return ctx
+		return next(ctx)
 	}
-	requestContext := graphql.GetRequestContext(ctx)
+
+	oc := graphql.GetOperationContext(ctx)
 	span.AddAttributes(
-		trace.StringAttribute("request.query", requestContext.RawQuery),
+		trace.StringAttribute("request.query", oc.RawQuery),
 	)
-	if requestContext.ComplexityLimit > 0 {
+	if stats := extension.GetComplexityStats(ctx); stats != nil {
 		span.AddAttributes(
-			trace.Int64Attribute("request.complexityLimit", int64(requestContext.ComplexityLimit)),
-			trace.Int64Attribute("request.operationComplexity", int64(requestContext.OperationComplexity)),
+			trace.Int64Attribute("request.complexityLimit", int64(stats.ComplexityLimit)),
+			trace.Int64Attribute("request.operationComplexity", int64(stats.Complexity)),
 		)
 	}
 
-	for key, val := range requestContext.Variables {
+	for key, val := range oc.Variables {
 		span.AddAttributes(
 			trace.StringAttribute(fmt.Sprintf("request.variables.%s", key), fmt.Sprintf("%+v", val)),
 		)
 	}
 
-	return ctx
+	return next(ctx)
 }
 
-func (tracerImpl) StartFieldExecution(ctx context.Context, field graphql.CollectedField) context.Context {
-	ctx, span := trace.StartSpan(ctx, field.ObjectDefinition.Name+"/"+field.Name)
+func (tracerImpl) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+
+	ctx, span := trace.StartSpan(ctx, fc.Object+"/"+fc.Field.Name)
+	defer span.End()
 	if !span.IsRecordingEvents() {
-		return ctx
+		return next(ctx)
 	}
+
 	span.AddAttributes(
-		trace.StringAttribute("resolver.object", field.ObjectDefinition.Name),
-		trace.StringAttribute("resolver.field", field.Name),
-		trace.StringAttribute("resolver.alias", field.Alias),
+		trace.StringAttribute("resolver.object", fc.Object),
+		trace.StringAttribute("resolver.field", fc.Field.Name),
+		trace.StringAttribute("resolver.alias", fc.Field.Alias),
+		trace.StringAttribute("resolver.path", fc.Path().String()),
 	)
-	for _, arg := range field.Arguments {
+	for _, arg := range fc.Field.Arguments {
 		if arg.Value != nil {
 			span.AddAttributes(
 				trace.StringAttribute(fmt.Sprintf("resolver.args.%s", arg.Name), arg.Value.String()),
@@ -82,39 +95,13 @@ func (tracerImpl) StartFieldExecution(ctx context.Context, field graphql.Collect
 		}
 	}
 
-	return ctx
-}
-
-func (tracerImpl) StartFieldResolverExecution(ctx context.Context, rc *graphql.ResolverContext) context.Context {
-	span := trace.FromContext(ctx)
-	if !span.IsRecordingEvents() {
-		return ctx
-	}
-	span.AddAttributes(
-		trace.StringAttribute("resolver.path", fmt.Sprintf("%+v", rc.Path())),
-	)
-
-	return ctx
-}
-
-func (tracerImpl) StartFieldChildExecution(ctx context.Context) context.Context {
-	return ctx
-}
-
-func (tracerImpl) EndFieldExecution(ctx context.Context) {
-	span := trace.FromContext(ctx)
-	defer span.End()
-	if !span.IsRecordingEvents() {
-		return
-	}
+	res, err := next(ctx)
 
-	rc := graphql.GetResolverContext(ctx)
-	reqCtx := graphql.GetRequestContext(ctx)
-	errList := reqCtx.GetErrors(rc)
+	errList := gqlctx.FieldErrors(ctx, fc, err)
 	if len(errList) != 0 {
 		span.SetStatus(trace.Status{
 			Code:    2, // UNKNOWN, HTTP Mapping: 500 Internal Server Error
-			Message: errList.Error(),
+			Message: errList[0].Error(),
 		})
 		span.AddAttributes(
 			trace.BoolAttribute("resolver.hasError", true),
@@ -126,22 +113,6 @@ func (tracerImpl) EndFieldExecution(ctx context.Context) {
 			)
 		}
 	}
-}
-
-func (tracerImpl) EndOperationExecution(ctx context.Context) {
-	span := trace.FromContext(ctx)
-	defer span.End()
-}
-
-func operationName(ctx context.Context) string {
-	requestContext := graphql.GetRequestContext(ctx)
-	requestName := "nameless-operation"
-	if requestContext.Doc != nil && len(requestContext.Doc.Operations) != 0 {
-		op := requestContext.Doc.Operations[0]
-		if op.Name != "" {
-			requestName = op.Name
-		}
-	}
 
-	return requestName
+	return res, err
 }
@@ -2,13 +2,23 @@ package gqlopencensus_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/stretchr/testify/assert"
-	"github.com/vektah/gqlparser/ast"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 	"go.opencensus.io/trace"
 )
 
@@ -44,7 +54,7 @@ func TestTracer(t *testing.T) {
 
 	specs := []struct {
 		SpecName      string
-		Tracer        graphql.Tracer
+		Tracer        graphql.HandlerExtension
 		Sampler       trace.Sampler
 		ExpectedAttrs []map[string]interface{}
 	}{
@@ -57,7 +67,7 @@ func TestTracer(t *testing.T) {
 					"resolver.object": "OD",
 					"resolver.field":  "F",
 					"resolver.alias":  "F",
-					"resolver.path":   "[]",
+					"resolver.path":   "F",
 				},
 				{
 					"request.query":               "query { foobar }",
@@ -82,7 +92,7 @@ func TestTracer(t *testing.T) {
 					"resolver.object": "OD",
 					"resolver.field":  "F",
 					"resolver.alias":  "F",
-					"resolver.path":   "[]",
+					"resolver.path":   "F",
 					"resource.name":   "nameless-operation",
 				},
 				{
@@ -107,33 +117,43 @@ func TestTracer(t *testing.T) {
 			defer mu.Unlock()
 			exporter.Reset()
 
-			tracer := spec.Tracer
-			ctx := context.Background()
-			ctx = graphql.WithRequestContext(ctx, &graphql.RequestContext{
+			tracer := spec.Tracer.(interface {
+				graphql.ResponseInterceptor
+				graphql.FieldInterceptor
+			})
+			oc := &graphql.OperationContext{
 				RawQuery: "query { foobar }",
 				Variables: map[string]interface{}{
 					"fizz": "buzz",
 				},
-				ComplexityLimit:     1000,
-				OperationComplexity: 100,
+			}
+			oc.Stats.SetExtension("ComplexityLimit", &extension.ComplexityStats{
+				Complexity:      100,
+				ComplexityLimit: 1000,
 			})
+			ctx := context.Background()
+			ctx = graphql.WithOperationContext(ctx, oc)
+			ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
 			ctx, _ = trace.StartSpan(ctx, "test", trace.WithSampler(spec.Sampler))
-			ctx = tracer.StartOperationExecution(ctx)
-			{
-				ctx2 := tracer.StartFieldExecution(ctx, graphql.CollectedField{
-					Field: &ast.Field{
-						Name:  "F",
-						Alias: "F",
-						ObjectDefinition: &ast.Definition{
-							Name: "OD",
+			tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+				ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+					Object: "OD",
+					Field: graphql.CollectedField{
+						Field: &ast.Field{
+							Name:  "F",
+							Alias: "F",
+							ObjectDefinition: &ast.Definition{
+								Name: "OD",
+							},
 						},
 					},
 				})
-				ctx2 = tracer.StartFieldResolverExecution(ctx2, &graphql.ResolverContext{})
-				ctx2 = tracer.StartFieldChildExecution(ctx2)
-				tracer.EndFieldExecution(ctx2)
-			}
-			tracer.EndOperationExecution(ctx)
+				_, _ = tracer.InterceptField(ctx, func(ctx context.Context) (interface{}, error) {
+					return nil, nil
+				})
+
+				return &graphql.Response{}
+			})
 
 			if len(spec.ExpectedAttrs) == 0 && len(exporter.Spans) != 0 {
 				t.Errorf("unexpected spans: %+v", exporter.Spans)
@@ -148,3 +168,63 @@ func TestTracer(t *testing.T) {
 		})
 	}
 }
+
+func TestStats(t *testing.T) {
+	require.NoError(t, view.Register(gqlopencensus.DefaultViews...))
+	defer view.Unregister(gqlopencensus.DefaultViews...)
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.FixedComplexityLimit(100))
+	srv.Use(gqlopencensus.Stats{})
+
+	for _, query := range []string{
+		`{"query":"query List { todos { id } }"}`,
+		`{"query":"query Missing { todo(id: \"unknown\") { id } }"}`,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(query))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	counts := map[string]int64{}
+	rows, err := view.RetrieveData(gqlopencensus.RequestCountView.Name)
+	require.NoError(t, err)
+	for _, row := range rows {
+		counts[tagValue(row.Tags, gqlopencensus.KeyOperationName)+"/"+tagValue(row.Tags, gqlopencensus.KeyExitStatus)] += row.Data.(*view.CountData).Value
+	}
+	assert.Equal(t, map[string]int64{"List/success": 1, "Missing/failure": 1}, counts)
+
+	rows, err = view.RetrieveData(gqlopencensus.ResolverCountView.Name)
+	require.NoError(t, err)
+	counts = map[string]int64{}
+	for _, row := range rows {
+		counts[tagValue(row.Tags, gqlopencensus.KeyObject)+"."+tagValue(row.Tags, gqlopencensus.KeyField)] += row.Data.(*view.CountData).Value
+	}
+	assert.Equal(t, int64(1), counts["Query.todos"])
+	assert.Equal(t, int64(3), counts["Todo.id"])
+	assert.Equal(t, int64(1), counts["Query.todo"])
+
+	rows, err = view.RetrieveData(gqlopencensus.OperationComplexityView.Name)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	rows, err = view.RetrieveData(gqlopencensus.RequestErrorsView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Missing", tagValue(rows[0].Tags, gqlopencensus.KeyOperationName))
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func tagValue(tags []tag.Tag, key tag.Key) string {
+	for _, t := range tags {
+		if t.Key == key {
+			return t.Value
+		}
+	}
+	return ""
+}
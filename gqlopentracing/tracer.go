@@ -4,74 +4,61 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
 )
 
-var _ graphql.Tracer = (tracerImpl)(0)
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = tracerImpl(0)
 
 // New returns Tracer for OpenTracing.
 // see https://opentracing.io/
-func New() graphql.Tracer {
+func New() graphql.HandlerExtension {
 	return tracerImpl(0)
 }
 
 type tracerImpl int
 
-func (tracerImpl) StartOperationParsing(ctx context.Context) context.Context {
-	return ctx
+func (tracerImpl) ExtensionName() string {
+	return "OpenTracing"
 }
 
-func (tracerImpl) EndOperationParsing(ctx context.Context) {
+func (tracerImpl) Validate(schema graphql.ExecutableSchema) error {
+	return nil
 }
 
-func (tracerImpl) StartOperationValidation(ctx context.Context) context.Context {
-	return ctx
-}
-
-func (tracerImpl) EndOperationValidation(ctx context.Context) {
-}
+func (tracerImpl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
 
-func (tracerImpl) StartOperationExecution(ctx context.Context) context.Context {
-	requestContext := graphql.GetRequestContext(ctx)
-	span, ctx := opentracing.StartSpanFromContext(ctx, requestContext.RawQuery)
+	oc := graphql.GetOperationContext(ctx)
+	span, ctx := opentracing.StartSpanFromContext(ctx, oc.RawQuery)
+	defer span.Finish()
 	ext.SpanKind.Set(span, "server")
 	ext.Component.Set(span, "gqlgen")
 
-	return ctx
+	return next(ctx)
 }
 
-func (tracerImpl) StartFieldExecution(ctx context.Context, field graphql.CollectedField) context.Context {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "unnamed")
+func (tracerImpl) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	span, ctx := opentracing.StartSpanFromContext(ctx, fc.Object+"_"+fc.Field.Name)
+	defer span.Finish()
 	ext.SpanKind.Set(span, "server")
 	ext.Component.Set(span, "gqlgen")
+	span.SetTag("resolver.object", fc.Object)
+	span.SetTag("resolver.field", fc.Field.Name)
 
-	return ctx
-}
-
-func (tracerImpl) StartFieldResolverExecution(ctx context.Context, rc *graphql.ResolverContext) context.Context {
-	span := opentracing.SpanFromContext(ctx)
-	span.SetOperationName(rc.Object + "_" + rc.Field.Name)
-	span.SetTag("resolver.object", rc.Object)
-	span.SetTag("resolver.field", rc.Field.Name)
+	res, err := next(ctx)
 
-	return ctx
-}
-
-func (tracerImpl) StartFieldChildExecution(ctx context.Context) context.Context {
-	return ctx
-}
-
-func (tracerImpl) EndFieldExecution(ctx context.Context) {
-	span := opentracing.SpanFromContext(ctx)
-	defer span.Finish()
-
-	rc := graphql.GetResolverContext(ctx)
-	reqCtx := graphql.GetRequestContext(ctx)
-
-	errList := reqCtx.GetErrors(rc)
+	errList := gqlctx.FieldErrors(ctx, fc, err)
 	if len(errList) != 0 {
 		ext.Error.Set(span, true)
 		span.LogFields(
@@ -85,9 +72,6 @@ func (tracerImpl) EndFieldExecution(ctx context.Context) {
 			)
 		}
 	}
-}
 
-func (tracerImpl) EndOperationExecution(ctx context.Context) {
-	span := opentracing.SpanFromContext(ctx)
-	defer span.Finish()
+	return res, err
 }
@@ -0,0 +1,45 @@
+package grpctransport
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Client calls the GraphQL service over a connection.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient returns a Client calling the service over cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+// Execute runs a query or a mutation.
+func (c *Client) Execute(ctx context.Context, req *Request, opts ...grpc.CallOption) (*Response, error) {
+	resp := new(Response)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(Name)}, opts...)
+	if err := c.cc.Invoke(ctx, executeMethod, req, resp, opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Subscribe runs an operation, returning the stream of its responses, which
+// ends with io.EOF.
+func (c *Client) Subscribe(ctx context.Context, req *Request, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Response], error) {
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(Name)}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ServiceDesc.Streams[0], subscribeMethod, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := &grpc.GenericClientStream[Request, Response]{ClientStream: stream}
+	if err := s.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := s.CloseSend(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
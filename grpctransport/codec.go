@@ -0,0 +1,145 @@
+package grpctransport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Name is the name of the codec of the service, the content subtype its
+// requests are sent with.
+const Name = "graphql"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec encodes Request and Response as the messages of graphql.proto.
+type codec struct{}
+
+func (codec) Name() string { return Name }
+
+func (codec) Marshal(v any) ([]byte, error) {
+	var b []byte
+	switch m := v.(type) {
+	case *Request:
+		b = appendString(b, 1, m.Query)
+		b = appendString(b, 2, m.OperationName)
+		var err error
+		if b, err = appendJSON(b, 3, m.Variables, m.Variables == nil); err != nil {
+			return nil, err
+		}
+		if b, err = appendJSON(b, 4, m.Extensions, m.Extensions == nil); err != nil {
+			return nil, err
+		}
+	case *Response:
+		if len(m.Data) > 0 {
+			b = protowire.AppendTag(b, 1, protowire.BytesType)
+			b = protowire.AppendBytes(b, m.Data)
+		}
+		var err error
+		if b, err = appendJSON(b, 2, m.Errors, len(m.Errors) == 0); err != nil {
+			return nil, err
+		}
+		if b, err = appendJSON(b, 3, m.Extensions, m.Extensions == nil); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("grpctransport: cannot marshal %T", v)
+	}
+	return b, nil
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case *Request:
+		*m = Request{}
+		return consume(data, func(num protowire.Number, value []byte) error {
+			switch num {
+			case 1:
+				m.Query = string(value)
+			case 2:
+				m.OperationName = string(value)
+			case 3:
+				return decodeJSON(value, &m.Variables)
+			case 4:
+				return decodeJSON(value, &m.Extensions)
+			}
+			return nil
+		})
+	case *Response:
+		*m = Response{}
+		return consume(data, func(num protowire.Number, value []byte) error {
+			switch num {
+			case 1:
+				m.Data = bytes.Clone(value)
+			case 2:
+				return decodeJSON(value, &m.Errors)
+			case 3:
+				return decodeJSON(value, &m.Extensions)
+			}
+			return nil
+		})
+	default:
+		return fmt.Errorf("grpctransport: cannot unmarshal %T", v)
+	}
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendJSON(b []byte, num protowire.Number, v any, empty bool) ([]byte, error) {
+	if empty {
+		return b, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, data), nil
+}
+
+// decodeJSON decodes numbers as json.Number, as gqlgen does for variables.
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// consume calls fn with the length delimited fields of data, skipping the
+// others for forward compatibility.
+func consume(data []byte, fn func(num protowire.Number, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, value); err != nil {
+			return fmt.Errorf("field %d: %w", num, err)
+		}
+		data = data[n:]
+	}
+	return nil
+}
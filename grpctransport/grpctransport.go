@@ -0,0 +1,168 @@
+// Package grpctransport serves a GraphQL executor over gRPC, for service to
+// service calls that do not need HTTP. The service and its messages,
+// described in graphql.proto, are defined by hand so that no code generation
+// is needed, and are encoded by a codec registered under Name.
+package grpctransport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	serviceName     = "graphql.v1.GraphQL"
+	executeMethod   = "/" + serviceName + "/Execute"
+	subscribeMethod = "/" + serviceName + "/Subscribe"
+)
+
+// Request is a GraphQL operation.
+type Request struct {
+	Query         string
+	OperationName string
+	Variables     map[string]any
+	Extensions    map[string]any
+}
+
+// Response is a result of a GraphQL operation, Data being JSON.
+type Response struct {
+	Data       json.RawMessage
+	Errors     gqlerror.List
+	Extensions map[string]any
+}
+
+// Server runs the operations received over gRPC with an executor, such as
+// the one returned by executor.New with the extensions of the HTTP handler.
+// The metadata of calls is given to the executor as the headers of the
+// operations.
+type Server struct {
+	exec graphql.GraphExecutor
+}
+
+// New returns a Server running operations with exec.
+func New(exec graphql.GraphExecutor) *Server {
+	return &Server{exec: exec}
+}
+
+// Register registers the GraphQL service on registrar.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	registrar.RegisterService(&ServiceDesc, s)
+}
+
+// ServiceDesc describes the GraphQL service of graphql.proto, implemented by
+// Server.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Execute",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Request)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return srv.(*Server).Execute(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: executeMethod}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return srv.(*Server).Execute(ctx, req.(*Request))
+			})
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName: "Subscribe",
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			req := new(Request)
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			return srv.(*Server).Subscribe(req, &grpc.GenericServerStream[Request, Response]{ServerStream: stream})
+		},
+		ServerStreams: true,
+	}},
+	Metadata: "graphql.proto",
+}
+
+// Execute runs a query or a mutation. Subscriptions fail with
+// codes.InvalidArgument, as they need Subscribe.
+func (s *Server) Execute(ctx context.Context, req *Request) (*Response, error) {
+	var resp *Response
+	err := s.run(ctx, req, false, func(r *graphql.Response) error {
+		resp = newResponse(r)
+		return nil
+	})
+	return resp, err
+}
+
+// Subscribe runs an operation, sending its responses on stream until it
+// ends or the client cancels the call.
+func (s *Server) Subscribe(req *Request, stream grpc.ServerStreamingServer[Response]) error {
+	return s.run(stream.Context(), req, true, func(r *graphql.Response) error {
+		return stream.Send(newResponse(r))
+	})
+}
+
+func (s *Server) run(ctx context.Context, req *Request, subscriptions bool, send func(*graphql.Response) error) error {
+	ctx = graphql.StartOperationTrace(ctx)
+	start := graphql.Now()
+	params := &graphql.RawParams{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+		Extensions:    req.Extensions,
+		Headers:       headers(ctx),
+		ReadTime:      graphql.TraceTiming{Start: start, End: graphql.Now()},
+	}
+
+	rc, errs := s.exec.CreateOperationContext(ctx, params)
+	if errs != nil {
+		return send(s.exec.DispatchError(graphql.WithOperationContext(ctx, rc), errs))
+	}
+	if !subscriptions && rc.Operation.Operation == ast.Subscription {
+		return status.Error(codes.InvalidArgument, "subscriptions must be called with Subscribe")
+	}
+
+	responses, ctx := s.exec.DispatchOperation(ctx, rc)
+	for {
+		resp := responses(ctx)
+		if resp == nil {
+			return ctx.Err()
+		}
+		if err := send(resp); err != nil {
+			return err
+		}
+		if !subscriptions {
+			return nil
+		}
+	}
+}
+
+// headers returns the metadata of the call as HTTP headers, pseudo-headers
+// such as :authority excluded.
+func headers(ctx context.Context) http.Header {
+	h := http.Header{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") {
+			continue
+		}
+		for _, value := range values {
+			h.Add(key, value)
+		}
+	}
+	return h
+}
+
+func newResponse(r *graphql.Response) *Response {
+	return &Response{Data: r.Data, Errors: r.Errors, Extensions: r.Extensions}
+}
@@ -0,0 +1,103 @@
+package grpctransport_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/grpctransport"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer_Execute(t *testing.T) {
+	exec := executor.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	var tenant string
+	exec.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		tenant = graphql.GetOperationContext(ctx).Headers.Get("X-Tenant")
+		return next(ctx)
+	})
+	client := newClient(t, exec)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "acme")
+	resp, err := client.Execute(ctx, &grpctransport.Request{
+		Query:     "query Lookup($id: ID!) { todo(id: $id) { id text } }",
+		Variables: map[string]any{"id": graph.TodoA.ID},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"todo":{"id":"`+graph.TodoA.ID+`","text":"`+graph.TodoA.Text+`"}}`, string(resp.Data))
+	assert.Equal(t, "acme", tenant)
+
+	resp, err = client.Execute(context.Background(), &grpctransport.Request{Query: "{ unknown }"})
+	require.NoError(t, err)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "GRAPHQL_VALIDATION_FAILED", resp.Errors[0].Extensions["code"])
+
+	_, err = client.Execute(context.Background(), &grpctransport.Request{Query: "subscription { todoAdded { id } }"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	client := newClient(t, executor.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	})))
+
+	stream, err := client.Subscribe(context.Background(), &grpctransport.Request{Query: "subscription { todoAdded { id } }"})
+	require.NoError(t, err)
+
+	var ids []string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		var data struct {
+			TodoAdded struct{ ID string }
+		}
+		require.NoError(t, json.Unmarshal(resp.Data, &data))
+		ids = append(ids, data.TodoAdded.ID)
+	}
+	assert.Equal(t, []string{graph.TodoA.ID, graph.TodoB.ID, graph.TodoC.ID}, ids)
+
+	stream, err = client.Subscribe(context.Background(), &grpctransport.Request{Query: "{ todos { id } }"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func newClient(t *testing.T, exec graphql.GraphExecutor) *grpctransport.Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	grpctransport.New(exec).Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+	return grpctransport.NewClient(cc)
+}
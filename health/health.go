@@ -0,0 +1,171 @@
+// Package health provides liveness and readiness http.Handlers for gqlgen
+// servers, checking that the executable schema answers a query and that
+// the dependencies of the server are reachable.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+)
+
+const (
+	// LivenessPath is where Liveness is conventionally mounted.
+	LivenessPath = "/healthz"
+	// ReadinessPath is where Readiness is conventionally mounted.
+	ReadinessPath = "/readyz"
+
+	// GraphQLCheck is the name the query against the schema is reported
+	// under.
+	GraphQLCheck = "graphql"
+)
+
+// Check returns an error when a dependency is unavailable, for instance:
+//
+//	health.WithCheck("redis", func(ctx context.Context) error {
+//		return rdb.Ping(ctx).Err()
+//	})
+type Check func(ctx context.Context) error
+
+// Status is the outcome of a check or of a whole Report.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status   Status `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the outcome of the checks, failed if any of them failed.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker runs the health checks of a server.
+type Checker struct {
+	cfg  *config
+	exec *executor.Executor
+}
+
+// New returns a Checker querying a dedicated executor of es, so that the
+// checks are not seen by the extensions of the server.
+func New(es graphql.ExecutableSchema, opts ...Option) *Checker {
+	return &Checker{
+		cfg:  newConfig(opts...),
+		exec: executor.New(es),
+	}
+}
+
+// Live runs the query against the schema only.
+func (c *Checker) Live(ctx context.Context) Report {
+	return c.run(ctx, []namedCheck{{name: GraphQLCheck, check: c.query}})
+}
+
+// Ready runs the query against the schema and the dependency checks,
+// concurrently.
+func (c *Checker) Ready(ctx context.Context) Report {
+	checks := append([]namedCheck{{name: GraphQLCheck, check: c.query}}, c.cfg.checks...)
+	return c.run(ctx, checks)
+}
+
+// Liveness serves the Live report as JSON, with status 503 when it failed.
+func (c *Checker) Liveness() http.Handler {
+	return handler(c.Live)
+}
+
+// Readiness serves the Ready report as JSON, with status 503 when it
+// failed.
+func (c *Checker) Readiness() http.Handler {
+	return handler(c.Ready)
+}
+
+func handler(report func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := report(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if rep.Status != StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(rep)
+	})
+}
+
+func (c *Checker) run(ctx context.Context, checks []namedCheck) Report {
+	rep := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, nc := range checks {
+		wg.Go(func() {
+			res := c.check(ctx, nc.check)
+			mu.Lock()
+			defer mu.Unlock()
+			rep.Checks[nc.name] = res
+			if res.Status != StatusOK {
+				rep.Status = StatusFail
+			}
+		})
+	}
+	wg.Wait()
+
+	return rep
+}
+
+func (c *Checker) check(ctx context.Context, check Check) Result {
+	if c.cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := check(ctx)
+	res := Result{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// query runs the configured query, failing on any error in the response.
+func (c *Checker) query(ctx context.Context) error {
+	ctx = graphql.StartOperationTrace(ctx)
+	rc, errs := c.exec.CreateOperationContext(ctx, &graphql.RawParams{
+		Query: c.cfg.query,
+		ReadTime: graphql.TraceTiming{
+			Start: graphql.Now(),
+			End:   graphql.Now(),
+		},
+	})
+	if errs != nil {
+		return errs
+	}
+
+	responses, ctx := c.exec.DispatchOperation(ctx, rc)
+	resp := responses(ctx)
+	if resp == nil {
+		return errors.New("health: no response")
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,94 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/health"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var es = graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}})
+
+func TestChecker(t *testing.T) {
+	redisErr := errors.New("dial tcp: connection refused")
+	checker := health.New(es,
+		health.WithQuery("{ todos { id } }"),
+		health.WithCheck("broker", func(ctx context.Context) error { return nil }),
+		health.WithCheck("redis", func(ctx context.Context) error { return redisErr }),
+	)
+
+	resp := doRequest(checker.Liveness(), health.LivenessPath)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	rep := decode(t, resp)
+	assert.Equal(t, health.StatusOK, rep.Status)
+	assert.Equal(t, []string{health.GraphQLCheck}, keys(rep))
+
+	resp = doRequest(checker.Readiness(), health.ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	rep = decode(t, resp)
+	assert.Equal(t, health.StatusFail, rep.Status)
+	assert.ElementsMatch(t, []string{health.GraphQLCheck, "broker", "redis"}, keys(rep))
+	assert.Equal(t, health.StatusOK, rep.Checks[health.GraphQLCheck].Status)
+	assert.Equal(t, health.StatusOK, rep.Checks["broker"].Status)
+	assert.Equal(t, health.StatusFail, rep.Checks["redis"].Status)
+	assert.Equal(t, redisErr.Error(), rep.Checks["redis"].Error)
+	assert.NotEmpty(t, rep.Checks["redis"].Duration)
+}
+
+func TestChecker_Query(t *testing.T) {
+	for name, query := range map[string]string{
+		"invalid":  "{ unknown }",
+		"resolver": `{ todo(id: "unknown") { id } }`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rep := health.New(es, health.WithQuery(query)).Live(context.Background())
+			assert.Equal(t, health.StatusFail, rep.Status)
+			assert.NotEmpty(t, rep.Checks[health.GraphQLCheck].Error)
+		})
+	}
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := health.New(es,
+		health.WithTimeout(10*time.Millisecond),
+		health.WithCheck("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	)
+
+	rep := checker.Ready(context.Background())
+	assert.Equal(t, health.StatusFail, rep.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), rep.Checks["slow"].Error)
+	assert.Equal(t, health.StatusOK, rep.Checks[health.GraphQLCheck].Status)
+}
+
+func keys(rep health.Report) []string {
+	var names []string
+	for name := range rep.Checks {
+		names = append(names, name)
+	}
+	return names
+}
+
+func decode(t *testing.T, resp *httptest.ResponseRecorder) health.Report {
+	var rep health.Report
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rep))
+	return rep
+}
+
+func doRequest(handler http.Handler, path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,53 @@
+package health
+
+import "time"
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type config struct {
+	query   string
+	checks  []namedCheck
+	timeout time.Duration
+}
+
+// Option is anything that can configure Checker.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		query:   "{ __typename }",
+		timeout: 5 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithQuery runs query against the schema instead of { __typename }, for
+// instance to go through a resolver. The query must not require variables.
+func WithQuery(query string) Option {
+	return func(cfg *config) {
+		cfg.query = query
+	}
+}
+
+// WithCheck adds a dependency the server is only ready with, such as a
+// cache or a broker, reported under name.
+func WithCheck(name string, check Check) Option {
+	return func(cfg *config) {
+		cfg.checks = append(cfg.checks, namedCheck{name: name, check: check})
+	}
+}
+
+// WithTimeout bounds the duration of each check, 5 seconds by default.
+func WithTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = d
+	}
+}
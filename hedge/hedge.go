@@ -0,0 +1,155 @@
+// Package hedge cuts the tail latency of slow resolvers by running them
+// twice.
+package hedge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// Hedger is a gqlgen handler extension hedging the resolvers of registered
+// fields: when a resolver has not returned after the delay of its field, it
+// is invoked a second time, and the result of whichever invocation returns
+// first is used, the context of the other being canceled.
+//
+// Only register read-only fields whose resolvers are safe to run
+// concurrently, and which return errors rather than adding them with
+// graphql.AddError. Resolvers are counted in
+// graphql_resolver_hedge_candidates_total{object,field}, second invocations
+// in graphql_resolver_hedge_fired_total{object,field}, and second
+// invocations returning first in graphql_resolver_hedge_wins_total{object,field}.
+type Hedger struct {
+	cfg        *config
+	candidates *prometheusclient.CounterVec
+	fired      *prometheusclient.CounterVec
+	wins       *prometheusclient.CounterVec
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Hedger{}
+
+// New returns a Hedger whose metrics are registered on the configured
+// registerer.
+func New(opts ...Option) *Hedger {
+	cfg := newConfig(opts...)
+
+	counter := func(name, help string) *prometheusclient.CounterVec {
+		return prometheusclient.NewCounterVec(
+			prometheusclient.CounterOpts{
+				Namespace:   cfg.namespace,
+				Subsystem:   cfg.subsystem,
+				Name:        name,
+				Help:        help,
+				ConstLabels: cfg.constLabels,
+			},
+			[]string{"object", "field"},
+		)
+	}
+	h := &Hedger{
+		cfg:        cfg,
+		candidates: counter("graphql_resolver_hedge_candidates_total", "Total number of resolvers of hedged fields."),
+		fired:      counter("graphql_resolver_hedge_fired_total", "Total number of hedged resolvers invoked a second time."),
+		wins:       counter("graphql_resolver_hedge_wins_total", "Total number of hedged resolvers whose second invocation returned first."),
+	}
+	cfg.registerer.MustRegister(h.candidates, h.fired, h.wins)
+
+	return h
+}
+
+// UnRegister removes the metrics from the registerer they were registered
+// on.
+func (h *Hedger) UnRegister() {
+	h.cfg.registerer.Unregister(h.candidates)
+	h.cfg.registerer.Unregister(h.fired)
+	h.cfg.registerer.Unregister(h.wins)
+}
+
+func (h *Hedger) ExtensionName() string {
+	return "Hedge"
+}
+
+// Validate checks that the registered fields exist.
+func (h *Hedger) Validate(schema graphql.ExecutableSchema) error {
+	for coordinate := range h.cfg.fields {
+		object, field, _ := strings.Cut(coordinate, ".")
+		def := schema.Schema().Types[object]
+		if def == nil || def.Fields.ForName(field) == nil {
+			return fmt.Errorf("hedge: unknown field %s", coordinate)
+		}
+	}
+	return nil
+}
+
+type result struct {
+	res    any
+	err    error
+	hedged bool
+}
+
+func (h *Hedger) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	delay, ok := h.cfg.fields[fc.Object+"."+fc.Field.Name]
+	if !ok {
+		return next(ctx)
+	}
+	if delay <= 0 {
+		delay = h.cfg.delay
+	}
+	h.candidates.WithLabelValues(fc.Object, fc.Field.Name).Inc()
+
+	// Buffered so the losing invocation does not block once abandoned.
+	results := make(chan result, 2)
+	invoke := func(hedged bool) context.CancelFunc {
+		ctx, cancel := context.WithCancel(ctx)
+		go func() {
+			// Panics would escape the recovery of gqlgen, which runs
+			// in the resolver goroutine.
+			defer func() {
+				if r := recover(); r != nil {
+					results <- result{err: graphql.GetOperationContext(ctx).Recover(ctx, r), hedged: hedged}
+				}
+			}()
+			res, err := next(ctx)
+			results <- result{res: res, err: err, hedged: hedged}
+		}()
+		return cancel
+	}
+
+	cancelFirst := invoke(false)
+	defer cancelFirst()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case r := <-results:
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	}
+
+	h.fired.WithLabelValues(fc.Object, fc.Field.Name).Inc()
+	cancelSecond := invoke(true)
+	defer cancelSecond()
+
+	select {
+	case r := <-results:
+		if r.hedged {
+			h.wins.WithLabelValues(fc.Object, fc.Field.Name).Inc()
+		}
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+package hedge_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/hedge"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedger(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	hedger := hedge.New(
+		hedge.WithRegisterer(registry),
+		hedge.WithField("Query.todos", 10*time.Millisecond),
+		hedge.WithFields("Query.todo"),
+	)
+	defer hedger.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(hedger)
+
+	// The first invocation of todos hangs until canceled.
+	var calls atomic.Int32
+	canceled := make(chan struct{})
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if fc := graphql.GetFieldContext(ctx); fc.Field.Name != "todos" {
+			return next(ctx)
+		}
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		}
+		return next(ctx)
+	})
+
+	start := time.Now()
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.EqualValues(t, 2, calls.Load())
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("first invocation not canceled")
+	}
+
+	resp = doRequest(srv, `{"query":"{ todo(id: \"`+graph.TodoA.ID+`\") { id } }"}`)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_resolver_hedge_candidates_total{field="todos",object="Query"} 1`)
+	assert.Contains(t, body, `graphql_resolver_hedge_fired_total{field="todos",object="Query"} 1`)
+	assert.Contains(t, body, `graphql_resolver_hedge_wins_total{field="todos",object="Query"} 1`)
+	assert.Contains(t, body, `graphql_resolver_hedge_candidates_total{field="todo",object="Query"} 1`)
+	assert.NotContains(t, body, `graphql_resolver_hedge_fired_total{field="todo"`)
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	method := http.MethodPost
+	if body == "" {
+		method = http.MethodGet
+	}
+	r := httptest.NewRequest(method, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
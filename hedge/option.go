@@ -0,0 +1,89 @@
+package hedge
+
+import (
+	"time"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	fields      map[string]time.Duration
+	delay       time.Duration
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Hedger.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		fields:     map[string]time.Duration{},
+		delay:      100 * time.Millisecond,
+		registerer: prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithFields hedges the resolvers of the given fields, as Object.field,
+// after the default delay.
+func WithFields(coordinates ...string) Option {
+	return func(cfg *config) {
+		for _, coordinate := range coordinates {
+			cfg.fields[coordinate] = 0
+		}
+	}
+}
+
+// WithField hedges the resolver of the field Object.field once it has run
+// for delay, typically its 95th percentile latency.
+func WithField(coordinate string, delay time.Duration) Option {
+	return func(cfg *config) {
+		cfg.fields[coordinate] = delay
+	}
+}
+
+// WithDelay sets the delay of fields registered without one, 100ms by
+// default.
+func WithDelay(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.delay = d
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,222 @@
+// Package hive reports the usage of a gqlgen server to GraphQL Hive: the
+// operations executed, the schema coordinates they use, their latency and
+// their errors.
+package hive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Endpoint is the usage reporting endpoint of GraphQL Hive Cloud.
+const Endpoint = "https://app.graphql-hive.com/usage"
+
+// Reporter is a gqlgen handler extension batching the usage of queries and
+// mutations and sending it to Hive in the background, authenticated with a
+// registry access token. Subscriptions are not reported. Register it after
+// clientinfo for operations to be reported with their client, and Close it
+// to send the last batch.
+type Reporter struct {
+	cfg   *config
+	token string
+
+	mu      sync.Mutex
+	pending report
+	dropped atomic.Int64
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Reporter{}
+
+// New returns a Reporter sending batches to Hive with token.
+func New(token string, opts ...Option) *Reporter {
+	r := &Reporter{
+		cfg:     newConfig(opts...),
+		token:   token,
+		pending: report{Map: map[string]operationInfo{}},
+		flush:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.run()
+
+	return r
+}
+
+func (r *Reporter) ExtensionName() string {
+	return "HiveUsage"
+}
+
+func (r *Reporter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (r *Reporter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation == ast.Subscription || !r.sampled(ctx) {
+		return next(ctx)
+	}
+
+	start := oc.Stats.OperationStart
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	normalized, err := normalize.Document(oc.Doc, oc.OperationName)
+	if err != nil {
+		return resp
+	}
+	key := normalize.Hash(normalized)
+
+	op := operation{
+		OperationMapKey: key,
+		Timestamp:       start.UnixMilli(),
+		Execution: execution{
+			OK:          len(resp.Errors) == 0,
+			Duration:    time.Since(start).Nanoseconds(),
+			ErrorsTotal: len(resp.Errors),
+		},
+	}
+	if info := clientinfo.ForContext(ctx); info.Name != "" {
+		op.Metadata = &metadata{Client: client{Name: info.Name, Version: info.Version}}
+	}
+
+	r.mu.Lock()
+	if r.cfg.maxPending > 0 && len(r.pending.Operations) >= r.cfg.maxPending {
+		// Hive is not keeping up, or cannot be reached.
+		r.mu.Unlock()
+		r.dropped.Add(1)
+		return resp
+	}
+	if _, ok := r.pending.Map[key]; !ok {
+		r.pending.Map[key] = operationInfo{
+			OperationName: oc.Operation.Name,
+			Operation:     normalized,
+			Fields:        coordinates(oc.Operation),
+		}
+	}
+	r.pending.Operations = append(r.pending.Operations, op)
+	full := r.cfg.maxBatch > 0 && len(r.pending.Operations) >= r.cfg.maxBatch
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.flush <- struct{}{}:
+		default:
+		}
+	}
+
+	return resp
+}
+
+func (r *Reporter) sampled(ctx context.Context) bool {
+	if r.cfg.sampler != nil {
+		return r.cfg.sampler(ctx)
+	}
+	return r.cfg.sampleRate >= 1 || rand.Float64() < r.cfg.sampleRate
+}
+
+// Dropped returns the number of operations dropped because the pending
+// ones reached the WithMaxPending limit.
+func (r *Reporter) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Flush sends the operations reported since the last flush, if any. They
+// are dropped if Hive cannot be reached.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	batch := r.pending
+	r.pending = report{Map: map[string]operationInfo{}}
+	r.mu.Unlock()
+
+	if len(batch.Operations) == 0 {
+		return nil
+	}
+	batch.Size = len(batch.Operations)
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Usage-API-Version", "2")
+
+	resp, err := r.cfg.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("hive: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Close stops the background flushes and sends the last batch.
+func (r *Reporter) Close(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return r.Flush(ctx)
+}
+
+func (r *Reporter) run() {
+	defer close(r.done)
+
+	var tick <-chan time.Time
+	if r.cfg.flushInterval > 0 {
+		ticker := time.NewTicker(r.cfg.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+		case <-r.flush:
+		case <-r.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), r.cfg.flushTimeout)
+		if err := r.Flush(ctx); err != nil {
+			r.cfg.errorHandler(err)
+		}
+		cancel()
+	}
+}
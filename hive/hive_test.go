@@ -0,0 +1,241 @@
+package hive_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/hive"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type report struct {
+	Size int `json:"size"`
+	Map  map[string]struct {
+		OperationName string   `json:"operationName"`
+		Operation     string   `json:"operation"`
+		Fields        []string `json:"fields"`
+	} `json:"map"`
+	Operations []struct {
+		OperationMapKey string `json:"operationMapKey"`
+		Timestamp       int64  `json:"timestamp"`
+		Execution       struct {
+			OK          bool  `json:"ok"`
+			Duration    int64 `json:"duration"`
+			ErrorsTotal int   `json:"errorsTotal"`
+		} `json:"execution"`
+		Metadata *struct {
+			Client struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"client"`
+		} `json:"metadata"`
+	} `json:"operations"`
+}
+
+type collector struct {
+	mu      sync.Mutex
+	reports []report
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Usage-API-Version") != "2" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var rep report
+	if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.reports = append(c.reports, rep)
+	c.mu.Unlock()
+}
+
+func (c *collector) get() []report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]report(nil), c.reports...)
+}
+
+func TestReporter(t *testing.T) {
+	c := &collector{}
+	hub := httptest.NewServer(c)
+	defer hub.Close()
+
+	reporter := hive.New("token", hive.WithEndpoint(hub.URL), hive.WithFlushInterval(0))
+	srv := newServer(reporter)
+
+	doRequest(srv, `{"query":"query Todos { todos(status: OPEN) { ...Fields } } fragment Fields on Todo { id user { name } }"}`, "web")
+	doRequest(srv, `{"query":"query Todos {\n  todos(status: OPEN) { ...Fields }\n}\nfragment Fields on Todo { user { name } id }"}`, "")
+	doRequest(srv, `{"query":"query Lookup($id: ID!) { todo(id: $id) { id } }","variables":{"id":"unknown"}}`, "web")
+	doRequest(srv, `{"query":"{ unknown }"}`, "web")
+
+	require.NoError(t, reporter.Close(context.Background()))
+
+	reports := c.get()
+	require.Len(t, reports, 1)
+	rep := reports[0]
+	assert.Equal(t, 3, rep.Size)
+	require.Len(t, rep.Map, 2)
+	require.Len(t, rep.Operations, 3)
+
+	todos := rep.Map[rep.Operations[0].OperationMapKey]
+	assert.Equal(t, "Todos", todos.OperationName)
+	assert.Equal(t, "query Todos { todos(status: OPEN) { ...Fields } } fragment Fields on Todo { id user { name } }", todos.Operation)
+	assert.Equal(t, []string{"ID", "Query", "Query.todos", "Query.todos.status", "String", "Todo", "Todo.id", "Todo.user", "TodoStatus", "User", "User.name"}, todos.Fields)
+	assert.Equal(t, rep.Operations[0].OperationMapKey, rep.Operations[1].OperationMapKey)
+
+	assert.True(t, rep.Operations[0].Execution.OK)
+	assert.NotZero(t, rep.Operations[0].Execution.Duration)
+	assert.InDelta(t, time.Now().UnixMilli(), rep.Operations[0].Timestamp, float64(time.Minute.Milliseconds()))
+	require.NotNil(t, rep.Operations[0].Metadata)
+	assert.Equal(t, "web", rep.Operations[0].Metadata.Client.Name)
+	assert.Equal(t, "1.0", rep.Operations[0].Metadata.Client.Version)
+	assert.Nil(t, rep.Operations[1].Metadata)
+
+	lookup := rep.Map[rep.Operations[2].OperationMapKey]
+	assert.Equal(t, []string{"ID", "Query", "Query.todo", "Query.todo.id", "Todo", "Todo.id"}, lookup.Fields)
+	assert.False(t, rep.Operations[2].Execution.OK)
+	assert.Equal(t, 1, rep.Operations[2].Execution.ErrorsTotal)
+
+	require.NoError(t, reporter.Flush(context.Background()))
+	assert.Len(t, c.get(), 1)
+}
+
+func TestReporter_Sampling(t *testing.T) {
+	c := &collector{}
+	hub := httptest.NewServer(c)
+	defer hub.Close()
+
+	reporter := hive.New("token", hive.WithEndpoint(hub.URL), hive.WithFlushInterval(0),
+		hive.WithSampler(func(ctx context.Context) bool {
+			return clientinfo.ForContext(ctx).Name == "web"
+		}),
+	)
+	srv := newServer(reporter)
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "web")
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "ios")
+
+	require.NoError(t, reporter.Close(context.Background()))
+	require.Len(t, c.get(), 1)
+	assert.Equal(t, 1, c.get()[0].Size)
+
+	reporter = hive.New("token", hive.WithEndpoint(hub.URL), hive.WithFlushInterval(0), hive.WithSampleRate(0))
+	doRequest(newServer(reporter), `{"query":"{ todos { id } }"}`, "web")
+	require.NoError(t, reporter.Close(context.Background()))
+	assert.Len(t, c.get(), 1)
+}
+
+func TestReporter_MaxBatch(t *testing.T) {
+	c := &collector{}
+	hub := httptest.NewServer(c)
+	defer hub.Close()
+
+	reporter := hive.New("token", hive.WithEndpoint(hub.URL), hive.WithFlushInterval(0), hive.WithMaxBatch(2))
+	defer reporter.Close(context.Background())
+	srv := newServer(reporter)
+
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "web")
+	assert.Empty(t, c.get())
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "web")
+	assert.Eventually(t, func() bool { return len(c.get()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestReporter_MaxPending(t *testing.T) {
+	c := &collector{}
+	hub := httptest.NewServer(c)
+	defer hub.Close()
+
+	reporter := hive.New("token", hive.WithEndpoint(hub.URL), hive.WithFlushInterval(0), hive.WithMaxBatch(0), hive.WithMaxPending(2))
+	srv := newServer(reporter)
+	for range 3 {
+		doRequest(srv, `{"query":"{ todos { id } }"}`, "web")
+	}
+	assert.Equal(t, int64(1), reporter.Dropped())
+
+	require.NoError(t, reporter.Close(context.Background()))
+	require.Len(t, c.get(), 1)
+	assert.Len(t, c.get()[0].Operations, 2)
+}
+
+func TestReporter_FlushTimeout(t *testing.T) {
+	release := make(chan struct{})
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hub.Close()
+	defer close(release)
+
+	errs := make(chan error, 1)
+	reporter := hive.New("token", hive.WithEndpoint(hub.URL), hive.WithFlushInterval(0), hive.WithMaxBatch(1),
+		hive.WithFlushTimeout(20*time.Millisecond),
+		hive.WithErrorHandler(func(err error) { errs <- err }),
+	)
+	defer reporter.Close(context.Background())
+	doRequest(newServer(reporter), `{"query":"{ todos { id } }"}`, "web")
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("flush to a hung endpoint did not time out")
+	}
+}
+
+func TestReporter_Error(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer hub.Close()
+
+	errs := make(chan error, 1)
+	reporter := hive.New("wrong", hive.WithEndpoint(hub.URL), hive.WithFlushInterval(10*time.Millisecond),
+		hive.WithErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+	)
+	defer reporter.Close(context.Background())
+	doRequest(newServer(reporter), `{"query":"{ todos { id } }"}`, "web")
+
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "401")
+	case <-time.After(time.Second):
+		t.Fatal("no flush error")
+	}
+}
+
+func newServer(reporter *hive.Reporter) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(clientinfo.New())
+	srv.Use(reporter)
+	return srv
+}
+
+func doRequest(handler http.Handler, body, client string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if client != "" {
+		r.Header.Set("apollographql-client-name", client)
+		r.Header.Set("apollographql-client-version", "1.0")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
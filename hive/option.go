@@ -0,0 +1,113 @@
+package hive
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type config struct {
+	endpoint      string
+	client        *http.Client
+	sampleRate    float64
+	sampler       func(ctx context.Context) bool
+	flushInterval time.Duration
+	maxBatch      int
+	maxPending    int
+	flushTimeout  time.Duration
+	errorHandler  func(err error)
+}
+
+// Option is anything that can configure Reporter.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		endpoint:      Endpoint,
+		client:        http.DefaultClient,
+		sampleRate:    1,
+		flushInterval: 10 * time.Second,
+		maxBatch:      1000,
+		maxPending:    10000,
+		flushTimeout:  10 * time.Second,
+		errorHandler:  func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithEndpoint sends the reports to a self-hosted Hive instead of Endpoint.
+func WithEndpoint(url string) Option {
+	return func(cfg *config) {
+		cfg.endpoint = url
+	}
+}
+
+// WithHTTPClient sends the reports with client instead of
+// http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(cfg *config) {
+		cfg.client = client
+	}
+}
+
+// WithSampleRate reports the given fraction of the operations, picked at
+// random, instead of all of them.
+func WithSampleRate(rate float64) Option {
+	return func(cfg *config) {
+		cfg.sampleRate = rate
+	}
+}
+
+// WithSampler decides which operations are reported instead of the sample
+// rate, for instance to keep all the operations of some clients.
+func WithSampler(fn func(ctx context.Context) bool) Option {
+	return func(cfg *config) {
+		cfg.sampler = fn
+	}
+}
+
+// WithFlushInterval sets how often the reports are sent, every 10 seconds
+// by default. A value of 0 or less only sends them on Flush, Close or when
+// a batch is full.
+func WithFlushInterval(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.flushInterval = d
+	}
+}
+
+// WithMaxBatch sends a report as soon as it holds n operations, 1000 by
+// default.
+func WithMaxBatch(n int) Option {
+	return func(cfg *config) {
+		cfg.maxBatch = n
+	}
+}
+
+// WithMaxPending drops the operations reported while n are waiting to be
+// sent, 10000 by default, counting them in Dropped. A value of 0 or less
+// keeps them all.
+func WithMaxPending(n int) Option {
+	return func(cfg *config) {
+		cfg.maxPending = n
+	}
+}
+
+// WithFlushTimeout bounds each background flush, 10 seconds by default.
+func WithFlushTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.flushTimeout = d
+	}
+}
+
+// WithErrorHandler is called with the errors of the background flushes,
+// which are otherwise dropped along with the reports they failed to send.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
@@ -0,0 +1,101 @@
+package hive
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// report is a batch of the version 2 of the Hive usage reporting protocol.
+type report struct {
+	Size       int                      `json:"size"`
+	Map        map[string]operationInfo `json:"map"`
+	Operations []operation              `json:"operations"`
+}
+
+// operationInfo describes an operation document of the report, once per
+// batch whatever the number of times it was executed.
+type operationInfo struct {
+	OperationName string   `json:"operationName,omitempty"`
+	Operation     string   `json:"operation"`
+	Fields        []string `json:"fields"`
+}
+
+type operation struct {
+	OperationMapKey string    `json:"operationMapKey"`
+	Timestamp       int64     `json:"timestamp"`
+	Execution       execution `json:"execution"`
+	Metadata        *metadata `json:"metadata,omitempty"`
+}
+
+type execution struct {
+	OK          bool  `json:"ok"`
+	Duration    int64 `json:"duration"`
+	ErrorsTotal int   `json:"errorsTotal"`
+}
+
+type metadata struct {
+	Client client `json:"client"`
+}
+
+type client struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// coordinates returns the sorted schema coordinates used by op: the types,
+// fields and arguments Hive tracks the usage of.
+func coordinates(op *ast.OperationDefinition) []string {
+	w := walker{seen: map[string]bool{}, fragments: map[string]bool{}}
+	for _, v := range op.VariableDefinitions {
+		w.add(v.Type.Name())
+	}
+	w.selectionSet(op.SelectionSet)
+
+	fields := make([]string, 0, len(w.seen))
+	for coordinate := range w.seen {
+		fields = append(fields, coordinate)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+type walker struct {
+	seen      map[string]bool
+	fragments map[string]bool
+}
+
+func (w *walker) add(coordinate string) {
+	w.seen[coordinate] = true
+}
+
+func (w *walker) selectionSet(set ast.SelectionSet) {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.ObjectDefinition == nil || sel.Definition == nil || strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			parent := sel.ObjectDefinition.Name + "." + sel.Name
+			w.add(sel.ObjectDefinition.Name)
+			w.add(parent)
+			w.add(sel.Definition.Type.Name())
+			for _, arg := range sel.Arguments {
+				w.add(parent + "." + arg.Name)
+				if def := sel.Definition.Arguments.ForName(arg.Name); def != nil {
+					w.add(def.Type.Name())
+				}
+			}
+			w.selectionSet(sel.SelectionSet)
+		case *ast.InlineFragment:
+			w.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition == nil || w.fragments[sel.Name] {
+				continue
+			}
+			w.fragments[sel.Name] = true
+			w.selectionSet(sel.Definition.SelectionSet)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Package httpcompliance makes a gqlgen handler follow the status codes and
+// content negotiation of the GraphQL-over-HTTP specification.
+package httpcompliance
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Media types of GraphQL responses.
+const (
+	MediaTypeGraphQLResponse = "application/graphql-response+json"
+	MediaTypeJSON            = "application/json"
+)
+
+// Middleware wraps the GraphQL handler next so that:
+//
+//   - methods other than GET and POST are answered 405,
+//   - the response media type is negotiated from the Accept header, honouring
+//     q-values, and requests accepting neither media type are answered 406,
+//   - request errors raised before execution, such as parse and validation
+//     errors, are answered 400 with application/graphql-response+json and
+//     200 with application/json,
+//   - mutations sent over GET are answered 405.
+//
+// WebSocket upgrades and requests accepting text/event-stream or
+// multipart/mixed are passed to next unchanged. CORS preflight requests must
+// be answered by a middleware wrapping this one.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := newConfig(opts...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			respond(w, http.StatusMethodNotAllowed, "only GET and POST requests are supported")
+			return
+		}
+
+		ranges := parseAccept(r.Header.Get("Accept"))
+		if streaming(ranges) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mediaType := cfg.negotiate(ranges)
+		if mediaType == "" {
+			respond(w, http.StatusNotAcceptable, "the Accept header allows neither "+MediaTypeGraphQLResponse+" nor "+MediaTypeJSON)
+			return
+		}
+
+		r = r.Clone(r.Context())
+		r.Header.Set("Accept", mediaType)
+		next.ServeHTTP(&responseWriter{ResponseWriter: w, method: r.Method, mediaType: mediaType}, r)
+	})
+}
+
+// mediaRange is one entry of an Accept header.
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q-value given to mediaType by the most specific range
+// matching it, 0 if none does.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, 0
+	for _, mr := range ranges {
+		var s int
+		switch mr.mediaType {
+		case mediaType:
+			s = 3
+		case typ + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+	return q
+}
+
+// streaming reports whether the request is meant for the SSE or multipart
+// transports, which the spec does not cover.
+func streaming(ranges []mediaRange) bool {
+	for _, mr := range ranges {
+		if mr.q > 0 && (mr.mediaType == "text/event-stream" || mr.mediaType == "multipart/mixed") {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate returns the media type to respond with, "" if none is
+// acceptable.
+func (cfg *config) negotiate(ranges []mediaRange) string {
+	preferred, other := MediaTypeGraphQLResponse, MediaTypeJSON
+	if cfg.legacy == LegacyFallback {
+		preferred, other = other, preferred
+	}
+	if len(ranges) == 0 {
+		return preferred
+	}
+
+	best, bestQ := "", 0.0
+	for _, mediaType := range []string{preferred, other} {
+		if mediaType == MediaTypeJSON && cfg.legacy == LegacyDisabled {
+			continue
+		}
+		if q := quality(ranges, mediaType); q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+	return best
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	method      string
+	mediaType   string
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	w.wroteHeader = true
+
+	switch {
+	case statusCode == http.StatusNotAcceptable && w.method == http.MethodGet:
+		// gqlgen answers 406 to mutations sent over GET.
+		w.Header().Set("Allow", "POST")
+		statusCode = http.StatusMethodNotAllowed
+	case statusCode == http.StatusUnprocessableEntity && w.mediaType == MediaTypeJSON:
+		statusCode = http.StatusOK
+	case statusCode == http.StatusUnprocessableEntity:
+		statusCode = http.StatusBadRequest
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func respond(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", MediaTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": gqlerror.List{{Message: message}},
+	})
+}
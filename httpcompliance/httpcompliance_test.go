@@ -0,0 +1,77 @@
+package httpcompliance_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/httpcompliance"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+
+	tests := []struct {
+		name        string
+		legacy      httpcompliance.Legacy
+		method      string
+		accept      string
+		query       string
+		status      int
+		contentType string
+		allow       string
+	}{
+		{name: "missing accept", method: http.MethodPost, query: `{ todos { id } }`, status: http.StatusOK, contentType: httpcompliance.MediaTypeJSON},
+		{name: "wildcard", method: http.MethodPost, accept: "*/*", query: `{ todos { id } }`, status: http.StatusOK, contentType: httpcompliance.MediaTypeJSON},
+		{name: "graphql response", method: http.MethodPost, accept: httpcompliance.MediaTypeGraphQLResponse, query: `{ todos { id } }`, status: http.StatusOK, contentType: httpcompliance.MediaTypeGraphQLResponse},
+		{name: "q-values", method: http.MethodPost, accept: "application/graphql-response+json;q=0.5, application/json", query: `{ todos { id } }`, status: http.StatusOK, contentType: httpcompliance.MediaTypeJSON},
+		{name: "not acceptable", method: http.MethodPost, accept: "text/html", query: `{ todos { id } }`, status: http.StatusNotAcceptable, contentType: httpcompliance.MediaTypeJSON},
+		{name: "validation error", method: http.MethodPost, accept: httpcompliance.MediaTypeGraphQLResponse, query: `{ unknown }`, status: http.StatusBadRequest, contentType: httpcompliance.MediaTypeGraphQLResponse},
+		{name: "legacy validation error", method: http.MethodPost, query: `{ unknown }`, status: http.StatusOK, contentType: httpcompliance.MediaTypeJSON},
+		{name: "method not allowed", method: http.MethodPut, query: `{ todos { id } }`, status: http.StatusMethodNotAllowed, allow: "GET, POST"},
+		{name: "mutation over get", method: http.MethodGet, query: `mutation { createTodo(input: {text: "a", userId: "1"}) { id } }`, status: http.StatusMethodNotAllowed, contentType: httpcompliance.MediaTypeJSON, allow: "POST"},
+		{name: "legacy explicit", legacy: httpcompliance.LegacyExplicit, method: http.MethodGet, query: `{ todos { id } }`, status: http.StatusOK, contentType: httpcompliance.MediaTypeGraphQLResponse},
+		{name: "legacy explicit json", legacy: httpcompliance.LegacyExplicit, method: http.MethodPost, accept: "application/json", query: `{ todos { id } }`, status: http.StatusOK, contentType: httpcompliance.MediaTypeJSON},
+		{name: "legacy disabled", legacy: httpcompliance.LegacyDisabled, method: http.MethodPost, accept: "application/json", query: `{ todos { id } }`, status: http.StatusNotAcceptable, contentType: httpcompliance.MediaTypeJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := httpcompliance.Middleware(srv, httpcompliance.WithLegacy(tt.legacy))
+
+			resp := doRequest(h, tt.method, tt.accept, tt.query)
+			assert.Equal(t, tt.status, resp.Code, resp.Body.String())
+			if tt.contentType != "" {
+				assert.Equal(t, tt.contentType, resp.Header().Get("Content-Type"))
+			}
+			assert.Equal(t, tt.allow, resp.Header().Get("Allow"))
+		})
+	}
+}
+
+func doRequest(handler http.Handler, method, accept, query string) *httptest.ResponseRecorder {
+	var r *http.Request
+	if method == http.MethodGet {
+		r = httptest.NewRequest(method, "/query?query="+url.QueryEscape(query), nil)
+	} else {
+		r = httptest.NewRequest(method, "/query", strings.NewReader(`{"query":`+strconv.Quote(query)+`}`))
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,44 @@
+package httpcompliance
+
+// Legacy controls how the application/json media type of GraphQL responses,
+// superseded by application/graphql-response+json, is served.
+type Legacy int
+
+const (
+	// LegacyFallback serves application/json when requested, and when the
+	// Accept header is missing or a wildcard. This is the behaviour the spec
+	// recommends until its watershed.
+	LegacyFallback Legacy = iota
+	// LegacyExplicit serves application/json only to clients asking for it
+	// explicitly.
+	LegacyExplicit
+	// LegacyDisabled answers 406 to clients accepting only application/json.
+	LegacyDisabled
+)
+
+type config struct {
+	legacy Legacy
+}
+
+// Option is anything that can configure Middleware.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		legacy: LegacyFallback,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithLegacy sets how application/json responses are served, LegacyFallback
+// by default.
+func WithLegacy(legacy Legacy) Option {
+	return func(cfg *config) {
+		cfg.legacy = legacy
+	}
+}
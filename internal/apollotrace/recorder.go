@@ -0,0 +1,118 @@
+package apollotrace
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Recorder builds the node tree of a Trace while fields are resolved
+// concurrently.
+type Recorder struct {
+	mu    sync.Mutex
+	start time.Time
+	root  *Node
+	nodes map[string]*Node
+}
+
+// NewRecorder returns a Recorder for an operation started at start.
+func NewRecorder(start time.Time) *Recorder {
+	return &Recorder{
+		start: start,
+		root:  &Node{},
+		nodes: map[string]*Node{},
+	}
+}
+
+// StartField records that the field in fc started resolving now.
+func (r *Recorder) StartField(fc *graphql.FieldContext) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := r.node(fc.Path())
+	n.ParentType = fc.Object
+	if fc.Field.Definition != nil {
+		n.Type = fc.Field.Definition.Type.String()
+	}
+	if fc.Field.Alias != "" && fc.Field.Alias != fc.Field.Name {
+		n.OriginalFieldName = fc.Field.Name
+	}
+	n.StartTime = now.Sub(r.start)
+}
+
+// EndField records that the field in fc finished resolving now.
+func (r *Recorder) EndField(fc *graphql.FieldContext) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.node(fc.Path()).EndTime = now.Sub(r.start)
+}
+
+// AddErrors attaches errs to the nodes at their path, or to the root node.
+func (r *Recorder) AddErrors(errs gqlerror.List) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, err := range errs {
+		n := r.root
+		if len(err.Path) != 0 {
+			n = r.node(err.Path)
+		}
+		n.Errors = append(n.Errors, newError(err))
+	}
+}
+
+// Root returns the root node of the tree.
+func (r *Recorder) Root() *Node {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.root
+}
+
+// node returns the node at path, creating it and its parents as needed.
+// r.mu must be held.
+func (r *Recorder) node(path ast.Path) *Node {
+	if len(path) == 0 {
+		return r.root
+	}
+
+	key := path.String()
+	if n, ok := r.nodes[key]; ok {
+		return n
+	}
+
+	parent := r.node(path[:len(path)-1])
+	n := &Node{}
+	switch el := path[len(path)-1].(type) {
+	case ast.PathIndex:
+		n.IsIndex = true
+		n.Index = int(el)
+	case ast.PathName:
+		n.ResponseName = string(el)
+	}
+	parent.Children = append(parent.Children, n)
+	r.nodes[key] = n
+
+	return n
+}
+
+func newError(err *gqlerror.Error) Error {
+	e := Error{Message: err.Message}
+	for _, loc := range err.Locations {
+		e.Locations = append(e.Locations, Location{Line: loc.Line, Column: loc.Column})
+	}
+	if b, jsonErr := json.Marshal(err); jsonErr == nil {
+		e.JSON = string(b)
+	}
+
+	return e
+}
@@ -0,0 +1,50 @@
+package apollotrace
+
+import "time"
+
+// ReportHeader describes the server sending a Report.
+type ReportHeader struct {
+	GraphRef       string
+	Hostname       string
+	AgentVersion   string
+	ServiceVersion string
+	RuntimeVersion string
+	Uname          string
+}
+
+// Report is a batch of encoded traces grouped by their stats report key.
+type Report struct {
+	Header         ReportHeader
+	EndTime        time.Time
+	TracesPerKey   map[string][][]byte
+	OperationCount int
+}
+
+// Marshal returns the protobuf encoding of r.
+func (r *Report) Marshal() []byte {
+	var header []byte
+	header = appendString(header, 5, r.Header.Hostname)
+	header = appendString(header, 6, r.Header.AgentVersion)
+	header = appendString(header, 7, r.Header.ServiceVersion)
+	header = appendString(header, 8, r.Header.RuntimeVersion)
+	header = appendString(header, 9, r.Header.Uname)
+	header = appendString(header, 12, r.Header.GraphRef)
+
+	var b []byte
+	b = appendMessage(b, 1, header)
+	b = appendMessage(b, 2, appendTimestamp(nil, r.EndTime))
+	for key, traces := range r.TracesPerKey {
+		var tracesAndStats []byte
+		for _, trace := range traces {
+			tracesAndStats = appendMessage(tracesAndStats, 1, trace)
+		}
+
+		var entry []byte
+		entry = appendString(entry, 1, key)
+		entry = appendMessage(entry, 2, tracesAndStats)
+		b = appendMessage(b, 5, entry)
+	}
+	b = appendVarint(b, 6, uint64(r.OperationCount))
+
+	return b
+}
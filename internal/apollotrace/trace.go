@@ -0,0 +1,137 @@
+// Package apollotrace encodes the subset of Apollo's reports.proto needed to
+// report gqlgen traces, shared by the usage reporting and federated tracing
+// extensions. Field numbers follow
+// https://github.com/apollographql/apollo-server/blob/main/packages/usage-reporting-protobuf/src/reports.proto.
+package apollotrace
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Trace is the timing and error tree of a single operation.
+type Trace struct {
+	StartTime     time.Time
+	EndTime       time.Time
+	Root          *Node
+	OperationName string
+	ClientName    string
+	ClientVersion string
+}
+
+// Node is one resolved field, or one element of a list. Times are relative to
+// Trace.StartTime.
+type Node struct {
+	ResponseName string
+	Index        int
+	// IsIndex marks list element nodes, which are identified by Index.
+	IsIndex bool
+
+	OriginalFieldName string
+	Type              string
+	ParentType        string
+	StartTime         time.Duration
+	EndTime           time.Duration
+	Errors            []Error
+	Children          []*Node
+}
+
+// Error is a GraphQL error attached to the node it occurred at.
+type Error struct {
+	Message   string
+	Locations []Location
+	// JSON is the error as serialized in the response.
+	JSON string
+}
+
+// Location is a position in the query document.
+type Location struct {
+	Line   int
+	Column int
+}
+
+// Marshal returns the protobuf encoding of t.
+func (t *Trace) Marshal() []byte {
+	var b []byte
+	b = appendMessage(b, 3, appendTimestamp(nil, t.EndTime))
+	b = appendMessage(b, 4, appendTimestamp(nil, t.StartTime))
+	if t.OperationName != "" {
+		b = appendMessage(b, 6, appendString(nil, 3, t.OperationName))
+	}
+	b = appendString(b, 7, t.ClientName)
+	b = appendString(b, 8, t.ClientVersion)
+	b = appendVarint(b, 11, uint64(t.EndTime.Sub(t.StartTime)))
+	root := t.Root
+	if root == nil {
+		root = &Node{}
+	}
+	b = appendMessage(b, 14, root.marshal())
+
+	return b
+}
+
+func (n *Node) marshal() []byte {
+	var b []byte
+	if n.IsIndex {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(n.Index))
+	} else {
+		b = appendString(b, 1, n.ResponseName)
+	}
+	b = appendString(b, 3, n.Type)
+	b = appendVarint(b, 8, uint64(n.StartTime))
+	b = appendVarint(b, 9, uint64(n.EndTime))
+	for _, err := range n.Errors {
+		b = appendMessage(b, 11, err.marshal())
+	}
+	for _, child := range n.Children {
+		b = appendMessage(b, 12, child.marshal())
+	}
+	b = appendString(b, 13, n.ParentType)
+	b = appendString(b, 14, n.OriginalFieldName)
+
+	return b
+}
+
+func (e Error) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, e.Message)
+	for _, loc := range e.Locations {
+		var l []byte
+		l = appendVarint(l, 1, uint64(loc.Line))
+		l = appendVarint(l, 2, uint64(loc.Column))
+		b = appendMessage(b, 2, l)
+	}
+	b = appendString(b, 4, e.JSON)
+
+	return b
+}
+
+// appendTimestamp encodes t as a google.protobuf.Timestamp.
+func appendTimestamp(b []byte, t time.Time) []byte {
+	b = appendVarint(b, 1, uint64(t.Unix()))
+	b = appendVarint(b, 2, uint64(t.Nanosecond()))
+	return b
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
@@ -0,0 +1,129 @@
+// Package entities finds the objects identified by their typename and id in
+// the data of responses, for caches to purge the entries containing them.
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Entity is an object of the schema identified by its id.
+type Entity struct {
+	Typename string
+	ID       string
+}
+
+func (e Entity) String() string {
+	return e.Typename + ":" + e.ID
+}
+
+// FromSelection returns the entities of data, the response data of
+// selectionSet. Objects of abstract types are only found if __typename is
+// selected, or if id is selected in a fragment on an object type.
+func FromSelection(schema *ast.Schema, selectionSet ast.SelectionSet, data []byte) []Entity {
+	var v map[string]any
+	if json.Unmarshal(data, &v) != nil {
+		return nil
+	}
+	s := scan{schema: schema, seen: map[Entity]bool{}}
+	s.selection(selectionSet, v, schema.Query)
+	return s.found
+}
+
+// FromType returns the entities of data, the JSON encoding of a value of
+// the type named typename, whose keys are the names of the fields of the
+// schema.
+func FromType(schema *ast.Schema, typename string, data []byte) []Entity {
+	var v any
+	if json.Unmarshal(data, &v) != nil {
+		return nil
+	}
+	s := scan{schema: schema, seen: map[Entity]bool{}}
+	s.value(v, schema.Types[typename], 0)
+	return s.found
+}
+
+// maxDepth bounds FromType, whose types may be recursive.
+const maxDepth = 16
+
+type scan struct {
+	schema *ast.Schema
+	seen   map[Entity]bool
+	found  []Entity
+}
+
+func (s *scan) add(def *ast.Definition, obj map[string]any) {
+	if typename, ok := obj["__typename"].(string); ok {
+		def = s.schema.Types[typename]
+	}
+	if def == nil || def.Kind != ast.Object || obj["id"] == nil {
+		return
+	}
+	e := Entity{Typename: def.Name, ID: fmt.Sprint(obj["id"])}
+	if !s.seen[e] {
+		s.seen[e] = true
+		s.found = append(s.found, e)
+	}
+}
+
+// selection scans v, an object or list of objects of type def for
+// selectionSet.
+func (s *scan) selection(selectionSet ast.SelectionSet, v any, def *ast.Definition) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			s.selection(selectionSet, item, def)
+		}
+	case map[string]any:
+		s.add(def, v)
+		for _, sel := range selectionSet {
+			switch sel := sel.(type) {
+			case *ast.Field:
+				if sel.Definition != nil && len(sel.SelectionSet) != 0 {
+					s.selection(sel.SelectionSet, v[sel.Alias], s.schema.Types[sel.Definition.Type.Name()])
+				} else if sel.Name == "id" && sel.Alias != "id" {
+					s.add(def, map[string]any{"id": v[sel.Alias], "__typename": v["__typename"]})
+				}
+			case *ast.InlineFragment:
+				s.selection(sel.SelectionSet, v, s.condition(sel.TypeCondition, def))
+			case *ast.FragmentSpread:
+				if sel.Definition != nil {
+					s.selection(sel.Definition.SelectionSet, v, s.condition(sel.Definition.TypeCondition, def))
+				}
+			}
+		}
+	}
+}
+
+// condition returns the type of the objects of a fragment on condition
+// spread in a selection of type def.
+func (s *scan) condition(condition string, def *ast.Definition) *ast.Definition {
+	if t := s.schema.Types[condition]; t != nil && t.Kind == ast.Object {
+		return t
+	}
+	return def
+}
+
+// value scans v, a value of type def.
+func (s *scan) value(v any, def *ast.Definition, depth int) {
+	if def == nil || depth > maxDepth {
+		return
+	}
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			s.value(item, def, depth)
+		}
+	case map[string]any:
+		s.add(def, v)
+		for _, field := range def.Fields {
+			if child, ok := v[field.Name]; ok {
+				if t := s.schema.Types[field.Type.Name()]; t != nil && t.Kind != ast.Scalar && t.Kind != ast.Enum {
+					s.value(child, t, depth+1)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Package extcode reads the extensions.code of GraphQL errors.
+package extcode
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Code returns the extensions.code of err, "" if it has none or is not a
+// *gqlerror.Error.
+func Code(err error) string {
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) || gqlErr.Extensions == nil {
+		return ""
+	}
+	if code, ok := gqlErr.Extensions["code"]; ok {
+		return fmt.Sprint(code)
+	}
+	return ""
+}
+
+// Codes returns the distinct codes of errList in order of first appearance.
+func Codes(errList gqlerror.List) []string {
+	var codes []string
+	seen := map[string]bool{}
+	for _, err := range errList {
+		code := Code(err)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	return codes
+}
@@ -0,0 +1,44 @@
+// Package gqlctx reads the operation and field contexts of gqlgen the same
+// way in every extension reporting on them.
+package gqlctx
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Nameless is the name tracers and loggers give operations without one.
+const Nameless = "nameless-operation"
+
+// OperationName returns the name of the operation of oc as written in the
+// document, or else the operationName of the request, "" if neither is set.
+func OperationName(oc *graphql.OperationContext) string {
+	if oc.Operation != nil && oc.Operation.Name != "" {
+		return oc.Operation.Name
+	}
+	return oc.OperationName
+}
+
+// OperationNameOrNameless returns the OperationName of oc, Nameless if it is
+// empty.
+func OperationNameOrNameless(oc *graphql.OperationContext) string {
+	if name := OperationName(oc); name != "" {
+		return name
+	}
+	return Nameless
+}
+
+// FieldErrors returns err, unless nil, followed by the errors added to the
+// response for fc.
+func FieldErrors(ctx context.Context, fc *graphql.FieldContext, err error) []error {
+	var errList []error
+	if err != nil {
+		errList = append(errList, err)
+	}
+	for _, gqlErr := range graphql.GetFieldErrors(ctx, fc) {
+		errList = append(errList, gqlErr)
+	}
+
+	return errList
+}
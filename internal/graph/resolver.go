@@ -0,0 +1,123 @@
+//go:generate go run github.com/99designs/gqlgen
+
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTodoNotFound is returned by the todo query for unknown ids.
+var ErrTodoNotFound = errors.New("todo not found")
+
+var (
+	TodoA = &Todo{
+		ID:   "0be25fcf-20e6-4a6d-b0f9-7804224ef20e",
+		Text: "Todo A",
+		Done: false,
+		User: &User{
+			ID:   "123",
+			Name: "Name A",
+		},
+	}
+
+	TodoB = &Todo{
+		ID:   "53314dfd-35a8-4665-b528-20d06ba549be",
+		Text: "Todo B",
+		Done: false,
+		User: &User{
+			ID:   "456",
+			Name: "Name B",
+		},
+	}
+
+	TodoC = &Todo{
+		ID:   "bb5893c7-eb8d-496b-ae36-6002bbe50b7e",
+		Text: "Todo C",
+		Done: false,
+		User: &User{
+			ID:   "789",
+			Name: "Name C",
+		},
+	}
+)
+
+type Resolver struct{}
+
+func (r *Resolver) Mutation() MutationResolver {
+	return &mutationResolver{r}
+}
+func (r *Resolver) Query() QueryResolver {
+	return &queryResolver{r}
+}
+func (r *Resolver) Subscription() SubscriptionResolver {
+	return &subscriptionResolver{r}
+}
+func (r *Resolver) Todo() TodoResolver {
+	return &todoResolver{r}
+}
+
+type mutationResolver struct{ *Resolver }
+
+func (r *mutationResolver) CreateTodo(ctx context.Context, input NewTodo) (*Todo, error) {
+	return &Todo{
+		ID:   "bf7f59b7-a147-43a5-9f80-00a2d4ed6880",
+		Text: input.Text,
+		Done: false,
+		User: &User{
+			ID:   input.UserID,
+			Name: fmt.Sprintf("Test%v", input.UserID),
+		},
+	}, nil
+}
+
+type queryResolver struct{ *Resolver }
+
+func (r *queryResolver) Todos(ctx context.Context, status *TodoStatus) ([]*Todo, error) {
+	todos := []*Todo{}
+	for _, todo := range []*Todo{TodoA, TodoB, TodoC} {
+		switch {
+		case status == nil, *status == TodoStatusAny:
+		case *status == TodoStatusDone && !todo.Done:
+			continue
+		case *status == TodoStatusOpen && todo.Done:
+			continue
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+func (r *queryResolver) Todo(ctx context.Context, id string) (*Todo, error) {
+	for _, todo := range []*Todo{TodoA, TodoB, TodoC} {
+		if todo.ID == id {
+			return todo, nil
+		}
+	}
+	return nil, ErrTodoNotFound
+}
+
+type todoResolver struct{ *Resolver }
+
+func (r *todoResolver) Completed(ctx context.Context, obj *Todo) (bool, error) {
+	return obj.Done, nil
+}
+
+type subscriptionResolver struct{ *Resolver }
+
+// TodoAdded sends every todo once, then ends the subscription.
+func (r *subscriptionResolver) TodoAdded(ctx context.Context) (<-chan *Todo, error) {
+	ch := make(chan *Todo)
+	go func() {
+		defer close(ch)
+		for _, todo := range []*Todo{TodoA, TodoB, TodoC} {
+			select {
+			case ch <- todo:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
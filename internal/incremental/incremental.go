@@ -0,0 +1,126 @@
+// Package incremental follows operations whose results @defer splits into an
+// initial payload and patches.
+package incremental
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Stream accumulates the responses of an operation. Each extension stores its
+// own Stream in the context, under a key of its own.
+type Stream struct {
+	mu          sync.Mutex
+	start       time.Time
+	responses   int
+	incremental bool
+	first       time.Duration
+	last        time.Duration
+	errors      gqlerror.List
+	patchErrors int
+	finished    bool
+	stop        func() bool
+}
+
+// New returns a Stream for an operation started at start.
+func New(start time.Time) *Stream {
+	return &Stream{start: start}
+}
+
+// Add records res, returning whether it is the first response, and whether
+// it is the last one, which non incremental responses are too.
+func (s *Stream) Add(res *graphql.Response) (first, last bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.start)
+	s.responses++
+	first = s.responses == 1
+	if first {
+		s.first = elapsed
+		s.incremental = res.HasNext != nil
+	} else if len(res.Errors) != 0 {
+		s.patchErrors++
+	}
+	s.last = elapsed
+	s.errors = append(s.errors, res.Errors...)
+	return first, res.HasNext == nil || !*res.HasNext
+}
+
+// Incremental reports whether the operation is delivered in several
+// responses.
+func (s *Stream) Incremental() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.incremental
+}
+
+// Finish reports whether the caller is the first to finish the stream, so
+// that it is reported once.
+func (s *Stream) Finish() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return false
+	}
+	s.finished = true
+	if s.stop != nil {
+		s.stop()
+	}
+	return true
+}
+
+// AfterFunc calls fn when ctx is done unless the stream was finished first.
+// Transports such as POST only deliver the initial payload, so the last
+// patch would never be seen.
+func (s *Stream) AfterFunc(ctx context.Context, fn func()) {
+	stop := context.AfterFunc(ctx, func() {
+		if s.Finish() {
+			fn()
+		}
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop = stop
+}
+
+// Patches returns the number of responses after the initial payload.
+func (s *Stream) Patches() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return max(s.responses-1, 0)
+}
+
+// PatchErrors returns the number of patches with errors.
+func (s *Stream) PatchErrors() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.patchErrors
+}
+
+// Errors returns the errors of all the responses.
+func (s *Stream) Errors() gqlerror.List {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errors
+}
+
+// FirstResponse returns the time from the start of the operation to its
+// initial payload.
+func (s *Stream) FirstResponse() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.first
+}
+
+// Duration returns the time from the start of the operation to its last
+// response so far.
+func (s *Stream) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
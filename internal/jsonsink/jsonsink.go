@@ -0,0 +1,63 @@
+// Package jsonsink encodes the values of extension sinks as JSON, to writers
+// or HTTP endpoints.
+package jsonsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Writer writes values to an io.Writer as JSON lines. It is safe for
+// concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriter returns a Writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w)}
+}
+
+// Encode writes v as one JSON line.
+func (w *Writer) Encode(v any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.enc.Encode(v)
+}
+
+// Post POSTs v as JSON to url, adding header to the request. Responses other
+// than 2xx are errors.
+func Post(ctx context.Context, client *http.Client, url string, header http.Header, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s responded %s", url, resp.Status)
+	}
+	return nil
+}
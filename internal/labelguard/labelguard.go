@@ -0,0 +1,87 @@
+// Package labelguard bounds the cardinality of Prometheus labels whose
+// values clients choose.
+package labelguard
+
+import (
+	"sync"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// OverflowValue replaces the label values rejected by a Guard by default.
+const OverflowValue = "__overflow__"
+
+// Guard bounds the number of distinct values a label can take. Values
+// outside the allowlist, or first seen after max distinct values, are
+// reported as Overflow. The empty value always passes through. Every
+// overflow is counted in Dropped, if set.
+type Guard struct {
+	Overflow string
+	Dropped  prometheusclient.Counter
+
+	allow map[string]struct{}
+	max   int
+
+	mu   sync.RWMutex
+	seen map[string]struct{}
+}
+
+// New returns a Guard letting through the values of allow if not empty, or
+// else the first max distinct values, max being unbounded if 0 or less.
+func New(allow []string, max int) *Guard {
+	g := &Guard{
+		Overflow: OverflowValue,
+		max:      max,
+		seen:     map[string]struct{}{},
+	}
+	if len(allow) != 0 {
+		g.allow = make(map[string]struct{}, len(allow))
+		for _, v := range allow {
+			g.allow[v] = struct{}{}
+		}
+	}
+
+	return g
+}
+
+// Value returns v if it is let through, Overflow otherwise.
+func (g *Guard) Value(v string) string {
+	if v == "" {
+		return v
+	}
+	if g.allow != nil {
+		if _, ok := g.allow[v]; ok {
+			return v
+		}
+		return g.drop()
+	}
+	if g.max <= 0 {
+		return v
+	}
+
+	g.mu.RLock()
+	_, ok := g.seen[v]
+	g.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.seen[v]; ok {
+		return v
+	}
+	if len(g.seen) >= g.max {
+		return g.drop()
+	}
+	g.seen[v] = struct{}{}
+
+	return v
+}
+
+func (g *Guard) drop() string {
+	if g.Dropped != nil {
+		g.Dropped.Inc()
+	}
+	return g.Overflow
+}
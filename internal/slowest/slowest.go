@@ -0,0 +1,66 @@
+// Package slowest keeps track of the slowest resolvers of an operation.
+package slowest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Field is a resolved field and the time it took.
+type Field struct {
+	Path     string
+	Duration time.Duration
+}
+
+// Collector counts the resolvers of an operation and keeps the slowest of
+// them, while fields are resolved concurrently. Each extension stores its
+// own Collector in the context, under a key of its own.
+type Collector struct {
+	mu        sync.Mutex
+	max       int
+	resolvers int
+	slowest   []Field
+}
+
+// NewCollector returns a Collector keeping the max slowest fields.
+func NewCollector(max int) *Collector {
+	return &Collector{max: max}
+}
+
+// Add records a resolver that took d.
+func (c *Collector) Add(path string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resolvers++
+	if c.max <= 0 {
+		return
+	}
+
+	i := sort.Search(len(c.slowest), func(i int) bool {
+		return c.slowest[i].Duration < d
+	})
+	if i == c.max {
+		return
+	}
+	if len(c.slowest) < c.max {
+		c.slowest = append(c.slowest, Field{})
+	}
+	copy(c.slowest[i+1:], c.slowest[i:])
+	c.slowest[i] = Field{Path: path, Duration: d}
+}
+
+// Resolvers returns the number of resolvers recorded so far.
+func (c *Collector) Resolvers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resolvers
+}
+
+// Slowest returns the slowest fields recorded so far, slowest first.
+func (c *Collector) Slowest() []Field {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Field(nil), c.slowest...)
+}
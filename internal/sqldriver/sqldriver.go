@@ -0,0 +1,257 @@
+// Package sqldriver wraps database/sql drivers to intercept the queries and
+// statements they execute.
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// Interceptor hooks into the queries of the connections of a connector,
+// either func being optional.
+type Interceptor struct {
+	// Rewrite returns the query sent to the driver in place of query, when it
+	// is executed or prepared in ctx.
+	Rewrite func(ctx context.Context, query string) string
+	// Around runs fn, which executes query, operation being "query" or
+	// "exec". fn returns driver.ErrSkip if the driver lacks the method, for
+	// database/sql to prepare the query instead.
+	Around func(ctx context.Context, operation, query string, fn func(ctx context.Context) error) error
+}
+
+// Wrap returns a connector whose connections are those of c, intercepted by
+// i.
+func Wrap(c driver.Connector, i Interceptor) driver.Connector {
+	return &connector{Connector: c, i: &i}
+}
+
+// Connector returns the connector of the databases sql.Open opens with
+// driverName and dsn.
+func Connector(driverName, dsn string) (driver.Connector, error) {
+	// The driver is only known by the databases opened with it.
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	_ = db.Close()
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+	return dsnConnector{dsn: dsn, driver: d}, nil
+}
+
+func (i *Interceptor) rewrite(ctx context.Context, query string) string {
+	if i.Rewrite == nil {
+		return query
+	}
+	return i.Rewrite(ctx, query)
+}
+
+func (i *Interceptor) around(ctx context.Context, operation, query string, fn func(ctx context.Context) error) error {
+	if i.Around == nil {
+		return fn(ctx)
+	}
+	return i.Around(ctx, operation, query, fn)
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type connector struct {
+	driver.Connector
+	i *Interceptor
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, i: c.i}, nil
+}
+
+// conn intercepts the queries of a connection. It implements the optional
+// interfaces of drivers so database/sql uses them, falling back as it would
+// when Conn does not.
+type conn struct {
+	driver.Conn
+	i *Interceptor
+}
+
+var _ interface {
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+	driver.NamedValueChecker
+} = &conn{}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query = c.i.rewrite(ctx, query)
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, query: query, i: c.i}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("sqldriver: driver does not support non-default isolation level or read-only transactions")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	query = c.i.rewrite(ctx, query)
+	var rows driver.Rows
+	err := c.i.around(ctx, "query", query, func(ctx context.Context) error {
+		var err error
+		rows, err = qc.QueryContext(ctx, query, args)
+		return err
+	})
+	return rows, err
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	query = c.i.rewrite(ctx, query)
+	var res driver.Result
+	err := c.i.around(ctx, "exec", query, func(ctx context.Context) error {
+		var err error
+		res, err = ec.ExecContext(ctx, query, args)
+		return err
+	})
+	return res, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt intercepts the executions of a prepared statement, query being as
+// rewritten when it was prepared.
+type stmt struct {
+	driver.Stmt
+	query string
+	i     *Interceptor
+}
+
+var _ interface {
+	driver.StmtQueryContext
+	driver.StmtExecContext
+	driver.NamedValueChecker
+} = &stmt{}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := s.i.around(ctx, "query", s.query, func(ctx context.Context) error {
+		var err error
+		if sq, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			rows, err = sq.QueryContext(ctx, args)
+			return err
+		}
+		values, err := values(args)
+		if err != nil {
+			return err
+		}
+		rows, err = s.Stmt.Query(values)
+		return err
+	})
+	return rows, err
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+	err := s.i.around(ctx, "exec", s.query, func(ctx context.Context) error {
+		var err error
+		if se, ok := s.Stmt.(driver.StmtExecContext); ok {
+			res, err = se.ExecContext(ctx, args)
+			return err
+		}
+		values, err := values(args)
+		if err != nil {
+			return err
+		}
+		res, err = s.Stmt.Exec(values)
+		return err
+	})
+	return res, err
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// values converts args for drivers without context methods, which do not
+// support named parameters.
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sqldriver: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
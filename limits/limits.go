@@ -0,0 +1,204 @@
+// Package limits rejects oversized requests before gqlgen parses them.
+package limits
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes set as extensions.code of the error answered to rejected
+// requests.
+const (
+	ErrCodeRequestTooLarge = "REQUEST_TOO_LARGE"
+	ErrCodeTooManyUploads  = "TOO_MANY_UPLOADS"
+	ErrCodeUploadTooLarge  = "UPLOAD_TOO_LARGE"
+	ErrCodeBatchTooLarge   = "BATCH_TOO_LARGE"
+)
+
+// rejection describes why a request was rejected.
+type rejection struct {
+	reason  string
+	code    string
+	message string
+}
+
+func (cfg *config) bodySize() *rejection {
+	return &rejection{"body_size", ErrCodeRequestTooLarge, "request body exceeds " + strconv.FormatInt(cfg.maxBodySize, 10) + " bytes"}
+}
+
+func (cfg *config) uploads() *rejection {
+	return &rejection{"uploads", ErrCodeTooManyUploads, "request has more than " + strconv.Itoa(cfg.maxUploads) + " uploads"}
+}
+
+func (cfg *config) uploadSize() *rejection {
+	return &rejection{"upload_size", ErrCodeUploadTooLarge, "upload exceeds " + strconv.FormatInt(cfg.maxUploadSize, 10) + " bytes"}
+}
+
+func (cfg *config) batchSize() *rejection {
+	return &rejection{"batch_size", ErrCodeBatchTooLarge, "batch has more than " + strconv.Itoa(cfg.maxBatchSize) + " operations"}
+}
+
+// Limiter is an HTTP middleware answering 413 to requests exceeding its
+// limits, counting them in graphql_request_rejected_total{reason}, reason
+// being one of body_size, uploads, upload_size and batch_size.
+//
+// JSON bodies are read in full before being passed on, so batches can be
+// counted. Multipart bodies are streamed, the limits being checked as gqlgen
+// reads them.
+type Limiter struct {
+	cfg        *config
+	counter    *prometheusclient.CounterVec
+	registerer prometheusclient.Registerer
+}
+
+// New returns a Limiter whose counter is registered on the configured
+// registerer.
+func New(opts ...Option) Limiter {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_request_rejected_total",
+			Help:        "Total number of requests rejected for exceeding a limit.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"reason"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return Limiter{
+		cfg:        cfg,
+		counter:    counter,
+		registerer: cfg.registerer,
+	}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (l Limiter) UnRegister() {
+	l.registerer.Unregister(l.counter)
+}
+
+// Middleware enforces the limits on requests to next.
+func (l Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "multipart/form-data" && params["boundary"] != "" {
+			body := newMultipartReader(r.Body, params["boundary"], l.cfg, l.reject)
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{body, r.Body}
+			next.ServeHTTP(&responseWriter{ResponseWriter: w, body: body}, r)
+			return
+		}
+
+		if l.cfg.maxBodySize > 0 && r.ContentLength > l.cfg.maxBodySize {
+			l.respond(w, l.cfg.bodySize())
+			return
+		}
+		if l.cfg.maxBodySize == 0 && l.cfg.maxBatchSize == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var limited io.Reader = r.Body
+		if l.cfg.maxBodySize > 0 {
+			limited = io.LimitReader(r.Body, l.cfg.maxBodySize+1)
+		}
+		buf, err := io.ReadAll(limited)
+		if err == nil && l.cfg.maxBodySize > 0 && int64(len(buf)) > l.cfg.maxBodySize {
+			l.respond(w, l.cfg.bodySize())
+			return
+		}
+		if err == nil && l.cfg.maxBatchSize > 0 && batchSize(buf) > l.cfg.maxBatchSize {
+			l.respond(w, l.cfg.batchSize())
+			return
+		}
+
+		// Read errors surface to next, which reads the remainder of the body.
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l Limiter) reject(rej *rejection) {
+	l.counter.WithLabelValues(rej.reason).Inc()
+}
+
+func (l Limiter) respond(w http.ResponseWriter, rej *rejection) {
+	l.reject(rej)
+	writeRejection(w, rej)
+}
+
+func writeRejection(w http.ResponseWriter, rej *rejection) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": gqlerror.List{{
+			Message:    rej.message,
+			Extensions: map[string]any{"code": rej.code},
+		}},
+	})
+}
+
+// batchSize returns the number of operations of a batched JSON body, 0 if
+// body is not a batch.
+func batchSize(body []byte) int {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	if len(body) == 0 || body[0] != '[' {
+		return 0
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return 0
+	}
+	return len(batch)
+}
+
+// responseWriter replaces the response to a multipart request rejected while
+// being read.
+type responseWriter struct {
+	http.ResponseWriter
+	body     *multipartReader
+	rejected bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.body.rejected == nil {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if !w.rejected {
+		w.rejected = true
+		writeRejection(w.ResponseWriter, w.body.rejected)
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.body.rejected == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	return len(b), nil
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
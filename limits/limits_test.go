@@ -0,0 +1,104 @@
+package limits_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/limits"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	limiter := limits.New(
+		limits.WithRegisterer(registry),
+		limits.WithMaxBodySize(256),
+		limits.WithMaxUploads(2),
+		limits.WithMaxUploadSize(64),
+		limits.WithMaxBatchSize(2),
+	)
+	defer limiter.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	h := limiter.Middleware(srv)
+
+	query := `{"query":"{ todos { id } }"}`
+
+	resp := doRequest(h, "application/json", strings.NewReader(query))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(h, "application/json", strings.NewReader(`{"query":"{ todos { id } }", "extensions": {"padding": "`+strings.Repeat("x", 256)+`"}}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"REQUEST_TOO_LARGE"`)
+
+	resp = doRequest(h, "application/json", strings.NewReader(`[`+query+`,`+query+`,`+query+`]`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"BATCH_TOO_LARGE"`)
+
+	contentType, body := multipartBody(t, 2, 32)
+	resp = doRequest(h, contentType, body)
+	assert.NotEqual(t, http.StatusRequestEntityTooLarge, resp.Code, resp.Body.String())
+
+	contentType, body = multipartBody(t, 3, 32)
+	resp = doRequest(h, contentType, body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"TOO_MANY_UPLOADS"`)
+
+	contentType, body = multipartBody(t, 1, 128)
+	resp = doRequest(h, contentType, body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"UPLOAD_TOO_LARGE"`)
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "", nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+	for _, reason := range []string{"body_size", "batch_size", "uploads", "upload_size"} {
+		assert.Contains(t, resp.Body.String(), `graphql_request_rejected_total{reason="`+reason+`"} 1`)
+	}
+}
+
+// multipartBody returns a GraphQL multipart request uploading n files of
+// size bytes.
+func multipartBody(t *testing.T, n, size int) (string, io.Reader) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("operations", `{"query":"{ todos { id } }"}`))
+	require.NoError(t, mw.WriteField("map", `{}`))
+	for i := range n {
+		fw, err := mw.CreateFormFile(string(rune('a'+i)), "file.txt")
+		require.NoError(t, err)
+		_, err = fw.Write(bytes.Repeat([]byte("x"), size))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	return mw.FormDataContentType(), &buf
+}
+
+func doRequest(handler http.Handler, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	method := http.MethodPost
+	if body == nil {
+		method = http.MethodGet
+	}
+	r := httptest.NewRequest(method, "/query", body)
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,141 @@
+package limits
+
+import (
+	"errors"
+	"io"
+)
+
+var headersEnd = []byte("\r\n\r\n")
+
+var errRejected = errors.New("limits: request rejected")
+
+// multipartReader checks the parts of a multipart body as it is read,
+// failing reads once a limit is exceeded. It only looks for part
+// delimiters: the first two parts are the operations and map fields of the
+// GraphQL multipart request spec, the others files.
+type multipartReader struct {
+	r        io.Reader
+	cfg      *config
+	onReject func(rej *rejection)
+	rejected *rejection
+
+	delim   []byte
+	fail    []int
+	matched int
+	// part is the number of the current part, 0 for the preamble.
+	part int
+	size int64
+	// headers counts the bytes of the CRLFCRLF ending the part headers
+	// seen so far, -1 once they ended.
+	headers int
+	// dashes counts the dashes following the last delimiter, two of which
+	// close the body. pending is set until that is known.
+	dashes  int
+	pending bool
+	closed  bool
+}
+
+func newMultipartReader(r io.Reader, boundary string, cfg *config, onReject func(rej *rejection)) *multipartReader {
+	delim := []byte("\r\n--" + boundary)
+
+	// fail is the failure function of the Knuth-Morris-Pratt search.
+	fail := make([]int, len(delim))
+	for i, k := 1, 0; i < len(delim); i++ {
+		for k > 0 && delim[i] != delim[k] {
+			k = fail[k-1]
+		}
+		if delim[i] == delim[k] {
+			k++
+		}
+		fail[i] = k
+	}
+
+	return &multipartReader{
+		r:        r,
+		cfg:      cfg,
+		onReject: onReject,
+		delim:    delim,
+		fail:     fail,
+		// The first delimiter is not preceded by a line break.
+		matched: 2,
+	}
+}
+
+func (m *multipartReader) Read(p []byte) (int, error) {
+	if m.rejected != nil {
+		return 0, errRejected
+	}
+	n, err := m.r.Read(p)
+	for i, b := range p[:n] {
+		if rej := m.step(b); rej != nil {
+			m.rejected = rej
+			m.onReject(rej)
+			return i, errRejected
+		}
+	}
+	return n, err
+}
+
+func (m *multipartReader) step(b byte) *rejection {
+	if m.closed {
+		return nil
+	}
+
+	if m.pending {
+		if b == '-' {
+			if m.dashes++; m.dashes == 2 {
+				m.closed = true
+				return nil
+			}
+		} else {
+			m.pending = false
+			m.part++
+			m.size, m.headers = 0, 0
+			if m.part > 2 && m.cfg.maxUploads > 0 && m.part-2 > m.cfg.maxUploads {
+				return m.cfg.uploads()
+			}
+		}
+	}
+
+	for m.matched > 0 && b != m.delim[m.matched] {
+		m.matched = m.fail[m.matched-1]
+	}
+	if b == m.delim[m.matched] {
+		m.matched++
+	}
+	if m.matched == len(m.delim) {
+		m.matched = m.fail[m.matched-1]
+		m.pending, m.dashes = true, 0
+		return nil
+	}
+
+	if m.part == 0 || m.pending {
+		return nil
+	}
+	if m.headers >= 0 {
+		switch {
+		case b == headersEnd[m.headers]:
+			if m.headers++; m.headers == len(headersEnd) {
+				m.headers = -1
+			}
+		case b == '\r':
+			m.headers = 1
+		default:
+			m.headers = 0
+		}
+		return nil
+	}
+	m.size++
+	limit := m.cfg.maxBodySize
+	if m.part > 2 {
+		limit = m.cfg.maxUploadSize
+	}
+	// The size includes what may turn out to be the next delimiter.
+	if limit > 0 && m.size > limit+int64(len(m.delim)) {
+		if m.part > 2 {
+			return m.cfg.uploadSize()
+		}
+		return m.cfg.bodySize()
+	}
+	return nil
+}
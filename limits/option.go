@@ -0,0 +1,96 @@
+package limits
+
+import (
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	maxBodySize   int64
+	maxUploads    int
+	maxUploadSize int64
+	maxBatchSize  int
+	namespace     string
+	subsystem     string
+	constLabels   prometheusclient.Labels
+	registerer    prometheusclient.Registerer
+}
+
+// Option is anything that can configure Limiter.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		maxBodySize:   1 << 20,
+		maxUploads:    10,
+		maxUploadSize: 32 << 20,
+		registerer:    prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithMaxBodySize limits request bodies, and the operations and map fields
+// of multipart requests, to n bytes, 1MiB by default. 0 lifts the limit.
+func WithMaxBodySize(n int64) Option {
+	return func(cfg *config) {
+		cfg.maxBodySize = n
+	}
+}
+
+// WithMaxUploads limits multipart requests to n files, 10 by default. 0
+// lifts the limit.
+func WithMaxUploads(n int) Option {
+	return func(cfg *config) {
+		cfg.maxUploads = n
+	}
+}
+
+// WithMaxUploadSize limits each file of multipart requests to n bytes,
+// 32MiB by default. 0 lifts the limit.
+func WithMaxUploadSize(n int64) Option {
+	return func(cfg *config) {
+		cfg.maxUploadSize = n
+	}
+}
+
+// WithMaxBatchSize limits batched requests, whose JSON body is an array of
+// operations, to n operations. Batches are not limited by default.
+func WithMaxBatchSize(n int) Option {
+	return func(cfg *config) {
+		cfg.maxBatchSize = n
+	}
+}
+
+// WithNamespace prefixes the metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metric on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,78 @@
+package runtime
+
+import (
+	"log/slog"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+	logger      *slog.Logger
+	rewrite     bool
+}
+
+// Option is anything that can configure Enforcer.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		registerer: prometheusclient.DefaultRegisterer,
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithLogger logs the uncoded errors to logger instead of slog.Default. A
+// nil logger only counts them.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cfg *config) {
+		cfg.logger = logger
+	}
+}
+
+// WithRewrite gives the uncoded errors the INTERNAL_SERVER_ERROR code
+// before they reach clients, once they are reported.
+func WithRewrite() Option {
+	return func(cfg *config) {
+		cfg.rewrite = true
+	}
+}
+
+// WithNamespace prefixes the metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metric on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,97 @@
+// Package runtime checks at run time that the errors sent to clients follow
+// the error contract of the errcode package: each of them has an
+// extensions.code.
+package runtime
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/99designs/gqlgen-contrib/errcode"
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// Enforcer is a gqlgen handler extension reporting the response errors
+// without an extensions.code, each one incrementing
+// graphql_uncoded_errors_total{operation_name} and logged at warn level.
+// Register it before the other extensions to see the errors they add.
+type Enforcer struct {
+	cfg     *config
+	counter *prometheusclient.CounterVec
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = Enforcer{}
+
+// New returns an Enforcer whose counter is registered on the configured
+// registerer.
+func New(opts ...Option) Enforcer {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_uncoded_errors_total",
+			Help:        "Total number of errors returned to clients without an extensions.code.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"operation_name"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return Enforcer{cfg: cfg, counter: counter}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (e Enforcer) UnRegister() {
+	e.cfg.registerer.Unregister(e.counter)
+}
+
+func (e Enforcer) ExtensionName() string {
+	return "ErrorCodeLint"
+}
+
+func (e Enforcer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Enforcer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || len(res.Errors) == 0 {
+		return res
+	}
+
+	var name string
+	if graphql.HasOperationContext(ctx) {
+		name = gqlctx.OperationName(graphql.GetOperationContext(ctx))
+	}
+
+	for _, err := range res.Errors {
+		if extcode.Code(err) != "" {
+			continue
+		}
+
+		e.counter.WithLabelValues(name).Inc()
+		if e.cfg.logger != nil {
+			e.cfg.logger.LogAttrs(ctx, slog.LevelWarn, "graphql error without code",
+				slog.String("operation", name),
+				slog.String("path", err.Path.String()),
+				slog.String("error", err.Message),
+			)
+		}
+		if e.cfg.rewrite {
+			if err.Extensions == nil {
+				err.Extensions = map[string]interface{}{}
+			}
+			err.Extensions["code"] = errcode.CodeInternal
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,106 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/errcode"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/lint/runtime"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestEnforcer(t *testing.T) {
+	specs := []struct {
+		SpecName string
+		Options  []runtime.Option
+		Code     interface{}
+	}{
+		{SpecName: "report"},
+		{SpecName: "rewrite", Options: []runtime.Option{runtime.WithRewrite()}, Code: errcode.CodeInternal},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			var logs bytes.Buffer
+			registry := prometheusclient.NewRegistry()
+			enforcer := runtime.New(append(spec.Options,
+				runtime.WithRegisterer(registry),
+				runtime.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+			)...)
+			defer enforcer.UnRegister()
+
+			srv := handler.New(graph.NewExecutableSchema(graph.Config{
+				Resolvers: &graph.Resolver{},
+			}))
+			srv.AddTransport(transport.POST{})
+			srv.Use(enforcer)
+
+			resp := doRequest(srv, `{"query":"query Missing { todo(id: \"unknown\") { id } }"}`)
+			require.Equal(t, http.StatusOK, resp.Code)
+			resp = doRequest(srv, `{"query":"query Broken { unknown }"}`)
+			require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+			assert.Equal(t, 1, testutil.CollectAndCount(registry, "graphql_uncoded_errors_total"))
+
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+			assert.Equal(t, "WARN", record["level"])
+			assert.Equal(t, "Missing", record["operation"])
+			assert.Equal(t, "todo", record["path"])
+			assert.Equal(t, graph.ErrTodoNotFound.Error(), record["error"])
+
+			resp = doRequest(srv, `{"query":"query Missing { todo(id: \"unknown\") { id } }"}`)
+			var body struct {
+				Errors []struct {
+					Extensions map[string]interface{} `json:"extensions"`
+				} `json:"errors"`
+			}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+			require.Len(t, body.Errors, 1)
+			assert.Equal(t, spec.Code, body.Errors[0].Extensions["code"])
+		})
+	}
+}
+
+func TestEnforcer_NonStringCode(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	enforcer := runtime.New(runtime.WithRegisterer(registry), runtime.WithRewrite())
+	defer enforcer.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(enforcer)
+	srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		res := next(ctx)
+		res.Errors = append(res.Errors, &gqlerror.Error{Message: "gone", Extensions: map[string]interface{}{"code": 410}})
+		return res
+	})
+
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+	assert.Contains(t, resp.Body.String(), `"code":410`)
+	assert.Equal(t, 0, testutil.CollectAndCount(registry, "graphql_uncoded_errors_total"))
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package loadshed
+
+import "time"
+
+func cpuTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,156 @@
+// Package loadshed rejects operations while the server is overloaded,
+// dropping those of low priority clients first.
+package loadshed
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeServiceUnavailable is the extensions.code of the error answered to
+// shed operations.
+const ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+
+// pollInterval is how often queued operations check the load.
+const pollInterval = 10 * time.Millisecond
+
+// Shedder is a gqlgen handler extension measuring load with its probes
+// before every operation, and rejecting the operation with a
+// SERVICE_UNAVAILABLE error when load reaches the threshold of its
+// priority. With a queue timeout, the operation waits for load to drop
+// first.
+//
+// Shed operations are counted in graphql_requests_shed_total{priority} and
+// operations that had to wait in graphql_requests_queued_total{priority}.
+type Shedder struct {
+	cfg      *config
+	inFlight atomic.Int64
+	shed     *prometheusclient.CounterVec
+	queued   *prometheusclient.CounterVec
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Shedder{}
+
+// New returns a Shedder whose metrics are registered on the configured
+// registerer.
+func New(opts ...Option) *Shedder {
+	cfg := newConfig(opts...)
+
+	counter := func(name, help string) *prometheusclient.CounterVec {
+		return prometheusclient.NewCounterVec(
+			prometheusclient.CounterOpts{
+				Namespace:   cfg.namespace,
+				Subsystem:   cfg.subsystem,
+				Name:        name,
+				Help:        help,
+				ConstLabels: cfg.constLabels,
+			},
+			[]string{"priority"},
+		)
+	}
+	s := &Shedder{
+		cfg:    cfg,
+		shed:   counter("graphql_requests_shed_total", "Total number of operations rejected for load."),
+		queued: counter("graphql_requests_queued_total", "Total number of operations that waited for load to drop."),
+	}
+	cfg.registerer.MustRegister(s.shed, s.queued)
+
+	return s
+}
+
+// UnRegister removes the metrics from the registerer they were registered
+// on.
+func (s *Shedder) UnRegister() {
+	s.cfg.registerer.Unregister(s.shed)
+	s.cfg.registerer.Unregister(s.queued)
+}
+
+func (s *Shedder) ExtensionName() string {
+	return "LoadShed"
+}
+
+func (s *Shedder) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Load returns the highest load reported by the probes.
+func (s *Shedder) Load() float64 {
+	var load float64
+	if s.cfg.maxInFlight > 0 {
+		load = float64(s.inFlight.Load()) / float64(s.cfg.maxInFlight)
+	}
+	for _, probe := range s.cfg.probes {
+		load = max(load, probe())
+	}
+	return load
+}
+
+func (s *Shedder) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	priority := s.cfg.priority(ctx)
+	if threshold, ok := s.cfg.thresholds[priority]; ok && s.Load() >= threshold && !s.wait(ctx, priority, threshold) {
+		s.shed.WithLabelValues(priority.String()).Inc()
+		return graphql.OneShot(&graphql.Response{
+			Errors: gqlerror.List{{
+				Message:    "server overloaded, retry later",
+				Extensions: map[string]any{"code": ErrCodeServiceUnavailable},
+			}},
+		})
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	subscription := oc.Operation != nil && oc.Operation.Operation == ast.Subscription
+
+	s.inFlight.Add(1)
+	var once sync.Once
+	done := func() {
+		once.Do(func() { s.inFlight.Add(-1) })
+	}
+
+	responses := next(ctx)
+
+	// Subscriptions stay in flight until the stream ends with a nil response.
+	return func(ctx context.Context) *graphql.Response {
+		res := responses(ctx)
+		if res == nil || !subscription {
+			done()
+		}
+		return res
+	}
+}
+
+// wait reports whether load dropped under threshold within the queue
+// timeout.
+func (s *Shedder) wait(ctx context.Context, priority Priority, threshold float64) bool {
+	if s.cfg.queueTimeout <= 0 {
+		return false
+	}
+	s.queued.WithLabelValues(priority.String()).Inc()
+
+	timeout := time.NewTimer(s.cfg.queueTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timeout.C:
+			return false
+		case <-ticker.C:
+			if s.Load() < threshold {
+				return true
+			}
+		}
+	}
+}
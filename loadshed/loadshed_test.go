@@ -0,0 +1,133 @@
+package loadshed_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/loadshed"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShedder(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	shedder := loadshed.New(
+		loadshed.WithRegisterer(registry),
+		loadshed.WithMaxInFlight(1),
+		loadshed.WithPriorityFunc(loadshed.ByClient(map[string]loadshed.Priority{
+			"batch":   loadshed.Low,
+			"console": loadshed.Critical,
+		})),
+	)
+	defer shedder.UnRegister()
+
+	srv, release := newServer(shedder)
+
+	// A slow operation holds the only slot.
+	slow := make(chan string)
+	go func() {
+		slow <- doRequest(srv, `{"query":"query Slow { todos { id } }"}`, "").Body.String()
+	}()
+	require.Eventually(t, func() bool { return shedder.Load() == 1 }, time.Second, time.Millisecond)
+
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`, "batch")
+	assert.Contains(t, resp.Body.String(), `"code":"SERVICE_UNAVAILABLE"`)
+	resp = doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	assert.Contains(t, resp.Body.String(), `"code":"SERVICE_UNAVAILABLE"`)
+	resp = doRequest(srv, `{"query":"{ todos { id } }"}`, "console")
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	close(release)
+	assert.NotContains(t, <-slow, "errors")
+	assert.Zero(t, shedder.Load())
+
+	resp = doRequest(srv, `{"query":"{ todos { id } }"}`, "batch")
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `graphql_requests_shed_total{priority="low"} 1`)
+	assert.Contains(t, resp.Body.String(), `graphql_requests_shed_total{priority="normal"} 1`)
+	assert.NotContains(t, resp.Body.String(), `priority="critical"`)
+}
+
+func TestShedder_Queue(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	shedder := loadshed.New(
+		loadshed.WithRegisterer(registry),
+		loadshed.WithMaxInFlight(1),
+		loadshed.WithQueueTimeout(time.Second),
+	)
+	defer shedder.UnRegister()
+
+	srv, release := newServer(shedder)
+
+	slow := make(chan string)
+	go func() {
+		slow <- doRequest(srv, `{"query":"query Slow { todos { id } }"}`, "").Body.String()
+	}()
+	require.Eventually(t, func() bool { return shedder.Load() == 1 }, time.Second, time.Millisecond)
+
+	time.AfterFunc(30*time.Millisecond, func() { close(release) })
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.NotContains(t, <-slow, "errors")
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "", "")
+	assert.Contains(t, resp.Body.String(), `graphql_requests_queued_total{priority="normal"} 1`)
+	assert.NotContains(t, resp.Body.String(), `graphql_requests_shed_total`)
+}
+
+func TestCPU(t *testing.T) {
+	probe := loadshed.CPU(time.Millisecond)
+	assert.Zero(t, probe())
+
+	end := time.Now().Add(20 * time.Millisecond)
+	for time.Now().Before(end) {
+	}
+	assert.Greater(t, probe(), 0.0)
+}
+
+// newServer returns a server whose Slow operation blocks until release is
+// closed.
+func newServer(shedder *loadshed.Shedder) (*handler.Server, chan struct{}) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(shedder)
+
+	release := make(chan struct{})
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if graphql.GetOperationContext(ctx).Operation.Name == "Slow" {
+			<-release
+		}
+		return next(ctx)
+	})
+	return srv, release
+}
+
+func doRequest(handler http.Handler, body, client string) *httptest.ResponseRecorder {
+	method := http.MethodPost
+	if body == "" {
+		method = http.MethodGet
+	}
+	r := httptest.NewRequest(method, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if client != "" {
+		r.Header.Set("apollographql-client-name", client)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,112 @@
+package loadshed
+
+import (
+	"context"
+	"time"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	probes       []Probe
+	maxInFlight  int64
+	thresholds   map[Priority]float64
+	priority     func(ctx context.Context) Priority
+	queueTimeout time.Duration
+	namespace    string
+	subsystem    string
+	constLabels  prometheusclient.Labels
+	registerer   prometheusclient.Registerer
+}
+
+// Option is anything that can configure Shedder.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		thresholds: map[Priority]float64{
+			Low:    0.7,
+			Normal: 0.9,
+			High:   1,
+		},
+		priority:   ForContext,
+		registerer: prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithMaxInFlight measures load as the number of operations in flight over
+// n.
+func WithMaxInFlight(n int) Option {
+	return func(cfg *config) {
+		cfg.maxInFlight = int64(n)
+	}
+}
+
+// WithProbe measures load with probe too, the highest load of all probes
+// being used. Calling it again adds more probes.
+func WithProbe(probe Probe) Option {
+	return func(cfg *config) {
+		cfg.probes = append(cfg.probes, probe)
+	}
+}
+
+// WithThreshold sheds operations of priority p once load reaches
+// threshold. Defaults to 0.7 for Low, 0.9 for Normal and 1 for High.
+// Critical operations are never shed.
+func WithThreshold(p Priority, threshold float64) Option {
+	return func(cfg *config) {
+		cfg.thresholds[p] = threshold
+	}
+}
+
+// WithPriorityFunc derives the priority of operations with fn instead of
+// ForContext, for instance with ByClient.
+func WithPriorityFunc(fn func(ctx context.Context) Priority) Option {
+	return func(cfg *config) {
+		cfg.priority = fn
+	}
+}
+
+// WithQueueTimeout lets operations over their threshold wait up to d for
+// load to drop before shedding them. They are shed right away by default.
+func WithQueueTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.queueTimeout = d
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
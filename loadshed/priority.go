@@ -0,0 +1,61 @@
+package loadshed
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+)
+
+// Priority orders operations for shedding, lower priorities going first.
+type Priority int
+
+// Priorities, Normal being the zero value.
+const (
+	Low Priority = iota - 1
+	Normal
+	High
+	Critical
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	case Critical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx carrying p, for instance set by an
+// HTTP middleware from the authenticated user.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// ForContext returns the priority set with WithPriority, Normal if there is
+// none.
+func ForContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return Normal
+}
+
+// ByClient returns a priority func giving operations the priority of their
+// client name in priorities, as reported by clientinfo, or ForContext.
+func ByClient(priorities map[string]Priority) func(ctx context.Context) Priority {
+	return func(ctx context.Context) Priority {
+		if p, ok := priorities[clientinfo.ForContext(ctx).Name]; ok {
+			return p
+		}
+		return ForContext(ctx)
+	}
+}
@@ -0,0 +1,41 @@
+package loadshed
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Probe returns the current load, 1 meaning the service is at capacity.
+type Probe func() float64
+
+// CPU returns a Probe measuring the CPU time used by the process over the
+// CPUs available to it, GOMAXPROCS, sampled at most every interval. It
+// always reports 0 on platforms without getrusage.
+func CPU(interval time.Duration) Probe {
+	var (
+		mu       sync.Mutex
+		last     time.Time
+		lastUsed time.Duration
+		load     float64
+	)
+
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		if now.Sub(last) < interval {
+			return load
+		}
+		used, ok := cpuTime()
+		if !ok {
+			return 0
+		}
+		if !last.IsZero() {
+			load = float64(used-lastUsed) / float64(now.Sub(last)) / float64(runtime.GOMAXPROCS(0))
+		}
+		last, lastUsed = now, used
+		return load
+	}
+}
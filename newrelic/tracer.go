@@ -0,0 +1,111 @@
+package newrelic
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// Tracer is a gqlgen handler extension reporting operations to New Relic.
+//
+// Operations join the transaction found in the request context, typically
+// started by newrelic.WrapHandle, or start their own transaction on the
+// configured Application otherwise. Resolvers are reported as segments and
+// GraphQL errors as noticed errors.
+// see https://pkg.go.dev/github.com/newrelic/go-agent/v3/newrelic
+type Tracer struct {
+	app *newrelic.Application
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+// New returns a Tracer starting transactions on app when the request context
+// carries none. app may be nil when every request is already instrumented.
+func New(app *newrelic.Application) Tracer {
+	return Tracer{app: app}
+}
+
+// Transaction returns the New Relic transaction of the current operation,
+// or nil if there is none. Resolvers use it to add attributes or segments.
+func Transaction(ctx context.Context) *newrelic.Transaction {
+	return newrelic.FromContext(ctx)
+}
+
+func (t Tracer) ExtensionName() string {
+	return "NewRelic"
+}
+
+func (t Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := transactionName(oc)
+
+	txn := newrelic.FromContext(ctx)
+	if txn == nil {
+		if t.app == nil {
+			return next(ctx)
+		}
+		txn = t.app.StartTransaction(name)
+		defer txn.End()
+		ctx = newrelic.NewContext(ctx, txn)
+	} else {
+		txn.SetName(name)
+	}
+
+	txn.AddAttribute("graphql.operation.name", gqlctx.OperationNameOrNameless(oc))
+	txn.AddAttribute("graphql.operation.type", operationType(oc))
+
+	res := next(ctx)
+	if res != nil {
+		for _, err := range res.Errors {
+			txn.NoticeError(err)
+		}
+	}
+
+	return res
+}
+
+func (t Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	txn := newrelic.FromContext(ctx)
+	if txn == nil {
+		return next(ctx)
+	}
+
+	// gqlgen resolves fields concurrently, segments have to be started on a
+	// goroutine specific transaction.
+	txn = txn.NewGoroutine()
+	ctx = newrelic.NewContext(ctx, txn)
+
+	fc := graphql.GetFieldContext(ctx)
+	segment := txn.StartSegment("GraphQL/resolve/" + fc.Object + "." + fc.Field.Name)
+	defer segment.End()
+	segment.AddAttribute("graphql.field.path", fc.Path().String())
+
+	return next(ctx)
+}
+
+// transactionName names transactions after the operation instead of the
+// shared HTTP endpoint, e.g. "GraphQL/query/ListTodos".
+func transactionName(oc *graphql.OperationContext) string {
+	return "GraphQL/" + operationType(oc) + "/" + gqlctx.OperationNameOrNameless(oc)
+}
+
+func operationType(oc *graphql.OperationContext) string {
+	if oc.Operation == nil {
+		return "operation"
+	}
+	return string(oc.Operation.Operation)
+}
@@ -0,0 +1,79 @@
+package newrelic_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	gqlnewrelic "github.com/99designs/gqlgen-contrib/newrelic"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer(t *testing.T) {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("gqlgen-contrib"),
+		newrelic.ConfigEnabled(false),
+	)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var names []string
+	recordTransaction := handler.FieldFunc(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		txn := gqlnewrelic.Transaction(ctx)
+		require.NotNil(t, txn)
+
+		mu.Lock()
+		names = append(names, txn.Name())
+		mu.Unlock()
+
+		return next(ctx)
+	})
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(gqlnewrelic.New(app))
+	srv.Use(recordTransaction)
+
+	t.Run("starts a transaction", func(t *testing.T) {
+		names = nil
+
+		resp := doRequest(context.Background(), srv, `{"query":"query ListTodos { todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		require.NotEmpty(t, names)
+		for _, name := range names {
+			assert.Equal(t, "GraphQL/query/ListTodos", name)
+		}
+	})
+
+	t.Run("renames the request transaction", func(t *testing.T) {
+		names = nil
+
+		txn := app.StartTransaction("POST /query")
+		defer txn.End()
+
+		resp := doRequest(newrelic.NewContext(context.Background(), txn), srv, `{"query":"{ todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Equal(t, "GraphQL/query/nameless-operation", txn.Name())
+	})
+}
+
+func doRequest(ctx context.Context, handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,254 @@
+// Package normalize canonicalizes GraphQL operations, so that operations
+// differing only in formatting, field order, unused fragments or inline
+// values share one signature. It is the normalization behind the operation
+// signatures of prometheus and otel, the keys of responsecache and the
+// normalized hashes of the persisted query allowlist.
+//
+// The canonical form of an operation is printed on a single line with
+// minimal whitespace. It holds the operation followed by the fragments it
+// uses, sorted by name. In selection sets, duplicate selections are removed
+// and, unless KeepOrder is given, the rest are sorted. Arguments and input
+// object fields are sorted by name, as are variable definitions, and
+// aliases equal to the field name are dropped, or all of them with
+// DropAliases. Unless KeepLiterals is given, literals are replaced by the
+// zero value of their kind: strings by "", numbers by 0, lists by [] and
+// input objects by {}. Booleans, enum values and null are kept.
+package normalize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Document returns the canonical form of the operation operationName of
+// doc. operationName may be empty when doc holds a single operation.
+func Document(doc *ast.QueryDocument, operationName string, opts ...Option) (string, error) {
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		if operationName == "" {
+			return "", fmt.Errorf("normalize: operation name is required with %d operations", len(doc.Operations))
+		}
+		return "", fmt.Errorf("normalize: no operation named %q", operationName)
+	}
+
+	p := &printer{
+		cfg:       newConfig(opts...),
+		doc:       doc,
+		fragments: map[string]bool{},
+	}
+
+	return p.document(op), nil
+}
+
+// Query parses query, without a schema, and returns the canonical form of
+// its operation operationName.
+func Query(query, operationName string, opts ...Option) (string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return "", fmt.Errorf("normalize: %w", err)
+	}
+	return Document(doc, operationName, opts...)
+}
+
+// Hash returns the hex encoded SHA-256 hash of a canonical form, the
+// signature of the operation.
+func Hash(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+type printer struct {
+	cfg *config
+	doc *ast.QueryDocument
+	// fragments holds the names of the fragments spread so far.
+	fragments map[string]bool
+}
+
+func (p *printer) document(op *ast.OperationDefinition) string {
+	parts := []string{p.operation(op)}
+
+	// Spreads are collected while printing, including those of the fragments
+	// printed here, until no new fragment shows up.
+	printed := map[string]bool{}
+	for len(printed) < len(p.fragments) {
+		names := make([]string, 0, len(p.fragments))
+		for name := range p.fragments {
+			if !printed[name] {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			printed[name] = true
+			if def := p.doc.Fragments.ForName(name); def != nil {
+				parts = append(parts, p.fragment(def))
+			}
+		}
+	}
+	slices.Sort(parts[1:])
+
+	return strings.Join(parts, " ")
+}
+
+func (p *printer) operation(op *ast.OperationDefinition) string {
+	var b strings.Builder
+	b.WriteString(string(op.Operation))
+	if op.Name != "" {
+		b.WriteString(" " + op.Name)
+	}
+	if len(op.VariableDefinitions) != 0 {
+		vars := make([]string, len(op.VariableDefinitions))
+		for i, v := range op.VariableDefinitions {
+			vars[i] = p.variable(v)
+		}
+		slices.Sort(vars)
+		b.WriteString("(" + strings.Join(vars, ", ") + ")")
+	}
+	b.WriteString(p.directives(op.Directives))
+	b.WriteString(" " + p.selectionSet(op.SelectionSet))
+
+	return b.String()
+}
+
+func (p *printer) variable(v *ast.VariableDefinition) string {
+	s := "$" + v.Variable + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + p.value(v.DefaultValue)
+	}
+	return s + p.directives(v.Directives)
+}
+
+func (p *printer) fragment(def *ast.FragmentDefinition) string {
+	return "fragment " + def.Name + " on " + def.TypeCondition + p.directives(def.Directives) + " " + p.selectionSet(def.SelectionSet)
+}
+
+func (p *printer) selectionSet(set ast.SelectionSet) string {
+	selections := make([]string, 0, len(set))
+	seen := map[string]bool{}
+	for _, sel := range set {
+		s := p.selection(sel)
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		selections = append(selections, s)
+	}
+	if !p.cfg.keepOrder {
+		slices.Sort(selections)
+	}
+
+	return "{ " + strings.Join(selections, " ") + " }"
+}
+
+func (p *printer) selection(sel ast.Selection) string {
+	switch sel := sel.(type) {
+	case *ast.Field:
+		var b strings.Builder
+		if sel.Alias != "" && sel.Alias != sel.Name && !p.cfg.dropAliases {
+			b.WriteString(sel.Alias + ": ")
+		}
+		b.WriteString(sel.Name)
+		b.WriteString(p.arguments(sel.Arguments))
+		b.WriteString(p.directives(sel.Directives))
+		if len(sel.SelectionSet) != 0 {
+			b.WriteString(" " + p.selectionSet(sel.SelectionSet))
+		}
+		return b.String()
+	case *ast.FragmentSpread:
+		p.fragments[sel.Name] = true
+		return "..." + sel.Name + p.directives(sel.Directives)
+	case *ast.InlineFragment:
+		s := "..."
+		if sel.TypeCondition != "" {
+			s += " on " + sel.TypeCondition
+		}
+		return s + p.directives(sel.Directives) + " " + p.selectionSet(sel.SelectionSet)
+	default:
+		panic(fmt.Errorf("normalize: unknown selection %T", sel))
+	}
+}
+
+func (p *printer) arguments(args ast.ArgumentList) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	list := make([]string, len(args))
+	for i, arg := range args {
+		list[i] = arg.Name + ": " + p.value(arg.Value)
+	}
+	slices.Sort(list)
+
+	return "(" + strings.Join(list, ", ") + ")"
+}
+
+// directives are printed in document order, as directives may be
+// repeatable and order dependent.
+func (p *printer) directives(directives ast.DirectiveList) string {
+	var b strings.Builder
+	for _, d := range directives {
+		b.WriteString(" @" + d.Name + p.arguments(d.Arguments))
+	}
+	return b.String()
+}
+
+func (p *printer) value(v *ast.Value) string {
+	switch v.Kind {
+	case ast.Variable:
+		return "$" + v.Raw
+	case ast.BooleanValue, ast.EnumValue, ast.NullValue:
+		return v.Raw
+	case ast.IntValue, ast.FloatValue:
+		if !p.cfg.keepLiterals {
+			return "0"
+		}
+		return v.Raw
+	case ast.StringValue, ast.BlockValue:
+		if !p.cfg.keepLiterals {
+			return `""`
+		}
+		return strconv.Quote(v.Raw)
+	case ast.ListValue:
+		if !p.cfg.keepLiterals && !hasVariable(v) {
+			return "[]"
+		}
+		items := make([]string, len(v.Children))
+		for i, child := range v.Children {
+			items[i] = p.value(child.Value)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ast.ObjectValue:
+		if !p.cfg.keepLiterals && !hasVariable(v) {
+			return "{}"
+		}
+		fields := make([]string, len(v.Children))
+		for i, child := range v.Children {
+			fields[i] = child.Name + ": " + p.value(child.Value)
+		}
+		slices.Sort(fields)
+		return "{" + strings.Join(fields, ", ") + "}"
+	default:
+		panic(fmt.Errorf("normalize: unknown value kind %d", v.Kind))
+	}
+}
+
+// hasVariable reports whether a list or object value refers to a variable,
+// in which case it is printed with its literals stripped rather than
+// replaced as a whole, to keep the variable usage in the signature.
+func hasVariable(v *ast.Value) bool {
+	if v.Kind == ast.Variable {
+		return true
+	}
+	for _, child := range v.Children {
+		if hasVariable(child.Value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package normalize_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func TestQuery(t *testing.T) {
+	specs := []struct {
+		SpecName string
+		Query    string
+		Options  []normalize.Option
+		Expected string
+	}{
+		{
+			SpecName: "sorts fields and arguments",
+			Query:    "query Q {\n  todos(status: OPEN) { text id user { name id } }\n  todo(id: \"1\") { id }\n}",
+			Expected: `query Q { todo(id: "") { id } todos(status: OPEN) { id text user { id name } } }`,
+		},
+		{
+			SpecName: "keeps order",
+			Query:    "{ todos { text id } }",
+			Options:  []normalize.Option{normalize.KeepOrder()},
+			Expected: `query { todos { text id } }`,
+		},
+		{
+			SpecName: "strips literals",
+			Query:    `{ a(s: "x", i: 12, f: 1.5, b: true, e: OPEN, n: null, l: [1, 2], o: {z: 1, a: "b"}) }`,
+			Expected: `query { a(b: true, e: OPEN, f: 0, i: 0, l: [], n: null, o: {}, s: "") }`,
+		},
+		{
+			SpecName: "keeps literals",
+			Query:    `{ a(s: "x", l: [1, 2], o: {z: 1, a: "b"}) }`,
+			Options:  []normalize.Option{normalize.KeepLiterals()},
+			Expected: `query { a(l: [1, 2], o: {a: "b", z: 1}, s: "x") }`,
+		},
+		{
+			SpecName: "keeps variables in values",
+			Query:    `query($id: ID!, $first: Int = 10) { a(o: {id: $id, n: 3}, first: $first) }`,
+			Expected: `query($first: Int = 0, $id: ID!) { a(first: $first, o: {id: $id, n: 0}) }`,
+		},
+		{
+			SpecName: "aliases",
+			Query:    `{ todos: todos { id } open: todos(status: OPEN) { id: id } }`,
+			Expected: `query { open: todos(status: OPEN) { id } todos { id } }`,
+		},
+		{
+			SpecName: "drops aliases",
+			Query:    `{ todos: todos { id } open: todos(status: OPEN) { id: id } }`,
+			Options:  []normalize.Option{normalize.DropAliases()},
+			Expected: `query { todos { id } todos(status: OPEN) { id } }`,
+		},
+		{
+			SpecName: "removes duplicates",
+			Query:    `{ todos { id id text } }`,
+			Expected: `query { todos { id text } }`,
+		},
+		{
+			SpecName: "fragments",
+			Query:    `fragment Unused on Todo { id } fragment U on User { id } fragment T on Todo { text user { ...U } } { todos { ...T ... on Todo @include(if: true) { done } } }`,
+			Expected: `query { todos { ... on Todo @include(if: true) { done } ...T } } fragment T on Todo { text user { ...U } } fragment U on User { id }`,
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			normalized, err := normalize.Query(spec.Query, "", spec.Options...)
+			require.NoError(t, err)
+			assert.Equal(t, spec.Expected, normalized)
+		})
+	}
+}
+
+func TestQuery_OperationName(t *testing.T) {
+	query := `query A { todos { id } } query B { todo(id: "1") { id } }`
+
+	normalized, err := normalize.Query(query, "B")
+	require.NoError(t, err)
+	assert.Equal(t, `query B { todo(id: "") { id } }`, normalized)
+
+	_, err = normalize.Query(query, "")
+	assert.Error(t, err)
+	_, err = normalize.Query(query, "C")
+	assert.Error(t, err)
+	_, err = normalize.Query("{ todos {", "")
+	assert.Error(t, err)
+}
+
+func TestHash(t *testing.T) {
+	a, err := normalize.Query("{ todos { id text } }", "")
+	require.NoError(t, err)
+	b, err := normalize.Query("query {\n  todos {\n    text\n    id\n  }\n}", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, normalize.Hash(a), normalize.Hash(b))
+	assert.Len(t, normalize.Hash(a), 64)
+}
+
+func TestTable(t *testing.T) {
+	table := normalize.NewTable(2)
+
+	var signatures []string
+	for _, query := range []string{"{ todos { id } }", "{ todos { text } }", "{ todos { id } }", "{ todos { done } }"} {
+		doc, err := parser.ParseQuery(&ast.Source{Input: query})
+		require.NoError(t, err)
+		signature, err := table.Signature(doc, "")
+		require.NoError(t, err)
+		assert.Len(t, signature, normalize.SignatureLength)
+		signatures = append(signatures, signature)
+	}
+	assert.Equal(t, signatures[0], signatures[2])
+
+	// { todos { text } } was the least recently seen.
+	assert.Equal(t, map[string]string{
+		signatures[0]: "query { todos { id } }",
+		signatures[3]: "query { todos { done } }",
+	}, table.Entries())
+
+	w := httptest.NewRecorder()
+	table.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, normalize.DebugPath+"?signature="+signatures[3], nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"`+signatures[3]+`":"query { todos { done } }"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	table.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, normalize.DebugPath+"?signature="+signatures[1], nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var nilTable *normalize.Table
+	doc, err := parser.ParseQuery(&ast.Source{Input: "{ todos { id } }"})
+	require.NoError(t, err)
+	signature, err := nilTable.Signature(doc, "")
+	require.NoError(t, err)
+	assert.Equal(t, signatures[0], signature)
+}
@@ -0,0 +1,45 @@
+package normalize
+
+type config struct {
+	keepLiterals bool
+	keepOrder    bool
+	dropAliases  bool
+}
+
+// Option is anything that can configure a normalization.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// KeepLiterals keeps inline argument values, for uses where they are part
+// of the identity of an operation such as cache keys and allowlists.
+func KeepLiterals() Option {
+	return func(cfg *config) {
+		cfg.keepLiterals = true
+	}
+}
+
+// KeepOrder keeps the fields of selection sets in document order. Field
+// order decides the order of the response, so normalizations that stand in
+// for a response, such as cache keys, must keep it.
+func KeepOrder() Option {
+	return func(cfg *config) {
+		cfg.keepOrder = true
+	}
+}
+
+// DropAliases prints fields without their alias, as Apollo does in the
+// usage reporting signature of operations.
+func DropAliases() Option {
+	return func(cfg *config) {
+		cfg.dropAliases = true
+	}
+}
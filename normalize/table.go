@@ -0,0 +1,129 @@
+package normalize
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SignatureLength is the number of hex digits of a signature, short enough
+// for a metric label and long enough to tell operations apart.
+const SignatureLength = 12
+
+// DebugPath is the conventional path to serve Table.Handler on.
+const DebugPath = "/debug/graphql/signatures"
+
+// Signature returns the signature of the operation operationName of doc: the
+// first SignatureLength hex digits of the Hash of its canonical form.
+func Signature(doc *ast.QueryDocument, operationName string) (string, error) {
+	normalized, err := Document(doc, operationName)
+	if err != nil {
+		return "", err
+	}
+	return Hash(normalized)[:SignatureLength], nil
+}
+
+// Table maps signatures back to the canonical form of their operation, so
+// that a signature seen on a dashboard can be looked up. It holds a fixed
+// number of entries, evicting the least recently seen one first.
+type Table struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type tableEntry struct {
+	signature  string
+	normalized string
+}
+
+// NewTable returns a Table holding at most size signatures.
+func NewTable(size int) *Table {
+	return &Table{
+		size:    size,
+		ll:      list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// Signature is like the package level Signature, and records the operation
+// in t. A nil Table records nothing.
+func (t *Table) Signature(doc *ast.QueryDocument, operationName string) (string, error) {
+	normalized, err := Document(doc, operationName)
+	if err != nil {
+		return "", err
+	}
+	signature := Hash(normalized)[:SignatureLength]
+	if t != nil {
+		t.add(signature, normalized)
+	}
+	return signature, nil
+}
+
+func (t *Table) add(signature, normalized string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.entries[signature]; ok {
+		t.ll.MoveToFront(el)
+		return
+	}
+
+	t.entries[signature] = t.ll.PushFront(&tableEntry{signature: signature, normalized: normalized})
+	for t.ll.Len() > t.size {
+		el := t.ll.Back()
+		t.ll.Remove(el)
+		delete(t.entries, el.Value.(*tableEntry).signature)
+	}
+}
+
+// Lookup returns the canonical form of the operation with the given
+// signature.
+func (t *Table) Lookup(signature string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	el, ok := t.entries[signature]
+	if !ok {
+		return "", false
+	}
+	return el.Value.(*tableEntry).normalized, true
+}
+
+// Entries returns a copy of the table, keyed by signature.
+func (t *Table) Entries() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make(map[string]string, len(t.entries))
+	for signature, el := range t.entries {
+		entries[signature] = el.Value.(*tableEntry).normalized
+	}
+	return entries
+}
+
+// Handler serves the table as a JSON object keyed by signature, or only the
+// entry of the signature query parameter when given. It is meant for an
+// internal port only, as operations are shown with their structure.
+func (t *Table) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries map[string]string
+		if signature := r.URL.Query().Get("signature"); signature != "" {
+			normalized, ok := t.Lookup(signature)
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			entries = map[string]string{signature: normalized}
+		} else {
+			entries = t.Entries()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
@@ -0,0 +1,62 @@
+package nplusone
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/internal/sqldriver"
+)
+
+// Transport returns a RoundTripper recording the requests sent with base, or
+// http.DefaultTransport if nil, keyed by method, host and path. Path
+// segments looking like identifiers are replaced by {id}, so requests for
+// each item share a key.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	segments := strings.Split(req.URL.Path, "/")
+	for i, s := range segments {
+		if identifier(s) {
+			segments[i] = "{id}"
+		}
+	}
+	Record(req.Context(), req.Method+" "+req.URL.Host+strings.Join(segments, "/"))
+	return t.base.RoundTrip(req)
+}
+
+// identifier reports whether s is a number, or a long string of hex digits
+// and dashes such as a UUID.
+func identifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	digits, hex := true, true
+	for _, c := range s {
+		digits = digits && '0' <= c && c <= '9'
+		hex = hex && ('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' || c == '-')
+	}
+	return digits || hex && len(s) >= 16
+}
+
+// Connector returns a connector whose connections record the queries and
+// statements executed with c, keyed by query. Queries should use
+// parameters, as literals make the queries of each item differ.
+func Connector(c driver.Connector) driver.Connector {
+	return sqldriver.Wrap(c, sqldriver.Interceptor{
+		Around: func(ctx context.Context, operation, query string, fn func(ctx context.Context) error) error {
+			Record(ctx, query)
+			return fn(ctx)
+		},
+	})
+}
@@ -0,0 +1,273 @@
+// Package nplusone detects the fields of list items making the same
+// downstream call for each item, the N+1 calls a dataloader would batch.
+//
+// Calls are recorded with Record, or by the RoundTripper of Transport and
+// the database connections of Connector. Offenders are logged, counted in
+// graphql_nplusone_detected_total{path} and served by Handler.
+package nplusone
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/incremental"
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// DebugPath is the conventional path to serve Handler on.
+const DebugPath = "/debug/graphql/nplusone"
+
+// Offender is a field found making the same call from items of a list.
+type Offender struct {
+	Operation string `json:"operation"`
+	// Path is the path of the field without list indexes, made of field
+	// names rather than aliases, such as todos.user.
+	Path string `json:"path"`
+	Key  string `json:"key"`
+	// Calls is the number of calls of the last operation it was found in.
+	Calls int `json:"calls"`
+	// Operations is the number of operations it was found in.
+	Operations int       `json:"operations"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// Detector is a gqlgen handler extension tracking the calls recorded in each
+// query and mutation, and reporting the repeated ones when the operation
+// completes. Subscriptions are not tracked.
+type Detector struct {
+	cfg     *config
+	counter *prometheusclient.CounterVec
+
+	mu        sync.Mutex
+	offenders map[offenderKey]*Offender
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+} = &Detector{}
+
+type offenderKey struct {
+	operation, path, key string
+}
+
+// New returns a Detector whose counter is registered on the configured
+// registerer.
+func New(opts ...Option) *Detector {
+	cfg := newConfig(opts...)
+
+	d := &Detector{
+		cfg: cfg,
+		counter: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_nplusone_detected_total",
+			Help:        "Total number of operations in which a field repeated a call for items of a list.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"path"}),
+		offenders: map[offenderKey]*Offender{},
+	}
+	cfg.registerer.MustRegister(d.counter)
+
+	return d
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (d *Detector) UnRegister() {
+	d.cfg.registerer.Unregister(d.counter)
+}
+
+func (d *Detector) ExtensionName() string {
+	return "NPlusOne"
+}
+
+func (d *Detector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+type (
+	trackerKey struct{}
+	streamKey  struct{}
+)
+
+// tracker counts the calls of an operation by field path and key.
+type tracker struct {
+	threshold int
+
+	mu    sync.Mutex
+	calls map[callKey]*calls
+}
+
+type callKey struct {
+	path, key string
+}
+
+// calls records the distinct paths a call was made from, up to the
+// threshold.
+type calls struct {
+	count int
+	paths map[string]struct{}
+}
+
+func (d *Detector) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+	ctx = context.WithValue(ctx, trackerKey{}, &tracker{threshold: d.cfg.threshold, calls: map[callKey]*calls{}})
+	return next(context.WithValue(ctx, streamKey{}, incremental.New(oc.Stats.OperationStart)))
+}
+
+func (d *Detector) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	stream, ok := ctx.Value(streamKey{}).(*incremental.Stream)
+	if res == nil || !ok {
+		return res
+	}
+
+	switch first, last := stream.Add(res); {
+	case first && last:
+		d.report(ctx)
+	case first:
+		stream.AfterFunc(ctx, func() {
+			d.report(ctx)
+		})
+	case last && stream.Finish():
+		d.report(ctx)
+	}
+
+	return res
+}
+
+// Record records a call identified by key, such as a normalized SQL query,
+// made by the field resolved in ctx. It does nothing outside of the
+// operations of a Detector.
+func Record(ctx context.Context, key string) {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil {
+		return
+	}
+
+	k := callKey{path: pattern(fc), key: key}
+	path := fc.Path().String()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.calls[k]
+	if !ok {
+		c = &calls{paths: map[string]struct{}{}}
+		t.calls[k] = c
+	}
+	c.count++
+	if len(c.paths) < t.threshold {
+		c.paths[path] = struct{}{}
+	}
+}
+
+// pattern returns the path of fc without list indexes. Field names are used
+// rather than aliases, which clients choose, for the paths to be bounded by
+// the schema.
+func pattern(fc *graphql.FieldContext) string {
+	var names []string
+	for it := fc; it != nil; it = it.Parent {
+		if it.Index == nil && it.Field.Field != nil {
+			names = append(names, it.Field.Name)
+		}
+	}
+	slices.Reverse(names)
+	return strings.Join(names, ".")
+}
+
+// report reports the repeated calls of the operation of ctx.
+func (d *Detector) report(ctx context.Context) {
+	t := ctx.Value(trackerKey{}).(*tracker)
+	operation := gqlctx.OperationName(graphql.GetOperationContext(ctx))
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, c := range t.calls {
+		if len(c.paths) < d.cfg.threshold {
+			continue
+		}
+		d.counter.WithLabelValues(k.path).Inc()
+		if d.cfg.logger != nil {
+			d.cfg.logger.WarnContext(ctx, "repeated calls for items of a list",
+				slog.String("operation", operation),
+				slog.String("path", k.path),
+				slog.String("key", k.key),
+				slog.Int("calls", c.count),
+			)
+		}
+		d.add(offenderKey{operation, k.path, k.key}, c.count, now)
+	}
+}
+
+func (d *Detector) add(k offenderKey, calls int, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o, ok := d.offenders[k]
+	if !ok {
+		if d.cfg.size <= 0 {
+			return
+		}
+		if len(d.offenders) >= d.cfg.size {
+			var oldest *Offender
+			for _, o := range d.offenders {
+				if oldest == nil || o.LastSeen.Before(oldest.LastSeen) {
+					oldest = o
+				}
+			}
+			delete(d.offenders, offenderKey{oldest.Operation, oldest.Path, oldest.Key})
+		}
+		o = &Offender{Operation: k.operation, Path: k.path, Key: k.key}
+		d.offenders[k] = o
+	}
+	o.Calls = calls
+	o.Operations++
+	o.LastSeen = now
+}
+
+// Offenders returns the offenders found so far, most frequent first.
+func (d *Detector) Offenders() []Offender {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	offenders := make([]Offender, 0, len(d.offenders))
+	for _, o := range d.offenders {
+		offenders = append(offenders, *o)
+	}
+	slices.SortFunc(offenders, func(a, b Offender) int {
+		if a.Operations != b.Operations {
+			return b.Operations - a.Operations
+		}
+		return strings.Compare(a.Path+a.Key, b.Path+b.Key)
+	})
+	return offenders
+}
+
+// Handler serves the offenders as JSON. Like the other debug endpoints, it
+// is meant for an internal port only, as keys may show queries verbatim.
+func (d *Detector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Offenders []Offender `json:"offenders"`
+		}{d.Offenders()})
+	})
+}
@@ -0,0 +1,184 @@
+package nplusone_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/nplusone"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetector(t *testing.T) {
+	var logs syncBuffer
+	registry := prometheusclient.NewRegistry()
+	d := nplusone.New(
+		nplusone.WithRegisterer(registry),
+		nplusone.WithThreshold(3),
+		nplusone.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+	)
+	defer d.UnRegister()
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer downstream.Close()
+	client := &http.Client{Transport: nplusone.Transport(nil)}
+	db := sql.OpenDB(nplusone.Connector(connector{}))
+	defer db.Close()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(d)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		fc := graphql.GetFieldContext(ctx)
+		switch fc.Object + "." + fc.Field.Name {
+		case "Query.todos":
+			nplusone.Record(ctx, "todos")
+		case "Todo.completed":
+			id := "1"
+			if fc.Parent.Index != nil {
+				id = strconv.Itoa(*fc.Parent.Index + 1)
+			}
+			rows, err := db.QueryContext(ctx, "select done from todos where id = ?", id)
+			if err != nil {
+				return nil, err
+			}
+			rows.Close()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, downstream.URL+"/todos/"+id, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			resp.Body.Close()
+		}
+		return next(ctx)
+	})
+
+	for range 2 {
+		resp := doRequest(srv, `{"query":"query Board { todos { id items: completed } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "errors")
+	}
+	// Without the list, the call is not repeated.
+	resp := doRequest(srv, `{"query":"query One { todo(id: \"0be25fcf-20e6-4a6d-b0f9-7804224ef20e\") { completed } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	nplusone.Record(context.Background(), "outside")
+
+	host := strings.TrimPrefix(downstream.URL, "http://")
+	offenders := d.Offenders()
+	require.Len(t, offenders, 2)
+	assert.ElementsMatch(t, []string{"GET " + host + "/todos/{id}", "select done from todos where id = ?"}, []string{offenders[0].Key, offenders[1].Key})
+	for _, o := range offenders {
+		assert.Equal(t, "Board", o.Operation)
+		assert.Equal(t, "todos.completed", o.Path, "paths are made of field names")
+		assert.Equal(t, 3, o.Calls)
+		assert.Equal(t, 2, o.Operations)
+	}
+
+	metrics := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, metrics.Body.String(), `graphql_nplusone_detected_total{path="todos.completed"} 4`)
+	assert.Equal(t, 4, strings.Count(logs.String(), `"path":"todos.completed"`))
+
+	w := httptest.NewRecorder()
+	d.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, nplusone.DebugPath, nil))
+	var body struct {
+		Offenders []nplusone.Offender `json:"offenders"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Offenders, 2)
+}
+
+func TestDetector_SizeZero(t *testing.T) {
+	d := nplusone.New(
+		nplusone.WithRegisterer(prometheusclient.NewRegistry()),
+		nplusone.WithThreshold(0),
+		nplusone.WithSize(0),
+		nplusone.WithLogger(nil),
+	)
+	defer d.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(d)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		if graphql.GetFieldContext(ctx).Field.Name == "completed" {
+			nplusone.Record(ctx, "completed")
+		}
+		return next(ctx)
+	})
+
+	resp := doRequest(srv, `{"query":"{ todos { completed } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, d.Offenders())
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type connector struct{}
+
+func (connector) Connect(context.Context) (driver.Conn, error) { return conn{}, nil }
+func (connector) Driver() driver.Driver                        { return nil }
+
+// conn is a database connection answering no rows.
+type conn struct{}
+
+func (conn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (conn) Close() error                              { return nil }
+func (conn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return rows{}, nil
+}
+
+type rows struct{}
+
+func (rows) Columns() []string              { return []string{"done"} }
+func (rows) Close() error                   { return nil }
+func (rows) Next(dest []driver.Value) error { return io.EOF }
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
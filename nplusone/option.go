@@ -0,0 +1,91 @@
+package nplusone
+
+import (
+	"log/slog"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	threshold int
+	size      int
+	logger    *slog.Logger
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Detector.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		threshold:  5,
+		size:       100,
+		logger:     slog.Default(),
+		registerer: prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.threshold = max(cfg.threshold, 2)
+
+	return cfg
+}
+
+// WithThreshold sets from how many items of a list a field must make the
+// same call for it to be reported. It defaults to 5, and is at least 2.
+func WithThreshold(n int) Option {
+	return func(cfg *config) {
+		cfg.threshold = n
+	}
+}
+
+// WithSize sets how many offenders Offenders keeps, those seen least
+// recently being forgotten first. It defaults to 100, 0 keeping none.
+func WithSize(n int) Option {
+	return func(cfg *config) {
+		cfg.size = n
+	}
+}
+
+// WithLogger logs offenders to logger instead of slog.Default(), nil
+// disabling logging.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cfg *config) {
+		cfg.logger = logger
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,27 @@
+package otel_test
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/99designs/gqlgen-contrib/otel"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+var es graphql.ExecutableSchema
+
+func Example() {
+	// NOTE: requires setting of a TracerProvider
+	//   otel.SetTracerProvider(provider)
+
+	srv := handler.New(es)
+	srv.AddTransport(transport.POST{})
+	srv.Use(otel.New())
+	http.Handle("/query", srv)
+
+	if err := http.ListenAndServe(":8080", nil); err != nil {
+		log.Fatal(err)
+	}
+}
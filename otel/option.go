@@ -0,0 +1,80 @@
+package otel
+
+import (
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	enricher       contrib.Enricher
+	signature      bool
+	signatures     *normalize.Table
+	variables      bool
+	redactor       *redact.Redactor
+	fieldFilter    func(fc *graphql.FieldContext) bool
+	classifier     contrib.Classifier
+}
+
+// Option is anything that can configure Tracer.
+type Option func(cfg *config)
+
+// WithTracerProvider creates spans from provider instead of the global
+// TracerProvider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(cfg *config) {
+		cfg.tracerProvider = provider
+	}
+}
+
+// WithEnricher sets the labels returned by fn as attributes of the
+// operation spans. Calling it again adds more labels.
+func WithEnricher(fn contrib.Enricher) Option {
+	return func(cfg *config) {
+		if cfg.enricher == nil {
+			cfg.enricher = fn
+		} else {
+			cfg.enricher = contrib.Enrichers(cfg.enricher, fn)
+		}
+	}
+}
+
+// WithOperationSignature sets the normalize signature of the operation as
+// the graphql.operation.signature attribute of operation spans, recording
+// it in table, which may be nil.
+func WithOperationSignature(table *normalize.Table) Option {
+	return func(cfg *config) {
+		cfg.signature = true
+		cfg.signatures = table
+	}
+}
+
+// WithVariables sets the operation variables, redacted by r, as the JSON
+// encoded graphql.variables attribute of operation spans. r may be nil to
+// record variables unchanged.
+func WithVariables(r *redact.Redactor) Option {
+	return func(cfg *config) {
+		cfg.variables = true
+		cfg.redactor = r
+	}
+}
+
+// WithFieldFilter selects the fields resolver spans are created for. By
+// default only fields bound to a resolver or a method get one, struct field
+// lookups being too cheap and numerous to be worth a span.
+func WithFieldFilter(fn func(fc *graphql.FieldContext) bool) Option {
+	return func(cfg *config) {
+		cfg.fieldFilter = fn
+	}
+}
+
+// WithClassifier sets the classifier of the graphql.outcome attribute of
+// operation spans, contrib.Classify by default.
+func WithClassifier(fn contrib.Classifier) Option {
+	return func(cfg *config) {
+		cfg.classifier = fn
+	}
+}
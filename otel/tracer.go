@@ -0,0 +1,197 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the instrumentation library on the produced spans.
+const tracerName = "github.com/99designs/gqlgen-contrib/otel"
+
+// Tracer is a gqlgen handler extension creating OpenTelemetry spans for
+// operations and field resolvers. The zero value uses the global
+// TracerProvider.
+// see https://opentelemetry.io/docs/languages/go/
+type Tracer struct {
+	tracer      trace.Tracer
+	enricher    contrib.Enricher
+	signature   bool
+	signatures  *normalize.Table
+	variables   bool
+	redactor    *redact.Redactor
+	fieldFilter func(fc *graphql.FieldContext) bool
+	classifier  contrib.Classifier
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+// New returns a Tracer configured by opts.
+func New(opts ...Option) Tracer {
+	cfg := &config{}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	t := Tracer{
+		enricher:    cfg.enricher,
+		signature:   cfg.signature,
+		signatures:  cfg.signatures,
+		variables:   cfg.variables,
+		redactor:    cfg.redactor,
+		fieldFilter: cfg.fieldFilter,
+		classifier:  cfg.classifier,
+	}
+	if cfg.tracerProvider != nil {
+		t.tracer = cfg.tracerProvider.Tracer(tracerName)
+	}
+
+	return t
+}
+
+func (t Tracer) otelTracer() trace.Tracer {
+	if t.tracer != nil {
+		return t.tracer
+	}
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// traced reports whether fc gets a resolver span, see WithFieldFilter.
+func (t Tracer) traced(fc *graphql.FieldContext) bool {
+	if t.fieldFilter != nil {
+		return t.fieldFilter(fc)
+	}
+	return fc.IsResolver || fc.IsMethod
+}
+
+func (t Tracer) ExtensionName() string {
+	return "OpenTelemetry"
+}
+
+func (t Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name, operationType := gqlctx.OperationNameOrNameless(oc), operationType(oc)
+
+	ctx, span := t.otelTracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithTimestamp(oc.Stats.OperationStart),
+	)
+	defer span.End()
+
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("graphql.operation.name", name),
+			attribute.String("graphql.operation.type", operationType),
+			attribute.String("graphql.document", oc.RawQuery),
+		)
+		if t.signature && oc.Doc != nil {
+			if signature, err := t.signatures.Signature(oc.Doc, oc.OperationName); err == nil {
+				span.SetAttributes(attribute.String("graphql.operation.signature", signature))
+			}
+		}
+		if t.variables && len(oc.Variables) != 0 {
+			if variables, err := json.Marshal(t.redactor.Variables(oc)); err == nil {
+				span.SetAttributes(attribute.String("graphql.variables", string(variables)))
+			}
+		}
+		if client := clientinfo.ForContext(ctx); client.Name != "" {
+			span.SetAttributes(
+				attribute.String("graphql.client.name", client.Name),
+				attribute.String("graphql.client.version", client.Version),
+			)
+		}
+		if t.enricher != nil {
+			for key, value := range t.enricher(ctx) {
+				span.SetAttributes(attribute.String(key, value))
+			}
+		}
+		if stats := extension.GetComplexityStats(ctx); stats != nil {
+			span.SetAttributes(
+				attribute.Int("graphql.operation.complexity", stats.Complexity),
+				attribute.Int("graphql.operation.complexity_limit", stats.ComplexityLimit),
+			)
+		}
+	}
+
+	res := next(ctx)
+	if res == nil {
+		return res
+	}
+	if len(res.Errors) != 0 {
+		span.SetStatus(codes.Error, res.Errors.Error())
+		span.SetAttributes(attribute.Int("graphql.errors.count", len(res.Errors)))
+	}
+	if span.IsRecording() {
+		classify := t.classifier
+		if classify == nil {
+			classify = contrib.Classify
+		}
+		span.SetAttributes(attribute.String("graphql.outcome", string(classify(ctx, res.Errors))))
+	}
+
+	return res
+}
+
+func (t Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !t.traced(fc) {
+		return next(ctx)
+	}
+
+	ctx, span := t.otelTracer().Start(ctx, fc.Object+"."+fc.Field.Name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+	)
+	defer span.End()
+
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("graphql.resolver.object", fc.Object),
+			attribute.String("graphql.resolver.field", fc.Field.Name),
+			attribute.String("graphql.resolver.alias", fc.Field.Alias),
+			attribute.String("graphql.resolver.path", fc.Path().String()),
+		)
+	}
+
+	res, err := next(ctx)
+
+	errList := gqlctx.FieldErrors(ctx, fc, err)
+	if len(errList) != 0 {
+		span.SetStatus(codes.Error, errList[0].Error())
+		for _, err := range errList {
+			span.RecordError(err)
+		}
+	}
+
+	return res, err
+}
+
+func operationType(oc *graphql.OperationContext) string {
+	if oc.Operation == nil {
+		return ""
+	}
+	return string(oc.Operation.Operation)
+}
@@ -0,0 +1,131 @@
+package otel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen-contrib/otel"
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.FixedComplexityLimit(100))
+	table := normalize.NewTable(10)
+	srv.Use(otel.New(
+		otel.WithTracerProvider(provider),
+		otel.WithOperationSignature(table),
+		otel.WithVariables(redact.New(nil, redact.Path("id"))),
+		otel.WithEnricher(func(ctx context.Context) map[string]string {
+			return map[string]string{"tenant": "acme"}
+		}),
+	))
+
+	resp := doRequest(srv, `{"query":"query Lookup($id: ID!) { todos { id } todo(id: $id) { id } }","variables":{"id":"unknown"}}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	operation := spans["Lookup"]
+	require.NotNil(t, operation)
+	assert.Equal(t, trace.SpanKindServer, operation.SpanKind())
+	assert.Equal(t, codes.Error, operation.Status().Code)
+	assert.Subset(t, operation.Attributes(), []attribute.KeyValue{
+		attribute.String("graphql.operation.name", "Lookup"),
+		attribute.String("graphql.operation.type", "query"),
+		attribute.Int("graphql.operation.complexity", 4),
+		attribute.Int("graphql.operation.complexity_limit", 100),
+		attribute.Int("graphql.errors.count", 1),
+		attribute.String("graphql.outcome", "internal_error"),
+		attribute.String("tenant", "acme"),
+		attribute.String("graphql.variables", `{"id":"[REDACTED]"}`),
+	})
+
+	var signature string
+	for _, attr := range operation.Attributes() {
+		if attr.Key == "graphql.operation.signature" {
+			signature = attr.Value.AsString()
+		}
+	}
+	normalized, ok := table.Lookup(signature)
+	require.True(t, ok)
+	assert.Equal(t, `query Lookup($id: ID!) { todo(id: $id) { id } todos { id } }`, normalized)
+
+	todos := spans["Query.todos"]
+	require.NotNil(t, todos)
+	assert.Equal(t, operation.SpanContext().SpanID(), todos.Parent().SpanID())
+	assert.Equal(t, codes.Unset, todos.Status().Code)
+	assert.Subset(t, todos.Attributes(), []attribute.KeyValue{
+		attribute.String("graphql.resolver.object", "Query"),
+		attribute.String("graphql.resolver.field", "todos"),
+		attribute.String("graphql.resolver.path", "todos"),
+	})
+
+	todo := spans["Query.todo"]
+	require.NotNil(t, todo)
+	assert.Equal(t, codes.Error, todo.Status().Code)
+	assert.Equal(t, graph.ErrTodoNotFound.Error(), todo.Status().Description)
+	require.Len(t, todo.Events(), 1)
+	assert.Equal(t, "exception", todo.Events()[0].Name)
+
+	assert.NotContains(t, spans, "Todo.id")
+}
+
+func TestTracer_FieldFilter(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(otel.New(
+		otel.WithTracerProvider(provider),
+		otel.WithFieldFilter(func(fc *graphql.FieldContext) bool {
+			return fc.Object == "Todo"
+		}),
+	))
+
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	spans := map[string]int{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()]++
+	}
+	assert.NotContains(t, spans, "Query.todos")
+	assert.NotZero(t, spans["Todo.id"])
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
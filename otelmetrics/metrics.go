@@ -0,0 +1,200 @@
+package otelmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/labelguard"
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName identifies the instrumentation library on the produced metrics.
+const meterName = "github.com/99designs/gqlgen-contrib/otelmetrics"
+
+const (
+	existStatusFailure = "failure"
+	exitStatusSuccess  = "success"
+)
+
+// Tracer is a gqlgen handler extension recording request and resolver
+// metrics through the OpenTelemetry Meter API. It records the same set as
+// the prometheus package.
+type Tracer struct {
+	requestStartedCounter    metric.Int64Counter
+	requestCompletedCounter  metric.Int64Counter
+	resolverStartedCounter   metric.Int64Counter
+	resolverCompletedCounter metric.Int64Counter
+	timeToResolveField       metric.Float64Histogram
+	timeToHandleRequest      metric.Float64Histogram
+
+	fieldFilter    func(fc *graphql.FieldContext) bool
+	operationNames *labelguard.Guard
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = (*Tracer)(nil)
+
+// New creates the instruments of a Tracer from the configured MeterProvider.
+func New(opts ...Option) (*Tracer, error) {
+	cfg := newConfig(opts...)
+	if cfg.meterProvider == nil {
+		cfg.meterProvider = otel.GetMeterProvider()
+	}
+	meter := cfg.meterProvider.Meter(meterName)
+
+	var err error
+	t := &Tracer{
+		fieldFilter:    cfg.fieldFilter,
+		operationNames: labelguard.New(nil, cfg.maxOperationNames),
+	}
+
+	t.requestStartedCounter, err = meter.Int64Counter("graphql.request.started",
+		metric.WithDescription("Total number of requests started on the graphql server."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.requestCompletedCounter, err = meter.Int64Counter("graphql.request.completed",
+		metric.WithDescription("Total number of requests completed on the graphql server."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.resolverStartedCounter, err = meter.Int64Counter("graphql.resolver.started",
+		metric.WithDescription("Total number of resolver started on the graphql server."),
+		metric.WithUnit("{resolver}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.resolverCompletedCounter, err = meter.Int64Counter("graphql.resolver.completed",
+		metric.WithDescription("Total number of resolver completed on the graphql server."),
+		metric.WithUnit("{resolver}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.timeToResolveField, err = meter.Float64Histogram("graphql.resolver.duration",
+		metric.WithDescription("The time taken to resolve a field by graphql server."),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.timeToHandleRequest, err = meter.Float64Histogram("graphql.request.duration",
+		metric.WithDescription("The time taken to handle a request by graphql server."),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Tracer) ExtensionName() string {
+	return "OpenTelemetryMetrics"
+}
+
+func (t *Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t *Tracer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	t.requestStartedCounter.Add(ctx, 1, metric.WithAttributes(t.operationAttributes(ctx)...))
+	return next(ctx)
+}
+
+func (t *Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	// Transports dispatch undecodable requests without an operation context.
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	var exitStatus string
+	if len(res.Errors) > 0 {
+		exitStatus = existStatusFailure
+	} else {
+		exitStatus = exitStatusSuccess
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	attrs := t.operationAttributes(ctx)
+
+	t.timeToHandleRequest.Record(ctx, milliseconds(time.Since(oc.Stats.OperationStart)),
+		metric.WithAttributes(append(attrs, attribute.String("graphql.exit_status", exitStatus))...))
+
+	t.requestCompletedCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
+
+	return res
+}
+
+func (t *Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !t.fieldFilter(fc) {
+		return next(ctx)
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("graphql.resolver.object", fc.Object),
+		attribute.String("graphql.resolver.field", fc.Field.Name),
+	}
+
+	t.resolverStartedCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
+
+	observerStart := time.Now()
+
+	res, err := next(ctx)
+
+	var exitStatus string
+	if err != nil {
+		exitStatus = existStatusFailure
+	} else {
+		exitStatus = exitStatusSuccess
+	}
+
+	t.timeToResolveField.Record(ctx, milliseconds(time.Since(observerStart)),
+		metric.WithAttributes(append(attrs, attribute.String("graphql.exit_status", exitStatus))...))
+
+	t.resolverCompletedCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
+
+	return res, err
+}
+
+// operationAttributes describes the operation in ctx.
+func (t *Tracer) operationAttributes(ctx context.Context) []attribute.KeyValue {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := gqlctx.OperationName(oc)
+	var operationType string
+	if oc.Operation != nil {
+		operationType = string(oc.Operation.Operation)
+	}
+
+	return []attribute.KeyValue{
+		attribute.String("graphql.operation.name", t.operationNames.Value(name)),
+		attribute.String("graphql.operation.type", operationType),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,163 @@
+package otelmetrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/otelmetrics"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestTracer(t *testing.T) {
+	srv, reader := newServer(t)
+
+	for i := 0; i < 3; i++ {
+		resp := doRequest(srv, `{"query":"query Lookup { todos { id } todo(id: \"unknown\") { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	metrics := collect(t, reader)
+
+	operation := attribute.NewSet(
+		attribute.String("graphql.operation.name", "Lookup"),
+		attribute.String("graphql.operation.type", "query"),
+	)
+	assert.Equal(t, int64(3), sumFor(t, metrics["graphql.request.started"], operation))
+	assert.Equal(t, int64(3), sumFor(t, metrics["graphql.request.completed"], operation))
+
+	todos := attribute.NewSet(
+		attribute.String("graphql.resolver.object", "Query"),
+		attribute.String("graphql.resolver.field", "todos"),
+	)
+	assert.Equal(t, int64(3), sumFor(t, metrics["graphql.resolver.started"], todos))
+	assert.Equal(t, int64(3), sumFor(t, metrics["graphql.resolver.completed"], todos))
+
+	failedLookup := attribute.NewSet(
+		attribute.String("graphql.resolver.object", "Query"),
+		attribute.String("graphql.resolver.field", "todo"),
+		attribute.String("graphql.exit_status", "failure"),
+	)
+	assert.Equal(t, uint64(3), countFor(t, metrics["graphql.resolver.duration"], failedLookup))
+
+	failedRequest := attribute.NewSet(
+		attribute.String("graphql.operation.name", "Lookup"),
+		attribute.String("graphql.operation.type", "query"),
+		attribute.String("graphql.exit_status", "failure"),
+	)
+	assert.Equal(t, uint64(3), countFor(t, metrics["graphql.request.duration"], failedRequest))
+}
+
+func TestTracer_FieldFilter(t *testing.T) {
+	srv, reader := newServer(t, otelmetrics.WithFieldFilter(func(fc *graphql.FieldContext) bool {
+		return fc.Field.Name == "id"
+	}))
+
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	metrics := collect(t, reader)
+	assert.Zero(t, sumFor(t, metrics["graphql.resolver.started"], attribute.NewSet(
+		attribute.String("graphql.resolver.object", "Query"),
+		attribute.String("graphql.resolver.field", "todos"),
+	)))
+	assert.NotZero(t, sumFor(t, metrics["graphql.resolver.started"], attribute.NewSet(
+		attribute.String("graphql.resolver.object", "Todo"),
+		attribute.String("graphql.resolver.field", "id"),
+	)))
+}
+
+func TestTracer_MaxOperationNames(t *testing.T) {
+	srv, reader := newServer(t, otelmetrics.WithMaxOperationNames(1))
+
+	for _, name := range []string{"A", "B", "C"} {
+		resp := doRequest(srv, `{"query":"query `+name+` { todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	metrics := collect(t, reader)
+	assert.Equal(t, int64(1), sumFor(t, metrics["graphql.request.completed"], attribute.NewSet(
+		attribute.String("graphql.operation.name", "A"),
+		attribute.String("graphql.operation.type", "query"),
+	)))
+	assert.Equal(t, int64(2), sumFor(t, metrics["graphql.request.completed"], attribute.NewSet(
+		attribute.String("graphql.operation.name", "__overflow__"),
+		attribute.String("graphql.operation.type", "query"),
+	)))
+}
+
+func TestTracer_MalformedBody(t *testing.T) {
+	srv, _ := newServer(t)
+
+	resp := doRequest(srv, `{"query":`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "json request body could not be decoded")
+}
+
+func newServer(t *testing.T, opts ...otelmetrics.Option) (http.Handler, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	tracer, err := otelmetrics.New(append(opts, otelmetrics.WithMeterProvider(provider))...)
+	require.NoError(t, err)
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tracer)
+
+	return srv, reader
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+	return metrics
+}
+
+func sumFor(t *testing.T, data metricdata.Aggregation, attrs attribute.Set) int64 {
+	sum, ok := data.(metricdata.Sum[int64])
+	require.True(t, ok, "unexpected aggregation %T", data)
+	for _, dp := range sum.DataPoints {
+		if dp.Attributes.Equals(&attrs) {
+			return dp.Value
+		}
+	}
+	return 0
+}
+
+func countFor(t *testing.T, data metricdata.Aggregation, attrs attribute.Set) uint64 {
+	histogram, ok := data.(metricdata.Histogram[float64])
+	require.True(t, ok, "unexpected aggregation %T", data)
+	for _, dp := range histogram.DataPoints {
+		if dp.Attributes.Equals(&attrs) {
+			return dp.Count
+		}
+	}
+	return 0
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,63 @@
+package otelmetrics
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// defaultMaxOperationNames caps the graphql.operation.name attribute unless
+// configured otherwise with WithMaxOperationNames.
+const defaultMaxOperationNames = 100
+
+type config struct {
+	meterProvider     metric.MeterProvider
+	fieldFilter       func(fc *graphql.FieldContext) bool
+	maxOperationNames int
+}
+
+// Option is anything that can configure Tracer.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		fieldFilter:       resolvedField,
+		maxOperationNames: defaultMaxOperationNames,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithMeterProvider creates instruments from provider instead of the global
+// MeterProvider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(cfg *config) {
+		cfg.meterProvider = provider
+	}
+}
+
+// WithFieldFilter selects the fields whose resolution is measured. By
+// default only fields bound to a resolver or a method are, skipping the
+// many cheap struct field lookups.
+func WithFieldFilter(fn func(fc *graphql.FieldContext) bool) Option {
+	return func(cfg *config) {
+		cfg.fieldFilter = fn
+	}
+}
+
+func resolvedField(fc *graphql.FieldContext) bool {
+	return fc.IsResolver || fc.IsMethod
+}
+
+// WithMaxOperationNames caps the number of distinct graphql.operation.name
+// attribute values, 100 by default. Names first seen after the cap is
+// reached are reported as "__overflow__". A value of 0 or less disables the
+// cap.
+func WithMaxOperationNames(max int) Option {
+	return func(cfg *config) {
+		cfg.maxOperationNames = max
+	}
+}
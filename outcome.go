@@ -0,0 +1,74 @@
+package contrib
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Outcome classifies how an operation ended, telling failures caused by
+// the client, like 4xx HTTP statuses, from those of the server, like 5xx.
+type Outcome string
+
+// Outcomes returned by Classify.
+const (
+	Success       Outcome = "success"
+	UserError     Outcome = "user_error"
+	InternalError Outcome = "internal_error"
+	Cancelled     Outcome = "cancelled"
+	Timeout       Outcome = "timeout"
+	RateLimited   Outcome = "rate_limited"
+)
+
+// Classifier returns the Outcome of the operation in ctx that responded
+// with errs. The observability extensions of this module accept one
+// through their WithClassifier option, Classify by default.
+type Classifier func(ctx context.Context, errs gqlerror.List) Outcome
+
+// severity orders the outcomes of errors, the most severe one of a
+// response being its outcome.
+var severity = map[Outcome]int{
+	UserError:     1,
+	RateLimited:   2,
+	Timeout:       3,
+	InternalError: 4,
+}
+
+// Classify is the default Classifier. Operations whose context is done are
+// Cancelled, or Timeout past their deadline, whatever their errors. Others
+// take the most severe outcome of their errors, by extensions.code: TIMEOUT
+// is a Timeout, RATE_LIMITED and CONCURRENCY_LIMITED are RateLimited,
+// errors without a code, INTERNAL_SERVER_ERROR, SERVICE_UNAVAILABLE,
+// UPLOAD_FAILED and CHAOS_FAULT are InternalError, and any other code is
+// deliberate, a UserError.
+func Classify(ctx context.Context, errs gqlerror.List) Outcome {
+	switch err := ctx.Err(); {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case err != nil:
+		return Cancelled
+	}
+
+	outcome := Success
+	for _, err := range errs {
+		if o := errorOutcome(err); severity[o] > severity[outcome] {
+			outcome = o
+		}
+	}
+	return outcome
+}
+
+func errorOutcome(err *gqlerror.Error) Outcome {
+	switch extcode.Code(err) {
+	case "", "INTERNAL_SERVER_ERROR", "SERVICE_UNAVAILABLE", "UPLOAD_FAILED", "CHAOS_FAULT":
+		return InternalError
+	case "TIMEOUT":
+		return Timeout
+	case "RATE_LIMITED", "CONCURRENCY_LIMITED":
+		return RateLimited
+	default:
+		return UserError
+	}
+}
@@ -0,0 +1,45 @@
+package contrib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestClassify(t *testing.T) {
+	coded := func(code string) *gqlerror.Error {
+		return &gqlerror.Error{Message: code, Extensions: map[string]interface{}{"code": code}}
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	specs := []struct {
+		SpecName string
+		Ctx      context.Context
+		Errors   gqlerror.List
+		Expected contrib.Outcome
+	}{
+		{SpecName: "no error", Ctx: context.Background(), Expected: contrib.Success},
+		{SpecName: "coded", Ctx: context.Background(), Errors: gqlerror.List{coded("NOT_FOUND")}, Expected: contrib.UserError},
+		{SpecName: "uncoded", Ctx: context.Background(), Errors: gqlerror.List{gqlerror.Errorf("boom")}, Expected: contrib.InternalError},
+		{SpecName: "numeric code", Ctx: context.Background(), Errors: gqlerror.List{{Message: "gone", Extensions: map[string]interface{}{"code": 410}}}, Expected: contrib.UserError},
+		{SpecName: "upload failed", Ctx: context.Background(), Errors: gqlerror.List{coded("UPLOAD_FAILED")}, Expected: contrib.InternalError},
+		{SpecName: "chaos fault", Ctx: context.Background(), Errors: gqlerror.List{coded("CHAOS_FAULT")}, Expected: contrib.InternalError},
+		{SpecName: "rate limited", Ctx: context.Background(), Errors: gqlerror.List{coded("RATE_LIMITED")}, Expected: contrib.RateLimited},
+		{SpecName: "most severe", Ctx: context.Background(), Errors: gqlerror.List{coded("FORBIDDEN"), coded("TIMEOUT"), coded("CONCURRENCY_LIMITED")}, Expected: contrib.Timeout},
+		{SpecName: "cancelled", Ctx: canceled, Errors: gqlerror.List{gqlerror.Errorf("context canceled")}, Expected: contrib.Cancelled},
+		{SpecName: "deadline", Ctx: expired, Expected: contrib.Timeout},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			assert.Equal(t, spec.Expected, contrib.Classify(spec.Ctx, spec.Errors))
+		})
+	}
+}
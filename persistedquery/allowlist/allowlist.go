@@ -0,0 +1,140 @@
+// Package allowlist restricts a server to the operations listed in a
+// persisted query manifest.
+package allowlist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeNotAllowed is the extensions.code of the default rejection.
+const ErrCodeNotAllowed = "PERSISTED_QUERY_NOT_ALLOWED"
+
+// Allowlist is a gqlgen handler extension rejecting every operation whose
+// query hash is missing from the manifest. When used together with
+// extension.AutomaticPersistedQuery, add it after the APQ extension so the
+// query has been resolved from its hash first.
+type Allowlist struct {
+	load   Loader
+	cfg    config
+	hashes atomic.Pointer[map[string]struct{}]
+
+	stop chan struct{}
+	done sync.WaitGroup
+	once sync.Once
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = (*Allowlist)(nil)
+
+// New loads the manifest once and returns an Allowlist enforcing it.
+func New(ctx context.Context, load Loader, opts ...Option) (*Allowlist, error) {
+	a := &Allowlist{
+		load: load,
+		cfg:  *newConfig(opts...),
+		stop: make(chan struct{}),
+	}
+
+	if err := a.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	if a.cfg.refreshInterval > 0 {
+		a.done.Add(1)
+		go a.refresh()
+	}
+
+	return a, nil
+}
+
+// Reload fetches and parses the manifest, replacing the current one on
+// success.
+func (a *Allowlist) Reload(ctx context.Context) error {
+	data, err := a.load(ctx)
+	if err != nil {
+		return fmt.Errorf("allowlist: could not load manifest: %w", err)
+	}
+
+	hashes, err := parseManifest(data)
+	if err != nil {
+		return fmt.Errorf("allowlist: could not parse manifest: %w", err)
+	}
+
+	a.hashes.Store(&hashes)
+	return nil
+}
+
+// Close stops the periodic refresh, if any.
+func (a *Allowlist) Close() error {
+	a.once.Do(func() { close(a.stop) })
+	a.done.Wait()
+	return nil
+}
+
+func (a *Allowlist) refresh() {
+	defer a.done.Done()
+
+	ticker := time.NewTicker(a.cfg.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			if err := a.Reload(context.Background()); err != nil {
+				a.cfg.errorHandler(err)
+			}
+		}
+	}
+}
+
+// Allowed reports whether the hex encoded SHA-256 hash is in the manifest.
+func (a *Allowlist) Allowed(hash string) bool {
+	_, ok := (*a.hashes.Load())[hash]
+	return ok
+}
+
+func (a *Allowlist) ExtensionName() string {
+	return "PersistedQueryAllowlist"
+}
+
+func (a *Allowlist) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (a *Allowlist) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	sum := sha256.Sum256([]byte(rawParams.Query))
+	hash := hex.EncodeToString(sum[:])
+	if a.cfg.normalized {
+		// Queries that do not parse are rejected under their raw hash.
+		if query, err := normalize.Query(rawParams.Query, rawParams.OperationName, normalize.KeepLiterals()); err == nil {
+			hash = normalize.Hash(query)
+		}
+	}
+	if a.Allowed(hash) {
+		return nil
+	}
+
+	return a.cfg.rejection(ctx, hash)
+}
+
+func defaultRejection(ctx context.Context, hash string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: "operation is not in the persisted query allowlist",
+		Extensions: map[string]interface{}{
+			"code": ErrCodeNotAllowed,
+		},
+	}
+}
@@ -0,0 +1,156 @@
+package allowlist_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen-contrib/persistedquery/allowlist"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	allowedQuery = "{ todos { id } }"
+	otherQuery   = "{ todos { text } }"
+)
+
+func TestAllowlist(t *testing.T) {
+	manifests := map[string]string{
+		"apollo": fmt.Sprintf(`{"format":"apollo-persisted-query-manifest","version":1,"operations":[{"id":%q,"name":"Todos","type":"query","body":%q}]}`, hash(allowedQuery), allowedQuery),
+		"relay":  fmt.Sprintf(`{%q:%q}`, hash(allowedQuery), allowedQuery),
+		"array":  fmt.Sprintf(`[%q]`, hash(allowedQuery)),
+	}
+
+	for name, manifest := range manifests {
+		t.Run(name, func(t *testing.T) {
+			fsys := fstest.MapFS{"manifest.json": {Data: []byte(manifest)}}
+			list, err := allowlist.New(context.Background(), allowlist.FS(fsys, "manifest.json"))
+			require.NoError(t, err)
+			defer list.Close()
+
+			srv := newServer(list)
+
+			resp := doRequest(srv, allowedQuery)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.NotContains(t, resp.Body.String(), "errors")
+
+			resp = doRequest(srv, otherQuery)
+			assert.Contains(t, resp.Body.String(), allowlist.ErrCodeNotAllowed)
+			assert.Contains(t, resp.Body.String(), `"data":null`)
+		})
+	}
+}
+
+func TestAllowlist_Rejection(t *testing.T) {
+	fsys := fstest.MapFS{"manifest.json": {Data: []byte(`[]`)}}
+	list, err := allowlist.New(context.Background(), allowlist.FS(fsys, "manifest.json"),
+		allowlist.WithRejection(func(ctx context.Context, hash string) *gqlerror.Error {
+			return gqlerror.Errorf("unknown operation %s", hash)
+		}),
+	)
+	require.NoError(t, err)
+
+	resp := doRequest(newServer(list), allowedQuery)
+	assert.Contains(t, resp.Body.String(), "unknown operation "+hash(allowedQuery))
+}
+
+func TestAllowlist_NormalizedHashes(t *testing.T) {
+	normalized, err := normalize.Query("query Todos { todos { id text } }", "", normalize.KeepLiterals())
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{"manifest.json": {Data: []byte(fmt.Sprintf(`[%q]`, normalize.Hash(normalized)))}}
+	list, err := allowlist.New(context.Background(), allowlist.FS(fsys, "manifest.json"), allowlist.WithNormalizedHashes())
+	require.NoError(t, err)
+
+	srv := newServer(list)
+
+	resp := doRequest(srv, "query Todos {\n  todos {\n    text\n    id\n  }\n}")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(srv, "query Todos { todos { id } }")
+	assert.Contains(t, resp.Body.String(), allowlist.ErrCodeNotAllowed)
+}
+
+func TestAllowlist_Refresh(t *testing.T) {
+	var manifest atomic.Value
+	manifest.Store(`[]`)
+	var fail atomic.Bool
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, manifest.Load())
+	}))
+	defer ts.Close()
+
+	reloadErrs := make(chan error, 10)
+	list, err := allowlist.New(context.Background(), allowlist.URL(ts.Client(), ts.URL),
+		allowlist.WithRefresh(10*time.Millisecond),
+		allowlist.WithErrorHandler(func(err error) { reloadErrs <- err }),
+	)
+	require.NoError(t, err)
+	defer list.Close()
+
+	assert.False(t, list.Allowed(hash(allowedQuery)))
+
+	manifest.Store(fmt.Sprintf(`[%q]`, hash(allowedQuery)))
+	assert.Eventually(t, func() bool { return list.Allowed(hash(allowedQuery)) }, time.Second, 5*time.Millisecond)
+
+	fail.Store(true)
+	select {
+	case err := <-reloadErrs:
+		assert.ErrorContains(t, err, "500")
+	case <-time.After(time.Second):
+		t.Fatal("expected a reload error")
+	}
+	assert.True(t, list.Allowed(hash(allowedQuery)))
+}
+
+func TestNew_InvalidManifest(t *testing.T) {
+	fsys := fstest.MapFS{"manifest.json": {Data: []byte(`"nope"`)}}
+	_, err := allowlist.New(context.Background(), allowlist.FS(fsys, "manifest.json"))
+	assert.Error(t, err)
+
+	_, err = allowlist.New(context.Background(), allowlist.File("testdata/missing.json"))
+	assert.Error(t, err)
+}
+
+func newServer(list *allowlist.Allowlist) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(list)
+
+	return srv
+}
+
+func hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func doRequest(handler http.Handler, query string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(fmt.Sprintf(`{"query":%q}`, query)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
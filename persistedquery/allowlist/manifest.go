@@ -0,0 +1,105 @@
+package allowlist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+)
+
+// Loader returns the raw content of a manifest.
+//
+// Three JSON layouts are understood: an Apollo persisted query manifest
+// ({"operations":[{"id":"<sha256>",...}]}), an object mapping hashes to
+// query bodies as generated by Relay, and a plain array of hashes.
+type Loader func(ctx context.Context) ([]byte, error)
+
+// File loads the manifest from the file at path.
+func File(path string) Loader {
+	return func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// FS loads the manifest from name in fsys, typically an embed.FS.
+func FS(fsys fs.FS, name string) Loader {
+	return func(ctx context.Context) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
+
+// URL loads the manifest with a GET request to url. A nil client uses
+// http.DefaultClient.
+func URL(client *http.Client, url string) Loader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+
+		return io.ReadAll(resp.Body)
+	}
+}
+
+// parseManifest returns the set of hashes listed in data.
+func parseManifest(data []byte) (map[string]struct{}, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty manifest")
+	}
+
+	hashes := map[string]struct{}{}
+
+	switch data[0] {
+	case '[':
+		var list []string
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		for _, hash := range list {
+			hashes[hash] = struct{}{}
+		}
+	case '{':
+		var apollo struct {
+			Operations []struct {
+				ID string `json:"id"`
+			} `json:"operations"`
+		}
+		if err := json.Unmarshal(data, &apollo); err == nil && apollo.Operations != nil {
+			for _, op := range apollo.Operations {
+				hashes[op.ID] = struct{}{}
+			}
+			break
+		}
+
+		var relay map[string]string
+		if err := json.Unmarshal(data, &relay); err != nil {
+			return nil, err
+		}
+		for hash := range relay {
+			hashes[hash] = struct{}{}
+		}
+	default:
+		return nil, fmt.Errorf("manifest must be a JSON object or array")
+	}
+
+	return hashes, nil
+}
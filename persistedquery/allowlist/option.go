@@ -0,0 +1,65 @@
+package allowlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type config struct {
+	refreshInterval time.Duration
+	errorHandler    func(err error)
+	rejection       func(ctx context.Context, hash string) *gqlerror.Error
+	normalized      bool
+}
+
+// Option is anything that can configure Allowlist.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		errorHandler: func(err error) {},
+		rejection:    defaultRejection,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithRefresh reloads the manifest at the given interval until Close is
+// called. The previous manifest stays in use when a reload fails.
+func WithRefresh(interval time.Duration) Option {
+	return func(cfg *config) {
+		cfg.refreshInterval = interval
+	}
+}
+
+// WithErrorHandler is called when a periodic reload fails.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithRejection builds the error returned for operations missing from the
+// manifest. hash is the hex encoded SHA-256 of the query, normalized with
+// WithNormalizedHashes.
+func WithRejection(fn func(ctx context.Context, hash string) *gqlerror.Error) Option {
+	return func(cfg *config) {
+		cfg.rejection = fn
+	}
+}
+
+// WithNormalizedHashes expects the manifest to list the hashes of queries
+// normalized with normalize.Query and normalize.KeepLiterals, computed with
+// normalize.Hash. Requests then only have to match a listed operation up to
+// formatting, field order and unused fragments.
+func WithNormalizedHashes() Option {
+	return func(cfg *config) {
+		cfg.normalized = true
+	}
+}
@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// taggedTemplate matches gql`...` and graphql(`...`) literals, escaped
+	// backticks included.
+	taggedTemplate = regexp.MustCompile("\\b(?:gql|graphql)\\s*(?:\\(\\s*)?`((?:[^`\\\\]|\\\\.)*)`")
+	interpolation  = regexp.MustCompile(`\$\{[^}]*\}`)
+)
+
+// Extract returns the GraphQL documents held by the file name of content
+// src: the whole file for .graphql and .gql files, and the gql and graphql
+// tagged template literals of JavaScript and TypeScript sources. Template
+// interpolations are dropped, the fragments they usually embed being
+// resolved by name across sources by Build.
+func Extract(name string, src []byte) []string {
+	switch extension(name) {
+	case graphqlFile:
+		return []string{string(src)}
+	case scriptFile:
+		var docs []string
+		for _, match := range taggedTemplate.FindAllSubmatch(src, -1) {
+			doc := interpolation.ReplaceAllString(string(match[1]), "")
+			docs = append(docs, strings.ReplaceAll(doc, "\\`", "`"))
+		}
+		return docs
+	default:
+		return nil
+	}
+}
+
+const (
+	otherFile = iota
+	graphqlFile
+	scriptFile
+)
+
+func extension(name string) int {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".graphql", ".gql":
+		return graphqlFile
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts":
+		return scriptFile
+	default:
+		return otherFile
+	}
+}
@@ -0,0 +1,197 @@
+// Package manifest generates the Apollo persisted query manifests consumed
+// by the allowlist package from the operations of client code bases.
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const (
+	// Format is the format of Apollo persisted query manifests.
+	Format = "apollo-persisted-query-manifest"
+	// Version is the version of the format written by Build.
+	Version = 1
+)
+
+// Manifest is an Apollo persisted query manifest.
+type Manifest struct {
+	Format     string      `json:"format"`
+	Version    int         `json:"version"`
+	Operations []Operation `json:"operations"`
+}
+
+// Operation is an operation of a Manifest. Body is the document clients
+// send, the operation followed by the fragments it spreads, and ID the hex
+// encoded SHA-256 of Body.
+type Operation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Body string `json:"body"`
+}
+
+// Source is a GraphQL document to build a manifest from, Name being used in
+// error messages.
+type Source struct {
+	Name  string
+	Input string
+}
+
+// Build returns the manifest of the operations of sources, sorted by name.
+// Fragments are shared by all sources, so an operation may spread a
+// fragment defined in another file. Operations with the same name must have
+// the same body.
+func Build(sources []Source, opts ...Option) (*Manifest, error) {
+	cfg := newConfig(opts...)
+
+	var operations []*ast.OperationDefinition
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, src := range sources {
+		doc, err := parser.ParseQuery(&ast.Source{Name: src.Name, Input: src.Input})
+		if err != nil {
+			return nil, fmt.Errorf("manifest: %w", err)
+		}
+		for _, fragment := range doc.Fragments {
+			if prev, ok := fragments[fragment.Name]; ok && formatFragment(prev) != formatFragment(fragment) {
+				return nil, fmt.Errorf("manifest: fragment %s is defined twice, in %s and %s", fragment.Name, prev.Position.Src.Name, src.Name)
+			}
+			fragments[fragment.Name] = fragment
+		}
+		operations = append(operations, doc.Operations...)
+	}
+
+	m := &Manifest{Format: Format, Version: Version, Operations: []Operation{}}
+	seen := map[string]*ast.OperationDefinition{}
+	bodies := map[string]string{}
+	for _, op := range operations {
+		if op.Name == "" {
+			return nil, fmt.Errorf("manifest: anonymous operation in %s", op.Position.Src.Name)
+		}
+
+		doc := &ast.QueryDocument{Operations: ast.OperationList{op}}
+		if err := spreads(doc, fragments, op.SelectionSet, map[string]bool{}); err != nil {
+			return nil, fmt.Errorf("manifest: operation %s: %w", op.Name, err)
+		}
+		sort.Slice(doc.Fragments, func(i, j int) bool {
+			return doc.Fragments[i].Name < doc.Fragments[j].Name
+		})
+		body := format(doc)
+
+		if prev, ok := seen[op.Name]; ok {
+			if bodies[op.Name] != body {
+				return nil, fmt.Errorf("manifest: operation %s is defined twice, in %s and %s", op.Name, prev.Position.Src.Name, op.Position.Src.Name)
+			}
+			continue
+		}
+		seen[op.Name], bodies[op.Name] = op, body
+
+		id := hash(body)
+		if cfg.normalized {
+			normalized, err := normalize.Document(doc, op.Name, normalize.KeepLiterals())
+			if err != nil {
+				return nil, fmt.Errorf("manifest: %w", err)
+			}
+			id = normalize.Hash(normalized)
+		}
+
+		m.Operations = append(m.Operations, Operation{
+			ID:   id,
+			Name: op.Name,
+			Type: string(op.Operation),
+			Body: body,
+		})
+	}
+
+	sort.Slice(m.Operations, func(i, j int) bool {
+		return m.Operations[i].Name < m.Operations[j].Name
+	})
+
+	return m, nil
+}
+
+// Walk returns the documents extracted from the files of fsys under root,
+// skipping node_modules and hidden directories.
+func Walk(fsys fs.FS, root string) ([]Source, error) {
+	var sources []Source
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "node_modules" || strings.HasPrefix(name, ".")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if extension(path) == otherFile {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		for _, doc := range Extract(path, src) {
+			sources = append(sources, Source{Name: path, Input: doc})
+		}
+		return nil
+	})
+	return sources, err
+}
+
+// spreads adds to doc the fragments spread by set, transitively.
+func spreads(doc *ast.QueryDocument, fragments map[string]*ast.FragmentDefinition, set ast.SelectionSet, added map[string]bool) error {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if err := spreads(doc, fragments, sel.SelectionSet, added); err != nil {
+				return err
+			}
+		case *ast.InlineFragment:
+			if err := spreads(doc, fragments, sel.SelectionSet, added); err != nil {
+				return err
+			}
+		case *ast.FragmentSpread:
+			if added[sel.Name] {
+				continue
+			}
+			fragment, ok := fragments[sel.Name]
+			if !ok {
+				return fmt.Errorf("undefined fragment %s", sel.Name)
+			}
+			added[sel.Name] = true
+			doc.Fragments = append(doc.Fragments, fragment)
+			if err := spreads(doc, fragments, fragment.SelectionSet, added); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func format(doc *ast.QueryDocument) string {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf, formatter.WithIndent("  ")).FormatQueryDocument(doc)
+	return strings.TrimSpace(buf.String())
+}
+
+func formatFragment(fragment *ast.FragmentDefinition) string {
+	return format(&ast.QueryDocument{Fragments: ast.FragmentDefinitionList{fragment}})
+}
+
+func hash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,121 @@
+package manifest_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen-contrib/persistedquery/allowlist"
+	"github.com/99designs/gqlgen-contrib/persistedquery/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var client = fstest.MapFS{
+	"src/todos.graphql": {Data: []byte(`query Todos { todos { ...TodoFields } }`)},
+	"src/todo.tsx": {Data: []byte("const TODO_FIELDS = gql`\n  fragment TodoFields on Todo { id text }\n`;\n" +
+		"const CREATE = gql`\n  mutation CreateTodo($text: String!) { createTodo(input: {text: $text, userId: \"1\"}) { ...TodoFields } }\n  ${TODO_FIELDS}\n`;\n")},
+	"src/README.md":                  {Data: []byte("gql`query Ignored { todos { id } }`")},
+	"node_modules/lib/index.js":      {Data: []byte("gql`query Vendored { todos { id } }`")},
+	".cache/todos.graphql":           {Data: []byte(`query Cached { todos { id } }`)},
+	"src/duplicate/todos.graphql":    {Data: []byte("query Todos {\n  todos { ...TodoFields }\n}\n")},
+	"src/components/unrelated.ts":    {Data: []byte(`export const x = 1;`)},
+	"src/components/user.graphql":    {Data: []byte(`query User { todo(id: "1") { user { name } } }`)},
+	"src/components/escaped.js":      {Data: []byte("graphql(`query Escaped { todos { text } } # \\`quoted\\``)")},
+	"src/components/fragments.gql":   {Data: []byte(`fragment Unused on Todo { done }`)},
+	"src/components/subscription.ts": {Data: []byte("gql`subscription TodoAdded { todoAdded { id } }`")},
+}
+
+func TestBuild(t *testing.T) {
+	sources, err := manifest.Walk(client, ".")
+	require.NoError(t, err)
+
+	m, err := manifest.Build(sources)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Format, m.Format)
+	assert.Equal(t, manifest.Version, m.Version)
+
+	var order []string
+	names := map[string]manifest.Operation{}
+	for _, op := range m.Operations {
+		order = append(order, op.Name)
+		names[op.Name] = op
+		sum := sha256.Sum256([]byte(op.Body))
+		assert.Equal(t, hex.EncodeToString(sum[:]), op.ID, op.Name)
+	}
+	assert.Equal(t, []string{"CreateTodo", "Escaped", "TodoAdded", "Todos", "User"}, order)
+
+	assert.Equal(t, "mutation", names["CreateTodo"].Type)
+	assert.Equal(t, "subscription", names["TodoAdded"].Type)
+	assert.Contains(t, names["CreateTodo"].Body, "fragment TodoFields on Todo")
+	assert.Contains(t, names["Todos"].Body, "fragment TodoFields on Todo")
+	assert.NotContains(t, names["Todos"].Body, "Unused")
+	assert.NotContains(t, names["User"].Body, "fragment")
+}
+
+func TestBuild_Allowlist(t *testing.T) {
+	sources, err := manifest.Walk(client, ".")
+	require.NoError(t, err)
+
+	t.Run("raw", func(t *testing.T) {
+		m, err := manifest.Build(sources)
+		require.NoError(t, err)
+
+		list := newAllowlist(t, m)
+		for _, op := range m.Operations {
+			sum := sha256.Sum256([]byte(op.Body))
+			assert.True(t, list.Allowed(hex.EncodeToString(sum[:])), op.Name)
+		}
+	})
+
+	t.Run("normalized", func(t *testing.T) {
+		m, err := manifest.Build(sources, manifest.WithNormalizedHashes())
+		require.NoError(t, err)
+
+		list := newAllowlist(t, m, allowlist.WithNormalizedHashes())
+		normalized, err := normalize.Query("query Todos { todos { ... TodoFields } } fragment TodoFields on Todo { text id }", "Todos", normalize.KeepLiterals())
+		require.NoError(t, err)
+		assert.True(t, list.Allowed(normalize.Hash(normalized)))
+	})
+}
+
+func TestBuild_Errors(t *testing.T) {
+	for name, sources := range map[string][]manifest.Source{
+		"syntax":    {{Name: "a.graphql", Input: `query {`}},
+		"anonymous": {{Name: "a.graphql", Input: `{ todos { id } }`}},
+		"fragment":  {{Name: "a.graphql", Input: `query A { todos { ...Missing } }`}},
+		"duplicate": {
+			{Name: "a.graphql", Input: `query A { todos { id } }`},
+			{Name: "b.graphql", Input: `query A { todos { text } }`},
+		},
+		"duplicate fragment": {
+			{Name: "a.graphql", Input: `fragment F on Todo { id }`},
+			{Name: "b.graphql", Input: `fragment F on Todo { text }`},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := manifest.Build(sources)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	docs := manifest.Extract("todo.ts", []byte("const a = gql`{ todos { id } }`, b = graphql(`\n  query B { todos { ${fields} } }\n`)"))
+	assert.Equal(t, []string{"{ todos { id } }", "\n  query B { todos {  } }\n"}, docs)
+	assert.Nil(t, manifest.Extract("todo.go", []byte("gql`{ todos { id } }`")))
+}
+
+func newAllowlist(t *testing.T, m *manifest.Manifest, opts ...allowlist.Option) *allowlist.Allowlist {
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+
+	list, err := allowlist.New(context.Background(), allowlist.FS(fstest.MapFS{"manifest.json": {Data: data}}, "manifest.json"), opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { list.Close() })
+	return list
+}
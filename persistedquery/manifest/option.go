@@ -0,0 +1,27 @@
+package manifest
+
+type config struct {
+	normalized bool
+}
+
+// Option is anything that can configure Build.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithNormalizedHashes sets the id of each operation to the hash of its
+// normalized form, as expected by allowlist.WithNormalizedHashes, instead of
+// the hash of its body.
+func WithNormalizedHashes() Option {
+	return func(cfg *config) {
+		cfg.normalized = true
+	}
+}
@@ -0,0 +1,120 @@
+// Package memcacheapq provides an automatic persisted query cache shared
+// between server instances through memcached.
+package memcacheapq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// flagGzip marks items whose value is gzip compressed.
+const flagGzip uint32 = 1 << 0
+
+// Cache implements graphql.Cache[string] over memcached, for use as
+// extension.AutomaticPersistedQuery.Cache.
+type Cache struct {
+	client *memcache.Client
+	cfg    config
+}
+
+var _ graphql.Cache[string] = (*Cache)(nil)
+
+// New returns a Cache storing queries through client.
+func New(client *memcache.Client, opts ...Option) *Cache {
+	return &Cache{
+		client: client,
+		cfg:    *newConfig(opts...),
+	}
+}
+
+// Get looks up the query stored for the given hash.
+func (c *Cache) Get(ctx context.Context, key string) (string, bool) {
+	item, err := c.client.Get(c.cfg.prefix + key)
+	if err != nil {
+		if !errors.Is(err, memcache.ErrCacheMiss) {
+			c.cfg.errorHandler(ctx, err)
+		}
+		c.cfg.onMiss(ctx)
+		return "", false
+	}
+
+	value := item.Value
+	if item.Flags&flagGzip != 0 {
+		value, err = decompress(value)
+		if err != nil {
+			c.cfg.errorHandler(ctx, err)
+			c.cfg.onMiss(ctx)
+			return "", false
+		}
+	}
+
+	c.cfg.onHit(ctx)
+	return string(value), true
+}
+
+// Add stores query under the given hash.
+func (c *Cache) Add(ctx context.Context, key string, query string) {
+	item := &memcache.Item{
+		Key:        c.cfg.prefix + key,
+		Value:      []byte(query),
+		Expiration: expiration(c.cfg.ttl),
+	}
+
+	if c.cfg.compressMinSize >= 0 && len(query) >= c.cfg.compressMinSize {
+		value, err := compress(item.Value)
+		if err != nil {
+			c.cfg.errorHandler(ctx, err)
+			return
+		}
+		item.Value = value
+		item.Flags |= flagGzip
+	}
+
+	if err := c.client.Set(item); err != nil {
+		c.cfg.errorHandler(ctx, err)
+	}
+}
+
+// maxRelativeExpiration is the longest expiration memcached reads as a
+// number of seconds, longer ones being unix timestamps.
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// expiration rounds ttl up to whole seconds, as memcached reads 0 as never
+// expiring.
+func expiration(ttl time.Duration) int32 {
+	if ttl > maxRelativeExpiration {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	return int32(math.Ceil(ttl.Seconds()))
+}
+
+func compress(value []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decompress(value []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
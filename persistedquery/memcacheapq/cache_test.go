@@ -0,0 +1,168 @@
+package memcacheapq_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/persistedquery/memcacheapq"
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	server := newFakeMemcached(t)
+
+	var hits, misses int
+	cache := memcacheapq.New(memcache.New(server.addr),
+		memcacheapq.WithPrefix("test:"),
+		memcacheapq.WithTTL(time.Minute),
+		memcacheapq.WithCompression(64),
+		memcacheapq.WithHitMissHooks(
+			func(ctx context.Context) { hits++ },
+			func(ctx context.Context) { misses++ },
+		),
+	)
+
+	ctx := context.Background()
+	short := "{ todos { id } }"
+	long := "{ todos { id text done user { id name } } }" + strings.Repeat(" ", 64)
+
+	_, ok := cache.Get(ctx, "short")
+	assert.False(t, ok)
+
+	cache.Add(ctx, "short", short)
+	cache.Add(ctx, "long", long)
+
+	got, ok := cache.Get(ctx, "short")
+	require.True(t, ok)
+	assert.Equal(t, short, got)
+
+	got, ok = cache.Get(ctx, "long")
+	require.True(t, ok)
+	assert.Equal(t, long, got)
+
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, 1, misses)
+
+	assert.Equal(t, short, string(server.item("test:short").value))
+	assert.Equal(t, uint32(0), server.item("test:short").flags)
+	assert.NotEqual(t, long, string(server.item("test:long").value))
+	assert.Equal(t, uint32(1), server.item("test:long").flags)
+	assert.Equal(t, 60, server.item("test:long").exptime)
+
+	// Expirations over 30 days are unix timestamps.
+	year := memcacheapq.New(memcache.New(server.addr), memcacheapq.WithPrefix("test:"), memcacheapq.WithTTL(365*24*time.Hour))
+	year.Add(ctx, "year", short)
+	assert.InDelta(t, time.Now().Add(365*24*time.Hour).Unix(), server.item("test:year").exptime, 5)
+
+	// Sub-second expirations are rounded up, 0 meaning never.
+	brief := memcacheapq.New(memcache.New(server.addr), memcacheapq.WithPrefix("test:"), memcacheapq.WithTTL(500*time.Millisecond))
+	brief.Add(ctx, "brief", short)
+	assert.Equal(t, 1, server.item("test:brief").exptime)
+}
+
+func TestCache_Errors(t *testing.T) {
+	var errs []error
+	cache := memcacheapq.New(memcache.New("127.0.0.1:1"),
+		memcacheapq.WithErrorHandler(func(ctx context.Context, err error) {
+			errs = append(errs, err)
+		}),
+	)
+
+	cache.Add(context.Background(), "key", "{ todos { id } }")
+	_, ok := cache.Get(context.Background(), "key")
+	assert.False(t, ok)
+	assert.Len(t, errs, 2)
+}
+
+type fakeItem struct {
+	flags   uint32
+	exptime int
+	value   []byte
+}
+
+// fakeMemcached speaks just enough of the memcached text protocol for the
+// get and set commands used by Cache.
+type fakeMemcached struct {
+	addr  string
+	mu    sync.Mutex
+	items map[string]fakeItem
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	s := &fakeMemcached{addr: l.Addr().String(), items: map[string]fakeItem{}}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeMemcached) item(key string) fakeItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.items[key]
+}
+
+func (s *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+
+		switch fields[0] {
+		case "gets":
+			for _, key := range fields[1:] {
+				s.mu.Lock()
+				item, ok := s.items[key]
+				s.mu.Unlock()
+				if ok {
+					fmt.Fprintf(rw, "VALUE %s %d %d 1\r\n%s\r\n", key, item.flags, len(item.value), item.value)
+				}
+			}
+			fmt.Fprint(rw, "END\r\n")
+		case "set":
+			var item fakeItem
+			var size int
+			fmt.Sscanf(strings.Join(fields[2:5], " "), "%d %d %d", &item.flags, &item.exptime, &size)
+			item.value = make([]byte, size+2)
+			if _, err := io.ReadFull(rw, item.value); err != nil {
+				return
+			}
+			item.value = item.value[:size]
+			s.mu.Lock()
+			s.items[fields[1]] = item
+			s.mu.Unlock()
+			fmt.Fprint(rw, "STORED\r\n")
+		default:
+			fmt.Fprint(rw, "ERROR\r\n")
+		}
+		rw.Flush()
+	}
+}
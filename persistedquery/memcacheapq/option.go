@@ -0,0 +1,82 @@
+package memcacheapq
+
+import (
+	"context"
+	"time"
+)
+
+type config struct {
+	ttl             time.Duration
+	prefix          string
+	compressMinSize int
+	onHit           func(ctx context.Context)
+	onMiss          func(ctx context.Context)
+	errorHandler    func(ctx context.Context, err error)
+}
+
+// Option is anything that can configure Cache.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	nop := func(ctx context.Context) {}
+	cfg := &config{
+		ttl:             24 * time.Hour,
+		prefix:          "apq:",
+		compressMinSize: -1,
+		onHit:           nop,
+		onMiss:          nop,
+		errorHandler:    func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithTTL sets how long a query is kept after it was last added. Defaults to
+// 24 hours, 0 keeps queries until memcached evicts them.
+func WithTTL(ttl time.Duration) Option {
+	return func(cfg *config) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithPrefix sets the prefix of every key written to memcached. Defaults to
+// "apq:".
+func WithPrefix(prefix string) Option {
+	return func(cfg *config) {
+		cfg.prefix = prefix
+	}
+}
+
+// WithCompression gzips queries of at least minSize bytes before storing
+// them. Compressed and plain items can be read regardless of this setting.
+func WithCompression(minSize int) Option {
+	return func(cfg *config) {
+		cfg.compressMinSize = minSize
+	}
+}
+
+// WithHitMissHooks calls onHit or onMiss after every Get, for example to
+// count cache hits and misses. Either may be nil.
+func WithHitMissHooks(onHit, onMiss func(ctx context.Context)) Option {
+	return func(cfg *config) {
+		if onHit != nil {
+			cfg.onHit = onHit
+		}
+		if onMiss != nil {
+			cfg.onMiss = onMiss
+		}
+	}
+}
+
+// WithErrorHandler is called with every memcached error. graphql.Cache has
+// no way to return them, so by default they are dropped and a failing Get is
+// treated as a cache miss.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
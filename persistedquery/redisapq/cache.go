@@ -0,0 +1,69 @@
+// Package redisapq provides an automatic persisted query cache shared
+// between server instances through redis.
+package redisapq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache implements graphql.Cache[string] over redis, for use as
+// extension.AutomaticPersistedQuery.Cache.
+type Cache struct {
+	client redis.UniversalClient
+	cfg    config
+}
+
+var _ graphql.Cache[string] = (*Cache)(nil)
+
+// New connects to redis with redisOpts and returns a Cache once the server
+// answers a ping.
+func New(ctx context.Context, redisOpts *redis.Options, opts ...Option) (*Cache, error) {
+	client := redis.NewClient(redisOpts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("redisapq: could not ping redis: %w", err)
+	}
+
+	return NewWithClient(client, opts...), nil
+}
+
+// NewWithClient returns a Cache using an existing client, which may also be
+// a cluster or ring client.
+func NewWithClient(client redis.UniversalClient, opts ...Option) *Cache {
+	return &Cache{
+		client: client,
+		cfg:    *newConfig(opts...),
+	}
+}
+
+// Get looks up the query stored for the given hash.
+func (c *Cache) Get(ctx context.Context, key string) (string, bool) {
+	s, err := c.client.Get(ctx, c.cfg.prefix+key).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			c.cfg.errorHandler(ctx, err)
+		}
+		c.cfg.onMiss(ctx)
+		return "", false
+	}
+
+	c.cfg.onHit(ctx)
+	return s, true
+}
+
+// Add stores query under the given hash.
+func (c *Cache) Add(ctx context.Context, key string, query string) {
+	if err := c.client.Set(ctx, c.cfg.prefix+key, query, c.cfg.ttl).Err(); err != nil {
+		c.cfg.errorHandler(ctx, err)
+	}
+}
+
+// Close closes the underlying client.
+func (c *Cache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,122 @@
+package redisapq_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/persistedquery/redisapq"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	cache, err := redisapq.New(context.Background(), &redis.Options{Addr: mr.Addr()},
+		redisapq.WithPrefix("test:"),
+		redisapq.WithTTL(time.Minute),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.AutomaticPersistedQuery{Cache: cache})
+
+	query := "{ todos { id } }"
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+	persisted := fmt.Sprintf(`"extensions":{"persistedQuery":{"version":1,"sha256Hash":%q}}`, hash)
+
+	resp := doRequest(srv, `{`+persisted+`}`)
+	assert.Contains(t, resp.Body.String(), "PersistedQueryNotFound")
+
+	resp = doRequest(srv, fmt.Sprintf(`{"query":%q,%s}`, query, persisted))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	stored, err := mr.Get("test:" + hash)
+	require.NoError(t, err)
+	assert.Equal(t, query, stored)
+	assert.Equal(t, time.Minute, mr.TTL("test:"+hash))
+
+	resp = doRequest(srv, `{`+persisted+`}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), graph.TodoA.ID)
+}
+
+func TestCache_Errors(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	var errs []error
+	cache := redisapq.NewWithClient(redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1}),
+		redisapq.WithErrorHandler(func(ctx context.Context, err error) {
+			errs = append(errs, err)
+		}),
+	)
+	defer cache.Close()
+
+	_, ok := cache.Get(context.Background(), "missing")
+	assert.False(t, ok)
+	assert.Empty(t, errs)
+
+	mr.Close()
+
+	cache.Add(context.Background(), "key", "{ todos { id } }")
+	_, ok = cache.Get(context.Background(), "key")
+	assert.False(t, ok)
+	assert.Len(t, errs, 2)
+}
+
+func TestCache_HitMissHooks(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	var hits, misses int
+	cache := redisapq.NewWithClient(redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+		redisapq.WithHitMissHooks(
+			func(ctx context.Context) { hits++ },
+			func(ctx context.Context) { misses++ },
+		),
+	)
+	defer cache.Close()
+
+	_, ok := cache.Get(context.Background(), "key")
+	assert.False(t, ok)
+	cache.Add(context.Background(), "key", "{ todos { id } }")
+	_, ok = cache.Get(context.Background(), "key")
+	assert.True(t, ok)
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 1, misses)
+}
+
+func TestNew_Unreachable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	mr.Close()
+
+	_, err := redisapq.New(context.Background(), &redis.Options{Addr: addr, MaxRetries: -1})
+	assert.Error(t, err)
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
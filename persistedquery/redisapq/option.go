@@ -0,0 +1,72 @@
+package redisapq
+
+import (
+	"context"
+	"time"
+)
+
+type config struct {
+	ttl          time.Duration
+	prefix       string
+	onHit        func(ctx context.Context)
+	onMiss       func(ctx context.Context)
+	errorHandler func(ctx context.Context, err error)
+}
+
+// Option is anything that can configure Cache.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	nop := func(ctx context.Context) {}
+	cfg := &config{
+		ttl:          24 * time.Hour,
+		prefix:       "apq:",
+		onHit:        nop,
+		onMiss:       nop,
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithTTL sets how long a query is kept after it was last added. Defaults to
+// 24 hours, 0 keeps queries forever.
+func WithTTL(ttl time.Duration) Option {
+	return func(cfg *config) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithPrefix sets the prefix of every key written to redis. Defaults to
+// "apq:".
+func WithPrefix(prefix string) Option {
+	return func(cfg *config) {
+		cfg.prefix = prefix
+	}
+}
+
+// WithHitMissHooks calls onHit or onMiss after every Get, for example to
+// count cache hits and misses. Either may be nil.
+func WithHitMissHooks(onHit, onMiss func(ctx context.Context)) Option {
+	return func(cfg *config) {
+		if onHit != nil {
+			cfg.onHit = onHit
+		}
+		if onMiss != nil {
+			cfg.onMiss = onMiss
+		}
+	}
+}
+
+// WithErrorHandler is called with every redis error. graphql.Cache has no
+// way to return them, so by default they are dropped and a failing Get is
+// treated as a cache miss.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
@@ -0,0 +1,35 @@
+package pproflabel
+
+import "github.com/99designs/gqlgen/graphql"
+
+type config struct {
+	fieldFilter func(fc *graphql.FieldContext) bool
+}
+
+// Option is anything that can configure Labeler.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		fieldFilter: func(fc *graphql.FieldContext) bool {
+			return fc.IsResolver || fc.IsMethod
+		},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithFieldFilter selects the fields whose resolution is labeled with
+// LabelField. By default only fields bound to a resolver or a method are,
+// labeling each struct field lookup costing more than it tells, and other
+// fields keep the label of the closest labeled field above them. A filter
+// returning false only labels operations.
+func WithFieldFilter(fn func(fc *graphql.FieldContext) bool) Option {
+	return func(cfg *config) {
+		cfg.fieldFilter = fn
+	}
+}
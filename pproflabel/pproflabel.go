@@ -0,0 +1,76 @@
+// Package pproflabel sets pprof labels on the goroutines executing GraphQL
+// operations and resolvers, so CPU profiles can be sliced by operation and
+// field, e.g. with go tool pprof -tagfocus graphql_operation=Name.
+package pproflabel
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Labels set by Labeler.
+const (
+	// LabelOperation is the name of the operation being executed.
+	LabelOperation = "graphql_operation"
+	// LabelField is the object.field coordinate of the field being resolved.
+	LabelField = "graphql_field"
+)
+
+// Labeler is a gqlgen handler extension running operations and resolvers
+// under pprof.Do. Goroutines started by resolvers inherit the labels.
+type Labeler struct {
+	fieldFilter func(fc *graphql.FieldContext) bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Labeler{}
+
+// New returns a Labeler.
+func New(opts ...Option) Labeler {
+	cfg := newConfig(opts...)
+	return Labeler{fieldFilter: cfg.fieldFilter}
+}
+
+func (l Labeler) ExtensionName() string {
+	return "PprofLabels"
+}
+
+func (l Labeler) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l Labeler) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	name := gqlctx.OperationName(graphql.GetOperationContext(ctx))
+
+	var res *graphql.Response
+	pprof.Do(ctx, pprof.Labels(LabelOperation, name), func(ctx context.Context) {
+		res = next(ctx)
+	})
+	return res
+}
+
+func (l Labeler) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || l.fieldFilter == nil || !l.fieldFilter(fc) {
+		return next(ctx)
+	}
+
+	var (
+		res interface{}
+		err error
+	)
+	pprof.Do(ctx, pprof.Labels(LabelField, fc.Object+"."+fc.Field.Name), func(ctx context.Context) {
+		res, err = next(ctx)
+	})
+	return res, err
+}
@@ -0,0 +1,82 @@
+package pproflabel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/pproflabel"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabeler(t *testing.T) {
+	specs := []struct {
+		SpecName string
+		Options  []pproflabel.Option
+		Expected map[string]map[string]string
+	}{
+		{
+			SpecName: "resolvers",
+			Expected: map[string]map[string]string{
+				"Query.todos": {pproflabel.LabelOperation: "List", pproflabel.LabelField: "Query.todos"},
+				"Todo.id":     {pproflabel.LabelOperation: "List", pproflabel.LabelField: "Query.todos"},
+			},
+		},
+		{
+			SpecName: "every field",
+			Options: []pproflabel.Option{pproflabel.WithFieldFilter(func(fc *graphql.FieldContext) bool {
+				return true
+			})},
+			Expected: map[string]map[string]string{
+				"Query.todos": {pproflabel.LabelOperation: "List", pproflabel.LabelField: "Query.todos"},
+				"Todo.id":     {pproflabel.LabelOperation: "List", pproflabel.LabelField: "Todo.id"},
+			},
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			var mu sync.Mutex
+			labels := map[string]map[string]string{}
+
+			srv := handler.New(graph.NewExecutableSchema(graph.Config{
+				Resolvers: &graph.Resolver{},
+			}))
+			srv.AddTransport(transport.POST{})
+			srv.Use(pproflabel.New(spec.Options...))
+			srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+				fc := graphql.GetFieldContext(ctx)
+				set := map[string]string{}
+				pprof.ForLabels(ctx, func(key, value string) bool {
+					set[key] = value
+					return true
+				})
+				mu.Lock()
+				labels[fc.Object+"."+fc.Field.Name] = set
+				mu.Unlock()
+				return next(ctx)
+			})
+
+			resp := doRequest(srv, `{"query":"query List { todos { id } }"}`)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, spec.Expected, labels)
+		})
+	}
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
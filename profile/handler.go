@@ -0,0 +1,26 @@
+package profile
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+)
+
+// ProfilePath is the conventional path to serve Handler on, followed by the
+// timeline id.
+const ProfilePath = "/debug/graphql/profile/"
+
+// Handler serves the stored timeline whose id ends the request path as
+// JSON, see WithStorage. It is meant for an internal port only.
+func (p *Profiler) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tl, ok := p.Get(path.Base(r.URL.Path))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(tl)
+	})
+}
@@ -0,0 +1,51 @@
+package profile
+
+import "context"
+
+type config struct {
+	header     string
+	authorized func(ctx context.Context) bool
+	stored     int
+}
+
+// Option is anything that can configure Profiler.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		header:     Header,
+		authorized: func(ctx context.Context) bool { return false },
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithHeader sets the request header asking for a profile, Header by
+// default.
+func WithHeader(name string) Option {
+	return func(cfg *config) {
+		cfg.header = name
+	}
+}
+
+// WithAuthorizer sets which callers may profile their requests, all
+// requests asking for a profile being ignored otherwise. fn is called with
+// the operation context, once the auth middleware has run.
+func WithAuthorizer(fn func(ctx context.Context) bool) Option {
+	return func(cfg *config) {
+		cfg.authorized = fn
+	}
+}
+
+// WithStorage keeps the last n timelines in the Profiler, for Get and
+// Handler, the profile response extension only holding their id instead of
+// the whole timeline.
+func WithStorage(n int) Option {
+	return func(cfg *config) {
+		cfg.stored = n
+	}
+}
@@ -0,0 +1,208 @@
+// Package profile records the execution timeline of the requests asking
+// for it: when each resolver started and ended, and on which concurrency
+// lane, a small flame graph of the operation.
+package profile
+
+import (
+	"context"
+	"crypto/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Header is the request header asking for a profile, with a true value as
+// parsed by strconv.ParseBool.
+const Header = "X-GraphQL-Profile"
+
+// ExtensionKey is the response extension holding the profile.
+const ExtensionKey = "profile"
+
+// Timeline is the execution profile of an operation.
+type Timeline struct {
+	ID         string    `json:"id,omitempty"`
+	Operation  string    `json:"operation"`
+	Start      time.Time `json:"start"`
+	DurationMs float64   `json:"duration_ms"`
+	// Lanes is the number of resolvers that ran concurrently at most.
+	Lanes int    `json:"lanes"`
+	Spans []Span `json:"spans"`
+}
+
+// Span is the execution of a resolver. Spans running at the same time are on
+// different lanes.
+type Span struct {
+	Path       string  `json:"path"`
+	Object     string  `json:"object"`
+	Field      string  `json:"field"`
+	StartMs    float64 `json:"start_ms"`
+	DurationMs float64 `json:"duration_ms"`
+	Lane       int     `json:"lane"`
+	Error      string  `json:"error,omitempty"`
+
+	start, end time.Time
+}
+
+// recording is the timeline of an operation being profiled.
+type recording struct {
+	mu       sync.Mutex
+	timeline *Timeline
+}
+
+type recordingKey struct{}
+
+// Profiler is a gqlgen handler extension recording the Timeline of the
+// operations of authorized callers sending Header.
+type Profiler struct {
+	cfg *config
+
+	mu     sync.Mutex
+	stored map[string]*Timeline
+	order  []string
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Profiler{}
+
+// New returns a Profiler. No one may profile requests unless WithAuthorizer
+// is given.
+func New(opts ...Option) *Profiler {
+	return &Profiler{
+		cfg:    newConfig(opts...),
+		stored: map[string]*Timeline{},
+	}
+}
+
+// Get returns the stored timeline id, see WithStorage.
+func (p *Profiler) Get(id string) (*Timeline, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tl, ok := p.stored[id]
+	return tl, ok
+}
+
+func (p *Profiler) ExtensionName() string {
+	return "Profile"
+}
+
+func (p *Profiler) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (p *Profiler) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	oc := graphql.GetOperationContext(ctx)
+	if enabled, _ := strconv.ParseBool(oc.Headers.Get(p.cfg.header)); !enabled || !p.cfg.authorized(ctx) {
+		return next(ctx)
+	}
+
+	tl := &Timeline{
+		Operation: gqlctx.OperationName(oc),
+		Start:     oc.Stats.OperationStart,
+		Spans:     []Span{},
+	}
+
+	// The timeline is filled in once the operation is done, before the
+	// response is written.
+	if p.cfg.stored > 0 {
+		tl.ID = rand.Text()
+		graphql.RegisterExtension(ctx, ExtensionKey, map[string]string{"id": tl.ID})
+	} else {
+		graphql.RegisterExtension(ctx, ExtensionKey, tl)
+	}
+	rec := &recording{timeline: tl}
+
+	res := next(context.WithValue(ctx, recordingKey{}, rec))
+
+	rec.mu.Lock()
+	tl.DurationMs = milliseconds(time.Since(tl.Start))
+	tl.Lanes = assignLanes(tl.Spans, tl.Start)
+	rec.mu.Unlock()
+	if p.cfg.stored > 0 {
+		p.store(tl)
+	}
+
+	return res
+}
+
+func (p *Profiler) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	rec, _ := ctx.Value(recordingKey{}).(*recording)
+	if rec == nil {
+		return next(ctx)
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	span := Span{
+		Path:   fc.Path().String(),
+		Object: fc.Object,
+		Field:  fc.Field.Name,
+		start:  time.Now(),
+	}
+
+	res, err := next(ctx)
+
+	span.end = time.Now()
+	if err != nil {
+		span.Error = err.Error()
+	}
+	rec.mu.Lock()
+	rec.timeline.Spans = append(rec.timeline.Spans, span)
+	rec.mu.Unlock()
+
+	return res, err
+}
+
+func (p *Profiler) store(tl *Timeline) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stored[tl.ID] = tl
+	p.order = append(p.order, tl.ID)
+	for len(p.order) > p.cfg.stored {
+		delete(p.stored, p.order[0])
+		p.order = p.order[1:]
+	}
+}
+
+// assignLanes sorts spans by start and puts each of them on the first lane
+// free when it starts, returning the number of lanes used.
+func assignLanes(spans []Span, start time.Time) int {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start.Before(spans[j].start)
+	})
+
+	var lanes []time.Time
+	for i := range spans {
+		s := &spans[i]
+		s.StartMs = milliseconds(s.start.Sub(start))
+		s.DurationMs = milliseconds(s.end.Sub(s.start))
+
+		s.Lane = len(lanes)
+		for lane, end := range lanes {
+			if !end.After(s.start) {
+				s.Lane = lane
+				break
+			}
+		}
+		if s.Lane == len(lanes) {
+			lanes = append(lanes, s.end)
+		} else {
+			lanes[s.Lane] = s.end
+		}
+	}
+
+	return len(lanes)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
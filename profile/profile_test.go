@@ -0,0 +1,127 @@
+package profile_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/profile"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiler(t *testing.T) {
+	srv := newServer(profile.New(profile.WithAuthorizer(admin)))
+
+	t.Run("not asked", func(t *testing.T) {
+		resp := doRequest(srv, `{"query":"{ todos { id } }"}`, map[string]string{"X-Role": "admin"})
+		assert.NotContains(t, resp.Body.String(), "extensions")
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		resp := doRequest(srv, `{"query":"{ todos { id } }"}`, map[string]string{profile.Header: "1"})
+		assert.NotContains(t, resp.Body.String(), "extensions")
+	})
+
+	t.Run("timeline", func(t *testing.T) {
+		resp := doRequest(srv, `{"query":"query List { todos { id } }"}`, map[string]string{profile.Header: "1", "X-Role": "admin"})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var body struct {
+			Extensions struct {
+				Profile profile.Timeline `json:"profile"`
+			} `json:"extensions"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+		tl := body.Extensions.Profile
+		assert.Equal(t, "List", tl.Operation)
+		assert.GreaterOrEqual(t, tl.DurationMs, 10.0)
+		// The ids of the todos resolve concurrently.
+		assert.Greater(t, tl.Lanes, 1)
+		require.Len(t, tl.Spans, 4)
+		assert.Equal(t, "todos", tl.Spans[0].Path)
+		assert.Equal(t, 0, tl.Spans[0].Lane)
+		for _, span := range tl.Spans[1:] {
+			assert.Equal(t, "Todo", span.Object)
+			assert.Equal(t, "id", span.Field)
+			assert.GreaterOrEqual(t, span.StartMs, tl.Spans[0].StartMs+tl.Spans[0].DurationMs)
+			assert.GreaterOrEqual(t, span.DurationMs, 10.0)
+		}
+	})
+}
+
+func TestProfiler_Storage(t *testing.T) {
+	profiler := profile.New(profile.WithAuthorizer(admin), profile.WithStorage(1))
+	srv := newServer(profiler)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		resp := doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`, map[string]string{profile.Header: "true", "X-Role": "admin"})
+		var body struct {
+			Extensions struct {
+				Profile map[string]string `json:"profile"`
+			} `json:"extensions"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		require.NotEmpty(t, body.Extensions.Profile["id"])
+		ids = append(ids, body.Extensions.Profile["id"])
+	}
+
+	_, ok := profiler.Get(ids[0])
+	assert.False(t, ok, "evicted")
+
+	r := httptest.NewRequest(http.MethodGet, profile.ProfilePath+ids[1], nil)
+	w := httptest.NewRecorder()
+	profiler.Handler().ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var tl profile.Timeline
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tl))
+	assert.Equal(t, ids[1], tl.ID)
+	require.Len(t, tl.Spans, 1)
+	assert.Equal(t, graph.ErrTodoNotFound.Error(), tl.Spans[0].Error)
+
+	r = httptest.NewRequest(http.MethodGet, profile.ProfilePath+ids[0], nil)
+	w = httptest.NewRecorder()
+	profiler.Handler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func admin(ctx context.Context) bool {
+	return graphql.GetOperationContext(ctx).Headers.Get("X-Role") == "admin"
+}
+
+func newServer(profiler *profile.Profiler) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(profiler)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		if graphql.GetFieldContext(ctx).Object == "Todo" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return next(ctx)
+	})
+	return srv
+}
+
+func doRequest(handler http.Handler, body string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,9 @@
+package prometheus
+
+import "github.com/99designs/gqlgen-contrib/internal/labelguard"
+
+// overflowLabelValue replaces label values rejected by a guard.
+const overflowLabelValue = labelguard.OverflowValue
+
+// tenantOverflowLabelValue replaces tenants beyond the WithMaxTenants cap.
+const tenantOverflowLabelValue = "other"
@@ -0,0 +1,110 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/dataloader"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// LoaderMetrics is a dataloader.Observer recording the batch sizes, cache
+// hits and misses and fetch durations of loaders, labeled by loader name:
+//
+//	metrics := prometheus.NewLoaderMetrics()
+//	var userLoader = dataloader.Register(fetchUsers,
+//		dataloader.WithName("user"),
+//		dataloader.WithObserver(metrics),
+//	)
+type LoaderMetrics struct {
+	batchSize    *prometheusclient.HistogramVec
+	loadDuration durationHistograms
+	cacheHits    *prometheusclient.CounterVec
+	cacheMisses  *prometheusclient.CounterVec
+	registerer   prometheusclient.Registerer
+}
+
+var _ dataloader.Observer = (*LoaderMetrics)(nil)
+
+// NewLoaderMetrics returns LoaderMetrics built from opts and registered on
+// the configured registerer. The operation name options do not apply.
+func NewLoaderMetrics(opts ...Option) *LoaderMetrics {
+	cfg := newConfig(opts...)
+
+	m := &LoaderMetrics{registerer: cfg.registerer}
+
+	m.batchSize = newHistogramVec(cfg, prometheusclient.HistogramOpts{
+		Namespace:   cfg.namespace,
+		Subsystem:   cfg.subsystem,
+		Name:        "graphql_dataloader_batch_size",
+		Help:        "The number of keys fetched per dataloader batch.",
+		Buckets:     cfg.batchSizeBuckets,
+		ConstLabels: cfg.constLabels,
+	}, []string{"loader"})
+
+	m.loadDuration = newDurationHistograms(cfg,
+		"graphql_dataloader_load_duration",
+		"The time taken by a dataloader to fetch a batch.",
+		[]string{"exitStatus", "loader"},
+	)
+
+	m.cacheHits = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_dataloader_cache_hits_total",
+			Help:        "Total number of dataloader loads served from the request cache.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"loader"},
+	)
+
+	m.cacheMisses = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_dataloader_cache_misses_total",
+			Help:        "Total number of dataloader loads that had to be fetched.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"loader"},
+	)
+
+	cfg.registerer.MustRegister(m.collectors()...)
+
+	return m
+}
+
+func (m *LoaderMetrics) collectors() []prometheusclient.Collector {
+	return append([]prometheusclient.Collector{
+		m.batchSize,
+		m.cacheHits,
+		m.cacheMisses,
+	}, m.loadDuration.collectors()...)
+}
+
+// UnRegister removes the metrics from the registerer they were registered on.
+func (m *LoaderMetrics) UnRegister() {
+	for _, c := range m.collectors() {
+		m.registerer.Unregister(c)
+	}
+}
+
+func (m *LoaderMetrics) ObserveLoad(name string, hit bool) {
+	if hit {
+		m.cacheHits.WithLabelValues(name).Inc()
+	} else {
+		m.cacheMisses.WithLabelValues(name).Inc()
+	}
+}
+
+func (m *LoaderMetrics) ObserveBatch(name string, size int, duration time.Duration, err error) {
+	exitStatus := exitStatusSuccess
+	if err != nil {
+		exitStatus = existStatusFailure
+	}
+
+	m.batchSize.WithLabelValues(name).Observe(float64(size))
+	// Batches are dispatched outside of any request, without a trace to link.
+	m.loadDuration.observe(context.Background(), duration, exitStatus, name)
+}
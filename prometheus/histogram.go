@@ -0,0 +1,139 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExemplarFunc returns the exemplar labels of observations made in ctx, nil
+// for none.
+type ExemplarFunc func(ctx context.Context) prometheusclient.Labels
+
+// TraceExemplar is an ExemplarFunc linking observations to the sampled
+// OpenTelemetry span of ctx, such as those of the otel package, with
+// trace_id and span_id labels.
+func TraceExemplar(ctx context.Context) prometheusclient.Labels {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return nil
+	}
+	return prometheusclient.Labels{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	}
+}
+
+// newHistogramVec returns a histogram applying the native histogram options
+// to opts.
+func newHistogramVec(cfg *config, opts prometheusclient.HistogramOpts, labels []string) *prometheusclient.HistogramVec {
+	if cfg.nativeBucketFactor > 1 {
+		opts.NativeHistogramBucketFactor = cfg.nativeBucketFactor
+		opts.NativeHistogramMaxBucketNumber = cfg.nativeMaxBuckets
+		if cfg.nativeMaxBuckets > 0 {
+			opts.NativeHistogramMinResetDuration = time.Hour
+		}
+		if !cfg.classicBuckets {
+			opts.Buckets = nil
+		}
+	}
+	return prometheusclient.NewHistogramVec(opts, labels)
+}
+
+// durationHistograms records a duration in the legacy _ms histogram and the
+// _seconds one, each only if enabled.
+type durationHistograms struct {
+	ms       *prometheusclient.HistogramVec
+	seconds  *prometheusclient.HistogramVec
+	exemplar ExemplarFunc
+}
+
+// newDurationHistograms returns the histograms named name followed by _ms
+// and _seconds, the buckets of the latter being cfg.buckets in seconds.
+func newDurationHistograms(cfg *config, name, help string, labels []string) durationHistograms {
+	h := durationHistograms{exemplar: cfg.exemplar}
+	if cfg.milliseconds {
+		h.ms = newHistogramVec(cfg, prometheusclient.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        name + "_ms",
+			Help:        help,
+			Buckets:     cfg.buckets,
+			ConstLabels: cfg.constLabels,
+		}, labels)
+	}
+	if cfg.seconds {
+		buckets := make([]float64, len(cfg.buckets))
+		for i, bucket := range cfg.buckets {
+			buckets[i] = bucket / 1000
+		}
+		h.seconds = newHistogramVec(cfg, prometheusclient.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        name + "_seconds",
+			Help:        help,
+			Buckets:     buckets,
+			ConstLabels: cfg.constLabels,
+		}, labels)
+	}
+	return h
+}
+
+func (h durationHistograms) observe(ctx context.Context, d time.Duration, labels ...string) {
+	h.with(labels...).observe(ctx, d)
+}
+
+// with returns the observers of the series of labels, to observe durations
+// without looking them up again.
+func (h durationHistograms) with(labels ...string) durationObservers {
+	o := durationObservers{exemplar: h.exemplar}
+	if h.ms != nil {
+		o.ms = h.ms.WithLabelValues(labels...)
+	}
+	if h.seconds != nil {
+		o.seconds = h.seconds.WithLabelValues(labels...)
+	}
+	return o
+}
+
+// durationObservers are the series of a durationHistograms for given label
+// values.
+type durationObservers struct {
+	ms       prometheusclient.Observer
+	seconds  prometheusclient.Observer
+	exemplar ExemplarFunc
+}
+
+func (o durationObservers) observe(ctx context.Context, d time.Duration) {
+	var exemplar prometheusclient.Labels
+	if o.exemplar != nil {
+		exemplar = o.exemplar(ctx)
+	}
+	if o.ms != nil {
+		observe(o.ms, float64(d.Nanoseconds()/int64(time.Millisecond)), exemplar)
+	}
+	if o.seconds != nil {
+		observe(o.seconds, d.Seconds(), exemplar)
+	}
+}
+
+func observe(o prometheusclient.Observer, v float64, exemplar prometheusclient.Labels) {
+	if eo, ok := o.(prometheusclient.ExemplarObserver); ok && exemplar != nil {
+		eo.ObserveWithExemplar(v, exemplar)
+		return
+	}
+	o.Observe(v)
+}
+
+func (h durationHistograms) collectors() []prometheusclient.Collector {
+	var collectors []prometheusclient.Collector
+	if h.ms != nil {
+		collectors = append(collectors, h.ms)
+	}
+	if h.seconds != nil {
+		collectors = append(collectors, h.seconds)
+	}
+	return collectors
+}
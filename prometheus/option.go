@@ -0,0 +1,349 @@
+package prometheus
+
+import (
+	"context"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	namespace   string
+	subsystem   string
+	buckets     []float64
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+
+	complexityBuckets []float64
+	batchSizeBuckets  []float64
+
+	subscriptionBuckets []float64
+
+	nativeBucketFactor float64
+	nativeMaxBuckets   uint32
+	classicBuckets     bool
+	milliseconds       bool
+	seconds            bool
+	exemplar           ExemplarFunc
+
+	operationNameAllowlist []string
+	maxOperationNames      int
+	maxClients             int
+	maxFields              int
+	hashNameless           bool
+	signatureLabel         bool
+	signatures             *normalize.Table
+
+	tenant     func(ctx context.Context) string
+	maxTenants int
+
+	errorCode   func(err error) string
+	cancelled   bool
+	classifier  contrib.Classifier
+	fieldFilter func(fc *graphql.FieldContext) bool
+
+	enricher       contrib.Enricher
+	enricherLabels []string
+}
+
+// defaultMaxOperationNames caps the operation_name label unless configured
+// otherwise with WithMaxOperationNames or WithOperationNameAllowlist.
+const defaultMaxOperationNames = 100
+
+// defaultMaxTenants caps the tenant label unless configured otherwise with
+// WithMaxTenants.
+const defaultMaxTenants = 100
+
+// defaultMaxClients caps the client_name and client_version labels unless
+// configured otherwise with WithMaxClients.
+const defaultMaxClients = 50
+
+// defaultMaxFields caps the (object, field) pairs of the resolver metrics
+// unless configured otherwise with WithMaxFields.
+const defaultMaxFields = 1000
+
+// Option is anything that can configure Tracer.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		buckets:    prometheusclient.ExponentialBuckets(1, 2, 11),
+		registerer: prometheusclient.DefaultRegisterer,
+
+		complexityBuckets: prometheusclient.ExponentialBuckets(1, 2, 12),
+		batchSizeBuckets:  prometheusclient.ExponentialBuckets(1, 2, 8),
+
+		subscriptionBuckets: prometheusclient.ExponentialBuckets(1, 4, 8),
+
+		classicBuckets: true,
+		milliseconds:   true,
+
+		maxOperationNames: defaultMaxOperationNames,
+		maxClients:        defaultMaxClients,
+		maxFields:         defaultMaxFields,
+		maxTenants:        defaultMaxTenants,
+
+		errorCode:   ExtensionErrorCode,
+		fieldFilter: resolvedField,
+		classifier:  contrib.Classify,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithNamespace prefixes every metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithBuckets sets the buckets of the request and resolver duration
+// histograms, in milliseconds.
+func WithBuckets(buckets []float64) Option {
+	return func(cfg *config) {
+		cfg.buckets = buckets
+	}
+}
+
+// WithComplexityBuckets sets the buckets of the operation complexity
+// histogram.
+func WithComplexityBuckets(buckets []float64) Option {
+	return func(cfg *config) {
+		cfg.complexityBuckets = buckets
+	}
+}
+
+// WithBatchSizeBuckets sets the buckets of the dataloader batch size
+// histogram, see NewLoaderMetrics.
+func WithBatchSizeBuckets(buckets []float64) Option {
+	return func(cfg *config) {
+		cfg.batchSizeBuckets = buckets
+	}
+}
+
+// WithSubscriptionBuckets sets the buckets of the subscription duration
+// histogram, in seconds.
+func WithSubscriptionBuckets(buckets []float64) Option {
+	return func(cfg *config) {
+		cfg.subscriptionBuckets = buckets
+	}
+}
+
+// WithNativeHistograms makes every histogram a native histogram too, whose
+// sparse buckets grow by at most factor, for instance 1.1, from one to the
+// next. Classic buckets are kept for scrapers not negotiating native
+// histograms, unless WithoutClassicBuckets is given.
+func WithNativeHistograms(factor float64) Option {
+	return func(cfg *config) {
+		cfg.nativeBucketFactor = factor
+	}
+}
+
+// WithNativeHistogramMaxBuckets bounds the number of sparse buckets of each
+// native histogram series, which are reset at most hourly when reached.
+func WithNativeHistogramMaxBuckets(n uint32) Option {
+	return func(cfg *config) {
+		cfg.nativeMaxBuckets = n
+	}
+}
+
+// WithoutClassicBuckets only exposes native histograms, along with
+// WithNativeHistograms.
+func WithoutClassicBuckets() Option {
+	return func(cfg *config) {
+		cfg.classicBuckets = false
+	}
+}
+
+// WithSecondsHistograms records request, resolver and dataloader durations
+// in graphql_request_duration_seconds, graphql_resolver_duration_seconds and
+// graphql_dataloader_load_duration_seconds too, following the Prometheus
+// naming conventions. Their buckets are those of WithBuckets in seconds.
+func WithSecondsHistograms() Option {
+	return func(cfg *config) {
+		cfg.seconds = true
+	}
+}
+
+// WithoutMillisecondHistograms stops recording the legacy _ms duration
+// histograms, once dashboards moved to those of WithSecondsHistograms.
+func WithoutMillisecondHistograms() Option {
+	return func(cfg *config) {
+		cfg.milliseconds = false
+	}
+}
+
+// WithExemplars attaches the labels returned by fn as exemplars to the
+// request and resolver duration observations, for instance
+// TraceExemplar to jump from a latency spike to one of its traces. Register
+// the otel extension before the Tracer for spans to be in the context of
+// observations. Exemplars are only exposed in the OpenMetrics format, see
+// promhttp.HandlerOpts.EnableOpenMetrics.
+func WithExemplars(fn ExemplarFunc) Option {
+	return func(cfg *config) {
+		cfg.exemplar = fn
+	}
+}
+
+// WithConstLabels attaches the given labels to every metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
+
+// WithOperationNameAllowlist restricts the operation_name label to the given
+// names. Any other named operation is reported as "__overflow__".
+func WithOperationNameAllowlist(names ...string) Option {
+	return func(cfg *config) {
+		cfg.operationNameAllowlist = names
+	}
+}
+
+// WithMaxOperationNames caps the number of distinct operation_name label
+// values. Names first seen after the cap is reached are reported as
+// "__overflow__". A value of 0 or less disables the cap.
+func WithMaxOperationNames(max int) Option {
+	return func(cfg *config) {
+		cfg.maxOperationNames = max
+	}
+}
+
+// WithNamelessOperationHash sets the operation_name label of operations
+// without a name to "nameless-" followed by the first 12 hex digits of their
+// normalize signature, instead of leaving it empty, so they can be told
+// apart. These values count against WithMaxOperationNames, and are recorded
+// in the table of WithOperationSignature if given.
+func WithNamelessOperationHash() Option {
+	return func(cfg *config) {
+		cfg.hashNameless = true
+	}
+}
+
+// WithOperationSignature adds an operation_signature label to the request
+// metrics, holding the normalize signature of the operation, so operations
+// aggregate by shape whether they are named or not. Signatures are recorded
+// in table, which may be nil, for them to be looked up. Like operation
+// names, their values are capped by WithMaxOperationNames.
+func WithOperationSignature(table *normalize.Table) Option {
+	return func(cfg *config) {
+		cfg.signatureLabel = true
+		cfg.signatures = table
+	}
+}
+
+// WithMaxClients caps the number of distinct client_name and client_version
+// label values, which clients choose freely. Values first seen after the cap
+// is reached are reported as "__overflow__". A value of 0 or less disables
+// the cap.
+func WithMaxClients(max int) Option {
+	return func(cfg *config) {
+		cfg.maxClients = max
+	}
+}
+
+// WithFieldFilter selects the fields whose resolution is measured. By
+// default only fields bound to a resolver or a method are, skipping the
+// many cheap struct field lookups.
+func WithFieldFilter(fn func(fc *graphql.FieldContext) bool) Option {
+	return func(cfg *config) {
+		cfg.fieldFilter = fn
+	}
+}
+
+func resolvedField(fc *graphql.FieldContext) bool {
+	return fc.IsResolver || fc.IsMethod
+}
+
+// WithMaxFields caps the number of distinct (object, field) label pairs of
+// the resolver metrics, 1000 by default. Fields first resolved after the cap
+// is reached are reported with both labels set to "__overflow__". A value of
+// 0 or less disables the cap.
+func WithMaxFields(max int) Option {
+	return func(cfg *config) {
+		cfg.maxFields = max
+	}
+}
+
+// WithTenantLabel adds a tenant label, valued by fn, to the request and
+// resolver metrics. Tenants first seen after the WithMaxTenants cap is
+// reached are reported as "other".
+func WithTenantLabel(fn func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.tenant = fn
+	}
+}
+
+// WithMaxTenants caps the number of distinct tenant label values, 100 by
+// default. A value of 0 or less disables the cap.
+func WithMaxTenants(max int) Option {
+	return func(cfg *config) {
+		cfg.maxTenants = max
+	}
+}
+
+// WithErrorCode sets how the err_code label of resolver metrics is derived
+// from the error a resolver returned, typically errcode.Presenter.Code so
+// it matches the code clients see. Defaults to ExtensionErrorCode.
+func WithErrorCode(fn func(err error) string) Option {
+	return func(cfg *config) {
+		cfg.errorCode = fn
+	}
+}
+
+// WithCancellationStatus sets the exitStatus label of failed requests and
+// resolvers whose context was canceled, typically by a client disconnect, to
+// "cancelled", and to "deadline_exceeded" for those that ran out of time,
+// instead of "failure".
+func WithCancellationStatus() Option {
+	return func(cfg *config) {
+		cfg.cancelled = true
+	}
+}
+
+// WithClassifier sets how the outcome label of
+// graphql_request_outcomes_total is derived from the response errors,
+// contrib.Classify by default.
+func WithClassifier(fn contrib.Classifier) Option {
+	return func(cfg *config) {
+		cfg.classifier = fn
+	}
+}
+
+// WithEnricher adds the given labels, valued by fn, to the request metrics.
+// Labels fn does not return are left empty, and the ones it returns beyond
+// labels are ignored. Every distinct value creates new series, so fn should
+// only return values from a small set. Calling it again adds more labels.
+func WithEnricher(fn contrib.Enricher, labels ...string) Option {
+	return func(cfg *config) {
+		if cfg.enricher == nil {
+			cfg.enricher = fn
+		} else {
+			cfg.enricher = contrib.Enrichers(cfg.enricher, fn)
+		}
+		cfg.enricherLabels = append(cfg.enricherLabels, labels...)
+	}
+}
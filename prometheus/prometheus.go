@@ -2,146 +2,718 @@ package prometheus
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/incremental"
+	"github.com/99designs/gqlgen-contrib/internal/labelguard"
+	"github.com/99designs/gqlgen-contrib/normalize"
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
 	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 const (
-	existStatusFailure = "failure"
-	exitStatusSuccess  = "success"
+	existStatusFailure         = "failure"
+	exitStatusSuccess          = "success"
+	exitStatusCancelled        = "cancelled"
+	exitStatusDeadlineExceeded = "deadline_exceeded"
+
+	namelessPrefix = "nameless-"
 )
 
-var (
-	requestStartedCounter    prometheusclient.Counter
-	requestCompletedCounter  prometheusclient.Counter
+type metrics struct {
+	requestStartedCounter    *prometheusclient.CounterVec
+	requestCompletedCounter  *prometheusclient.CounterVec
 	resolverStartedCounter   *prometheusclient.CounterVec
 	resolverCompletedCounter *prometheusclient.CounterVec
-	timeToResolveField       *prometheusclient.HistogramVec
-	timeToHandleRequest      *prometheusclient.HistogramVec
-)
+	timeToResolveField       durationHistograms
+	timeToHandleRequest      durationHistograms
+	operationComplexity      *prometheusclient.HistogramVec
+	complexityLimitExceeded  *prometheusclient.CounterVec
+	requestsCancelled        *prometheusclient.CounterVec
+	requestOutcomes          *prometheusclient.CounterVec
+	requestErrors            *prometheusclient.CounterVec
+	requestsInFlight         prometheusclient.Gauge
+	subscriptionsActive      *prometheusclient.GaugeVec
+	subscriptionEvents       *prometheusclient.CounterVec
+	subscriptionErrors       *prometheusclient.CounterVec
+	subscriptionDuration     *prometheusclient.HistogramVec
+	clientRequests           *prometheusclient.CounterVec
+	incrementalPatches       *prometheusclient.HistogramVec
+	incrementalPatchErrors   *prometheusclient.CounterVec
+	timeToFirstResponse      durationHistograms
+	timeToLastPatch          durationHistograms
+	seriesDropped            *prometheusclient.CounterVec
+
+	enricher       contrib.Enricher
+	enricherLabels []string
+
+	tenant         func(ctx context.Context) string
+	tenants        *labelguard.Guard
+	operationNames *labelguard.Guard
+	hashNameless   bool
+	signatureLabel bool
+	signatures     *normalize.Table
+	signatureGuard *labelguard.Guard
+	clientNames    *labelguard.Guard
+	clientVersions *labelguard.Guard
+	fields         *labelguard.Guard
+	fieldFilter    func(fc *graphql.FieldContext) bool
+	fieldCache     sync.Map // fieldKey -> *fieldMetrics
+	errorCode      func(err error) string
+	cancelled      bool
+	classifier     contrib.Classifier
+}
+
+// defaultMetrics backs the zero value of Tracer, see Register.
+var defaultMetrics *metrics
+
+// Tracer is a gqlgen handler extension recording request and resolver
+// metrics. Attach it with srv.Use(prometheus.Tracer{}) after calling Register,
+// or build a configured one with New.
+type Tracer struct {
+	metrics    *metrics
+	registerer prometheusclient.Registerer
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+// New returns a Tracer whose metrics are built from opts and registered on
+// the configured registerer.
+func New(opts ...Option) Tracer {
+	cfg := newConfig(opts...)
+	m := newMetrics(cfg)
+	cfg.registerer.MustRegister(m.collectors()...)
+
+	return Tracer{
+		metrics:    m,
+		registerer: cfg.registerer,
+	}
+}
 
 func Register() {
 	RegisterOn(prometheusclient.DefaultRegisterer)
 }
 
 func RegisterOn(registerer prometheusclient.Registerer) {
-	requestStartedCounter = prometheusclient.NewCounter(
+	defaultMetrics = newMetrics(newConfig())
+	registerer.MustRegister(defaultMetrics.collectors()...)
+}
+
+func UnRegister() {
+	UnRegisterFrom(prometheusclient.DefaultRegisterer)
+}
+
+func UnRegisterFrom(registerer prometheusclient.Registerer) {
+	defaultMetrics.unregisterFrom(registerer)
+}
+
+// UnRegister removes the metrics of a Tracer built by New from the
+// registerer they were registered on.
+func (a Tracer) UnRegister() {
+	if a.metrics == nil {
+		return
+	}
+	a.metrics.unregisterFrom(a.registerer)
+}
+
+func newMetrics(cfg *config) *metrics {
+	m := &metrics{
+		enricher:       cfg.enricher,
+		enricherLabels: cfg.enricherLabels,
+		tenant:         cfg.tenant,
+		tenants:        labelguard.New(nil, cfg.maxTenants),
+		operationNames: labelguard.New(cfg.operationNameAllowlist, cfg.maxOperationNames),
+		hashNameless:   cfg.hashNameless,
+		signatureLabel: cfg.signatureLabel,
+		signatures:     cfg.signatures,
+		signatureGuard: labelguard.New(nil, cfg.maxOperationNames),
+		clientNames:    labelguard.New(nil, cfg.maxClients),
+		clientVersions: labelguard.New(nil, cfg.maxClients),
+		fields:         labelguard.New(nil, cfg.maxFields),
+		fieldFilter:    cfg.fieldFilter,
+		errorCode:      cfg.errorCode,
+		cancelled:      cfg.cancelled,
+		classifier:     cfg.classifier,
+	}
+	m.tenants.Overflow = tenantOverflowLabelValue
+
+	m.seriesDropped = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_metrics_series_dropped_total",
+			Help:        "Total number of label values reported as overflow to bound the cardinality of the graphql metrics.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"label"},
+	)
+	for label, guard := range map[string]*labelguard.Guard{
+		"operation_name":      m.operationNames,
+		"operation_signature": m.signatureGuard,
+		"client_name":         m.clientNames,
+		"client_version":      m.clientVersions,
+		"tenant":              m.tenants,
+		"field":               m.fields,
+	} {
+		guard.Dropped = m.seriesDropped.WithLabelValues(label)
+	}
+
+	m.requestStartedCounter = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_request_started_total",
+			Help:        "Total number of requests started on the graphql server.",
+			ConstLabels: cfg.constLabels,
+		},
+		requestLabels(cfg, "operation_name", "operation_type"),
+	)
+
+	m.requestCompletedCounter = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_request_completed_total",
+			Help:        "Total number of requests completed on the graphql server.",
+			ConstLabels: cfg.constLabels,
+		},
+		requestLabels(cfg, "operation_name", "operation_type"),
+	)
+
+	m.resolverStartedCounter = prometheusclient.NewCounterVec(
 		prometheusclient.CounterOpts{
-			Name: "graphql_request_started_total",
-			Help: "Total number of requests started on the graphql server.",
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_resolver_started_total",
+			Help:        "Total number of resolver started on the graphql server.",
+			ConstLabels: cfg.constLabels,
 		},
+		resolverLabels(cfg, "object", "field"),
 	)
 
-	requestCompletedCounter = prometheusclient.NewCounter(
+	m.resolverCompletedCounter = prometheusclient.NewCounterVec(
 		prometheusclient.CounterOpts{
-			Name: "graphql_request_completed_total",
-			Help: "Total number of requests completed on the graphql server.",
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_resolver_completed_total",
+			Help:        "Total number of resolver completed on the graphql server.",
+			ConstLabels: cfg.constLabels,
 		},
+		resolverLabels(cfg, "object", "field"),
 	)
 
-	resolverStartedCounter = prometheusclient.NewCounterVec(
+	m.timeToResolveField = newDurationHistograms(cfg,
+		"graphql_resolver_duration",
+		"The time taken to resolve a field by graphql server.",
+		resolverLabels(cfg, "exitStatus", "err_code", "object", "field"),
+	)
+
+	m.timeToHandleRequest = newDurationHistograms(cfg,
+		"graphql_request_duration",
+		"The time taken to handle a request by graphql server.",
+		requestLabels(cfg, "exitStatus", "err_code", "operation_name", "operation_type"),
+	)
+
+	m.operationComplexity = newHistogramVec(cfg, prometheusclient.HistogramOpts{
+		Namespace:   cfg.namespace,
+		Subsystem:   cfg.subsystem,
+		Name:        "graphql_operation_complexity",
+		Help:        "The complexity of operations computed by the complexity limit extension.",
+		Buckets:     cfg.complexityBuckets,
+		ConstLabels: cfg.constLabels,
+	}, []string{"operation_name", "operation_type"})
+
+	m.complexityLimitExceeded = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_complexity_limit_exceeded_total",
+			Help:        "Total number of operations rejected for exceeding the complexity limit.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"operation_name", "operation_type"},
+	)
+
+	m.requestsCancelled = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_request_cancelled_total",
+			Help:        "Total number of requests whose context was canceled or exceeded its deadline before they completed.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"operation_name", "operation_type", "reason"},
+	)
+
+	m.requestOutcomes = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_request_outcomes_total",
+			Help:        "Total number of requests completed on the graphql server by outcome, telling user errors from internal ones.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"operation_name", "operation_type", "outcome"},
+	)
+
+	m.requestErrors = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_request_errors_total",
+			Help:        "Total number of errors returned in responses of the graphql server.",
+			ConstLabels: cfg.constLabels,
+		},
+		requestLabels(cfg, "error_code", "operation_name"),
+	)
+
+	m.requestsInFlight = prometheusclient.NewGauge(prometheusclient.GaugeOpts{
+		Namespace:   cfg.namespace,
+		Subsystem:   cfg.subsystem,
+		Name:        "graphql_requests_in_flight",
+		Help:        "Number of operations currently being handled by the graphql server.",
+		ConstLabels: cfg.constLabels,
+	})
+
+	m.subscriptionsActive = prometheusclient.NewGaugeVec(prometheusclient.GaugeOpts{
+		Namespace:   cfg.namespace,
+		Subsystem:   cfg.subsystem,
+		Name:        "graphql_subscriptions_active",
+		Help:        "Number of subscriptions currently open on the graphql server.",
+		ConstLabels: cfg.constLabels,
+	}, []string{"operation_name"})
+
+	m.subscriptionEvents = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_subscription_events_total",
+			Help:        "Total number of subscription events delivered by the graphql server.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"operation_name"},
+	)
+
+	m.subscriptionErrors = prometheusclient.NewCounterVec(
 		prometheusclient.CounterOpts{
-			Name: "graphql_resolver_started_total",
-			Help: "Total number of resolver started on the graphql server.",
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_subscription_errors_total",
+			Help:        "Total number of subscription events with errors and of websocket transport errors.",
+			ConstLabels: cfg.constLabels,
 		},
-		[]string{"object", "field"},
+		[]string{"operation_name"},
 	)
 
-	resolverCompletedCounter = prometheusclient.NewCounterVec(
+	m.subscriptionDuration = newHistogramVec(cfg, prometheusclient.HistogramOpts{
+		Namespace:   cfg.namespace,
+		Subsystem:   cfg.subsystem,
+		Name:        "graphql_subscription_duration_seconds",
+		Help:        "The time subscriptions stayed open on the graphql server.",
+		Buckets:     cfg.subscriptionBuckets,
+		ConstLabels: cfg.constLabels,
+	}, []string{"operation_name"})
+
+	m.clientRequests = prometheusclient.NewCounterVec(
 		prometheusclient.CounterOpts{
-			Name: "graphql_resolver_completed_total",
-			Help: "Total number of resolver completed on the graphql server.",
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_client_requests_total",
+			Help:        "Total number of requests completed on the graphql server per client, see the clientinfo package.",
+			ConstLabels: cfg.constLabels,
 		},
-		[]string{"object", "field"},
+		[]string{"client_name", "client_version"},
 	)
 
-	timeToResolveField = prometheusclient.NewHistogramVec(prometheusclient.HistogramOpts{
-		Name:    "graphql_resolver_duration_ms",
-		Help:    "The time taken to resolve a field by graphql server.",
-		Buckets: prometheusclient.ExponentialBuckets(1, 2, 11),
-	}, []string{"exitStatus", "object", "field"})
+	m.incrementalPatches = newHistogramVec(cfg, prometheusclient.HistogramOpts{
+		Namespace:   cfg.namespace,
+		Subsystem:   cfg.subsystem,
+		Name:        "graphql_incremental_patches",
+		Help:        "The number of patches delivered after the initial payload of operations using @defer.",
+		Buckets:     []float64{1, 2, 3, 5, 10, 25, 50, 100},
+		ConstLabels: cfg.constLabels,
+	}, []string{"operation_name"})
+
+	m.incrementalPatchErrors = prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_incremental_patch_errors_total",
+			Help:        "Total number of patches with errors delivered by operations using @defer.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"operation_name"},
+	)
 
-	timeToHandleRequest = prometheusclient.NewHistogramVec(prometheusclient.HistogramOpts{
-		Name:    "graphql_request_duration_ms",
-		Help:    "The time taken to handle a request by graphql server.",
-		Buckets: prometheusclient.ExponentialBuckets(1, 2, 11),
-	}, []string{"exitStatus"})
+	m.timeToFirstResponse = newDurationHistograms(cfg,
+		"graphql_incremental_first_response_duration",
+		"The time taken to deliver the initial payload of operations using @defer.",
+		[]string{"operation_name"},
+	)
 
-	registerer.MustRegister(
-		requestStartedCounter,
-		requestCompletedCounter,
-		resolverStartedCounter,
-		resolverCompletedCounter,
-		timeToResolveField,
-		timeToHandleRequest,
+	m.timeToLastPatch = newDurationHistograms(cfg,
+		"graphql_incremental_last_patch_duration",
+		"The time taken to deliver the last patch of operations using @defer.",
+		[]string{"operation_name"},
 	)
+
+	return m
 }
 
-func UnRegister() {
-	UnRegisterFrom(prometheusclient.DefaultRegisterer)
+// requestLabels returns the label names of a request metric: base, then the
+// signature, tenant and enricher labels.
+func requestLabels(cfg *config, base ...string) []string {
+	if cfg.signatureLabel {
+		base = append(base, "operation_signature")
+	}
+	return append(resolverLabels(cfg, base...), cfg.enricherLabels...)
 }
 
-func UnRegisterFrom(registerer prometheusclient.Registerer) {
-	registerer.Unregister(requestStartedCounter)
-	registerer.Unregister(requestCompletedCounter)
-	registerer.Unregister(resolverStartedCounter)
-	registerer.Unregister(resolverCompletedCounter)
-	registerer.Unregister(timeToResolveField)
-	registerer.Unregister(timeToHandleRequest)
+// resolverLabels returns the label names of a resolver metric: base, then
+// the tenant label.
+func resolverLabels(cfg *config, base ...string) []string {
+	if cfg.tenant != nil {
+		base = append(base, "tenant")
+	}
+	return base
 }
 
-func ResolverMiddleware() graphql.FieldMiddleware {
-	return func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
-		rctx := graphql.GetResolverContext(ctx)
+func (m *metrics) collectors() []prometheusclient.Collector {
+	collectors := []prometheusclient.Collector{
+		m.requestStartedCounter,
+		m.requestCompletedCounter,
+		m.resolverStartedCounter,
+		m.resolverCompletedCounter,
+		m.operationComplexity,
+		m.complexityLimitExceeded,
+		m.requestsCancelled,
+		m.requestOutcomes,
+		m.requestErrors,
+		m.requestsInFlight,
+		m.subscriptionsActive,
+		m.subscriptionEvents,
+		m.subscriptionErrors,
+		m.subscriptionDuration,
+		m.clientRequests,
+		m.incrementalPatches,
+		m.incrementalPatchErrors,
+		m.seriesDropped,
+	}
+	collectors = append(collectors, m.timeToResolveField.collectors()...)
+	collectors = append(collectors, m.timeToFirstResponse.collectors()...)
+	collectors = append(collectors, m.timeToLastPatch.collectors()...)
+	return append(collectors, m.timeToHandleRequest.collectors()...)
+}
+
+func (m *metrics) unregisterFrom(registerer prometheusclient.Registerer) {
+	for _, c := range m.collectors() {
+		registerer.Unregister(c)
+	}
+}
+
+// operationLabels returns the operation_name and operation_type label values
+// for the operation in ctx.
+func (m *metrics) operationLabels(ctx context.Context) (string, string) {
+	if !graphql.HasOperationContext(ctx) {
+		return "", ""
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := gqlctx.OperationName(oc)
+	var operationType string
+	if oc.Operation != nil {
+		operationType = string(oc.Operation.Operation)
+		if name == "" && m.hashNameless {
+			if signature := m.signature(ctx); signature != "" {
+				name = namelessPrefix + signature
+			}
+		}
+	}
+
+	return m.operationNames.Value(name), operationType
+}
+
+type signatureKey struct{}
+
+// signature returns the normalize signature of the operation in ctx, or ""
+// if it has none. It is computed once per operation, see InterceptOperation.
+func (m *metrics) signature(ctx context.Context) string {
+	if signature, ok := ctx.Value(signatureKey{}).(string); ok {
+		return signature
+	}
+	if !graphql.HasOperationContext(ctx) {
+		return ""
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Doc == nil {
+		return ""
+	}
+	signature, _ := m.signatures.Signature(oc.Doc, oc.OperationName)
+	return signature
+}
+
+// fieldLabels returns the object and field label values for fc, both
+// overflowLabelValue once the WithMaxFields cap is reached.
+func (m *metrics) fieldLabels(fc *graphql.FieldContext) (string, string) {
+	if m.fields.Value(fc.Object+"."+fc.Field.Name) == overflowLabelValue {
+		return overflowLabelValue, overflowLabelValue
+	}
+	return fc.Object, fc.Field.Name
+}
 
-		resolverStartedCounter.WithLabelValues(rctx.Object, rctx.Field.Name).Inc()
+// enrich appends the signature, tenant and enricher label values for ctx to
+// the values of a request metric, see requestLabels.
+func (m *metrics) enrich(ctx context.Context, values ...string) []string {
+	if m.signatureLabel {
+		values = append(values, m.signatureGuard.Value(m.signature(ctx)))
+	}
+	values = m.withTenant(ctx, values...)
+	if len(m.enricherLabels) == 0 {
+		return values
+	}
 
-		observerStart := time.Now()
+	labels := m.enricher(ctx)
+	for _, name := range m.enricherLabels {
+		values = append(values, labels[name])
+	}
+	return values
+}
 
-		res, err := next(ctx)
+// withTenant appends the tenant label value for ctx to the values of a
+// resolver metric, see resolverLabels.
+func (m *metrics) withTenant(ctx context.Context, values ...string) []string {
+	if m.tenant == nil {
+		return values
+	}
+	return append(values, m.tenants.Value(m.tenant(ctx)))
+}
 
-		var exitStatus string
-		if err != nil {
-			exitStatus = existStatusFailure
-		} else {
-			exitStatus = exitStatusSuccess
+// failureStatus returns the exitStatus label value of a failure in ctx,
+// see WithCancellationStatus.
+func (m *metrics) failureStatus(ctx context.Context) string {
+	if m.cancelled {
+		if reason := cancellation(ctx); reason != "" {
+			return reason
 		}
+	}
+	return existStatusFailure
+}
 
-		timeToResolveField.WithLabelValues(exitStatus, rctx.Object, rctx.Field.Name).
-			Observe(float64(time.Since(observerStart).Nanoseconds() / int64(time.Millisecond)))
+// cancellation returns why ctx is done, "" if it is not.
+func cancellation(ctx context.Context) string {
+	switch ctx.Err() {
+	case nil:
+		return ""
+	case context.DeadlineExceeded:
+		return exitStatusDeadlineExceeded
+	default:
+		return exitStatusCancelled
+	}
+}
 
-		resolverCompletedCounter.WithLabelValues(rctx.Object, rctx.Field.Name).Inc()
+// ExtensionErrorCode returns the extensions.code of err, formatted with
+// fmt.Sprint, if it is a *gqlerror.Error, "" otherwise.
+func ExtensionErrorCode(err error) string {
+	return extcode.Code(err)
+}
 
-		return res, err
+func (a Tracer) m() *metrics {
+	if a.metrics != nil {
+		return a.metrics
 	}
+	return defaultMetrics
+}
+
+func (a Tracer) ExtensionName() string {
+	return "Prometheus"
+}
+
+func (a Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
 }
 
-func RequestMiddleware() graphql.RequestMiddleware {
-	return func(ctx context.Context, next func(ctx context.Context) []byte) []byte {
-		requestStartedCounter.Inc()
+func (a Tracer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	m := a.m()
+	if m.signatureLabel || m.hashNameless {
+		ctx = context.WithValue(ctx, signatureKey{}, m.signature(ctx))
+	}
+	operationName, operationType := m.operationLabels(ctx)
+	m.requestStartedCounter.WithLabelValues(m.enrich(ctx, operationName, operationType)...).Inc()
+	m.requestsInFlight.Inc()
 
-		observerStart := time.Now()
+	oc := graphql.GetOperationContext(ctx)
+	subscription := oc.Operation != nil && oc.Operation.Operation == ast.Subscription
+	start := time.Now()
+	if subscription {
+		m.subscriptionsActive.WithLabelValues(operationName).Inc()
+	}
 
-		res := next(ctx)
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			m.requestsInFlight.Dec()
+			if subscription {
+				m.subscriptionsActive.WithLabelValues(operationName).Dec()
+				m.subscriptionDuration.WithLabelValues(operationName).Observe(time.Since(start).Seconds())
+			}
+		})
+	}
 
-		rctx := graphql.GetResolverContext(ctx)
-		reqCtx := graphql.GetRequestContext(ctx)
-		errList := reqCtx.GetErrors(rctx)
+	if !subscription {
+		ctx = context.WithValue(ctx, streamKey{}, incremental.New(oc.Stats.OperationStart))
+	}
+	responses := next(ctx)
 
-		var exitStatus string
-		if len(errList) > 0 {
-			exitStatus = existStatusFailure
-		} else {
-			exitStatus = exitStatusSuccess
+	// Subscriptions stay in flight until the stream ends with a nil response,
+	// operations using @defer until their last patch, or until the request
+	// ends for transports only delivering the initial payload.
+	first := true
+	return func(ctx context.Context) *graphql.Response {
+		res := responses(ctx)
+		hasNext := res != nil && res.HasNext != nil && *res.HasNext
+		switch {
+		case res == nil || !subscription && !hasNext:
+			done()
+		case first && hasNext:
+			context.AfterFunc(ctx, done)
 		}
+		first = false
+		if res != nil && subscription {
+			m.subscriptionEvents.WithLabelValues(operationName).Inc()
+			if len(res.Errors) > 0 {
+				m.subscriptionErrors.WithLabelValues(operationName).Inc()
+			}
+		}
+		return res
+	}
+}
 
-		timeToHandleRequest.With(prometheusclient.Labels{"exitStatus": exitStatus}).
-			Observe(float64(time.Since(observerStart).Nanoseconds() / int64(time.Millisecond)))
+// WebsocketErrorFunc counts websocket transport errors in
+// graphql_subscription_errors_total, with an empty operation_name. Set it as
+// the ErrorFunc of transport.Websocket.
+func (a Tracer) WebsocketErrorFunc(ctx context.Context, err error) {
+	a.m().subscriptionErrors.WithLabelValues("").Inc()
+}
 
-		requestCompletedCounter.Inc()
+// streamKey holds the incremental.Stream of queries and mutations.
+type streamKey struct{}
 
+// InterceptResponse records the request metrics on the initial payload. The
+// patches of operations using @defer are only counted by the
+// graphql_incremental metrics, and in graphql_request_errors_total.
+func (a Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	// Transports dispatch undecodable requests without an operation context.
+	if res == nil || !graphql.HasOperationContext(ctx) {
 		return res
 	}
+
+	m := a.m()
+	if stream, ok := ctx.Value(streamKey{}).(*incremental.Stream); ok {
+		if first, last := stream.Add(res); !first {
+			m.observePatch(ctx, stream, res, last)
+			return res
+		}
+	}
+	var exitStatus, errCode string
+	if len(res.Errors) > 0 {
+		exitStatus = m.failureStatus(ctx)
+		// Response errors are already presented, so they carry their code.
+		errCode = ExtensionErrorCode(res.Errors[0])
+	} else {
+		exitStatus = exitStatusSuccess
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	observerStart := oc.Stats.OperationStart
+
+	operationName, operationType := m.operationLabels(ctx)
+	if reason := cancellation(ctx); reason != "" {
+		m.requestsCancelled.WithLabelValues(operationName, operationType, reason).Inc()
+	}
+
+	m.timeToHandleRequest.observe(ctx, time.Since(observerStart), m.enrich(ctx, exitStatus, errCode, operationName, operationType)...)
+
+	m.requestCompletedCounter.WithLabelValues(m.enrich(ctx, operationName, operationType)...).Inc()
+	m.requestOutcomes.WithLabelValues(operationName, operationType, string(m.classifier(ctx, res.Errors))).Inc()
+
+	client := clientinfo.ForContext(ctx)
+	m.clientRequests.WithLabelValues(m.clientNames.Value(client.Name), m.clientVersions.Value(client.Version)).Inc()
+
+	for _, err := range res.Errors {
+		m.requestErrors.WithLabelValues(m.enrich(ctx, ExtensionErrorCode(err), operationName)...).Inc()
+	}
+
+	// Stats are only present when the ComplexityLimit extension is in use.
+	if stats := extension.GetComplexityStats(ctx); stats != nil {
+		m.operationComplexity.WithLabelValues(operationName, operationType).Observe(float64(stats.Complexity))
+		if stats.ComplexityLimit > 0 && stats.Complexity > stats.ComplexityLimit {
+			m.complexityLimitExceeded.WithLabelValues(operationName, operationType).Inc()
+		}
+	}
+
+	return res
+}
+
+func (a Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	m := a.m()
+	if !m.fieldFilter(fc) {
+		return next(ctx)
+	}
+	var tenant string
+	if m.tenant != nil {
+		tenant = m.tenants.Value(m.tenant(ctx))
+	}
+	fm := m.fieldMetrics(fc, tenant)
+
+	fm.started.Inc()
+
+	observerStart := time.Now()
+
+	res, err := next(ctx)
+
+	var exitStatus, errCode string
+	if err != nil {
+		exitStatus = m.failureStatus(ctx)
+		errCode = m.errorCode(err)
+	} else {
+		exitStatus = exitStatusSuccess
+	}
+
+	fm.durations(exitStatus, errCode).observe(ctx, time.Since(observerStart))
+
+	fm.completed.Inc()
+
+	return res, err
+}
+
+func (m *metrics) observePatch(ctx context.Context, stream *incremental.Stream, res *graphql.Response, last bool) {
+	operationName, _ := m.operationLabels(ctx)
+	if len(res.Errors) > 0 {
+		m.incrementalPatchErrors.WithLabelValues(operationName).Inc()
+	}
+	for _, err := range res.Errors {
+		m.requestErrors.WithLabelValues(m.enrich(ctx, ExtensionErrorCode(err), operationName)...).Inc()
+	}
+	if !last || !stream.Finish() {
+		return
+	}
+
+	m.incrementalPatches.WithLabelValues(operationName).Observe(float64(stream.Patches()))
+	m.timeToFirstResponse.observe(ctx, stream.FirstResponse(), operationName)
+	m.timeToLastPatch.observe(ctx, stream.Duration(), operationName)
 }
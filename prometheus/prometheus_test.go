@@ -1,31 +1,42 @@
 package prometheus_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/dataloader"
+	"github.com/99designs/gqlgen-contrib/errcode"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen-contrib/otel"
 	"github.com/99designs/gqlgen-contrib/prometheus"
-	"github.com/99designs/gqlgen-contrib/prometheus/internal/graph"
-	"github.com/99designs/gqlgen/handler"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
-func TestPrometheus_ResolverMiddleware_RequestMiddleware(t *testing.T) {
+func TestPrometheus_Tracer(t *testing.T) {
 
 	prometheus.Register()
 
 	mux := http.NewServeMux()
-	mux.Handle("/query", handler.GraphQL(
-		graph.NewExecutableSchema(graph.Config{
-			Resolvers: &graph.Resolver{},
-		}),
-		handler.RequestMiddleware(prometheus.RequestMiddleware()),
-		handler.ResolverMiddleware(prometheus.ResolverMiddleware()),
-	))
+	mux.Handle("/query", newServer(prometheus.Tracer{}))
 
 	for i := 0; i < 100; i++ {
 		resp := doRequest(mux, http.MethodPost, "/query", `{"query":"{ todos { id text } }"}`)
@@ -47,6 +58,660 @@ func TestPrometheus_ResolverMiddleware_RequestMiddleware(t *testing.T) {
 	assert.Contains(t, body, "graphql_resolver_completed_total")
 }
 
+func TestPrometheus_New(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithNamespace("app"),
+		prometheus.WithSubsystem("api"),
+		prometheus.WithBuckets([]float64{5, 50, 500}),
+		prometheus.WithConstLabels(prometheusclient.Labels{"service": "todo"}),
+	)
+	defer tracer.UnRegister()
+
+	mux := http.NewServeMux()
+	mux.Handle("/query", newServer(tracer))
+
+	resp := doRequest(mux, http.MethodPost, "/query", `{"query":"{ todos { id text } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+
+	assert.Contains(t, body, `app_api_graphql_request_started_total{operation_name="",operation_type="query",service="todo"} 1`)
+	assert.Contains(t, body, `app_api_graphql_request_completed_total{operation_name="",operation_type="query",service="todo"} 1`)
+	assert.Contains(t, body, `app_api_graphql_request_duration_ms_bucket{err_code="",exitStatus="success",operation_name="",operation_type="query",service="todo",le="50"}`)
+	assert.Contains(t, body, `app_api_graphql_resolver_started_total{field="todos",object="Query",service="todo"} 1`)
+	assert.Contains(t, body, `app_api_graphql_resolver_duration_ms_bucket{err_code="",exitStatus="success",field="todos",object="Query",service="todo",le="500"}`)
+	assert.NotContains(t, body, `le="1024"`)
+}
+
+func TestPrometheus_Histograms(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithNativeHistograms(1.1),
+		prometheus.WithNativeHistogramMaxBuckets(100),
+		prometheus.WithoutClassicBuckets(),
+		prometheus.WithSecondsHistograms(),
+		prometheus.WithoutMillisecondHistograms(),
+	)
+	defer tracer.UnRegister()
+
+	resp := doRequest(newServer(tracer), http.MethodPost, "/query", `{"query":"{ todos { id text } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	histograms := map[string]*dto.Histogram{}
+	for _, family := range families {
+		if family.GetType() == dto.MetricType_HISTOGRAM {
+			histograms[family.GetName()] = family.GetMetric()[0].GetHistogram()
+		}
+	}
+
+	assert.NotContains(t, histograms, "graphql_request_duration_ms")
+	assert.NotContains(t, histograms, "graphql_resolver_duration_ms")
+	for _, name := range []string{"graphql_request_duration_seconds", "graphql_resolver_duration_seconds"} {
+		require.Contains(t, histograms, name)
+		assert.NotZero(t, histograms[name].GetSampleCount(), name)
+		assert.Empty(t, histograms[name].GetBucket(), name)
+		assert.NotZero(t, histograms[name].GetZeroThreshold(), name)
+	}
+}
+
+func TestPrometheus_Exemplars(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithExemplars(prometheus.TraceExemplar),
+	)
+	defer tracer.UnRegister()
+
+	srv := newServer(tracer, otel.New(otel.WithTracerProvider(provider)))
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"query Todos { todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	spans := map[string]string{}
+	for _, span := range recorder.Ended() {
+		spans[span.SpanContext().SpanID().String()] = span.Name()
+	}
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	exemplars := map[string]string{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				labels := map[string]string{}
+				for _, label := range bucket.GetExemplar().GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if spanID, ok := labels["span_id"]; ok {
+					assert.Equal(t, recorder.Ended()[0].SpanContext().TraceID().String(), labels["trace_id"])
+					exemplars[family.GetName()] = spans[spanID]
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, "Todos", exemplars["graphql_request_duration_ms"])
+	assert.Equal(t, "Query.todos", exemplars["graphql_resolver_duration_ms"])
+}
+
+func TestPrometheus_OperationLabels(t *testing.T) {
+	queries := []string{
+		`{"query":"query ListTodos { todos { id } }"}`,
+		`{"query":"query Other { todos { text } }"}`,
+		`{"query":"mutation CreateTodo { createTodo(input: {text: \"a\", userId: \"1\"}) { id } }"}`,
+	}
+
+	specs := []struct {
+		SpecName string
+		Options  []prometheus.Option
+		Expected []string
+	}{
+		{
+			SpecName: "uncapped",
+			Options:  []prometheus.Option{prometheus.WithMaxOperationNames(0)},
+			Expected: []string{
+				`graphql_request_completed_total{operation_name="ListTodos",operation_type="query"} 1`,
+				`graphql_request_completed_total{operation_name="Other",operation_type="query"} 1`,
+				`graphql_request_completed_total{operation_name="CreateTodo",operation_type="mutation"} 1`,
+			},
+		},
+		{
+			SpecName: "allowlist",
+			Options:  []prometheus.Option{prometheus.WithOperationNameAllowlist("ListTodos")},
+			Expected: []string{
+				`graphql_request_completed_total{operation_name="ListTodos",operation_type="query"} 1`,
+				`graphql_request_completed_total{operation_name="__overflow__",operation_type="query"} 1`,
+				`graphql_request_completed_total{operation_name="__overflow__",operation_type="mutation"} 1`,
+			},
+		},
+		{
+			SpecName: "max unique names",
+			Options:  []prometheus.Option{prometheus.WithMaxOperationNames(2)},
+			Expected: []string{
+				`graphql_request_started_total{operation_name="ListTodos",operation_type="query"} 1`,
+				`graphql_request_started_total{operation_name="Other",operation_type="query"} 1`,
+				`graphql_request_started_total{operation_name="__overflow__",operation_type="mutation"} 1`,
+			},
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			registry := prometheusclient.NewRegistry()
+			tracer := prometheus.New(append(spec.Options, prometheus.WithRegisterer(registry))...)
+
+			srv := newServer(tracer)
+			for _, query := range queries {
+				resp := doRequest(srv, http.MethodPost, "/query", query)
+				require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+			}
+
+			resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			body := resp.Body.String()
+			for _, expected := range spec.Expected {
+				assert.Contains(t, body, expected)
+			}
+		})
+	}
+}
+
+func TestPrometheus_NamelessOperationHash(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	srv := newServer(prometheus.New(prometheus.WithRegisterer(registry), prometheus.WithNamelessOperationHash()))
+
+	for _, query := range []string{
+		`{"query":"{ todos { id text } }"}`,
+		`{"query":"query {\n  todos { text id }\n}"}`,
+		`{"query":"query Named { todos { id } }"}`,
+	} {
+		resp := doRequest(srv, http.MethodPost, "/query", query)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	normalized, err := normalize.Query("{ todos { id text } }", "")
+	require.NoError(t, err)
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_request_completed_total{operation_name="nameless-`+normalize.Hash(normalized)[:12]+`",operation_type="query"} 2`)
+	assert.Contains(t, body, `graphql_request_completed_total{operation_name="Named",operation_type="query"} 1`)
+}
+
+func TestPrometheus_OperationSignature(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	table := normalize.NewTable(10)
+	srv := newServer(prometheus.New(prometheus.WithRegisterer(registry), prometheus.WithOperationSignature(table)))
+
+	for _, query := range []string{
+		`{"query":"{ todo(id: \"1\") { id } }"}`,
+		`{"query":"{ todo(id: \"2\") { id } }"}`,
+	} {
+		resp := doRequest(srv, http.MethodPost, "/query", query)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	entries := table.Entries()
+	require.Len(t, entries, 1)
+	for signature, normalized := range entries {
+		assert.Len(t, signature, normalize.SignatureLength)
+		assert.Equal(t, `query { todo(id: "") { id } }`, normalized)
+
+		resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+		assert.Contains(t, resp.Body.String(), `graphql_request_completed_total{operation_name="",operation_signature="`+signature+`",operation_type="query"} 2`)
+	}
+}
+
+func TestPrometheus_Complexity(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithComplexityBuckets([]float64{2, 4, 8}),
+	)
+
+	srv := newServer(tracer, extension.FixedComplexityLimit(3))
+
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"query Small { todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = doRequest(srv, http.MethodPost, "/query", `{"query":"query Large { todos { id text done } }"}`)
+	require.Contains(t, resp.Body.String(), "COMPLEXITY_LIMIT_EXCEEDED")
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+
+	assert.Contains(t, body, `graphql_operation_complexity_bucket{operation_name="Small",operation_type="query",le="2"} 1`)
+	assert.Contains(t, body, `graphql_operation_complexity_bucket{operation_name="Large",operation_type="query",le="4"} 1`)
+	assert.Contains(t, body, `graphql_complexity_limit_exceeded_total{operation_name="Large",operation_type="query"} 1`)
+	assert.NotContains(t, body, `graphql_complexity_limit_exceeded_total{operation_name="Small"`)
+}
+
+func TestPrometheus_InFlight(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(prometheus.WithRegisterer(registry))
+
+	var mu sync.Mutex
+	var inFlight float64
+	srv := newServer(tracer, fieldHook(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight = gaugeValue(t, registry, "graphql_requests_in_flight")
+	}))
+
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	assert.Equal(t, float64(1), inFlight)
+	assert.Equal(t, float64(0), gaugeValue(t, registry, "graphql_requests_in_flight"))
+}
+
+func TestPrometheus_ErrorCode(t *testing.T) {
+	presenter := errcode.New(errcode.WithCode(graph.ErrTodoNotFound, "NOT_FOUND"))
+
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithErrorCode(presenter.Code),
+	)
+
+	srv := newServer(tracer)
+	srv.SetErrorPresenter(presenter.Present)
+
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"query Missing { todo(id: \"unknown\") { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Contains(t, resp.Body.String(), "NOT_FOUND")
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+
+	assert.Contains(t, body, `graphql_resolver_duration_ms_count{err_code="NOT_FOUND",exitStatus="failure",field="todo",object="Query"} 1`)
+	assert.Contains(t, body, `graphql_request_duration_ms_count{err_code="NOT_FOUND",exitStatus="failure",operation_name="Missing",operation_type="query"} 1`)
+}
+
+func TestPrometheus_RequestErrors(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(prometheus.WithRegisterer(registry))
+
+	srv := newServer(tracer)
+	srv.SetErrorPresenter(errcode.New(errcode.WithCode(graph.ErrTodoNotFound, "NOT_FOUND")).Present)
+
+	query := `{"query":"query Missing { a: todo(id: \"a\") { id } b: todo(id: \"b\") { id } }"}`
+	resp := doRequest(srv, http.MethodPost, "/query", query)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = doRequest(srv, http.MethodPost, "/query", `{"query":"query Broken { todos { unknown } }"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+
+	assert.Contains(t, body, `graphql_request_errors_total{error_code="NOT_FOUND",operation_name="Missing"} 2`)
+	assert.Contains(t, body, `graphql_request_outcomes_total{operation_name="Missing",operation_type="query",outcome="user_error"} 1`)
+	assert.Contains(t, body, `graphql_request_outcomes_total{operation_name="",operation_type="",outcome="user_error"} 1`)
+	assert.Contains(t, body, `graphql_request_errors_total{error_code="GRAPHQL_VALIDATION_FAILED",operation_name=""} 1`)
+}
+
+func TestPrometheus_MalformedBody(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	srv := newServer(prometheus.New(prometheus.WithRegisterer(registry)))
+
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "json request body could not be decoded")
+	assert.NotContains(t, resp.Body.String(), "internal system error")
+}
+
+func TestPrometheus_ClientRequests(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(prometheus.WithRegisterer(registry), prometheus.WithMaxClients(1))
+
+	srv := newServer(tracer, clientinfo.New(clientinfo.WithHeaders("X-Client", "X-Client-Version")))
+	for _, client := range [][2]string{{"web", "1.0"}, {"web", "1.0"}, {"ios", "2.0"}, {"", ""}} {
+		r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Client", client[0])
+		r.Header.Set("X-Client-Version", client[1])
+		srv.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_client_requests_total{client_name="web",client_version="1.0"} 2`)
+	assert.Contains(t, body, `graphql_client_requests_total{client_name="__overflow__",client_version="__overflow__"} 1`)
+	assert.Contains(t, body, `graphql_client_requests_total{client_name="",client_version=""} 1`)
+}
+
+func TestPrometheus_FieldFilter(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	srv := newServer(prometheus.New(prometheus.WithRegisterer(registry)))
+
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"{ todos { id text } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_resolver_completed_total{field="todos",object="Query"} 1`)
+	assert.NotContains(t, body, `object="Todo"`)
+}
+
+func TestPrometheus_PreRegisterFromSchema(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(prometheus.WithRegisterer(registry))
+	tracer.PreRegisterFromSchema(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	for _, field := range []string{`field="todos",object="Query"`, `field="createTodo",object="Mutation"`, `field="todoAdded",object="Subscription"`} {
+		assert.Contains(t, body, `graphql_resolver_started_total{`+field+`} 0`)
+		assert.Contains(t, body, `graphql_resolver_duration_ms_count{err_code="",exitStatus="success",`+field+`} 0`)
+	}
+	assert.NotContains(t, body, `object="Todo"`)
+	assert.NotContains(t, body, `__schema`)
+}
+
+func TestPrometheus_MaxFields(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithFieldFilter(func(fc *graphql.FieldContext) bool { return true }),
+		prometheus.WithMaxFields(2),
+		prometheus.WithMaxOperationNames(1),
+	)
+
+	srv := newServer(tracer)
+	for _, query := range []string{
+		`{"query":"query List { todos { id } }"}`,
+		`{"query":"query Other { todos { id text } }"}`,
+	} {
+		resp := doRequest(srv, http.MethodPost, "/query", query)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_resolver_completed_total{field="todos",object="Query"} 2`)
+	assert.Contains(t, body, `graphql_resolver_completed_total{field="id",object="Todo"} 6`)
+	assert.Contains(t, body, `graphql_resolver_completed_total{field="__overflow__",object="__overflow__"} 3`)
+	assert.NotContains(t, body, `field="text"`)
+	assert.Contains(t, body, `graphql_metrics_series_dropped_total{label="field"} 3`)
+	assert.Contains(t, body, `graphql_metrics_series_dropped_total{label="operation_name"}`)
+	assert.Contains(t, body, `graphql_metrics_series_dropped_total{label="client_name"} 0`)
+}
+
+func TestPrometheus_CancellationStatus(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(prometheus.WithRegisterer(registry), prometheus.WithCancellationStatus())
+	defer tracer.UnRegister()
+
+	srv := newServer(tracer)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok {
+			cancel()
+		}
+		if _, ok := ctx.Deadline(); ok {
+			<-ctx.Done()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return next(ctx)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Gone { todos { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), r.WithContext(context.WithValue(ctx, cancelKey{}, cancel)))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r = httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Slow { todos { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_request_cancelled_total{operation_name="Gone",operation_type="query",reason="cancelled"} 1`)
+	assert.Contains(t, body, `graphql_request_cancelled_total{operation_name="Slow",operation_type="query",reason="deadline_exceeded"} 1`)
+	assert.Contains(t, body, `graphql_request_duration_ms_count{err_code="",exitStatus="cancelled",operation_name="Gone",operation_type="query"} 1`)
+	assert.Contains(t, body, `graphql_request_duration_ms_count{err_code="",exitStatus="deadline_exceeded",operation_name="Slow",operation_type="query"} 1`)
+	assert.Contains(t, body, `graphql_resolver_duration_ms_count{err_code="",exitStatus="cancelled",field="todos",object="Query"} 1`)
+	assert.NotContains(t, body, `exitStatus="failure"`)
+}
+
+type cancelKey struct{}
+
+func TestPrometheus_Enricher(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithEnricher(func(ctx context.Context) map[string]string {
+			return map[string]string{"tenant": "acme", "ignored": "x"}
+		}, "tenant", "region"),
+	)
+
+	srv := newServer(tracer)
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"query List { todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_request_started_total{operation_name="List",operation_type="query",region="",tenant="acme"} 1`)
+	assert.Contains(t, body, `graphql_request_completed_total{operation_name="List",operation_type="query",region="",tenant="acme"} 1`)
+	assert.NotContains(t, body, "ignored")
+}
+
+func TestPrometheus_TenantLabel(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithTenantLabel(func(ctx context.Context) string {
+			return graphql.GetOperationContext(ctx).Headers.Get("X-Tenant")
+		}),
+		prometheus.WithMaxTenants(1),
+	)
+
+	srv := newServer(tracer)
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query List { todos { id } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Tenant", tenant)
+		srv.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_request_completed_total{operation_name="List",operation_type="query",tenant="acme"} 2`)
+	assert.Contains(t, body, `graphql_request_completed_total{operation_name="List",operation_type="query",tenant="other"} 1`)
+	assert.Contains(t, body, `graphql_resolver_completed_total{field="todos",object="Query",tenant="acme"} 2`)
+	assert.Contains(t, body, `graphql_resolver_duration_ms_count{err_code="",exitStatus="success",field="todos",object="Query",tenant="other"} 1`)
+}
+
+func TestPrometheus_LoaderMetrics(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	metrics := prometheus.NewLoaderMetrics(
+		prometheus.WithRegisterer(registry),
+		prometheus.WithBatchSizeBuckets([]float64{1, 2, 4}),
+	)
+	defer metrics.UnRegister()
+
+	loader := dataloader.NewLoader(func(ctx context.Context, keys []int) ([]int, []error) {
+		return keys, nil
+	}, dataloader.WithName("number"), dataloader.WithObserver(metrics))
+
+	_, err := loader.LoadAll(context.Background(), []int{1, 2, 3})
+	require.NoError(t, err)
+	_, err = loader.Load(context.Background(), 2)
+	require.NoError(t, err)
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+
+	assert.Contains(t, body, `graphql_dataloader_batch_size_bucket{loader="number",le="2"} 0`)
+	assert.Contains(t, body, `graphql_dataloader_batch_size_bucket{loader="number",le="4"} 1`)
+	assert.Contains(t, body, `graphql_dataloader_load_duration_ms_count{exitStatus="success",loader="number"} 1`)
+	assert.Contains(t, body, `graphql_dataloader_cache_hits_total{loader="number"} 1`)
+	assert.Contains(t, body, `graphql_dataloader_cache_misses_total{loader="number"} 3`)
+}
+
+func TestPrometheus_Subscriptions(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	tracer := prometheus.New(prometheus.WithRegisterer(registry))
+
+	var mu sync.Mutex
+	var active float64
+	srv := newServer(tracer, fieldHook(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		active = gaugeValue(t, registry, "graphql_subscriptions_active")
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"subscription Added { todoAdded { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 3, strings.Count(w.Body.String(), "event: next"), w.Body.String())
+
+	tracer.WebsocketErrorFunc(context.Background(), errors.New("connection reset"))
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+
+	assert.Equal(t, float64(1), active)
+	assert.Contains(t, body, `graphql_subscriptions_active{operation_name="Added"} 0`)
+	assert.Contains(t, body, `graphql_subscription_events_total{operation_name="Added"} 3`)
+	assert.Contains(t, body, `graphql_subscription_duration_seconds_count{operation_name="Added"} 1`)
+	assert.Contains(t, body, `graphql_subscription_errors_total{operation_name=""} 1`)
+	assert.Contains(t, body, `graphql_requests_in_flight 0`)
+}
+
+func TestPrometheus_Defer(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.MultipartMixed{})
+	srv.Use(prometheus.New(prometheus.WithRegisterer(registry), prometheus.WithSecondsHistograms()))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Deferred { todos { id ... @defer { completed } } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "multipart/mixed")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"hasNext":false`)
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	body := resp.Body.String()
+
+	assert.Contains(t, body, `graphql_request_completed_total{operation_name="Deferred",operation_type="query"} 1`)
+	assert.Contains(t, body, `graphql_incremental_patches_sum{operation_name="Deferred"} 3`)
+	assert.Contains(t, body, `graphql_incremental_first_response_duration_seconds_count{operation_name="Deferred"} 1`)
+	assert.Contains(t, body, `graphql_incremental_last_patch_duration_seconds_count{operation_name="Deferred"} 1`)
+	assert.Contains(t, body, `graphql_requests_in_flight 0`)
+}
+
+// fieldHook calls fn from inside every resolver.
+type fieldHook func()
+
+func (fieldHook) ExtensionName() string                          { return "FieldHook" }
+func (fieldHook) Validate(schema graphql.ExecutableSchema) error { return nil }
+
+func (f fieldHook) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	f()
+	return next(ctx)
+}
+
+func gaugeValue(t *testing.T, gatherer prometheusclient.Gatherer, name string) float64 {
+	families, err := gatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+// BenchmarkPrometheus_InterceptField measures the overhead the Tracer adds
+// to each resolved field of a large query.
+func BenchmarkPrometheus_InterceptField(b *testing.B) {
+	tracer := prometheus.New(
+		prometheus.WithRegisterer(prometheusclient.NewRegistry()),
+		prometheus.WithSecondsHistograms(),
+	)
+
+	var fields []context.Context
+	for _, object := range []string{"Todo", "User"} {
+		for _, field := range []string{"id", "text", "done", "user", "name"} {
+			fields = append(fields, graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+				Object:     object,
+				Field:      graphql.CollectedField{Field: &ast.Field{Name: field}},
+				IsResolver: true,
+			}))
+		}
+	}
+	resolver := func(ctx context.Context) (interface{}, error) { return nil, nil }
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ctx := range fields {
+			_, _ = tracer.InterceptField(ctx, resolver)
+		}
+	}
+}
+
+func newServer(tracer prometheus.Tracer, extensions ...graphql.HandlerExtension) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(transport.POST{})
+	for _, ext := range extensions {
+		srv.Use(ext)
+	}
+	srv.Use(tracer)
+
+	return srv
+}
+
 func doRequest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
 	r := httptest.NewRequest(method, target, strings.NewReader(body))
 	r.Header.Set("Content-Type", "application/json")
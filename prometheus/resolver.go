@@ -0,0 +1,139 @@
+package prometheus
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// fieldKey identifies the resolver series of a field, tenant being empty
+// unless WithTenantLabel is given.
+type fieldKey struct {
+	object, field, tenant string
+}
+
+type statusKey struct {
+	exitStatus, errCode string
+}
+
+// fieldMetrics holds the resolver series of a field, resolved once so that
+// InterceptField does not hash label values on every call.
+type fieldMetrics struct {
+	started   prometheusclient.Counter
+	completed prometheusclient.Counter
+	success   durationObservers
+
+	m        *metrics
+	labels   []string
+	failures sync.Map // statusKey -> durationObservers
+}
+
+// fieldMetrics returns the resolver series of fc, creating them the first
+// time the field is resolved.
+func (m *metrics) fieldMetrics(fc *graphql.FieldContext, tenant string) *fieldMetrics {
+	key := fieldKey{object: fc.Object, field: fc.Field.Name, tenant: tenant}
+	if fm, ok := m.fieldCache.Load(key); ok {
+		return fm.(*fieldMetrics)
+	}
+
+	// Only admitted fields are cached under their own key, for the cache to
+	// stay as bounded as the guard.
+	key.object, key.field = m.fieldLabels(fc)
+	if fm, ok := m.fieldCache.Load(key); ok {
+		return fm.(*fieldMetrics)
+	}
+
+	labels := []string{key.object, key.field}
+	if m.tenant != nil {
+		labels = append(labels, key.tenant)
+	}
+	fm := &fieldMetrics{
+		started:   m.resolverStartedCounter.WithLabelValues(labels...),
+		completed: m.resolverCompletedCounter.WithLabelValues(labels...),
+		success:   m.timeToResolveField.with(append([]string{exitStatusSuccess, ""}, labels...)...),
+		m:         m,
+		labels:    labels,
+	}
+	actual, _ := m.fieldCache.LoadOrStore(key, fm)
+	return actual.(*fieldMetrics)
+}
+
+// durations returns the duration observers of the field for a resolution
+// that ended with exitStatus and errCode.
+func (fm *fieldMetrics) durations(exitStatus, errCode string) durationObservers {
+	if exitStatus == exitStatusSuccess && errCode == "" {
+		return fm.success
+	}
+
+	key := statusKey{exitStatus: exitStatus, errCode: errCode}
+	if o, ok := fm.failures.Load(key); ok {
+		return o.(durationObservers)
+	}
+	o := fm.m.timeToResolveField.with(append([]string{exitStatus, errCode}, fm.labels...)...)
+	fm.failures.Store(key, o)
+	return o
+}
+
+// PreRegisterFromSchema initializes the resolver series of the fields of
+// es for the metrics registered by Register, see Tracer.PreRegisterFromSchema.
+func PreRegisterFromSchema(es graphql.ExecutableSchema) {
+	defaultMetrics.preRegister(es.Schema())
+}
+
+// PreRegisterFromSchema initializes the resolver series of the fields of es
+// passing the field filter, so they are exported as zeros before being first
+// resolved. Whether a field is bound to a resolver or a method is not known
+// from the schema, so only the fields of the root operation types pass the
+// default filter. Nothing is initialized when WithTenantLabel is given, as
+// tenants are not known in advance.
+func (a Tracer) PreRegisterFromSchema(es graphql.ExecutableSchema) {
+	a.m().preRegister(es.Schema())
+}
+
+func (m *metrics) preRegister(schema *ast.Schema) {
+	if m.tenant != nil {
+		return
+	}
+
+	roots := map[*ast.Definition]bool{schema.Query: true}
+	if schema.Mutation != nil {
+		roots[schema.Mutation] = true
+	}
+	if schema.Subscription != nil {
+		roots[schema.Subscription] = true
+	}
+
+	names := make([]string, 0, len(schema.Types))
+	for name, def := range schema.Types {
+		if def.Kind == ast.Object && !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := schema.Types[name]
+		for _, field := range def.Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			fc := &graphql.FieldContext{
+				Object: name,
+				Field: graphql.CollectedField{Field: &ast.Field{
+					Name:             field.Name,
+					Definition:       field,
+					ObjectDefinition: def,
+				}},
+				IsMethod:   roots[def],
+				IsResolver: roots[def],
+			}
+			if m.fieldFilter(fc) {
+				m.fieldMetrics(fc, "")
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package propagation
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Transport returns a RoundTripper sending requests with base, or
+// http.DefaultTransport if nil, in a client span injected in their headers.
+// Responses of status 5xx mark the span as failed.
+func Transport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{cfg: newConfig(opts...), base: base}
+}
+
+type transport struct {
+	cfg  *config
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The query and credentials of URLs are left out of spans.
+	u := *req.URL
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+
+	ctx, sp := t.cfg.backend.start(req.Context(), spanStart{
+		name:      req.Method,
+		operation: "http.request",
+		resource:  req.Method + " " + req.URL.Host,
+		spanType:  "http",
+		tags: withPath(req.Context(), map[string]string{
+			"http.method": req.Method,
+			"http.url":    u.String(),
+		}),
+	})
+
+	// RoundTrippers must not modify the request they are given.
+	req = req.Clone(ctx)
+	sp.inject(req.Header)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		sp.end(err)
+		return nil, err
+	}
+	sp.setTag("http.status_code", strconv.Itoa(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		sp.end(&statusError{resp.Status})
+	} else {
+		sp.end(nil)
+	}
+	return resp, nil
+}
+
+type statusError struct {
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "http: " + e.status
+}
@@ -0,0 +1,63 @@
+package propagation
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type config struct {
+	backend   backend
+	statement bool
+}
+
+// Option is anything that can configure the spans of downstream calls.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{statement: true}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.backend == nil {
+		cfg.backend = otelBackend{
+			tracer:     otel.GetTracerProvider().Tracer(tracerName),
+			propagator: otel.GetTextMapPropagator(),
+		}
+	}
+
+	return cfg
+}
+
+// WithOTel creates spans from provider, propagating them with propagator,
+// instead of the global TracerProvider and TextMapPropagator used by
+// default. Either may be nil to keep the global one.
+func WithOTel(provider trace.TracerProvider, propagator propagation.TextMapPropagator) Option {
+	return func(cfg *config) {
+		if provider == nil {
+			provider = otel.GetTracerProvider()
+		}
+		if propagator == nil {
+			propagator = otel.GetTextMapPropagator()
+		}
+		cfg.backend = otelBackend{tracer: provider.Tracer(tracerName), propagator: propagator}
+	}
+}
+
+// WithDatadog creates spans with the global Datadog tracer, as the datadog
+// package does, instead of OpenTelemetry.
+func WithDatadog() Option {
+	return func(cfg *config) {
+		cfg.backend = datadogBackend{}
+	}
+}
+
+// WithoutStatement leaves SQL statements out of query spans, for those
+// embedding literals that should not be recorded.
+func WithoutStatement() Option {
+	return func(cfg *config) {
+		cfg.statement = false
+	}
+}
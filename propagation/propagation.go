@@ -0,0 +1,125 @@
+// Package propagation traces the HTTP requests and SQL queries issued by
+// resolvers as children of the span of the resolver, such as those of the
+// otel and datadog packages, recording the GraphQL path of the field as the
+// graphql.path attribute.
+package propagation
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// tracerName identifies the instrumentation library on the produced spans.
+const tracerName = "github.com/99designs/gqlgen-contrib/propagation"
+
+// spanStart describes a span of a downstream call, operation, resource and
+// spanType being those of Datadog.
+type spanStart struct {
+	name      string
+	operation string
+	resource  string
+	spanType  string
+	tags      map[string]string
+}
+
+// backend creates spans with a tracing library.
+type backend interface {
+	start(ctx context.Context, s spanStart) (context.Context, span)
+}
+
+type span interface {
+	setTag(key, value string)
+	inject(header http.Header)
+	end(err error)
+}
+
+// withPath records the path of the field resolved in ctx, if any, in tags.
+func withPath(ctx context.Context, tags map[string]string) map[string]string {
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		tags["graphql.path"] = fc.Path().String()
+	}
+	return tags
+}
+
+type otelBackend struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func (b otelBackend) start(ctx context.Context, s spanStart) (context.Context, span) {
+	attrs := make([]attribute.KeyValue, 0, len(s.tags))
+	for k, v := range s.tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	ctx, sp := b.tracer.Start(ctx, s.name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, otelSpan{ctx: ctx, span: sp, propagator: b.propagator}
+}
+
+type otelSpan struct {
+	ctx        context.Context
+	span       trace.Span
+	propagator propagation.TextMapPropagator
+}
+
+func (s otelSpan) setTag(key, value string) {
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+func (s otelSpan) inject(header http.Header) {
+	s.propagator.Inject(s.ctx, propagation.HeaderCarrier(header))
+}
+
+func (s otelSpan) end(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+type datadogBackend struct{}
+
+func (datadogBackend) start(ctx context.Context, s spanStart) (context.Context, span) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ResourceName(s.resource),
+		tracer.SpanType(s.spanType),
+		tracer.Tag(ext.Component, "gqlgen"),
+	}
+	for k, v := range s.tags {
+		opts = append(opts, tracer.Tag(k, v))
+	}
+	sp, ctx := tracer.StartSpanFromContext(ctx, s.operation, opts...)
+	return ctx, datadogSpan{span: sp}
+}
+
+type datadogSpan struct {
+	span ddtrace.Span
+}
+
+func (s datadogSpan) setTag(key, value string) {
+	s.span.SetTag(key, value)
+}
+
+func (s datadogSpan) inject(header http.Header) {
+	_ = tracer.Inject(s.span.Context(), tracer.HTTPHeadersCarrier(header))
+}
+
+func (s datadogSpan) end(err error) {
+	if err != nil {
+		s.span.Finish(tracer.WithError(err))
+		return
+	}
+	s.span.Finish()
+}
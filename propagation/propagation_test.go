@@ -0,0 +1,181 @@
+package propagation_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/datadog"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/otel"
+	"github.com/99designs/gqlgen-contrib/propagation"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelpropagation "go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestOTel(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	opts := []propagation.Option{propagation.WithOTel(provider, otelpropagation.TraceContext{})}
+
+	var traceparent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+	}))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: propagation.Transport(nil, opts...)}
+	db := sql.OpenDB(propagation.Connector(connector{}, opts...))
+	defer db.Close()
+
+	srv := newServer(otel.New(otel.WithTracerProvider(provider)), client, db, downstream.URL)
+
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	todos := spans["Query.todos"]
+	require.NotNil(t, todos)
+
+	get := spans["GET"]
+	require.NotNil(t, get)
+	assert.Equal(t, todos.SpanContext().SpanID(), get.Parent().SpanID())
+	assert.Equal(t, trace.SpanKindClient, get.SpanKind())
+	assert.Subset(t, get.Attributes(), []attribute.KeyValue{
+		attribute.String("graphql.path", "todos"),
+		attribute.String("http.method", "GET"),
+		attribute.String("http.url", downstream.URL+"/todos"),
+		attribute.String("http.status_code", "200"),
+	})
+	assert.Contains(t, traceparent, get.SpanContext().SpanID().String())
+
+	query := spans["SELECT"]
+	require.NotNil(t, query)
+	assert.Equal(t, todos.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Subset(t, query.Attributes(), []attribute.KeyValue{
+		attribute.String("graphql.path", "todos"),
+		attribute.String("db.statement", "select id from todos"),
+	})
+}
+
+func TestDatadog(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	opts := []propagation.Option{propagation.WithDatadog(), propagation.WithoutStatement()}
+
+	var parentID string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parentID = r.Header.Get("X-Datadog-Parent-Id")
+	}))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: propagation.Transport(nil, opts...)}
+	db := sql.OpenDB(propagation.Connector(connector{}, opts...))
+	defer db.Close()
+
+	srv := newServer(datadog.New(), client, db, downstream.URL)
+
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	spans := map[string]mocktracer.Span{}
+	for _, span := range mt.FinishedSpans() {
+		spans[span.OperationName()] = span
+		if span.OperationName() == "graphql.field" && span.Tag("graphql.field") == "todos" {
+			spans["todos"] = span
+		}
+	}
+	field := spans["todos"]
+	require.NotNil(t, field)
+
+	request := spans["http.request"]
+	require.NotNil(t, request)
+	assert.Equal(t, field.SpanID(), request.ParentID())
+	assert.Equal(t, "todos", request.Tag("graphql.path"))
+	assert.NotEmpty(t, parentID)
+
+	query := spans["sql.query"]
+	require.NotNil(t, query)
+	assert.Equal(t, field.SpanID(), query.ParentID())
+	assert.Equal(t, "SELECT", query.Tag("resource.name"))
+	assert.Nil(t, query.Tag("db.statement"))
+}
+
+// newServer returns a server traced by tracer whose todos resolver calls
+// downstream and queries db.
+func newServer(tracer graphql.HandlerExtension, client *http.Client, db *sql.DB, downstream string) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tracer)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		if graphql.GetFieldContext(ctx).Field.Name != "todos" {
+			return next(ctx)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, downstream+"/todos?token=secret", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		rows, err := db.QueryContext(ctx, "select id from todos")
+		if err != nil {
+			return nil, err
+		}
+		rows.Close()
+		return next(ctx)
+	})
+	return srv
+}
+
+type connector struct{}
+
+func (connector) Connect(context.Context) (driver.Conn, error) { return conn{}, nil }
+func (connector) Driver() driver.Driver                        { return nil }
+
+// conn is a database connection answering no rows.
+type conn struct{}
+
+func (conn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (conn) Close() error                              { return nil }
+func (conn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return rows{}, nil
+}
+
+type rows struct{}
+
+func (rows) Columns() []string              { return []string{"id"} }
+func (rows) Close() error                   { return nil }
+func (rows) Next(dest []driver.Value) error { return io.EOF }
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,58 @@
+package propagation
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/internal/sqldriver"
+)
+
+// Open opens a database like sql.Open, the queries and statements executed
+// on it being traced.
+func Open(driverName, dsn string, opts ...Option) (*sql.DB, error) {
+	c, err := sqldriver.Connector(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(Connector(c, opts...)), nil
+}
+
+// Connector returns a connector whose connections trace the queries and
+// statements executed with c, to be opened with sql.OpenDB.
+func Connector(c driver.Connector, opts ...Option) driver.Connector {
+	cfg := newConfig(opts...)
+	return sqldriver.Wrap(c, sqldriver.Interceptor{Around: cfg.trace})
+}
+
+// trace runs fn in a span of query.
+func (cfg *config) trace(ctx context.Context, operation, query string, fn func(ctx context.Context) error) error {
+	name, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	name = strings.ToUpper(name)
+	if name == "" {
+		name = "sql"
+	}
+	tags := withPath(ctx, map[string]string{})
+	resource := name
+	if cfg.statement {
+		tags["db.statement"] = query
+		resource = query
+	}
+
+	ctx, sp := cfg.backend.start(ctx, spanStart{
+		name:      name,
+		operation: "sql." + operation,
+		resource:  resource,
+		spanType:  "sql",
+		tags:      tags,
+	})
+	err := fn(ctx)
+	if errors.Is(err, driver.ErrSkip) {
+		sp.end(nil)
+	} else {
+		sp.end(err)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// KeyFunc returns the identity an operation is limited by. Operations with an
+// empty key are not limited.
+type KeyFunc func(ctx context.Context) string
+
+// ByHeader limits by the value of the given request header, such as an API
+// key.
+func ByHeader(name string) KeyFunc {
+	return func(ctx context.Context) string {
+		if !graphql.HasOperationContext(ctx) {
+			return ""
+		}
+		return graphql.GetOperationContext(ctx).Headers.Get(name)
+	}
+}
+
+// ByContextValue limits by ctx.Value(key), typically a user ID stored by an
+// authentication middleware.
+func ByContextValue(key any) KeyFunc {
+	return func(ctx context.Context) string {
+		v := ctx.Value(key)
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+type remoteAddrKey struct{}
+
+// RemoteAddrMiddleware stores the client IP of each request for ByRemoteAddr.
+// It must wrap the GraphQL handler.
+func RemoteAddrMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), remoteAddrKey{}, host)))
+	})
+}
+
+// ByRemoteAddr limits by the client IP stored by RemoteAddrMiddleware.
+func ByRemoteAddr() KeyFunc {
+	return ByContextValue(remoteAddrKey{})
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type config struct {
+	errorHandler func(ctx context.Context, err error)
+	rejection    func(ctx context.Context, retryAfter time.Duration) *gqlerror.Error
+	limitFunc    func(ctx context.Context, key string) (Limit, bool)
+
+	chargeComplexity  bool
+	complexityOptions []complexity.Option
+	costFuncs         map[string]CostFunc
+}
+
+// Option is anything that can configure Limiter.
+type Option func(cfg *config)
+
+// CostFunc returns the cost of a field given the cost of its selection set
+// and its arguments.
+type CostFunc func(childCost int, args map[string]any) int
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		errorHandler: func(ctx context.Context, err error) {},
+		rejection:    defaultRejection,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithErrorHandler is called when the store fails. The operation is let
+// through in that case.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithRejection builds the error returned for limited operations.
+func WithRejection(fn func(ctx context.Context, retryAfter time.Duration) *gqlerror.Error) Option {
+	return func(cfg *config) {
+		cfg.rejection = fn
+	}
+}
+
+// WithLimitFunc picks the limit per client, for example a larger budget for
+// paying customers. Clients for which fn returns false, or an invalid Limit,
+// get the default limit passed to New; invalid limits are reported to the
+// error handler.
+func WithLimitFunc(fn func(ctx context.Context, key string) (Limit, bool)) Option {
+	return func(cfg *config) {
+		cfg.limitFunc = fn
+	}
+}
+
+// WithComplexityCost charges each operation its complexity, as computed by
+// gqlgen for the complexity limit extension, instead of a single token.
+func WithComplexityCost(opts ...complexity.Option) Option {
+	return func(cfg *config) {
+		cfg.chargeComplexity = true
+		cfg.complexityOptions = opts
+	}
+}
+
+// WithCostFunc overrides the cost of object.field when charging complexity.
+// It takes precedence over the complexity functions of the schema and
+// implies WithComplexityCost.
+func WithCostFunc(object, field string, fn CostFunc) Option {
+	return func(cfg *config) {
+		cfg.chargeComplexity = true
+		if cfg.costFuncs == nil {
+			cfg.costFuncs = map[string]CostFunc{}
+		}
+		cfg.costFuncs[object+"."+field] = fn
+	}
+}
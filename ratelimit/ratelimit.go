@@ -0,0 +1,149 @@
+// Package ratelimit limits the rate of operations per client.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	// ErrCodeRateLimited is the extensions.code of the default rejection.
+	ErrCodeRateLimited = "RATE_LIMITED"
+	// ErrCodeCostExceedsBudget is the extensions.code returned for
+	// operations costing more than the whole budget, which can never pass.
+	ErrCodeCostExceedsBudget = "COST_EXCEEDS_BUDGET"
+)
+
+// Limiter is a gqlgen handler extension enforcing a token bucket limit per
+// client key.
+type Limiter struct {
+	store  Store
+	key    KeyFunc
+	limit  Limit
+	cfg    config
+	schema graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = (*Limiter)(nil)
+
+// New returns a Limiter allowing limit per key, with buckets kept in store.
+func New(store Store, key KeyFunc, limit Limit, opts ...Option) *Limiter {
+	return &Limiter{
+		store: store,
+		key:   key,
+		limit: limit,
+		cfg:   *newConfig(opts...),
+	}
+}
+
+func (l *Limiter) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (l *Limiter) Validate(schema graphql.ExecutableSchema) error {
+	if err := l.limit.Validate(); err != nil {
+		return err
+	}
+	if len(l.cfg.costFuncs) != 0 {
+		schema = costSchema{ExecutableSchema: schema, costFuncs: l.cfg.costFuncs}
+	}
+	l.schema = schema
+	return nil
+}
+
+func (l *Limiter) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	key := l.key(ctx)
+	if key == "" {
+		return next(ctx)
+	}
+
+	limit := l.limit
+	if l.cfg.limitFunc != nil {
+		if clientLimit, ok := l.cfg.limitFunc(ctx, key); ok {
+			if err := clientLimit.Validate(); err != nil {
+				l.cfg.errorHandler(ctx, err)
+			} else {
+				limit = clientLimit
+			}
+		}
+	}
+
+	cost := l.cost(ctx)
+	if cost > limit.Burst {
+		return graphql.OneShot(&graphql.Response{
+			Errors: gqlerror.List{costExceedsBudget(cost, limit.Burst)},
+		})
+	}
+
+	ok, retryAfter, err := l.store.Take(ctx, key, limit, cost)
+	if err != nil {
+		l.cfg.errorHandler(ctx, err)
+		return next(ctx)
+	}
+	if ok {
+		return next(ctx)
+	}
+
+	return graphql.OneShot(&graphql.Response{
+		Errors: gqlerror.List{l.cfg.rejection(ctx, retryAfter)},
+	})
+}
+
+// cost returns the number of tokens the operation in ctx takes.
+func (l *Limiter) cost(ctx context.Context) int {
+	if !l.cfg.chargeComplexity {
+		return 1
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil {
+		return 1
+	}
+
+	return max(1, complexity.Calculate(ctx, l.schema, oc.Operation, oc.Variables, l.cfg.complexityOptions...))
+}
+
+// costSchema lets the CostFuncs given to the Limiter take precedence over
+// the complexity functions generated for the schema.
+type costSchema struct {
+	graphql.ExecutableSchema
+	costFuncs map[string]CostFunc
+}
+
+func (s costSchema) Complexity(ctx context.Context, typeName, fieldName string, childComplexity int, args map[string]any) (int, bool) {
+	if fn, ok := s.costFuncs[typeName+"."+fieldName]; ok {
+		return fn(childComplexity, args), true
+	}
+	return s.ExecutableSchema.Complexity(ctx, typeName, fieldName, childComplexity, args)
+}
+
+// defaultRejection reports retryAfter in whole seconds, rounded up like the
+// Retry-After HTTP header.
+func defaultRejection(ctx context.Context, retryAfter time.Duration) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: "rate limit exceeded",
+		Extensions: map[string]interface{}{
+			"code":       ErrCodeRateLimited,
+			"retryAfter": int(math.Ceil(retryAfter.Seconds())),
+		},
+	}
+}
+
+func costExceedsBudget(cost, budget int) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: "operation cost exceeds the rate limit budget",
+		Extensions: map[string]interface{}{
+			"code":   ErrCodeCostExceedsBudget,
+			"cost":   cost,
+			"budget": budget,
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/ratelimit"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	stores := map[string]ratelimit.Store{
+		"memory": ratelimit.NewMemoryStore(),
+		"redis":  ratelimit.NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "ratelimit:"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			srv := newServer(ratelimit.New(store, ratelimit.ByHeader("X-Api-Key"), ratelimit.Limit{Rate: 0.5, Burst: 2}))
+
+			for i := 0; i < 2; i++ {
+				resp := doRequest(srv, "a")
+				require.Equal(t, http.StatusOK, resp.Code)
+				assert.NotContains(t, resp.Body.String(), "errors")
+			}
+
+			resp := doRequest(srv, "a")
+			assert.JSONEq(t, `{"errors":[{"message":"rate limit exceeded","extensions":{"code":"RATE_LIMITED","retryAfter":2}}],"data":null}`, resp.Body.String())
+
+			resp = doRequest(srv, "b")
+			assert.NotContains(t, resp.Body.String(), "errors")
+
+			for i := 0; i < 5; i++ {
+				resp = doRequest(srv, "")
+				assert.NotContains(t, resp.Body.String(), "errors")
+			}
+		})
+	}
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Rate: 100, Burst: 1}
+
+	ok, _, err := store.Take(context.Background(), "key", limit, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, retryAfter, err := store.Take(context.Background(), "key", limit, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.LessOrEqual(t, retryAfter, 10*time.Millisecond)
+
+	time.Sleep(retryAfter + time.Millisecond)
+
+	ok, _, err = store.Take(context.Background(), "key", limit, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestMemoryStore_PruneKeepsLimits(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewMemoryStore()
+
+	budget := ratelimit.Budget(100, time.Hour)
+	ok, _, err := store.Take(ctx, "big", budget, 100)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	small := ratelimit.Limit{Rate: 1000, Burst: 1}
+	for i := range 10001 {
+		_, _, err := store.Take(ctx, strconv.Itoa(i), small, 1)
+		require.NoError(t, err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	_, _, err = store.Take(ctx, "prune", small, 1)
+	require.NoError(t, err)
+
+	ok, _, err = store.Take(ctx, "big", budget, 1)
+	require.NoError(t, err)
+	assert.False(t, ok, "drained buckets are pruned against their own limit")
+}
+
+func TestLimiter_StoreError(t *testing.T) {
+	var storeErr error
+	srv := newServer(ratelimit.New(failingStore{}, ratelimit.ByHeader("X-Api-Key"), ratelimit.Limit{Rate: 1, Burst: 1},
+		ratelimit.WithErrorHandler(func(ctx context.Context, err error) { storeErr = err }),
+	))
+
+	resp := doRequest(srv, "a")
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.EqualError(t, storeErr, "store down")
+}
+
+func TestLimit_Validate(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	stores := map[string]ratelimit.Store{
+		"memory": ratelimit.NewMemoryStore(),
+		"redis":  ratelimit.NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "ratelimit:"),
+	}
+
+	for _, limit := range []ratelimit.Limit{{Rate: 0, Burst: 1}, {Rate: -1, Burst: 1}, {Rate: 1, Burst: 0}} {
+		assert.Error(t, limit.Validate())
+		assert.Panics(t, func() { newServer(ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByHeader("X-Api-Key"), limit)) })
+
+		for name, store := range stores {
+			_, _, err := store.Take(context.Background(), "key", limit, 1)
+			assert.Error(t, err, name)
+		}
+	}
+	assert.NoError(t, ratelimit.Budget(10, time.Hour).Validate())
+}
+
+func TestLimiter_InvalidLimitFunc(t *testing.T) {
+	var limitErr error
+	srv := newServer(ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByHeader("X-Api-Key"), ratelimit.Limit{Rate: 1, Burst: 1},
+		ratelimit.WithLimitFunc(func(ctx context.Context, key string) (ratelimit.Limit, bool) {
+			return ratelimit.Limit{Rate: 1}, true
+		}),
+		ratelimit.WithErrorHandler(func(ctx context.Context, err error) { limitErr = err }),
+	))
+
+	resp := doRequest(srv, "a")
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.EqualError(t, limitErr, "ratelimit: burst must be at least 1, got 0")
+
+	resp = doRequest(srv, "a")
+	assert.Contains(t, resp.Body.String(), ratelimit.ErrCodeRateLimited, "the default limit applies")
+}
+
+func TestByRemoteAddr(t *testing.T) {
+	srv := ratelimit.RemoteAddrMiddleware(newServer(ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByRemoteAddr(), ratelimit.Limit{Rate: 1, Burst: 1})))
+
+	resp := doRequest(srv, "")
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	resp = doRequest(srv, "")
+	assert.Contains(t, resp.Body.String(), ratelimit.ErrCodeRateLimited)
+}
+
+func TestLimiter_ComplexityCost(t *testing.T) {
+	srv := newServer(ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByHeader("X-Api-Key"), ratelimit.Budget(10, time.Hour),
+		ratelimit.WithComplexityCost(),
+	))
+
+	// { todos { id } } costs 2, so the budget allows five of them.
+	for i := 0; i < 5; i++ {
+		resp := doRequest(srv, "a")
+		assert.NotContains(t, resp.Body.String(), "errors")
+	}
+
+	resp := doRequest(srv, "a")
+	assert.Contains(t, resp.Body.String(), ratelimit.ErrCodeRateLimited)
+	assert.Contains(t, resp.Body.String(), `"retryAfter":720`)
+}
+
+func TestLimiter_CostFunc(t *testing.T) {
+	premium := ratelimit.Budget(100, time.Hour)
+	srv := newServer(ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.ByHeader("X-Api-Key"), ratelimit.Budget(10, time.Hour),
+		ratelimit.WithCostFunc("Query", "todos", func(childCost int, args map[string]any) int {
+			return 20 * childCost
+		}),
+		ratelimit.WithLimitFunc(func(ctx context.Context, key string) (ratelimit.Limit, bool) {
+			return premium, key == "premium"
+		}),
+	))
+
+	resp := doRequest(srv, "a")
+	assert.JSONEq(t, `{"errors":[{"message":"operation cost exceeds the rate limit budget","extensions":{"code":"COST_EXCEEDS_BUDGET","cost":20,"budget":10}}],"data":null}`, resp.Body.String())
+
+	for i := 0; i < 5; i++ {
+		resp = doRequest(srv, "premium")
+		assert.NotContains(t, resp.Body.String(), "errors")
+	}
+
+	resp = doRequest(srv, "premium")
+	assert.Contains(t, resp.Body.String(), ratelimit.ErrCodeRateLimited)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit, cost int) (bool, time.Duration, error) {
+	return false, 0, errors.New("store down")
+}
+
+func newServer(limiter *ratelimit.Limiter) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(limiter)
+
+	return srv
+}
+
+func doRequest(handler http.Handler, apiKey string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		r.Header.Set("X-Api-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket refilled at Rate tokens per second and
+// holding at most Burst tokens. Every operation takes one token, or its cost
+// when the Limiter charges complexity.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Validate returns an error if l cannot be enforced: Rate must be positive
+// and finite, and Burst at least 1.
+func (l Limit) Validate() error {
+	if !(l.Rate > 0) || math.IsInf(l.Rate, 1) {
+		return fmt.Errorf("ratelimit: rate must be positive, got %v", l.Rate)
+	}
+	if l.Burst < 1 {
+		return fmt.Errorf("ratelimit: burst must be at least 1, got %d", l.Burst)
+	}
+	return nil
+}
+
+// Budget returns a Limit of points per interval. The bucket refills
+// continuously, so a client that spent its whole budget gets it back over
+// one interval.
+func Budget(points int, interval time.Duration) Limit {
+	return Limit{
+		Rate:  float64(points) / interval.Seconds(),
+		Burst: points,
+	}
+}
+
+// Store keeps the token buckets. Take removes cost tokens from the bucket of
+// key and reports whether enough were available; if not, nothing is taken
+// and retryAfter is the time until there will be.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, cost int) (ok bool, retryAfter time.Duration, err error)
+}
+
+// MemoryStore keeps buckets in process memory, so every server instance
+// enforces its own limit.
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	prunedAt time.Time
+}
+
+// bucket holds the limit it was last taken from, keys possibly having
+// different limits.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// pruneThreshold is the number of buckets above which full ones are dropped,
+// at most once per pruneInterval.
+const (
+	pruneThreshold = 10000
+	pruneInterval  = time.Minute
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, cost int) (bool, time.Duration, error) {
+	if err := limit.Validate(); err != nil {
+		return false, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.buckets) > pruneThreshold && now.Sub(s.prunedAt) >= pruneInterval {
+		s.prune(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	b.limit = limit
+
+	if b.tokens >= float64(cost) {
+		b.tokens -= float64(cost)
+		return true, 0, nil
+	}
+
+	return false, time.Duration((float64(cost) - b.tokens) / limit.Rate * float64(time.Second)), nil
+}
+
+// prune drops the buckets that have refilled completely, since they behave
+// exactly like a missing one.
+func (s *MemoryStore) prune(now time.Time) {
+	s.prunedAt = now
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
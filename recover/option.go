@@ -0,0 +1,76 @@
+package recover
+
+import (
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+	hooks       []Hook
+	message     string
+}
+
+// Option is anything that can configure Recoverer.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		registerer: prometheusclient.DefaultRegisterer,
+		message:    "internal system error",
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithNamespace prefixes the metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metric on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
+
+// WithHook calls hook for every recovered panic, for example to report it to
+// an error tracker. Hooks run in the order they were added.
+func WithHook(hook Hook) Option {
+	return func(cfg *config) {
+		cfg.hooks = append(cfg.hooks, hook)
+	}
+}
+
+// WithMessage sets the message returned to clients in place of the panic.
+// Defaults to "internal system error".
+func WithMessage(message string) Option {
+	return func(cfg *config) {
+		cfg.message = message
+	}
+}
@@ -0,0 +1,101 @@
+// Package recover turns resolver panics into a generic error for clients,
+// while keeping the panic value and stack trace for operators.
+package recover
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeInternal is the extensions.code of the error returned for a panic.
+const ErrCodeInternal = "INTERNAL_SERVER_ERROR"
+
+// Panic describes a recovered panic.
+type Panic struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+	// Object, Field and Path locate the resolver that panicked. They are
+	// empty when the panic happened outside of a resolver.
+	Object string
+	Field  string
+	Path   ast.Path
+}
+
+// Hook is called for every recovered panic.
+type Hook func(ctx context.Context, p *Panic)
+
+// Recoverer counts panics in graphql_resolver_panics_total{object,field} and
+// hands them to its hooks. Install it with:
+//
+//	srv.SetRecoverFunc(recover.New().Recover)
+type Recoverer struct {
+	counter    *prometheusclient.CounterVec
+	registerer prometheusclient.Registerer
+	hooks      []Hook
+	message    string
+}
+
+var _ graphql.RecoverFunc = (*Recoverer)(nil).Recover
+
+// New returns a Recoverer whose counter is registered on the configured
+// registerer.
+func New(opts ...Option) *Recoverer {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_resolver_panics_total",
+			Help:        "Total number of panics recovered on the graphql server.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"object", "field"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return &Recoverer{
+		counter:    counter,
+		registerer: cfg.registerer,
+		hooks:      cfg.hooks,
+		message:    cfg.message,
+	}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (r *Recoverer) UnRegister() {
+	r.registerer.Unregister(r.counter)
+}
+
+// Recover is a graphql.RecoverFunc. It must be called while the panic is
+// being recovered for the stack trace to point at the panic.
+func (r *Recoverer) Recover(ctx context.Context, err any) error {
+	p := &Panic{
+		Value: err,
+		Stack: debug.Stack(),
+	}
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		p.Object = fc.Object
+		p.Field = fc.Field.Name
+		p.Path = fc.Path()
+	}
+
+	r.counter.WithLabelValues(p.Object, p.Field).Inc()
+	for _, hook := range r.hooks {
+		hook(ctx, p)
+	}
+
+	return &gqlerror.Error{
+		Message: r.message,
+		Extensions: map[string]any{
+			"code": ErrCodeInternal,
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package recover_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/recover"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestRecoverer(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+
+	var panics []*recover.Panic
+	rec := recover.New(
+		recover.WithRegisterer(registry),
+		recover.WithHook(func(ctx context.Context, p *recover.Panic) {
+			panics = append(panics, p)
+		}),
+	)
+	defer rec.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.SetRecoverFunc(rec.Recover)
+	srv.Use(panicOn("todos"))
+
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{
+		"errors": [{
+			"message": "internal system error",
+			"path": ["todos"],
+			"extensions": {"code": "INTERNAL_SERVER_ERROR"}
+		}],
+		"data": null
+	}`, resp.Body.String())
+
+	require.Len(t, panics, 1)
+	assert.Equal(t, "secret database password", panics[0].Value)
+	assert.Equal(t, "Query", panics[0].Object)
+	assert.Equal(t, "todos", panics[0].Field)
+	assert.Equal(t, "todos", panics[0].Path.String())
+	assert.Contains(t, string(panics[0].Stack), "recover_test.panicOn.InterceptField")
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `graphql_resolver_panics_total{field="todos",object="Query"} 1`)
+}
+
+func TestRecoverer_Message(t *testing.T) {
+	rec := recover.New(
+		recover.WithRegisterer(prometheusclient.NewRegistry()),
+		recover.WithMessage("something went wrong"),
+	)
+
+	var gqlErr *gqlerror.Error
+	require.ErrorAs(t, rec.Recover(context.Background(), "boom"), &gqlErr)
+	assert.Equal(t, "something went wrong", gqlErr.Message)
+}
+
+// panicOn panics when resolving the named field.
+type panicOn string
+
+func (panicOn) ExtensionName() string                          { return "PanicOn" }
+func (panicOn) Validate(schema graphql.ExecutableSchema) error { return nil }
+
+func (p panicOn) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if graphql.GetFieldContext(ctx).Field.Name == string(p) {
+		panic("secret database password")
+	}
+	return next(ctx)
+}
+
+func doRequest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
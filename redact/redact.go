@@ -0,0 +1,258 @@
+// Package redact produces sanitized copies of GraphQL variables, for the
+// logging, tracing and audit extensions to share one set of rules and keep
+// secrets and personal data out of observability backends.
+//
+// Values are redacted by variable path, by input type or by directive:
+//
+//	redactor := redact.New(executableSchema.Schema(),
+//		redact.Path("password", "*.token", "input.*Email"),
+//		redact.Type("Password"),
+//		redact.Directive("sensitive"),
+//	)
+package redact
+
+import (
+	"path"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Value replaces redacted values.
+const Value = "[REDACTED]"
+
+// Rule is anything that can select values to redact.
+type Rule func(r *Redactor)
+
+// Path redacts the values at the given dotted variable paths, such as
+// "input.password". Each segment is a path.Match pattern matched against
+// object keys, so "*" matches any key and "*token*" any key containing
+// token. Lists are traversed transparently.
+func Path(patterns ...string) Rule {
+	return func(r *Redactor) {
+		for _, pattern := range patterns {
+			r.paths.add(strings.Split(pattern, "."))
+		}
+	}
+}
+
+// Type redacts the values of the given named input types, for example a
+// custom Password or Email scalar.
+func Type(names ...string) Rule {
+	return func(r *Redactor) {
+		for _, name := range names {
+			r.types[name] = true
+		}
+	}
+}
+
+// Directive redacts the values of variables, input fields and types carrying
+// the directive name, such as
+//
+//	directive @sensitive on INPUT_FIELD_DEFINITION | SCALAR | INPUT_OBJECT | VARIABLE_DEFINITION
+func Directive(name string) Rule {
+	return func(r *Redactor) {
+		r.directives[name] = true
+	}
+}
+
+// Redactor redacts variables according to its rules. Type and Directive
+// rules need the schema to look nested input types up; without it, they
+// only apply to the variables themselves. A nil Redactor redacts nothing.
+type Redactor struct {
+	schema     *ast.Schema
+	paths      *pathRule
+	types      map[string]bool
+	directives map[string]bool
+}
+
+// New returns a Redactor applying rules, with types resolved in schema,
+// which may be nil.
+func New(schema *ast.Schema, rules ...Rule) *Redactor {
+	r := &Redactor{
+		schema:     schema,
+		paths:      &pathRule{},
+		types:      map[string]bool{},
+		directives: map[string]bool{},
+	}
+
+	for _, rule := range rules {
+		rule(r)
+	}
+
+	return r
+}
+
+// With returns a copy of r applying rules as well. It is safe to call on a
+// nil Redactor, which has no schema.
+func (r *Redactor) With(rules ...Rule) *Redactor {
+	out := New(nil)
+	if r != nil {
+		out.schema = r.schema
+		out.paths = r.paths.clone()
+		for name := range r.types {
+			out.types[name] = true
+		}
+		for name := range r.directives {
+			out.directives[name] = true
+		}
+	}
+
+	for _, rule := range rules {
+		rule(out)
+	}
+
+	return out
+}
+
+// Variables returns a copy of the variables of oc with the values selected
+// by the rules replaced by Value.
+func (r *Redactor) Variables(oc *graphql.OperationContext) map[string]any {
+	if len(oc.Variables) == 0 {
+		return oc.Variables
+	}
+
+	out := make(map[string]any, len(oc.Variables))
+	for name, value := range oc.Variables {
+		if r == nil {
+			out[name] = value
+			continue
+		}
+
+		var def *ast.VariableDefinition
+		if oc.Operation != nil {
+			def = oc.Operation.VariableDefinitions.ForName(name)
+		}
+
+		v := visit{paths: match([]*pathRule{r.paths}, name)}
+		if def != nil {
+			v.def = def.Definition
+			if v.def == nil {
+				v.def = r.lookup(def.Type)
+			}
+			v.sensitive = r.directive(def.Directives) || r.definitionSensitive(v.def) || r.types[def.Type.Name()]
+		}
+		out[name] = r.redact(value, v)
+	}
+
+	return out
+}
+
+// visit is the state of the walk of a value.
+type visit struct {
+	// paths are the path rules matching the value.
+	paths []*pathRule
+	// def is the type of the value, when known.
+	def *ast.Definition
+	// sensitive is set when a directive or type rule selects the value.
+	sensitive bool
+}
+
+func (r *Redactor) redact(value any, v visit) any {
+	if v.sensitive {
+		return Value
+	}
+	for _, p := range v.paths {
+		if p.redact {
+			return Value
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, field := range value {
+			child := visit{paths: match(v.paths, key)}
+			if v.def != nil {
+				if fd := v.def.Fields.ForName(key); fd != nil {
+					child.def = r.lookup(fd.Type)
+					child.sensitive = r.directive(fd.Directives) || r.definitionSensitive(child.def) || r.types[fd.Type.Name()]
+				}
+			}
+			out[key] = r.redact(field, child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, elem := range value {
+			out[i] = r.redact(elem, v)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// definitionSensitive reports whether a type rule or a directive on the
+// type itself selects values of def.
+func (r *Redactor) definitionSensitive(def *ast.Definition) bool {
+	if def == nil {
+		return false
+	}
+	return r.types[def.Name] || r.directive(def.Directives)
+}
+
+func (r *Redactor) directive(directives ast.DirectiveList) bool {
+	for _, d := range directives {
+		if r.directives[d.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) lookup(typ *ast.Type) *ast.Definition {
+	if r.schema == nil || typ == nil {
+		return nil
+	}
+	return r.schema.Types[typ.Name()]
+}
+
+// pathRule is a tree of path patterns.
+type pathRule struct {
+	redact   bool
+	children []*pathChild
+}
+
+type pathChild struct {
+	pattern string
+	rule    *pathRule
+}
+
+func (p *pathRule) add(segments []string) {
+	if len(segments) == 0 {
+		p.redact = true
+		return
+	}
+	for _, child := range p.children {
+		if child.pattern == segments[0] {
+			child.rule.add(segments[1:])
+			return
+		}
+	}
+	child := &pathChild{pattern: segments[0], rule: &pathRule{}}
+	p.children = append(p.children, child)
+	child.rule.add(segments[1:])
+}
+
+func (p *pathRule) clone() *pathRule {
+	out := &pathRule{redact: p.redact}
+	for _, child := range p.children {
+		out.children = append(out.children, &pathChild{pattern: child.pattern, rule: child.rule.clone()})
+	}
+	return out
+}
+
+// match returns the children of the rules from whose pattern matches key.
+func match(from []*pathRule, key string) []*pathRule {
+	var matched []*pathRule
+	for _, rule := range from {
+		for _, child := range rule.children {
+			if ok, _ := path.Match(child.pattern, key); ok {
+				matched = append(matched, child.rule)
+			}
+		}
+	}
+	return matched
+}
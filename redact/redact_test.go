@@ -0,0 +1,109 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const schema = `
+directive @sensitive on INPUT_FIELD_DEFINITION | SCALAR | INPUT_OBJECT | VARIABLE_DEFINITION
+
+scalar Password
+scalar Email @sensitive
+
+input Card @sensitive {
+	number: String!
+}
+
+input SignUp {
+	name: String!
+	email: Email!
+	password: Password!
+	phone: String @sensitive
+	cards: [Card!]
+	tags: [Tag!]
+}
+
+input Tag {
+	label: String!
+	token: String
+}
+
+type Query {
+	ok: Boolean
+}
+
+type Mutation {
+	signUp(input: SignUp!, note: String, apiToken: String): Boolean
+}
+`
+
+const query = `mutation($input: SignUp!, $note: String @sensitive, $apiToken: String) {
+	signUp(input: $input, note: $note, apiToken: $apiToken)
+}`
+
+func TestRedactor(t *testing.T) {
+	s := gqlparser.MustLoadSchema(&ast.Source{Input: schema})
+	doc, errs := gqlparser.LoadQuery(s, query)
+	require.Empty(t, errs)
+
+	oc := &graphql.OperationContext{
+		Operation: doc.Operations[0],
+		Variables: map[string]any{
+			"input": map[string]any{
+				"name":     "Ada",
+				"email":    "ada@example.com",
+				"password": "hunter2",
+				"phone":    "555",
+				"cards":    []any{map[string]any{"number": "4242"}},
+				"tags":     []any{map[string]any{"label": "a", "token": "t1"}, map[string]any{"label": "b"}},
+			},
+			"note":     "private",
+			"apiToken": "secret",
+		},
+	}
+
+	redactor := redact.New(s,
+		redact.Path("*Token", "input.tags.token"),
+		redact.Type("Password"),
+		redact.Directive("sensitive"),
+	)
+
+	assert.Equal(t, map[string]any{
+		"input": map[string]any{
+			"name":     "Ada",
+			"email":    redact.Value,
+			"password": redact.Value,
+			"phone":    redact.Value,
+			"cards":    redact.Value,
+			"tags":     []any{map[string]any{"label": "a", "token": redact.Value}, map[string]any{"label": "b"}},
+		},
+		"note":     redact.Value,
+		"apiToken": redact.Value,
+	}, redactor.Variables(oc))
+
+	// The original variables are left untouched.
+	assert.Equal(t, "hunter2", oc.Variables["input"].(map[string]any)["password"])
+
+	pathsOnly := redact.New(nil, redact.Path("input.name"))
+	assert.Equal(t, map[string]any{
+		"name":     redact.Value,
+		"email":    "ada@example.com",
+		"password": "hunter2",
+		"phone":    "555",
+		"cards":    []any{map[string]any{"number": "4242"}},
+		"tags":     []any{map[string]any{"label": "a", "token": "t1"}, map[string]any{"label": "b"}},
+	}, pathsOnly.Variables(oc)["input"])
+	assert.Equal(t, "secret", pathsOnly.Variables(oc)["apiToken"], "patterns match from the root only")
+
+	var none *redact.Redactor
+	assert.Equal(t, oc.Variables, none.Variables(oc))
+	assert.Equal(t, redact.Value, none.With(redact.Path("note")).Variables(oc)["note"])
+	assert.Equal(t, "private", pathsOnly.Variables(oc)["note"], "With leaves the original Redactor unchanged")
+}
@@ -0,0 +1,210 @@
+// Package responsecache caches the responses of queries, so repeated
+// identical queries are answered without running any resolver.
+package responsecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/cachecontrol"
+	"github.com/99designs/gqlgen-contrib/internal/entities"
+	"github.com/99designs/gqlgen-contrib/normalize"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Cache is a gqlgen handler extension caching successful query responses in
+// a Store. Mutations and subscriptions are never cached.
+//
+// Hits are served from the response interceptors, with the extensions the
+// response had and the policy collected by the cachecontrol extension, so
+// the extensions registered before Cache, such as cachecontrol, metrics and
+// logging, see hits like any other response. Those registered after it only
+// see misses, and no field interceptor runs on hits.
+//
+// Entries are keyed by the operation as normalized by the normalize
+// package, keeping literals and field order, the variables and the WithVary
+// value. They expire after the configured TTL, or sooner if a selected field
+// or its type has a shorter @cacheControl(maxAge: ...) hint; a maxAge of 0
+// disables caching.
+//
+// With WithStaleTTL, entries are kept longer than their TTL: once expired,
+// they are still served while the query is executed again in the
+// background to refresh them.
+//
+// With WithInvalidator, Invalidate purges the entries containing a given
+// object, for example from the resolver of a mutation updating it.
+type Cache struct {
+	store   Store
+	cfg     config
+	schema  *ast.Schema
+	exec    *executor.Executor
+	metrics *metrics
+
+	refreshes  chan struct{}
+	mu         sync.Mutex
+	refreshing map[string]bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+} = (*Cache)(nil)
+
+// New returns a Cache keeping responses in store.
+func New(store Store, opts ...Option) *Cache {
+	c := &Cache{
+		store:      store,
+		cfg:        *newConfig(opts...),
+		refreshing: map[string]bool{},
+	}
+	c.refreshes = make(chan struct{}, max(c.cfg.refreshConcurrency, 1))
+	if c.cfg.registerer != nil {
+		c.metrics = newMetrics(&c.cfg)
+	}
+	return c
+}
+
+// UnRegister removes the metrics from the registerer given to
+// WithRegisterer.
+func (c *Cache) UnRegister() {
+	if c.metrics != nil {
+		c.metrics.unregister(c.cfg.registerer)
+	}
+}
+
+func (c *Cache) ExtensionName() string {
+	return "ResponseCache"
+}
+
+func (c *Cache) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema.Schema()
+	c.exec = executor.New(schema)
+	return nil
+}
+
+func (c *Cache) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Query {
+		return next(ctx)
+	}
+
+	ttl := c.cfg.ttl
+	if hint, ok := maxAge(c.schema, oc.Operation.SelectionSet); ok && hint < ttl {
+		ttl = hint
+	}
+	if ttl <= 0 {
+		return next(ctx)
+	}
+
+	key, err := c.key(ctx, oc)
+	if err != nil {
+		c.cfg.errorHandler(ctx, err)
+		return next(ctx)
+	}
+
+	value, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.cfg.errorHandler(ctx, err)
+	} else if ok {
+		if e, fresh, ok := c.decode(value); ok {
+			c.cfg.onHit(ctx)
+			if fresh {
+				c.metrics.served(resultFresh)
+			} else {
+				c.metrics.served(resultStale)
+				c.refresh(ctx, key, ttl)
+			}
+			return next(context.WithValue(ctx, lookupKey{}, &lookup{key: key, ttl: ttl, hit: e}))
+		}
+	}
+	c.cfg.onMiss(ctx)
+	c.metrics.served(resultMiss)
+
+	return next(context.WithValue(ctx, lookupKey{}, &lookup{key: key, ttl: ttl}))
+}
+
+// InterceptResponse serves hits in place of the resolvers, so the response
+// interceptors registered before Cache see them like any other response, and
+// stores the responses of misses as seen from there.
+func (c *Cache) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	l, ok := ctx.Value(lookupKey{}).(*lookup)
+	if !ok {
+		return next(ctx)
+	}
+	if l.hit == nil {
+		res := next(ctx)
+		c.set(ctx, l.key, res, l.ttl)
+		return res
+	}
+
+	if l.served {
+		return nil
+	}
+	l.served = true
+	if l.hit.Policy != nil {
+		cachecontrol.SetPolicy(ctx, *l.hit.Policy)
+	}
+	return &graphql.Response{Data: l.hit.Data, Extensions: l.hit.Extensions}
+}
+
+type lookupKey struct{}
+
+// lookup is the cache entry of an operation, hit being nil on misses.
+type lookup struct {
+	key    string
+	ttl    time.Duration
+	hit    *entry
+	served bool
+}
+
+// set stores res under key if it is a successful response. The payloads of
+// incremental responses, such as those of @defer, are never complete.
+func (c *Cache) set(ctx context.Context, key string, res *graphql.Response, ttl time.Duration) bool {
+	if res == nil || len(res.Errors) != 0 || res.Data == nil || res.HasNext != nil {
+		return false
+	}
+	e := &entry{Data: res.Data, Extensions: res.Extensions}
+	if policy, ok := cachecontrol.PolicyFor(ctx); ok {
+		e.Policy = &policy
+	}
+	value, err := c.encode(e, ttl)
+	if err == nil {
+		err = c.store.Set(ctx, key, value, ttl+c.cfg.staleTTL)
+	}
+	if err != nil {
+		c.cfg.errorHandler(ctx, err)
+		return false
+	}
+	if c.cfg.invalidator != nil {
+		found := entities.FromSelection(c.schema, graphql.GetOperationContext(ctx).Operation.SelectionSet, res.Data)
+		c.cfg.invalidator.Track(c.store, key, ttl+c.cfg.staleTTL, found...)
+	}
+	return true
+}
+
+func (c *Cache) key(ctx context.Context, oc *graphql.OperationContext) (string, error) {
+	variables, err := json.Marshal(oc.Variables)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := normalize.Document(oc.Doc, oc.OperationName, normalize.KeepLiterals(), normalize.KeepOrder())
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(doc), variables, []byte(c.cfg.vary(ctx))} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,343 @@
+package responsecache_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/cachecontrol"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/responsecache"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/alicebob/miniredis/v2"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	stores := map[string]responsecache.Store{
+		"memory": responsecache.NewMemoryStore(100),
+		"redis":  responsecache.NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "responsecache:"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			recorder := &recordingStore{Store: store}
+			srv, resolved := newServer(responsecache.New(recorder, responsecache.WithVary(func(ctx context.Context) string {
+				return graphql.GetOperationContext(ctx).Headers.Get("Authorization")
+			})))
+
+			first := doRequest(srv, `{"query":"query Q($id: ID!) { todo(id: $id) { id text } }","variables":{"id":"`+graph.TodoA.ID+`"}}`, "")
+			require.Equal(t, http.StatusOK, first.Code)
+			require.NotZero(t, resolved.Load())
+
+			resolved.Store(0)
+			second := doRequest(srv, `{"query":"query Q($id: ID!) {\n  todo(id: $id) {\n    id\n    text\n  }\n}","variables":{"id":"`+graph.TodoA.ID+`"}}`, "")
+			assert.Equal(t, first.Body.String(), second.Body.String())
+			assert.Zero(t, resolved.Load())
+			assert.Equal(t, time.Minute, recorder.ttl)
+
+			doRequest(srv, `{"query":"query Q($id: ID!) { todo(id: $id) { id text } }","variables":{"id":"`+graph.TodoB.ID+`"}}`, "")
+			assert.NotZero(t, resolved.Load(), "variables are part of the key")
+
+			resolved.Store(0)
+			doRequest(srv, `{"query":"query Q($id: ID!) { todo(id: $id) { id text } }","variables":{"id":"`+graph.TodoA.ID+`"}}`, "Bearer other")
+			assert.NotZero(t, resolved.Load(), "vary is part of the key")
+		})
+	}
+}
+
+func TestCache_HitMissHooks(t *testing.T) {
+	var hits, misses int
+	srv, _ := newServer(responsecache.New(responsecache.NewMemoryStore(100), responsecache.WithHitMissHooks(
+		func(ctx context.Context) { hits++ },
+		func(ctx context.Context) { misses++ },
+	)))
+
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	doRequest(srv, `{"query":"mutation { createTodo(input: {text: \"x\", userId: \"1\"}) { id } }"}`, "")
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 1, misses)
+}
+
+func TestCache_Hints(t *testing.T) {
+	recorder := &recordingStore{Store: responsecache.NewMemoryStore(100)}
+	srv, _ := newServer(responsecache.New(recorder, responsecache.WithTTL(time.Hour)))
+
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	assert.Equal(t, time.Minute, recorder.ttl)
+
+	doRequest(srv, `{"query":"{ todos { user { ... on User { name } } } }"}`, "")
+	assert.Equal(t, 30*time.Second, recorder.ttl)
+}
+
+func TestCache_Uncached(t *testing.T) {
+	recorder := &recordingStore{Store: responsecache.NewMemoryStore(100)}
+	srv, _ := newServer(responsecache.New(recorder))
+
+	doRequest(srv, `{"query":"mutation { createTodo(input: {text: \"a\", userId: \"1\"}) { id } }"}`, "")
+	doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`, "")
+	assert.Zero(t, recorder.sets)
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	cache := responsecache.New(responsecache.NewMemoryStore(100),
+		responsecache.WithTTL(20*time.Millisecond),
+		responsecache.WithStaleTTL(time.Minute, 1),
+		responsecache.WithRegisterer(registry),
+	)
+	defer cache.UnRegister()
+	srv, resolved := newServer(cache)
+	metrics := func() string {
+		w := httptest.NewRecorder()
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w.Body.String()
+	}
+
+	first := doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	require.NotZero(t, resolved.Load())
+
+	time.Sleep(30 * time.Millisecond)
+	resolved.Store(0)
+	stale := doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	assert.Equal(t, first.Body.String(), stale.Body.String())
+	require.Eventually(t, func() bool {
+		return strings.Contains(metrics(), `graphql_response_cache_refreshes_total{result="succeeded"} 1`)
+	}, time.Second, 5*time.Millisecond)
+	assert.NotZero(t, resolved.Load(), "stale entry is refreshed in the background")
+
+	resolved.Store(0)
+	fresh := doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	assert.Equal(t, first.Body.String(), fresh.Body.String())
+	assert.Zero(t, resolved.Load())
+
+	for _, result := range []string{"fresh", "stale", "miss"} {
+		assert.Contains(t, metrics(), `graphql_response_cache_requests_total{result="`+result+`"} 1`)
+	}
+}
+
+func TestCache_StaleInterceptors(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	cache := responsecache.New(responsecache.NewMemoryStore(100),
+		responsecache.WithTTL(20*time.Millisecond),
+		responsecache.WithStaleTTL(time.Minute, 1),
+		responsecache.WithRegisterer(registry),
+	)
+	defer cache.UnRegister()
+	responses := responseCounter{new(atomic.Int64)}
+	srv, resolved := newServer(cache, responses)
+
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	time.Sleep(30 * time.Millisecond)
+	resolved.Store(0)
+	doRequest(srv, `{"query":"{ todos { id } }"}`, "")
+	assert.Equal(t, int64(2), responses.Load(), "response interceptors see the stale response")
+	require.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return strings.Contains(w.Body.String(), `graphql_response_cache_refreshes_total{result="succeeded"} 1`)
+	}, time.Second, 5*time.Millisecond)
+
+	assert.NotZero(t, resolved.Load(), "field interceptors run for the refresh")
+	assert.Equal(t, int64(2), responses.Load(), "response interceptors do not see the refresh")
+}
+
+func TestCache_Replay(t *testing.T) {
+	responses := responseCounter{new(atomic.Int64)}
+	srv, resolved := newServer(responsecache.New(responsecache.NewMemoryStore(100)), cachecontrol.New(), responses)
+	var inner atomic.Int64
+	srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		res := next(ctx)
+		if res != nil {
+			inner.Add(1)
+			res.Extensions = map[string]any{"inner": true}
+		}
+		return res
+	})
+	handler := cachecontrol.Middleware(srv)
+
+	miss := doRequest(handler, `{"query":"{ todos { id user { name } } }"}`, "")
+	require.Equal(t, http.StatusOK, miss.Code)
+	require.Contains(t, miss.Body.String(), `"inner":true`)
+	require.Contains(t, miss.Body.String(), `"cacheControl"`)
+
+	resolved.Store(0)
+	hit := doRequest(handler, `{"query":"{ todos { id user { name } } }"}`, "")
+	assert.Zero(t, resolved.Load())
+	assert.Equal(t, int64(1), inner.Load(), "interceptors after Cache only see misses")
+	assert.Equal(t, int64(2), responses.Load(), "interceptors before Cache see hits")
+	assert.Equal(t, miss.Body.String(), hit.Body.String(), "extensions are replayed")
+	assert.Equal(t, "max-age=30, private", miss.Header().Get("Cache-Control"))
+	assert.Equal(t, miss.Header().Get("Cache-Control"), hit.Header().Get("Cache-Control"), "the cache policy is replayed")
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var servers []*handler.Server
+	var resolved []*atomic.Int64
+	var caches []*responsecache.Cache
+	for range 2 {
+		inv := responsecache.NewInvalidator(responsecache.WithPubSub(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "invalidations"))
+		go inv.Listen(ctx)
+		cache := responsecache.New(responsecache.NewMemoryStore(100), responsecache.WithInvalidator(inv))
+		srv, counter := newServer(cache)
+		servers = append(servers, srv)
+		resolved = append(resolved, counter)
+		caches = append(caches, cache)
+	}
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub("invalidations")["invalidations"] == 2
+	}, time.Second, 5*time.Millisecond)
+
+	queries := []string{
+		`{"query":"{ todos { id text } }"}`,
+		`{"query":"{ a: todo(id: \"` + graph.TodoA.ID + `\") { text ... on Todo { id } } }"}`,
+		`{"query":"{ todo(id: \"` + graph.TodoB.ID + `\") { id text } }"}`,
+	}
+	for _, srv := range servers {
+		for _, query := range queries {
+			require.Equal(t, http.StatusOK, doRequest(srv, query, "").Code)
+		}
+	}
+
+	require.NoError(t, caches[0].Invalidate(context.Background(), "Todo", graph.TodoA.ID))
+
+	for i, srv := range servers {
+		require.Eventually(t, func() bool {
+			resolved[i].Store(0)
+			doRequest(srv, queries[1], "")
+			return resolved[i].Load() != 0
+		}, time.Second, 5*time.Millisecond, "entry is purged on every instance")
+
+		resolved[i].Store(0)
+		doRequest(srv, queries[0], "")
+		assert.NotZero(t, resolved[i].Load(), "lists containing the entity are purged")
+
+		resolved[i].Store(0)
+		doRequest(srv, queries[2], "")
+		assert.Zero(t, resolved[i].Load(), "other entities stay cached")
+	}
+
+	assert.Error(t, responsecache.New(responsecache.NewMemoryStore(100)).Invalidate(context.Background(), "Todo", graph.TodoA.ID))
+}
+
+func TestCache_Defer(t *testing.T) {
+	srv, resolved := newServer(responsecache.New(responsecache.NewMemoryStore(100)))
+	srv.AddTransport(transport.MultipartMixed{})
+	deferred := func() string {
+		r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id ... @defer { completed } } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "multipart/mixed")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	first := deferred()
+	require.Contains(t, first, "completed")
+	resolved.Store(0)
+	assert.Contains(t, deferred(), "completed")
+	assert.NotZero(t, resolved.Load(), "incremental responses are not cached")
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := responsecache.NewMemoryStore(2)
+
+	require.NoError(t, store.Set(ctx, "a", []byte("a"), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", []byte("b"), time.Minute))
+	_, ok, _ := store.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, store.Set(ctx, "c", []byte("c"), time.Minute))
+
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok, "least recently used entry is evicted")
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	require.NoError(t, store.Set(ctx, "d", []byte("d"), time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	_, ok, _ = store.Get(ctx, "d")
+	assert.False(t, ok, "expired entry is not returned")
+}
+
+type recordingStore struct {
+	responsecache.Store
+	sets int
+	ttl  time.Duration
+}
+
+func (s *recordingStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.sets++
+	s.ttl = ttl
+	return s.Store.Set(ctx, key, value, ttl)
+}
+
+// resolveCounter counts the fields resolved by the server.
+type resolveCounter struct{ *atomic.Int64 }
+
+func (resolveCounter) ExtensionName() string                          { return "ResolveCounter" }
+func (resolveCounter) Validate(schema graphql.ExecutableSchema) error { return nil }
+
+func (c resolveCounter) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	c.Add(1)
+	return next(ctx)
+}
+
+// responseCounter counts the responses seen by its position in the server.
+type responseCounter struct{ *atomic.Int64 }
+
+func (responseCounter) ExtensionName() string                          { return "ResponseCounter" }
+func (responseCounter) Validate(schema graphql.ExecutableSchema) error { return nil }
+
+func (c responseCounter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res != nil {
+		c.Add(1)
+	}
+	return res
+}
+
+// newServer returns a server using cache, after the extensions in before.
+func newServer(cache *responsecache.Cache, before ...graphql.HandlerExtension) (*handler.Server, *atomic.Int64) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	for _, ext := range before {
+		srv.Use(ext)
+	}
+	srv.Use(cache)
+
+	resolved := new(atomic.Int64)
+	srv.Use(resolveCounter{resolved})
+
+	return srv, resolved
+}
+
+func doRequest(handler http.Handler, body string, authorization string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,72 @@
+package responsecache
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// maxAge returns the smallest maxAge of the @cacheControl hints on the fields
+// selected by set, or on the types they return, and whether any was found.
+func maxAge(schema *ast.Schema, set ast.SelectionSet) (time.Duration, bool) {
+	var (
+		age   time.Duration
+		found bool
+	)
+
+	var walk func(set ast.SelectionSet)
+	walk = func(set ast.SelectionSet) {
+		for _, sel := range set {
+			switch sel := sel.(type) {
+			case *ast.Field:
+				if hint, ok := fieldHint(schema, sel); ok && (!found || hint < age) {
+					age, found = hint, true
+				}
+				walk(sel.SelectionSet)
+			case *ast.InlineFragment:
+				walk(sel.SelectionSet)
+			case *ast.FragmentSpread:
+				if sel.Definition != nil {
+					walk(sel.Definition.SelectionSet)
+				}
+			}
+		}
+	}
+	walk(set)
+
+	return age, found
+}
+
+// fieldHint returns the maxAge hint of a field definition, falling back to
+// the type it returns.
+func fieldHint(schema *ast.Schema, field *ast.Field) (time.Duration, bool) {
+	if field.Definition == nil {
+		return 0, false
+	}
+	if hint, ok := directiveMaxAge(field.Definition.Directives); ok {
+		return hint, true
+	}
+	if def := schema.Types[field.Definition.Type.Name()]; def != nil {
+		return directiveMaxAge(def.Directives)
+	}
+
+	return 0, false
+}
+
+func directiveMaxAge(directives ast.DirectiveList) (time.Duration, bool) {
+	d := directives.ForName("cacheControl")
+	if d == nil {
+		return 0, false
+	}
+	arg := d.Arguments.ForName("maxAge")
+	if arg == nil || arg.Value == nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(arg.Value.Raw)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
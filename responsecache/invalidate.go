@@ -0,0 +1,194 @@
+package responsecache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/entities"
+	"github.com/redis/go-redis/v9"
+)
+
+// Entity is an object identified by its typename and id.
+type Entity = entities.Entity
+
+// Invalidator purges the cached entries containing an entity, an object
+// identified by its typename and id. Caches given it with WithInvalidator
+// track the entities of the entries they store, found by scanning their
+// data for objects whose id is selected.
+//
+// Entries are tracked in process memory, by the instance storing them. With
+// WithPubSub, invalidations are broadcast for every instance running Listen
+// to purge the entries it tracks, whether in its own MemoryStore or in a
+// shared RedisStore.
+type Invalidator struct {
+	cfg invalidatorConfig
+
+	mu      sync.Mutex
+	tracked map[string]map[trackedKey]time.Time
+	adds    int
+}
+
+// trackedKey is an entry of a store, an Invalidator being shared by caches
+// using different stores.
+type trackedKey struct {
+	store Deleter
+	key   string
+}
+
+// sweepEvery is the number of tracked entries after which those expired are
+// forgotten.
+const sweepEvery = 1024
+
+// NewInvalidator returns an Invalidator.
+func NewInvalidator(opts ...InvalidatorOption) *Invalidator {
+	return &Invalidator{
+		cfg:     *newInvalidatorConfig(opts...),
+		tracked: map[string]map[trackedKey]time.Time{},
+	}
+}
+
+// Track records that the entry stored under key for ttl contains the given
+// entities. It does nothing if store is not a Deleter.
+func (i *Invalidator) Track(store Store, key string, ttl time.Duration, found ...Entity) {
+	d, ok := store.(Deleter)
+	if !ok || len(found) == 0 {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	expires := now.Add(ttl)
+	for _, e := range found {
+		keys := i.tracked[e.String()]
+		if keys == nil {
+			keys = map[trackedKey]time.Time{}
+			i.tracked[e.String()] = keys
+		}
+		keys[trackedKey{store: d, key: key}] = expires
+	}
+
+	i.adds++
+	if i.adds%sweepEvery == 0 {
+		for entity, keys := range i.tracked {
+			for k, expires := range keys {
+				if now.After(expires) {
+					delete(keys, k)
+				}
+			}
+			if len(keys) == 0 {
+				delete(i.tracked, entity)
+			}
+		}
+	}
+}
+
+// Invalidate purges the entries containing the object of type typename
+// identified by id, publishing the invalidation for the other instances
+// with WithPubSub.
+func (i *Invalidator) Invalidate(ctx context.Context, typename, id string) error {
+	entity := Entity{Typename: typename, ID: id}.String()
+	err := i.purge(ctx, entity)
+	if i.cfg.client != nil {
+		err = errors.Join(err, i.cfg.client.Publish(ctx, i.cfg.channel, entity).Err())
+	}
+	return err
+}
+
+// Listen purges the entries containing the entities invalidated by the
+// other instances until ctx is done, requiring WithPubSub. Run it in its own
+// goroutine.
+func (i *Invalidator) Listen(ctx context.Context) error {
+	if i.cfg.client == nil {
+		return errors.New("responsecache: Listen requires WithPubSub")
+	}
+
+	sub := i.cfg.client.Subscribe(ctx, i.cfg.channel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			if err := i.purge(ctx, msg.Payload); err != nil {
+				i.cfg.errorHandler(ctx, err)
+			}
+		}
+	}
+}
+
+// purge deletes the entries tracked for entity.
+func (i *Invalidator) purge(ctx context.Context, entity string) error {
+	i.mu.Lock()
+	tracked := i.tracked[entity]
+	delete(i.tracked, entity)
+	i.mu.Unlock()
+
+	byStore := map[Deleter][]string{}
+	for k := range tracked {
+		byStore[k.store] = append(byStore[k.store], k.key)
+	}
+
+	var errs []error
+	for store, keys := range byStore {
+		errs = append(errs, store.Delete(ctx, keys...))
+	}
+	return errors.Join(errs...)
+}
+
+type invalidatorConfig struct {
+	client       redis.UniversalClient
+	channel      string
+	errorHandler func(ctx context.Context, err error)
+}
+
+// InvalidatorOption is anything that can configure Invalidator.
+type InvalidatorOption func(cfg *invalidatorConfig)
+
+func newInvalidatorConfig(opts ...InvalidatorOption) *invalidatorConfig {
+	cfg := &invalidatorConfig{
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithPubSub broadcasts invalidations on the given redis channel.
+func WithPubSub(client redis.UniversalClient, channel string) InvalidatorOption {
+	return func(cfg *invalidatorConfig) {
+		cfg.client = client
+		cfg.channel = channel
+	}
+}
+
+// WithInvalidationErrorHandler is called when Listen fails to purge the
+// entries of an entity invalidated by another instance.
+func WithInvalidationErrorHandler(fn func(ctx context.Context, err error)) InvalidatorOption {
+	return func(cfg *invalidatorConfig) {
+		cfg.errorHandler = fn
+	}
+}
+
+// Invalidate purges the entries containing the object of type typename
+// identified by id, see Invalidator.Invalidate.
+func (c *Cache) Invalidate(ctx context.Context, typename, id string) error {
+	if c.cfg.invalidator == nil {
+		return errors.New("responsecache: Invalidate requires WithInvalidator")
+	}
+	return c.cfg.invalidator.Invalidate(ctx, typename, id)
+}
@@ -0,0 +1,140 @@
+package responsecache
+
+import (
+	"context"
+	"time"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	ttl          time.Duration
+	vary         func(ctx context.Context) string
+	onHit        func(ctx context.Context)
+	onMiss       func(ctx context.Context)
+	errorHandler func(ctx context.Context, err error)
+
+	staleTTL           time.Duration
+	refreshConcurrency int
+	invalidator        *Invalidator
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Cache.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	nop := func(ctx context.Context) {}
+	cfg := &config{
+		ttl:          time.Minute,
+		vary:         func(ctx context.Context) string { return "" },
+		onHit:        nop,
+		onMiss:       nop,
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithTTL sets how long responses are cached when the selected fields carry
+// no shorter @cacheControl hint. Defaults to one minute.
+func WithTTL(ttl time.Duration) Option {
+	return func(cfg *config) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithVary adds the value returned by fn to the cache key, so responses are
+// only shared between requests for which it is equal. Use it whenever data
+// depends on who is asking, for example by returning the authenticated user
+// or their scopes.
+func WithVary(fn func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.vary = fn
+	}
+}
+
+// WithHitMissHooks calls onHit or onMiss after every lookup of a cacheable
+// query, for example to count cache hits and misses. Either may be nil.
+func WithHitMissHooks(onHit, onMiss func(ctx context.Context)) Option {
+	return func(cfg *config) {
+		if onHit != nil {
+			cfg.onHit = onHit
+		}
+		if onMiss != nil {
+			cfg.onMiss = onMiss
+		}
+	}
+}
+
+// WithErrorHandler is called when the store fails. Requests are executed
+// normally in that case.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithStaleTTL keeps entries for stale after they expire, serving them while
+// refreshing them in the background, running at most concurrency refreshes
+// at a time. A stale entry is served as is when the limit is reached, to be
+// refreshed by a later request. Refreshes are not seen by the operation and
+// response interceptors of the server, only by its field interceptors.
+//
+// Entries are stored along with the time they expire at, so stores must not
+// be shared with caches configured without a stale TTL.
+func WithStaleTTL(stale time.Duration, concurrency int) Option {
+	return func(cfg *config) {
+		cfg.staleTTL = stale
+		cfg.refreshConcurrency = concurrency
+	}
+}
+
+// WithInvalidator tracks the entities of the stored responses in inv, for
+// Invalidate to purge them. Responses are scanned for objects whose id is
+// selected, along with __typename for those of interfaces and unions.
+func WithInvalidator(inv *Invalidator) Option {
+	return func(cfg *config) {
+		cfg.invalidator = inv
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer exports metrics on registerer: queries by result, fresh,
+// stale or miss, in graphql_response_cache_requests_total{result}, and the
+// background refreshes in graphql_response_cache_refreshes_total{result}.
+// No metrics are exported without it.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,103 @@
+package responsecache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps cached response data.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Deleter is implemented by the stores entries can be invalidated in.
+type Deleter interface {
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// MemoryStore is a Store keeping up to a fixed number of entries in process
+// memory, evicting the least recently used one first.
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+var (
+	_ Store   = (*MemoryStore)(nil)
+	_ Deleter = (*MemoryStore)(nil)
+)
+
+// NewMemoryStore returns a MemoryStore holding at most size entries.
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{
+		size:    size,
+		ll:      list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if time.Now().After(entry.expires) {
+		s.remove(el)
+		return nil, false, nil
+	}
+
+	s.ll.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if el, ok := s.entries[key]; ok {
+		el.Value = entry
+		s.ll.MoveToFront(el)
+		return nil
+	}
+
+	s.entries[key] = s.ll.PushFront(entry)
+	for s.ll.Len() > s.size {
+		s.remove(s.ll.Back())
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := s.entries[key]; ok {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) remove(el *list.Element) {
+	s.ll.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
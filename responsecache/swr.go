@@ -0,0 +1,175 @@
+package responsecache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/cachecontrol"
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// refreshTimeout bounds the background executions refreshing stale
+// entries, whose request may be long gone.
+const refreshTimeout = 30 * time.Second
+
+// staleMarker starts the entries stored with a stale TTL, followed by the
+// time they expire at. Entries, being JSON, never start with it.
+const staleMarker = 0xff
+
+const (
+	resultFresh = "fresh"
+	resultStale = "stale"
+	resultMiss  = "miss"
+)
+
+// entry is what is stored of a response: its data and extensions, as seen
+// by Cache in the response interceptors, and the policy collected by
+// cachecontrol, if in use, which are all replayed on hits.
+type entry struct {
+	Data       json.RawMessage      `json:"data"`
+	Extensions map[string]any       `json:"extensions,omitempty"`
+	Policy     *cachecontrol.Policy `json:"cacheControl,omitempty"`
+}
+
+// encode returns the stored value of e, which expires after ttl.
+func (c *Cache) encode(e *entry, ttl time.Duration) ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil || c.cfg.staleTTL <= 0 {
+		return data, err
+	}
+	value := make([]byte, 9, 9+len(data))
+	value[0] = staleMarker
+	binary.BigEndian.PutUint64(value[1:], uint64(time.Now().Add(ttl).UnixNano()))
+	return append(value, data...), nil
+}
+
+// decode returns the entry of value and whether it is fresh, false if value
+// was not stored with the current configuration.
+func (c *Cache) decode(value []byte) (e *entry, fresh, ok bool) {
+	fresh = true
+	if c.cfg.staleTTL > 0 {
+		if len(value) < 9 || value[0] != staleMarker {
+			return nil, false, false
+		}
+		expires := time.Unix(0, int64(binary.BigEndian.Uint64(value[1:9])))
+		value, fresh = value[9:], time.Now().Before(expires)
+	} else if len(value) != 0 && value[0] == staleMarker {
+		return nil, false, false
+	}
+
+	if err := json.Unmarshal(value, &e); err != nil || e == nil || e.Data == nil {
+		return nil, false, false
+	}
+	return e, fresh, true
+}
+
+// refresh executes the operation of ctx again in the background to store its
+// response under key, unless the key is already being refreshed or the
+// refreshes in flight are at the limit of WithStaleTTL.
+//
+// The refresh runs on a dedicated executor, outside the operation and
+// response interceptors of the server, which only see the served response.
+// Field interceptors still run, since they may change what resolvers return.
+func (c *Cache) refresh(ctx context.Context, key string, ttl time.Duration) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	select {
+	case c.refreshes <- struct{}{}:
+	default:
+		c.mu.Unlock()
+		c.metrics.refreshed(refreshSkipped)
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	oc := graphql.GetOperationContext(ctx)
+	refreshed := &graphql.OperationContext{
+		RawQuery:               oc.RawQuery,
+		Variables:              oc.Variables,
+		OperationName:          oc.OperationName,
+		Doc:                    oc.Doc,
+		Extensions:             oc.Extensions,
+		Headers:                oc.Headers,
+		Operation:              oc.Operation,
+		DisableIntrospection:   oc.DisableIntrospection,
+		RecoverFunc:            oc.RecoverFunc,
+		ResolverMiddleware:     oc.ResolverMiddleware,
+		RootResolverMiddleware: oc.RootResolverMiddleware,
+		Stats:                  graphql.Stats{OperationStart: graphql.Now()},
+	}
+	ctx, cancel := context.WithTimeout(cachecontrol.Detach(context.WithoutCancel(ctx)), refreshTimeout)
+
+	go func() {
+		defer func() {
+			cancel()
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+			<-c.refreshes
+		}()
+
+		responses, ctx := c.exec.DispatchOperation(ctx, refreshed)
+		if c.set(ctx, key, responses(ctx), ttl) {
+			c.metrics.refreshed(refreshSucceeded)
+		} else {
+			c.metrics.refreshed(refreshFailed)
+		}
+	}()
+}
+
+const (
+	refreshSucceeded = "succeeded"
+	refreshFailed    = "failed"
+	refreshSkipped   = "skipped"
+)
+
+// metrics are the counters registered with WithRegisterer.
+type metrics struct {
+	requests  *prometheusclient.CounterVec
+	refreshes *prometheusclient.CounterVec
+}
+
+func newMetrics(cfg *config) *metrics {
+	m := &metrics{
+		requests: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_response_cache_requests_total",
+			Help:        "Total number of cacheable queries, by result: fresh, stale or miss.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"result"}),
+		refreshes: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_response_cache_refreshes_total",
+			Help:        "Total number of background refreshes of stale entries, by result: succeeded, failed or skipped.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"result"}),
+	}
+	cfg.registerer.MustRegister(m.requests, m.refreshes)
+	return m
+}
+
+func (m *metrics) unregister(registerer prometheusclient.Registerer) {
+	registerer.Unregister(m.requests)
+	registerer.Unregister(m.refreshes)
+}
+
+func (m *metrics) served(result string) {
+	if m != nil {
+		m.requests.WithLabelValues(result).Inc()
+	}
+}
+
+func (m *metrics) refreshed(result string) {
+	if m != nil {
+		m.refreshes.WithLabelValues(result).Inc()
+	}
+}
@@ -0,0 +1,112 @@
+package retry
+
+import (
+	"time"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	fields       map[string]int
+	maxAttempts  int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	isTransient  func(err error) bool
+	namespace    string
+	subsystem    string
+	constLabels  prometheusclient.Labels
+	registerer   prometheusclient.Registerer
+}
+
+// Option is anything that can configure Retrier.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		fields:       map[string]int{},
+		maxAttempts:  3,
+		initialDelay: 50 * time.Millisecond,
+		maxDelay:     time.Second,
+		isTransient:  IsTransient,
+		registerer:   prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithFields makes the resolvers of the given fields, as Object.field,
+// retryable without annotating the schema.
+func WithFields(coordinates ...string) Option {
+	return func(cfg *config) {
+		for _, coordinate := range coordinates {
+			cfg.fields[coordinate] = 0
+		}
+	}
+}
+
+// WithField makes the resolver of the field Object.field retryable, running
+// it at most attempts times.
+func WithField(coordinate string, attempts int) Option {
+	return func(cfg *config) {
+		cfg.fields[coordinate] = attempts
+	}
+}
+
+// WithMaxAttempts runs retryable resolvers at most n times, 3 by default,
+// unless their field sets its own number of attempts.
+func WithMaxAttempts(n int) Option {
+	return func(cfg *config) {
+		cfg.maxAttempts = n
+	}
+}
+
+// WithBackoff waits a random delay of up to initial before the first retry,
+// doubling the bound for every retry up to max. Defaults to 50ms and 1s.
+func WithBackoff(initial, max time.Duration) Option {
+	return func(cfg *config) {
+		cfg.initialDelay = initial
+		cfg.maxDelay = max
+	}
+}
+
+// WithTransientFunc retries the errors for which fn returns true, instead
+// of those IsTransient reports.
+func WithTransientFunc(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.isTransient = fn
+	}
+}
+
+// WithNamespace prefixes the metric name with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric name.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metric.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metric on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,177 @@
+// Package retry runs idempotent resolvers again when they fail with a
+// transient error.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Directive is the name of the directive marking fields as retryable in the
+// schema, declared as
+//
+//	directive @retryable(attempts: Int) on FIELD_DEFINITION
+//
+// with skip_runtime set in gqlgen.yml: only Retrier reads it.
+const Directive = "retryable"
+
+type transientError struct {
+	err error
+}
+
+func (e transientError) Error() string { return e.err.Error() }
+func (e transientError) Unwrap() error { return e.err }
+
+// Transient marks err as transient, for resolvers to tell Retrier it is
+// worth retrying.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return transientError{err: err}
+}
+
+// IsTransient reports whether err was marked with Transient, or is a
+// timeout or temporary error such as those of the net package.
+func IsTransient(err error) bool {
+	if errors.As(err, new(transientError)) {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// Retrier is a gqlgen handler extension running the resolvers of retryable
+// fields again while they fail with a transient error, waiting with
+// exponential backoff and jitter in between. Fields are retryable when
+// annotated with the @retryable directive, or registered with WithFields.
+// Only mark fields whose resolvers are idempotent.
+//
+// Retries are counted in graphql_resolver_retries_total{object,field}. The
+// context of the operation bounds the retries: once done, the last error is
+// returned.
+type Retrier struct {
+	cfg     *config
+	counter *prometheusclient.CounterVec
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Retrier{}
+
+// New returns a Retrier whose counter is registered on the configured
+// registerer.
+func New(opts ...Option) *Retrier {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_resolver_retries_total",
+			Help:        "Total number of resolver retries.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"object", "field"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return &Retrier{cfg: cfg, counter: counter}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (r *Retrier) UnRegister() {
+	r.cfg.registerer.Unregister(r.counter)
+}
+
+func (r *Retrier) ExtensionName() string {
+	return "Retry"
+}
+
+// Validate checks that the fields given to WithFields exist.
+func (r *Retrier) Validate(schema graphql.ExecutableSchema) error {
+	for coordinate := range r.cfg.fields {
+		object, field, _ := strings.Cut(coordinate, ".")
+		def := schema.Schema().Types[object]
+		if def == nil || def.Fields.ForName(field) == nil {
+			return fmt.Errorf("retry: unknown field %s", coordinate)
+		}
+	}
+	return nil
+}
+
+func (r *Retrier) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	attempts, ok := r.attempts(fc)
+	if !ok {
+		return next(ctx)
+	}
+
+	delay := r.cfg.initialDelay
+	for attempt := 1; ; attempt++ {
+		res, err := next(ctx)
+		if err == nil || attempt >= attempts || !r.cfg.isTransient(err) {
+			return res, err
+		}
+
+		timer := time.NewTimer(rand.N(delay + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, err
+		case <-timer.C:
+		}
+		delay = min(2*delay, r.cfg.maxDelay)
+
+		r.counter.WithLabelValues(fc.Object, fc.Field.Name).Inc()
+	}
+}
+
+// attempts returns how many times the resolver of fc may run, false if its
+// field is not retryable.
+func (r *Retrier) attempts(fc *graphql.FieldContext) (int, bool) {
+	attempts, ok := r.cfg.fields[fc.Object+"."+fc.Field.Name]
+	if !ok {
+		attempts, ok = directiveAttempts(fc.Field.Definition)
+	}
+	if !ok {
+		return 0, false
+	}
+	if attempts <= 0 {
+		attempts = r.cfg.maxAttempts
+	}
+	return attempts, true
+}
+
+func directiveAttempts(def *ast.FieldDefinition) (int, bool) {
+	if def == nil {
+		return 0, false
+	}
+	directive := def.Directives.ForName(Directive)
+	if directive == nil {
+		return 0, false
+	}
+	if arg := directive.Arguments.ForName("attempts"); arg != nil && arg.Value != nil {
+		if attempts, err := strconv.Atoi(arg.Value.Raw); err == nil {
+			return attempts, true
+		}
+	}
+	return 0, true
+}
@@ -0,0 +1,129 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/retry"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestRetrier(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	retrier := retry.New(
+		retry.WithRegisterer(registry),
+		retry.WithFields("Query.todos"),
+		retry.WithField("Query.todo", 2),
+		retry.WithBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+	defer retrier.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(retrier)
+
+	// Resolvers fail twice with a transient error before succeeding.
+	calls := map[string]int{}
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		fc := graphql.GetFieldContext(ctx)
+		if fc.Object != "Query" {
+			return next(ctx)
+		}
+		if calls[fc.Field.Name]++; calls[fc.Field.Name] <= 2 {
+			return nil, retry.Transient(errFlaky)
+		}
+		return next(ctx)
+	})
+
+	resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.Equal(t, 3, calls["todos"])
+
+	resp = doRequest(srv, `{"query":"{ todo(id: \"`+graph.TodoA.ID+`\") { id } }"}`)
+	assert.Contains(t, resp.Body.String(), errFlaky.Error())
+	assert.Equal(t, 2, calls["todo"])
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `graphql_resolver_retries_total{field="todos",object="Query"} 2`)
+	assert.Contains(t, resp.Body.String(), `graphql_resolver_retries_total{field="todo",object="Query"} 1`)
+}
+
+func TestRetrier_Directive(t *testing.T) {
+	retrier := retry.New(retry.WithRegisterer(prometheusclient.NewRegistry()), retry.WithBackoff(0, 0))
+
+	fieldContext := func(directives ast.DirectiveList) context.Context {
+		return graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+			Object:     "Query",
+			IsResolver: true,
+			Field: graphql.CollectedField{Field: &ast.Field{
+				Name:       "remote",
+				Definition: &ast.FieldDefinition{Name: "remote", Directives: directives},
+			}},
+		})
+	}
+
+	tests := []struct {
+		name       string
+		directives ast.DirectiveList
+		err        error
+		calls      int
+	}{
+		{name: "not retryable", err: retry.Transient(errFlaky), calls: 1},
+		{name: "default attempts", directives: ast.DirectiveList{{Name: retry.Directive}}, err: retry.Transient(errFlaky), calls: 3},
+		{name: "attempts", directives: ast.DirectiveList{{Name: retry.Directive, Arguments: ast.ArgumentList{{
+			Name:  "attempts",
+			Value: &ast.Value{Kind: ast.IntValue, Raw: "5"},
+		}}}}, err: retry.Transient(errFlaky), calls: 5},
+		{name: "permanent error", directives: ast.DirectiveList{{Name: retry.Directive}}, err: errFlaky, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			_, err := retrier.InterceptField(fieldContext(tt.directives), func(ctx context.Context) (any, error) {
+				calls++
+				return nil, tt.err
+			})
+			assert.ErrorIs(t, err, errFlaky)
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, retry.IsTransient(retry.Transient(errFlaky)))
+	assert.True(t, retry.IsTransient(context.DeadlineExceeded))
+	assert.False(t, retry.IsTransient(errFlaky))
+	assert.Nil(t, retry.Transient(nil))
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	method := http.MethodPost
+	if body == "" {
+		method = http.MethodGet
+	}
+	r := httptest.NewRequest(method, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,66 @@
+package schemadiff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ErrBreakingChanges is wrapped by the error returned by Check when the
+// schema breaks its baseline.
+var ErrBreakingChanges = errors.New("schemadiff: breaking changes")
+
+// Check compares the schema of es against the baseline SDL, typically the
+// schema last deployed embedded with go:embed, so that a service can refuse
+// to start when it would break its clients:
+//
+//	if err := schemadiff.Check(baseline, graph.NewExecutableSchema(cfg)); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Breaking changes are logged at error level and dangerous ones at warn
+// level. The breaking changes are returned as an error wrapping
+// ErrBreakingChanges unless WithWarnOnly is given.
+func Check(baseline string, es graphql.ExecutableSchema, opts ...Option) error {
+	cfg := newConfig(opts...)
+
+	old, err := gqlparser.LoadSchema(&ast.Source{Name: "baseline", Input: baseline})
+	if err != nil {
+		return fmt.Errorf("schemadiff: baseline: %w", err)
+	}
+
+	changes := Diff(old, es.Schema())
+	if cfg.logger != nil {
+		for _, change := range changes {
+			level := slog.LevelWarn
+			switch change.Criticality {
+			case Breaking:
+				level = slog.LevelError
+			case Safe:
+				continue
+			}
+			cfg.logger.Log(context.Background(), level, "graphql schema change",
+				slog.String("criticality", string(change.Criticality)),
+				slog.String("path", change.Path),
+				slog.String("change", change.Message),
+			)
+		}
+	}
+
+	breaking := changes.Filter(Breaking)
+	if len(breaking) == 0 || cfg.warnOnly {
+		return nil
+	}
+
+	messages := make([]string, len(breaking))
+	for i, change := range breaking {
+		messages[i] = change.Message
+	}
+	return fmt.Errorf("%w against the baseline: %s", ErrBreakingChanges, strings.Join(messages, "; "))
+}
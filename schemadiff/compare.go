@@ -0,0 +1,258 @@
+package schemadiff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type differ struct {
+	changes Changes
+}
+
+func (d *differ) add(criticality Criticality, path, format string, args ...any) {
+	d.changes = append(d.changes, Change{
+		Criticality: criticality,
+		Path:        path,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) roots(operation string, oldDef, newDef *ast.Definition) {
+	switch {
+	case oldDef == nil && newDef == nil:
+	case oldDef == nil:
+		d.add(Safe, newDef.Name, "%s root type %s was added", operation, newDef.Name)
+	case newDef == nil:
+		d.add(Breaking, oldDef.Name, "%s root type %s was removed", operation, oldDef.Name)
+	case oldDef.Name != newDef.Name:
+		d.add(Breaking, newDef.Name, "%s root type changed from %s to %s", operation, oldDef.Name, newDef.Name)
+	}
+}
+
+func (d *differ) definition(oldDef, newDef *ast.Definition) {
+	name := oldDef.Name
+	if oldDef.Kind != newDef.Kind {
+		d.add(Breaking, name, "type %s changed from %s to %s", name, kind(oldDef.Kind), kind(newDef.Kind))
+		return
+	}
+	if oldDef.Description != newDef.Description {
+		d.add(Safe, name, "description of type %s changed", name)
+	}
+
+	switch oldDef.Kind {
+	case ast.Object, ast.Interface:
+		d.outputFields(oldDef, newDef)
+		d.members(name, "interface", oldDef.Interfaces, newDef.Interfaces)
+	case ast.InputObject:
+		d.inputFields(oldDef, newDef)
+	case ast.Union:
+		d.members(name, "member", oldDef.Types, newDef.Types)
+	case ast.Enum:
+		d.enumValues(oldDef, newDef)
+	}
+}
+
+// members compares the interfaces of objects and interfaces, or the types
+// of unions.
+func (d *differ) members(name, member string, oldNames, newNames []string) {
+	for _, oldName := range oldNames {
+		if !slices.Contains(newNames, oldName) {
+			d.add(Breaking, name, "%s %s was removed from %s", member, oldName, name)
+		}
+	}
+	for _, newName := range newNames {
+		if !slices.Contains(oldNames, newName) {
+			d.add(Dangerous, name, "%s %s was added to %s", member, newName, name)
+		}
+	}
+}
+
+func (d *differ) outputFields(oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		path := oldDef.Name + "." + oldField.Name
+		if isIntrospection(oldField.Name) {
+			continue
+		}
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			d.add(Breaking, path, "field %s was removed", path)
+			continue
+		}
+
+		if !safeOutput(oldField.Type, newField.Type) {
+			d.add(Breaking, path, "field %s changed type from %s to %s", path, oldField.Type, newField.Type)
+		} else if oldField.Type.String() != newField.Type.String() {
+			d.add(Safe, path, "field %s changed type from %s to %s", path, oldField.Type, newField.Type)
+		}
+		if oldField.Description != newField.Description {
+			d.add(Safe, path, "description of field %s changed", path)
+		}
+		d.deprecation(path, "field", oldField.Directives, newField.Directives)
+		d.arguments(path, oldField.Arguments, newField.Arguments)
+	}
+	for _, newField := range newDef.Fields {
+		if !isIntrospection(newField.Name) && oldDef.Fields.ForName(newField.Name) == nil {
+			path := newDef.Name + "." + newField.Name
+			d.add(Safe, path, "field %s was added", path)
+		}
+	}
+}
+
+func (d *differ) inputFields(oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		path := oldDef.Name + "." + oldField.Name
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			d.add(Breaking, path, "input field %s was removed", path)
+			continue
+		}
+		d.input(path, "input field", oldField.Type, newField.Type, oldField.DefaultValue, newField.DefaultValue)
+	}
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) == nil {
+			path := newDef.Name + "." + newField.Name
+			d.added(path, "input field", newField.Type, newField.DefaultValue)
+		}
+	}
+}
+
+func (d *differ) arguments(path string, oldArgs, newArgs ast.ArgumentDefinitionList) {
+	for _, oldArg := range oldArgs {
+		argPath := path + "." + oldArg.Name
+		newArg := newArgs.ForName(oldArg.Name)
+		if newArg == nil {
+			d.add(Breaking, argPath, "argument %s was removed", argPath)
+			continue
+		}
+		d.input(argPath, "argument", oldArg.Type, newArg.Type, oldArg.DefaultValue, newArg.DefaultValue)
+	}
+	for _, newArg := range newArgs {
+		if oldArgs.ForName(newArg.Name) == nil {
+			d.added(path+"."+newArg.Name, "argument", newArg.Type, newArg.DefaultValue)
+		}
+	}
+}
+
+// input compares an input field or an argument present in both schemas.
+func (d *differ) input(path, what string, oldType, newType *ast.Type, oldDefault, newDefault *ast.Value) {
+	if !safeInput(oldType, newType) {
+		d.add(Breaking, path, "%s %s changed type from %s to %s", what, path, oldType, newType)
+	} else if oldType.String() != newType.String() {
+		d.add(Safe, path, "%s %s changed type from %s to %s", what, path, oldType, newType)
+	}
+	if value(oldDefault) != value(newDefault) {
+		d.add(Dangerous, path, "default value of %s %s changed from %s to %s", what, path, value(oldDefault), value(newDefault))
+	}
+}
+
+// added reports an input field or an argument missing from the old schema,
+// breaking operations when required.
+func (d *differ) added(path, what string, typ *ast.Type, defaultValue *ast.Value) {
+	if typ.NonNull && defaultValue == nil {
+		d.add(Breaking, path, "required %s %s was added", what, path)
+		return
+	}
+	d.add(Dangerous, path, "optional %s %s was added", what, path)
+}
+
+func (d *differ) enumValues(oldDef, newDef *ast.Definition) {
+	for _, oldValue := range oldDef.EnumValues {
+		path := oldDef.Name + "." + oldValue.Name
+		newValue := newDef.EnumValues.ForName(oldValue.Name)
+		if newValue == nil {
+			d.add(Breaking, path, "enum value %s was removed", path)
+			continue
+		}
+		d.deprecation(path, "enum value", oldValue.Directives, newValue.Directives)
+	}
+	for _, newValue := range newDef.EnumValues {
+		if oldDef.EnumValues.ForName(newValue.Name) == nil {
+			path := newDef.Name + "." + newValue.Name
+			d.add(Dangerous, path, "enum value %s was added", path)
+		}
+	}
+}
+
+func (d *differ) deprecation(path, what string, oldDirectives, newDirectives ast.DirectiveList) {
+	oldDeprecated := oldDirectives.ForName("deprecated") != nil
+	newDeprecated := newDirectives.ForName("deprecated") != nil
+	switch {
+	case !oldDeprecated && newDeprecated:
+		d.add(Safe, path, "%s %s was deprecated", what, path)
+	case oldDeprecated && !newDeprecated:
+		d.add(Dangerous, path, "%s %s is no longer deprecated", what, path)
+	}
+}
+
+func (d *differ) directive(oldDir, newDir *ast.DirectiveDefinition) {
+	path := "@" + oldDir.Name
+	for _, location := range oldDir.Locations {
+		if !slices.Contains(newDir.Locations, location) {
+			d.add(Breaking, path, "location %s was removed from directive %s", location, path)
+		}
+	}
+	for _, location := range newDir.Locations {
+		if !slices.Contains(oldDir.Locations, location) {
+			d.add(Safe, path, "location %s was added to directive %s", location, path)
+		}
+	}
+	if oldDir.IsRepeatable && !newDir.IsRepeatable {
+		d.add(Breaking, path, "directive %s is no longer repeatable", path)
+	}
+	d.arguments(path, oldDir.Arguments, newDir.Arguments)
+}
+
+// safeOutput tells whether values of newType can be read where oldType was
+// expected: the new type may only be stricter on nullability.
+func safeOutput(oldType, newType *ast.Type) bool {
+	if oldType.NonNull {
+		return newType.NonNull && safeOutput(nullable(oldType), nullable(newType))
+	}
+	if newType.NonNull {
+		return safeOutput(oldType, nullable(newType))
+	}
+	if oldType.Elem != nil {
+		return newType.Elem != nil && safeOutput(oldType.Elem, newType.Elem)
+	}
+	return newType.Elem == nil && oldType.NamedType == newType.NamedType
+}
+
+// safeInput tells whether values of oldType are still accepted as newType:
+// the new type may only be looser on nullability.
+func safeInput(oldType, newType *ast.Type) bool {
+	if newType.NonNull {
+		return oldType.NonNull && safeInput(nullable(oldType), nullable(newType))
+	}
+	if oldType.NonNull {
+		return safeInput(nullable(oldType), newType)
+	}
+	if oldType.Elem != nil {
+		return newType.Elem != nil && safeInput(oldType.Elem, newType.Elem)
+	}
+	return newType.Elem == nil && oldType.NamedType == newType.NamedType
+}
+
+func nullable(t *ast.Type) *ast.Type {
+	c := *t
+	c.NonNull = false
+	return &c
+}
+
+func value(v *ast.Value) string {
+	if v == nil {
+		return "none"
+	}
+	return v.String()
+}
+
+// kind returns k in lower case words, such as "input object".
+func kind(k ast.DefinitionKind) string {
+	return strings.ReplaceAll(strings.ToLower(string(k)), "_", " ")
+}
+
+func isIntrospection(name string) bool {
+	return strings.HasPrefix(name, "__")
+}
@@ -0,0 +1,39 @@
+package schemadiff
+
+import "log/slog"
+
+type config struct {
+	logger   *slog.Logger
+	warnOnly bool
+}
+
+// Option is anything that can configure Check.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		logger: slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithLogger logs the breaking and dangerous changes to logger instead of
+// slog.Default. A nil logger does not log them.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cfg *config) {
+		cfg.logger = logger
+	}
+}
+
+// WithWarnOnly makes Check only log breaking changes, for a service to boot
+// anyway, instead of returning them as an error.
+func WithWarnOnly() Option {
+	return func(cfg *config) {
+		cfg.warnOnly = true
+	}
+}
@@ -0,0 +1,129 @@
+// Package schemadiff compares two versions of a GraphQL schema and
+// classifies their differences by how they affect existing clients.
+package schemadiff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Criticality tells how a Change affects the clients of the old schema.
+type Criticality string
+
+const (
+	// Breaking changes make valid operations invalid, or their results
+	// unexpected, such as removing a field or making an argument required.
+	Breaking Criticality = "breaking"
+	// Dangerous changes keep operations valid but may change their results
+	// or break exhaustive client code, such as adding an enum value.
+	Dangerous Criticality = "dangerous"
+	// Safe changes cannot affect existing clients, such as adding a field.
+	Safe Criticality = "safe"
+)
+
+// Change is a difference between two schemas. Path is the coordinate of the
+// changed element: Type, Type.field, Type.field.argument or @directive.
+type Change struct {
+	Criticality Criticality `json:"criticality"`
+	Path        string      `json:"path"`
+	Message     string      `json:"message"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Criticality, c.Message)
+}
+
+// Changes is a list of changes, sorted by path.
+type Changes []Change
+
+// Filter returns the changes of the given criticality.
+func (c Changes) Filter(criticality Criticality) Changes {
+	var changes Changes
+	for _, change := range c {
+		if change.Criticality == criticality {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// DiffSDL loads the old and new SDL documents, without the built-in types,
+// and returns their differences.
+func DiffSDL(oldSDL, newSDL string) (Changes, error) {
+	oldSchema, err := gqlparser.LoadSchema(&ast.Source{Name: "old", Input: oldSDL})
+	if err != nil {
+		return nil, fmt.Errorf("schemadiff: old schema: %w", err)
+	}
+	newSchema, err := gqlparser.LoadSchema(&ast.Source{Name: "new", Input: newSDL})
+	if err != nil {
+		return nil, fmt.Errorf("schemadiff: new schema: %w", err)
+	}
+	return Diff(oldSchema, newSchema), nil
+}
+
+// Diff returns the changes turning oldSchema into newSchema. Built-in types
+// and directives are ignored.
+func Diff(oldSchema, newSchema *ast.Schema) Changes {
+	d := &differ{}
+
+	d.roots("query", oldSchema.Query, newSchema.Query)
+	d.roots("mutation", oldSchema.Mutation, newSchema.Mutation)
+	d.roots("subscription", oldSchema.Subscription, newSchema.Subscription)
+
+	for _, name := range keys(oldSchema.Types) {
+		oldDef := oldSchema.Types[name]
+		if oldDef.BuiltIn {
+			continue
+		}
+		newDef, ok := newSchema.Types[name]
+		if !ok {
+			d.add(Breaking, name, "type %s was removed", name)
+			continue
+		}
+		d.definition(oldDef, newDef)
+	}
+	for _, name := range keys(newSchema.Types) {
+		if _, ok := oldSchema.Types[name]; !ok && !newSchema.Types[name].BuiltIn {
+			d.add(Safe, name, "type %s was added", name)
+		}
+	}
+
+	for _, name := range keys(oldSchema.Directives) {
+		oldDir := oldSchema.Directives[name]
+		if builtIn(oldDir) {
+			continue
+		}
+		newDir, ok := newSchema.Directives[name]
+		if !ok {
+			d.add(Breaking, "@"+name, "directive @%s was removed", name)
+			continue
+		}
+		d.directive(oldDir, newDir)
+	}
+	for _, name := range keys(newSchema.Directives) {
+		if _, ok := oldSchema.Directives[name]; !ok && !builtIn(newSchema.Directives[name]) {
+			d.add(Safe, "@"+name, "directive @%s was added", name)
+		}
+	}
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return d.changes[i].Path < d.changes[j].Path
+	})
+	return d.changes
+}
+
+func builtIn(dir *ast.DirectiveDefinition) bool {
+	return dir.Position != nil && dir.Position.Src != nil && dir.Position.Src.BuiltIn
+}
+
+func keys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,163 @@
+package schemadiff_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/schemadiff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oldSDL = `
+directive @auth(role: String) on FIELD_DEFINITION | OBJECT
+
+interface Node { id: ID! }
+
+type Todo implements Node {
+  id: ID!
+  text: String
+  done: Boolean!
+  tags: [String!]
+  user(first: Int): User
+}
+
+type User implements Node { id: ID! name: String! }
+
+enum Status { OPEN DONE }
+
+union Result = Todo | User
+
+input NewTodo {
+  text: String!
+  done: Boolean = false
+}
+
+type Query {
+  todos(status: Status, limit: Int = 10): [Todo!]!
+  search(term: String!): [Result!]!
+  legacy: String
+}
+
+type Mutation {
+  createTodo(input: NewTodo!): Todo!
+}
+`
+
+const newSDL = `
+directive @auth(role: String!) on FIELD_DEFINITION
+
+directive @cost(weight: Int) on FIELD_DEFINITION
+
+interface Node { id: ID! }
+
+type Todo {
+  id: ID!
+  text: String! @deprecated
+  done: Boolean
+  tags: [String]
+  user(first: Int, after: String, filter: String!): User
+}
+
+type User implements Node { id: ID! name: String! email: String }
+
+enum Status { OPEN DONE ARCHIVED }
+
+union Result = Todo
+
+input NewTodo {
+  text: String
+  done: Boolean = true
+  userId: ID!
+}
+
+type Query {
+  todos(status: Status, limit: Int = 20): [Todo!]!
+  search(term: String): [Result!]!
+}
+`
+
+func TestDiffSDL(t *testing.T) {
+	changes, err := schemadiff.DiffSDL(oldSDL, newSDL)
+	require.NoError(t, err)
+
+	got := map[string]schemadiff.Criticality{}
+	for _, change := range changes {
+		got[change.Message] = change.Criticality
+	}
+
+	assert.Equal(t, map[string]schemadiff.Criticality{
+		"location OBJECT was removed from directive @auth":                     schemadiff.Breaking,
+		"argument @auth.role changed type from String to String!":              schemadiff.Breaking,
+		"directive @cost was added":                                            schemadiff.Safe,
+		"interface Node was removed from Todo":                                 schemadiff.Breaking,
+		"field Todo.text changed type from String to String!":                  schemadiff.Safe,
+		"field Todo.text was deprecated":                                       schemadiff.Safe,
+		"field Todo.done changed type from Boolean! to Boolean":                schemadiff.Breaking,
+		"field Todo.tags changed type from [String!] to [String]":              schemadiff.Breaking,
+		"optional argument Todo.user.after was added":                          schemadiff.Dangerous,
+		"required argument Todo.user.filter was added":                         schemadiff.Breaking,
+		"field User.email was added":                                           schemadiff.Safe,
+		"enum value Status.ARCHIVED was added":                                 schemadiff.Dangerous,
+		"member User was removed from Result":                                  schemadiff.Breaking,
+		"input field NewTodo.text changed type from String! to String":         schemadiff.Safe,
+		"default value of input field NewTodo.done changed from false to true": schemadiff.Dangerous,
+		"required input field NewTodo.userId was added":                        schemadiff.Breaking,
+		"default value of argument Query.todos.limit changed from 10 to 20":    schemadiff.Dangerous,
+		"argument Query.search.term changed type from String! to String":       schemadiff.Safe,
+		"field Query.legacy was removed":                                       schemadiff.Breaking,
+		"mutation root type Mutation was removed":                              schemadiff.Breaking,
+		"type Mutation was removed":                                            schemadiff.Breaking,
+	}, got)
+
+	for i := 1; i < len(changes); i++ {
+		assert.LessOrEqual(t, changes[i-1].Path, changes[i].Path)
+	}
+	assert.Len(t, changes.Filter(schemadiff.Breaking), 11)
+}
+
+func TestDiffSDL_Identical(t *testing.T) {
+	changes, err := schemadiff.DiffSDL(oldSDL, oldSDL)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffSDL_Invalid(t *testing.T) {
+	_, err := schemadiff.DiffSDL(oldSDL, `type Query { todos: [Missing] }`)
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	baseline, err := os.ReadFile("../internal/graph/schema.graphql")
+	require.NoError(t, err)
+	es := graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}})
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	t.Run("unchanged", func(t *testing.T) {
+		logs.Reset()
+		assert.NoError(t, schemadiff.Check(string(baseline), es, schemadiff.WithLogger(logger)))
+		assert.Empty(t, logs.String())
+	})
+
+	pinned := string(baseline) + "\nextend type User { email: String }\n"
+
+	t.Run("breaking", func(t *testing.T) {
+		logs.Reset()
+		err := schemadiff.Check(pinned, es, schemadiff.WithLogger(logger))
+		assert.ErrorIs(t, err, schemadiff.ErrBreakingChanges)
+		assert.ErrorContains(t, err, "field User.email was removed")
+		assert.Contains(t, logs.String(), "level=ERROR")
+		assert.Contains(t, logs.String(), "path=User.email")
+	})
+
+	t.Run("warn only", func(t *testing.T) {
+		logs.Reset()
+		assert.NoError(t, schemadiff.Check(pinned, es, schemadiff.WithLogger(logger), schemadiff.WithWarnOnly()))
+		assert.Contains(t, logs.String(), "field User.email was removed")
+	})
+}
@@ -0,0 +1,93 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ApolloEndpoint is the Platform API of Apollo GraphOS.
+const ApolloEndpoint = "https://api.apollographql.com/api/graphql"
+
+// Apollo is an Apollo GraphOS registry publishing subgraphs of the graph
+// variant GraphRef, such as "my-graph@current", authenticated with a graph
+// APIKey. Endpoint defaults to ApolloEndpoint.
+//
+// Apollo only implements Publisher: GraphOS checks run asynchronously, use
+// rover subgraph check before deploying instead.
+type Apollo struct {
+	Client   *http.Client
+	Endpoint string
+	APIKey   string
+	GraphRef string
+}
+
+var _ Publisher = Apollo{}
+
+const apolloPublish = `mutation PublishSubgraph($graphId: ID!, $variant: String!, $name: String!, $url: String, $revision: String!, $schema: PartialSchemaInput!, $gitContext: GitContextInput) {
+  graph(id: $graphId) {
+    publishSubgraph(graphVariant: $variant, name: $name, url: $url, revision: $revision, activePartialSchema: $schema, gitContext: $gitContext) {
+      errors { message }
+    }
+  }
+}`
+
+// Publish publishes schema as the subgraph named after its service, failing
+// when composition reports errors.
+func (a Apollo) Publish(ctx context.Context, schema Schema) error {
+	graphID, variant, ok := strings.Cut(a.GraphRef, "@")
+	if !ok {
+		variant = "current"
+	}
+	if schema.Service == "" {
+		return fmt.Errorf("schemaregistry: apollo requires a service name")
+	}
+
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = ApolloEndpoint
+	}
+	header := http.Header{
+		"X-Api-Key":                    {a.APIKey},
+		"Apollographql-Client-Name":    {"gqlgen-contrib"},
+		"Apollographql-Client-Version": {"1"},
+	}
+	variables := map[string]any{
+		"graphId":  graphID,
+		"variant":  variant,
+		"name":     schema.Service,
+		"revision": schema.Version,
+		"schema":   map[string]any{"sdl": schema.SDL},
+	}
+	if schema.URL != "" {
+		variables["url"] = schema.URL
+	}
+	if schema.Version != "" {
+		variables["gitContext"] = map[string]any{"commit": schema.Version}
+	}
+
+	var data struct {
+		Graph *struct {
+			PublishSubgraph struct {
+				Errors []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			} `json:"publishSubgraph"`
+		} `json:"graph"`
+	}
+	if err := graphqlRequest(ctx, a.Client, endpoint, header, apolloPublish, variables, &data); err != nil {
+		return err
+	}
+	if data.Graph == nil {
+		return fmt.Errorf("schemaregistry: apollo graph %s not found", graphID)
+	}
+	if errors := data.Graph.PublishSubgraph.Errors; len(errors) > 0 {
+		messages := make([]string, len(errors))
+		for i, err := range errors {
+			messages[i] = err.Message
+		}
+		return fmt.Errorf("schemaregistry: apollo composition failed: %s", strings.Join(messages, "; "))
+	}
+	return nil
+}
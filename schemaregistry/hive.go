@@ -0,0 +1,140 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/schemadiff"
+)
+
+// HiveEndpoint is the GraphQL API of GraphQL Hive Cloud.
+const HiveEndpoint = "https://app.graphql-hive.com/graphql"
+
+// Hive is a GraphQL Hive registry, authenticated with a registry access
+// Token. Endpoint defaults to HiveEndpoint, Author to the service name.
+type Hive struct {
+	Client   *http.Client
+	Endpoint string
+	Token    string
+	Author   string
+}
+
+var _ interface {
+	Publisher
+	Checker
+} = Hive{}
+
+const hivePublish = `mutation schemaPublish($input: SchemaPublishInput!) {
+  schemaPublish(input: $input) {
+    __typename
+    ... on SchemaPublishSuccess { valid }
+    ... on SchemaPublishError { valid errors { nodes { message } } }
+    ... on SchemaPublishMissingServiceError { message }
+    ... on SchemaPublishMissingUrlError { message }
+  }
+}`
+
+const hiveCheck = `mutation schemaCheck($input: SchemaCheckInput!) {
+  schemaCheck(input: $input) {
+    __typename
+    ... on SchemaCheckSuccess { valid changes { nodes { message criticality } } }
+    ... on SchemaCheckError { valid changes { nodes { message criticality } } errors { nodes { message } } }
+  }
+}`
+
+type hiveResult struct {
+	Typename string `json:"__typename"`
+	Valid    bool   `json:"valid"`
+	Message  string `json:"message"`
+	Changes  struct {
+		Nodes []struct {
+			Message     string `json:"message"`
+			Criticality string `json:"criticality"`
+		} `json:"nodes"`
+	} `json:"changes"`
+	Errors struct {
+		Nodes []struct {
+			Message string `json:"message"`
+		} `json:"nodes"`
+	} `json:"errors"`
+}
+
+func (r hiveResult) errors() []string {
+	var errors []string
+	for _, node := range r.Errors.Nodes {
+		errors = append(errors, node.Message)
+	}
+	if r.Message != "" {
+		errors = append(errors, r.Message)
+	}
+	return errors
+}
+
+// Publish publishes schema with the schemaPublish mutation, failing when
+// Hive rejects it.
+func (h Hive) Publish(ctx context.Context, schema Schema) error {
+	author := h.Author
+	if author == "" {
+		author = schema.Service
+	}
+	input := map[string]any{
+		"sdl":     schema.SDL,
+		"author":  author,
+		"commit":  schema.Version,
+		"service": schema.Service,
+		"url":     schema.URL,
+	}
+	if schema.Metadata != nil {
+		metadata, err := json.Marshal(schema.Metadata)
+		if err != nil {
+			return err
+		}
+		input["metadata"] = string(metadata)
+	}
+
+	var data struct {
+		SchemaPublish hiveResult `json:"schemaPublish"`
+	}
+	if err := h.request(ctx, hivePublish, input, &data); err != nil {
+		return err
+	}
+	if result := data.SchemaPublish; result.Typename != "SchemaPublishSuccess" {
+		return fmt.Errorf("schemaregistry: hive rejected the schema: %s", strings.Join(result.errors(), "; "))
+	}
+	return nil
+}
+
+// Check checks schema with the schemaCheck mutation.
+func (h Hive) Check(ctx context.Context, schema Schema) (*CheckResult, error) {
+	var data struct {
+		SchemaCheck hiveResult `json:"schemaCheck"`
+	}
+	input := map[string]any{"sdl": schema.SDL, "service": schema.Service}
+	if err := h.request(ctx, hiveCheck, input, &data); err != nil {
+		return nil, err
+	}
+
+	result := &CheckResult{
+		Valid:  data.SchemaCheck.Valid,
+		Errors: data.SchemaCheck.errors(),
+	}
+	for _, node := range data.SchemaCheck.Changes.Nodes {
+		result.Changes = append(result.Changes, schemadiff.Change{
+			Criticality: schemadiff.Criticality(strings.ToLower(node.Criticality)),
+			Message:     node.Message,
+		})
+	}
+	return result, nil
+}
+
+func (h Hive) request(ctx context.Context, query string, input map[string]any, out any) error {
+	endpoint := h.Endpoint
+	if endpoint == "" {
+		endpoint = HiveEndpoint
+	}
+	header := http.Header{"Authorization": {"Bearer " + h.Token}}
+	return graphqlRequest(ctx, h.Client, endpoint, header, query, map[string]any{"input": input}, out)
+}
@@ -0,0 +1,96 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTP is a registry accepting schemas as JSON encoded Schema values, POSTed
+// to PublishURL and CheckURL. The check endpoint answers with a JSON
+// encoded CheckResult. Header is added to the requests, typically for
+// authentication.
+type HTTP struct {
+	Client     *http.Client
+	PublishURL string
+	CheckURL   string
+	Header     http.Header
+}
+
+var _ interface {
+	Publisher
+	Checker
+} = HTTP{}
+
+// Publish posts schema to PublishURL.
+func (h HTTP) Publish(ctx context.Context, schema Schema) error {
+	return post(ctx, h.Client, h.PublishURL, h.Header, schema, nil)
+}
+
+// Check posts schema to CheckURL.
+func (h HTTP) Check(ctx context.Context, schema Schema) (*CheckResult, error) {
+	var result CheckResult
+	if err := post(ctx, h.Client, h.CheckURL, h.Header, schema, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// post sends body as JSON to url and decodes the response into out unless
+// nil.
+func post(ctx context.Context, client *http.Client, url string, header http.Header, body, out any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("schemaregistry: unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// graphqlRequest runs query against a GraphQL API and decodes its data into
+// out.
+func graphqlRequest(ctx context.Context, client *http.Client, url string, header http.Header, query string, variables map[string]any, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	body := map[string]any{"query": query, "variables": variables}
+	if err := post(ctx, client, url, header, body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("schemaregistry: %s", resp.Errors[0].Message)
+	}
+	return json.Unmarshal(resp.Data, out)
+}
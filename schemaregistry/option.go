@@ -0,0 +1,69 @@
+package schemaregistry
+
+import "runtime/debug"
+
+type config struct {
+	service  string
+	url      string
+	version  string
+	metadata map[string]string
+}
+
+// Option is anything that can configure Publish and Check.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		version: vcsRevision(),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithService names the service, or subgraph, the schema belongs to.
+func WithService(name string) Option {
+	return func(cfg *config) {
+		cfg.service = name
+	}
+}
+
+// WithURL is the URL the service is reachable at, for gateways routing to
+// it.
+func WithURL(url string) Option {
+	return func(cfg *config) {
+		cfg.url = url
+	}
+}
+
+// WithVersion identifies the published schema, such as a git SHA, instead
+// of the VCS revision stamped in the binary by go build.
+func WithVersion(version string) Option {
+	return func(cfg *config) {
+		cfg.version = version
+	}
+}
+
+// WithMetadata attaches free form metadata, such as the environment or the
+// deployment, to the published schema.
+func WithMetadata(metadata map[string]string) Option {
+	return func(cfg *config) {
+		cfg.metadata = metadata
+	}
+}
+
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
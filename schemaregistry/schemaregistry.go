@@ -0,0 +1,78 @@
+// Package schemaregistry publishes the schema of a gqlgen server to a
+// schema registry, such as Apollo GraphOS or GraphQL Hive, and checks
+// schemas against it before deploying.
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/schemadiff"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// Schema is the SDL of a service with its metadata.
+type Schema struct {
+	SDL      string            `json:"sdl"`
+	Service  string            `json:"service,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// CheckResult is the outcome of checking a schema against a registry. Valid
+// is false when the schema would break the registered one or fail to
+// compose, Errors then telling why.
+type CheckResult struct {
+	Valid   bool                `json:"valid"`
+	Errors  []string            `json:"errors,omitempty"`
+	Changes []schemadiff.Change `json:"changes,omitempty"`
+}
+
+// Publisher publishes schemas to a registry.
+type Publisher interface {
+	Publish(ctx context.Context, schema Schema) error
+}
+
+// Checker checks schemas against the ones of a registry.
+type Checker interface {
+	Check(ctx context.Context, schema Schema) (*CheckResult, error)
+}
+
+// SDL returns the schema of es in SDL, without the built-in types and
+// directives.
+func SDL(es graphql.ExecutableSchema) string {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf, formatter.WithIndent("  ")).FormatSchema(es.Schema())
+	return strings.TrimSpace(buf.String()) + "\n"
+}
+
+// New returns the Schema of es with the configured metadata.
+func New(es graphql.ExecutableSchema, opts ...Option) Schema {
+	cfg := newConfig(opts...)
+	return Schema{
+		SDL:      SDL(es),
+		Service:  cfg.service,
+		URL:      cfg.url,
+		Version:  cfg.version,
+		Metadata: cfg.metadata,
+	}
+}
+
+// Publish publishes the schema of es with p, typically on startup:
+//
+//	go func() {
+//		if err := schemaregistry.Publish(ctx, es, registry, schemaregistry.WithService("todos")); err != nil {
+//			log.Printf("publishing schema: %v", err)
+//		}
+//	}()
+func Publish(ctx context.Context, es graphql.ExecutableSchema, p Publisher, opts ...Option) error {
+	return p.Publish(ctx, New(es, opts...))
+}
+
+// Check checks the schema of es with c, typically in CI before deploying.
+func Check(ctx context.Context, es graphql.ExecutableSchema, c Checker, opts ...Option) (*CheckResult, error) {
+	return c.Check(ctx, New(es, opts...))
+}
@@ -0,0 +1,146 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/schemadiff"
+	"github.com/99designs/gqlgen-contrib/schemaregistry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var es = graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}})
+
+func TestSDL(t *testing.T) {
+	sdl := schemaregistry.SDL(es)
+	assert.Contains(t, sdl, "type Todo {")
+	assert.NotContains(t, sdl, "__Schema")
+
+	reloaded, err := gqlparser.LoadSchema(&ast.Source{Input: sdl})
+	require.NoError(t, err)
+	assert.Empty(t, schemadiff.Diff(es.Schema(), reloaded))
+}
+
+func TestHTTP(t *testing.T) {
+	var published schemaregistry.Schema
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /publish", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&published))
+	})
+	mux.HandleFunc("POST /check", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"valid":false,"errors":["field Todo.id was removed"],"changes":[{"criticality":"breaking","path":"Todo.id","message":"field Todo.id was removed"}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	registry := schemaregistry.HTTP{
+		PublishURL: srv.URL + "/publish",
+		CheckURL:   srv.URL + "/check",
+		Header:     http.Header{"Authorization": {"secret"}},
+	}
+	opts := []schemaregistry.Option{
+		schemaregistry.WithService("todos"),
+		schemaregistry.WithURL("http://todos/query"),
+		schemaregistry.WithVersion("abc123"),
+		schemaregistry.WithMetadata(map[string]string{"env": "test"}),
+	}
+
+	require.NoError(t, schemaregistry.Publish(context.Background(), es, registry, opts...))
+	assert.Equal(t, schemaregistry.New(es, opts...), published)
+	assert.Equal(t, "abc123", published.Version)
+
+	result, err := schemaregistry.Check(context.Background(), es, registry, opts...)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, []string{"field Todo.id was removed"}, result.Errors)
+	assert.Equal(t, schemadiff.Breaking, result.Changes[0].Criticality)
+
+	registry.PublishURL = srv.URL + "/missing"
+	assert.ErrorContains(t, schemaregistry.Publish(context.Background(), es, registry), "404")
+}
+
+func TestHive(t *testing.T) {
+	var requests []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req.Variables["input"].(map[string]any))
+
+		if len(requests) == 1 {
+			w.Write([]byte(`{"data":{"schemaPublish":{"__typename":"SchemaPublishSuccess","valid":true}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"schemaCheck":{"__typename":"SchemaCheckError","valid":false,` +
+			`"changes":{"nodes":[{"message":"Field 'id' was removed from object type 'Todo'","criticality":"Breaking"}]},` +
+			`"errors":{"nodes":[{"message":"Breaking Change: Field 'id' was removed from object type 'Todo'"}]}}}}`))
+	}))
+	defer srv.Close()
+
+	registry := schemaregistry.Hive{Endpoint: srv.URL, Token: "token"}
+	opts := []schemaregistry.Option{
+		schemaregistry.WithService("todos"),
+		schemaregistry.WithVersion("abc123"),
+		schemaregistry.WithMetadata(map[string]string{"env": "test"}),
+	}
+
+	require.NoError(t, schemaregistry.Publish(context.Background(), es, registry, opts...))
+	assert.Equal(t, "todos", requests[0]["author"])
+	assert.Equal(t, "abc123", requests[0]["commit"])
+	assert.Equal(t, `{"env":"test"}`, requests[0]["metadata"])
+	assert.Equal(t, schemaregistry.SDL(es), requests[0]["sdl"])
+
+	result, err := schemaregistry.Check(context.Background(), es, registry, opts...)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, schemadiff.Breaking, result.Changes[0].Criticality)
+}
+
+func TestApollo(t *testing.T) {
+	var variables map[string]any
+	response := `{"data":{"graph":{"publishSubgraph":{"errors":[]}}}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-Api-Key"))
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		variables = req.Variables
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+
+	registry := schemaregistry.Apollo{Endpoint: srv.URL, APIKey: "key", GraphRef: "todos-graph@staging"}
+	opts := []schemaregistry.Option{
+		schemaregistry.WithService("todos"),
+		schemaregistry.WithURL("http://todos/query"),
+		schemaregistry.WithVersion("abc123"),
+	}
+
+	require.NoError(t, schemaregistry.Publish(context.Background(), es, registry, opts...))
+	assert.Equal(t, "todos-graph", variables["graphId"])
+	assert.Equal(t, "staging", variables["variant"])
+	assert.Equal(t, "todos", variables["name"])
+	assert.Equal(t, "http://todos/query", variables["url"])
+	assert.Equal(t, map[string]any{"commit": "abc123"}, variables["gitContext"])
+
+	response = `{"data":{"graph":{"publishSubgraph":{"errors":[{"message":"conflicting types"}]}}}}`
+	assert.ErrorContains(t, schemaregistry.Publish(context.Background(), es, registry, opts...), "conflicting types")
+
+	response = `{"data":{"graph":null}}`
+	assert.ErrorContains(t, schemaregistry.Publish(context.Background(), es, registry, opts...), "not found")
+
+	assert.Error(t, schemaregistry.Publish(context.Background(), es, registry))
+}
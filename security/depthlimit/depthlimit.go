@@ -0,0 +1,150 @@
+// Package depthlimit rejects abusive deeply nested operations before they
+// are executed.
+package depthlimit
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes set as extensions.code of rejected operations.
+const (
+	ErrCodeDepthLimit     = "DEPTH_LIMIT_EXCEEDED"
+	ErrCodeAliasLimit     = "ALIAS_LIMIT_EXCEEDED"
+	ErrCodeRootFieldLimit = "ROOT_FIELD_LIMIT_EXCEEDED"
+)
+
+// DepthLimit is a gqlgen handler extension rejecting operations nested deeper
+// than the configured number of selections. Top level fields have depth 1,
+// fragments do not add depth, and __typename fields are not counted.
+// Introspection fields such as __schema and __type are measured like any
+// other field, so nested introspection queries are limited as well.
+// Limits of 0 or less are not enforced.
+type DepthLimit struct {
+	maxDepth int
+	cfg      config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = DepthLimit{}
+
+// New returns a DepthLimit rejecting operations deeper than maxDepth.
+func New(maxDepth int, opts ...Option) DepthLimit {
+	d := DepthLimit{maxDepth: maxDepth}
+
+	for _, opt := range opts {
+		opt(&d.cfg)
+	}
+
+	return d
+}
+
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d DepthLimit) MutateOperationContext(ctx context.Context, oc *graphql.OperationContext) *gqlerror.Error {
+	if oc.Operation == nil {
+		return nil
+	}
+
+	m := measure(oc.Operation.SelectionSet)
+
+	switch {
+	case d.maxDepth > 0 && m.depth > d.maxDepth:
+		return limitError(ErrCodeDepthLimit, "operation has depth %d, which exceeds the limit of %d", m.depth, d.maxDepth)
+	case d.cfg.maxAliases > 0 && m.aliases > d.cfg.maxAliases:
+		return limitError(ErrCodeAliasLimit, "operation has %d aliases, which exceeds the limit of %d", m.aliases, d.cfg.maxAliases)
+	case d.cfg.maxRootFields > 0 && m.rootFields > d.cfg.maxRootFields:
+		return limitError(ErrCodeRootFieldLimit, "operation has %d root fields, which exceeds the limit of %d", m.rootFields, d.cfg.maxRootFields)
+	}
+
+	return nil
+}
+
+func limitError(code string, format string, args ...interface{}) *gqlerror.Error {
+	err := gqlerror.Errorf(format, args...)
+	errcode.Set(err, code)
+	return err
+}
+
+// typenameField is the only field that is not measured: it is a leaf which
+// costs nothing to resolve.
+const typenameField = "__typename"
+
+type measurement struct {
+	depth      int
+	aliases    int
+	rootFields int
+}
+
+func measure(set ast.SelectionSet) measurement {
+	var m measurement
+	m.depth = m.selectionSet(set, 1)
+	m.rootFields = countFields(set)
+
+	return m
+}
+
+// selectionSet returns the depth of set, whose fields are at the given
+// depth, and counts its aliases.
+func (m *measurement) selectionSet(set ast.SelectionSet, depth int) int {
+	maxDepth := 0
+	for _, sel := range set {
+		var d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.Name == typenameField {
+				continue
+			}
+			if sel.Alias != "" && sel.Alias != sel.Name {
+				m.aliases++
+			}
+			d = depth
+			if len(sel.SelectionSet) != 0 {
+				d = m.selectionSet(sel.SelectionSet, depth+1)
+			}
+		case *ast.InlineFragment:
+			d = m.selectionSet(sel.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				d = m.selectionSet(sel.Definition.SelectionSet, depth)
+			}
+		}
+		maxDepth = max(maxDepth, d)
+	}
+
+	return maxDepth
+}
+
+// countFields returns the number of fields in set, looking through
+// fragments.
+func countFields(set ast.SelectionSet) int {
+	n := 0
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.Name != typenameField {
+				n++
+			}
+		case *ast.InlineFragment:
+			n += countFields(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				n += countFields(sel.Definition.SelectionSet)
+			}
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,96 @@
+package depthlimit_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/security/depthlimit"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepthLimit(t *testing.T) {
+	specs := []struct {
+		SpecName string
+		Query    string
+		Options  []depthlimit.Option
+		Expected string
+	}{
+		{
+			SpecName: "within depth",
+			Query:    `{ todos { id done } }`,
+		},
+		{
+			SpecName: "too deep",
+			Query:    `{ todos { user { id } } }`,
+			Expected: `operation has depth 3, which exceeds the limit of 2`,
+		},
+		{
+			SpecName: "too deep through fragments",
+			Query:    `{ todos { ...F } } fragment F on Todo { ... on Todo { user { name } } }`,
+			Expected: depthlimit.ErrCodeDepthLimit,
+		},
+		{
+			SpecName: "typename is not counted",
+			Query:    `{ __typename todos { __typename id } }`,
+		},
+		{
+			SpecName: "deep introspection",
+			Query:    `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`,
+			Expected: `operation has depth 7, which exceeds the limit of 2`,
+		},
+		{
+			SpecName: "introspection aliases",
+			Query:    `{ a: __type(name: "Todo") { name } b: __type(name: "User") { name } }`,
+			Options:  []depthlimit.Option{depthlimit.WithMaxAliases(1)},
+			Expected: `operation has 2 aliases, which exceeds the limit of 1`,
+		},
+		{
+			SpecName: "aliases",
+			Query:    `{ a: todos { id } b: todos { id } c: todos { x: id } }`,
+			Options:  []depthlimit.Option{depthlimit.WithMaxAliases(3)},
+			Expected: `operation has 4 aliases, which exceeds the limit of 3`,
+		},
+		{
+			SpecName: "root fields",
+			Query:    `{ todos { id } todo(id: "x") { id } ...F } fragment F on Query { again: todos { id } }`,
+			Options:  []depthlimit.Option{depthlimit.WithMaxRootFields(2)},
+			Expected: depthlimit.ErrCodeRootFieldLimit,
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			srv := handler.New(graph.NewExecutableSchema(graph.Config{
+				Resolvers: &graph.Resolver{},
+			}))
+			srv.AddTransport(transport.POST{})
+			srv.Use(depthlimit.New(2, spec.Options...))
+
+			body := fmt.Sprintf(`{"query":%q}`, spec.Query)
+			resp := doRequest(srv, body)
+			if spec.Expected == "" {
+				require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+				assert.NotContains(t, resp.Body.String(), "errors")
+				return
+			}
+
+			assert.Contains(t, resp.Body.String(), spec.Expected)
+			assert.Contains(t, resp.Body.String(), `"data":null`)
+		})
+	}
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
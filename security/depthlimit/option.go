@@ -0,0 +1,26 @@
+package depthlimit
+
+type config struct {
+	maxAliases    int
+	maxRootFields int
+}
+
+// Option is anything that can configure DepthLimit.
+type Option func(cfg *config)
+
+// WithMaxAliases rejects operations using more than max aliased fields, which
+// would otherwise let a client run the same expensive field many times in a
+// single selection set.
+func WithMaxAliases(max int) Option {
+	return func(cfg *config) {
+		cfg.maxAliases = max
+	}
+}
+
+// WithMaxRootFields rejects operations selecting more than max top level
+// fields.
+func WithMaxRootFields(max int) Option {
+	return func(cfg *config) {
+		cfg.maxRootFields = max
+	}
+}
@@ -0,0 +1,80 @@
+// Package introspection restricts schema introspection to trusted clients.
+package introspection
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeIntrospectionDisabled is the extensions.code of rejected operations.
+const ErrCodeIntrospectionDisabled = "INTROSPECTION_DISABLED"
+
+// Gate is a gqlgen handler extension allowing __schema and __type only for
+// requests satisfying allow. Operations selecting them otherwise are rejected
+// as a whole. Use it instead of extension.Introspection; __typename is
+// always available.
+type Gate struct {
+	allow func(ctx context.Context) bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = Gate{}
+
+// New returns a Gate enabling introspection for requests whose context
+// satisfies allow, for example because an auth middleware marked the caller
+// as an admin.
+func New(allow func(ctx context.Context) bool) Gate {
+	return Gate{allow: allow}
+}
+
+func (g Gate) ExtensionName() string {
+	return "IntrospectionGate"
+}
+
+func (g Gate) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (g Gate) MutateOperationContext(ctx context.Context, oc *graphql.OperationContext) *gqlerror.Error {
+	if g.allow(ctx) {
+		oc.DisableIntrospection = false
+		return nil
+	}
+
+	oc.DisableIntrospection = true
+	if oc.Operation != nil && introspects(oc.Operation.SelectionSet) {
+		err := gqlerror.Errorf("introspection is disabled")
+		errcode.Set(err, ErrCodeIntrospectionDisabled)
+		return err
+	}
+
+	return nil
+}
+
+// introspects reports whether set selects __schema or __type anywhere.
+func introspects(set ast.SelectionSet) bool {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.Name == "__schema" || sel.Name == "__type" || introspects(sel.SelectionSet) {
+				return true
+			}
+		case *ast.InlineFragment:
+			if introspects(sel.SelectionSet) {
+				return true
+			}
+		case *ast.FragmentSpread:
+			if sel.Definition != nil && introspects(sel.Definition.SelectionSet) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
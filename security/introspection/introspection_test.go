@@ -0,0 +1,55 @@
+package introspection_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/security/introspection"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGate(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(introspection.New(func(ctx context.Context) bool {
+		return graphql.GetOperationContext(ctx).Headers.Get("X-Admin") == "true"
+	}))
+
+	queries := []string{
+		`{ __schema { queryType { name } } }`,
+		`{ ...F } fragment F on Query { ... on Query { __type(name: "Todo") { name } } }`,
+		`{ todos { id } __type(name: "Todo") { name } }`,
+	}
+
+	for _, query := range queries {
+		resp := doRequest(srv, query, false)
+		assert.JSONEq(t, `{"errors":[{"message":"introspection is disabled","extensions":{"code":"INTROSPECTION_DISABLED"}}],"data":null}`, resp.Body.String(), query)
+
+		resp = doRequest(srv, query, true)
+		assert.NotContains(t, resp.Body.String(), "errors", query)
+	}
+
+	resp := doRequest(srv, `{ todos { __typename } }`, false)
+	assert.NotContains(t, resp.Body.String(), "errors")
+}
+
+func doRequest(handler http.Handler, query string, admin bool) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(fmt.Sprintf(`{"query":%q}`, query)))
+	r.Header.Set("Content-Type", "application/json")
+	if admin {
+		r.Header.Set("X-Admin", "true")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,41 @@
+package shutdown
+
+import (
+	"context"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type config struct {
+	rejection func(ctx context.Context) *gqlerror.Error
+}
+
+// Option is anything that can configure Drainer.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		rejection: defaultRejection,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithRejection builds the error returned for the operations received once
+// draining started.
+func WithRejection(fn func(ctx context.Context) *gqlerror.Error) Option {
+	return func(cfg *config) {
+		cfg.rejection = fn
+	}
+}
+
+func defaultRejection(ctx context.Context) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    "server is shutting down",
+		Extensions: map[string]any{"code": ErrCodeShuttingDown},
+	}
+}
@@ -0,0 +1,213 @@
+// Package shutdown drains a gqlgen server before it stops, for rolling
+// deploys without errors: operations in flight are given time to complete
+// while new ones are rejected, and subscriptions and websocket connections
+// are closed so that clients reconnect to another instance.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeShuttingDown is the extensions.code of the operations rejected
+// while draining.
+const ErrCodeShuttingDown = "SERVICE_UNAVAILABLE"
+
+// ErrShuttingDown is the cause of the contexts of the operations and
+// connections cancelled by Drain.
+var ErrShuttingDown = errors.New("shutdown: server is shutting down")
+
+type operation struct {
+	cancel       context.CancelCauseFunc
+	subscription bool
+}
+
+// Drainer is a gqlgen handler extension tracking the operations in flight.
+// Register it first, for the operations it rejects not to reach the other
+// extensions. Websocket connections are only tracked when the transport
+// uses InitFunc.
+type Drainer struct {
+	cfg *config
+
+	mu       sync.Mutex
+	draining bool
+	nextID   uint64
+	ops      map[uint64]operation
+	conns    map[uint64]context.CancelCauseFunc
+	// idle is closed once draining with no operation in flight.
+	idle chan struct{}
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Drainer{}
+
+// New returns a Drainer accepting operations until Drain is called.
+func New(opts ...Option) *Drainer {
+	return &Drainer{
+		cfg:   newConfig(opts...),
+		ops:   map[uint64]operation{},
+		conns: map[uint64]context.CancelCauseFunc{},
+		idle:  make(chan struct{}),
+	}
+}
+
+func (d *Drainer) ExtensionName() string {
+	return "Shutdown"
+}
+
+func (d *Drainer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation tracks the operation until its last response.
+// Operations are given a context cancelled by Drain with ErrShuttingDown.
+func (d *Drainer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	subscription := oc.Operation != nil && oc.Operation.Operation == ast.Subscription
+
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{d.cfg.rejection(ctx)}})
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	id := d.nextID
+	d.nextID++
+	d.ops[id] = operation{cancel: cancel, subscription: subscription}
+	d.mu.Unlock()
+
+	context.AfterFunc(ctx, func() { d.release(id) })
+
+	handler := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		if resp == nil || (!subscription && (resp.HasNext == nil || !*resp.HasNext)) {
+			cancel(nil)
+		}
+		return resp
+	}
+}
+
+func (d *Drainer) release(id uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.ops, id)
+	d.checkIdle()
+}
+
+// checkIdle closes d.idle if draining is over. d.mu must be held.
+func (d *Drainer) checkIdle() {
+	if !d.draining || len(d.ops) > 0 {
+		return
+	}
+	select {
+	case <-d.idle:
+	default:
+		close(d.idle)
+	}
+}
+
+// InitFunc wraps the InitFunc of a transport.Websocket, which may be nil,
+// so that Drain closes its connections and new ones are refused while
+// draining.
+func (d *Drainer) InitFunc(next transport.WebsocketInitFunc) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		var ack *transport.InitPayload
+		if next != nil {
+			var err error
+			ctx, ack, err = next(ctx, payload)
+			if err != nil {
+				return ctx, ack, err
+			}
+		}
+
+		d.mu.Lock()
+		if d.draining {
+			d.mu.Unlock()
+			return ctx, nil, ErrShuttingDown
+		}
+		ctx, cancel := context.WithCancelCause(ctx)
+		id := d.nextID
+		d.nextID++
+		d.conns[id] = cancel
+		d.mu.Unlock()
+
+		context.AfterFunc(ctx, func() {
+			d.mu.Lock()
+			delete(d.conns, id)
+			d.mu.Unlock()
+		})
+
+		return ctx, ack, nil
+	}
+}
+
+// Draining reports whether Drain was called.
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// InFlight returns the number of operations in flight.
+func (d *Drainer) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.ops)
+}
+
+// Ready returns ErrShuttingDown once draining, to fail a readiness check
+// such as health.WithCheck("shutdown", d.Ready) and take the instance out
+// of load balancing.
+func (d *Drainer) Ready(ctx context.Context) error {
+	if d.Draining() {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// Drain stops accepting operations and ends the subscriptions, then waits
+// for the other operations in flight to complete, at most until ctx is
+// done, when they are cancelled and ctx.Err is returned. The websocket
+// connections are closed last. Call it before http.Server.Shutdown, which
+// does not wait for hijacked websocket connections.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	for _, op := range d.ops {
+		if op.subscription {
+			op.cancel(ErrShuttingDown)
+		}
+	}
+	d.checkIdle()
+	d.mu.Unlock()
+
+	var err error
+	select {
+	case <-d.idle:
+	case <-ctx.Done():
+		err = ctx.Err()
+		d.mu.Lock()
+		for _, op := range d.ops {
+			op.cancel(ErrShuttingDown)
+		}
+		d.mu.Unlock()
+	}
+
+	d.mu.Lock()
+	for _, cancel := range d.conns {
+		cancel(ErrShuttingDown)
+	}
+	d.mu.Unlock()
+
+	return err
+}
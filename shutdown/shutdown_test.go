@@ -0,0 +1,144 @@
+package shutdown_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/shutdown"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blocker holds the Todo.id fields until released or cancelled.
+type blocker struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlocker() *blocker {
+	return &blocker{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (b *blocker) around(ctx context.Context, next graphql.Resolver) (any, error) {
+	if fc := graphql.GetFieldContext(ctx); fc.Object == "Todo" && fc.Field.Name == "id" {
+		b.started <- struct{}{}
+		select {
+		case <-b.release:
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+	return next(ctx)
+}
+
+func TestDrainer(t *testing.T) {
+	drainer := shutdown.New()
+	b := newBlocker()
+	srv := newServer(drainer, b)
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- doRequest(srv, `{"query":"{ todos { id } }"}`, false) }()
+	<-b.started
+	assert.Equal(t, 1, drainer.InFlight())
+	assert.NoError(t, drainer.Ready(context.Background()))
+
+	drained := make(chan error)
+	go func() { drained <- drainer.Drain(context.Background()) }()
+	assert.Eventually(t, drainer.Draining, time.Second, time.Millisecond)
+	assert.ErrorIs(t, drainer.Ready(context.Background()), shutdown.ErrShuttingDown)
+
+	rejected := doRequest(srv, `{"query":"{ todos { text } }"}`, false)
+	assert.Contains(t, rejected.Body.String(), shutdown.ErrCodeShuttingDown)
+
+	select {
+	case <-drained:
+		t.Fatal("drained with an operation in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(b.release)
+	assert.NoError(t, <-drained)
+	resp := <-first
+	assert.NotContains(t, resp.Body.String(), "errors")
+	assert.Contains(t, resp.Body.String(), graph.TodoA.ID)
+	assert.Zero(t, drainer.InFlight())
+}
+
+func TestDrainer_Timeout(t *testing.T) {
+	drainer := shutdown.New()
+	b := newBlocker()
+	srv := newServer(drainer, b)
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- doRequest(srv, `{"query":"{ todos { id } }"}`, false) }()
+	<-b.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, drainer.Drain(ctx), context.DeadlineExceeded)
+	assert.Contains(t, (<-first).Body.String(), shutdown.ErrShuttingDown.Error())
+	assert.Eventually(t, func() bool { return drainer.InFlight() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestDrainer_Subscription(t *testing.T) {
+	drainer := shutdown.New()
+	b := newBlocker()
+	srv := newServer(drainer, b)
+
+	sub := make(chan *httptest.ResponseRecorder)
+	go func() { sub <- doRequest(srv, `{"query":"subscription { todoAdded { id } }"}`, true) }()
+	<-b.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, drainer.Drain(ctx))
+	assert.Contains(t, (<-sub).Body.String(), "event: complete")
+}
+
+func TestDrainer_InitFunc(t *testing.T) {
+	drainer := shutdown.New()
+	var called bool
+	init := drainer.InitFunc(func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		called = true
+		return ctx, &transport.InitPayload{"ok": true}, nil
+	})
+
+	ctx, ack, err := init(context.Background(), transport.InitPayload{})
+	require.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, &transport.InitPayload{"ok": true}, ack)
+
+	require.NoError(t, drainer.Drain(context.Background()))
+	assert.ErrorIs(t, context.Cause(ctx), shutdown.ErrShuttingDown)
+
+	_, _, err = init(context.Background(), transport.InitPayload{})
+	assert.ErrorIs(t, err, shutdown.ErrShuttingDown)
+}
+
+func newServer(drainer *shutdown.Drainer, b *blocker) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(drainer)
+	srv.AroundFields(b.around)
+	return srv
+}
+
+func doRequest(handler http.Handler, body string, stream bool) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if stream {
+		r.Header.Set("Accept", "text/event-stream")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,92 @@
+package singleflight
+
+import (
+	"context"
+
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	fields map[string]func(ctx context.Context) string
+	scope  func(ctx context.Context) string
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Group.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		fields:     map[string]func(ctx context.Context) string{},
+		scope:      func(ctx context.Context) string { return "" },
+		registerer: prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithFields collapses the invocations of the resolvers of the given
+// fields, as Object.field, within the scope of WithScope.
+func WithFields(coordinates ...string) Option {
+	return func(cfg *config) {
+		for _, coordinate := range coordinates {
+			cfg.fields[coordinate] = nil
+		}
+	}
+}
+
+// WithField collapses the invocations of the resolver of the field
+// Object.field made in the same scope, as returned by fn.
+func WithField(coordinate string, scope func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.fields[coordinate] = scope
+	}
+}
+
+// WithScope sets the scope of fields given to WithFields, only invocations
+// for which fn returns the same value being collapsed. By default every
+// caller shares the scope, so use it whenever results depend on who is
+// asking, for example by returning the authenticated user.
+func WithScope(fn func(ctx context.Context) string) Option {
+	return func(cfg *config) {
+		cfg.scope = fn
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
@@ -0,0 +1,179 @@
+// Package singleflight runs identical concurrent invocations of resolvers
+// once, protecting the backends of hot keys during traffic spikes.
+package singleflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+// Group is a gqlgen handler extension collapsing the concurrent invocations
+// of the resolvers of configured fields with the same arguments and scope,
+// within and across requests, into one execution whose result they all
+// get. Fields of other types than Query are also keyed by the id of their
+// parent object, and never collapsed if it has none.
+//
+// Results are shared as is, resolvers must not return values that callers
+// modify. An invocation waiting for another one stops at the end of its own
+// context, and runs the resolver itself if the one it waited for was
+// canceled.
+//
+// Invocations served by another one are counted in
+// graphql_resolver_deduplicated_total{object,field}.
+type Group struct {
+	cfg     *config
+	counter *prometheusclient.CounterVec
+	group   singleflight.Group
+	query   string
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Group{}
+
+// New returns a Group whose counter is registered on the configured
+// registerer.
+func New(opts ...Option) *Group {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_resolver_deduplicated_total",
+			Help:        "Total number of resolver invocations served by a concurrent identical one.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"object", "field"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return &Group{cfg: cfg, counter: counter}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (g *Group) UnRegister() {
+	g.cfg.registerer.Unregister(g.counter)
+}
+
+func (g *Group) ExtensionName() string {
+	return "Singleflight"
+}
+
+// Validate checks that the configured fields exist.
+func (g *Group) Validate(schema graphql.ExecutableSchema) error {
+	s := schema.Schema()
+	for coordinate := range g.cfg.fields {
+		object, field, _ := strings.Cut(coordinate, ".")
+		def := s.Types[object]
+		if def == nil || def.Fields.ForName(field) == nil {
+			return fmt.Errorf("singleflight: unknown field %s", coordinate)
+		}
+	}
+	if s.Query != nil {
+		g.query = s.Query.Name
+	}
+	return nil
+}
+
+func (g *Group) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	scope, ok := g.cfg.fields[fc.Object+"."+fc.Field.Name]
+	if !ok {
+		return next(ctx)
+	}
+	if scope == nil {
+		scope = g.cfg.scope
+	}
+
+	key, err := g.key(ctx, fc, scope)
+	if err != nil {
+		return next(ctx)
+	}
+
+	// DoChan runs fn in a goroutine of its own, whose panics would not be
+	// recovered by gqlgen: they are panicked again by the invocation that
+	// ran it.
+	var ran bool
+	ch := g.group.DoChan(key, func() (res any, err error) {
+		ran = true
+		defer func() {
+			if r := recover(); r != nil {
+				err = &panicError{value: r}
+			}
+		}()
+		return next(ctx)
+	})
+
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-ch:
+	}
+
+	var pe *panicError
+	if errors.As(res.Err, &pe) {
+		if ran {
+			panic(pe.value)
+		}
+		return nil, fmt.Errorf("singleflight: resolver panicked: %v", pe.value)
+	}
+	if ran {
+		return res.Val, res.Err
+	}
+	if (errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded)) && ctx.Err() == nil {
+		// The run ended with the context of another invocation.
+		return next(ctx)
+	}
+	g.counter.WithLabelValues(fc.Object, fc.Field.Name).Inc()
+	return res.Val, res.Err
+}
+
+var errNoParentID = errors.New("singleflight: parent has no id")
+
+type panicError struct {
+	value any
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprint(e.value)
+}
+
+func (g *Group) key(ctx context.Context, fc *graphql.FieldContext, scope func(ctx context.Context) string) (string, error) {
+	parts := map[string]any{
+		"args":  fc.Args,
+		"scope": scope(ctx),
+	}
+	if fc.Object != g.query && fc.Parent != nil && fc.Parent.Result != nil {
+		data, err := json.Marshal(fc.Parent.Result)
+		if err != nil {
+			return "", err
+		}
+		var parent struct {
+			ID any `json:"id"`
+		}
+		_ = json.Unmarshal(data, &parent)
+		if parent.ID == nil {
+			// Invocations for every parent would share a key.
+			return "", errNoParentID
+		}
+		parts["parent"] = parent.ID
+	}
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", err
+	}
+	return fc.Object + "." + fc.Field.Name + ":" + string(data), nil
+}
@@ -0,0 +1,164 @@
+package singleflight_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/singleflight"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type userKey struct{}
+
+func TestGroup(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	group := singleflight.New(
+		singleflight.WithRegisterer(registry),
+		singleflight.WithFields("Query.todo"),
+		singleflight.WithScope(func(ctx context.Context) string {
+			user, _ := ctx.Value(userKey{}).(string)
+			return user
+		}),
+	)
+	defer group.UnRegister()
+
+	var calls atomic.Int32
+	var release chan struct{}
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(group)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if graphql.GetFieldContext(ctx).Field.Name == "todo" {
+			calls.Add(1)
+			<-release
+		}
+		return next(ctx)
+	})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, r.Header.Get("X-User"))))
+	})
+
+	// concurrently sends the queries as the users, returning the responses.
+	concurrently := func(users []string, queries ...string) []string {
+		calls.Store(0)
+		release = make(chan struct{})
+		responses := make([]string, len(users))
+		var wg sync.WaitGroup
+		for i, user := range users {
+			wg.Go(func() {
+				responses[i] = doRequest(h, user, queries[i%len(queries)]).Body.String()
+			})
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		return responses
+	}
+
+	todo := `{"query":"{ todo(id: \"` + graph.TodoA.ID + `\") { text } }"}`
+	for _, resp := range concurrently([]string{"alice", "alice", "alice"}, todo) {
+		assert.JSONEq(t, `{"data":{"todo":{"text":"Todo A"}}}`, resp)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Other arguments and scopes are not collapsed.
+	other := `{"query":"{ todo(id: \"unknown\") { text } }"}`
+	concurrently([]string{"alice", "bob", "alice"}, todo, todo, other)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Aliases of the same field in a request are collapsed too.
+	resp := concurrently([]string{"alice"}, `{"query":"{ a: todo(id: \"`+graph.TodoA.ID+`\") { id } b: todo(id: \"`+graph.TodoA.ID+`\") { text } }"}`)
+	assert.JSONEq(t, `{"data":{"a":{"id":"`+graph.TodoA.ID+`"},"b":{"text":"Todo A"}}}`, resp[0])
+	assert.Equal(t, int32(1), calls.Load())
+
+	metrics := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, metrics.Body.String(), `graphql_resolver_deduplicated_total{field="todo",object="Query"} 3`)
+}
+
+func TestGroup_Panic(t *testing.T) {
+	group := singleflight.New(
+		singleflight.WithRegisterer(prometheusclient.NewRegistry()),
+		singleflight.WithFields("Query.todos"),
+	)
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(group)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if graphql.GetFieldContext(ctx).Field.Name == "todos" {
+			panic("boom")
+		}
+		return next(ctx)
+	})
+
+	resp := doRequest(srv, "", `{"query":"{ todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "internal system error")
+}
+
+func TestGroup_ParentWithoutID(t *testing.T) {
+	group := singleflight.New(
+		singleflight.WithRegisterer(prometheusclient.NewRegistry()),
+		singleflight.WithFields("Widget.label"),
+	)
+
+	// Each resolver waits for the other to start, or gives up if only one
+	// runs.
+	var started atomic.Int32
+	both := make(chan struct{})
+	resolve := func(name string) any {
+		ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{Result: &struct{ Name string }{name}})
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object:     "Widget",
+			Field:      graphql.CollectedField{Field: &ast.Field{Name: "label"}},
+			IsResolver: true,
+		})
+		res, err := group.InterceptField(ctx, func(ctx context.Context) (any, error) {
+			if started.Add(1) == 2 {
+				close(both)
+			}
+			select {
+			case <-both:
+			case <-time.After(100 * time.Millisecond):
+			}
+			return name, nil
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	results := make([]any, 2)
+	var wg sync.WaitGroup
+	for i, name := range []string{"a", "b"} {
+		wg.Go(func() { results[i] = resolve(name) })
+	}
+	wg.Wait()
+	assert.Equal(t, []any{"a", "b"}, results, "parents without id are not collapsed")
+}
+
+func doRequest(handler http.Handler, user, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-User", user)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,273 @@
+package sloglog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/incremental"
+	"github.com/99designs/gqlgen-contrib/internal/slowest"
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Logger is a gqlgen handler extension writing one record per GraphQL
+// operation through log/slog. Successful operations are logged at info level,
+// operations with errors at warn level. GraphQL metadata is grouped under
+// the "graphql" attribute.
+//
+// With WithSlowThreshold, only operations slower than the threshold are
+// logged, at warn level.
+//
+// Operations using @defer are logged once their last patch is delivered, with
+// the errors of all their responses and an "incremental" group, or when the
+// request ends for transports only delivering the initial payload.
+type Logger struct {
+	logger *slog.Logger
+	cfg    config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Logger{}
+
+// New returns a Logger writing to logger.
+func New(logger *slog.Logger, opts ...Option) Logger {
+	l := Logger{
+		logger: logger,
+		cfg: config{
+			sampleRate:    1,
+			slowestFields: 5,
+			classifier:    contrib.Classify,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&l.cfg)
+	}
+	if len(l.cfg.redactedVariables) != 0 {
+		l.cfg.redactor = l.cfg.redactor.With(redact.Path(l.cfg.redactedVariables...))
+	}
+
+	return l
+}
+
+func (l Logger) ExtensionName() string {
+	return "SlogLogger"
+}
+
+func (l Logger) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+type (
+	collectorKey struct{}
+	streamKey    struct{}
+)
+
+func (l Logger) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+	ctx = context.WithValue(ctx, streamKey{}, incremental.New(oc.Stats.OperationStart))
+	if l.cfg.slowThreshold <= 0 {
+		return next(ctx)
+	}
+	return next(context.WithValue(ctx, collectorKey{}, slowest.NewCollector(l.cfg.slowestFields)))
+}
+
+func (l Logger) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	stream, ok := ctx.Value(streamKey{}).(*incremental.Stream)
+	if !ok {
+		l.log(ctx, res.Errors, time.Since(graphql.GetOperationContext(ctx).Stats.OperationStart), nil)
+		return res
+	}
+	switch first, last := stream.Add(res); {
+	case first && last:
+		l.log(ctx, res.Errors, stream.Duration(), nil)
+	case first:
+		stream.AfterFunc(ctx, func() {
+			l.log(ctx, stream.Errors(), stream.Duration(), stream)
+		})
+	case last && stream.Finish():
+		l.log(ctx, stream.Errors(), stream.Duration(), stream)
+	}
+
+	return res
+}
+
+// log writes the record of an operation, stream being nil unless it used
+// @defer.
+func (l Logger) log(ctx context.Context, errs gqlerror.List, duration time.Duration, stream *incremental.Stream) {
+	oc := graphql.GetOperationContext(ctx)
+	collector, slow := ctx.Value(collectorKey{}).(*slowest.Collector)
+
+	level := slog.LevelInfo
+	switch {
+	case slow:
+		if duration < l.cfg.slowThreshold {
+			return
+		}
+		level = slog.LevelWarn
+	case l.cfg.slowThreshold > 0:
+		// Subscriptions have no collector and are not slow-logged.
+		return
+	case len(errs) != 0:
+		level = slog.LevelWarn
+	case !l.sampled():
+		return
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []any{
+		slog.Group("operation",
+			slog.String("name", gqlctx.OperationNameOrNameless(oc)),
+			slog.String("type", operationType(oc)),
+		),
+		slog.Int("errors", len(errs)),
+		slog.String("outcome", string(l.cfg.classifier(ctx, errs))),
+	}
+	if codes := extcode.Codes(errs); len(codes) != 0 {
+		attrs = append(attrs, slog.Any("error_codes", codes))
+	}
+	if stats := extension.GetComplexityStats(ctx); stats != nil {
+		attrs = append(attrs, slog.Group("complexity",
+			slog.Int("value", stats.Complexity),
+			slog.Int("limit", stats.ComplexityLimit),
+		))
+	}
+	if client := clientAttrs(ctx); len(client) != 0 {
+		attrs = append(attrs, slog.Group("client", client...))
+	}
+	if l.cfg.logVariables {
+		attrs = append(attrs, slog.Any("variables", l.cfg.redactor.Variables(oc)))
+	}
+	if stream != nil {
+		attrs = append(attrs, slog.Group("incremental",
+			slog.Int("patches", stream.Patches()),
+			slog.Int("patch_errors", stream.PatchErrors()),
+			slog.Duration("first_response", stream.FirstResponse()),
+		))
+	}
+	if slow {
+		attrs = append(attrs,
+			slog.Int("resolvers", collector.Resolvers()),
+			slog.Any("slowest", slowFields(collector.Slowest())),
+		)
+	}
+
+	msg := "graphql operation"
+	if slow {
+		msg = "slow graphql operation"
+	}
+	record := []slog.Attr{
+		slog.Duration("duration", duration),
+		slog.Group("graphql", attrs...),
+	}
+	if l.cfg.enricher != nil {
+		labels := l.cfg.enricher(ctx)
+		for _, key := range slices.Sorted(maps.Keys(labels)) {
+			record = append(record, slog.String(key, labels[key]))
+		}
+	}
+
+	l.logger.LogAttrs(ctx, level, msg, record...)
+}
+
+func (l Logger) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	collector, slow := ctx.Value(collectorKey{}).(*slowest.Collector)
+	logResolver := l.cfg.resolverLevel != nil && l.logger.Enabled(ctx, *l.cfg.resolverLevel)
+	if !slow && !logResolver {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	duration := time.Since(start)
+
+	fc := graphql.GetFieldContext(ctx)
+	if slow {
+		collector.Add(fc.Path().String(), duration)
+	}
+	if !logResolver {
+		return res, err
+	}
+
+	attrs := []any{
+		slog.Group("resolver",
+			slog.String("object", fc.Object),
+			slog.String("field", fc.Field.Name),
+			slog.String("path", fc.Path().String()),
+		),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	l.logger.LogAttrs(ctx, *l.cfg.resolverLevel, "graphql resolver",
+		slog.Duration("duration", duration),
+		slog.Group("graphql", attrs...),
+	)
+
+	return res, err
+}
+
+func (l Logger) sampled() bool {
+	return l.cfg.sampleRate >= 1 || rand.Float64() < l.cfg.sampleRate
+}
+
+// slowField is a resolver listed in slow operation records.
+type slowField struct {
+	Path     string        `json:"path"`
+	Duration time.Duration `json:"duration"`
+}
+
+func slowFields(fields []slowest.Field) []slowField {
+	out := make([]slowField, len(fields))
+	for i, f := range fields {
+		out[i] = slowField{Path: f.Path, Duration: f.Duration}
+	}
+
+	return out
+}
+
+func clientAttrs(ctx context.Context) []any {
+	var attrs []any
+	client := clientinfo.ForContext(ctx)
+	if client.Name != "" {
+		attrs = append(attrs, slog.String("name", client.Name))
+	}
+	if client.Version != "" {
+		attrs = append(attrs, slog.String("version", client.Version))
+	}
+
+	return attrs
+}
+
+func operationType(oc *graphql.OperationContext) string {
+	if oc.Operation == nil {
+		return ""
+	}
+	return string(oc.Operation.Operation)
+}
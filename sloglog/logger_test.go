@@ -0,0 +1,213 @@
+package sloglog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/sloglog"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	newServer := func(buf *bytes.Buffer, opts ...sloglog.Option) *handler.Server {
+		logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		srv := handler.New(graph.NewExecutableSchema(graph.Config{
+			Resolvers: &graph.Resolver{},
+		}))
+		srv.AddTransport(transport.MultipartMixed{})
+		srv.AddTransport(transport.POST{})
+		srv.Use(sloglog.New(logger, opts...))
+		return srv
+	}
+
+	t.Run("operation", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		resp := doRequest(newServer(&buf, sloglog.WithVariables("id")), `{"query":"query Lookup($id: ID!) { todo(id: $id) { id } }","variables":{"id":"unknown"}}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		records := decode(t, &buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "WARN", records[0]["level"])
+		assert.Equal(t, "graphql operation", records[0]["msg"])
+		assert.Contains(t, records[0], "duration")
+		assert.Equal(t, map[string]interface{}{
+			"operation": map[string]interface{}{
+				"name": "Lookup",
+				"type": "query",
+			},
+			"errors":  float64(1),
+			"outcome": "internal_error",
+			"client": map[string]interface{}{
+				"name": "web",
+			},
+			"variables": map[string]interface{}{
+				"id": "[REDACTED]",
+			},
+		}, records[0]["graphql"])
+	})
+
+	t.Run("resolvers", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		resp := doRequest(newServer(&buf, sloglog.WithResolvers(slog.LevelDebug)), `{"query":"{ todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		records := decode(t, &buf)
+		require.Len(t, records, 5)
+
+		var paths []string
+		for _, record := range records[:4] {
+			assert.Equal(t, "DEBUG", record["level"])
+			assert.Equal(t, "graphql resolver", record["msg"])
+			resolver := record["graphql"].(map[string]interface{})["resolver"].(map[string]interface{})
+			paths = append(paths, resolver["path"].(string))
+		}
+		assert.ElementsMatch(t, []string{"todos", "todos[0].id", "todos[1].id", "todos[2].id"}, paths)
+		assert.Equal(t, "graphql operation", records[4]["msg"])
+	})
+
+	t.Run("enricher", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		resp := doRequest(newServer(&buf, sloglog.WithEnricher(func(ctx context.Context) map[string]string {
+			return map[string]string{"tenant": "acme", "region": "eu"}
+		})), `{"query":"{ todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		records := decode(t, &buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "acme", records[0]["tenant"])
+		assert.Equal(t, "eu", records[0]["region"])
+	})
+
+	t.Run("slow threshold", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		srv := newServer(&buf, sloglog.WithSlowThreshold(20*time.Millisecond), sloglog.WithSlowestFields(2))
+		srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+			if graphql.GetFieldContext(ctx).Field.Name == "todos" {
+				time.Sleep(30 * time.Millisecond)
+			}
+			return next(ctx)
+		})
+
+		resp := doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, decode(t, &buf))
+
+		resp = doRequest(srv, `{"query":"query Slow { todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		records := decode(t, &buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "WARN", records[0]["level"])
+		assert.Equal(t, "slow graphql operation", records[0]["msg"])
+
+		attrs := records[0]["graphql"].(map[string]interface{})
+		assert.Equal(t, float64(4), attrs["resolvers"])
+		slowest := attrs["slowest"].([]interface{})
+		require.Len(t, slowest, 2)
+		assert.Equal(t, "todos", slowest[0].(map[string]interface{})["path"])
+		assert.GreaterOrEqual(t, slowest[0].(map[string]interface{})["duration"], float64(30*time.Millisecond))
+	})
+
+	t.Run("defer", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Deferred { todos { id ... @defer { completed } } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "multipart/mixed")
+		w := httptest.NewRecorder()
+		newServer(&buf).ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		records := decode(t, &buf)
+		require.Len(t, records, 1)
+		record := records[0]["graphql"].(map[string]interface{})
+		assert.Equal(t, "Deferred", record["operation"].(map[string]interface{})["name"])
+		incremental := record["incremental"].(map[string]interface{})
+		assert.Equal(t, float64(3), incremental["patches"])
+		assert.Equal(t, float64(0), incremental["patch_errors"])
+		assert.Contains(t, incremental, "first_response")
+	})
+
+	t.Run("defer without patches", func(t *testing.T) {
+		records := make(chanWriter, 1)
+		srv := handler.New(graph.NewExecutableSchema(graph.Config{
+			Resolvers: &graph.Resolver{},
+		}))
+		srv.AddTransport(transport.POST{})
+		srv.Use(sloglog.New(slog.New(slog.NewJSONHandler(records, nil))))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		r := httptest.NewRequestWithContext(ctx, http.MethodPost, "/query", strings.NewReader(`{"query":"query Deferred { todos { id ... @defer { completed } } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, records)
+
+		// POST only delivers the initial payload, the record is written once
+		// the request ends.
+		cancel()
+		var record map[string]interface{}
+		select {
+		case b := <-records:
+			require.NoError(t, json.Unmarshal(b, &record))
+		case <-time.After(time.Second):
+			t.Fatal("operation not logged")
+		}
+		incremental := record["graphql"].(map[string]interface{})["incremental"].(map[string]interface{})
+		assert.Equal(t, float64(0), incremental["patches"])
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		resp := doRequest(newServer(&buf, sloglog.WithSampleRate(0)), `{"query":"{ todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, decode(t, &buf))
+	})
+}
+
+// chanWriter sends each record written to it.
+type chanWriter chan []byte
+
+func (w chanWriter) Write(b []byte) (int, error) {
+	w <- bytes.Clone(b)
+	return len(b), nil
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]interface{}
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("apollographql-client-name", "web")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,93 @@
+package sloglog
+
+import (
+	"log/slog"
+	"time"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/99designs/gqlgen-contrib/redact"
+)
+
+type config struct {
+	sampleRate        float64
+	logVariables      bool
+	redactedVariables []string
+	redactor          *redact.Redactor
+	resolverLevel     *slog.Level
+	enricher          contrib.Enricher
+	slowThreshold     time.Duration
+	slowestFields     int
+	classifier        contrib.Classifier
+}
+
+// Option is anything that can configure Logger.
+type Option func(cfg *config)
+
+// WithSampleRate logs only the given fraction (0 to 1) of successful
+// operations. Operations with errors are always logged.
+func WithSampleRate(rate float64) Option {
+	return func(cfg *config) {
+		cfg.sampleRate = rate
+	}
+}
+
+// WithVariables adds the operation variables to each operation record. The
+// values of the named variables are replaced by "[REDACTED]".
+func WithVariables(redacted ...string) Option {
+	return func(cfg *config) {
+		cfg.logVariables = true
+		cfg.redactedVariables = append(cfg.redactedVariables, redacted...)
+	}
+}
+
+// WithRedactor redacts the variables logged by WithVariables with r, on top
+// of the variables named there.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(cfg *config) {
+		cfg.redactor = r
+	}
+}
+
+// WithResolvers additionally emits one record per resolved field at the
+// given level, typically slog.LevelDebug.
+func WithResolvers(level slog.Level) Option {
+	return func(cfg *config) {
+		cfg.resolverLevel = &level
+	}
+}
+
+// WithEnricher adds the labels returned by fn to each operation record, in
+// key order. Calling it again adds more labels.
+func WithEnricher(fn contrib.Enricher) Option {
+	return func(cfg *config) {
+		if cfg.enricher == nil {
+			cfg.enricher = fn
+		} else {
+			cfg.enricher = contrib.Enrichers(cfg.enricher, fn)
+		}
+	}
+}
+
+// WithSlowThreshold logs only operations taking at least d, at warn level
+// and with their slowest resolvers, in place of every operation.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.slowThreshold = d
+	}
+}
+
+// WithSlowestFields sets how many of the slowest resolvers are listed in
+// slow operation records. It defaults to 5.
+func WithSlowestFields(n int) Option {
+	return func(cfg *config) {
+		cfg.slowestFields = n
+	}
+}
+
+// WithClassifier sets how the outcome attribute of operation records is
+// computed. It defaults to contrib.Classify.
+func WithClassifier(fn contrib.Classifier) Option {
+	return func(cfg *config) {
+		cfg.classifier = fn
+	}
+}
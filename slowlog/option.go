@@ -0,0 +1,40 @@
+package slowlog
+
+import "time"
+
+type config struct {
+	size   int
+	window time.Duration
+}
+
+// Option is anything that can configure Log.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		size:   10,
+		window: 5 * time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithSize sets how many of the slowest operations, and of the slowest
+// resolvers, are kept. It defaults to 10.
+func WithSize(n int) Option {
+	return func(cfg *config) {
+		cfg.size = n
+	}
+}
+
+// WithWindow sets how long an invocation is kept before it ages out. It
+// defaults to 5 minutes.
+func WithWindow(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.window = d
+	}
+}
@@ -0,0 +1,175 @@
+// Package slowlog keeps the slowest GraphQL operations and resolvers of a
+// sliding window in memory, for triage of a running server through a Go API
+// or an HTTP debug endpoint.
+package slowlog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// DebugPath is the conventional path to serve Handler on.
+const DebugPath = "/debug/graphql/slow"
+
+// Operation is a recorded GraphQL operation.
+type Operation struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Query    string        `json:"query"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+	Errors   int           `json:"errors"`
+}
+
+// Resolver is a recorded resolver invocation.
+type Resolver struct {
+	Object    string        `json:"object"`
+	Field     string        `json:"field"`
+	Path      string        `json:"path"`
+	Operation string        `json:"operation"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"duration_ns"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Log is a gqlgen handler extension keeping the slowest operations and
+// resolvers. Subscriptions are not recorded, and only fields with a resolver
+// are.
+type Log struct {
+	mu         sync.Mutex
+	operations *slowest[Operation]
+	resolvers  *slowest[Resolver]
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Log{}
+
+// New returns an empty Log.
+func New(opts ...Option) *Log {
+	cfg := newConfig(opts...)
+	return &Log{
+		operations: newSlowest(cfg.size, cfg.window, func(o Operation) time.Duration { return o.Duration }),
+		resolvers:  newSlowest(cfg.size, cfg.window, func(r Resolver) time.Duration { return r.Duration }),
+	}
+}
+
+// Operations returns the slowest operations of the window, slowest first.
+func (l *Log) Operations() []Operation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.operations.list(time.Now())
+}
+
+// Resolvers returns the slowest resolvers of the window, slowest first.
+func (l *Log) Resolvers() []Resolver {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.resolvers.list(time.Now())
+}
+
+// Reset forgets everything recorded so far.
+func (l *Log) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.operations.reset()
+	l.resolvers.reset()
+}
+
+// Handler serves the slowest operations and resolvers as JSON. Like the
+// other debug endpoints, it is meant for an internal port only, as queries
+// are shown verbatim.
+func (l *Log) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Operations []Operation `json:"operations"`
+			Resolvers  []Resolver  `json:"resolvers"`
+		}{
+			Operations: l.Operations(),
+			Resolvers:  l.Resolvers(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+func (l *Log) ExtensionName() string {
+	return "SlowLog"
+}
+
+func (l *Log) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l *Log) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return res
+	}
+
+	now := time.Now()
+	op := Operation{
+		Name:     gqlctx.OperationName(oc),
+		Query:    oc.RawQuery,
+		Start:    oc.Stats.OperationStart,
+		Duration: now.Sub(oc.Stats.OperationStart),
+		Errors:   len(res.Errors),
+	}
+	if oc.Operation != nil {
+		op.Type = string(oc.Operation.Operation)
+	}
+
+	l.mu.Lock()
+	l.operations.add(now, op)
+	l.mu.Unlock()
+
+	return res
+}
+
+func (l *Log) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !fc.IsResolver {
+		return next(ctx)
+	}
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	now := time.Now()
+
+	resolver := Resolver{
+		Object:    fc.Object,
+		Field:     fc.Field.Name,
+		Path:      fc.Path().String(),
+		Operation: gqlctx.OperationName(oc),
+		Start:     start,
+		Duration:  now.Sub(start),
+	}
+	if err != nil {
+		resolver.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	l.resolvers.add(now, resolver)
+	l.mu.Unlock()
+
+	return res, err
+}
@@ -0,0 +1,119 @@
+package slowlog_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/slowlog"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	log := slowlog.New(slowlog.WithSize(2))
+	srv := newServer(log)
+
+	doRequest(srv, `{"query":"query Fast { todo(id: \"unknown\") { id } }"}`)
+	doRequest(srv, `{"query":"query Slow { todos { id } }"}`)
+	doRequest(srv, `{"query":"query Slower { todos { id user { id } } }"}`)
+
+	operations := log.Operations()
+	require.Len(t, operations, 2)
+	assert.Equal(t, "Slower", operations[0].Name)
+	assert.Equal(t, "query", operations[0].Type)
+	assert.Equal(t, "query Slower { todos { id user { id } } }", operations[0].Query)
+	assert.Equal(t, "Slow", operations[1].Name)
+	assert.GreaterOrEqual(t, operations[0].Duration, operations[1].Duration)
+
+	resolvers := log.Resolvers()
+	require.Len(t, resolvers, 2)
+	assert.Equal(t, "Query", resolvers[0].Object)
+	assert.Equal(t, "todos", resolvers[0].Field)
+	assert.Equal(t, "todos", resolvers[0].Path)
+	assert.Equal(t, "Slower", resolvers[0].Operation)
+	assert.GreaterOrEqual(t, resolvers[0].Duration, resolvers[1].Duration)
+
+	log.Reset()
+	assert.Empty(t, log.Operations())
+	assert.Empty(t, log.Resolvers())
+}
+
+func TestLog_Window(t *testing.T) {
+	log := slowlog.New(slowlog.WithWindow(100 * time.Millisecond))
+	srv := newServer(log)
+
+	doRequest(srv, `{"query":"query Old { todos { id } }"}`)
+	require.Len(t, log.Operations(), 1)
+
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, log.Operations())
+	assert.Empty(t, log.Resolvers())
+
+	doRequest(srv, `{"query":"query New { todos { id } }"}`)
+	operations := log.Operations()
+	require.Len(t, operations, 1)
+	assert.Equal(t, "New", operations[0].Name)
+}
+
+func TestLog_Handler(t *testing.T) {
+	log := slowlog.New()
+	doRequest(newServer(log), `{"query":"query List { todos { id } }"}`)
+
+	mux := http.NewServeMux()
+	mux.Handle(slowlog.DebugPath, log.Handler())
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, slowlog.DebugPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body struct {
+		Operations []map[string]any `json:"operations"`
+		Resolvers  []map[string]any `json:"resolvers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Operations, 1)
+	assert.Equal(t, "List", body.Operations[0]["name"])
+	assert.Contains(t, body.Operations[0], "duration_ns")
+	require.Len(t, body.Resolvers, 1)
+	assert.Equal(t, "todos", body.Resolvers[0]["path"])
+}
+
+// newServer slows the todos and user resolvers down, so that the order of
+// the slowest entries is deterministic.
+func newServer(log *slowlog.Log) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(log)
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		switch graphql.GetFieldContext(ctx).Field.Name {
+		case "todos":
+			time.Sleep(10 * time.Millisecond)
+			if graphql.GetOperationContext(ctx).Operation.Name == "Slower" {
+				time.Sleep(20 * time.Millisecond)
+			}
+		case "user":
+			time.Sleep(time.Millisecond)
+		}
+		return next(ctx)
+	})
+	return srv
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,88 @@
+package slowlog
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// buckets is the number of slices a window is split into. Entries age out a
+// bucket at a time, so the window slides in steps of window/buckets.
+const buckets = 10
+
+type bucket[T any] struct {
+	start   time.Time
+	entries []T
+}
+
+// slowest keeps the size slowest entries of each bucket of a sliding window,
+// which is enough to answer the size slowest entries of the whole window.
+type slowest[T any] struct {
+	size     int
+	window   time.Duration
+	duration func(T) time.Duration
+	buckets  []bucket[T]
+}
+
+func newSlowest[T any](size int, window time.Duration, duration func(T) time.Duration) *slowest[T] {
+	return &slowest[T]{size: size, window: window, duration: duration}
+}
+
+func (s *slowest[T]) add(now time.Time, entry T) {
+	if s.size <= 0 {
+		return
+	}
+	s.expire(now)
+
+	start := now.Truncate(s.window / buckets)
+	if len(s.buckets) == 0 || s.buckets[len(s.buckets)-1].start.Before(start) {
+		s.buckets = append(s.buckets, bucket[T]{start: start})
+	}
+	b := &s.buckets[len(s.buckets)-1]
+
+	d := s.duration(entry)
+	// Entries are sorted slowest first.
+	i, _ := slices.BinarySearchFunc(b.entries, d, func(e T, d time.Duration) int {
+		return cmp.Compare(d, s.duration(e))
+	})
+	if i == s.size {
+		return
+	}
+	if len(b.entries) < s.size {
+		b.entries = append(b.entries, entry)
+	}
+	copy(b.entries[i+1:], b.entries[i:])
+	b.entries[i] = entry
+}
+
+// expire drops the buckets that ended before the window.
+func (s *slowest[T]) expire(now time.Time) {
+	cutoff := now.Add(-s.window)
+	i := 0
+	for i < len(s.buckets) && !s.buckets[i].start.Add(s.window/buckets).After(cutoff) {
+		i++
+	}
+	s.buckets = slices.Delete(s.buckets, 0, i)
+}
+
+// list returns the size slowest entries of the window, slowest first.
+func (s *slowest[T]) list(now time.Time) []T {
+	s.expire(now)
+
+	var all []T
+	for _, b := range s.buckets {
+		all = append(all, b.entries...)
+	}
+	slices.SortStableFunc(all, func(a, b T) int {
+		return cmp.Compare(s.duration(b), s.duration(a))
+	})
+	if len(all) > s.size {
+		all = all[:s.size]
+	}
+
+	return all
+}
+
+func (s *slowest[T]) reset() {
+	s.buckets = nil
+}
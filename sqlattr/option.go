@@ -0,0 +1,44 @@
+package sqlattr
+
+import (
+	contrib "github.com/99designs/gqlgen-contrib"
+)
+
+type config struct {
+	enricher    contrib.Enricher
+	traceparent bool
+}
+
+// Option is anything that can configure the comments of queries.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithEnricher adds the labels returned by fn to the comments. Calling it
+// again adds more labels.
+func WithEnricher(fn contrib.Enricher) Option {
+	return func(cfg *config) {
+		if cfg.enricher == nil {
+			cfg.enricher = fn
+		} else {
+			cfg.enricher = contrib.Enrichers(cfg.enricher, fn)
+		}
+	}
+}
+
+// WithTraceparent adds the W3C traceparent of the OpenTelemetry span of the
+// query, if sampled, as databases such as Cloud SQL link queries to traces
+// with it.
+func WithTraceparent() Option {
+	return func(cfg *config) {
+		cfg.traceparent = true
+	}
+}
@@ -0,0 +1,95 @@
+// Package sqlattr comments the SQL queries issued by resolvers with the
+// GraphQL operation and field path they were issued for, in the sqlcommenter
+// format, so the slow query logs of databases can be traced back to
+// resolvers.
+//
+// For instance, a query of the todos field of the Todos operation is sent
+// as:
+//
+//	SELECT id FROM todos /*graphql_operation='Todos',graphql_path='todos'*/
+package sqlattr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/sqldriver"
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Open opens a database like sql.Open, the queries and statements executed
+// on it being commented.
+func Open(driverName, dsn string, opts ...Option) (*sql.DB, error) {
+	c, err := sqldriver.Connector(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(Connector(c, opts...)), nil
+}
+
+// Connector returns a connector whose connections comment the queries and
+// statements executed with c, to be opened with sql.OpenDB. Statements are
+// commented when prepared.
+func Connector(c driver.Connector, opts ...Option) driver.Connector {
+	cfg := newConfig(opts...)
+	return sqldriver.Wrap(c, sqldriver.Interceptor{Rewrite: cfg.comment})
+}
+
+// comment appends the comment describing ctx to query. Queries issued
+// outside of operations, and those already commented, are left unchanged
+// as sqlcommenter requires.
+func (cfg *config) comment(ctx context.Context, query string) string {
+	if strings.Contains(query, "/*") || strings.Contains(query, "--") {
+		return query
+	}
+
+	tags := map[string]string{}
+	if graphql.HasOperationContext(ctx) {
+		if name := gqlctx.OperationName(graphql.GetOperationContext(ctx)); name != "" {
+			tags["graphql_operation"] = name
+		}
+	}
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		tags["graphql_path"] = fc.Path().String()
+	}
+	if len(tags) == 0 {
+		return query
+	}
+	if cfg.enricher != nil {
+		for key, value := range cfg.enricher(ctx) {
+			tags[key] = value
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); cfg.traceparent && sc.IsValid() && sc.IsSampled() {
+		tags["traceparent"] = "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String()
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	trimmed := strings.TrimRight(query, " \t\n;")
+	b.WriteString(trimmed)
+	b.WriteString(" /*")
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		// Escaping leaves no quote or comment delimiter in values.
+		b.WriteString(url.PathEscape(key) + "='" + url.PathEscape(tags[key]) + "'")
+	}
+	b.WriteString("*/")
+	if strings.HasSuffix(strings.TrimRight(query, " \t\n"), ";") {
+		b.WriteByte(';')
+	}
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package sqlattr_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/otel"
+	"github.com/99designs/gqlgen-contrib/sqlattr"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestConnector(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	c := &connector{}
+	db := sql.OpenDB(sqlattr.Connector(c,
+		sqlattr.WithTraceparent(),
+		sqlattr.WithEnricher(func(ctx context.Context) map[string]string {
+			return map[string]string{"application": "todo api"}
+		}),
+	))
+	defer db.Close()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(otel.New(otel.WithTracerProvider(provider)))
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		if graphql.GetFieldContext(ctx).Field.Name != "todos" {
+			return next(ctx)
+		}
+		rows, err := db.QueryContext(ctx, "select id from todos")
+		if err != nil {
+			return nil, err
+		}
+		rows.Close()
+		stmt, err := db.PrepareContext(ctx, "select id from todos where id = ?;")
+		if err != nil {
+			return nil, err
+		}
+		defer stmt.Close()
+		if _, err := stmt.ExecContext(context.Background(), 1); err != nil {
+			return nil, err
+		}
+		if _, err := db.ExecContext(ctx, "/* keep */ delete from todos"); err != nil {
+			return nil, err
+		}
+		return next(ctx)
+	})
+
+	resp := doRequest(srv, `{"query":"query Todos { todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "errors")
+
+	var span sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "Query.todos" {
+			span = s
+		}
+	}
+	require.NotNil(t, span)
+	traceparent := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	comment := "/*application='todo%20api',graphql_operation='Todos',graphql_path='todos',traceparent='" + traceparent + "'*/"
+
+	_, err := db.ExecContext(context.Background(), "delete from todos")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"select id from todos " + comment,
+		"select id from todos where id = ? " + comment + ";",
+		"exec: select id from todos where id = ? " + comment + ";",
+		"/* keep */ delete from todos",
+		"delete from todos",
+	}, c.queries)
+}
+
+// connector records the queries of its connections, which answer no rows.
+type connector struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) { return &conn{c}, nil }
+func (c *connector) Driver() driver.Driver                        { return nil }
+
+func (c *connector) record(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+}
+
+type conn struct {
+	c *connector
+}
+
+func (cn *conn) Prepare(query string) (driver.Stmt, error) {
+	cn.c.record(query)
+	return &stmt{cn.c, query}, nil
+}
+func (cn *conn) Close() error              { return nil }
+func (cn *conn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+func (cn *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	cn.c.record(query)
+	return rows{}, nil
+}
+
+func (cn *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	cn.c.record(query)
+	return driver.RowsAffected(0), nil
+}
+
+type stmt struct {
+	c     *connector
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.record("exec: " + s.query)
+	return driver.RowsAffected(0), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.record("query: " + s.query)
+	return rows{}, nil
+}
+
+type rows struct{}
+
+func (rows) Columns() []string              { return []string{"id"} }
+func (rows) Close() error                   { return nil }
+func (rows) Next(dest []driver.Value) error { return io.EOF }
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,120 @@
+package sse
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultMaxOperationNames caps the operation_name label unless configured
+// otherwise with WithMaxOperationNames.
+const defaultMaxOperationNames = 100
+
+type config struct {
+	heartbeat time.Duration
+	retry     time.Duration
+	eventID   func(resp *graphql.Response, seq int) string
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+	buckets     []float64
+
+	maxOperationNames int
+}
+
+// Option is anything that can configure Transport.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		heartbeat:  15 * time.Second,
+		registerer: prometheusclient.DefaultRegisterer,
+		buckets:    []float64{1, 10, 60, 300, 1800, 3600},
+
+		maxOperationNames: defaultMaxOperationNames,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithHeartbeat sends a comment every d without events, 15 seconds by
+// default, so that proxies do not close idle streams. A value of 0 or less
+// disables it.
+func WithHeartbeat(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.heartbeat = d
+	}
+}
+
+// WithRetry tells clients to wait d before reconnecting a dropped stream,
+// instead of the delay of their choice.
+func WithRetry(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.retry = d
+	}
+}
+
+// WithEventID returns the id of the event resp, seq being its sequence
+// number, instead of seq. Clients send the id of the last event they
+// received in the Last-Event-ID header when reconnecting, so it should let
+// resolvers resume the stream, for instance the id of the event in the
+// data of resp.
+func WithEventID(fn func(resp *graphql.Response, seq int) string) Option {
+	return func(cfg *config) {
+		cfg.eventID = fn
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
+
+// WithDurationBuckets sets the buckets, in seconds, of
+// graphql_sse_stream_duration_seconds.
+func WithDurationBuckets(buckets []float64) Option {
+	return func(cfg *config) {
+		cfg.buckets = buckets
+	}
+}
+
+// WithMaxOperationNames caps the number of distinct operation_name label
+// values, 100 by default. Names first seen after the cap is reached are
+// reported as "__overflow__". A value of 0 or less disables the cap.
+func WithMaxOperationNames(max int) Option {
+	return func(cfg *config) {
+		cfg.maxOperationNames = max
+	}
+}
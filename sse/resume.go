@@ -0,0 +1,55 @@
+package sse
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+)
+
+// Resume subscribes to topic on broker for a subscription resolver. When the
+// client reconnects with a Last-Event-ID, the events it missed, as returned
+// by replay, are sent before the ones published from now on, for instance:
+//
+//	func (r *subscriptionResolver) TodoAdded(ctx context.Context) (<-chan *Todo, error) {
+//		return sse.Resume(ctx, r.broker, "todos", func(ctx context.Context, lastEventID string) ([]*Todo, error) {
+//			return r.store.TodosAddedAfter(ctx, lastEventID)
+//		})
+//	}
+//
+// The subscription starts before replay is called, so that no event is
+// lost in between, and events published meanwhile may be sent twice.
+func Resume[T any](ctx context.Context, broker subscriptions.Broker[T], topic string, replay func(ctx context.Context, lastEventID string) ([]T, error)) (<-chan T, error) {
+	live, err := broker.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	lastEventID := LastEventID(ctx)
+	if lastEventID == "" || replay == nil {
+		return live, nil
+	}
+	missed, err := replay(ctx, lastEventID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan T)
+	go func() {
+		defer close(events)
+		for _, event := range missed {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for event := range live {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,299 @@
+// Package sse is a Server-Sent Events transport for gqlgen, compatible with
+// the one of gqlgen, that numbers events so that clients can resume their
+// streams after reconnecting, sends heartbeats and exports Prometheus
+// metrics per stream. Unlike the gqlgen transport, it also accepts GET
+// requests, so that subscriptions can be consumed with an EventSource,
+// which reconnects on its own.
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/labelguard"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type lastEventIDKey struct{}
+
+// WithLastEventID returns a copy of ctx holding id, as the transport does
+// with the Last-Event-ID header of reconnecting clients.
+func WithLastEventID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, lastEventIDKey{}, id)
+}
+
+// LastEventID returns the id of the last event the client received before
+// reconnecting, empty for new streams.
+func LastEventID(ctx context.Context) string {
+	id, _ := ctx.Value(lastEventIDKey{}).(string)
+	return id
+}
+
+// Transport serves operations as event streams. Each result is sent as a
+// next event, with an id line, and the stream ends with a complete event.
+// Register it before the POST and GET transports, as it handles the requests
+// accepting text/event-stream.
+type Transport struct {
+	cfg            *config
+	operationNames *labelguard.Guard
+
+	streams    prometheusclient.Gauge
+	events     *prometheusclient.CounterVec
+	reconnects *prometheusclient.CounterVec
+	duration   *prometheusclient.HistogramVec
+}
+
+var _ graphql.Transport = &Transport{}
+
+// New returns a Transport whose metrics are registered on the configured
+// registerer: graphql_sse_streams, graphql_sse_events_total,
+// graphql_sse_reconnects_total and graphql_sse_stream_duration_seconds, the
+// last three by operation_name.
+func New(opts ...Option) *Transport {
+	cfg := newConfig(opts...)
+
+	t := &Transport{
+		cfg:            cfg,
+		operationNames: labelguard.New(nil, cfg.maxOperationNames),
+		streams: prometheusclient.NewGauge(prometheusclient.GaugeOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_sse_streams",
+			Help:        "Number of event streams open.",
+			ConstLabels: cfg.constLabels,
+		}),
+		events: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_sse_events_total",
+			Help:        "Total number of events sent on event streams.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"operation_name"}),
+		reconnects: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_sse_reconnects_total",
+			Help:        "Total number of event streams opened with a Last-Event-ID.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"operation_name"}),
+		duration: prometheusclient.NewHistogramVec(prometheusclient.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_sse_stream_duration_seconds",
+			Help:        "Time event streams stayed open.",
+			Buckets:     cfg.buckets,
+			ConstLabels: cfg.constLabels,
+		}, []string{"operation_name"}),
+	}
+	cfg.registerer.MustRegister(t.streams, t.events, t.reconnects, t.duration)
+
+	return t
+}
+
+// UnRegister removes the metrics from the registerer they were registered
+// on.
+func (t *Transport) UnRegister() {
+	t.cfg.registerer.Unregister(t.streams)
+	t.cfg.registerer.Unregister(t.events)
+	t.cfg.registerer.Unregister(t.reconnects)
+	t.cfg.registerer.Unregister(t.duration)
+}
+
+func (t *Transport) Supports(r *http.Request) bool {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet:
+		return true
+	case http.MethodPost:
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		return err == nil && mediaType == "application/json"
+	default:
+		return false
+	}
+}
+
+func (t *Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		transport.SendErrorf(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	start := graphql.Now()
+	params, err := readParams(r)
+	if err != nil {
+		transport.SendErrorf(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	params.Headers = r.Header
+	params.ReadTime = graphql.TraceTiming{Start: start, End: graphql.Now()}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	ctx := WithLastEventID(r.Context(), lastEventID)
+	rc, opErr := exec.CreateOperationContext(ctx, params)
+	if r.Method == http.MethodGet && rc != nil && rc.Operation != nil && rc.Operation.Operation == ast.Mutation {
+		// EventSources can be opened cross-site with credentials.
+		w.Header().Set("Allow", http.MethodPost)
+		transport.SendErrorf(w, http.StatusMethodNotAllowed, "GET requests only allow query and subscription operations")
+		return
+	}
+	ctx = graphql.WithOperationContext(ctx, rc)
+
+	name := params.OperationName
+	if rc != nil {
+		name = gqlctx.OperationName(rc)
+	}
+	name = t.operationNames.Value(name)
+	t.streams.Inc()
+	defer t.streams.Dec()
+	defer func(opened time.Time) {
+		t.duration.WithLabelValues(name).Observe(time.Since(opened).Seconds())
+	}(time.Now())
+	if lastEventID != "" {
+		t.reconnects.WithLabelValues(name).Inc()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	s := &stream{w: w, f: flusher, events: make(chan struct{}, 1)}
+	if t.cfg.retry > 0 {
+		s.write(fmt.Sprintf("retry: %d\n\n", t.cfg.retry.Milliseconds()))
+	} else {
+		s.write(":\n\n")
+	}
+
+	// The heartbeat must not write once Do returns.
+	var heartbeats sync.WaitGroup
+	done := make(chan struct{})
+	defer heartbeats.Wait()
+	defer close(done)
+	if t.cfg.heartbeat > 0 {
+		heartbeats.Go(func() { s.heartbeat(t.cfg.heartbeat, done) })
+	}
+
+	// Sequence numbers carry on from the Last-Event-ID of the client, so
+	// that ids keep increasing across reconnections.
+	seq, _ := strconv.Atoi(lastEventID)
+	send := func(resp *graphql.Response) error {
+		seq++
+		id := strconv.Itoa(seq)
+		if t.cfg.eventID != nil {
+			id = t.cfg.eventID(resp, seq)
+		}
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		t.events.WithLabelValues(name).Inc()
+		s.write(fmt.Sprintf("id: %s\nevent: next\ndata: %s\n\n", id, data))
+		return nil
+	}
+
+	if opErr != nil {
+		_ = send(exec.DispatchError(ctx, opErr))
+	} else {
+		responses, ctx := exec.DispatchOperation(ctx, rc)
+		for {
+			resp := responses(ctx)
+			if resp == nil {
+				break
+			}
+			if err := send(resp); err != nil {
+				_ = send(&graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("%s", err)}})
+				break
+			}
+		}
+	}
+
+	if r.Context().Err() == nil {
+		s.write("event: complete\n\n")
+	}
+}
+
+// readParams decodes the operation of a GET request from its query string,
+// as the GET transport of gqlgen, or of a POST request from its body.
+func readParams(r *http.Request) (*graphql.RawParams, error) {
+	params := &graphql.RawParams{}
+	if r.Method == http.MethodPost {
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(params); err != nil {
+			return nil, fmt.Errorf("json request body could not be decoded: %w", err)
+		}
+		return params, nil
+	}
+
+	query := r.URL.Query()
+	params.Query = query.Get("query")
+	params.OperationName = query.Get("operationName")
+	for key, out := range map[string]*map[string]any{"variables": &params.Variables, "extensions": &params.Extensions} {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(value))
+		dec.UseNumber()
+		if err := dec.Decode(out); err != nil {
+			return nil, fmt.Errorf("%s could not be decoded", key)
+		}
+	}
+	return params, nil
+}
+
+// stream serializes the writes of events and heartbeats.
+type stream struct {
+	mu     sync.Mutex
+	w      io.Writer
+	f      http.Flusher
+	events chan struct{}
+}
+
+func (s *stream) write(frame string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = io.WriteString(s.w, frame)
+	s.f.Flush()
+
+	select {
+	case s.events <- struct{}{}:
+	default:
+	}
+}
+
+// heartbeat sends a comment whenever no frame was written for d.
+func (s *stream) heartbeat(d time.Duration, done <-chan struct{}) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-s.events:
+			timer.Reset(d)
+		case <-timer.C:
+			s.mu.Lock()
+			_, _ = io.WriteString(s.w, ": ping\n\n")
+			s.f.Flush()
+			s.mu.Unlock()
+			timer.Reset(d)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package sse_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/sse"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	srv := newServer(sse.New(sse.WithRegisterer(registry), sse.WithRetry(time.Second)))
+
+	resp := doRequest(srv, http.MethodPost, `{"query":"subscription Added { todoAdded { id } }"}`, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+
+	events := parse(resp.Body.String())
+	require.Len(t, events, 5)
+	assert.Equal(t, "1000", events[0]["retry"])
+	for i, id := range []string{"1", "2", "3"} {
+		assert.Equal(t, id, events[i+1]["id"])
+		assert.Equal(t, "next", events[i+1]["event"])
+	}
+	assert.JSONEq(t, `{"data":{"todoAdded":{"id":"`+graph.TodoA.ID+`"}}}`, events[1]["data"])
+	assert.Equal(t, "complete", events[4]["event"])
+
+	assert.Equal(t, 3.0, counter(t, registry, "graphql_sse_events_total", "Added"))
+	assert.Equal(t, 0.0, counter(t, registry, "graphql_sse_reconnects_total", "Added"))
+}
+
+func TestTransport_MaxOperationNames(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	srv := newServer(sse.New(sse.WithRegisterer(registry), sse.WithMaxOperationNames(1)))
+
+	doRequest(srv, http.MethodPost, `{"query":"query First { todos { id } }"}`, "")
+	doRequest(srv, http.MethodPost, `{"query":"query Second { todos { id } }"}`, "")
+
+	assert.Equal(t, 1.0, counter(t, registry, "graphql_sse_events_total", "First"))
+	assert.Equal(t, 0.0, counter(t, registry, "graphql_sse_events_total", "Second"))
+	assert.Equal(t, 1.0, counter(t, registry, "graphql_sse_events_total", "__overflow__"))
+}
+
+func TestTransport_LastEventID(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	srv := newServer(sse.New(sse.WithRegisterer(registry)))
+
+	query := url.Values{"query": {"subscription Added { todoAdded { id } }"}}
+	resp := doRequest(srv, http.MethodGet, query.Encode(), "5")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var ids []string
+	for _, event := range parse(resp.Body.String()) {
+		if event["event"] == "next" {
+			ids = append(ids, event["id"])
+		}
+	}
+	assert.Equal(t, []string{"6", "7", "8"}, ids)
+	assert.Equal(t, 1.0, counter(t, registry, "graphql_sse_reconnects_total", "Added"))
+}
+
+func TestTransport_EventID(t *testing.T) {
+	srv := newServer(sse.New(
+		sse.WithRegisterer(prometheusclient.NewRegistry()),
+		sse.WithEventID(func(resp *graphql.Response, seq int) string {
+			return "todo-" + strings.Repeat("x", seq)
+		}),
+	))
+
+	resp := doRequest(srv, http.MethodPost, `{"query":"subscription { todoAdded { id } }"}`, "")
+	events := parse(resp.Body.String())
+	require.Len(t, events, 4)
+	assert.Equal(t, "todo-x", events[0]["id"])
+	assert.Equal(t, "todo-xxx", events[2]["id"])
+}
+
+func TestTransport_Heartbeat(t *testing.T) {
+	srv := newServer(sse.New(
+		sse.WithRegisterer(prometheusclient.NewRegistry()),
+		sse.WithHeartbeat(10*time.Millisecond),
+	))
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		if graphql.GetFieldContext(ctx).Object == "Todo" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return next(ctx)
+	})
+
+	resp := doRequest(srv, http.MethodPost, `{"query":"{ todos { id } }"}`, "")
+	assert.Contains(t, resp.Body.String(), ": ping\n\n")
+	events := parse(resp.Body.String())
+	assert.Equal(t, "complete", events[len(events)-1]["event"])
+}
+
+func TestTransport_GetMutation(t *testing.T) {
+	srv := newServer(sse.New(sse.WithRegisterer(prometheusclient.NewRegistry())))
+
+	query := url.Values{"query": {`mutation { createTodo(input: {text: "x", userId: "1"}) { id } }`}}
+	resp := doRequest(srv, http.MethodGet, query.Encode(), "")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	assert.Equal(t, http.MethodPost, resp.Header().Get("Allow"))
+	assert.NotContains(t, resp.Body.String(), "createTodo")
+}
+
+func TestTransport_Supports(t *testing.T) {
+	tr := sse.New(sse.WithRegisterer(prometheusclient.NewRegistry()))
+
+	r := httptest.NewRequest(http.MethodGet, "/query", nil)
+	assert.False(t, tr.Supports(r))
+	r.Header.Set("Accept", "text/event-stream")
+	assert.True(t, tr.Supports(r))
+
+	r = httptest.NewRequest(http.MethodPost, "/query", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	assert.False(t, tr.Supports(r))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	assert.True(t, tr.Supports(r))
+}
+
+func TestResume(t *testing.T) {
+	broker := &memoryBroker{subscribed: make(chan struct{})}
+	replay := func(ctx context.Context, lastEventID string) ([]string, error) {
+		assert.Equal(t, "b", lastEventID)
+		return []string{"c", "d"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(sse.WithLastEventID(context.Background(), "b"))
+	defer cancel()
+	events, err := sse.Resume[string](ctx, broker, "letters", replay)
+	require.NoError(t, err)
+	<-broker.subscribed
+	go func() { _ = broker.Publish(ctx, "letters", "e") }()
+
+	var got []string
+	for event := range events {
+		got = append(got, event)
+		if len(got) == 3 {
+			cancel()
+		}
+	}
+	assert.Equal(t, []string{"c", "d", "e"}, got)
+
+	_, err = sse.Resume[string](context.Background(), &memoryBroker{subscribed: make(chan struct{})}, "letters", func(ctx context.Context, lastEventID string) ([]string, error) {
+		t.Fatal("replayed a new stream")
+		return nil, nil
+	})
+	require.NoError(t, err)
+}
+
+// memoryBroker is a single subscriber broker.
+type memoryBroker struct {
+	ch         chan string
+	subscribed chan struct{}
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, topic string, event string) error {
+	b.ch <- event
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(ctx context.Context, topic string) (<-chan string, error) {
+	b.ch = make(chan string)
+	close(b.subscribed)
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case event := <-b.ch:
+				out <- event
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func newServer(tr *sse.Transport) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(tr)
+	srv.AddTransport(transport.POST{})
+	return srv
+}
+
+func counter(t *testing.T, registry *prometheusclient.Registry, name, operation string) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if metric.GetLabel()[0].GetValue() == operation {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+// parse splits an event stream into its events, comments dropped.
+func parse(body string) []map[string]string {
+	var events []map[string]string
+	event := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(event) > 0 {
+				events = append(events, event)
+				event = map[string]string{}
+			}
+		case strings.HasPrefix(line, ":"):
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			event[field] = strings.TrimPrefix(value, " ")
+		}
+	}
+	return events
+}
+
+func doRequest(handler http.Handler, method, body, lastEventID string) *httptest.ResponseRecorder {
+	var r *http.Request
+	if method == http.MethodGet {
+		r = httptest.NewRequest(method, "/query?"+body, nil)
+	} else {
+		r = httptest.NewRequest(method, "/query", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+	}
+	r.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		r.Header.Set("Last-Event-ID", lastEventID)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,68 @@
+package statsd
+
+import contrib "github.com/99designs/gqlgen-contrib"
+
+// defaultMaxOperationNames caps the operation_name tag unless configured
+// otherwise with WithMaxOperationNames.
+const defaultMaxOperationNames = 100
+
+type config struct {
+	sampleRate         float64
+	resolverSampleRate float64
+	enricher           contrib.Enricher
+	maxOperationNames  int
+}
+
+// Option is anything that can configure Tracer.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		sampleRate:         1,
+		resolverSampleRate: 1,
+		maxOperationNames:  defaultMaxOperationNames,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithSampleRate sends only the given fraction (0 to 1) of request metrics,
+// the agent scaling counts back up.
+func WithSampleRate(rate float64) Option {
+	return func(cfg *config) {
+		cfg.sampleRate = rate
+	}
+}
+
+// WithResolverSampleRate sends only the given fraction (0 to 1) of resolver
+// metrics, which outnumber request metrics by the number of fields resolved.
+func WithResolverSampleRate(rate float64) Option {
+	return func(cfg *config) {
+		cfg.resolverSampleRate = rate
+	}
+}
+
+// WithEnricher adds the labels returned by fn as key:value tags of the
+// request metrics. Calling it again adds more labels.
+func WithEnricher(fn contrib.Enricher) Option {
+	return func(cfg *config) {
+		if cfg.enricher == nil {
+			cfg.enricher = fn
+		} else {
+			cfg.enricher = contrib.Enrichers(cfg.enricher, fn)
+		}
+	}
+}
+
+// WithMaxOperationNames caps the number of distinct operation_name tag
+// values, 100 by default. Names first seen after the cap is reached are
+// reported as "__overflow__". A value of 0 or less disables the cap.
+func WithMaxOperationNames(max int) Option {
+	return func(cfg *config) {
+		cfg.maxOperationNames = max
+	}
+}
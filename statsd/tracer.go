@@ -0,0 +1,169 @@
+package statsd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/labelguard"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	dogstatsd "github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	exitStatusFailure = "failure"
+	exitStatusSuccess = "success"
+)
+
+// Tracer is a gqlgen handler extension sending the metrics of the prometheus
+// package through a DogStatsD client, durations in milliseconds.
+// see https://pkg.go.dev/github.com/DataDog/datadog-go/v5/statsd
+type Tracer struct {
+	client         dogstatsd.ClientInterface
+	cfg            *config
+	inFlight       *atomic.Int64
+	operationNames *labelguard.Guard
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+// New returns a Tracer sending metrics through client.
+func New(client dogstatsd.ClientInterface, opts ...Option) Tracer {
+	cfg := newConfig(opts...)
+	return Tracer{
+		client:         client,
+		cfg:            cfg,
+		inFlight:       &atomic.Int64{},
+		operationNames: labelguard.New(nil, cfg.maxOperationNames),
+	}
+}
+
+func (t Tracer) ExtensionName() string {
+	return "StatsD"
+}
+
+func (t Tracer) Validate(schema graphql.ExecutableSchema) error {
+	if t.client == nil {
+		return fmt.Errorf("statsd: no client")
+	}
+	return nil
+}
+
+func (t Tracer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	_ = t.client.Incr("graphql.request.started", t.operationTags(ctx), t.cfg.sampleRate)
+	_ = t.client.Gauge("graphql.requests.in_flight", float64(t.inFlight.Add(1)), nil, 1)
+
+	oc := graphql.GetOperationContext(ctx)
+	subscription := oc.Operation != nil && oc.Operation.Operation == ast.Subscription
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			_ = t.client.Gauge("graphql.requests.in_flight", float64(t.inFlight.Add(-1)), nil, 1)
+		})
+	}
+
+	responses := next(ctx)
+
+	// Subscriptions stay in flight until the stream ends with a nil response.
+	return func(ctx context.Context) *graphql.Response {
+		res := responses(ctx)
+		if res == nil || !subscription {
+			done()
+		}
+		return res
+	}
+}
+
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	tags := t.operationTags(ctx)
+
+	exitStatus, errCode := exitStatusSuccess, ""
+	if len(res.Errors) != 0 {
+		exitStatus, errCode = exitStatusFailure, extcode.Code(res.Errors[0])
+	}
+
+	_ = t.client.Distribution("graphql.request.duration", milliseconds(time.Since(oc.Stats.OperationStart)),
+		append(tags, "exit_status:"+exitStatus, "err_code:"+errCode), t.cfg.sampleRate)
+	_ = t.client.Incr("graphql.request.completed", tags, t.cfg.sampleRate)
+
+	for _, err := range res.Errors {
+		_ = t.client.Incr("graphql.request.errors", append(tags, "error_code:"+extcode.Code(err)), t.cfg.sampleRate)
+	}
+
+	// Stats are only present when the ComplexityLimit extension is in use.
+	if stats := extension.GetComplexityStats(ctx); stats != nil {
+		_ = t.client.Histogram("graphql.operation.complexity", float64(stats.Complexity), tags, t.cfg.sampleRate)
+		if stats.ComplexityLimit > 0 && stats.Complexity > stats.ComplexityLimit {
+			_ = t.client.Incr("graphql.operation.complexity_limit_exceeded", tags, t.cfg.sampleRate)
+		}
+	}
+
+	return res
+}
+
+func (t Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	tags := []string{"object:" + fc.Object, "field:" + fc.Field.Name}
+	_ = t.client.Incr("graphql.resolver.started", tags, t.cfg.resolverSampleRate)
+
+	start := time.Now()
+	res, err := next(ctx)
+
+	exitStatus, errCode := exitStatusSuccess, ""
+	if err != nil {
+		exitStatus, errCode = exitStatusFailure, extcode.Code(err)
+	}
+
+	_ = t.client.Distribution("graphql.resolver.duration", milliseconds(time.Since(start)),
+		append(tags, "exit_status:"+exitStatus, "err_code:"+errCode), t.cfg.resolverSampleRate)
+	_ = t.client.Incr("graphql.resolver.completed", tags, t.cfg.resolverSampleRate)
+
+	return res, err
+}
+
+// operationTags returns the operation_name, operation_type and enricher tags
+// of the operation in ctx.
+func (t Tracer) operationTags(ctx context.Context) []string {
+	var name, operationType string
+	if graphql.HasOperationContext(ctx) {
+		oc := graphql.GetOperationContext(ctx)
+		name = gqlctx.OperationName(oc)
+		if oc.Operation != nil {
+			operationType = string(oc.Operation.Operation)
+		}
+	}
+
+	tags := []string{"operation_name:" + t.operationNames.Value(name), "operation_type:" + operationType}
+	if t.cfg.enricher != nil {
+		labels := t.cfg.enricher(ctx)
+		for _, key := range slices.Sorted(maps.Keys(labels)) {
+			tags = append(tags, key+":"+labels[key])
+		}
+	}
+	// Clipped so that appending per metric tags never shares a backing array.
+	return slices.Clip(tags)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,142 @@
+package statsd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/statsd"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	dogstatsd "github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metric struct {
+	Name string
+	Tags []string
+	Rate float64
+}
+
+// recorder records the metrics sent through it.
+type recorder struct {
+	dogstatsd.NoOpClient
+
+	mu      sync.Mutex
+	metrics []metric
+	gauges  []float64
+}
+
+func (r *recorder) record(name string, tags []string, rate float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, metric{Name: name, Tags: tags, Rate: rate})
+	return nil
+}
+
+func (r *recorder) Incr(name string, tags []string, rate float64) error {
+	return r.record(name, tags, rate)
+}
+
+func (r *recorder) Distribution(name string, value float64, tags []string, rate float64) error {
+	return r.record(name, tags, rate)
+}
+
+func (r *recorder) Histogram(name string, value float64, tags []string, rate float64) error {
+	return r.record(name, tags, rate)
+}
+
+func (r *recorder) Gauge(name string, value float64, tags []string, rate float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gauges = append(r.gauges, value)
+	return nil
+}
+
+func (r *recorder) find(name string) []metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []metric
+	for _, m := range r.metrics {
+		if m.Name == name {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+func TestTracer(t *testing.T) {
+	client := &recorder{}
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.FixedComplexityLimit(100))
+	srv.Use(statsd.New(client,
+		statsd.WithResolverSampleRate(0.5),
+		statsd.WithEnricher(func(ctx context.Context) map[string]string {
+			return map[string]string{"tenant": "acme"}
+		}),
+	))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Lookup { todos { id } todo(id: \"unknown\") { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	operationTags := []string{"operation_name:Lookup", "operation_type:query", "tenant:acme"}
+	assert.Equal(t, []metric{{Name: "graphql.request.started", Tags: operationTags, Rate: 1}}, client.find("graphql.request.started"))
+	assert.Equal(t, []metric{{Name: "graphql.request.completed", Tags: operationTags, Rate: 1}}, client.find("graphql.request.completed"))
+	assert.Equal(t, []metric{{
+		Name: "graphql.request.duration",
+		Tags: append(operationTags, "exit_status:failure", "err_code:"),
+		Rate: 1,
+	}}, client.find("graphql.request.duration"))
+	assert.Len(t, client.find("graphql.request.errors"), 1)
+	assert.Len(t, client.find("graphql.operation.complexity"), 1)
+	assert.Empty(t, client.find("graphql.operation.complexity_limit_exceeded"))
+	assert.Equal(t, []float64{1, 0}, client.gauges)
+
+	resolvers := client.find("graphql.resolver.duration")
+	assert.Len(t, resolvers, 5)
+	assert.Contains(t, resolvers, metric{
+		Name: "graphql.resolver.duration",
+		Tags: []string{"object:Query", "field:todo", "exit_status:failure", "err_code:"},
+		Rate: 0.5,
+	})
+	assert.Contains(t, resolvers, metric{
+		Name: "graphql.resolver.duration",
+		Tags: []string{"object:Todo", "field:id", "exit_status:success", "err_code:"},
+		Rate: 0.5,
+	})
+}
+
+func TestTracer_MaxOperationNames(t *testing.T) {
+	client := &recorder{}
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(statsd.New(client, statsd.WithMaxOperationNames(1)))
+
+	for _, name := range []string{"First", "Second", "First"} {
+		r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query `+name+` { todos { id } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var names []string
+	for _, m := range client.find("graphql.request.completed") {
+		names = append(names, m.Tags[0])
+	}
+	assert.Equal(t, []string{"operation_name:First", "operation_name:__overflow__", "operation_name:First"}, names)
+}
@@ -0,0 +1,43 @@
+// Package subscriptions defines the Broker fanning subscription events out
+// across instances, implemented by its subpackages.
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Broker publishes events of type T to topics and delivers them to every
+// subscriber of the topic, whichever instance it runs on.
+type Broker[T any] interface {
+	// Publish sends event to the subscribers of topic.
+	Publish(ctx context.Context, topic string, event T) error
+	// Subscribe returns the events published to topic from now on. The
+	// channel is closed once ctx is done, typically when the client
+	// unsubscribes, so it can be returned straight from a resolver.
+	Subscribe(ctx context.Context, topic string) (<-chan T, error)
+}
+
+// Codec encodes events on the wire.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSON encodes events with encoding/json.
+var JSON Codec = jsonCodec{}
+
+// MsgPack encodes events with MessagePack, more compact than JSON.
+var MsgPack Codec = msgpackCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
@@ -0,0 +1,120 @@
+// Package kafkabroker implements subscriptions.Broker over Kafka, for event
+// streams too large for Redis or NATS.
+//
+// By default subscriptions fan out: every subscription reads the whole topic
+// through its own client, starting from the events published after it
+// subscribed. See WithConsumerGroup for the alternative.
+package kafkabroker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Broker publishes and subscribes to events of type T over Kafka.
+type Broker[T any] struct {
+	seeds    []string
+	producer *kgo.Client
+	cfg      *config
+}
+
+var _ subscriptions.Broker[struct{}] = (*Broker[struct{}])(nil)
+
+// New returns a Broker connecting to the given seed brokers.
+func New[T any](seeds []string, opts ...Option) (*Broker[T], error) {
+	cfg := newConfig(opts...)
+
+	producer, err := kgo.NewClient(append([]kgo.Opt{kgo.SeedBrokers(seeds...)}, cfg.clientOpts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Broker[T]{
+		seeds:    seeds,
+		producer: producer,
+		cfg:      cfg,
+	}, nil
+}
+
+// Close closes the producer. Subscriptions close their own client along
+// with their context.
+func (b *Broker[T]) Close() {
+	b.producer.Close()
+}
+
+// Publish returns once Kafka acknowledged event.
+func (b *Broker[T]) Publish(ctx context.Context, topic string, event T) error {
+	data, err := b.cfg.codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("kafkabroker: encode event: %w", err)
+	}
+
+	record := &kgo.Record{Topic: b.cfg.topic(topic), Value: data}
+	return b.producer.ProduceSync(ctx, record).FirstErr()
+}
+
+func (b *Broker[T]) Subscribe(ctx context.Context, topic string) (<-chan T, error) {
+	var group string
+	if b.cfg.consumerGroup != nil {
+		group = b.cfg.consumerGroup(ctx, topic)
+	}
+
+	// Starting from the subscription time rather than the end of the topic
+	// keeps events published while partitions are being assigned.
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(b.seeds...),
+		kgo.ConsumeTopics(b.cfg.topic(topic)),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AfterMilli(time.Now().UnixMilli())),
+		kgo.MaxBufferedRecords(b.cfg.bufferSize),
+	}
+	if group != "" {
+		opts = append(opts, kgo.ConsumerGroup(group), kgo.DisableAutoCommit())
+	}
+
+	consumer, err := kgo.NewClient(append(opts, b.cfg.clientOpts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan T)
+	go func() {
+		defer close(events)
+		defer consumer.Close()
+
+		for {
+			fetches := consumer.PollFetches(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			fetches.EachError(func(topic string, partition int32, err error) {
+				b.cfg.errorHandler(ctx, fmt.Errorf("kafkabroker: fetch %s/%d: %w", topic, partition, err))
+			})
+
+			for _, record := range fetches.Records() {
+				var event T
+				if err := b.cfg.codec.Unmarshal(record.Value, &event); err != nil {
+					b.cfg.errorHandler(ctx, fmt.Errorf("kafkabroker: decode event: %w", err))
+				} else {
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						// Left uncommitted, the group redelivers it.
+						return
+					}
+				}
+
+				if group != "" {
+					if err := consumer.CommitRecords(ctx, record); err != nil {
+						b.cfg.errorHandler(ctx, fmt.Errorf("kafkabroker: commit: %w", err))
+					}
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,94 @@
+package kafkabroker_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/subscriptions/kafkabroker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// The tests need a Kafka cluster allowing automatic topic creation, for
+// example KAFKA_BROKERS=localhost:9092.
+func newBroker(t *testing.T, opts ...kafkabroker.Option) *kafkabroker.Broker[*graph.Todo] {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_BROKERS not set")
+	}
+
+	// A fresh topic per test keeps runs independent.
+	topic := fmt.Sprintf("graphql-test-%s-%d", strings.ToLower(t.Name()), time.Now().UnixNano())
+	opts = append(opts,
+		kafkabroker.WithTopic(func(string) string { return topic }),
+		kafkabroker.WithClientOptions(kgo.AllowAutoTopicCreation()),
+	)
+
+	broker, err := kafkabroker.New[*graph.Todo](strings.Split(brokers, ","), opts...)
+	require.NoError(t, err)
+	t.Cleanup(broker.Close)
+
+	return broker
+}
+
+func TestBroker_FanOut(t *testing.T) {
+	broker := newBroker(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := broker.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+	second, err := broker.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoA))
+
+	assert.Equal(t, graph.TodoA, receive(t, first))
+	assert.Equal(t, graph.TodoA, receive(t, second))
+}
+
+func TestBroker_ConsumerGroup(t *testing.T) {
+	group := fmt.Sprintf("graphql-test-%d", time.Now().UnixNano())
+	broker := newBroker(t, kafkabroker.WithConsumerGroup(func(ctx context.Context, topic string) string {
+		return group
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := broker.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoA))
+	assert.Equal(t, graph.TodoA, receive(t, events))
+
+	cancel()
+	for range events {
+	}
+
+	// Published while the group has no member, delivered once it is back.
+	require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoB))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events, err = broker.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+
+	assert.Equal(t, graph.TodoB, receive(t, events))
+}
+
+func receive(t *testing.T, events <-chan *graph.Todo) *graph.Todo {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(30 * time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package kafkabroker
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type config struct {
+	codec         subscriptions.Codec
+	topic         func(topic string) string
+	bufferSize    int
+	errorHandler  func(ctx context.Context, err error)
+	consumerGroup func(ctx context.Context, topic string) string
+	clientOpts    []kgo.Opt
+}
+
+// Option is anything that can configure Broker.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		codec:        subscriptions.JSON,
+		topic:        func(topic string) string { return "graphql." + topic },
+		bufferSize:   100,
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithCodec sets how events are encoded. Defaults to subscriptions.JSON.
+func WithCodec(codec subscriptions.Codec) Option {
+	return func(cfg *config) {
+		cfg.codec = codec
+	}
+}
+
+// WithTopic sets the Kafka topic of each broker topic. Defaults to the topic
+// prefixed with "graphql.".
+func WithTopic(fn func(topic string) string) Option {
+	return func(cfg *config) {
+		cfg.topic = fn
+	}
+}
+
+// WithBufferSize sets how many events are buffered per subscriber. Defaults
+// to 100.
+func WithBufferSize(size int) Option {
+	return func(cfg *config) {
+		cfg.bufferSize = size
+	}
+}
+
+// WithErrorHandler is called with fetch, decode and commit errors, which are
+// otherwise dropped.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithConsumerGroup switches subscriptions from fan-out to consumer group
+// mode. fn names the group of a subscription; subscriptions of the same
+// group share the events of the topic, each delivered to only one of them,
+// and resume from the offset the group committed. Offsets are committed once
+// events are handed to the resolver, so they are delivered at least once. An
+// empty name falls back to fan-out.
+func WithConsumerGroup(fn func(ctx context.Context, topic string) string) Option {
+	return func(cfg *config) {
+		cfg.consumerGroup = fn
+	}
+}
+
+// WithClientOptions adds options to every Kafka client of the Broker, for
+// example to configure TLS or SASL.
+func WithClientOptions(opts ...kgo.Opt) Option {
+	return func(cfg *config) {
+		cfg.clientOpts = append(cfg.clientOpts, opts...)
+	}
+}
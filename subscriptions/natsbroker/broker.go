@@ -0,0 +1,96 @@
+// Package natsbroker implements subscriptions.Broker over NATS. Broker uses
+// core NATS and delivers events at most once, JetStreamBroker persists them
+// in a stream and can resume subscriptions after reconnects.
+package natsbroker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+	"github.com/nats-io/nats.go"
+)
+
+// Broker publishes and subscribes to events of type T over core NATS.
+type Broker[T any] struct {
+	conn *nats.Conn
+	cfg  *config
+}
+
+var _ subscriptions.Broker[struct{}] = (*Broker[struct{}])(nil)
+
+// New returns a Broker using conn.
+func New[T any](conn *nats.Conn, opts ...Option) *Broker[T] {
+	return &Broker[T]{
+		conn: conn,
+		cfg:  newConfig(opts...),
+	}
+}
+
+func (b *Broker[T]) Publish(ctx context.Context, topic string, event T) error {
+	data, err := b.cfg.codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("natsbroker: encode event: %w", err)
+	}
+
+	return b.conn.Publish(b.cfg.subject(topic), data)
+}
+
+// Subscribe returns once the server registered the subscription, so events
+// published afterwards are guaranteed to be delivered.
+func (b *Broker[T]) Subscribe(ctx context.Context, topic string) (<-chan T, error) {
+	msgs := make(chan *nats.Msg, b.cfg.bufferSize)
+	sub, err := b.conn.ChanSubscribe(b.cfg.subject(topic), msgs)
+	if err != nil {
+		return nil, err
+	}
+	if err := flush(ctx, b.conn); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+
+	events := make(chan T)
+	go func() {
+		defer close(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-msgs:
+				event, ok := decode[T](ctx, b.cfg, msg.Data)
+				if !ok {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// flush waits for the server to process pending messages, bounded by the
+// deadline of ctx if any, since FlushWithContext requires one.
+func flush(ctx context.Context, conn *nats.Conn) error {
+	if _, ok := ctx.Deadline(); ok {
+		return conn.FlushWithContext(ctx)
+	}
+	return conn.Flush()
+}
+
+func decode[T any](ctx context.Context, cfg *config, data []byte) (T, bool) {
+	var event T
+	if err := cfg.codec.Unmarshal(data, &event); err != nil {
+		cfg.errorHandler(ctx, fmt.Errorf("natsbroker: decode event: %w", err))
+		return event, false
+	}
+
+	return event, true
+}
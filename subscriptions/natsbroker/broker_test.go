@@ -0,0 +1,120 @@
+package natsbroker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+	"github.com/99designs/gqlgen-contrib/subscriptions/natsbroker"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runServer(t *testing.T) *nats.Conn {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(5*time.Second))
+
+	conn, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+
+	return conn
+}
+
+func TestBroker(t *testing.T) {
+	for _, codec := range []subscriptions.Codec{subscriptions.JSON, subscriptions.MsgPack} {
+		conn := runServer(t)
+		broker := natsbroker.New[*graph.Todo](conn, natsbroker.WithCodec(codec))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events, err := broker.Subscribe(ctx, "todos")
+		require.NoError(t, err)
+
+		require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoA))
+		require.NoError(t, broker.Publish(context.Background(), "other", graph.TodoB))
+		require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoC))
+
+		assert.Equal(t, graph.TodoA, receive(t, events))
+		assert.Equal(t, graph.TodoC, receive(t, events))
+
+		cancel()
+		for range events {
+		}
+	}
+}
+
+func TestJetStreamBroker(t *testing.T) {
+	conn := runServer(t)
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "GRAPHQL",
+		Subjects: []string{"graphql.>"},
+	})
+	require.NoError(t, err)
+
+	type clientKey struct{}
+	broker := natsbroker.NewJetStream[*graph.Todo](js, "GRAPHQL",
+		natsbroker.WithDurable(func(ctx context.Context, topic string) string {
+			client, _ := ctx.Value(clientKey{}).(string)
+			return client
+		}),
+	)
+
+	// Published before anyone subscribed, so nobody receives it.
+	require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoA))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), clientKey{}, "alice"))
+	events, err := broker.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+
+	ephemeralCtx, cancelEphemeral := context.WithCancel(context.Background())
+	defer cancelEphemeral()
+	ephemeral, err := broker.Subscribe(ephemeralCtx, "todos")
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoB))
+	assert.Equal(t, graph.TodoB, receive(t, events))
+	assert.Equal(t, graph.TodoB, receive(t, ephemeral))
+
+	cancel()
+	for range events {
+	}
+
+	// Published while alice is disconnected, delivered once she is back.
+	require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoC))
+
+	ctx, cancel = context.WithCancel(context.WithValue(context.Background(), clientKey{}, "alice"))
+	defer cancel()
+	events, err = broker.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+
+	assert.Equal(t, graph.TodoC, receive(t, events))
+	assert.Equal(t, graph.TodoC, receive(t, ephemeral))
+}
+
+func receive(t *testing.T, events <-chan *graph.Todo) *graph.Todo {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package natsbroker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// JetStreamBroker publishes and subscribes to events of type T through a
+// JetStream stream, which must capture the subjects of every topic.
+type JetStreamBroker[T any] struct {
+	js     jetstream.JetStream
+	stream string
+	cfg    *config
+}
+
+var _ subscriptions.Broker[struct{}] = (*JetStreamBroker[struct{}])(nil)
+
+// NewJetStream returns a JetStreamBroker using stream.
+func NewJetStream[T any](js jetstream.JetStream, stream string, opts ...Option) *JetStreamBroker[T] {
+	return &JetStreamBroker[T]{
+		js:     js,
+		stream: stream,
+		cfg:    newConfig(opts...),
+	}
+}
+
+// Publish returns once the stream stored event.
+func (b *JetStreamBroker[T]) Publish(ctx context.Context, topic string, event T) error {
+	data, err := b.cfg.codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("natsbroker: encode event: %w", err)
+	}
+
+	_, err = b.js.Publish(ctx, b.cfg.subject(topic), data)
+	return err
+}
+
+// Subscribe delivers the events published from now on, or for a durable
+// subscription, since its consumer was created.
+func (b *JetStreamBroker[T]) Subscribe(ctx context.Context, topic string) (<-chan T, error) {
+	consumerCfg := jetstream.ConsumerConfig{
+		FilterSubject: b.cfg.subject(topic),
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+		AckPolicy:     jetstream.AckNonePolicy,
+	}
+	if b.cfg.durable != nil {
+		if name := b.cfg.durable(ctx, topic); name != "" {
+			consumerCfg.Durable = name
+			consumerCfg.AckPolicy = jetstream.AckExplicitPolicy
+			consumerCfg.InactiveThreshold = b.cfg.inactiveThreshold
+		}
+	}
+
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.stream, consumerCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan T)
+	done := make(chan struct{})
+
+	// mu keeps events from being closed while the handler sends on it.
+	var mu sync.Mutex
+	var closed bool
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+
+		event, ok := decode[T](ctx, b.cfg, msg.Data())
+		if !ok {
+			// Redelivering would fail the same way.
+			b.ack(msg, consumerCfg)
+			return
+		}
+
+		select {
+		case events <- event:
+			b.ack(msg, consumerCfg)
+		case <-done:
+			// Left unacknowledged, a durable consumer redelivers it on the
+			// next subscription.
+		}
+	}, jetstream.PullMaxMessages(b.cfg.bufferSize))
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		close(done)
+		consumeCtx.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		closed = true
+		close(events)
+	}()
+
+	return events, nil
+}
+
+func (b *JetStreamBroker[T]) ack(msg jetstream.Msg, cfg jetstream.ConsumerConfig) {
+	if cfg.AckPolicy == jetstream.AckNonePolicy {
+		return
+	}
+	if err := msg.Ack(); err != nil {
+		b.cfg.errorHandler(context.Background(), fmt.Errorf("natsbroker: ack event: %w", err))
+	}
+}
@@ -0,0 +1,87 @@
+package natsbroker
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+)
+
+type config struct {
+	codec             subscriptions.Codec
+	subject           func(topic string) string
+	bufferSize        int
+	errorHandler      func(ctx context.Context, err error)
+	durable           func(ctx context.Context, topic string) string
+	inactiveThreshold time.Duration
+}
+
+// Option is anything that can configure Broker and JetStreamBroker.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		codec:             subscriptions.JSON,
+		subject:           func(topic string) string { return "graphql." + topic },
+		bufferSize:        100,
+		errorHandler:      func(ctx context.Context, err error) {},
+		inactiveThreshold: time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithCodec sets how events are encoded. Defaults to subscriptions.JSON.
+func WithCodec(codec subscriptions.Codec) Option {
+	return func(cfg *config) {
+		cfg.codec = codec
+	}
+}
+
+// WithSubject sets the NATS subject of each topic. Defaults to the topic
+// prefixed with "graphql.".
+func WithSubject(fn func(topic string) string) Option {
+	return func(cfg *config) {
+		cfg.subject = fn
+	}
+}
+
+// WithBufferSize sets how many events are buffered per subscriber. Defaults
+// to 100.
+func WithBufferSize(size int) Option {
+	return func(cfg *config) {
+		cfg.bufferSize = size
+	}
+}
+
+// WithErrorHandler is called with events that fail to decode, which are
+// otherwise dropped, and with failed acknowledgements.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithDurable makes JetStreamBroker subscriptions resume where they left off.
+// fn names the durable consumer of a subscription, typically from the
+// client identity in ctx and the topic; an empty name falls back to an
+// ephemeral consumer. Events are acknowledged once handed to the resolver, so
+// they are delivered at least once across reconnects. Names may not contain
+// whitespace, ".", "*" or ">".
+func WithDurable(fn func(ctx context.Context, topic string) string) Option {
+	return func(cfg *config) {
+		cfg.durable = fn
+	}
+}
+
+// WithInactiveThreshold sets how long durable consumers survive without a
+// subscriber. Defaults to one hour.
+func WithInactiveThreshold(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.inactiveThreshold = d
+	}
+}
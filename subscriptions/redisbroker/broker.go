@@ -0,0 +1,79 @@
+// Package redisbroker is a subscriptions.Broker over Redis Pub/Sub. Events
+// are delivered at most once: subscribers miss events published while they
+// are disconnected.
+package redisbroker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+	"github.com/redis/go-redis/v9"
+)
+
+// Broker publishes and subscribes to events of type T over redis.
+type Broker[T any] struct {
+	client redis.UniversalClient
+	cfg    *config
+}
+
+var _ subscriptions.Broker[struct{}] = (*Broker[struct{}])(nil)
+
+// New returns a Broker using client.
+func New[T any](client redis.UniversalClient, opts ...Option) *Broker[T] {
+	return &Broker[T]{
+		client: client,
+		cfg:    newConfig(opts...),
+	}
+}
+
+func (b *Broker[T]) Publish(ctx context.Context, topic string, event T) error {
+	data, err := b.cfg.codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("redisbroker: encode event: %w", err)
+	}
+
+	return b.client.Publish(ctx, b.cfg.channel(topic), data).Err()
+}
+
+// Subscribe returns once redis confirmed the subscription, so events
+// published afterwards are guaranteed to be delivered.
+func (b *Broker[T]) Subscribe(ctx context.Context, topic string) (<-chan T, error) {
+	pubsub := b.client.Subscribe(ctx, b.cfg.channel(topic))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan T, b.cfg.bufferSize)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event T
+				if err := b.cfg.codec.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					b.cfg.errorHandler(ctx, fmt.Errorf("redisbroker: decode event: %w", err))
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,90 @@
+package redisbroker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+	"github.com/99designs/gqlgen-contrib/subscriptions/redisbroker"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	for _, codec := range []subscriptions.Codec{subscriptions.JSON, subscriptions.MsgPack} {
+		mr := miniredis.RunT(t)
+
+		// Two brokers stand for two instances of the server.
+		newBroker := func() *redisbroker.Broker[*graph.Todo] {
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return redisbroker.New[*graph.Todo](client, redisbroker.WithCodec(codec))
+		}
+		publisher, subscriber := newBroker(), newBroker()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events, err := subscriber.Subscribe(ctx, "todos")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"graphql:todos"}, mr.PubSubChannels(""))
+
+		require.NoError(t, publisher.Publish(context.Background(), "todos", graph.TodoA))
+		require.NoError(t, publisher.Publish(context.Background(), "other", graph.TodoB))
+		require.NoError(t, publisher.Publish(context.Background(), "todos", graph.TodoC))
+
+		assert.Equal(t, graph.TodoA, receive(t, events))
+		assert.Equal(t, graph.TodoC, receive(t, events))
+
+		cancel()
+		for range events {
+		}
+	}
+}
+
+func TestBroker_DecodeError(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	broker := redisbroker.New[*graph.Todo](client,
+		redisbroker.WithChannel(func(topic string) string { return "app/" + topic }),
+		redisbroker.WithErrorHandler(func(ctx context.Context, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := broker.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+
+	require.NoError(t, client.Publish(context.Background(), "app/todos", "not json").Err())
+	require.NoError(t, broker.Publish(context.Background(), "todos", graph.TodoA))
+
+	assert.Equal(t, graph.TodoA, receive(t, events))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "redisbroker: decode event")
+}
+
+func receive(t *testing.T, events <-chan *graph.Todo) *graph.Todo {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package redisbroker
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/subscriptions"
+)
+
+type config struct {
+	codec        subscriptions.Codec
+	channel      func(topic string) string
+	bufferSize   int
+	errorHandler func(ctx context.Context, err error)
+}
+
+// Option is anything that can configure Broker.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		codec:        subscriptions.JSON,
+		channel:      func(topic string) string { return "graphql:" + topic },
+		bufferSize:   100,
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithCodec sets how events are encoded. Defaults to subscriptions.JSON.
+func WithCodec(codec subscriptions.Codec) Option {
+	return func(cfg *config) {
+		cfg.codec = codec
+	}
+}
+
+// WithChannel sets the redis channel of each topic. Defaults to the topic
+// prefixed with "graphql:".
+func WithChannel(fn func(topic string) string) Option {
+	return func(cfg *config) {
+		cfg.channel = fn
+	}
+}
+
+// WithBufferSize sets how many events are buffered per subscriber before
+// redis messages start queuing up. Defaults to 100.
+func WithBufferSize(size int) Option {
+	return func(cfg *config) {
+		cfg.bufferSize = size
+	}
+}
+
+// WithErrorHandler is called with events that fail to decode, which are
+// otherwise dropped.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
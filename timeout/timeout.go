@@ -0,0 +1,152 @@
+// Package timeout bounds how long queries and mutations may run.
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeTimeout is the extensions.code of the error added to responses of
+// operations that ran out of time.
+const ErrCodeTimeout = "TIMEOUT"
+
+// Enforcer is a gqlgen handler extension running every query and mutation
+// with a context deadline. Resolvers are expected to honor the deadline;
+// once it passes, the response gets a TIMEOUT error and
+// graphql_request_timeouts_total{operation_name} is incremented. The label
+// is only set for operations configured with WithOperationTimeout. The
+// deadline covers the deferred payloads of operations using @defer.
+//
+// Register it after metrics extensions so they see the TIMEOUT error.
+// Subscriptions are not limited.
+type Enforcer struct {
+	timeout    time.Duration
+	operations map[string]time.Duration
+	counter    *prometheusclient.CounterVec
+	registerer prometheusclient.Registerer
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+} = Enforcer{}
+
+// New returns an Enforcer limiting operations to timeout, 0 meaning
+// unbounded, whose counter is registered on the configured registerer.
+func New(timeout time.Duration, opts ...Option) Enforcer {
+	cfg := newConfig(opts...)
+
+	counter := prometheusclient.NewCounterVec(
+		prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_request_timeouts_total",
+			Help:        "Total number of operations that exceeded their timeout.",
+			ConstLabels: cfg.constLabels,
+		},
+		[]string{"operation_name"},
+	)
+	cfg.registerer.MustRegister(counter)
+
+	return Enforcer{
+		timeout:    timeout,
+		operations: cfg.operations,
+		counter:    counter,
+		registerer: cfg.registerer,
+	}
+}
+
+// UnRegister removes the counter from the registerer it was registered on.
+func (e Enforcer) UnRegister() {
+	e.registerer.Unregister(e.counter)
+}
+
+func (e Enforcer) ExtensionName() string {
+	return "Timeout"
+}
+
+func (e Enforcer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+type deadlineKey struct{}
+
+func (e Enforcer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	timeout, label := e.timeout, ""
+	if t, ok := e.operations[oc.Operation.Name]; ok {
+		timeout, label = t, oc.Operation.Name
+	}
+	if timeout <= 0 {
+		return next(ctx)
+	}
+
+	deadline := time.Now().Add(timeout)
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	ctx = context.WithValue(ctx, deadlineKey{}, deadline)
+	responses := next(ctx)
+
+	// The deadline is released once the last response is produced, which
+	// is not the first one for operations using @defer.
+	var done, counted bool
+	return func(ctx context.Context) *graphql.Response {
+		if done {
+			return nil
+		}
+
+		res := responses(ctx)
+		if res == nil || res.HasNext == nil || !*res.HasNext {
+			done = true
+			defer cancel()
+		}
+		if res == nil {
+			// The executor skips the response middleware altogether once the
+			// deadline passed, answering with nil.
+			if !timedOut(ctx) {
+				return nil
+			}
+			res = &graphql.Response{Errors: gqlerror.List{timeoutError()}}
+		}
+		if timedOut(ctx) && !counted {
+			counted = true
+			e.counter.WithLabelValues(label).Inc()
+		}
+
+		return res
+	}
+}
+
+func (e Enforcer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res != nil && timedOut(ctx) {
+		res.Errors = append(res.Errors, timeoutError())
+	}
+
+	return res
+}
+
+// timedOut reports whether the deadline set by InterceptOperation passed,
+// as opposed to one inherited from the request.
+func timedOut(ctx context.Context) bool {
+	deadline, ok := ctx.Value(deadlineKey{}).(time.Time)
+	return ok && !time.Now().Before(deadline)
+}
+
+func timeoutError() *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: "operation timed out",
+		Extensions: map[string]any{
+			"code": ErrCodeTimeout,
+		},
+	}
+}
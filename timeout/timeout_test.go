@@ -0,0 +1,107 @@
+package timeout_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/timeout"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnforcer(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	enforcer := timeout.New(time.Hour,
+		timeout.WithRegisterer(registry),
+		timeout.WithOperationTimeout("Slow", 20*time.Millisecond),
+		timeout.WithOperationTimeout("Unbounded", 0),
+	)
+	defer enforcer.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(enforcer)
+	srv.Use(slowField{name: "todos", d: 100 * time.Millisecond})
+
+	resp := doRequest(srv, http.MethodPost, "/query", `{"query":"query Slow { todos { id } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `{"message":"operation timed out","extensions":{"code":"TIMEOUT"}}`)
+	assert.Contains(t, resp.Body.String(), `"message":"context deadline exceeded"`)
+
+	for _, name := range []string{"Other", "Unbounded"} {
+		resp = doRequest(srv, http.MethodPost, "/query", `{"query":"query `+name+` { todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "errors")
+	}
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `graphql_request_timeouts_total{operation_name="Slow"} 1`)
+	assert.NotContains(t, resp.Body.String(), `operation_name="Other"`)
+}
+
+func TestEnforcer_Defer(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	enforcer := timeout.New(time.Second, timeout.WithRegisterer(registry))
+	defer enforcer.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.MultipartMixed{})
+	srv.Use(enforcer)
+	srv.Use(slowField{name: "completed", d: 20 * time.Millisecond})
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id ... @defer { completed } } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "multipart/mixed")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	assert.Equal(t, 3, strings.Count(w.Body.String(), `"data":{"completed":false}`), "deferred resolvers keep the deadline context")
+	assert.Contains(t, w.Body.String(), `"hasNext":false`)
+	assert.NotContains(t, w.Body.String(), "context canceled")
+	assert.NotContains(t, w.Body.String(), "TIMEOUT")
+}
+
+// slowField makes the resolver of the named field take d, unless canceled.
+type slowField struct {
+	name string
+	d    time.Duration
+}
+
+func (slowField) ExtensionName() string                          { return "SlowField" }
+func (slowField) Validate(schema graphql.ExecutableSchema) error { return nil }
+
+func (f slowField) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if graphql.GetFieldContext(ctx).Field.Name != f.name {
+		return next(ctx)
+	}
+
+	select {
+	case <-time.After(f.d):
+		return next(ctx)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func doRequest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
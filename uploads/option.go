@@ -0,0 +1,106 @@
+package uploads
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	scanners     []Scanner
+	store        Store
+	key          func(ctx context.Context, upload graphql.Upload) string
+	errorHandler func(err error)
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Guard.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		key:          randomKey,
+		errorHandler: func(err error) {},
+		registerer:   prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithScanner adds s to the scanners uploads go through, in order.
+func WithScanner(s Scanner) Option {
+	return func(cfg *config) {
+		cfg.scanners = append(cfg.scanners, s)
+	}
+}
+
+// WithStore saves uploads to store once scanned, under a random key unless
+// WithKey is given. Resolvers get where with Location.
+func WithStore(store Store) Option {
+	return func(cfg *config) {
+		cfg.store = store
+	}
+}
+
+// WithKey names the uploads saved to the store with fn. The filename of
+// uploads is chosen by clients, it should not be used as is.
+func WithKey(fn func(ctx context.Context, upload graphql.Upload) string) Option {
+	return func(cfg *config) {
+		cfg.key = fn
+	}
+}
+
+// WithErrorHandler calls fn with the errors of scanners and of the store,
+// which clients are only told failed with ErrCodeUploadFailed.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
+
+func randomKey(ctx context.Context, upload graphql.Upload) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,64 @@
+package s3
+
+import (
+	"net/http"
+)
+
+type config struct {
+	region   string
+	creds    credentials
+	client   *http.Client
+	partSize int64
+}
+
+type credentials struct {
+	accessKeyID, secretAccessKey, sessionToken string
+}
+
+// Option is anything that can configure Uploader.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		region:   "us-east-1",
+		client:   http.DefaultClient,
+		partSize: 8 << 20,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithRegion sets the region requests are signed for, us-east-1 by default.
+func WithRegion(region string) Option {
+	return func(cfg *config) {
+		cfg.region = region
+	}
+}
+
+// WithCredentials signs requests with the given keys, sessionToken being
+// set for temporary credentials.
+func WithCredentials(accessKeyID, secretAccessKey, sessionToken string) Option {
+	return func(cfg *config) {
+		cfg.creds = credentials{accessKeyID, secretAccessKey, sessionToken}
+	}
+}
+
+// WithHTTPClient sends requests with client instead of http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(cfg *config) {
+		cfg.client = client
+	}
+}
+
+// WithPartSize sets the size of the parts of multipart uploads, 8 MiB by
+// default. It is the most held in memory per upload; S3 refuses parts under
+// 5 MiB but the last.
+func WithPartSize(size int64) Option {
+	return func(cfg *config) {
+		cfg.partSize = size
+	}
+}
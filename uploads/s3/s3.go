@@ -0,0 +1,238 @@
+// Package s3 streams graphql.Upload files to S3-compatible services, such as
+// AWS S3 or MinIO, with multipart uploads, holding at most a part of a file
+// in memory.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/sigv4"
+	"github.com/99designs/gqlgen-contrib/uploads"
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Object describes an uploaded object.
+type Object struct {
+	Bucket string
+	Key    string
+	ETag   string
+	Size   int64
+	// Location is the URL of the object.
+	Location string
+}
+
+// Uploader uploads files to a bucket, addressed by path on the endpoint.
+// Files no larger than a part are put with a single request, larger ones
+// are sent as a multipart upload, aborted if a part fails.
+type Uploader struct {
+	cfg      *config
+	endpoint string
+	bucket   string
+}
+
+var _ uploads.Store = &Uploader{}
+
+// New returns an Uploader to bucket of the service at endpoint, such as
+// https://s3.eu-west-1.amazonaws.com.
+func New(endpoint, bucket string, opts ...Option) *Uploader {
+	return &Uploader{
+		cfg:      newConfig(opts...),
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		bucket:   bucket,
+	}
+}
+
+// Put uploads upload under key, returning the URL of the object, for
+// Uploader to be the store of uploads.Guard.
+func (u *Uploader) Put(ctx context.Context, key string, upload graphql.Upload) (string, error) {
+	obj, err := u.Upload(ctx, key, upload)
+	if err != nil {
+		return "", err
+	}
+	return obj.Location, nil
+}
+
+// Upload streams upload.File to the object key.
+func (u *Uploader) Upload(ctx context.Context, key string, upload graphql.Upload) (*Object, error) {
+	location := u.endpoint + "/" + sigv4.EscapePath(u.bucket+"/"+key)
+	obj := &Object{Bucket: u.bucket, Key: key, Location: location}
+
+	if upload.Size <= u.cfg.partSize {
+		resp, err := u.do(ctx, http.MethodPut, location, upload.File, upload.Size, upload.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		obj.ETag, obj.Size = resp.Header.Get("ETag"), upload.Size
+		return obj, nil
+	}
+
+	resp, err := u.do(ctx, http.MethodPost, location+"?uploads", nil, 0, upload.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := decode(resp, &initiated); err != nil {
+		return nil, err
+	}
+	partsURL := location + "?uploadId=" + url.QueryEscape(initiated.UploadID)
+
+	complete, err := u.uploadParts(ctx, partsURL, upload.File, obj)
+	if err == nil {
+		var body []byte
+		body, err = xml.Marshal(complete)
+		if err == nil {
+			resp, err = u.do(ctx, http.MethodPost, partsURL, bytes.NewReader(body), int64(len(body)), "application/xml")
+		}
+		if err == nil {
+			var completed struct {
+				ETag string `xml:"ETag"`
+			}
+			if err = decode(resp, &completed); err == nil {
+				obj.ETag = completed.ETag
+				return obj, nil
+			}
+		}
+	}
+
+	// The upload is aborted even if ctx is done, for its parts not to be kept
+	// and billed.
+	abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if resp, abortErr := u.do(abortCtx, http.MethodDelete, partsURL, nil, 0, ""); abortErr == nil {
+		resp.Body.Close()
+	}
+	return nil, err
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []part   `xml:"Part"`
+}
+
+type part struct {
+	PartNumber int
+	ETag       string
+}
+
+// uploadParts sends the parts of r, counting their bytes in obj.Size.
+func (u *Uploader) uploadParts(ctx context.Context, partsURL string, r io.Reader, obj *Object) (*completeMultipartUpload, error) {
+	complete := &completeMultipartUpload{}
+	buf := make([]byte, u.cfg.partSize)
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF && number > 1 {
+			return complete, nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, err
+		}
+
+		resp, perr := u.do(ctx, http.MethodPut, partsURL+"&partNumber="+strconv.Itoa(number), bytes.NewReader(buf[:n]), int64(n), "")
+		if perr != nil {
+			return nil, perr
+		}
+		resp.Body.Close()
+		complete.Parts = append(complete.Parts, part{PartNumber: number, ETag: resp.Header.Get("ETag")})
+		obj.Size += int64(n)
+
+		if err != nil {
+			return complete, nil
+		}
+	}
+}
+
+// do sends a signed request, returning an error for responses other than
+// 200 OK.
+func (u *Uploader) do(ctx context.Context, method, url string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	switch {
+	case body != nil && size == 0:
+		// A reader of unknown length would be sent chunked, which S3 refuses.
+		body = http.NoBody
+	case body != nil:
+		// The client closes bodies, which are the files of resolvers.
+		body = io.NopCloser(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	sigv4.Sign(req, sigv4.Credentials{
+		AccessKeyID:     u.cfg.creds.accessKeyID,
+		SecretAccessKey: u.cfg.creds.secretAccessKey,
+		SessionToken:    u.cfg.creds.sessionToken,
+	}, u.cfg.region, "s3", time.Now())
+
+	resp, err := u.cfg.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// Error is the error answered by the service.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("s3: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func responseError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+	var body struct {
+		Code    string
+		Message string
+	}
+	if xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body) == nil {
+		e.Code, e.Message = body.Code, body.Message
+	}
+	return e
+}
+
+// decode decodes the XML body of resp into v. CompleteMultipartUpload may
+// fail after answering 200 OK, with an Error body.
+func decode(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+	var root struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return err
+	}
+	if root.XMLName.Local == "Error" {
+		return &Error{StatusCode: resp.StatusCode, Code: root.Code, Message: root.Message}
+	}
+	if err := xml.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("s3: malformed response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package s3_test
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/uploads/s3"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bucket is a fake S3 bucket, failing the part numbered failPart.
+type bucket struct {
+	mu       sync.Mutex
+	objects  map[string]string
+	parts    map[int]string
+	aborted  bool
+	failPart string
+}
+
+func (b *bucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if len(r.TransferEncoding) != 0 {
+		w.WriteHeader(http.StatusLengthRequired)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		b.parts = map[int]string{}
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>u/1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && query.Get("uploadId") == "u/1":
+		if query.Get("partNumber") == b.failPart {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<Error><Code>InternalError</Code><Message>part lost</Message></Error>`)
+			return
+		}
+		var n int
+		fmt.Sscan(query.Get("partNumber"), &n)
+		b.parts[n] = string(body)
+		w.Header().Set("ETag", `"part`+query.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost && query.Get("uploadId") == "u/1":
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		_ = xml.Unmarshal(body, &complete)
+		var object strings.Builder
+		for _, p := range complete.Parts {
+			object.WriteString(b.parts[p.PartNumber])
+		}
+		b.objects[r.URL.Path] = object.String()
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><ETag>"multi-%d"</ETag></CompleteMultipartUploadResult>`, len(complete.Parts))
+	case r.Method == http.MethodDelete && query.Get("uploadId") == "u/1":
+		b.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		b.objects[r.URL.Path] = string(body)
+		w.Header().Set("ETag", `"single"`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestUploader(t *testing.T) {
+	b := &bucket{objects: map[string]string{}}
+	ts := httptest.NewServer(b)
+	defer ts.Close()
+
+	u := s3.New(ts.URL, "media", s3.WithCredentials("AKID", "secret", ""), s3.WithPartSize(4))
+
+	obj, err := u.Upload(context.Background(), "small.txt", upload("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, &s3.Object{Bucket: "media", Key: "small.txt", ETag: `"single"`, Size: 3, Location: ts.URL + "/media/small.txt"}, obj)
+	assert.Equal(t, "abc", b.objects["/media/small.txt"])
+
+	_, err = u.Upload(context.Background(), "empty.txt", upload(""))
+	require.NoError(t, err, "empty files are not sent chunked")
+	assert.Contains(t, b.objects, "/media/empty.txt")
+
+	obj, err = u.Upload(context.Background(), "large.txt", upload("0123456789"))
+	require.NoError(t, err)
+	assert.Equal(t, `"multi-3"`, obj.ETag)
+	assert.Equal(t, int64(10), obj.Size)
+	assert.Equal(t, "0123456789", b.objects["/media/large.txt"])
+	assert.False(t, b.aborted)
+
+	b.failPart = "2"
+	_, err = u.Upload(context.Background(), "lost.txt", upload("0123456789"))
+	var s3err *s3.Error
+	require.ErrorAs(t, err, &s3err)
+	assert.Equal(t, "InternalError", s3err.Code)
+	assert.True(t, b.aborted)
+	assert.NotContains(t, b.objects, "/media/lost.txt")
+}
+
+func upload(content string) graphql.Upload {
+	return graphql.Upload{
+		File:        strings.NewReader(content),
+		Filename:    "file.txt",
+		Size:        int64(len(content)),
+		ContentType: "text/plain",
+	}
+}
@@ -0,0 +1,170 @@
+package uploads
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Scanner inspects an upload before resolvers get it, returning a
+// *Rejection to refuse it. The file is rewound before each scanner, which
+// may correct the fields of upload, such as its content type.
+type Scanner interface {
+	Scan(ctx context.Context, upload *graphql.Upload) error
+}
+
+// ScannerFunc is a func implementing Scanner.
+type ScannerFunc func(ctx context.Context, upload *graphql.Upload) error
+
+func (f ScannerFunc) Scan(ctx context.Context, upload *graphql.Upload) error {
+	return f(ctx, upload)
+}
+
+// Rejection is the error of scanners refusing an upload, answered to the
+// client with Code as extensions.code.
+type Rejection struct {
+	Code    string
+	Message string
+}
+
+func (r *Rejection) Error() string {
+	return r.Message
+}
+
+// MaxSize refuses uploads larger than n bytes with ErrCodeUploadTooLarge.
+func MaxSize(n int64) Scanner {
+	return ScannerFunc(func(ctx context.Context, upload *graphql.Upload) error {
+		if upload.Size > n {
+			return &Rejection{ErrCodeUploadTooLarge, "upload exceeds " + strconv.FormatInt(n, 10) + " bytes"}
+		}
+		return nil
+	})
+}
+
+// ContentTypes refuses with ErrCodeUploadTypeNotAllowed the uploads whose
+// content, sniffed with http.DetectContentType, is not of one of the given
+// media types, such as image/png or image/*. The content type announced by
+// clients is replaced by the sniffed one.
+func ContentTypes(allowed ...string) Scanner {
+	return ScannerFunc(func(ctx context.Context, upload *graphql.Upload) error {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(upload.File, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		contentType := http.DetectContentType(head[:n])
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		for _, a := range allowed {
+			if a == mediaType || strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a, "*")) {
+				upload.ContentType = contentType
+				return nil
+			}
+		}
+		return &Rejection{ErrCodeUploadTypeNotAllowed, "uploads of type " + mediaType + " are not allowed"}
+	})
+}
+
+// VirusScanner scans content for malware, returning the name of the threat
+// found, "" if clean.
+type VirusScanner interface {
+	ScanReader(ctx context.Context, r io.Reader) (threat string, err error)
+}
+
+// Antivirus refuses with ErrCodeUploadInfected the uploads in which v finds
+// a threat.
+func Antivirus(v VirusScanner) Scanner {
+	return ScannerFunc(func(ctx context.Context, upload *graphql.Upload) error {
+		threat, err := v.ScanReader(ctx, upload.File)
+		if err != nil {
+			return fmt.Errorf("uploads: antivirus: %w", err)
+		}
+		if threat != "" {
+			return &Rejection{ErrCodeUploadInfected, "upload was rejected by the antivirus"}
+		}
+		return nil
+	})
+}
+
+// ClamAV is a VirusScanner streaming content to a clamd daemon with the
+// INSTREAM command.
+type ClamAV struct {
+	// Network and Address of clamd, such as "tcp" and "localhost:3310" or
+	// "unix" and "/run/clamav/clamd.ctl".
+	Network, Address string
+	// Timeout bounds each scan, 30 seconds if 0.
+	Timeout time.Duration
+}
+
+var _ VirusScanner = ClamAV{}
+
+func (c ClamAV) ScanReader(ctx context.Context, r io.Reader) (string, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return "", err
+	}
+	chunk := make([]byte, 32<<10)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return "", err
+			}
+			if _, err := conn.Write(chunk[:n]); err != nil {
+				return "", err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	result := string(bytes.TrimRight(reply, "\x00\n"))
+	result = strings.TrimPrefix(result, "stream: ")
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamd: %s", result)
+	}
+}
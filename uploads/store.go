@@ -0,0 +1,51 @@
+package uploads
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Store saves uploads, returning where they can be found.
+type Store interface {
+	Put(ctx context.Context, key string, upload graphql.Upload) (location string, err error)
+}
+
+// Dir is a Store writing uploads to files of the directory, their location
+// being their path.
+type Dir string
+
+var _ Store = Dir("")
+
+func (d Dir) Put(ctx context.Context, key string, upload graphql.Upload) (string, error) {
+	path := filepath.Join(string(d), filepath.Base(key))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, upload.File); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// storedFile is the File of uploads saved to the store.
+type storedFile struct {
+	io.ReadSeeker
+	location string
+}
+
+// Location returns where the store saved upload, false when there is no
+// store.
+func Location(upload graphql.Upload) (string, bool) {
+	f, ok := upload.File.(*storedFile)
+	if !ok {
+		return "", false
+	}
+	return f.location, true
+}
@@ -0,0 +1,187 @@
+// Package uploads scans the files of multipart requests before resolvers get
+// them, and saves them to a store.
+package uploads
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes set as extensions.code of the error answered to requests
+// whose uploads are refused.
+const (
+	ErrCodeUploadTooLarge       = "UPLOAD_TOO_LARGE"
+	ErrCodeUploadTypeNotAllowed = "UPLOAD_TYPE_NOT_ALLOWED"
+	ErrCodeUploadInfected       = "UPLOAD_INFECTED"
+	ErrCodeUploadFailed         = "UPLOAD_FAILED"
+)
+
+// Guard is an extension passing the uploads of operations through its
+// scanners, then saving them to its store, before the operation is
+// executed. An operation is refused with the error of its first refused
+// upload.
+//
+// It counts uploads in graphql_uploads_total{result}, result being accepted,
+// rejected or failed, and observes their size and the time spent scanning
+// and saving them.
+type Guard struct {
+	cfg      *config
+	total    *prometheusclient.CounterVec
+	size     prometheusclient.Histogram
+	duration prometheusclient.Histogram
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = &Guard{}
+
+// New returns a Guard whose metrics are registered on the configured
+// registerer.
+func New(opts ...Option) *Guard {
+	cfg := newConfig(opts...)
+
+	g := &Guard{
+		cfg: cfg,
+		total: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_uploads_total",
+			Help:        "Total number of uploads scanned, by result.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"result"}),
+		size: prometheusclient.NewHistogram(prometheusclient.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_upload_size_bytes",
+			Help:        "Size of the uploads scanned.",
+			Buckets:     prometheusclient.ExponentialBuckets(1<<10, 4, 10),
+			ConstLabels: cfg.constLabels,
+		}),
+		duration: prometheusclient.NewHistogram(prometheusclient.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_upload_processing_duration_seconds",
+			Help:        "Time spent scanning and saving uploads.",
+			Buckets:     prometheusclient.DefBuckets,
+			ConstLabels: cfg.constLabels,
+		}),
+	}
+	cfg.registerer.MustRegister(g.total, g.size, g.duration)
+
+	return g
+}
+
+// UnRegister removes the metrics from the registerer they were registered
+// on.
+func (g *Guard) UnRegister() {
+	g.cfg.registerer.Unregister(g.total)
+	g.cfg.registerer.Unregister(g.size)
+	g.cfg.registerer.Unregister(g.duration)
+}
+
+func (g *Guard) ExtensionName() string {
+	return "Uploads"
+}
+
+func (g *Guard) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (g *Guard) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	for name, v := range rawParams.Variables {
+		v, err := g.walk(ctx, v)
+		if err != nil {
+			return err
+		}
+		rawParams.Variables[name] = v
+	}
+	return nil
+}
+
+// walk processes the uploads found in v, returning v with them replaced.
+func (g *Guard) walk(ctx context.Context, v any) (any, *gqlerror.Error) {
+	switch v := v.(type) {
+	case graphql.Upload:
+		return g.process(ctx, v)
+	case map[string]any:
+		for k, e := range v {
+			e, err := g.walk(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = e
+		}
+	case []any:
+		for i, e := range v {
+			e, err := g.walk(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = e
+		}
+	}
+	return v, nil
+}
+
+func (g *Guard) process(ctx context.Context, upload graphql.Upload) (graphql.Upload, *gqlerror.Error) {
+	start := time.Now()
+	g.size.Observe(float64(upload.Size))
+	defer func() {
+		g.duration.Observe(time.Since(start).Seconds())
+	}()
+
+	for _, s := range g.cfg.scanners {
+		if err := rewind(upload); err != nil {
+			return upload, g.fail(err)
+		}
+		if err := s.Scan(ctx, &upload); err != nil {
+			var rejection *Rejection
+			if errors.As(err, &rejection) {
+				g.total.WithLabelValues("rejected").Inc()
+				return upload, &gqlerror.Error{
+					Message:    rejection.Message,
+					Extensions: map[string]interface{}{"code": rejection.Code},
+				}
+			}
+			return upload, g.fail(err)
+		}
+	}
+
+	if g.cfg.store != nil {
+		if err := rewind(upload); err != nil {
+			return upload, g.fail(err)
+		}
+		location, err := g.cfg.store.Put(ctx, g.cfg.key(ctx, upload), upload)
+		if err != nil {
+			return upload, g.fail(err)
+		}
+		upload.File = &storedFile{ReadSeeker: upload.File, location: location}
+	}
+	if err := rewind(upload); err != nil {
+		return upload, g.fail(err)
+	}
+
+	g.total.WithLabelValues("accepted").Inc()
+	return upload, nil
+}
+
+func (g *Guard) fail(err error) *gqlerror.Error {
+	g.cfg.errorHandler(err)
+	g.total.WithLabelValues("failed").Inc()
+	return &gqlerror.Error{
+		Message:    "upload could not be processed",
+		Extensions: map[string]interface{}{"code": ErrCodeUploadFailed},
+	}
+}
+
+func rewind(upload graphql.Upload) error {
+	_, err := upload.File.Seek(0, io.SeekStart)
+	return err
+}
@@ -0,0 +1,217 @@
+package uploads_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/uploads"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var png = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 24)...)
+
+func TestGuard(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	g := uploads.New(
+		uploads.WithRegisterer(registry),
+		uploads.WithScanner(uploads.MaxSize(64)),
+		uploads.WithScanner(uploads.ContentTypes("image/*")),
+	)
+	defer g.UnRegister()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.MultipartForm{})
+	srv.Use(g)
+
+	resp := doRequest(srv, bytes.Repeat([]byte("x"), 128))
+	assert.Contains(t, resp.Body.String(), `"code":"UPLOAD_TOO_LARGE"`)
+
+	resp = doRequest(srv, []byte("<html><body>hello</body></html>"))
+	assert.Contains(t, resp.Body.String(), `"code":"UPLOAD_TYPE_NOT_ALLOWED"`)
+
+	// Accepted uploads reach validation, which fails as the test schema has
+	// no Upload scalar.
+	resp = doRequest(srv, png)
+	assert.NotContains(t, resp.Body.String(), "UPLOAD_")
+
+	metrics := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, metrics.Body.String(), `graphql_uploads_total{result="rejected"} 2`)
+	assert.Contains(t, metrics.Body.String(), `graphql_uploads_total{result="accepted"} 1`)
+	assert.Contains(t, metrics.Body.String(), `graphql_upload_size_bytes_count 3`)
+}
+
+func TestGuard_Store(t *testing.T) {
+	dir := t.TempDir()
+	g := uploads.New(
+		uploads.WithRegisterer(prometheusclient.NewRegistry()),
+		uploads.WithScanner(uploads.ContentTypes("image/png")),
+		uploads.WithStore(uploads.Dir(dir)),
+		uploads.WithKey(func(ctx context.Context, upload graphql.Upload) string {
+			return "avatar.png"
+		}),
+	)
+
+	params := &graphql.RawParams{Variables: map[string]any{
+		"input": map[string]any{"files": []any{graphql.Upload{
+			File:        bytes.NewReader(png),
+			Filename:    "avatar.png",
+			Size:        int64(len(png)),
+			ContentType: "application/octet-stream",
+		}}},
+	}}
+	require.Nil(t, g.MutateOperationParameters(context.Background(), params))
+
+	upload := params.Variables["input"].(map[string]any)["files"].([]any)[0].(graphql.Upload)
+	assert.Equal(t, "image/png", upload.ContentType)
+	location, ok := uploads.Location(upload)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "avatar.png"), location)
+
+	stored, err := os.ReadFile(location)
+	require.NoError(t, err)
+	assert.Equal(t, png, stored)
+	content, err := io.ReadAll(upload.File)
+	require.NoError(t, err)
+	assert.Equal(t, png, content)
+
+	// The key is taken, the second upload fails.
+	var failure error
+	g.UnRegister()
+	g = uploads.New(
+		uploads.WithRegisterer(prometheusclient.NewRegistry()),
+		uploads.WithStore(uploads.Dir(dir)),
+		uploads.WithKey(func(ctx context.Context, upload graphql.Upload) string {
+			return "avatar.png"
+		}),
+		uploads.WithErrorHandler(func(err error) { failure = err }),
+	)
+	params = &graphql.RawParams{Variables: map[string]any{"file": graphql.Upload{File: bytes.NewReader(png)}}}
+	gerr := g.MutateOperationParameters(context.Background(), params)
+	require.NotNil(t, gerr)
+	assert.Equal(t, uploads.ErrCodeUploadFailed, gerr.Extensions["code"])
+	assert.ErrorIs(t, failure, os.ErrExist)
+}
+
+func TestS3(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+
+	store := uploads.S3{
+		Endpoint:        ts.URL,
+		Bucket:          "media",
+		Region:          "eu-west-1",
+		Prefix:          "uploads/",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	}
+	location, err := store.Put(context.Background(), "a b.png", graphql.Upload{
+		File:        bytes.NewReader(png),
+		Size:        int64(len(png)),
+		ContentType: "image/png",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/media/uploads/a%20b.png", location)
+
+	require.NotNil(t, got)
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, "/media/uploads/a b.png", got.URL.Path)
+	assert.Equal(t, png, body)
+	assert.Equal(t, "image/png", got.Header.Get("Content-Type"))
+	assert.Equal(t, "UNSIGNED-PAYLOAD", got.Header.Get("X-Amz-Content-Sha256"))
+	authorization := got.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKID/"), authorization)
+	assert.Contains(t, authorization, "/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=")
+
+	_, err = store.Put(context.Background(), "empty.txt", graphql.Upload{File: bytes.NewReader(nil)})
+	require.NoError(t, err)
+	assert.Empty(t, got.TransferEncoding, "empty files are not sent chunked")
+}
+
+func TestClamAV(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			command := make([]byte, len("zINSTREAM\x00"))
+			_, _ = io.ReadFull(conn, command)
+			var content []byte
+			for {
+				var size uint32
+				if binary.Read(conn, binary.BigEndian, &size) != nil || size == 0 {
+					break
+				}
+				chunk := make([]byte, size)
+				_, _ = io.ReadFull(conn, chunk)
+				content = append(content, chunk...)
+			}
+			if bytes.Contains(content, []byte("EICAR")) {
+				_, _ = io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+			} else {
+				_, _ = io.WriteString(conn, "stream: OK\x00")
+			}
+			conn.Close()
+		}
+	}()
+
+	clamav := uploads.ClamAV{Network: "tcp", Address: l.Addr().String()}
+	threat, err := clamav.ScanReader(context.Background(), bytes.NewReader(png))
+	require.NoError(t, err)
+	assert.Empty(t, threat)
+
+	threat, err = clamav.ScanReader(context.Background(), strings.NewReader("X5O!P%@AP EICAR-STANDARD-ANTIVIRUS-TEST-FILE"))
+	require.NoError(t, err)
+	assert.Equal(t, "Eicar-Test-Signature", threat)
+
+	upload := graphql.Upload{File: strings.NewReader("EICAR")}
+	err = uploads.Antivirus(clamav).Scan(context.Background(), &upload)
+	var rejection *uploads.Rejection
+	require.ErrorAs(t, err, &rejection)
+	assert.Equal(t, uploads.ErrCodeUploadInfected, rejection.Code)
+}
+
+// doRequest sends a multipart mutation uploading content as $file.
+func doRequest(handler http.Handler, content []byte) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("operations", `{"query":"mutation($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`)
+	_ = mw.WriteField("map", `{"0":["variables.file"]}`)
+	fw, _ := mw.CreateFormFile("0", "file")
+	_, _ = fw.Write(content)
+	_ = mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/query", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,56 @@
+package wideevent
+
+import (
+	"context"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+)
+
+type config struct {
+	slowestFields int
+	enricher      contrib.Enricher
+	errorHandler  func(ctx context.Context, err error)
+}
+
+// Option is anything that can configure Recorder.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		slowestFields: 5,
+		errorHandler:  func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithSlowestFields sets how many of the slowest resolvers are listed in
+// each event, 5 by default.
+func WithSlowestFields(n int) Option {
+	return func(cfg *config) {
+		cfg.slowestFields = n
+	}
+}
+
+// WithEnricher adds the labels returned by fn to every event. Calling it
+// again adds more labels.
+func WithEnricher(fn contrib.Enricher) Option {
+	return func(cfg *config) {
+		if cfg.enricher == nil {
+			cfg.enricher = fn
+		} else {
+			cfg.enricher = contrib.Enrichers(cfg.enricher, fn)
+		}
+	}
+}
+
+// WithErrorHandler is called when the Sink fails to accept an event.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
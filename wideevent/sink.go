@@ -0,0 +1,168 @@
+package wideevent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/jsonsink"
+)
+
+// Sink receives events. Send is called synchronously before the response is
+// sent and must be safe for concurrent use.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, event Event) error
+
+func (f SinkFunc) Send(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// WriterSink writes events to w as JSON lines.
+func WriterSink(w io.Writer) Sink {
+	return writerSink{jsonsink.NewWriter(w)}
+}
+
+type writerSink struct {
+	w *jsonsink.Writer
+}
+
+func (s writerSink) Send(ctx context.Context, event Event) error {
+	return s.w.Encode(event)
+}
+
+// HTTPSink POSTs every event as JSON to url. Responses other than 2xx are
+// errors.
+func HTTPSink(client *http.Client, url string) Sink {
+	return SinkFunc(func(ctx context.Context, event Event) error {
+		return post(ctx, client, url, nil, event)
+	})
+}
+
+// ErrBufferFull is returned by Honeycomb.Send when events are produced
+// faster than they can be sent. The event is dropped.
+var ErrBufferFull = errors.New("wideevent: buffer full")
+
+// Honeycomb sends events to the Honeycomb batch events API in the
+// background, batching them by up to BatchSize or every FlushInterval.
+// Fields must be set before the first Send; Close flushes pending events.
+// see https://docs.honeycomb.io/api/tag/Events#operation/createEvents
+type Honeycomb struct {
+	APIKey  string
+	Dataset string
+	// APIHost defaults to https://api.honeycomb.io.
+	APIHost string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// BatchSize defaults to 100, FlushInterval to 1s.
+	BatchSize     int
+	FlushInterval time.Duration
+	// ErrorHandler is called with the errors of background sends.
+	ErrorHandler func(err error)
+
+	once   sync.Once
+	events chan honeycombEvent
+	done   chan struct{}
+}
+
+type honeycombEvent struct {
+	Time time.Time `json:"time"`
+	Data Event     `json:"data"`
+}
+
+func (h *Honeycomb) start() {
+	if h.APIHost == "" {
+		h.APIHost = "https://api.honeycomb.io"
+	}
+	if h.Client == nil {
+		h.Client = http.DefaultClient
+	}
+	if h.BatchSize <= 0 {
+		h.BatchSize = 100
+	}
+	if h.FlushInterval <= 0 {
+		h.FlushInterval = time.Second
+	}
+	if h.ErrorHandler == nil {
+		h.ErrorHandler = func(err error) {}
+	}
+
+	h.events = make(chan honeycombEvent, 10*h.BatchSize)
+	h.done = make(chan struct{})
+	go h.run()
+}
+
+func (h *Honeycomb) Send(ctx context.Context, event Event) error {
+	h.once.Do(h.start)
+
+	e := honeycombEvent{Time: time.Now(), Data: event}
+	if t, ok := event["timestamp"].(time.Time); ok {
+		e.Time = t
+	}
+
+	select {
+	case h.events <- e:
+		return nil
+	default:
+		return ErrBufferFull
+	}
+}
+
+// Close sends the pending events and stops the background sender. Send must
+// not be called afterwards.
+func (h *Honeycomb) Close() error {
+	h.once.Do(h.start)
+	close(h.events)
+	<-h.done
+	return nil
+}
+
+func (h *Honeycomb) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []honeycombEvent
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		url := h.APIHost + "/1/batch/" + h.Dataset
+		header := http.Header{"X-Honeycomb-Team": {h.APIKey}}
+		if err := post(context.Background(), h.Client, url, header, batch); err != nil {
+			h.ErrorHandler(err)
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case e, ok := <-h.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= h.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func post(ctx context.Context, client *http.Client, url string, header http.Header, v any) error {
+	if err := jsonsink.Post(ctx, client, url, header, v); err != nil {
+		return fmt.Errorf("wideevent: %w", err)
+	}
+	return nil
+}
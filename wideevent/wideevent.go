@@ -0,0 +1,140 @@
+// Package wideevent emits one wide event per GraphQL operation: a flat set
+// of fields holding its timing, resolver count and slowest fields, errors,
+// client and the labels of an Enricher, ready for Honeycomb or any store of
+// structured events.
+package wideevent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/slowest"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Event is a wide event, keyed by field name.
+type Event map[string]any
+
+// Field is a resolver listed among the slowest of an operation.
+type Field struct {
+	Path       string  `json:"path"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Recorder is a gqlgen handler extension sending a wide event to a Sink for
+// every query and mutation.
+type Recorder struct {
+	sink Sink
+	cfg  *config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Recorder{}
+
+// New returns a Recorder sending events to sink.
+func New(sink Sink, opts ...Option) *Recorder {
+	return &Recorder{
+		sink: sink,
+		cfg:  newConfig(opts...),
+	}
+}
+
+func (r *Recorder) ExtensionName() string {
+	return "WideEvent"
+}
+
+func (r *Recorder) Validate(schema graphql.ExecutableSchema) error {
+	if r.sink == nil {
+		return fmt.Errorf("wideevent: no sink")
+	}
+	return nil
+}
+
+type collectorKey struct{}
+
+func (r *Recorder) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+	return next(context.WithValue(ctx, collectorKey{}, slowest.NewCollector(r.cfg.slowestFields)))
+}
+
+func (r *Recorder) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	c, ok := ctx.Value(collectorKey{}).(*slowest.Collector)
+	if !ok {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	c.Add(graphql.GetFieldContext(ctx).Path().String(), time.Since(start))
+
+	return res, err
+}
+
+func (r *Recorder) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return res
+	}
+
+	event := Event{
+		"timestamp":              oc.Stats.OperationStart,
+		"duration_ms":            milliseconds(time.Since(oc.Stats.OperationStart)),
+		"graphql.operation.name": gqlctx.OperationName(oc),
+		"graphql.errors.count":   len(res.Errors),
+	}
+	if oc.Operation != nil {
+		event["graphql.operation.type"] = string(oc.Operation.Operation)
+	}
+	if c, ok := ctx.Value(collectorKey{}).(*slowest.Collector); ok {
+		event["graphql.resolvers.count"] = c.Resolvers()
+		if fields := c.Slowest(); len(fields) != 0 {
+			list := make([]Field, len(fields))
+			for i, f := range fields {
+				list[i] = Field{Path: f.Path, DurationMs: milliseconds(f.Duration)}
+			}
+			event["graphql.resolvers.slowest"] = list
+		}
+	}
+	if len(res.Errors) != 0 {
+		event["error"] = res.Errors[0].Message
+		if codes := extcode.Codes(res.Errors); len(codes) != 0 {
+			event["graphql.errors.codes"] = codes
+		}
+	}
+	if client := clientinfo.ForContext(ctx); client.Name != "" {
+		event["client.name"] = client.Name
+		event["client.version"] = client.Version
+	}
+	if r.cfg.enricher != nil {
+		for key, value := range r.cfg.enricher(ctx) {
+			event[key] = value
+		}
+	}
+
+	if err := r.sink.Send(ctx, event); err != nil {
+		r.cfg.errorHandler(ctx, err)
+	}
+
+	return res
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
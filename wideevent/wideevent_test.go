@@ -0,0 +1,133 @@
+package wideevent_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/wideevent"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	var events []wideevent.Event
+	sink := wideevent.SinkFunc(func(ctx context.Context, event wideevent.Event) error {
+		events = append(events, event)
+		return nil
+	})
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(wideevent.New(sink,
+		wideevent.WithSlowestFields(2),
+		wideevent.WithEnricher(func(ctx context.Context) map[string]string {
+			return map[string]string{"tenant": "acme"}
+		}),
+	))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Lookup { todos { id } todo(id: \"unknown\") { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("apollographql-client-name", "web")
+	r.Header.Set("apollographql-client-version", "1.0")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Len(t, events, 1)
+	event := events[0]
+	assert.Equal(t, "Lookup", event["graphql.operation.name"])
+	assert.Equal(t, "query", event["graphql.operation.type"])
+	assert.Equal(t, 5, event["graphql.resolvers.count"])
+	assert.Equal(t, 1, event["graphql.errors.count"])
+	assert.Equal(t, graph.ErrTodoNotFound.Error(), event["error"])
+	assert.Equal(t, "web", event["client.name"])
+	assert.Equal(t, "1.0", event["client.version"])
+	assert.Equal(t, "acme", event["tenant"])
+	assert.IsType(t, float64(0), event["duration_ms"])
+
+	slowest := event["graphql.resolvers.slowest"].([]wideevent.Field)
+	require.Len(t, slowest, 2)
+	assert.GreaterOrEqual(t, slowest[0].DurationMs, slowest[1].DurationMs)
+}
+
+func TestRecorder_SinkError(t *testing.T) {
+	var errs []error
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(wideevent.New(
+		wideevent.SinkFunc(func(ctx context.Context, event wideevent.Event) error {
+			return assert.AnError
+		}),
+		wideevent.WithErrorHandler(func(ctx context.Context, err error) {
+			errs = append(errs, err)
+		}),
+	))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []error{assert.AnError}, errs)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := wideevent.WriterSink(&buf)
+	require.NoError(t, sink.Send(context.Background(), wideevent.Event{"a": 1}))
+	require.NoError(t, sink.Send(context.Background(), wideevent.Event{"b": "x"}))
+	assert.Equal(t, "{\"a\":1}\n{\"b\":\"x\"}\n", buf.String())
+}
+
+func TestHoneycomb(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1/batch/graphql", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Honeycomb-Team"))
+
+		var batch []map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	sink := &wideevent.Honeycomb{
+		APIKey:        "secret",
+		Dataset:       "graphql",
+		APIHost:       server.URL,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sink.Send(context.Background(), wideevent.Event{"n": i, "timestamp": start}))
+	}
+	require.NoError(t, sink.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0], 2)
+	assert.Len(t, batches[1], 1)
+	assert.Equal(t, "2024-01-02T03:04:05Z", batches[0][0]["time"])
+	assert.Equal(t, float64(2), batches[1][0]["data"].(map[string]any)["n"])
+}
@@ -0,0 +1,43 @@
+package wsauth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT returns a Verifier accepting JSON Web Tokens whose signature checks out
+// with the key returned by keyFunc. Parser options restrict the accepted
+// signing methods, audience or issuer. The Identity carries the sub claim as
+// Subject and expires along with the token.
+func JWT(keyFunc jwt.Keyfunc, opts ...jwt.ParserOption) Verifier {
+	parser := jwt.NewParser(opts...)
+
+	return func(ctx context.Context, token string) (*Identity, error) {
+		claims := jwt.MapClaims{}
+		if _, err := parser.ParseWithClaims(token, claims, keyFunc); err != nil {
+			return nil, err
+		}
+
+		subject, err := claims.GetSubject()
+		if err != nil {
+			return nil, err
+		}
+		if subject == "" {
+			return nil, errors.New("token has no subject")
+		}
+
+		id := &Identity{
+			Subject: subject,
+			Claims:  claims,
+		}
+		if exp, err := claims.GetExpirationTime(); err != nil {
+			return nil, err
+		} else if exp != nil {
+			id.ExpiresAt = exp.Time
+		}
+
+		return id, nil
+	}
+}
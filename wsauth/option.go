@@ -0,0 +1,38 @@
+package wsauth
+
+import "time"
+
+type config struct {
+	payloadKey  string
+	maxLifetime time.Duration
+}
+
+// Option is anything that can configure Authenticator.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithPayloadKey reads the token from the given connection_init payload key
+// instead of Authorization.
+func WithPayloadKey(key string) Option {
+	return func(cfg *config) {
+		cfg.payloadKey = key
+	}
+}
+
+// WithMaxLifetime closes connections after d even if their token is still
+// valid, so clients authenticate again from time to time. 0, the default,
+// only closes them once the token expires.
+func WithMaxLifetime(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.maxLifetime = d
+	}
+}
@@ -0,0 +1,109 @@
+// Package wsauth authenticates websocket connections from the token sent in
+// their connection_init payload.
+package wsauth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// Close codes sent when a connection is refused, as used by graphql-ws.
+const (
+	CloseUnauthorized = 4401
+	CloseForbidden    = 4403
+)
+
+var (
+	// ErrMissingToken is returned when connection_init carries no token.
+	ErrMissingToken = errors.New("missing token")
+	// ErrExpired is the cause of the connection context being canceled
+	// once its token or lifetime expired.
+	ErrExpired = errors.New("connection expired")
+)
+
+// Identity is the authenticated party of a connection.
+type Identity struct {
+	Subject string
+	Claims  map[string]any
+	// ExpiresAt closes the connection when reached, unless zero.
+	ExpiresAt time.Time
+	// Value is left to the Verifier, for example its own claims type.
+	Value any
+}
+
+// Verifier checks token and returns the Identity it authenticates.
+type Verifier func(ctx context.Context, token string) (*Identity, error)
+
+// Authenticator verifies websocket connections. Install it with:
+//
+//	srv.AddTransport(transport.Websocket{
+//		InitFunc: wsauth.New(verify).InitFunc,
+//	})
+//
+// Resolvers then find the Identity with ForContext.
+type Authenticator struct {
+	verify Verifier
+	cfg    *config
+}
+
+var _ transport.WebsocketInitFunc = (*Authenticator)(nil).InitFunc
+
+// New returns an Authenticator checking tokens with verify.
+func New(verify Verifier, opts ...Option) *Authenticator {
+	return &Authenticator{
+		verify: verify,
+		cfg:    newConfig(opts...),
+	}
+}
+
+type identityKey struct{}
+
+// ForContext returns the Identity of the connection, nil if there is none.
+func ForContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// InitFunc is a transport.WebsocketInitFunc. It refuses connections whose
+// token is missing or invalid, and closes the others once their token or
+// lifetime expires.
+func (a *Authenticator) InitFunc(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	token := payload.Authorization()
+	if a.cfg.payloadKey != "" {
+		token = payload.GetString(a.cfg.payloadKey)
+	}
+	token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
+	if token == "" {
+		return transport.WithWebsocketCloseCode(ctx, CloseUnauthorized), nil, ErrMissingToken
+	}
+
+	id, err := a.verify(ctx, token)
+	if err != nil {
+		return transport.WithWebsocketCloseCode(ctx, CloseForbidden), nil, err
+	}
+
+	ctx = context.WithValue(ctx, identityKey{}, id)
+
+	deadline := id.ExpiresAt
+	if a.cfg.maxLifetime > 0 {
+		if lifetime := time.Now().Add(a.cfg.maxLifetime); deadline.IsZero() || lifetime.Before(deadline) {
+			deadline = lifetime
+		}
+	}
+	if deadline.IsZero() {
+		return ctx, nil, nil
+	}
+
+	// The transport closes the connection once its context is done, telling
+	// the client the reason so it reconnects with a fresh token.
+	ctx = transport.AppendCloseReason(ctx, ErrExpired.Error())
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(time.Until(deadline), func() { cancel(ErrExpired) })
+	context.AfterFunc(ctx, func() { timer.Stop() })
+
+	return ctx, nil, nil
+}
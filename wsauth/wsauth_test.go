@@ -0,0 +1,118 @@
+package wsauth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/wsauth"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("secret")
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthenticator_JWT(t *testing.T) {
+	auth := wsauth.New(wsauth.JWT(func(token *jwt.Token) (any, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{"HS256"})))
+
+	specs := []struct {
+		SpecName string
+		Payload  transport.InitPayload
+		Error    string
+		Subject  string
+	}{
+		{
+			SpecName: "valid",
+			Payload:  transport.InitPayload{"Authorization": "Bearer " + sign(t, jwt.MapClaims{"sub": "alice"})},
+			Subject:  "alice",
+		},
+		{
+			SpecName: "missing",
+			Payload:  transport.InitPayload{},
+			Error:    "missing token",
+		},
+		{
+			SpecName: "expired",
+			Payload:  transport.InitPayload{"authorization": sign(t, jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(-time.Minute).Unix()})},
+			Error:    "token has invalid claims: token is expired",
+		},
+		{
+			SpecName: "bad signature",
+			Payload:  transport.InitPayload{"Authorization": sign(t, jwt.MapClaims{"sub": "alice"}) + "x"},
+			Error:    "token signature is invalid: signature is invalid",
+		},
+		{
+			SpecName: "no subject",
+			Payload:  transport.InitPayload{"Authorization": sign(t, jwt.MapClaims{})},
+			Error:    "token has no subject",
+		},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.SpecName, func(t *testing.T) {
+			ctx, ack, err := auth.InitFunc(context.Background(), spec.Payload)
+			assert.Nil(t, ack)
+			if spec.Error != "" {
+				assert.EqualError(t, err, spec.Error)
+				assert.Nil(t, wsauth.ForContext(ctx))
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, wsauth.ForContext(ctx))
+			assert.Equal(t, spec.Subject, wsauth.ForContext(ctx).Subject)
+			assert.NoError(t, ctx.Err())
+		})
+	}
+}
+
+func TestAuthenticator_Expiry(t *testing.T) {
+	now := time.Now()
+	verify := func(ctx context.Context, token string) (*wsauth.Identity, error) {
+		switch token {
+		case "short":
+			return &wsauth.Identity{Subject: "alice", ExpiresAt: now.Add(20 * time.Millisecond)}, nil
+		case "long":
+			return &wsauth.Identity{Subject: "alice", ExpiresAt: now.Add(time.Hour)}, nil
+		}
+		return nil, errors.New("unknown token")
+	}
+
+	auth := wsauth.New(verify,
+		wsauth.WithPayloadKey("token"),
+		wsauth.WithMaxLifetime(50*time.Millisecond),
+	)
+
+	for _, token := range []string{"short", "long"} {
+		t.Run(token, func(t *testing.T) {
+			ctx, _, err := auth.InitFunc(context.Background(), transport.InitPayload{"token": token})
+			require.NoError(t, err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+				t.Fatal("connection context not canceled")
+			}
+			assert.ErrorIs(t, context.Cause(ctx), wsauth.ErrExpired)
+		})
+	}
+
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, _, err := auth.InitFunc(parent, transport.InitPayload{"token": "long"})
+	require.NoError(t, err)
+	cancel()
+	assert.ErrorIs(t, context.Cause(ctx), context.Canceled)
+
+	_, _, err = auth.InitFunc(context.Background(), transport.InitPayload{"token": "forged"})
+	assert.EqualError(t, err, "unknown token")
+}
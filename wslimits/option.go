@@ -0,0 +1,142 @@
+package wslimits
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	maxConnections int
+	maxPerIP       int
+	maxPerUser     int
+	user           func(ctx context.Context, payload transport.InitPayload) string
+	clientIP       func(r *http.Request) string
+	idleTimeout    time.Duration
+	maxMessageSize int64
+	keepAlive      time.Duration
+
+	namespace   string
+	subsystem   string
+	constLabels prometheusclient.Labels
+	registerer  prometheusclient.Registerer
+}
+
+// Option is anything that can configure Transport.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		clientIP:   remoteIP,
+		registerer: prometheusclient.DefaultRegisterer,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithMaxConnections caps the number of connections open at once. Requests
+// beyond it are answered with status 429 before being upgraded.
+func WithMaxConnections(n int) Option {
+	return func(cfg *config) {
+		cfg.maxConnections = n
+	}
+}
+
+// WithMaxConnectionsPerIP caps the number of connections open at once from
+// a client IP, as returned by WithClientIP. Requests beyond it are answered
+// with status 429 before being upgraded.
+func WithMaxConnectionsPerIP(n int) Option {
+	return func(cfg *config) {
+		cfg.maxPerIP = n
+	}
+}
+
+// WithMaxConnectionsPerUser caps the number of connections open at once by
+// a user, identified by user from the connection_init payload once the
+// InitFunc of the transport succeeded. Connections beyond it are closed
+// with a connection error. Connections whose user is empty are not capped.
+func WithMaxConnectionsPerUser(n int, user func(ctx context.Context, payload transport.InitPayload) string) Option {
+	return func(cfg *config) {
+		cfg.maxPerUser = n
+		cfg.user = user
+	}
+}
+
+// WithClientIP returns the IP a request comes from, the host of
+// RemoteAddr by default. Behind a proxy, it should read the header the
+// proxy sets instead.
+func WithClientIP(fn func(r *http.Request) string) Option {
+	return func(cfg *config) {
+		cfg.clientIP = fn
+	}
+}
+
+// WithIdleTimeout closes the connections with no operation in flight for d.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.idleTimeout = d
+	}
+}
+
+// WithMaxMessageSize sets the PayloadReadLimit of the transport, the
+// maximum size in bytes of the messages clients send.
+func WithMaxMessageSize(n int64) Option {
+	return func(cfg *config) {
+		cfg.maxMessageSize = n
+	}
+}
+
+// WithKeepAlive pings clients every d, with keepalive messages on
+// graphql-ws and with pings on graphql-transport-ws, closing the latter
+// connections when no pong is received within 2*d.
+func WithKeepAlive(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.keepAlive = d
+	}
+}
+
+// WithNamespace prefixes the metric names with the given namespace.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithSubsystem inserts the given subsystem between the namespace and the
+// metric names.
+func WithSubsystem(subsystem string) Option {
+	return func(cfg *config) {
+		cfg.subsystem = subsystem
+	}
+}
+
+// WithConstLabels attaches the given labels to the metrics.
+func WithConstLabels(labels prometheusclient.Labels) Option {
+	return func(cfg *config) {
+		cfg.constLabels = labels
+	}
+}
+
+// WithRegisterer registers the metrics on registerer instead of
+// prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheusclient.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = registerer
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,323 @@
+// Package wslimits wraps the websocket transport of gqlgen to limit the
+// connections clients may open and keep idle, exporting Prometheus metrics
+// on the connections and why they were closed.
+package wslimits
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons are the values of the reason label of
+// graphql_websocket_closes_total.
+const (
+	ReasonNormal         = "normal"
+	ReasonProtocolError  = "protocol_error"
+	ReasonReadError      = "read_error"
+	ReasonIdleTimeout    = "idle_timeout"
+	ReasonMaxConnections = "max_connections"
+	ReasonIPLimit        = "ip_limit"
+	ReasonUserLimit      = "user_limit"
+	ReasonOther          = "other"
+)
+
+// ErrTooManyConnections is the connection error of the connections beyond
+// WithMaxConnectionsPerUser.
+var ErrTooManyConnections = errors.New("too many connections")
+
+// Transport is a transport.Websocket enforcing connection limits. It tracks
+// the open connections in graphql_websocket_connections and the closed or
+// refused ones in graphql_websocket_closes_total{reason}.
+type Transport struct {
+	ws  transport.Websocket
+	cfg *config
+
+	connections prometheusclient.Gauge
+	closes      *prometheusclient.CounterVec
+
+	mu      sync.Mutex
+	total   int
+	perIP   map[string]int
+	perUser map[string]int
+}
+
+var _ graphql.Transport = &Transport{}
+
+// New returns a Transport wrapping ws, whose InitFunc, ErrorFunc and
+// CloseFunc are still called. Its metrics are registered on the configured
+// registerer.
+func New(ws transport.Websocket, opts ...Option) *Transport {
+	cfg := newConfig(opts...)
+
+	t := &Transport{
+		cfg: cfg,
+		connections: prometheusclient.NewGauge(prometheusclient.GaugeOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_websocket_connections",
+			Help:        "Number of websocket connections open.",
+			ConstLabels: cfg.constLabels,
+		}),
+		closes: prometheusclient.NewCounterVec(prometheusclient.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   cfg.subsystem,
+			Name:        "graphql_websocket_closes_total",
+			Help:        "Total number of websocket connections closed or refused, by reason.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"reason"}),
+		perIP:   map[string]int{},
+		perUser: map[string]int{},
+	}
+	cfg.registerer.MustRegister(t.connections, t.closes)
+
+	if cfg.maxMessageSize != 0 {
+		ws.PayloadReadLimit = &cfg.maxMessageSize
+	}
+	if cfg.keepAlive > 0 {
+		ws.KeepAlivePingInterval = cfg.keepAlive
+		ws.PingPongInterval = cfg.keepAlive
+	}
+	ws.InitFunc = t.initFunc(ws.InitFunc)
+	ws.ErrorFunc = errorFunc(ws.ErrorFunc)
+	ws.CloseFunc = t.closeFunc(ws.CloseFunc)
+	t.ws = ws
+
+	return t
+}
+
+// UnRegister removes the metrics from the registerer they were registered
+// on.
+func (t *Transport) UnRegister() {
+	t.cfg.registerer.Unregister(t.connections)
+	t.cfg.registerer.Unregister(t.closes)
+}
+
+func (t *Transport) Supports(r *http.Request) bool {
+	return t.ws.Supports(r)
+}
+
+func (t *Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	ip := t.cfg.clientIP(r)
+	if reason := t.acquire(ip); reason != "" {
+		t.closes.WithLabelValues(reason).Inc()
+		transport.SendErrorf(w, http.StatusTooManyRequests, "too many websocket connections")
+		return
+	}
+	defer t.release(ip)
+
+	t.connections.Inc()
+	defer t.connections.Dec()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	c := &conn{t: t, cancel: cancel}
+	defer c.stop()
+	if t.cfg.idleTimeout > 0 {
+		c.timer = time.AfterFunc(t.cfg.idleTimeout, c.idle)
+	}
+
+	t.ws.Do(w, r.WithContext(context.WithValue(ctx, connKey{}, c)), executor{GraphExecutor: exec, c: c})
+}
+
+// acquire counts a connection from ip, returning the reason it is refused
+// for if any.
+func (t *Transport) acquire(ip string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cfg.maxConnections > 0 && t.total >= t.cfg.maxConnections {
+		return ReasonMaxConnections
+	}
+	if t.cfg.maxPerIP > 0 && t.perIP[ip] >= t.cfg.maxPerIP {
+		return ReasonIPLimit
+	}
+	t.total++
+	t.perIP[ip]++
+	return ""
+}
+
+func (t *Transport) release(ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total--
+	if t.perIP[ip]--; t.perIP[ip] <= 0 {
+		delete(t.perIP, ip)
+	}
+}
+
+func (t *Transport) initFunc(next transport.WebsocketInitFunc) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		var ack *transport.InitPayload
+		if next != nil {
+			var err error
+			ctx, ack, err = next(ctx, payload)
+			if err != nil {
+				return ctx, ack, err
+			}
+		}
+
+		c, ok := ctx.Value(connKey{}).(*conn)
+		if !ok || t.cfg.maxPerUser <= 0 || t.cfg.user == nil {
+			return ctx, ack, nil
+		}
+		user := t.cfg.user(ctx, payload)
+		if user == "" {
+			return ctx, ack, nil
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.perUser[user] >= t.cfg.maxPerUser {
+			c.setReason(ReasonUserLimit)
+			return transport.AppendCloseReason(ctx, ErrTooManyConnections.Error()), nil, ErrTooManyConnections
+		}
+		t.perUser[user]++
+		c.user = user
+		return ctx, ack, nil
+	}
+}
+
+func errorFunc(next transport.WebsocketErrorFunc) transport.WebsocketErrorFunc {
+	return func(ctx context.Context, err error) {
+		var wsErr transport.WebsocketError
+		if c, ok := ctx.Value(connKey{}).(*conn); ok && errors.As(err, &wsErr) && wsErr.IsReadError {
+			c.setReason(ReasonReadError)
+		}
+		if next != nil {
+			next(ctx, err)
+		}
+	}
+}
+
+func (t *Transport) closeFunc(next transport.WebsocketCloseFunc) transport.WebsocketCloseFunc {
+	return func(ctx context.Context, closeCode int) {
+		reason := codeReason(closeCode)
+		if c, ok := ctx.Value(connKey{}).(*conn); ok {
+			if r := c.getReason(); r != "" {
+				reason = r
+			}
+		}
+		t.closes.WithLabelValues(reason).Inc()
+		if next != nil {
+			next(ctx, closeCode)
+		}
+	}
+}
+
+func codeReason(code int) string {
+	switch code {
+	case transport.WebsocketCloseNormalClosure:
+		return ReasonNormal
+	case transport.WebsocketCloseProtocolError:
+		return ReasonProtocolError
+	default:
+		return ReasonOther
+	}
+}
+
+type connKey struct{}
+
+// conn is the state of an open connection.
+type conn struct {
+	t      *Transport
+	cancel context.CancelFunc
+	// user is guarded by t.mu.
+	user string
+
+	mu     sync.Mutex
+	active int
+	timer  *time.Timer
+	reason string
+}
+
+func (c *conn) setReason(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reason == "" {
+		c.reason = reason
+	}
+}
+
+func (c *conn) getReason() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reason
+}
+
+// idle closes the connection unless an operation started meanwhile.
+func (c *conn) idle() {
+	c.mu.Lock()
+	if c.active > 0 {
+		c.mu.Unlock()
+		return
+	}
+	if c.reason == "" {
+		c.reason = ReasonIdleTimeout
+	}
+	c.mu.Unlock()
+	c.cancel()
+}
+
+func (c *conn) begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active++
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+func (c *conn) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	if c.active == 0 && c.timer != nil {
+		c.timer.Reset(c.t.cfg.idleTimeout)
+	}
+}
+
+func (c *conn) stop() {
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.mu.Unlock()
+
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	if c.user == "" {
+		return
+	}
+	if c.t.perUser[c.user]--; c.t.perUser[c.user] <= 0 {
+		delete(c.t.perUser, c.user)
+	}
+}
+
+// executor counts the operations in flight on a connection, for the idle
+// timeout.
+type executor struct {
+	graphql.GraphExecutor
+	c *conn
+}
+
+func (e executor) DispatchOperation(ctx context.Context, oc *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	e.c.begin()
+	handler, ctx := e.GraphExecutor.DispatchOperation(ctx, oc)
+
+	var once sync.Once
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		if resp == nil {
+			once.Do(e.c.end)
+		}
+		return resp
+	}, ctx
+}
@@ -0,0 +1,142 @@
+package wslimits_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/wslimits"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/coder/websocket"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_MaxConnectionsPerIP(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	url := newServer(t, wslimits.New(transport.Websocket{},
+		wslimits.WithRegisterer(registry),
+		wslimits.WithMaxConnectionsPerIP(1),
+	))
+
+	first := dial(t, url, nil)
+	assert.Equal(t, 1.0, gauge(t, registry))
+
+	_, resp, err := websocket.Dial(context.Background(), url, &websocket.DialOptions{Subprotocols: []string{"graphql-transport-ws"}})
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, 1.0, closes(t, registry, wslimits.ReasonIPLimit))
+
+	first.Close(websocket.StatusNormalClosure, "")
+	assert.Eventually(t, func() bool { return gauge(t, registry) == 0 }, time.Second, 5*time.Millisecond)
+	dial(t, url, nil).Close(websocket.StatusNormalClosure, "")
+}
+
+func TestTransport_MaxConnectionsPerUser(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	url := newServer(t, wslimits.New(transport.Websocket{},
+		wslimits.WithRegisterer(registry),
+		wslimits.WithMaxConnectionsPerUser(1, func(ctx context.Context, payload transport.InitPayload) string {
+			return payload.GetString("user")
+		}),
+	))
+
+	first := dial(t, url, map[string]any{"user": "alice"})
+	defer first.Close(websocket.StatusNormalClosure, "")
+	other := dial(t, url, map[string]any{"user": "bob"})
+	defer other.Close(websocket.StatusNormalClosure, "")
+
+	conn, _, err := websocket.Dial(context.Background(), url, &websocket.DialOptions{Subprotocols: []string{"graphql-transport-ws"}})
+	require.NoError(t, err)
+	write(t, conn, map[string]any{"type": "connection_init", "payload": map[string]any{"user": "alice"}})
+	_, _, err = conn.Read(context.Background())
+	assert.Error(t, err)
+	assert.Eventually(t, func() bool { return closes(t, registry, wslimits.ReasonUserLimit) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestTransport_IdleTimeout(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	url := newServer(t, wslimits.New(transport.Websocket{},
+		wslimits.WithRegisterer(registry),
+		wslimits.WithIdleTimeout(50*time.Millisecond),
+	))
+
+	conn := dial(t, url, nil)
+	write(t, conn, map[string]any{"id": "1", "type": "subscribe", "payload": map[string]any{"query": "subscription { todoAdded { id } }"}})
+
+	var types []string
+	for {
+		_, data, err := conn.Read(context.Background())
+		if err != nil {
+			assert.Equal(t, websocket.StatusNormalClosure, websocket.CloseStatus(err))
+			break
+		}
+		var msg struct {
+			Type string `json:"type"`
+		}
+		require.NoError(t, json.Unmarshal(data, &msg))
+		types = append(types, msg.Type)
+	}
+	assert.Equal(t, []string{"next", "next", "next", "complete"}, types)
+	assert.Eventually(t, func() bool { return closes(t, registry, wslimits.ReasonIdleTimeout) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func newServer(t *testing.T, ws *wslimits.Transport) string {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	srv.AddTransport(ws)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+// dial opens a graphql-transport-ws connection and initializes it.
+func dial(t *testing.T, url string, payload map[string]any) *websocket.Conn {
+	conn, _, err := websocket.Dial(context.Background(), url, &websocket.DialOptions{Subprotocols: []string{"graphql-transport-ws"}})
+	require.NoError(t, err)
+	write(t, conn, map[string]any{"type": "connection_init", "payload": payload})
+	_, data, err := conn.Read(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, string(data), "connection_ack")
+	return conn
+}
+
+func write(t *testing.T, conn *websocket.Conn, msg map[string]any) {
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	require.NoError(t, conn.Write(context.Background(), websocket.MessageText, data))
+}
+
+func gauge(t *testing.T, registry *prometheusclient.Registry) float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "graphql_websocket_connections" {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+func closes(t *testing.T, registry *prometheusclient.Registry, reason string) float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "graphql_websocket_closes_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if m.GetLabel()[0].GetValue() == reason {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
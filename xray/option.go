@@ -0,0 +1,17 @@
+package xray
+
+type config struct {
+	allFields bool
+}
+
+// Option is anything that can configure Tracer.
+type Option func(cfg *config)
+
+// WithAllFields creates a subsegment for every field. By default only fields
+// backed by a resolver get one, keeping segment documents under the X-Ray
+// size limit.
+func WithAllFields() Option {
+	return func(cfg *config) {
+		cfg.allFields = true
+	}
+}
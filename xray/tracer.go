@@ -0,0 +1,116 @@
+package xray
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// Tracer is a gqlgen handler extension creating AWS X-Ray subsegments for
+// operations and field resolvers. Operations outside of an X-Ray segment,
+// see Handler, are not traced.
+// see https://pkg.go.dev/github.com/aws/aws-xray-sdk-go/xray
+type Tracer struct {
+	cfg config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+// New returns a Tracer configured by opts.
+func New(opts ...Option) Tracer {
+	var t Tracer
+
+	for _, opt := range opts {
+		opt(&t.cfg)
+	}
+
+	return t
+}
+
+// Handler starts an X-Ray segment named name for every request, continuing
+// the trace of its X-Amzn-Trace-Id header if any. It is not needed on AWS
+// Lambda, where the segment comes with the invocation context.
+func Handler(name string, next http.Handler) http.Handler {
+	return xray.Handler(xray.NewFixedSegmentNamer(name), next)
+}
+
+func (t Tracer) ExtensionName() string {
+	return "XRay"
+}
+
+func (t Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) || !traced(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := gqlctx.OperationNameOrNameless(oc)
+
+	ctx, seg := xray.BeginSubsegment(ctx, "graphql "+name)
+	if seg == nil {
+		return next(ctx)
+	}
+	_ = seg.AddAnnotation("graphql_operation_name", name)
+	_ = seg.AddAnnotation("graphql_operation_type", operationType(oc))
+	_ = seg.AddMetadataToNamespace("graphql", "document", oc.RawQuery)
+
+	res := next(ctx)
+
+	var err error
+	if res != nil && len(res.Errors) != 0 {
+		if codes := extcode.Codes(res.Errors); len(codes) != 0 {
+			_ = seg.AddMetadataToNamespace("graphql", "error_codes", codes)
+		}
+		err = res.Errors
+	}
+	seg.Close(err)
+
+	return res
+}
+
+func (t Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !t.cfg.allFields && !fc.IsResolver || !traced(ctx) {
+		return next(ctx)
+	}
+
+	ctx, seg := xray.BeginSubsegment(ctx, fc.Object+"."+fc.Field.Name)
+	if seg == nil {
+		return next(ctx)
+	}
+	_ = seg.AddMetadataToNamespace("graphql", "path", fc.Path().String())
+
+	res, err := next(ctx)
+
+	if code := extcode.Code(err); code != "" {
+		_ = seg.AddMetadataToNamespace("graphql", "error_code", code)
+	}
+	seg.Close(err)
+
+	return res, err
+}
+
+// traced reports whether ctx belongs to an X-Ray segment, either started by
+// Handler or given by AWS Lambda.
+func traced(ctx context.Context) bool {
+	return xray.GetSegment(ctx) != nil || ctx.Value(xray.LambdaTraceHeaderKey) != nil
+}
+
+func operationType(oc *graphql.OperationContext) string {
+	if oc.Operation == nil {
+		return ""
+	}
+	return string(oc.Operation.Operation)
+}
@@ -0,0 +1,131 @@
+package xray_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/xray"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/aws/aws-xray-sdk-go/strategy/sampling"
+	awsxray "github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type alwaysSample struct{}
+
+func (alwaysSample) ShouldTrace(*sampling.Request) *sampling.Decision {
+	return &sampling.Decision{Sample: true}
+}
+
+type segment struct {
+	Name        string                    `json:"name"`
+	TraceID     string                    `json:"trace_id"`
+	Fault       bool                      `json:"fault"`
+	Annotations map[string]any            `json:"annotations"`
+	Metadata    map[string]map[string]any `json:"metadata"`
+	Subsegments []*segment                `json:"subsegments"`
+}
+
+func (s *segment) find(name string) *segment {
+	if s.Name == name {
+		return s
+	}
+	for _, sub := range s.Subsegments {
+		if found := sub.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	daemon, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer daemon.Close()
+
+	require.NoError(t, awsxray.Configure(awsxray.Config{
+		DaemonAddr:       daemon.LocalAddr().String(),
+		SamplingStrategy: alwaysSample{},
+	}))
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(xray.New())
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Lookup { todos { id } todo(id: \"unknown\") { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Amzn-Trace-Id", "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1")
+	w := httptest.NewRecorder()
+	xray.Handler("api", srv).ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	root := receive(t, daemon, "api")
+	assert.Equal(t, "1-5759e988-bd862e3fe1be46a994272793", root.TraceID)
+
+	operation := root.find("graphql Lookup")
+	require.NotNil(t, operation)
+	assert.True(t, operation.Fault)
+	assert.Equal(t, map[string]any{
+		"graphql_operation_name": "Lookup",
+		"graphql_operation_type": "query",
+	}, operation.Annotations)
+	assert.Contains(t, operation.Metadata["graphql"]["document"], "query Lookup")
+
+	todos := operation.find("Query.todos")
+	require.NotNil(t, todos)
+	assert.False(t, todos.Fault)
+	assert.Equal(t, "todos", todos.Metadata["graphql"]["path"])
+
+	todo := operation.find("Query.todo")
+	require.NotNil(t, todo)
+	assert.True(t, todo.Fault)
+
+	assert.Nil(t, operation.find("Todo.id"))
+}
+
+func TestTracer_Untraced(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(xray.New(xray.WithAllFields()))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ todos { id } }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), graph.TodoA.ID)
+}
+
+// receive reads the documents sent to daemon until the segment named name
+// arrives.
+func receive(t *testing.T, daemon *net.UDPConn, name string) *segment {
+	t.Helper()
+
+	buf := make([]byte, 64*1024)
+	require.NoError(t, daemon.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		n, _, err := daemon.ReadFromUDP(buf)
+		require.NoError(t, err)
+
+		// Each document is preceded by a header line.
+		_, doc, _ := bytes.Cut(buf[:n], []byte("\n"))
+		var seg segment
+		require.NoError(t, json.Unmarshal(doc, &seg))
+		if seg.Name == name {
+			return &seg
+		}
+	}
+}
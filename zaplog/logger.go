@@ -0,0 +1,227 @@
+package zaplog
+
+import (
+	"context"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	contrib "github.com/99designs/gqlgen-contrib"
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/internal/extcode"
+	"github.com/99designs/gqlgen-contrib/internal/gqlctx"
+	"github.com/99designs/gqlgen-contrib/internal/incremental"
+	"github.com/99designs/gqlgen-contrib/internal/slowest"
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is a gqlgen handler extension writing one structured entry per
+// GraphQL operation through zap. Successful operations are logged at info
+// level, operations with errors at warn level. With WithSlowThreshold, only
+// operations slower than the threshold are logged, at warn level. Operations
+// using @defer are logged once their last patch is delivered, with the
+// errors of all their responses and graphql.incremental fields, or when the
+// request ends for transports only delivering the initial payload.
+// see https://pkg.go.dev/go.uber.org/zap
+type Logger struct {
+	logger *zap.Logger
+	cfg    config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Logger{}
+
+// New returns a Logger writing to logger.
+func New(logger *zap.Logger, opts ...Option) Logger {
+	l := Logger{
+		logger: logger,
+		cfg: config{
+			sampleRate:    1,
+			slowestFields: 5,
+			classifier:    contrib.Classify,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&l.cfg)
+	}
+	if len(l.cfg.redactedVariables) != 0 {
+		l.cfg.redactor = l.cfg.redactor.With(redact.Path(l.cfg.redactedVariables...))
+	}
+
+	return l
+}
+
+func (l Logger) ExtensionName() string {
+	return "ZapLogger"
+}
+
+func (l Logger) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+type (
+	collectorKey struct{}
+	streamKey    struct{}
+)
+
+func (l Logger) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+	ctx = context.WithValue(ctx, streamKey{}, incremental.New(oc.Stats.OperationStart))
+	if l.cfg.slowThreshold <= 0 {
+		return next(ctx)
+	}
+	return next(context.WithValue(ctx, collectorKey{}, slowest.NewCollector(l.cfg.slowestFields)))
+}
+
+func (l Logger) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	res := next(ctx)
+	if res == nil || !graphql.HasOperationContext(ctx) {
+		return res
+	}
+
+	stream, ok := ctx.Value(streamKey{}).(*incremental.Stream)
+	if !ok {
+		l.log(ctx, res.Errors, time.Since(graphql.GetOperationContext(ctx).Stats.OperationStart), nil)
+		return res
+	}
+	switch first, last := stream.Add(res); {
+	case first && last:
+		l.log(ctx, res.Errors, stream.Duration(), nil)
+	case first:
+		stream.AfterFunc(ctx, func() {
+			l.log(ctx, stream.Errors(), stream.Duration(), stream)
+		})
+	case last && stream.Finish():
+		l.log(ctx, stream.Errors(), stream.Duration(), stream)
+	}
+
+	return res
+}
+
+// log writes the entry of an operation, stream being nil unless it used
+// @defer.
+func (l Logger) log(ctx context.Context, errs gqlerror.List, duration time.Duration, stream *incremental.Stream) {
+	oc := graphql.GetOperationContext(ctx)
+	collector, slow := ctx.Value(collectorKey{}).(*slowest.Collector)
+
+	switch {
+	case slow:
+		if duration < l.cfg.slowThreshold {
+			return
+		}
+	case l.cfg.slowThreshold > 0:
+		// Subscriptions have no collector and are not slow-logged.
+		return
+	case len(errs) == 0 && !l.sampled():
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("graphql.operation.name", gqlctx.OperationNameOrNameless(oc)),
+		zap.String("graphql.operation.type", operationType(oc)),
+		zap.Duration("duration", duration),
+		zap.Int("graphql.errors.count", len(errs)),
+		zap.String("graphql.outcome", string(l.cfg.classifier(ctx, errs))),
+	}
+	if codes := extcode.Codes(errs); len(codes) != 0 {
+		fields = append(fields, zap.Strings("graphql.errors.codes", codes))
+	}
+	if stats := extension.GetComplexityStats(ctx); stats != nil {
+		fields = append(fields,
+			zap.Int("graphql.operation.complexity", stats.Complexity),
+			zap.Int("graphql.operation.complexity_limit", stats.ComplexityLimit),
+		)
+	}
+	client := clientinfo.ForContext(ctx)
+	if client.Name != "" {
+		fields = append(fields, zap.String("graphql.client.name", client.Name))
+	}
+	if client.Version != "" {
+		fields = append(fields, zap.String("graphql.client.version", client.Version))
+	}
+	if l.cfg.logVariables {
+		fields = append(fields, zap.Any("graphql.variables", l.cfg.redactor.Variables(oc)))
+	}
+	if l.cfg.enricher != nil {
+		labels := l.cfg.enricher(ctx)
+		for _, key := range slices.Sorted(maps.Keys(labels)) {
+			fields = append(fields, zap.String(key, labels[key]))
+		}
+	}
+
+	if stream != nil {
+		fields = append(fields,
+			zap.Int("graphql.incremental.patches", stream.Patches()),
+			zap.Int("graphql.incremental.patch_errors", stream.PatchErrors()),
+			zap.Duration("graphql.incremental.first_response", stream.FirstResponse()),
+		)
+	}
+	if slow {
+		fields = append(fields,
+			zap.Int("graphql.resolvers.count", collector.Resolvers()),
+			zap.Array("graphql.resolvers.slowest", slowFields(collector.Slowest())),
+		)
+		l.logger.Warn("slow graphql operation", fields...)
+	} else if len(errs) != 0 {
+		l.logger.Warn("graphql operation", fields...)
+	} else {
+		l.logger.Info("graphql operation", fields...)
+	}
+}
+
+func (l Logger) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	collector, ok := ctx.Value(collectorKey{}).(*slowest.Collector)
+	if !ok {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	collector.Add(graphql.GetFieldContext(ctx).Path().String(), time.Since(start))
+
+	return res, err
+}
+
+func (l Logger) sampled() bool {
+	return l.cfg.sampleRate >= 1 || rand.Float64() < l.cfg.sampleRate
+}
+
+// slowFields lists the slowest resolvers of an operation in an entry.
+type slowFields []slowest.Field
+
+func (fields slowFields) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, f := range fields {
+		err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("path", f.Path)
+			enc.AddDuration("duration", f.Duration)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func operationType(oc *graphql.OperationContext) string {
+	if oc.Operation == nil {
+		return ""
+	}
+	return string(oc.Operation.Operation)
+}
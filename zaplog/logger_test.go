@@ -0,0 +1,165 @@
+package zaplog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/redact"
+	"github.com/99designs/gqlgen-contrib/zaplog"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	newServer := func(opts ...zaplog.Option) *handler.Server {
+		srv := handler.New(graph.NewExecutableSchema(graph.Config{
+			Resolvers: &graph.Resolver{},
+		}))
+		srv.AddTransport(transport.MultipartMixed{})
+		srv.AddTransport(transport.POST{})
+		srv.Use(extension.FixedComplexityLimit(100))
+		srv.Use(zaplog.New(zap.New(core), opts...))
+		return srv
+	}
+
+	t.Run("defer", func(t *testing.T) {
+		logs.TakeAll()
+
+		r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Deferred { todos { id ... @defer { completed } } }"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "multipart/mixed")
+		w := httptest.NewRecorder()
+		newServer().ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "Deferred", fields["graphql.operation.name"])
+		assert.Equal(t, int64(3), fields["graphql.incremental.patches"])
+		assert.Equal(t, int64(0), fields["graphql.incremental.patch_errors"])
+		assert.Contains(t, fields, "graphql.incremental.first_response")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		logs.TakeAll()
+
+		resp := doRequest(newServer(), `{"query":"query ListTodos { todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "ListTodos", fields["graphql.operation.name"])
+		assert.Equal(t, "query", fields["graphql.operation.type"])
+		assert.Equal(t, int64(0), fields["graphql.errors.count"])
+		assert.Equal(t, int64(2), fields["graphql.operation.complexity"])
+		assert.Equal(t, "ios", fields["graphql.client.name"])
+		assert.Equal(t, "1.2.3", fields["graphql.client.version"])
+		assert.Contains(t, fields, "duration")
+		assert.NotContains(t, fields, "graphql.variables")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		logs.TakeAll()
+
+		resp := doRequest(newServer(), `{"query":"{ unknown }"}`)
+		require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+
+		fields := entries[0].ContextMap()
+		assert.Equal(t, int64(1), fields["graphql.errors.count"])
+		assert.Equal(t, []interface{}{"GRAPHQL_VALIDATION_FAILED"}, fields["graphql.errors.codes"])
+		assert.Equal(t, "user_error", fields["graphql.outcome"])
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		logs.TakeAll()
+
+		srv := newServer(zaplog.WithSampleRate(0))
+		resp := doRequest(srv, `{"query":"{ todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, logs.TakeAll())
+
+		resp = doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Len(t, logs.TakeAll(), 1)
+	})
+
+	t.Run("slow threshold", func(t *testing.T) {
+		logs.TakeAll()
+
+		srv := newServer(zaplog.WithSlowThreshold(20*time.Millisecond), zaplog.WithSlowestFields(1))
+		srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+			if graphql.GetFieldContext(ctx).Field.Name == "todos" {
+				time.Sleep(30 * time.Millisecond)
+			}
+			return next(ctx)
+		})
+
+		resp := doRequest(srv, `{"query":"{ todo(id: \"unknown\") { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, logs.TakeAll())
+
+		resp = doRequest(srv, `{"query":"{ todos { id } }"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+		assert.Equal(t, "slow graphql operation", entries[0].Message)
+
+		fields := entries[0].ContextMap()
+		assert.Equal(t, int64(4), fields["graphql.resolvers.count"])
+		slowest := fields["graphql.resolvers.slowest"].([]interface{})
+		require.Len(t, slowest, 1)
+		assert.Equal(t, "todos", slowest[0].(map[string]interface{})["path"])
+		assert.GreaterOrEqual(t, slowest[0].(map[string]interface{})["duration"], 30*time.Millisecond)
+	})
+
+	t.Run("variables", func(t *testing.T) {
+		logs.TakeAll()
+
+		resp := doRequest(
+			newServer(zaplog.WithVariables("userId"), zaplog.WithRedactor(redact.New(nil, redact.Path("te*")))),
+			`{"query":"mutation Create($text: String!, $userId: String!) { createTodo(input: {text: $text, userId: $userId}) { id } }","variables":{"text":"hello","userId":"secret"}}`,
+		)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, map[string]interface{}{
+			"text":   "[REDACTED]",
+			"userId": "[REDACTED]",
+		}, entries[0].ContextMap()["graphql.variables"])
+	})
+}
+
+func doRequest(handler http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("apollographql-client-name", "ios")
+	r.Header.Set("apollographql-client-version", "1.2.3")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}